@@ -0,0 +1,154 @@
+// Package newsbuilder — conversion of Atom feeds back into entries HTML.
+package newsbuilder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+)
+
+// atomFeed and atomEntry hold the parts of an Atom document that have a
+// counterpart in the entries HTML format.  Element names match any
+// namespace, so both newsgo feeds and other Atom feeds decode.
+type atomFeed struct {
+	Title   string      `xml:"title"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string `xml:"id"`
+	Title     string `xml:"title"`
+	Updated   string `xml:"updated"`
+	Published string `xml:"published"`
+	Summary   string `xml:"summary"`
+	Rights    string `xml:"rights"`
+	Author    struct {
+		Name  string `xml:"name"`
+		Email string `xml:"email"`
+		URI   string `xml:"uri"`
+	} `xml:"author"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+	} `xml:"link"`
+	Content struct {
+		Type  string `xml:"type,attr"`
+		Inner string `xml:",innerxml"`
+		Text  string `xml:",chardata"`
+	} `xml:"content"`
+}
+
+// link returns the alternate link of e: the first link with rel="alternate"
+// or without rel.
+func (e *atomEntry) link() string {
+	for _, l := range e.Links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// licenseLink returns the href of the rel="license" link of e, if any.
+func (e *atomEntry) licenseLink() string {
+	for _, l := range e.Links {
+		if l.Rel == "license" {
+			return l.Href
+		}
+	}
+	return ""
+}
+
+// body returns the content of e as HTML.  XHTML content is unwrapped from its
+// <div xmlns="http://www.w3.org/1999/xhtml"> container, HTML content is used
+// as-is, and text content is escaped.
+func (e *atomEntry) body() string {
+	switch e.Content.Type {
+	case "xhtml":
+		inner := strings.TrimSpace(e.Content.Inner)
+		if strings.HasPrefix(inner, "<div") && strings.HasSuffix(inner, "</div>") {
+			if i := strings.Index(inner, ">"); i >= 0 {
+				inner = inner[i+1 : len(inner)-len("</div>")]
+			}
+		}
+		return strings.TrimSpace(inner)
+	case "html":
+		return strings.TrimSpace(e.Content.Text)
+	default:
+		if text := strings.TrimSpace(e.Content.Text); text != "" {
+			return "<p>" + html.EscapeString(text) + "</p>"
+		}
+		return ""
+	}
+}
+
+// EntriesFromAtom converts an Atom feed back into the entries HTML format
+// read by Build: the feed title becomes the <header> and each <entry> an
+// <article> carrying its id, title, link, author, dates, and license as
+// attributes, its summary in <details><summary>, and its content as the
+// article body.  It returns the document and the number of articles in it.
+// Releases, blocklists, feed-level rights, and other feed-level extensions
+// are not entries and are dropped.
+func EntriesFromAtom(atom []byte) ([]byte, int, error) {
+	var feed atomFeed
+	if err := xml.Unmarshal(atom, &feed); err != nil {
+		return nil, 0, fmt.Errorf("EntriesFromAtom: %w", err)
+	}
+	var b bytes.Buffer
+	b.WriteString("<html><body>\n")
+	if title := strings.TrimSpace(feed.Title); title != "" {
+		fmt.Fprintf(&b, "<header>%s</header>\n", html.EscapeString(title))
+	}
+	for i := range feed.Entries {
+		e := &feed.Entries[i]
+		b.WriteString("<article")
+		for _, attr := range [][2]string{
+			{"id", e.ID},
+			{"title", e.Title},
+			{"href", e.link()},
+			{"author", e.Author.Name},
+			{"author-email", e.Author.Email},
+			{"author-uri", e.Author.URI},
+			{"published", e.Published},
+			{"updated", e.Updated},
+			{"license", e.Rights},
+			{"license-uri", e.licenseLink()},
+		} {
+			if val := strings.TrimSpace(attr[1]); val != "" || attr[0] == "id" {
+				fmt.Fprintf(&b, " %s=\"%s\"", attr[0], html.EscapeString(val))
+			}
+		}
+		b.WriteString(">\n")
+		if summary := strings.TrimSpace(e.Summary); summary != "" {
+			fmt.Fprintf(&b, "<details><summary>%s</summary></details>\n", html.EscapeString(summary))
+		}
+		if body := e.body(); body != "" {
+			b.WriteString(body)
+			b.WriteString("\n")
+		}
+		b.WriteString("</article>\n")
+	}
+	b.WriteString("</body></html>\n")
+	return b.Bytes(), len(feed.Entries), nil
+}
+
+// ExportEntries reads the Atom feed at atomPath and writes it to entriesPath
+// in the entries HTML format; see EntriesFromAtom.  It returns the number of
+// articles written.
+func ExportEntries(atomPath, entriesPath string) (int, error) {
+	data, err := os.ReadFile(atomPath)
+	if err != nil {
+		return 0, fmt.Errorf("ExportEntries: %w", err)
+	}
+	doc, n, err := EntriesFromAtom(data)
+	if err != nil {
+		return 0, fmt.Errorf("ExportEntries: %s: %w", atomPath, err)
+	}
+	if err := os.WriteFile(entriesPath, doc, 0o644); err != nil {
+		return 0, fmt.Errorf("ExportEntries: %w", err)
+	}
+	return n, nil
+}
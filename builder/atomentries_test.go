@@ -0,0 +1,99 @@
+package newsbuilder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestEntriesFromAtom_RoundTrip verifies that a built feed converted back to
+// entries HTML builds into a feed with the same entries.
+func TestEntriesFromAtom_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	nb := writeFixtures(t, dir)
+	nb.Feed.EntriesHTMLPath = filepath.Join(dir, "entries.html")
+	html := `<html><body>
+<header>Test Feed</header>
+<article id="urn:test:1" title="Fish &amp; Chips" href="http://example.com/?a=1&amp;b=2"
+         author="Author" author-email="a@example.i2p" published="2024-01-01" updated="2024-01-02"
+         license="CC BY 4.0" license-uri="https://creativecommons.org/licenses/by/4.0/">
+<details><summary>Summary &lt;1&gt;</summary></details>
+<p>Body with <a href="http://example.com/">a link</a><br/>and a break</p>
+</article>
+<article id="urn:test:2" title="Second" href="http://example.com/2"
+         author="Author" published="2024-02-01" updated="2024-02-02">
+<p>No summary</p>
+</article>
+</body></html>`
+	if err := os.WriteFile(nb.Feed.EntriesHTMLPath, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+
+	doc, n, err := EntriesFromAtom([]byte(feed))
+	if err != nil {
+		t.Fatalf("EntriesFromAtom: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("EntriesFromAtom returned %d articles, want 2", n)
+	}
+	for _, want := range []string{
+		`<header>I2P News</header>`,
+		`id="urn:test:1"`,
+		`title="Fish &amp; Chips"`,
+		`href="http://example.com/?a=1&amp;b=2"`,
+		`author-email="a@example.i2p"`,
+		`license="CC BY 4.0"`,
+		`license-uri="https://creativecommons.org/licenses/by/4.0/"`,
+		`published="2024-01-01T00:00:00Z"`,
+		`<details><summary>Summary &lt;1&gt;</summary></details>`,
+		`<a href="http://example.com/">`,
+	} {
+		if !strings.Contains(string(doc), want) {
+			t.Errorf("exported entries missing %s:\n%s", want, doc)
+		}
+	}
+
+	rebuilt := writeFixtures(t, t.TempDir())
+	if err := os.WriteFile(rebuilt.Feed.EntriesHTMLPath, doc, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	again, err := rebuilt.Build()
+	if err != nil {
+		t.Fatalf("Build() of exported entries failed: %v", err)
+	}
+	for _, want := range []string{"urn:test:1", "urn:test:2", "Fish &amp; Chips", "Summary &lt;1&gt;", "No summary", "a@example.i2p"} {
+		if !strings.Contains(again, want) {
+			t.Errorf("rebuilt feed missing %q", want)
+		}
+	}
+}
+
+// TestEntriesFromAtom_ContentTypes covers html and text content and rejects
+// documents that are not XML.
+func TestEntriesFromAtom_ContentTypes(t *testing.T) {
+	atom := `<feed xmlns="http://www.w3.org/2005/Atom"><title>T</title>
+<entry><id>h</id><title>H</title><link rel="self" href="http://self/"/><link href="http://alt/"/>
+<content type="html">&lt;p&gt;html &amp;amp; more&lt;/p&gt;</content></entry>
+<entry><id>t</id><title>T</title><content>a &lt; b</content></entry>
+</feed>`
+	doc, n, err := EntriesFromAtom([]byte(atom))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("got %d articles, want 2", n)
+	}
+	for _, want := range []string{`href="http://alt/"`, "<p>html &amp; more</p>", "<p>a &lt; b</p>"} {
+		if !strings.Contains(string(doc), want) {
+			t.Errorf("exported entries missing %s:\n%s", want, doc)
+		}
+	}
+	if _, _, err := EntriesFromAtom([]byte("not xml <")); err == nil {
+		t.Error("EntriesFromAtom accepted a document that is not XML")
+	}
+}
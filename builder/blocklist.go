@@ -0,0 +1,189 @@
+// Package newsbuilder — blocklist signature and metadata helpers.
+package newsbuilder
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// BlocklistSigSuffix is appended to the blocklist path to locate its detached
+// signature, e.g. "data/blocklist.xml.sig".
+const BlocklistSigSuffix = ".sig"
+
+// blocklistSigAlgorithms returns the x509 signature algorithms tried for the
+// key type of cert when verifying a detached blocklist signature.  The RSA and
+// ECDSA lists cover `openssl dgst -sha256|-sha384|-sha512 -sign`.
+func blocklistSigAlgorithms(cert *x509.Certificate) []x509.SignatureAlgorithm {
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return []x509.SignatureAlgorithm{x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA}
+	case *ecdsa.PublicKey:
+		return []x509.SignatureAlgorithm{x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512}
+	case ed25519.PublicKey:
+		return []x509.SignatureAlgorithm{x509.PureEd25519}
+	default:
+		return nil
+	}
+}
+
+// readBlocklistSignature reads the detached signature at path.  Both the raw
+// binary signature written by `openssl dgst -sign` and its base64 encoding
+// are accepted.
+func readBlocklistSignature(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("verifyBlocklist: reading signature: %w", err)
+	}
+	if dec, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(raw))); err == nil && len(dec) > 0 {
+		return dec, nil
+	}
+	return raw, nil
+}
+
+// verifyBlocklist checks the detached signature at sigPath over content
+// against certs.  It succeeds when any certificate verifies the signature
+// under any algorithm matching its key type.
+func verifyBlocklist(content []byte, sigPath string, certs []*x509.Certificate) error {
+	sig, err := readBlocklistSignature(sigPath)
+	if err != nil {
+		return err
+	}
+	for _, cert := range certs {
+		for _, alg := range blocklistSigAlgorithms(cert) {
+			if cert.CheckSignature(alg, content, sig) == nil {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("verifyBlocklist: signature %s does not verify against any of %d trusted certificates", sigPath, len(certs))
+}
+
+// annotateBlocklist adds updated and expires attributes, formatted as RFC
+// 3339 timestamps, to every top-level blocklist element in content (matched
+// by local name, so <i2p:blocklist> and <blocklist> both qualify).  An
+// element that already carries either attribute is left untouched so that
+// hand-written metadata always wins.  content must already have passed
+// validateBlocklistXML.
+func annotateBlocklist(content []byte, updated, expires time.Time) ([]byte, error) {
+	const open = `<_root xmlns:i2p="http://geti2p.net/en/docs/spec/updates">`
+	wrapped := append([]byte(open), content...)
+	wrapped = append(wrapped, []byte(`</_root>`)...)
+	dec := xml.NewDecoder(bytes.NewReader(wrapped))
+	attrs := fmt.Sprintf(` updated="%s" expires="%s"`,
+		updated.UTC().Format(time.RFC3339), expires.UTC().Format(time.RFC3339))
+
+	// Offsets in content just before each qualifying start tag's closing
+	// ">" or "/>".
+	var inserts []int
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("annotateBlocklist: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth != 2 || t.Name.Local != "blocklist" || hasAttr(t, "updated") || hasAttr(t, "expires") {
+				continue
+			}
+			end := int(dec.InputOffset()) - len(open) - 1 // index of '>'
+			if end > 0 && content[end-1] == '/' {
+				end--
+			}
+			inserts = append(inserts, end)
+		case xml.EndElement:
+			depth--
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(inserts)))
+	out := append([]byte(nil), content...)
+	for _, at := range inserts {
+		out = append(out[:at], append([]byte(attrs), out[at:]...)...)
+	}
+	return out, nil
+}
+
+// hasAttr reports whether se carries an attribute with the given local name.
+func hasAttr(se xml.StartElement, local string) bool {
+	for _, a := range se.Attr {
+		if a.Name.Local == local {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultBlocklistMaxSize is the BlocklistMaxSize build uses unless told
+// otherwise.  Real blocklists are a few kilobytes; one this large is far more
+// likely a mistake than a list routers should download with every feed.
+const DefaultBlocklistMaxSize = 256 << 10
+
+// blocklistTooLarge reports whether content exceeds nb.BlocklistMaxSize.
+func (nb *NewsBuilder) blocklistTooLarge(content []byte) bool {
+	return nb.BlocklistMaxSize > 0 && int64(len(content)) > nb.BlocklistMaxSize
+}
+
+// blocklistReference returns the <i2p:blocklist href> element that stands in
+// for content, a blocklist over nb.BlocklistMaxSize, or an error naming the
+// limit when there is no nb.BlocklistURL to point at.  The referenced
+// blocklist must still carry a valid signature when BlocklistCerts is set.
+func (nb *NewsBuilder) blocklistReference(content []byte) (string, error) {
+	if nb.BlocklistURL == "" {
+		return "", fmt.Errorf("Build: blocklist %s is %d bytes, over the limit of %d; shrink it, raise the limit, or publish it and reference it by URL instead", nb.BlocklistXML, len(content), nb.BlocklistMaxSize)
+	}
+	if len(nb.BlocklistCerts) > 0 {
+		if err := verifyBlocklist(content, nb.BlocklistXML+BlocklistSigSuffix, nb.BlocklistCerts); err != nil {
+			return "", fmt.Errorf("Build: %w", err)
+		}
+	}
+	newslogger.Printf("Build: blocklist %s is %d bytes, over the limit of %d; referencing %s instead of inlining it", nb.BlocklistXML, len(content), nb.BlocklistMaxSize, nb.BlocklistURL)
+	return "<i2p:blocklist href=\"" + xmlEsc(nb.BlocklistURL) + "\"/>", nil
+}
+
+// prepareBlocklist applies the optional signature check and metadata to the
+// blocklist content read from nb.BlocklistXML.  An empty blocklist is
+// returned unchanged: there is nothing to verify or annotate.
+func (nb *NewsBuilder) prepareBlocklist(content []byte) ([]byte, error) {
+	if len(content) == 0 {
+		return content, nil
+	}
+	if len(nb.BlocklistCerts) > 0 {
+		if err := verifyBlocklist(content, nb.BlocklistXML+BlocklistSigSuffix, nb.BlocklistCerts); err != nil {
+			return nil, fmt.Errorf("Build: %w", err)
+		}
+	}
+	if nb.BlocklistTTL <= 0 {
+		return content, nil
+	}
+	fi, err := os.Stat(nb.BlocklistXML)
+	if err != nil {
+		return nil, fmt.Errorf("Build: stat blocklist: %w", err)
+	}
+	updated := fi.ModTime()
+	expires := updated.Add(nb.BlocklistTTL)
+	if time.Now().After(expires) {
+		newslogger.Printf("Build: blocklist %s expired at %s; shipping it with a past expires date", nb.BlocklistXML, expires.UTC().Format(time.RFC3339))
+	}
+	out, err := annotateBlocklist(content, updated, expires)
+	if err != nil {
+		return nil, fmt.Errorf("Build: %w", err)
+	}
+	return out, nil
+}
@@ -0,0 +1,190 @@
+package newsbuilder
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBlocklist = `<i2p:blocklist signer="ops@example.i2p"><i2p:block host="203.0.113.7"/></i2p:blocklist>`
+
+// blocklistSigner returns an RSA key and a self-signed certificate for it.
+func blocklistSigner(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key, cert
+}
+
+// signBlocklist writes a detached SHA-256 RSA signature over content, as
+// `openssl dgst -sha256 -sign` would, optionally base64-encoded.
+func signBlocklist(t *testing.T, key *rsa.PrivateKey, content []byte, path string, b64 bool) {
+	t.Helper()
+	digest := sha256.Sum256(content)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b64 {
+		sig = []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+	}
+	if err := os.WriteFile(path, sig, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestBuild_BlocklistSignatureVerified verifies that a correctly signed
+// blocklist is included, in both raw and base64 signature encodings.
+func TestBuild_BlocklistSignatureVerified(t *testing.T) {
+	for _, b64 := range []bool{false, true} {
+		nb := writeFixtures(t, t.TempDir())
+		key, cert := blocklistSigner(t)
+		if err := os.WriteFile(nb.BlocklistXML, []byte(testBlocklist), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		signBlocklist(t, key, []byte(testBlocklist), nb.BlocklistXML+BlocklistSigSuffix, b64)
+		nb.BlocklistCerts = []*x509.Certificate{cert}
+		feed, err := nb.Build()
+		if err != nil {
+			t.Fatalf("base64=%v: Build() failed: %v", b64, err)
+		}
+		if !strings.Contains(feed, "203.0.113.7") {
+			t.Errorf("base64=%v: blocklist missing from feed", b64)
+		}
+	}
+}
+
+// TestBuild_BlocklistTamperedRejected verifies that a blocklist edited after
+// signing, a missing signature, and a signature by an untrusted key all fail
+// the build.
+func TestBuild_BlocklistTamperedRejected(t *testing.T) {
+	key, cert := blocklistSigner(t)
+	_, otherCert := blocklistSigner(t)
+
+	tests := []struct {
+		name  string
+		setup func(nb *NewsBuilder)
+	}{
+		{"tampered", func(nb *NewsBuilder) {
+			signBlocklist(t, key, []byte(testBlocklist), nb.BlocklistXML+BlocklistSigSuffix, false)
+			tampered := strings.Replace(testBlocklist, "203.0.113.7", "198.51.100.1", 1)
+			if err := os.WriteFile(nb.BlocklistXML, []byte(tampered), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			nb.BlocklistCerts = []*x509.Certificate{cert}
+		}},
+		{"missing signature", func(nb *NewsBuilder) {
+			nb.BlocklistCerts = []*x509.Certificate{cert}
+		}},
+		{"untrusted signer", func(nb *NewsBuilder) {
+			signBlocklist(t, key, []byte(testBlocklist), nb.BlocklistXML+BlocklistSigSuffix, false)
+			nb.BlocklistCerts = []*x509.Certificate{otherCert}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nb := writeFixtures(t, t.TempDir())
+			if err := os.WriteFile(nb.BlocklistXML, []byte(testBlocklist), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			tt.setup(nb)
+			if _, err := nb.Build(); err == nil {
+				t.Fatal("expected Build() to fail, got nil")
+			}
+		})
+	}
+}
+
+// TestAnnotateBlocklist verifies that updated/expires are added to top-level
+// blocklist elements only, including self-closing ones, and that existing
+// metadata is preserved.
+func TestAnnotateBlocklist(t *testing.T) {
+	updated := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expires := updated.Add(48 * time.Hour)
+	const stamp = ` updated="2024-01-02T03:04:05Z" expires="2024-01-04T03:04:05Z"`
+
+	tests := []struct {
+		in, want string
+	}{
+		{testBlocklist, `<i2p:blocklist signer="ops@example.i2p"` + stamp + `><i2p:block host="203.0.113.7"/></i2p:blocklist>`},
+		{`<i2p:blocklist/>`, `<i2p:blocklist` + stamp + `/>`},
+		{`<i2p:blocklist expires="2030-01-01T00:00:00Z"/>`, `<i2p:blocklist expires="2030-01-01T00:00:00Z"/>`},
+		{`<other><blocklist/></other>`, `<other><blocklist/></other>`},
+	}
+	for _, tt := range tests {
+		got, err := annotateBlocklist([]byte(tt.in), updated, expires)
+		if err != nil {
+			t.Fatalf("annotateBlocklist(%q): %v", tt.in, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("annotateBlocklist(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestBuild_BlocklistTTL verifies that BlocklistTTL stamps the blocklist with
+// its modification time and the derived expiry.
+func TestBuild_BlocklistTTL(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	if err := os.WriteFile(nb.BlocklistXML, []byte(testBlocklist), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(nb.BlocklistXML, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	nb.BlocklistTTL = 7 * 24 * time.Hour
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if !strings.Contains(feed, `updated="2024-05-01T00:00:00Z"`) || !strings.Contains(feed, `expires="2024-05-08T00:00:00Z"`) {
+		t.Errorf("blocklist metadata missing from feed:\n%s", feed)
+	}
+}
+
+// TestBuild_BlocklistMaxSize verifies that a blocklist over BlocklistMaxSize
+// fails the build, or is referenced by BlocklistURL instead of inlined.
+func TestBuild_BlocklistMaxSize(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	if err := os.WriteFile(nb.BlocklistXML, []byte(testBlocklist), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	nb.BlocklistMaxSize = int64(len(testBlocklist))
+	if feed, err := nb.Build(); err != nil || !strings.Contains(feed, `<i2p:block host="203.0.113.7"`) {
+		t.Fatalf("blocklist at the limit not inlined: %v\n%s", err, feed)
+	}
+
+	nb.BlocklistMaxSize--
+	if _, err := nb.Build(); err == nil || !strings.Contains(err.Error(), "over the limit") {
+		t.Fatalf("Build() with an oversized blocklist: err = %v, want over the limit", err)
+	}
+
+	nb.BlocklistURL = "http://news.example.i2p/blocklist.xml?v=1&x=2"
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() with BlocklistURL failed: %v", err)
+	}
+	if strings.Contains(feed, "203.0.113.7") || !strings.Contains(feed, `<i2p:blocklist href="http://news.example.i2p/blocklist.xml?v=1&amp;x=2"`) {
+		t.Errorf("oversized blocklist not replaced by a reference:\n%s", feed)
+	}
+}
@@ -0,0 +1,71 @@
+// Package newsbuilder — entry and feed size budgets.
+package newsbuilder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Default size budgets.  Routers fetch the whole feed on every news check, so
+// a single pasted changelog inflates every fetch of every router; an entry
+// over DefaultMaxEntrySize is almost always a mistake.
+const (
+	DefaultMaxEntrySize = 64 << 10
+	DefaultMaxFeedSize  = 1 << 20
+)
+
+// ErrOverBudget is wrapped by the error Build returns when a strict
+// SizeBudget is exceeded.  Callers may detect it with errors.Is.
+var ErrOverBudget = errors.New("size budget exceeded")
+
+// SizeBudget limits the size in bytes of each rendered <entry> element and of
+// the whole formatted feed.  A zero limit is not enforced.  Exceeding a
+// budget logs a warning, or fails the build when Strict is set.
+type SizeBudget struct {
+	MaxEntry int
+	MaxFeed  int
+	Strict   bool
+}
+
+// budgetViolation records one exceeded size budget.  Entry is the id of the
+// oversized article, or empty when the whole feed is over budget.
+type budgetViolation struct {
+	Entry string
+	Size  int
+	Limit int
+}
+
+// String describes v for logs and errors.
+func (v budgetViolation) String() string {
+	if v.Entry == "" {
+		return fmt.Sprintf("feed is %d bytes, over the %d-byte feed budget", v.Size, v.Limit)
+	}
+	return fmt.Sprintf("entry %q is %d bytes, over the %d-byte entry budget", v.Entry, v.Size, v.Limit)
+}
+
+// checkEntry returns the violation of the entry budget by the entry of the
+// article with id uid, if any.
+func (b SizeBudget) checkEntry(uid, entry string) (budgetViolation, bool) {
+	if b.MaxEntry <= 0 || len(entry) <= b.MaxEntry {
+		return budgetViolation{}, false
+	}
+	return budgetViolation{Entry: uid, Size: len(entry), Limit: b.MaxEntry}, true
+}
+
+// checkFeed returns the violation of the feed budget by feed, if any.
+func (b SizeBudget) checkFeed(feed string) (budgetViolation, bool) {
+	if b.MaxFeed <= 0 || len(feed) <= b.MaxFeed {
+		return budgetViolation{}, false
+	}
+	return budgetViolation{Size: len(feed), Limit: b.MaxFeed}, true
+}
+
+// overBudgetError returns the error of a strict build with violations.
+func overBudgetError(violations []budgetViolation) error {
+	msgs := make([]string, len(violations))
+	for i, v := range violations {
+		msgs[i] = v.String()
+	}
+	return fmt.Errorf("Build: %w: %s", ErrOverBudget, strings.Join(msgs, "; "))
+}
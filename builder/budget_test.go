@@ -0,0 +1,46 @@
+package newsbuilder
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestBuild_SizeBudget verifies that an oversized entry and feed are counted
+// in the metadata when warning, and fail the build in strict mode.
+func TestBuild_SizeBudget(t *testing.T) {
+	build := func(b SizeBudget) (FeedMetadata, error) {
+		nb := writeFixtures(t, t.TempDir())
+		nb.Budget = b
+		feed, err := nb.Build()
+		return nb.Metadata(feed), err
+	}
+
+	for _, tt := range []struct {
+		budget SizeBudget
+		want   int
+	}{
+		{SizeBudget{}, 0},
+		{SizeBudget{MaxEntry: 1 << 20, MaxFeed: 1 << 20, Strict: true}, 0},
+		{SizeBudget{MaxEntry: 16}, 1},
+		{SizeBudget{MaxEntry: 16, MaxFeed: 64}, 2},
+	} {
+		m, err := build(tt.budget)
+		if err != nil {
+			t.Fatalf("Build() with %+v failed: %v", tt.budget, err)
+		}
+		if m.OverBudget != tt.want {
+			t.Errorf("Build() with %+v: OverBudget = %d, want %d", tt.budget, m.OverBudget, tt.want)
+		}
+	}
+
+	_, err := build(SizeBudget{MaxEntry: 16, MaxFeed: 64, Strict: true})
+	if !errors.Is(err, ErrOverBudget) {
+		t.Fatalf("strict Build() error = %v, want ErrOverBudget", err)
+	}
+	for _, want := range []string{`entry "urn:test:1"`, "16-byte entry budget", "64-byte feed budget"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
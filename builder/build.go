@@ -4,15 +4,21 @@ package newsbuilder
 
 import (
 	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	newslogger "github.com/go-i2p/newsgo/logger"
 	"github.com/yosssi/gohtml"
 )
 
@@ -30,8 +36,101 @@ type NewsBuilder struct {
 	MAINFEED     string
 	BACKUPFEED   string
 	SUBTITLE     string
+	// IncludeDrafts emits articles marked draft="true" in the built feed.
+	// When false (the default) draft articles are logged and skipped.
+	IncludeDrafts bool
+	// TimestampSource selects the value of the feed-level <updated> element:
+	// TimestampBuildTime (the default, also used when empty) or
+	// TimestampNewestEntry.
+	TimestampSource string
+	// BlocklistCerts, when non-empty, requires a detached signature at
+	// BlocklistXML+BlocklistSigSuffix that verifies against one of them
+	// before the blocklist is included.
+	BlocklistCerts []*x509.Certificate
+	// BlocklistTTL, when positive, stamps each top-level blocklist element
+	// with updated (the file modification time) and expires (updated plus
+	// BlocklistTTL) attributes.
+	BlocklistTTL time.Duration
+	// BlocklistMaxSize, when positive, is the largest blocklist in bytes
+	// that is inlined in the feed; see DefaultBlocklistMaxSize.  A larger one
+	// is replaced by a reference to BlocklistURL, or fails the build when
+	// BlocklistURL is empty.
+	BlocklistMaxSize int64
+	// BlocklistURL is the URL the blocklist is published at, advertised with
+	// an <i2p:blocklist href> element instead of a blocklist larger than
+	// BlocklistMaxSize.
+	BlocklistURL string
+	// Budget limits the size of each entry and of the feed; see SizeBudget.
+	// The zero value enforces no limit.
+	Budget SizeBudget
+	// Hub, when set, is the URL of a WebSub hub advertised with a
+	// rel="hub" link, so that subscribers of MAINFEED can receive pushed
+	// updates instead of polling; see PublishToHub.
+	Hub string
+	// FeedID, when set, is the complete Atom <id> of the feed, e.g. from
+	// IDStrategy.FeedID; otherwise the id is urn:uuid:URNID.  No entry may
+	// use it as its own id.
+	FeedID string
+	// Channel, when set, is the pre-release status the feed is built for,
+	// e.g. "beta".  The header then carries an <i2p:channel> element and the
+	// title is prefixed with ChannelTitlePrefix, so that neither routers nor
+	// readers can mistake the feed for the stable one.
+	Channel string
+	// ChannelTitlePrefix is put in front of the title of a Channel feed, with
+	// "{status}" replaced by Channel, e.g. "[{status}] ".
+	ChannelTitlePrefix string
+	// Rights, when set, is the feed-level <rights> statement, e.g.
+	// "Copyright The I2P Project, CC BY-SA 4.0", and LicenseURI the URL of
+	// the license, advertised with a rel="license" link (RFC 4946).  They
+	// cover every entry without a license attribute of its own.
+	Rights     string
+	LicenseURI string
+	// Icon and Logo, when set, are the URLs of the feed's <icon>, a small
+	// square image such as a favicon, and its <logo>, a larger image twice
+	// as wide as high, which feed readers show next to the feed.
+	Icon string
+	Logo string
+	// EntriesPageURL, when set, is the URL of the HTML entries page of the
+	// feed (see EntriesPage).  Every entry then links to its anchor on it
+	// with a rel="alternate" type="text/html" link, so readers can be sent
+	// to the mirror's copy of the article.
+	EntriesPageURL string
+
+	// built holds the metadata of the last successful Build; see Metadata.
+	built FeedMetadata
+	// builtArticles holds the articles of the last successful Build; see
+	// EntriesPage.
+	builtArticles []*newsfeed.Article
+	// timings holds the stage durations of the last successful Build; see
+	// Timings.
+	timings BuildTimings
+}
+
+// BuildTimings is how long each stage of a Build took: loading and parsing
+// the entries files, rendering the header, blocklist, release and entries,
+// and formatting the finished document.
+type BuildTimings struct {
+	Parse  time.Duration
+	Render time.Duration
+	Format time.Duration
 }
 
+// Timings returns the stage durations of the last successful Build.
+func (nb *NewsBuilder) Timings() BuildTimings {
+	return nb.timings
+}
+
+// Recognised values for NewsBuilder.TimestampSource.
+const (
+	// TimestampBuildTime stamps <updated> with the wall-clock time of the
+	// build, so every rebuild looks like a feed change to routers.
+	TimestampBuildTime = "build"
+	// TimestampNewestEntry stamps <updated> with the newest article date, so
+	// <updated> only moves when the entries themselves change and routers do
+	// not re-download an otherwise identical su3.
+	TimestampNewestEntry = "newest-entry"
+)
+
 // xmlEsc returns s with XML-special characters replaced by their standard
 // entity references, making the value safe for XML text content and attribute
 // values.  encoding/xml.EscapeText is the canonical implementation: it handles
@@ -142,11 +241,61 @@ func extractSU3Update(release map[string]interface{}) (magnet string, urlSlice [
 	return magnet, urlSlice, err
 }
 
+// updateHashLengths maps the hash types accepted in updates.su3.hashes to
+// the length of their hex digests.
+var updateHashLengths = map[string]int{"sha256": 64, "sha512": 128}
+
+// updateHash is one digest of the su3 update, emitted as <i2p:hash>.
+type updateHash struct {
+	Type, Digest string
+}
+
+// extractSU3Hashes returns the digests of the optional updates.su3.hashes
+// object, e.g. {"sha256": "9f86d0..."}, sorted by type.  Digests are
+// lower-cased; an unknown type or a digest that is not hex of the right
+// length is an error, so a typo cannot publish a hash no router can match.
+func extractSU3Hashes(release map[string]interface{}) ([]updateHash, error) {
+	su3, err := navigateToSU3Map(release)
+	if err != nil {
+		return nil, err
+	}
+	hashesRaw, ok := su3["hashes"]
+	if !ok || hashesRaw == nil {
+		return nil, nil
+	}
+	hashesMap, ok := hashesRaw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSONtoXML: field \"updates.su3.hashes\" is not an object")
+	}
+	types := make([]string, 0, len(hashesMap))
+	for typ := range hashesMap {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	var hashes []updateHash
+	for _, typ := range types {
+		want, known := updateHashLengths[typ]
+		if !known {
+			return nil, fmt.Errorf("JSONtoXML: updates.su3.hashes: unknown hash type %q (want sha256 or sha512)", typ)
+		}
+		digest, err := jsonStr(hashesMap, typ)
+		if err != nil {
+			return nil, err
+		}
+		digest = strings.ToLower(strings.TrimSpace(digest))
+		if _, err := hex.DecodeString(digest); err != nil || len(digest) != want {
+			return nil, fmt.Errorf("JSONtoXML: updates.su3.hashes.%s is not a %d-digit hex digest", typ, want)
+		}
+		hashes = append(hashes, updateHash{Type: typ, Digest: digest})
+	}
+	return hashes, nil
+}
+
 // buildReleaseXML assembles the <i2p:release> XML fragment from validated
-// release metadata and SU3 update fields. All string values are XML-escaped
-// before insertion. An error is returned if any URL element in urlSlice is
-// not a string.
-func buildReleaseXML(releasedate, version, minVersion, minJavaVersion, magnet string, urlSlice []interface{}) (string, error) {
+// release metadata, SU3 update fields, revoked versions, and signer IDs.
+// All string values are XML-escaped before insertion. An error is returned
+// if any URL element in urlSlice is not a string.
+func buildReleaseXML(releasedate, version, minVersion, minJavaVersion, magnet string, urlSlice []interface{}, hashes []updateHash, revoked, signers []string) (string, error) {
 	// Attribute values are quoted and XML-escaped as required by the XML specification.
 	str := "<i2p:release date=\"" + xmlEsc(releasedate) + "\" minVersion=\"" + xmlEsc(minVersion) + "\" minJavaVersion=\"" + xmlEsc(minJavaVersion) + "\">\n"
 	str += "<i2p:version>" + xmlEsc(version) + "</i2p:version>"
@@ -159,7 +308,16 @@ func buildReleaseXML(releasedate, version, minVersion, minJavaVersion, magnet st
 		}
 		str += "<i2p:url href=\"" + xmlEsc(us) + "\"/>"
 	}
+	for _, h := range hashes {
+		str += "<i2p:hash type=\"" + xmlEsc(h.Type) + "\">" + xmlEsc(h.Digest) + "</i2p:hash>"
+	}
 	str += "</i2p:update>"
+	for _, v := range revoked {
+		str += "<i2p:revoke version=\"" + xmlEsc(v) + "\"/>"
+	}
+	for _, id := range signers {
+		str += "<i2p:signer id=\"" + xmlEsc(id) + "\"/>"
+	}
 	str += "</i2p:release>"
 	return str, nil
 }
@@ -173,6 +331,8 @@ func buildReleaseXML(releasedate, version, minVersion, minJavaVersion, magnet st
 //	<i2p:release date="2022-11-21" minVersion="0.9.9" minJavaVersion="1.8">
 //	  <i2p:version>2.0.0</i2p:version>
 //	  <i2p:update type="su3">...</i2p:update>
+//	  <i2p:revoke version="1.9.0"/>
+//	  <i2p:signer id="zzz@mail.i2p"/>
 //	</i2p:release>
 func (nb *NewsBuilder) JSONtoXML() (string, error) {
 	release, err := parseReleasesJSON(nb.ReleasesJson)
@@ -187,7 +347,19 @@ func (nb *NewsBuilder) JSONtoXML() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	return buildReleaseXML(releasedate, version, minVersion, minJavaVersion, magnet, urlSlice)
+	hashes, err := extractSU3Hashes(release)
+	if err != nil {
+		return "", err
+	}
+	revoked, err := extractRevoked(release, version)
+	if err != nil {
+		return "", err
+	}
+	signers, err := extractSigners(release)
+	if err != nil {
+		return "", err
+	}
+	return buildReleaseXML(releasedate, version, minVersion, minJavaVersion, magnet, urlSlice, hashes, revoked, signers)
 }
 
 // validateBlocklistXML checks that content is a valid XML fragment suitable
@@ -232,30 +404,25 @@ func validateBlocklistXML(content []byte) error {
 
 // buildFeedHeader constructs the Atom feed XML preamble for the given
 // NewsBuilder and timestamp. It emits the XML declaration, <feed> opening tag,
-// id, title, updated timestamp, link elements, generator, and subtitle.
+// id, title, updated timestamp, link elements, generator, subtitle, icon,
+// logo, and rights.
 //
 // The xml:lang attribute is set from nb.Language; it defaults to "en" when
 // nb.Language is empty to preserve backward-compatible output for callers that
 // construct NewsBuilder directly without setting the Language field.
 //
-// Title selection follows a two-level fallback:
+// Title selection follows a two-level fallback, after which a Channel feed
+// gets ChannelTitlePrefix in front:
 //  1. nb.TITLE when non-empty (set by --feedtitle or the Builder() default).
 //  2. nb.Feed.HeaderTitle when non-empty (parsed from the <header> element of
 //     the entries HTML by LoadHTML()). This allows the HTML source to drive the
 //     feed title without requiring a separate --feedtitle flag.
 func buildFeedHeader(nb *NewsBuilder, currentTime time.Time) string {
-	lang := nb.Language
-	if lang == "" {
-		lang = "en"
-	}
-	// Prefer the explicit TITLE field; fall back to the HTML header title.
-	title := nb.TITLE
-	if title == "" {
-		title = nb.Feed.HeaderTitle
-	}
+	lang := feedLanguage(nb)
+	title := feedTitle(nb)
 	str := "<?xml version='1.0' encoding='UTF-8'?>"
 	str += "<feed xmlns:i2p=\"http://geti2p.net/en/docs/spec/updates\" xmlns=\"http://www.w3.org/2005/Atom\" xml:lang=\"" + xmlEsc(lang) + "\">"
-	str += "<id>" + "urn:uuid:" + xmlEsc(nb.URNID) + "</id>"
+	str += "<id>" + xmlEsc(nb.feedID()) + "</id>"
 	str += "<title>" + xmlEsc(title) + "</title>"
 	milli := currentTime.Nanosecond() / 1_000_000
 	// No trailing newline: the \n was previously injected into the element text,
@@ -269,11 +436,59 @@ func buildFeedHeader(nb *NewsBuilder, currentTime time.Time) string {
 	if nb.BACKUPFEED != "" {
 		str += "<link href=\"" + xmlEsc(nb.BACKUPFEED) + "\" rel=\"alternate\"/>"
 	}
+	if nb.Hub != "" {
+		str += "<link href=\"" + xmlEsc(nb.Hub) + "\" rel=\"hub\"/>"
+	}
 	str += "<generator uri=\"http://idk.i2p/newsgo\" version=\"0.1.0\">newsgo</generator>"
 	str += "<subtitle>" + xmlEsc(nb.SUBTITLE) + "</subtitle>"
+	if nb.Icon != "" {
+		str += "<icon>" + xmlEsc(nb.Icon) + "</icon>"
+	}
+	if nb.Logo != "" {
+		str += "<logo>" + xmlEsc(nb.Logo) + "</logo>"
+	}
+	if nb.Rights != "" {
+		str += "<rights>" + xmlEsc(nb.Rights) + "</rights>"
+	}
+	if nb.LicenseURI != "" {
+		str += "<link href=\"" + xmlEsc(nb.LicenseURI) + "\" rel=\"license\"/>"
+	}
+	if nb.Channel != "" {
+		str += "<i2p:channel>" + xmlEsc(nb.Channel) + "</i2p:channel>"
+	}
 	return str
 }
 
+// feedTitle returns the title of the feed, selected as buildFeedHeader
+// describes.
+func feedTitle(nb *NewsBuilder) string {
+	// Prefer the explicit TITLE field; fall back to the HTML header title.
+	title := nb.TITLE
+	if title == "" {
+		title = nb.Feed.HeaderTitle
+	}
+	if nb.Channel != "" {
+		title = strings.ReplaceAll(nb.ChannelTitlePrefix, "{status}", nb.Channel) + title
+	}
+	return title
+}
+
+// feedID returns the Atom <id> of the feed: FeedID, or urn:uuid:URNID.
+func (nb *NewsBuilder) feedID() string {
+	if nb.FeedID != "" {
+		return nb.FeedID
+	}
+	return "urn:uuid:" + nb.URNID
+}
+
+// feedLanguage returns nb.Language, or "en" when it is empty.
+func feedLanguage(nb *NewsBuilder) string {
+	if nb.Language == "" {
+		return "en"
+	}
+	return nb.Language
+}
+
 // readBlocklistContent reads the blocklist XML file at path. A missing file is
 // treated as an empty blocklist and returns (nil, nil). Only unexpected I/O
 // errors such as permission failures are propagated as errors.
@@ -293,34 +508,220 @@ func readBlocklistContent(path string) ([]byte, error) {
 // An error is returned if the HTML cannot be loaded, the blocklist is invalid,
 // or the release JSON cannot be parsed.
 func (nb *NewsBuilder) Build() (string, error) {
+	return nb.build(context.Background())
+}
+
+// build implements Build.  ctx is checked after the entries are loaded and
+// again before the articles are rendered, the two stages whose cost grows
+// with the size of the entries files.
+func (nb *NewsBuilder) build(ctx context.Context) (string, error) {
+	start := time.Now()
 	if err := nb.Feed.LoadHTML(); err != nil {
-		return "", fmt.Errorf("Build: error %s", err.Error())
+		return "", fmt.Errorf("Build: %w", err)
 	}
-	// Use UTC explicitly so the hardcoded +00:00 offset is always correct.
-	// Dividing nanoseconds by 1,000,000 gives milliseconds (0-999); %03d
-	// zero-pads to the 3-digit width required by RFC 3339.
-	str := buildFeedHeader(nb, time.Now().UTC())
+	parsed := time.Now()
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("Build: %w", err)
+	}
+	now := time.Now()
+	articles, lastExpiry := nb.publishedArticles(now)
+	updated, err := nb.feedUpdated(articles, lastExpiry, now)
+	if err != nil {
+		return "", err
+	}
+	str := buildFeedHeader(nb, updated)
 	blocklistBytes, err := readBlocklistContent(nb.BlocklistXML)
 	if err != nil {
 		return "", err
 	}
-	// Validate before splicing: a blocklist with an XML declaration or broken
-	// markup would silently corrupt the output feed and every .su3 built from it.
-	if err := validateBlocklistXML(blocklistBytes); err != nil {
-		return "", fmt.Errorf("Build: %w", err)
+	if nb.blocklistTooLarge(blocklistBytes) {
+		// An oversized blocklist would be copied into this feed and every
+		// su3 built from it, so it is referenced rather than inlined.
+		ref, err := nb.blocklistReference(blocklistBytes)
+		if err != nil {
+			return "", err
+		}
+		str += ref
+	} else {
+		// Validate before splicing: a blocklist with an XML declaration or
+		// broken markup would silently corrupt the output feed and every
+		// .su3 built from it.
+		if err := validateBlocklistXML(blocklistBytes); err != nil {
+			return "", fmt.Errorf("Build: %w", err)
+		}
+		blocklistBytes, err = nb.prepareBlocklist(blocklistBytes)
+		if err != nil {
+			return "", err
+		}
+		str += string(blocklistBytes)
 	}
-	str += string(blocklistBytes)
 	jsonxml, err := nb.JSONtoXML()
 	if err != nil {
 		return "", err
 	}
 	str += jsonxml
-	for index := range nb.Feed.ArticlesSet {
-		art := nb.Feed.Article(index)
-		str += art.Entry()
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("Build: %w", err)
+	}
+	if nb.EntriesPageURL != "" {
+		if err := checkEntryAnchors(articles); err != nil {
+			return "", fmt.Errorf("Build: %w", err)
+		}
+	}
+	var over []budgetViolation
+	for _, art := range articles {
+		if err := art.Validate(); err != nil {
+			return "", fmt.Errorf("Build: %w", err)
+		}
+		if len(art.Stripped) > 0 {
+			newslogger.Printf("Build: warning: article %q: stripped characters illegal in XML 1.0: %s", art.UID, art.StrippedReport())
+		}
+		if art.UID == nb.feedID() {
+			return "", fmt.Errorf("Build: article %q has the id of the feed itself", art.UID)
+		}
+		if nb.EntriesPageURL != "" {
+			art.PageURL = nb.EntriesPageURL + "#" + newsfeed.EntryAnchor(art.UID)
+		}
+		entry := art.Entry()
+		if v, ok := nb.Budget.checkEntry(art.UID, entry); ok {
+			over = append(over, v)
+		}
+		str += entry
 	}
 	str += "</feed>"
-	return gohtml.Format(str), nil
+	rendered := time.Now()
+	feed := gohtml.Format(str)
+	formatted := time.Now()
+	if v, ok := nb.Budget.checkFeed(feed); ok {
+		over = append(over, v)
+	}
+	if len(over) > 0 && nb.Budget.Strict {
+		return "", overBudgetError(over)
+	}
+	for _, v := range over {
+		newslogger.Printf("Build: warning: %s", v)
+	}
+	// JSONtoXML has already validated the release, so the version is present.
+	release, _ := parseReleasesJSON(nb.ReleasesJson)
+	version, _ := jsonStr(release, "version")
+	newest, hasNewest := newestEntryTime(articles)
+	expiry, hasExpiry := nextExpiry(articles)
+	nb.recordMetadata(feedLanguage(nb), len(articles), newest, hasNewest, expiry, hasExpiry, version, updated, now)
+	nb.built.OverBudget = len(over)
+	nb.builtArticles = articles
+	nb.timings = BuildTimings{
+		Parse:  parsed.Sub(start),
+		Render: rendered.Sub(parsed),
+		Format: formatted.Sub(rendered),
+	}
+	return feed, nil
+}
+
+// publishedArticles walks the loaded articles and returns those that belong
+// in the built feed at now, in source order.  Draft articles are logged and
+// dropped unless IncludeDrafts is set; articles whose expires date has passed
+// are logged and dropped unconditionally.  lastExpiry is the latest expiry of
+// the dropped articles, or the zero time when none has expired.
+func (nb *NewsBuilder) publishedArticles(now time.Time) (articles []*newsfeed.Article, lastExpiry time.Time) {
+	for art := range nb.Feed.Articles() {
+		if art.Draft && !nb.IncludeDrafts {
+			newslogger.Verbosef("Build: skipping draft article %q (%s)", art.UID, art.Title)
+			continue
+		}
+		if art.Expires != "" {
+			expiry, ok := articleExpiry(art.Expires)
+			if !ok {
+				// Keep the article: a typo in the attribute must not silently
+				// pull a live announcement from every feed.
+				newslogger.Printf("Build: article %q has unparseable expires=%q; keeping it", art.UID, art.Expires)
+			} else if !now.Before(expiry) {
+				newslogger.Printf("Build: skipping expired article %q (%s, expired %s)", art.UID, art.Title, art.Expires)
+				if expiry.After(lastExpiry) {
+					lastExpiry = expiry
+				}
+				continue
+			}
+		}
+		articles = append(articles, art)
+	}
+	return articles, lastExpiry
+}
+
+// articleExpiry returns the instant at which an article with the given
+// expires attribute leaves the feed.  A bare date keeps the article for the
+// whole of that day (UTC), so expires="2024-03-01" drops it from builds made
+// on or after 2024-03-02; a full timestamp is used as-is.
+func articleExpiry(s string) (time.Time, bool) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.AddDate(0, 0, 1), true
+	}
+	return parseEntryDate(s)
+}
+
+// feedUpdated returns the value of the feed-level <updated> element according
+// to nb.TimestampSource.  buildTime is used for TimestampBuildTime and as the
+// fallback for TimestampNewestEntry when no article carries a parseable date
+// and none has expired.  For TimestampNewestEntry, lastExpiry (see
+// publishedArticles) wins over an older newest article date: dropping an
+// expired article changes the feed, so <updated> must move past the version
+// that still held it or routers would not notice the change.  The result is
+// always in UTC so the hardcoded +00:00 offset emitted by buildFeedHeader is
+// correct.
+func (nb *NewsBuilder) feedUpdated(articles []*newsfeed.Article, lastExpiry, buildTime time.Time) (time.Time, error) {
+	switch nb.TimestampSource {
+	case "", TimestampBuildTime:
+		return buildTime.UTC(), nil
+	case TimestampNewestEntry:
+		newest, ok := newestEntryTime(articles)
+		if !lastExpiry.IsZero() && (!ok || lastExpiry.After(newest)) {
+			return lastExpiry.UTC(), nil
+		}
+		if ok {
+			return newest.UTC(), nil
+		}
+		newslogger.Printf("Build: no article has a parseable updated/published date; using build time for <updated>")
+		return buildTime.UTC(), nil
+	default:
+		return time.Time{}, fmt.Errorf("Build: unknown timestamp source %q (want %q or %q)",
+			nb.TimestampSource, TimestampBuildTime, TimestampNewestEntry)
+	}
+}
+
+// parseEntryDate parses an article date attribute; see newsfeed.ParseDate.
+func parseEntryDate(s string) (time.Time, bool) {
+	return newsfeed.ParseDate(s)
+}
+
+// newestEntryTime returns the most recent date across all articles, preferring
+// each article's updated attribute and falling back to published.  ok is false
+// when no article carries a parseable date.
+func newestEntryTime(articles []*newsfeed.Article) (newest time.Time, ok bool) {
+	for _, art := range articles {
+		t, parsed := parseEntryDate(art.UpdatedDate)
+		if !parsed {
+			t, parsed = parseEntryDate(art.PublishedDate)
+		}
+		if parsed && (!ok || t.After(newest)) {
+			newest, ok = t, true
+		}
+	}
+	return newest, ok
+}
+
+// nextExpiry returns the earliest expiry of articles, the time the feed built
+// from them next loses an article; see articleExpiry.  ok is false when no
+// article has a parseable expires attribute.
+func nextExpiry(articles []*newsfeed.Article) (next time.Time, ok bool) {
+	for _, art := range articles {
+		if art.Expires == "" {
+			continue
+		}
+		t, parsed := articleExpiry(art.Expires)
+		if parsed && (!ok || t.Before(next)) {
+			next, ok = t, true
+		}
+	}
+	return next, ok
 }
 
 // Builder returns a *NewsBuilder configured with sensible defaults for the I2P
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 )
 
 // validReleasesJSON is a minimal releases.json fixture for testing.
@@ -115,6 +116,98 @@ func TestJSONtoXML_ValidInput(t *testing.T) {
 	}
 }
 
+// TestJSONtoXML_Hashes verifies that updates.su3.hashes are emitted as
+// lower-cased <i2p:hash> elements inside <i2p:update>, sorted by type, and
+// that unknown types and malformed digests are rejected.
+func TestJSONtoXML_Hashes(t *testing.T) {
+	sha256 := strings.Repeat("AB", 32)
+	sha512 := strings.Repeat("cd", 64)
+	release := func(hashes string) string {
+		return `[{"date":"2022-11-21","version":"2.0.0","minVersion":"0.9.9","minJavaVersion":"1.8",` +
+			`"updates":{"su3":{"torrent":"magnet:?xt=urn:btih:abc","url":["http://a.i2p/u.su3"],"hashes":` + hashes + `}}}]`
+	}
+	rp := filepath.Join(t.TempDir(), "releases.json")
+	if err := os.WriteFile(rp, []byte(release(`{"sha512":"`+sha512+`","sha256":"`+sha256+`"}`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&NewsBuilder{ReleasesJson: rp}).JSONtoXML()
+	if err != nil {
+		t.Fatalf("JSONtoXML: %v", err)
+	}
+	want := `<i2p:url href="http://a.i2p/u.su3"/><i2p:hash type="sha256">` + strings.ToLower(sha256) +
+		`</i2p:hash><i2p:hash type="sha512">` + sha512 + `</i2p:hash></i2p:update>`
+	if !strings.Contains(got, want) {
+		t.Errorf("JSONtoXML = %s\nwant it to contain %s", got, want)
+	}
+	for _, bad := range []string{`{"md5":"` + sha256[:32] + `"}`, `{"sha256":"abc"}`, `{"sha256":"` + strings.Repeat("zz", 32) + `"}`, `["` + sha256 + `"]`, `{"sha256":1}`} {
+		if err := os.WriteFile(rp, []byte(release(bad)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (&NewsBuilder{ReleasesJson: rp}).JSONtoXML(); err == nil {
+			t.Errorf("JSONtoXML accepted hashes %s", bad)
+		}
+	}
+}
+
+// TestJSONtoXML_Revoked verifies that the revoked versions of a release are
+// emitted as <i2p:revoke> in order, and that malformed lists are refused.
+func TestJSONtoXML_Revoked(t *testing.T) {
+	release := func(revoked string) string {
+		return `[{"date":"2022-11-21","version":"2.0.0","minVersion":"0.9.9","minJavaVersion":"1.8",` +
+			`"updates":{"su3":{"torrent":"magnet:?xt=urn:btih:abc","url":["http://a.i2p/u.su3"]}},"revoked":` + revoked + `}]`
+	}
+	rp := filepath.Join(t.TempDir(), "releases.json")
+	if err := os.WriteFile(rp, []byte(release(`["1.9.0","0.9.62-1"]`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&NewsBuilder{ReleasesJson: rp}).JSONtoXML()
+	if err != nil {
+		t.Fatalf("JSONtoXML: %v", err)
+	}
+	want := `</i2p:update><i2p:revoke version="1.9.0"/><i2p:revoke version="0.9.62-1"/></i2p:release>`
+	if !strings.Contains(got, want) {
+		t.Errorf("JSONtoXML = %s\nwant it to contain %s", got, want)
+	}
+	for _, bad := range []string{`"1.9.0"`, `[1.9]`, `["v1.9"]`, `["2.0.0"]`, `["1.9.0","1.9.0"]`} {
+		if err := os.WriteFile(rp, []byte(release(bad)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (&NewsBuilder{ReleasesJson: rp}).JSONtoXML(); err == nil {
+			t.Errorf("JSONtoXML accepted revoked %s", bad)
+		}
+	}
+}
+
+// TestJSONtoXML_Signers verifies that the signer IDs of a release are emitted
+// as <i2p:signer> after any revoked versions, and that malformed lists are
+// refused.
+func TestJSONtoXML_Signers(t *testing.T) {
+	release := func(signers string) string {
+		return `[{"date":"2022-11-21","version":"2.0.0","minVersion":"0.9.9","minJavaVersion":"1.8",` +
+			`"updates":{"su3":{"torrent":"magnet:?xt=urn:btih:abc","url":["http://a.i2p/u.su3"]}},"revoked":["1.9.0"],"signers":` + signers + `}]`
+	}
+	rp := filepath.Join(t.TempDir(), "releases.json")
+	if err := os.WriteFile(rp, []byte(release(`["zzz@mail.i2p","idk@mail.i2p"]`)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := (&NewsBuilder{ReleasesJson: rp}).JSONtoXML()
+	if err != nil {
+		t.Fatalf("JSONtoXML: %v", err)
+	}
+	want := `<i2p:revoke version="1.9.0"/><i2p:signer id="zzz@mail.i2p"/><i2p:signer id="idk@mail.i2p"/></i2p:release>`
+	if !strings.Contains(got, want) {
+		t.Errorf("JSONtoXML = %s\nwant it to contain %s", got, want)
+	}
+	for _, bad := range []string{`"zzz@mail.i2p"`, `[1]`, `[""]`, `["zzz @mail.i2p"]`, `["zzz@mail.i2p","zzz@mail.i2p"]`} {
+		if err := os.WriteFile(rp, []byte(release(bad)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (&NewsBuilder{ReleasesJson: rp}).JSONtoXML(); err == nil {
+			t.Errorf("JSONtoXML accepted signers %s", bad)
+		}
+	}
+}
+
 // TestJSONtoXML_MissingUpdatesKey verifies that an absent "updates" key returns
 // a descriptive error instead of panicking with a nil interface conversion.
 func TestJSONtoXML_MissingUpdatesKey(t *testing.T) {
@@ -680,6 +773,67 @@ func TestDetectTranslationFiles_SubdirsIgnored(t *testing.T) {
 	}
 }
 
+// TestDetectTranslationFiles_FragmentDirs verifies that an entries.{locale}.d
+// fragment directory is returned as its entries file, once, whether or not
+// the file exists too.
+func TestDetectTranslationFiles_FragmentDirs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"entries.de.d", "entries.fr.d", "entries.d"} {
+		if err := os.Mkdir(filepath.Join(dir, name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "entries.fr.html"), []byte(""), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, p := range DetectTranslationFiles(dir) {
+		got = append(got, filepath.Base(p))
+	}
+	if strings.Join(got, " ") != "entries.de.html entries.fr.html" {
+		t.Errorf("DetectTranslationFiles = %v, want entries.de.html entries.fr.html", got)
+	}
+}
+
+// --- Locale fallback tests ---
+
+// TestParseLocaleFallbacks verifies normalisation of both separators and the
+// rejection of malformed, self-referencing, and duplicate specs.
+func TestParseLocaleFallbacks(t *testing.T) {
+	got, err := ParseLocaleFallbacks([]string{"es_AR=es", " zh = zh-CN "})
+	if err != nil {
+		t.Fatalf("ParseLocaleFallbacks: %v", err)
+	}
+	if got["es-AR"] != "es" || got["zh"] != "zh-CN" || len(got) != 2 {
+		t.Errorf("ParseLocaleFallbacks = %v", got)
+	}
+	for _, bad := range [][]string{{"es-AR"}, {"=es"}, {"es=es"}, {"zh=zh-CN", "zh=zh-TW"}} {
+		if _, err := ParseLocaleFallbacks(bad); err == nil {
+			t.Errorf("ParseLocaleFallbacks(%q): expected error", bad)
+		}
+	}
+}
+
+// TestLocaleFallbackChain verifies chain following, the implicit English
+// terminal, and cycle protection.
+func TestLocaleFallbackChain(t *testing.T) {
+	fallbacks := map[string]string{"es-AR": "es", "es": "en", "a": "b", "b": "a", "pt-BR": "pt", "pt": "es"}
+	cases := []struct {
+		locale string
+		want   string
+	}{
+		{"es-AR", "es"},
+		{"pt-BR", "pt,es"},
+		{"a", "b"},
+		{"de", ""},
+	}
+	for _, tc := range cases {
+		if got := strings.Join(LocaleFallbackChain(tc.locale, fallbacks), ","); got != tc.want {
+			t.Errorf("LocaleFallbackChain(%q) = %q, want %q", tc.locale, got, tc.want)
+		}
+	}
+}
+
 // --- xml:lang end-to-end tests ---
 
 // TestBuild_DefaultLanguageIsEnglish verifies that a NewsBuilder constructed
@@ -715,6 +869,65 @@ func TestBuild_LanguageFieldPropagatedToHeader(t *testing.T) {
 	}
 }
 
+// TestBuild_Rights verifies that Rights and LicenseURI are emitted, escaped,
+// in the feed header, and that neither appears when unset.
+func TestBuild_Rights(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+	if strings.Contains(feed, "<rights>") || strings.Contains(feed, `rel="license"`) {
+		t.Errorf("feed without Rights carries rights: %s", excerptAround(feed, "rights"))
+	}
+	nb = writeFixtures(t, t.TempDir())
+	nb.Rights = "Copyright The I2P Project & contributors"
+	nb.LicenseURI = "https://creativecommons.org/licenses/by-sa/4.0/"
+	feed, err = nb.Build()
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+	for _, want := range []string{
+		"<rights>",
+		"Copyright The I2P Project &amp; contributors",
+		`<link href="https://creativecommons.org/licenses/by-sa/4.0/" rel="license"/>`,
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed missing %s: %s", want, excerptAround(feed, "rights"))
+		}
+	}
+}
+
+// TestBuild_IconLogo verifies that Icon and Logo are emitted in the feed
+// header and that neither appears when unset.
+func TestBuild_IconLogo(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+	if strings.Contains(feed, "<icon>") || strings.Contains(feed, "<logo>") {
+		t.Errorf("feed without Icon or Logo carries one: %s", excerptAround(feed, "subtitle"))
+	}
+	nb = writeFixtures(t, t.TempDir())
+	nb.Icon = "http://example.i2p/favicon.png"
+	nb.Logo = "http://example.i2p/logo.png?size=2&x=1"
+	feed, err = nb.Build()
+	if err != nil {
+		t.Fatalf("Build error: %v", err)
+	}
+	for _, want := range []string{
+		"<icon>",
+		"http://example.i2p/favicon.png",
+		"<logo>",
+		"http://example.i2p/logo.png?size=2&amp;x=1",
+	} {
+		if !strings.Contains(feed, want) {
+			t.Errorf("feed missing %s: %s", want, excerptAround(feed, "subtitle"))
+		}
+	}
+}
+
 // TestBuild_RegionalLocaleInHeader verifies that a regional BCP 47 tag
 // (e.g. "pt-BR") round-trips correctly through the feed header.
 func TestBuild_RegionalLocaleInHeader(t *testing.T) {
@@ -945,3 +1158,325 @@ func TestBuild_HeaderTitle_XMLEscaped(t *testing.T) {
 		}
 	}
 }
+
+// writeDraftFixtures writes a fixture set whose entries.html contains one
+// published article and one draft article, returning a configured builder.
+func writeDraftFixtures(t *testing.T, dir string) *NewsBuilder {
+	t.Helper()
+	nb := writeFixtures(t, dir)
+	html := `<html><body>
+<header>Test Feed</header>
+<article id="urn:test:live" title="Live" href="http://example.com"
+         author="Author" published="2024-01-01" updated="2024-01-02">
+<details><summary>Summary</summary></details>
+<p>Body</p>
+</article>
+<article id="urn:test:draft" title="Draft" href="http://example.com"
+         author="Author" published="2024-02-01" updated="2024-02-02" draft="true">
+<details><summary>Upcoming</summary></details>
+<p>Not yet</p>
+</article>
+</body></html>`
+	if err := os.WriteFile(nb.Feed.EntriesHTMLPath, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return nb
+}
+
+// TestBuild_DraftArticlesExcludedByDefault verifies that articles marked
+// draft="true" are parsed but omitted from the built feed.
+func TestBuild_DraftArticlesExcludedByDefault(t *testing.T) {
+	nb := writeDraftFixtures(t, t.TempDir())
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if !strings.Contains(feed, "urn:test:live") {
+		t.Errorf("published article missing from feed")
+	}
+	if strings.Contains(feed, "urn:test:draft") {
+		t.Errorf("draft article present in feed without IncludeDrafts")
+	}
+	if nb.Feed.Length() != 2 {
+		t.Errorf("Feed.Length() = %d, want 2 (drafts must still be parsed)", nb.Feed.Length())
+	}
+}
+
+// TestBuild_DraftArticlesIncluded verifies that IncludeDrafts emits draft
+// articles alongside published ones.
+func TestBuild_DraftArticlesIncluded(t *testing.T) {
+	nb := writeDraftFixtures(t, t.TempDir())
+	nb.IncludeDrafts = true
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	for _, id := range []string{"urn:test:live", "urn:test:draft"} {
+		if !strings.Contains(feed, id) {
+			t.Errorf("article %s missing from feed with IncludeDrafts", id)
+		}
+	}
+}
+
+// updatedElement returns the trimmed text of the first <updated> element in
+// feed, which is always the feed-level one emitted by buildFeedHeader.
+func updatedElement(t *testing.T, feed string) string {
+	t.Helper()
+	start := strings.Index(feed, "<updated>")
+	end := strings.Index(feed, "</updated>")
+	if start < 0 || end <= start {
+		t.Fatalf("<updated> element not found in output:\n%s", feed)
+	}
+	return strings.TrimSpace(feed[start+len("<updated>") : end])
+}
+
+// TestBuild_TimestampNewestEntry verifies that TimestampNewestEntry stamps the
+// feed with the newest non-draft article date instead of the build time.
+func TestBuild_TimestampNewestEntry(t *testing.T) {
+	nb := writeDraftFixtures(t, t.TempDir())
+	nb.TimestampSource = TimestampNewestEntry
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	// The draft (updated 2024-02-02) is excluded, so the live article wins.
+	if got, want := updatedElement(t, feed), "2024-01-02T00:00:00.000+00:00"; got != want {
+		t.Errorf("<updated> = %q; want %q", got, want)
+	}
+}
+
+// TestBuild_TimestampNewestEntry_Expired verifies that TimestampNewestEntry
+// stamps a feed that lost an expired article with the expiry when it is newer
+// than every remaining article, so routers see that the feed changed.
+func TestBuild_TimestampNewestEntry_Expired(t *testing.T) {
+	dir := t.TempDir()
+	nb := writeFixtures(t, dir)
+	nb.TimestampSource = TimestampNewestEntry
+	html := `<html><body>
+<header>Test Feed</header>
+<article id="urn:test:expired" title="Maintenance" href="http://example.com"
+         author="Author" published="2024-01-03" updated="2024-01-03" expires="2024-01-05">
+<details><summary>Scheduled maintenance</summary></details>
+<p>Over</p>
+</article>
+<article id="urn:test:current" title="Current" href="http://example.com"
+         author="Author" published="2024-01-01" updated="2024-01-02">
+<details><summary>Still relevant</summary></details>
+<p>Body</p>
+</article>
+</body></html>`
+	if err := os.WriteFile(nb.Feed.EntriesHTMLPath, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	// A bare expires date lasts through that day.
+	if got, want := updatedElement(t, feed), "2024-01-06T00:00:00.000+00:00"; got != want {
+		t.Errorf("<updated> = %q; want %q", got, want)
+	}
+}
+
+// TestBuild_TimestampNewestEntry_Stable verifies that two builds of unchanged
+// entries produce an identical feed-level <updated> value.
+func TestBuild_TimestampNewestEntry_Stable(t *testing.T) {
+	dir := t.TempDir()
+	first := writeFixtures(t, dir)
+	first.TimestampSource = TimestampNewestEntry
+	a, err := first.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	second := writeFixtures(t, dir)
+	second.TimestampSource = TimestampNewestEntry
+	b, err := second.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if updatedElement(t, a) != updatedElement(t, b) {
+		t.Errorf("<updated> changed between identical builds: %q vs %q",
+			updatedElement(t, a), updatedElement(t, b))
+	}
+}
+
+// TestBuild_TimestampSource_Unknown verifies that an unrecognised timestamp
+// source is reported instead of silently falling back.
+func TestBuild_TimestampSource_Unknown(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	nb.TimestampSource = "yesterday"
+	if _, err := nb.Build(); err == nil {
+		t.Fatal("expected error for unknown TimestampSource, got nil")
+	}
+}
+
+// TestParseEntryDate verifies the accepted article date layouts.
+func TestParseEntryDate(t *testing.T) {
+	for _, s := range []string{"2024-01-02", "2024-01-02T03:04:05", "2024-01-02T03:04:05Z", "2024-01-02T03:04:05.123+02:00"} {
+		if _, ok := parseEntryDate(s); !ok {
+			t.Errorf("parseEntryDate(%q) failed; want success", s)
+		}
+	}
+	for _, s := range []string{"", "yesterday", "02/01/2024"} {
+		if _, ok := parseEntryDate(s); ok {
+			t.Errorf("parseEntryDate(%q) succeeded; want failure", s)
+		}
+	}
+}
+
+// writeExpiryFixtures writes an entries.html with one article that has long
+// expired, one that expires far in the future, and one with a malformed
+// expires attribute.
+func writeExpiryFixtures(t *testing.T, dir string) *NewsBuilder {
+	t.Helper()
+	nb := writeFixtures(t, dir)
+	html := `<html><body>
+<header>Test Feed</header>
+<article id="urn:test:expired" title="Maintenance" href="http://example.com"
+         author="Author" published="2020-01-01" updated="2020-01-01" expires="2020-01-08">
+<details><summary>Scheduled maintenance</summary></details>
+<p>Over</p>
+</article>
+<article id="urn:test:current" title="Current" href="http://example.com"
+         author="Author" published="2024-01-01" updated="2024-01-02" expires="2999-01-01T00:00:00Z">
+<details><summary>Still relevant</summary></details>
+<p>Body</p>
+</article>
+<article id="urn:test:malformed" title="Malformed" href="http://example.com"
+         author="Author" published="2024-01-01" updated="2024-01-02" expires="next tuesday">
+<details><summary>Typo</summary></details>
+<p>Body</p>
+</article>
+</body></html>`
+	if err := os.WriteFile(nb.Feed.EntriesHTMLPath, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return nb
+}
+
+// TestBuild_ExpiredArticlesExcluded verifies that articles past their expires
+// date are dropped from the feed, unexpired ones are kept, a malformed
+// expires attribute never hides an article, and the metadata records when the
+// next kept article expires.
+func TestBuild_ExpiredArticlesExcluded(t *testing.T) {
+	nb := writeExpiryFixtures(t, t.TempDir())
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if strings.Contains(feed, "urn:test:expired") {
+		t.Errorf("expired article present in feed")
+	}
+	for _, id := range []string{"urn:test:current", "urn:test:malformed"} {
+		if !strings.Contains(feed, id) {
+			t.Errorf("article %s missing from feed", id)
+		}
+	}
+	if nb.Feed.Length() != 3 {
+		t.Errorf("Feed.Length() = %d, want 3 (expired articles stay in the source)", nb.Feed.Length())
+	}
+	if m := nb.Metadata(feed); m.NextExpiry != "2999-01-01T00:00:00Z" {
+		t.Errorf("NextExpiry = %q, want the expiry of urn:test:current", m.NextExpiry)
+	}
+}
+
+// TestArticleExpiry verifies that a bare date lasts through the end of that
+// UTC day while a timestamp is used exactly.
+func TestArticleExpiry(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		ok   bool
+	}{
+		{"2024-03-01", time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), true},
+		{"2024-03-01T12:00:00Z", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), true},
+		{"2024-03-01T12:00:00", time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), true},
+		{"soon", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := articleExpiry(tt.in)
+		if ok != tt.ok || !got.Equal(tt.want) {
+			t.Errorf("articleExpiry(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestBuild_InvalidExtensions verifies that an article whose extension
+// elements are not well-formed fails the build, naming the article.
+func TestBuild_InvalidExtensions(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	html := `<html><body>
+<article id="urn:test:ext" title="Ext" href="http://example.com"
+         author="Author" published="2024-01-01" updated="2024-01-02">
+<details><summary>Summary</summary></details>
+<p>Body</p>
+<script type="application/atom+xml"><i2p:rollout percent="10"></script>
+</article>
+</body></html>`
+	if err := os.WriteFile(nb.Feed.EntriesHTMLPath, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := nb.Build()
+	if err == nil || !strings.Contains(err.Error(), "urn:test:ext") {
+		t.Fatalf("Build() error = %v, want an error naming urn:test:ext", err)
+	}
+}
+
+// TestBuild_Channel verifies that a pre-release feed carries an
+// <i2p:channel> element and the ChannelTitlePrefix, and that a stable feed
+// carries neither.
+func TestBuild_Channel(t *testing.T) {
+	dir := t.TempDir()
+	nb := writeFixtures(t, dir)
+	nb.TITLE = "I2P News"
+	nb.Channel = "beta"
+	nb.ChannelTitlePrefix = "[{status}] "
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if !regexp.MustCompile(`(?s)<title>\s*\[beta\] I2P News\s*</title>`).MatchString(feed) {
+		t.Errorf("expected <title>[beta] I2P News</title>; feed snippet:\n%s", excerptAround(feed, "title"))
+	}
+	if !regexp.MustCompile(`(?s)<i2p:channel>\s*beta\s*</i2p:channel>`).MatchString(feed) {
+		t.Errorf("expected <i2p:channel>beta</i2p:channel>; feed snippet:\n%s", excerptAround(feed, "subtitle"))
+	}
+
+	nb = writeFixtures(t, t.TempDir())
+	nb.TITLE = "I2P News"
+	nb.ChannelTitlePrefix = "[{status}] "
+	feed, err = nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if strings.Contains(feed, "i2p:channel") || !regexp.MustCompile(`(?s)<title>\s*I2P News\s*</title>`).MatchString(feed) {
+		t.Errorf("stable feed carries a channel annotation; feed snippet:\n%s", excerptAround(feed, "title"))
+	}
+}
+
+// TestBuild_FeedID verifies that FeedID replaces the urn:uuid: feed id, that
+// entries keep their own ids, and that an entry reusing the feed id is
+// rejected.
+func TestBuild_FeedID(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	nb.FeedID = "tag:example.org,2025-01-01:news"
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if !regexp.MustCompile(`(?s)<id>\s*tag:example.org,2025-01-01:news\s*</id>`).MatchString(feed) {
+		t.Errorf("feed id not set from FeedID; feed snippet:\n%s", excerptAround(feed, "<id>"))
+	}
+	if strings.Contains(feed, "urn:uuid:") {
+		t.Errorf("urn:uuid: id written despite FeedID")
+	}
+	if !regexp.MustCompile(`(?s)<id>\s*urn:test:1\s*</id>`).MatchString(feed) {
+		t.Errorf("entry lost its own id; feed snippet:\n%s", excerptAround(feed, "urn:test:1"))
+	}
+
+	nb = writeFixtures(t, t.TempDir())
+	nb.FeedID = "urn:test:1"
+	if _, err := nb.Build(); err == nil || !strings.Contains(err.Error(), "urn:test:1") {
+		t.Errorf("Build() with an entry reusing the feed id = %v, want an error naming it", err)
+	}
+}
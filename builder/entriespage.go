@@ -0,0 +1,106 @@
+// Package newsbuilder — HTML entries pages.
+package newsbuilder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"strings"
+
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+)
+
+// EntriesPagePath returns the path of the HTML entries page of the feed at
+// feedPath: the ".atom.xml" suffix replaced by ".html", so the entries of
+// news_de.atom.xml are rendered to news_de.html.
+func EntriesPagePath(feedPath string) string {
+	return strings.TrimSuffix(feedPath, ".atom.xml") + ".html"
+}
+
+// entriesPageTemplate renders the entries of one feed.  Each <article>
+// carries the id returned by newsfeed.EntryAnchor, the target of the
+// rel="alternate" links written when EntriesPageURL is set.
+var entriesPageTemplate = template.Must(template.New("entries").Parse(`<!DOCTYPE html>
+<html lang="{{.Lang}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Entries}}<article id="{{.Anchor}}">
+<h2><a href="#{{.Anchor}}">{{.Title}}</a></h2>
+<p><time datetime="{{.Updated}}">{{.Updated}}</time>{{if .Author}} — {{.Author}}{{end}}{{if .Link}} — <a href="{{.Link}}">{{$.Messages.Source}}</a>{{end}}</p>
+{{if .Summary}}<p><em>{{.Summary}}</em></p>
+{{end}}<div>{{.Content}}</div>
+</article>
+{{end}}</body>
+</html>
+`))
+
+// entriesPageEntry is one article as rendered by entriesPageTemplate.
+type entriesPageEntry struct {
+	Anchor  string
+	Title   string
+	Updated string
+	Author  string
+	Link    string
+	Summary string
+	Content template.HTML
+}
+
+// EntriesPage renders the articles of the last successful Build as an HTML
+// page, in feed order, each under the anchor newsfeed.EntryAnchor derives
+// from its id, with the boilerplate of MessagesFor the feed's language.  The
+// article bodies are copied as they appear in the feed's <content>; like the
+// feed itself they are trusted.
+func (nb *NewsBuilder) EntriesPage() ([]byte, error) {
+	if err := checkEntryAnchors(nb.builtArticles); err != nil {
+		return nil, fmt.Errorf("EntriesPage: %w", err)
+	}
+	var entries []entriesPageEntry
+	for _, art := range nb.builtArticles {
+		updated := art.UpdatedDate
+		if updated == "" {
+			updated = art.PublishedDate
+		}
+		entries = append(entries, entriesPageEntry{
+			Anchor:  newsfeed.EntryAnchor(art.UID),
+			Title:   art.Title,
+			Updated: updated,
+			Author:  art.Author,
+			Link:    art.Link,
+			Summary: art.Summary,
+			Content: template.HTML(art.Content()),
+		})
+	}
+	var buf bytes.Buffer
+	err := entriesPageTemplate.Execute(&buf, struct {
+		Lang     string
+		Title    string
+		Entries  []entriesPageEntry
+		Messages Messages
+	}{feedLanguage(nb), feedTitle(nb), entries, MessagesFor(feedLanguage(nb))})
+	if err != nil {
+		return nil, fmt.Errorf("EntriesPage: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// errAnchorCollision reports two articles whose ids yield the same anchor.
+var errAnchorCollision = errors.New("articles share an entries page anchor")
+
+// checkEntryAnchors returns an error naming the first two articles whose ids
+// map to the same newsfeed.EntryAnchor, e.g. "news:1" and "news-1".
+func checkEntryAnchors(articles []*newsfeed.Article) error {
+	seen := map[string]string{}
+	for _, art := range articles {
+		anchor := newsfeed.EntryAnchor(art.UID)
+		if other, ok := seen[anchor]; ok {
+			return fmt.Errorf("%w: %q and %q both map to %q", errAnchorCollision, other, art.UID, anchor)
+		}
+		seen[anchor] = art.UID
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package newsbuilder
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestEntriesPagePath verifies that the entries page replaces the feed's
+// .atom.xml suffix, like the metadata sidecar.
+func TestEntriesPagePath(t *testing.T) {
+	for feed, want := range map[string]string{
+		"news.atom.xml":             "news.html",
+		"win/beta/news_de.atom.xml": "win/beta/news_de.html",
+	} {
+		if got := EntriesPagePath(feed); got != want {
+			t.Errorf("EntriesPagePath(%q) = %q, want %q", feed, got, want)
+		}
+	}
+}
+
+// TestBuild_EntriesPage verifies that with EntriesPageURL set every entry
+// links to its anchor on the page, and that the page renders the entry under
+// that anchor.
+func TestBuild_EntriesPage(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	nb.Language = "de"
+	nb.EntriesPageURL = "http://mirror.i2p/news_de.html"
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	link := `<link href="http://mirror.i2p/news_de.html#entry-urn-test-1" rel="alternate" type="text/html"/>`
+	if !strings.Contains(feed, link) {
+		t.Errorf("feed missing %s:\n%s", link, feed)
+	}
+	page, err := nb.EntriesPage()
+	if err != nil {
+		t.Fatalf("EntriesPage: %v", err)
+	}
+	for _, want := range []string{
+		`<html lang="de">`,
+		`<title>I2P News</title>`,
+		`<article id="entry-urn-test-1">`,
+		`<a href="#entry-urn-test-1">Title</a>`,
+		`<p>Body</p>`,
+		`<em>Summary</em>`,
+	} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("entries page missing %s:\n%s", want, page)
+		}
+	}
+}
+
+// TestBuild_EntriesPageAnchorCollision verifies that two ids sharing an
+// anchor fail a build that links to the entries page, and only such a build.
+func TestBuild_EntriesPageAnchorCollision(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	html := `<html><body>
+<article id="news:1" title="A" href="" author="X" published="2024-01-01" updated="2024-01-02"><p>a</p></article>
+<article id="news-1" title="B" href="" author="X" published="2024-01-01" updated="2024-01-02"><p>b</p></article>
+</body></html>`
+	if err := os.WriteFile(nb.Feed.EntriesHTMLPath, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := nb.Build(); err != nil {
+		t.Fatalf("Build without EntriesPageURL: %v", err)
+	}
+	if _, err := nb.EntriesPage(); !errors.Is(err, errAnchorCollision) {
+		t.Errorf("EntriesPage error = %v, want errAnchorCollision", err)
+	}
+	nb.EntriesPageURL = "news.html"
+	if _, err := nb.Build(); !errors.Is(err, errAnchorCollision) {
+		t.Errorf("Build error = %v, want errAnchorCollision", err)
+	}
+}
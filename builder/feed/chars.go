@@ -0,0 +1,113 @@
+package newsfeed
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// StrippedChar counts the occurrences of one character LoadHTML removed from
+// an article: a control character below U+0020 other than tab, newline and
+// carriage return, or one of the non-characters U+FFFE and U+FFFF, all of
+// which XML 1.0 forbids; or a byte that is not valid UTF-8, counted as
+// U+FFFD.  Text pasted from word processors carries them, and escaping does
+// not help: a strict parser refuses the feed whatever the markup around them.
+// The C1 controls U+0080 to U+009F are legal XML 1.0 and kept.
+type StrippedChar struct {
+	Rune  rune
+	Count int
+}
+
+// String returns c as, e.g., "U+000B x2".
+func (c StrippedChar) String() string {
+	return fmt.Sprintf("%U x%d", c.Rune, c.Count)
+}
+
+// strippedReport returns chars as a comma-separated list.
+func strippedReport(chars []StrippedChar) string {
+	parts := make([]string, len(chars))
+	for i, c := range chars {
+		parts[i] = c.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// StrippedReport returns the characters LoadHTML stripped from a, e.g.
+// "U+000B x2, U+000C x1", or "" when there were none.
+func (a *Article) StrippedReport() string {
+	return strippedReport(a.Stripped)
+}
+
+// strippedRune reports whether r, decoded with width size, is a character
+// LoadHTML strips; see StrippedChar.
+func strippedRune(r rune, size int) bool {
+	switch {
+	case r == utf8.RuneError && size == 1:
+		return true
+	case r < 0x20:
+		return r != '\t' && r != '\n' && r != '\r'
+	}
+	return r == 0xfffe || r == 0xffff
+}
+
+// stripString returns s without the characters LoadHTML strips, counting each
+// in counts.
+func stripString(s string, counts map[rune]int) string {
+	clean := true
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if strippedRune(r, size) {
+			clean = false
+			break
+		}
+		i += size
+	}
+	if clean {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if strippedRune(r, size) {
+			counts[r]++
+		} else {
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// stripInvalidChars removes the characters LoadHTML strips from the text,
+// comments and attribute values of n and its descendants, and returns what
+// it removed ordered by code point, or nil when n was clean.
+func stripInvalidChars(n *html.Node) []StrippedChar {
+	counts := make(map[rune]int)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode, html.CommentNode:
+			n.Data = stripString(n.Data, counts)
+		case html.ElementNode:
+			for i := range n.Attr {
+				n.Attr[i].Val = stripString(n.Attr[i].Val, counts)
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	if len(counts) == 0 {
+		return nil
+	}
+	chars := make([]StrippedChar, 0, len(counts))
+	for r, count := range counts {
+		chars = append(chars, StrippedChar{Rune: r, Count: count})
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i].Rune < chars[j].Rune })
+	return chars
+}
@@ -0,0 +1,143 @@
+package newsfeed
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoArticles is wrapped by the error LoadHTML returns when the entries
+// files hold no <article> element at all, which almost always means
+// the wrong file was passed or the markup was cut off.  Callers may detect it
+// with errors.Is.
+var ErrNoArticles = errors.New("no <article> elements")
+
+// ErrBadDate is wrapped by the error Article.Validate returns when the
+// published or updated attribute is not a date ParseDate accepts.  Callers
+// may detect it with errors.Is.
+var ErrBadDate = errors.New("unparseable date")
+
+// ErrInvalidExtensions is wrapped by every error ValidateExtensions returns.
+// Callers may detect it with errors.Is.
+var ErrInvalidExtensions = errors.New("invalid extensions")
+
+// ErrInvalidChars is wrapped by the error LoadHTML returns, with StrictChars
+// set, for an article holding characters it would otherwise strip; see
+// StrippedChar.  Callers may detect it with errors.Is.
+var ErrInvalidChars = errors.New("characters illegal in XML 1.0")
+
+// ErrMissingAttribute is returned by Article.Validate when an attribute the
+// Atom entry cannot do without is absent or empty.  Callers may detect it
+// with errors.As.
+type ErrMissingAttribute struct {
+	// Name is the attribute of the <article> element, e.g. "updated".
+	Name string
+	// ArticleID is the id attribute of the article; empty when Name is "id".
+	ArticleID string
+}
+
+// Error describes e.
+func (e *ErrMissingAttribute) Error() string {
+	if e.ArticleID == "" {
+		return fmt.Sprintf("article without an %s attribute", e.Name)
+	}
+	return fmt.Sprintf("article %q: missing %s attribute", e.ArticleID, e.Name)
+}
+
+// dateLayouts lists the date formats accepted in the updated and published
+// attributes of an <article>, most specific first.  Besides RFC 3339 a few
+// unambiguous forms people write by hand are accepted; numeric day/month
+// orders such as 02/01/2006 are not, since they read differently by locale.
+var dateLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+}
+
+// dateHint names the date forms in error messages.
+const dateHint = "want RFC 3339 such as 2006-01-02T15:04:05Z, 2006-01-02, or e.g. January 2, 2006"
+
+// ParseDate parses an article date attribute using dateLayouts, ignoring
+// surrounding whitespace.  Dates without a zone are interpreted as UTC.
+func ParseDate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// NormalizeDate returns the article date attribute s as the RFC 3339 UTC
+// timestamp Atom requires, e.g. "2024-01-02" becomes "2024-01-02T00:00:00Z".
+func NormalizeDate(s string) (string, bool) {
+	t, ok := ParseDate(s)
+	if !ok {
+		return "", false
+	}
+	return t.UTC().Format(time.RFC3339Nano), true
+}
+
+// badDate returns the error for the unparseable date attribute name of a.
+func badDate(a *Article, name, value string) error {
+	return fmt.Errorf("article %q: %s %q: %w (%s)", a.UID, name, value, ErrBadDate, dateHint)
+}
+
+// normalizeDates rewrites the updated and published dates of a, when set,
+// with NormalizeDate.  It returns an error wrapping ErrBadDate, naming the
+// article, for a date that does not parse and leaves a unchanged.
+func (a *Article) normalizeDates() error {
+	updated, published := a.UpdatedDate, a.PublishedDate
+	for _, date := range []struct {
+		name string
+		v    *string
+	}{{"updated", &updated}, {"published", &published}} {
+		if *date.v == "" {
+			continue
+		}
+		norm, ok := NormalizeDate(*date.v)
+		if !ok {
+			return badDate(a, date.name, *date.v)
+		}
+		*date.v = norm
+	}
+	a.UpdatedDate, a.PublishedDate = updated, published
+	return nil
+}
+
+// Validate checks that the article can be rendered as a valid Atom entry:
+// id, title, and updated are present, updated and published (when set) are
+// dates, and the extensions pass ValidateExtensions.
+func (a *Article) Validate() error {
+	required := []struct{ name, value string }{
+		{"id", a.UID},
+		{"title", a.Title},
+		{"updated", a.UpdatedDate},
+	}
+	for _, attr := range required {
+		if attr.value == "" {
+			return &ErrMissingAttribute{Name: attr.name, ArticleID: a.UID}
+		}
+	}
+	for _, attr := range []struct{ name, value string }{{"updated", a.UpdatedDate}, {"published", a.PublishedDate}} {
+		if _, ok := ParseDate(attr.value); attr.value != "" && !ok {
+			return badDate(a, attr.name, attr.value)
+		}
+	}
+	if err := ValidateExtensions(a.Extensions); err != nil {
+		return fmt.Errorf("article %q: %w", a.UID, err)
+	}
+	return nil
+}
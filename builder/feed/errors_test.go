@@ -0,0 +1,171 @@
+package newsfeed
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestArticle_Validate verifies that each kind of broken article is reported
+// with the error its category is detected by.
+func TestArticle_Validate(t *testing.T) {
+	valid := Article{UID: "urn:x", Title: "T", UpdatedDate: "2024-01-02", PublishedDate: "2024-01-01T10:00:00Z"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() on a valid article = %v", err)
+	}
+
+	noTitle := valid
+	noTitle.Title = ""
+	var missing *ErrMissingAttribute
+	if err := noTitle.Validate(); !errors.As(err, &missing) || missing.Name != "title" || missing.ArticleID != "urn:x" {
+		t.Errorf("missing title: Validate() = %v, want ErrMissingAttribute{title, urn:x}", err)
+	}
+	noID := valid
+	noID.UID = ""
+	if err := noID.Validate(); !errors.As(err, &missing) || missing.Name != "id" {
+		t.Errorf("missing id: Validate() = %v, want ErrMissingAttribute{id}", err)
+	}
+
+	for _, field := range []func(a *Article){
+		func(a *Article) { a.UpdatedDate = "yesterday" },
+		func(a *Article) { a.PublishedDate = "2024-13-01" },
+	} {
+		bad := valid
+		field(&bad)
+		if err := bad.Validate(); !errors.Is(err, ErrBadDate) {
+			t.Errorf("Validate() on %+v = %v, want ErrBadDate", bad, err)
+		}
+	}
+
+	badExt := valid
+	badExt.Extensions = "<title>again</title>"
+	if err := badExt.Validate(); !errors.Is(err, ErrInvalidExtensions) {
+		t.Errorf("Validate() with a repeated <title> = %v, want ErrInvalidExtensions", err)
+	}
+}
+
+// TestLoadHTML_NoArticles verifies that an entries file without articles is
+// reported with ErrNoArticles and a missing one with the os error.
+func TestLoadHTML_NoArticles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.html")
+	if err := os.WriteFile(path, []byte("<html><body><header>T</header></body></html>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := &Feed{EntriesHTMLPath: path}
+	if err := f.LoadHTML(); !errors.Is(err, ErrNoArticles) {
+		t.Errorf("LoadHTML() = %v, want ErrNoArticles", err)
+	}
+	f = &Feed{EntriesHTMLPath: path + ".missing"}
+	if err := f.LoadHTML(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadHTML() on a missing file = %v, want os.ErrNotExist", err)
+	}
+}
+
+// TestNormalizeDate verifies that the accepted date forms are rewritten as
+// RFC 3339 UTC timestamps and that impossible or ambiguous dates are not.
+func TestNormalizeDate(t *testing.T) {
+	for in, want := range map[string]string{
+		"2024-01-02":                      "2024-01-02T00:00:00Z",
+		" 2024-01-02T03:04:05 ":           "2024-01-02T03:04:05Z",
+		"2024-01-02T03:04:05+02:00":       "2024-01-02T01:04:05Z",
+		"2024-01-02 03:04":                "2024-01-02T03:04:00Z",
+		"2024-01-02T03:04:05.5Z":          "2024-01-02T03:04:05.5Z",
+		"January 2, 2024":                 "2024-01-02T00:00:00Z",
+		"2 Jan 2024":                      "2024-01-02T00:00:00Z",
+		"Tue, 02 Jan 2024 03:04:05 +0000": "2024-01-02T03:04:05Z",
+	} {
+		if got, ok := NormalizeDate(in); !ok || got != want {
+			t.Errorf("NormalizeDate(%q) = %q, %v; want %q", in, got, ok, want)
+		}
+	}
+	for _, in := range []string{"", "2024-13-40", "2024-02-30", "02/01/2024", "yesterday"} {
+		if got, ok := NormalizeDate(in); ok {
+			t.Errorf("NormalizeDate(%q) = %q; want failure", in, got)
+		}
+	}
+}
+
+// TestLoadHTML_Dates verifies that LoadHTML normalizes article dates and
+// rejects an impossible date naming the article.
+func TestLoadHTML_Dates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.html")
+	write := func(published string) {
+		t.Helper()
+		html := `<html><body><article id="urn:x" title="T" published="` + published + `" updated="2024-01-02 10:30"><p>x</p></article></body></html>`
+		if err := os.WriteFile(path, []byte(html), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("March 5, 2024")
+	f := &Feed{EntriesHTMLPath: path}
+	if err := f.LoadHTML(); err != nil {
+		t.Fatalf("LoadHTML() = %v", err)
+	}
+	if a := f.Article(0); a.PublishedDate != "2024-03-05T00:00:00Z" || a.UpdatedDate != "2024-01-02T10:30:00Z" {
+		t.Errorf("dates = %q, %q; want RFC 3339", a.PublishedDate, a.UpdatedDate)
+	}
+
+	write("2024-13-40")
+	f = &Feed{EntriesHTMLPath: path}
+	err := f.LoadHTML()
+	if !errors.Is(err, ErrBadDate) || !strings.Contains(err.Error(), `"urn:x"`) {
+		t.Errorf("LoadHTML() with published=2024-13-40 = %v, want ErrBadDate naming urn:x", err)
+	}
+
+	html := `<html><body><article id="urn:d" title="T" draft="true" published="TBD" updated="TBD"><p>x</p></article></body></html>`
+	if err := os.WriteFile(path, []byte(html), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f = &Feed{EntriesHTMLPath: path}
+	if err := f.LoadHTML(); err != nil || f.Article(0).UpdatedDate != "TBD" {
+		t.Errorf("LoadHTML() of a draft with placeholder dates = %v; want it loaded unchanged", err)
+	}
+}
+
+// TestLoadHTML_InvalidChars verifies that characters illegal in XML 1.0 are
+// stripped from the text and attributes of an article and reported per
+// article, and that StrictChars rejects them outside drafts.
+func TestLoadHTML_InvalidChars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.html")
+	write := func(draft string) {
+		t.Helper()
+		html := "<html><body>" +
+			"<article id=\"urn:x\" title=\"Ti\vtle\" published=\"2024-01-01\" updated=\"2024-01-02\"" + draft + "><p>a\vb\fc\x01d\te\xffé\u0085</p></article>" +
+			"<article id=\"urn:y\" title=\"T\" published=\"2024-01-01\" updated=\"2024-01-02\"><p>clean\n</p></article>" +
+			"</body></html>"
+		if err := os.WriteFile(path, []byte(html), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("")
+	f := &Feed{EntriesHTMLPath: path}
+	if err := f.LoadHTML(); err != nil {
+		t.Fatalf("LoadHTML() = %v", err)
+	}
+	a := f.Article(0)
+	if a.Title != "Title" {
+		t.Errorf("Title = %q, want the vertical tab stripped", a.Title)
+	}
+	if got := a.Content(); !strings.Contains(got, "abcd\teé\u0085") {
+		t.Errorf("Content() = %q; want the control characters stripped and the tab and C1 control kept", got)
+	}
+	if got, want := a.StrippedReport(), "U+0001 x1, U+000B x2, U+000C x1, U+FFFD x1"; got != want {
+		t.Errorf("StrippedReport() = %q, want %q", got, want)
+	}
+	if b := f.Article(1); b.Stripped != nil {
+		t.Errorf("clean article reports %v", b.Stripped)
+	}
+
+	f = &Feed{EntriesHTMLPath: path, StrictChars: true}
+	err := f.LoadHTML()
+	if !errors.Is(err, ErrInvalidChars) || !strings.Contains(err.Error(), `"urn:x"`) || !strings.Contains(err.Error(), "U+000B x2") {
+		t.Errorf("LoadHTML() with StrictChars = %v, want ErrInvalidChars naming urn:x and its characters", err)
+	}
+	write(` draft="true"`)
+	f = &Feed{EntriesHTMLPath: path, StrictChars: true}
+	if err := f.LoadHTML(); err != nil {
+		t.Errorf("LoadHTML() with StrictChars of a draft = %v, want it stripped only", err)
+	}
+}
@@ -0,0 +1,114 @@
+package newsfeed
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ExtensionsType is the type of the <script> child of an <article> that
+// holds raw Atom extension elements for the article's <entry>.  HTML parsers
+// keep the body of a <script> as unparsed text, so the XML survives the
+// entries file exactly as written and never shows up in the rendered page.
+const ExtensionsType = "application/atom+xml"
+
+const (
+	atomNS = "http://www.w3.org/2005/Atom"
+	i2pNS  = "http://geti2p.net/en/docs/spec/updates"
+)
+
+// repeatableAtomElements are the Atom elements an entry may carry more than
+// once (RFC 4287 §4.1.2), so extensions may add them alongside the ones Entry
+// already writes.  Every other Atom element is written by Entry and may
+// appear only once.
+var repeatableAtomElements = map[string]bool{
+	"category":    true,
+	"contributor": true,
+	"link":        true,
+}
+
+// isExtensionsScript reports whether n is a <script type="application/atom+xml">
+// element.
+func isExtensionsScript(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Data != "script" {
+		return false
+	}
+	for _, attr := range n.Attr {
+		if attr.Key == "type" {
+			return strings.EqualFold(strings.TrimSpace(attr.Val), ExtensionsType)
+		}
+	}
+	return false
+}
+
+// extensionsOf returns the contents of the extension scripts that are direct
+// children of the <article> element n, joined by newlines.
+func extensionsOf(n *html.Node) string {
+	var parts []string
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if !isExtensionsScript(child) {
+			continue
+		}
+		var sb strings.Builder
+		for text := child.FirstChild; text != nil; text = text.NextSibling {
+			if text.Type == html.TextNode {
+				sb.WriteString(text.Data)
+			}
+		}
+		if s := strings.TrimSpace(sb.String()); s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ValidateExtensions checks the raw extension elements of an article before
+// they are copied into its <entry>.  The fragment must be well-formed XML
+// made only of elements; the Atom and i2p prefixes of the feed are in scope,
+// so <i2p:...> elements need no namespace declaration of their own.  Atom
+// elements other than category, contributor and link are rejected, since the
+// entry already has them and Atom allows only one.  Every error wraps
+// ErrInvalidExtensions.
+func ValidateExtensions(raw string) error {
+	if strings.TrimSpace(raw) == "" {
+		return nil
+	}
+	if strings.HasPrefix(strings.TrimSpace(raw), "<?xml") {
+		return fmt.Errorf("ValidateExtensions: %w: extensions must not contain an XML declaration", ErrInvalidExtensions)
+	}
+	wrapped := `<_root xmlns="` + atomNS + `" xmlns:i2p="` + i2pNS + `">` + raw + `</_root>`
+	dec := xml.NewDecoder(strings.NewReader(wrapped))
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("ValidateExtensions: %w: malformed XML: %w", ErrInvalidExtensions, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			// encoding/xml leaves an undeclared prefix in Name.Space as is;
+			// a declared namespace is a URI and so always has a colon.
+			if !strings.Contains(t.Name.Space, ":") {
+				return fmt.Errorf("ValidateExtensions: %w: <%s:%s> uses an undeclared namespace prefix", ErrInvalidExtensions, t.Name.Space, t.Name.Local)
+			}
+			// depth 1 is inside _root: a top-level extension element.
+			if depth == 1 && t.Name.Space == atomNS && !repeatableAtomElements[t.Name.Local] {
+				return fmt.Errorf("ValidateExtensions: %w: <%s> is already written by the builder", ErrInvalidExtensions, t.Name.Local)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 1 && strings.TrimSpace(string(t)) != "" {
+				return fmt.Errorf("ValidateExtensions: %w: text %q outside an element", ErrInvalidExtensions, strings.TrimSpace(string(t)))
+			}
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package newsfeed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// TestArticle_Extensions verifies that the body of an atom+xml script child is
+// kept verbatim in Article.Extensions, left out of Content, and appended to
+// the entry.
+func TestArticle_Extensions(t *testing.T) {
+	f := &Feed{ArticlesSet: []string{
+		`<article id="urn:x" title="T"><details><summary>S</summary></details><p>B</p>` +
+			`<script type="application/atom+xml"><i2p:rollout percent="10" /></script></article>`,
+	}}
+	a := f.Article(0)
+	if a.Extensions != `<i2p:rollout percent="10" />` {
+		t.Fatalf("Extensions = %q", a.Extensions)
+	}
+	if strings.Contains(a.Content(), "rollout") {
+		t.Errorf("Content() includes the extension script: %q", a.Content())
+	}
+	entry := a.Entry()
+	if !strings.Contains(entry, `<i2p:rollout percent="10" />`+"\n</entry>") {
+		t.Errorf("extension not appended to entry:\n%s", entry)
+	}
+	doc := `<feed xmlns="http://www.w3.org/2005/Atom" xmlns:i2p="http://geti2p.net/en/docs/spec/updates">` + entry + `</feed>`
+	if err := xml.Unmarshal([]byte(doc), new(struct{})); err != nil {
+		t.Errorf("entry with extensions is not well-formed XML: %v", err)
+	}
+}
+
+// TestArticle_OtherScriptsKept verifies that a <script> of another type is
+// neither taken as extensions nor dropped from the content.
+func TestArticle_OtherScriptsKept(t *testing.T) {
+	f := &Feed{ArticlesSet: []string{
+		`<article id="urn:x" title="T"><p>B</p><script type="text/plain">hello</script></article>`,
+	}}
+	a := f.Article(0)
+	if a.Extensions != "" {
+		t.Errorf("Extensions = %q, want empty", a.Extensions)
+	}
+	if !strings.Contains(a.Content(), "hello") {
+		t.Errorf("Content() dropped a text/plain script: %q", a.Content())
+	}
+}
+
+func TestValidateExtensions(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"i2p element", `<i2p:rollout percent="10"/>`, false},
+		{"own namespace", `<x:flag xmlns:x="urn:example">on</x:flag>`, false},
+		{"atom category", `<category term="security"/>`, false},
+		{"atom link", `<link rel="related" href="http://example.i2p/"/>`, false},
+		{"atom title", `<title>Again</title>`, true},
+		{"atom id", `<id>urn:other</id>`, true},
+		{"unclosed", `<i2p:rollout>`, true},
+		{"unbound prefix", `<y:flag/>`, true},
+		{"bare text", `hello`, true},
+		{"declaration", `<?xml version="1.0"?><i2p:x/>`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateExtensions(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateExtensions(%q) = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+		})
+	}
+}
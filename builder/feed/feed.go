@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/xml"
 	"fmt"
+	"iter"
 	"log"
 	"os"
 	"regexp"
@@ -64,22 +65,67 @@ type Feed struct {
 	// is empty, HeaderTitle is used in the Atom <title> element instead.
 	// This allows the HTML source to declare the feed title without requiring
 	// a separate --feedtitle flag.
-	HeaderTitle         string
+	HeaderTitle string
+	// ArticlesSet holds raw <article> HTML for callers that construct a Feed
+	// by hand; Article and Articles parse it on demand.  LoadHTML does not
+	// fill it: loaded articles are kept parsed, see articles.
 	ArticlesSet         []string
 	EntriesHTMLPath     string
 	BaseEntriesHTMLPath string
-	doc                 soup.Root
+	// FallbackEntriesHTMLPaths lists the entries files of the locale's
+	// fallback chain (e.g. entries.es.html for es-AR), most specific first.
+	// They are merged after EntriesHTMLPath and before BaseEntriesHTMLPath;
+	// an article whose id was already loaded from an earlier file is skipped
+	// so that the regional translation wins over the base language.
+	FallbackEntriesHTMLPaths []string
+	// StrictChars makes LoadHTML fail on an article, other than a draft,
+	// holding characters illegal in XML 1.0 instead of stripping them; see
+	// StrippedChar.
+	StrictChars bool
+	doc         soup.Root
+	// articles holds the articles loaded by LoadHTML, each parsed exactly
+	// once from its entries file.
+	articles []*Article
 }
 
-// parseHTMLArticles reads the HTML file at path, extracts the <header> title
-// and all <article> elements. It returns the article HTML strings, the header
-// title text, a boolean indicating whether a <header> element was present
-// (regardless of its text content), and any I/O error encountered while
-// reading the file.
-func parseHTMLArticles(path string) (articles []string, headerTitle string, headerFound bool, err error) {
+// parseHTMLArticles reads the entries at path: the HTML file itself and then
+// the files of its fragment directory in filename order (see FragmentDir).
+// Either may be missing, but not both.  It extracts the <header> title and
+// all <article> elements, and returns the parsed articles, the header title
+// text of the first file with a <header> element, a boolean indicating
+// whether such an element was present (regardless of its text content), and
+// any error encountered while reading the files.  The dates of every article
+// are normalized to RFC 3339; an article other than a draft with an
+// unparseable date is an error wrapping ErrBadDate.  Characters illegal in
+// XML 1.0 are stripped from every article and recorded in its Stripped, or,
+// with strict, are an error wrapping ErrInvalidChars.
+func parseHTMLArticles(path string, strict bool) (articles []*Article, headerTitle string, headerFound bool, err error) {
+	fragments, err := FragmentPaths(path)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("LoadHTML: %w", err)
+	}
+	files := append([]string{path}, fragments...)
+	if _, err := os.Stat(path); err != nil && len(fragments) > 0 {
+		files = fragments
+	}
+	for _, file := range files {
+		fileArticles, title, found, err := parseHTMLFile(file, strict)
+		if err != nil {
+			return nil, "", false, err
+		}
+		if found && !headerFound {
+			headerTitle, headerFound = title, true
+		}
+		articles = append(articles, fileArticles...)
+	}
+	return articles, headerTitle, headerFound, nil
+}
+
+// parseHTMLFile parses the single HTML file at path; see parseHTMLArticles.
+func parseHTMLFile(path string, strict bool) (articles []*Article, headerTitle string, headerFound bool, err error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, "", false, fmt.Errorf("LoadHTML: error %s", err)
+		return nil, "", false, fmt.Errorf("LoadHTML: %w", err)
 	}
 	doc := soup.HTMLParse(string(data))
 	if headerEl := doc.Find("header"); headerEl.Error == nil {
@@ -87,32 +133,68 @@ func parseHTMLArticles(path string) (articles []string, headerTitle string, head
 		headerFound = true
 	}
 	for _, article := range doc.FindAll("article") {
-		articles = append(articles, article.HTML())
+		// Stripped before the attributes are read, so that no field of the
+		// Article keeps them.
+		stripped := stripInvalidChars(article.Pointer)
+		a := newArticle(article)
+		a.Stripped = stripped
+		// Drafts are not built, so their dates may still be placeholders.
+		if err := a.normalizeDates(); err != nil && !a.Draft {
+			return nil, "", false, fmt.Errorf("LoadHTML: %s: %w", path, err)
+		}
+		if strict && len(stripped) > 0 && !a.Draft {
+			return nil, "", false, fmt.Errorf("LoadHTML: %s: article %q: %s: %w", path, a.UID, a.StrippedReport(), ErrInvalidChars)
+		}
+		articles = append(articles, a)
 	}
 	return articles, headerTitle, headerFound, nil
 }
 
-// LoadHTML reads the HTML file at EntriesHTMLPath, extracts the <header> title
-// and all <article> elements into ArticlesSet. If BaseEntriesHTMLPath is also
-// set, that file is read and its articles are appended after the primary set.
+// LoadHTML reads the HTML file at EntriesHTMLPath and its fragment directory,
+// extracts the <header> title and all <article> elements, parsed into Article
+// values. Articles from FallbackEntriesHTMLPaths that are not already present
+// follow; if BaseEntriesHTMLPath is also set, its articles are appended last.
+// Every one of these paths is read with its fragment directory.  Finding no
+// article at all is an error wrapping ErrNoArticles.
 //
 // HeaderTitle is populated only when a <header> element is present; it is left
 // unchanged (empty string on first call) when the element is absent. soup's
 // Find() returns a Root with a non-nil Error when the element isn't found;
 // calling FullText() on such a Root would panic, so the Error is checked first.
 func (f *Feed) LoadHTML() error {
-	articles, headerTitle, headerFound, err := parseHTMLArticles(f.EntriesHTMLPath)
+	articles, headerTitle, headerFound, err := parseHTMLArticles(f.EntriesHTMLPath, f.StrictChars)
 	if err != nil {
 		return err
 	}
 	if headerFound {
 		f.HeaderTitle = headerTitle
 	}
-	f.ArticlesSet = append(f.ArticlesSet, articles...)
+	f.articles = append(f.articles, articles...)
+	if len(f.FallbackEntriesHTMLPaths) > 0 {
+		seen := make(map[string]bool, len(f.articles))
+		for _, a := range f.articles {
+			seen[a.UID] = true
+		}
+		for _, path := range f.FallbackEntriesHTMLPaths {
+			fbArticles, fbTitle, fbHeaderFound, err := parseHTMLArticles(path, f.StrictChars)
+			if err != nil {
+				return err
+			}
+			if fbHeaderFound && f.HeaderTitle == "" {
+				f.HeaderTitle = fbTitle
+			}
+			for _, a := range fbArticles {
+				if !seen[a.UID] {
+					seen[a.UID] = true
+					f.articles = append(f.articles, a)
+				}
+			}
+		}
+	}
 	if f.BaseEntriesHTMLPath == "" {
-		return nil
+		return f.checkArticles()
 	}
-	baseArticles, baseTitle, baseHeaderFound, err := parseHTMLArticles(f.BaseEntriesHTMLPath)
+	baseArticles, baseTitle, baseHeaderFound, err := parseHTMLArticles(f.BaseEntriesHTMLPath, f.StrictChars)
 	if err != nil {
 		return err
 	}
@@ -122,75 +204,221 @@ func (f *Feed) LoadHTML() error {
 	if baseHeaderFound && f.HeaderTitle == "" {
 		f.HeaderTitle = baseTitle
 	}
-	f.ArticlesSet = append(f.ArticlesSet, baseArticles...)
+	f.articles = append(f.articles, baseArticles...)
+	return f.checkArticles()
+}
+
+// checkArticles returns an error wrapping ErrNoArticles when LoadHTML found
+// no article in any of the entries files.
+func (f *Feed) checkArticles() error {
+	if len(f.articles) == 0 {
+		return fmt.Errorf("LoadHTML: %s: %w", f.EntriesHTMLPath, ErrNoArticles)
+	}
 	return nil
 }
 
-// Length returns the number of articles loaded from the entries HTML.
+// Length returns the number of articles loaded from the entries HTML, plus
+// any set directly in ArticlesSet.
 func (f *Feed) Length() int {
-	return len(f.ArticlesSet)
+	return len(f.articles) + len(f.ArticlesSet)
 }
 
-// Article parses the HTML of ArticlesSet[index] and returns a new Article
-// populated with the attributes and summary text of that element.
+// Article returns the article at index, counting the loaded articles first
+// and then ArticlesSet.  Loaded articles are returned as parsed by LoadHTML;
+// an ArticlesSet entry is parsed on every call.
 func (f *Feed) Article(index int) *Article {
-	html := soup.HTMLParse(f.ArticlesSet[index])
-	articleData := html.Find("article").Attrs()
-	articleSummary := html.Find("details").Find("summary").FullText()
+	if index < len(f.articles) {
+		return f.articles[index]
+	}
+	return parseArticle(f.ArticlesSet[index-len(f.articles)])
+}
+
+// Articles returns an iterator over the articles in Article order.  Unlike
+// looping over Article by index it parses each ArticlesSet entry only when
+// the iteration reaches it, and keeps no parsed copy afterwards.
+func (f *Feed) Articles() iter.Seq[*Article] {
+	return func(yield func(*Article) bool) {
+		for _, a := range f.articles {
+			if !yield(a) {
+				return
+			}
+		}
+		for _, raw := range f.ArticlesSet {
+			if !yield(parseArticle(raw)) {
+				return
+			}
+		}
+	}
+}
+
+// parseArticle parses the HTML of a single <article> element.
+func parseArticle(articleHTML string) *Article {
+	return newArticle(soup.HTMLParse(articleHTML).Find("article"))
+}
+
+// newArticle returns a new Article populated with the attributes, summary
+// text and content of the parsed <article> element el.  The content is
+// rendered at once rather than on demand, so that the Article keeps no
+// pointer into the parse tree and the tree can be freed once parsing is done.
+func newArticle(el soup.Root) *Article {
+	articleData := el.Attrs()
+	// An article without a metadata <details> block, or one without a
+	// <summary>, is given an empty summary.
+	var articleSummary string
+	if summary := childElement(metadataDetails(el.Pointer), "summary"); summary != nil {
+		articleSummary = soup.Root{Pointer: summary, NodeValue: summary.Data}.FullText()
+	}
 	return &Article{
 		UID:           articleData["id"],
 		Title:         articleData["title"],
 		Link:          articleData["href"],
 		Author:        articleData["author"],
+		AuthorEmail:   strings.TrimSpace(articleData["author-email"]),
+		AuthorURI:     strings.TrimSpace(articleData["author-uri"]),
 		PublishedDate: articleData["published"],
 		UpdatedDate:   articleData["updated"],
 		Summary:       articleSummary,
-		content:       html.HTML(),
+		Draft:         isDraft(articleData["draft"]),
+		Expires:       strings.TrimSpace(articleData["expires"]),
+		License:       strings.TrimSpace(articleData["license"]),
+		LicenseURI:    strings.TrimSpace(articleData["license-uri"]),
+		Extensions:    extensionsOf(el.Pointer),
+		Notes:         EditorialNotes(el.Pointer),
+		body:          articleBody(el.Pointer),
+		rendered:      true,
+	}
+}
+
+// metadataDetails returns the <details> block holding the summary of the
+// <article> element n: its first <details> child.  Further <details> blocks,
+// and any nested deeper in the body, are part of the content.  It returns nil
+// when n is nil or has no such child.
+func metadataDetails(n *html.Node) *html.Node {
+	return childElement(n, "details")
+}
+
+// childElement returns the first direct child of n that is a tag element, or
+// nil when n is nil or has none.
+func childElement(n *html.Node, tag string) *html.Node {
+	if n == nil {
+		return nil
 	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && child.Data == tag {
+			return child
+		}
+	}
+	return nil
+}
+
+// isDraft reports whether the value of an <article> draft attribute marks the
+// article as a draft.  Only "true" (case-insensitive, surrounding whitespace
+// ignored) enables draft mode; an absent attribute or any other value leaves
+// the article published so that a typo never silently hides a live entry.
+func isDraft(attr string) bool {
+	return strings.EqualFold(strings.TrimSpace(attr), "true")
 }
 
 // Article holds the metadata and HTML content of a single Atom feed entry,
 // extracted from an <article> element in the entries HTML source.
 type Article struct {
-	UID           string
-	Title         string
-	Link          string
-	Author        string
+	UID    string
+	Title  string
+	Link   string
+	Author string
+	// AuthorEmail and AuthorURI come from the optional author-email and
+	// author-uri attributes and fill the <email> and <uri> children of the
+	// Atom <author> construct.  Empty values are omitted from the output.
+	AuthorEmail   string
+	AuthorURI     string
 	PublishedDate string
 	UpdatedDate   string
 	Summary       string
-	// content holds the raw HTML of the article element as parsed from the entries HTML source.
-	// Content() extracts the body by skipping the wrapping <article> and <details>/<summary> nodes.
+	// Draft is true when the <article> element carries draft="true".  Draft
+	// articles are parsed like any other article so they can be inspected and
+	// reported, but NewsBuilder omits them from the built feed unless its
+	// IncludeDrafts field is set.
+	Draft bool
+	// Expires is the raw value of the optional expires attribute: a date
+	// (2006-01-02) or timestamp after which NewsBuilder drops the article from
+	// the built feed.  The article stays in the entries HTML for history.
+	// Empty when the attribute is absent.
+	Expires string
+	// License and LicenseURI come from the optional license and license-uri
+	// attributes: the rights statement of the article, e.g. "CC BY 4.0",
+	// emitted as the entry's <rights>, and the URL of its license, emitted
+	// as a rel="license" link.  They override the feed-level rights of
+	// NewsBuilder for this entry.  Empty values are omitted from the output.
+	License    string
+	LicenseURI string
+	// Extensions holds the raw Atom extension elements of the optional
+	// <script type="application/atom+xml"> children, copied verbatim into the
+	// <entry> by Entry.  Check them with ValidateExtensions first.  Empty
+	// when the article has none.
+	Extensions string
+	// Notes holds the editorial notes of the article: the text of the HTML
+	// comments inside it (see EditorialNotes).  Content leaves them out, so
+	// they never reach the feeds.
+	Notes []string
+	// Stripped lists the characters illegal in XML 1.0 LoadHTML removed
+	// from the article, ordered by code point; nil when there were none.
+	Stripped []StrippedChar
+	// PageURL, when set, is the URL of the entry on an HTML entries page,
+	// anchor included (see EntryAnchor).  Entry advertises it with a
+	// rel="alternate" type="text/html" link next to the article's own link.
+	PageURL string
+	// body is the content of the article, rendered by newArticle; it is
+	// valid when rendered is set.
+	body     string
+	rendered bool
+	// content holds raw article HTML for Articles built without a parsed
+	// element; Content() parses it when rendered is not set.
 	content string
 }
 
 // Content returns the HTML body of the article by walking the direct children
 // of the <article> element and skipping the <details>/<summary> metadata block
-// (whose text is already stored in Article.Summary). This replaces the old
-// magic-number approach (skip first 5 nodes) which silently dropped content for
-// any article that did not use the <details>/<summary> idiom.
+// (whose text is already stored in Article.Summary; see metadataDetails) and
+// HTML comments, which are editorial notes (see Article.Notes). This replaces
+// the old magic-number approach (skip first 5 nodes) which silently dropped
+// content for any article that did not use the <details>/<summary> idiom.
 //
 // If no <article> element is found in the stored HTML, Content logs the
 // problem and returns an empty string so the issue is visible at build time.
 func (a *Article) Content() string {
-	doc := soup.HTMLParse(a.content)
-	article := doc.Find("article")
+	if a.rendered {
+		return a.body
+	}
+	article := soup.HTMLParse(a.content).Find("article")
 	if article.Error != nil {
 		// Emit a build-time warning so operators see missing content immediately
 		// instead of silently receiving an empty <content> Atom element.
 		log.Printf("Content: no <article> element found in stored HTML; content will be empty")
 		return ""
 	}
+	return articleBody(article.Pointer)
+}
 
+// articleBody renders the content of the <article> element node; see
+// Content.
+func articleBody(node *html.Node) string {
 	var buf bytes.Buffer
-	// Walk direct children of <article>. The <details> element holds only the
-	// <summary> text that is already captured in Article.Summary; skip it so
-	// it does not appear twice (once in <summary> and once in <content>).
-	for node := article.Pointer.FirstChild; node != nil; node = node.NextSibling {
-		if node.Type == html.ElementNode && node.Data == "details" {
+	// Walk direct children of <article>. The metadata <details> element holds
+	// only the <summary> text that is already captured in Article.Summary;
+	// skip it so it does not appear twice (once in <summary> and once in
+	// <content>).  Any further <details> blocks are body content and kept.
+	meta := metadataDetails(node)
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child == meta {
 			continue
 		}
-		if err := html.Render(&buf, node); err != nil {
+		// Extension scripts belong in the <entry>, not in its content.
+		if isExtensionsScript(child) {
+			continue
+		}
+		// Comments are editorial notes (see EditorialNotes) and never
+		// published.
+		if err := RenderWithoutComments(&buf, child); err != nil {
 			log.Printf("Content: html.Render error: %v", err)
 		}
 	}
@@ -202,23 +430,78 @@ func (a *Article) Content() string {
 	return toXHTML(buf.String())
 }
 
+// authorElement returns the Atom <author> construct for the article.  The
+// name child is always present, as RFC 4287 §3.2 requires; email and uri are
+// added only when the corresponding attribute was set.
+func (a *Article) authorElement() string {
+	str := "<author><name>" + xmlEsc(a.Author) + "</name>"
+	if a.AuthorEmail != "" {
+		str += "<email>" + xmlEsc(a.AuthorEmail) + "</email>"
+	}
+	if a.AuthorURI != "" {
+		str += "<uri>" + xmlEsc(a.AuthorURI) + "</uri>"
+	}
+	return str + "</author>"
+}
+
 // Entry renders the Article as an Atom <entry> XML fragment. All metadata
 // fields are XML-escaped; the XHTML body from Content() is embedded verbatim
 // inside a <content type="xhtml"> element and must not be double-escaped.
+// The link to the entries page, the rights of the article, if any, and then
+// its extensions follow the content; the extensions are copied unchanged.
 func (a *Article) Entry() string {
 	// All text and attribute values are XML-escaped via xmlEsc so that special
 	// characters such as '&' in URLs (?a=1&b=2) or '<' in titles do not
 	// produce malformed XML.  Content() returns raw XHTML embedded inside
 	// <content type="xhtml"> and must NOT be escaped — it is parsed as markup.
-	return fmt.Sprintf(
-		"<entry>\n\t<id>%s</id>\n\t<title>%s</title>\n\t<updated>%s</updated>\n\t<author><name>%s</name></author>\n\t<link href=\"%s\" rel=\"alternate\"/>\n\t<published>%s</published>\n\t<summary>%s</summary>\n\t<content type=\"xhtml\">\n\t\t<div xmlns=\"http://www.w3.org/1999/xhtml\">\n\t\t%s\n\t\t</div>\n\t</content>\n</entry>",
+	entry := fmt.Sprintf(
+		"<entry>\n\t<id>%s</id>\n\t<title>%s</title>\n\t<updated>%s</updated>\n\t%s\n\t<link href=\"%s\" rel=\"alternate\"/>\n\t<published>%s</published>\n\t<summary>%s</summary>\n\t<content type=\"xhtml\">\n\t\t<div xmlns=\"http://www.w3.org/1999/xhtml\">\n\t\t%s\n\t\t</div>\n\t</content>",
 		xmlEsc(a.UID),
 		xmlEsc(a.Title),
 		xmlEsc(a.UpdatedDate),
-		xmlEsc(a.Author),
+		a.authorElement(),
 		xmlEsc(a.Link),
 		xmlEsc(a.PublishedDate),
 		xmlEsc(a.Summary),
 		a.Content(), // raw XHTML — embedded markup, must not be double-escaped
 	)
+	if a.PageURL != "" {
+		entry += "\n\t<link href=\"" + xmlEsc(a.PageURL) + "\" rel=\"alternate\" type=\"text/html\"/>"
+	}
+	if a.License != "" {
+		entry += "\n\t<rights>" + xmlEsc(a.License) + "</rights>"
+	}
+	if a.LicenseURI != "" {
+		entry += "\n\t<link href=\"" + xmlEsc(a.LicenseURI) + "\" rel=\"license\"/>"
+	}
+	if a.Extensions != "" {
+		entry += "\n\t" + a.Extensions
+	}
+	return entry + "\n</entry>"
+}
+
+// EntryAnchor returns the fragment identifier of the entry with Atom id id on
+// an HTML entries page: "entry-" followed by id with every run of characters
+// other than ASCII letters, digits and '_' replaced by a single '-', e.g.
+// "entry-urn-uuid-1b4e28ba" for "urn:uuid:1b4e28ba".  Trailing runs are
+// dropped, and an id without such characters yields "entry".  It depends on
+// the id alone, so links to it survive rebuilds and hold for every
+// translation.
+func EntryAnchor(id string) string {
+	var b strings.Builder
+	b.WriteString("entry")
+	dash := true
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			if dash {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	return b.String()
 }
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -128,6 +129,31 @@ func TestContent_StandardArticle_ExcludesDetails(t *testing.T) {
 	}
 }
 
+// TestContent_ExcludesComments verifies that HTML comments, top-level or
+// nested, are editorial notes: kept in Notes and never rendered by Content.
+func TestContent_ExcludesComments(t *testing.T) {
+	a := parseArticle(`<article id="urn:test:notes">
+<details><summary>Summary</summary></details>
+<!-- translators: "floodfill" is a router role, keep it untranslated -->
+<p>Body <!-- check the link before release --><a href="http://example.i2p/">link</a></p>
+<!-- -->
+</article>`)
+	got := a.Content()
+	if strings.Contains(got, "<!--") || strings.Contains(got, "floodfill") {
+		t.Errorf("Content() leaked a comment; got: %q", got)
+	}
+	if !strings.Contains(got, `Body <a href="http://example.i2p/">link</a>`) {
+		t.Errorf("Content() dropped the body around a comment; got: %q", got)
+	}
+	want := []string{`translators: "floodfill" is a router role, keep it untranslated`, "check the link before release"}
+	if !reflect.DeepEqual(a.Notes, want) {
+		t.Errorf("Notes = %q, want %q", a.Notes, want)
+	}
+	if again := a.Content(); again != got {
+		t.Errorf("second Content() = %q, want %q; the article must not be modified", again, got)
+	}
+}
+
 // TestContent_MultipleBodyElements verifies that Content() returns all body
 // elements when the article has multiple paragraphs after <details>.
 func TestContent_MultipleBodyElements(t *testing.T) {
@@ -148,6 +174,70 @@ func TestContent_MultipleBodyElements(t *testing.T) {
 	}
 }
 
+// TestArticle_MultipleDetails verifies that only the first <details> child
+// of an article is read as its summary and left out of the content, while
+// further and nested <details> blocks stay in the content and never leak into
+// the summary.
+func TestArticle_MultipleDetails(t *testing.T) {
+	tests := []struct {
+		name         string
+		html         string
+		summary      string
+		content      []string
+		notInContent []string
+	}{
+		{
+			name: "second details block is content",
+			html: `<article id="a"><details><summary>The summary</summary></details>
+<p>Body</p>
+<details><summary>Changelog</summary><ul><li>fix</li></ul></details></article>`,
+			summary:      "The summary",
+			content:      []string{"Body", "<summary>Changelog</summary>", "<li>fix</li>"},
+			notInContent: []string{"The summary"},
+		},
+		{
+			name: "details nested in the body is content",
+			html: `<article id="a"><details><summary>The summary</summary></details>
+<div><details><summary>Nested</summary><p>Hidden text</p></details></div></article>`,
+			summary:      "The summary",
+			content:      []string{"<summary>Nested</summary>", "Hidden text"},
+			notInContent: []string{"The summary"},
+		},
+		{
+			name:    "no metadata details: nested details is not the summary",
+			html:    `<article id="a"><div><details><summary>Nested</summary></details></div></article>`,
+			summary: "",
+			content: []string{"<summary>Nested</summary>"},
+		},
+		{
+			name:         "summary ignores details nested in the metadata block",
+			html:         `<article id="a"><details><summary>Outer <b>bold</b></summary><details><summary>Inner</summary></details></details><p>Body</p></article>`,
+			summary:      "Outer bold",
+			content:      []string{"Body"},
+			notInContent: []string{"Outer", "Inner"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := parseArticle(tt.html)
+			if a.Summary != tt.summary {
+				t.Errorf("Summary = %q, want %q", a.Summary, tt.summary)
+			}
+			got := a.Content()
+			for _, want := range tt.content {
+				if !strings.Contains(got, want) {
+					t.Errorf("Content() missing %q; got: %q", want, got)
+				}
+			}
+			for _, unwanted := range tt.notInContent {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("Content() contains %q; got: %q", unwanted, got)
+				}
+			}
+		})
+	}
+}
+
 // TestContent_EmptyBody verifies that Content() returns an empty string when
 // the article has only a <details> block and no other children.
 func TestContent_EmptyBody(t *testing.T) {
@@ -459,3 +549,250 @@ func TestEntry_XHTMLVoidElements_WellFormedXML(t *testing.T) {
 		}
 	}
 }
+
+// TestArticle_DraftAttribute verifies that draft="true" on an <article> is
+// surfaced as Article.Draft, and that absent or non-"true" values are not.
+func TestArticle_DraftAttribute(t *testing.T) {
+	tests := []struct {
+		name string
+		attr string
+		want bool
+	}{
+		{"absent", ``, false},
+		{"true", ` draft="true"`, true},
+		{"mixed case", ` draft="True"`, true},
+		{"false", ` draft="false"`, false},
+		{"typo", ` draft="yes"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &Feed{ArticlesSet: []string{
+				`<article id="urn:d" title="T"` + tt.attr + `><details><summary>S</summary></details><p>B</p></article>`,
+			}}
+			if got := f.Article(0).Draft; got != tt.want {
+				t.Errorf("Article(0).Draft = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestArticle_ExpiresAttribute verifies that the expires attribute is carried
+// through to Article.Expires with surrounding whitespace removed.
+func TestArticle_ExpiresAttribute(t *testing.T) {
+	f := &Feed{ArticlesSet: []string{
+		`<article id="urn:e" title="T" expires=" 2024-03-01 "><details><summary>S</summary></details><p>B</p></article>`,
+		`<article id="urn:n" title="T"><details><summary>S</summary></details><p>B</p></article>`,
+	}}
+	if got := f.Article(0).Expires; got != "2024-03-01" {
+		t.Errorf("Article(0).Expires = %q, want %q", got, "2024-03-01")
+	}
+	if got := f.Article(1).Expires; got != "" {
+		t.Errorf("Article(1).Expires = %q, want empty", got)
+	}
+}
+
+// TestEntry_AuthorEmailAndURI verifies that author-email and author-uri are
+// emitted as escaped <email> and <uri> children of <author>, and that they
+// are omitted when the attributes are absent.
+func TestEntry_AuthorEmailAndURI(t *testing.T) {
+	f := &Feed{ArticlesSet: []string{
+		`<article id="1" title="A" href="http://example.com" author="B" author-email="b@example.i2p" author-uri="http://example.i2p/?a=1&amp;b=2" published="2024-01-01" updated="2024-01-02"><details><summary>S</summary></details><p>x</p></article>`,
+		`<article id="2" title="C" href="http://example.com" author="D" published="2024-01-01" updated="2024-01-02"><details><summary>S</summary></details><p>y</p></article>`,
+	}}
+	full := f.Article(0).Entry()
+	want := "<author><name>B</name><email>b@example.i2p</email><uri>http://example.i2p/?a=1&amp;b=2</uri></author>"
+	if !strings.Contains(full, want) {
+		t.Errorf("Entry() missing full author construct %q; got:\n%s", want, full)
+	}
+	plain := f.Article(1).Entry()
+	if !strings.Contains(plain, "<author><name>D</name></author>") {
+		t.Errorf("Entry() should emit name-only author when email/uri are absent; got:\n%s", plain)
+	}
+}
+
+// TestEntry_License verifies that the license and license-uri attributes are
+// emitted as the entry's <rights> and rel="license" link, and omitted when
+// absent.
+func TestEntry_License(t *testing.T) {
+	f := &Feed{ArticlesSet: []string{
+		`<article id="1" title="A" href="" author="B" license="CC BY 4.0 &amp; more" license-uri="https://creativecommons.org/licenses/by/4.0/" published="2024-01-01" updated="2024-01-02"><p>x</p></article>`,
+		`<article id="2" title="C" href="" author="D" published="2024-01-01" updated="2024-01-02"><p>y</p></article>`,
+	}}
+	full := f.Article(0).Entry()
+	for _, want := range []string{
+		"<rights>CC BY 4.0 &amp; more</rights>",
+		`<link href="https://creativecommons.org/licenses/by/4.0/" rel="license"/>`,
+	} {
+		if !strings.Contains(full, want) {
+			t.Errorf("Entry() missing %s; got:\n%s", want, full)
+		}
+	}
+	if plain := f.Article(1).Entry(); strings.Contains(plain, "rights") || strings.Contains(plain, "license") {
+		t.Errorf("Entry() of an article without a license carries one:\n%s", plain)
+	}
+}
+
+// TestEntry_PageURL verifies that an article with a PageURL links to it with
+// a text/html alternate link beside its own link, and one without does not.
+func TestEntry_PageURL(t *testing.T) {
+	a := &Article{UID: "1", Title: "A", Link: "http://example.com/a", content: "<article><p>x</p></article>"}
+	if strings.Contains(a.Entry(), "text/html") {
+		t.Errorf("Entry() without PageURL links a page:\n%s", a.Entry())
+	}
+	a.PageURL = "http://mirror.i2p/news.html?a=1&b=2#entry-1"
+	entry := a.Entry()
+	for _, want := range []string{
+		`<link href="http://example.com/a" rel="alternate"/>`,
+		`<link href="http://mirror.i2p/news.html?a=1&amp;b=2#entry-1" rel="alternate" type="text/html"/>`,
+	} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("Entry() missing %s; got:\n%s", want, entry)
+		}
+	}
+}
+
+// TestEntryAnchor verifies that anchors keep the letters, digits and
+// underscores of the id and collapse everything else into single dashes.
+func TestEntryAnchor(t *testing.T) {
+	for id, want := range map[string]string{
+		"urn:uuid:1B4E28BA-2fa1-11d2": "entry-urn-uuid-1B4E28BA-2fa1-11d2",
+		"tag:example.org,2025:news/1": "entry-tag-example-org-2025-news-1",
+		"  a__b::":                    "entry-a__b",
+		"":                            "entry",
+		"::":                          "entry",
+		"über-1":                      "entry-ber-1",
+	} {
+		if got := EntryAnchor(id); got != want {
+			t.Errorf("EntryAnchor(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+// TestLoadHTML_FallbackEntries verifies that fallback files contribute only
+// articles missing from the primary file, ahead of the base file, and supply
+// the header title when the primary has none.
+func TestLoadHTML_FallbackEntries(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("<html><body>"+body+"</body></html>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	art := func(id, title string) string {
+		return `<article id="` + id + `" title="` + title + `" href="" author="" published="" updated=""><details><summary>S</summary></details></article>`
+	}
+	f := &Feed{
+		EntriesHTMLPath:          write("entries.es_AR.html", art("1", "regional")),
+		FallbackEntriesHTMLPaths: []string{write("entries.es.html", "<header>Noticias</header>"+art("1", "base language")+art("2", "base language"))},
+		BaseEntriesHTMLPath:      write("entries.html", art("3", "english")),
+	}
+	if err := f.LoadHTML(); err != nil {
+		t.Fatalf("LoadHTML: %v", err)
+	}
+	var got []string
+	for i := 0; i < f.Length(); i++ {
+		a := f.Article(i)
+		got = append(got, a.UID+":"+a.Title)
+	}
+	if want := "1:regional 2:base language 3:english"; strings.Join(got, " ") != want {
+		t.Errorf("articles = %q, want %q", strings.Join(got, " "), want)
+	}
+	if f.HeaderTitle != "Noticias" {
+		t.Errorf("HeaderTitle = %q, want fallback header", f.HeaderTitle)
+	}
+}
+
+// TestLoadHTML_FragmentDir verifies that the articles of entries.d follow
+// those of entries.html in filename order, that non-HTML and dot files are
+// ignored, and that a fragment directory alone is enough.
+func TestLoadHTML_FragmentDir(t *testing.T) {
+	dir := t.TempDir()
+	art := func(id string) string {
+		return `<html><body><article id="` + id + `" title="T" href="" author="" published="" updated=""><details><summary>S</summary></details></article></body></html>`
+	}
+	fragments := filepath.Join(dir, "entries.d")
+	if err := os.MkdirAll(fragments, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, id := range map[string]string{"020-b.html": "b", "010-a.html": "a", ".010-a.html.swp": "swap", "README.txt": "readme"} {
+		if err := os.WriteFile(filepath.Join(fragments, name), []byte(art(id)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := filepath.Join(dir, "entries.html")
+	load := func() string {
+		t.Helper()
+		f := &Feed{EntriesHTMLPath: entries}
+		if err := f.LoadHTML(); err != nil {
+			t.Fatalf("LoadHTML: %v", err)
+		}
+		var got []string
+		for a := range f.Articles() {
+			got = append(got, a.UID)
+		}
+		return strings.Join(got, " ")
+	}
+	if got := load(); got != "a b" {
+		t.Errorf("fragments only: articles = %q, want %q", got, "a b")
+	}
+	if err := os.WriteFile(entries, []byte(art("main")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := load(); got != "main a b" {
+		t.Errorf("articles = %q, want %q", got, "main a b")
+	}
+	if !EntriesExist(filepath.Join(dir, "entries.html")) || EntriesExist(filepath.Join(dir, "entries.de.html")) {
+		t.Error("EntriesExist does not match the entries on disk")
+	}
+}
+
+// TestArticles_ParsedOnce verifies that Articles yields the loaded articles
+// followed by the ArticlesSet entries, that loaded articles are the values
+// parsed by LoadHTML rather than fresh copies, and that iteration stops
+// when the loop breaks.
+func TestArticles_ParsedOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.html")
+	body := `<html><body>
+<article id="1" title="one" href="" author="" published="" updated=""><details><summary>S</summary></details><p>first<br>body</p></article>
+<article id="2" title="two" href="" author="" published="" updated=""><details><summary>S</summary></details><p>second</p></article>
+</body></html>`
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := &Feed{EntriesHTMLPath: path}
+	if err := f.LoadHTML(); err != nil {
+		t.Fatalf("LoadHTML: %v", err)
+	}
+	f.ArticlesSet = []string{`<article id="3" title="three"><p>third</p></article>`}
+
+	var got []string
+	for a := range f.Articles() {
+		got = append(got, a.UID+":"+a.Title)
+	}
+	if want := "1:one 2:two 3:three"; strings.Join(got, " ") != want {
+		t.Errorf("Articles() = %q, want %q", strings.Join(got, " "), want)
+	}
+	if f.Length() != 3 {
+		t.Errorf("Length() = %d, want 3", f.Length())
+	}
+	if f.Article(0) != f.Article(0) {
+		t.Error("Article(0) returned a different value on each call; loaded articles must be parsed once")
+	}
+	if got := f.Article(0).Content(); got != "<p>first<br/>body</p>" {
+		t.Errorf("Content() = %q, want the parsed body", got)
+	}
+	if got := f.Article(2).Content(); got != "<p>third</p>" {
+		t.Errorf("Content() of ArticlesSet entry = %q, want <p>third</p>", got)
+	}
+
+	n := 0
+	for range f.Articles() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("loop ran %d times after break, want 1", n)
+	}
+}
@@ -0,0 +1,51 @@
+package newsfeed
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FragmentDir returns the fragment directory of the entries file at path:
+// "entries.d" for "entries.html" and "entries.de.d" for "entries.de.html".
+// Each .html file in it holds articles, typically one, kept apart from the
+// monolithic file so that every article has its own git history and can be
+// edited without conflicts.
+func FragmentDir(path string) string {
+	return strings.TrimSuffix(path, ".html") + ".d"
+}
+
+// FragmentPaths returns the .html files in the fragment directory of path in
+// filename order, or none when there is no fragment directory.
+func FragmentPaths(path string) ([]string, error) {
+	entries, err := os.ReadDir(FragmentDir(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		// Dot-files are editor temporaries, never articles.
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || filepath.Ext(e.Name()) != ".html" {
+			continue
+		}
+		paths = append(paths, filepath.Join(FragmentDir(path), e.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// EntriesExist reports whether there are entries at path: the file itself or
+// its fragment directory.
+func EntriesExist(path string) bool {
+	if _, err := os.Stat(path); err == nil {
+		return true
+	}
+	fi, err := os.Stat(FragmentDir(path))
+	return err == nil && fi.IsDir()
+}
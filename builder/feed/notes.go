@@ -0,0 +1,56 @@
+package newsfeed
+
+import (
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// EditorialNotes returns the text of the HTML comments inside the <article>
+// element n, trimmed, in document order.  Comments are editorial notes, e.g.
+// "<!-- keep the version in sync with releases.json -->": they never reach
+// the feeds (see RenderWithoutComments) but are exported to translators with
+// the article's units.  Empty comments are left out.
+func EditorialNotes(n *html.Node) []string {
+	if n == nil {
+		return nil
+	}
+	var notes []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type == html.CommentNode {
+				if note := strings.TrimSpace(c.Data); note != "" {
+					notes = append(notes, note)
+				}
+				continue
+			}
+			walk(c)
+		}
+	}
+	walk(n)
+	return notes
+}
+
+// RenderWithoutComments renders n to w like html.Render, leaving out every
+// HTML comment in it, so editorial notes are never published.  n itself is
+// not modified.
+func RenderWithoutComments(w io.Writer, n *html.Node) error {
+	if n.Type == html.CommentNode {
+		return nil
+	}
+	return html.Render(w, withoutComments(n))
+}
+
+// withoutComments returns a detached copy of n and its descendants without
+// comment nodes.
+func withoutComments(n *html.Node) *html.Node {
+	out := &html.Node{Type: n.Type, DataAtom: n.DataAtom, Data: n.Data, Namespace: n.Namespace, Attr: n.Attr}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.CommentNode {
+			out.AppendChild(withoutComments(c))
+		}
+	}
+	return out
+}
@@ -0,0 +1,151 @@
+// Package newsbuilder — feed identifier derivation.
+package newsbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBaseUUID returns the base feed UUID used when the operator has not
+// supplied one with --feeduri.  It is a UUIDv5 of mainFeedURL in the URL
+// namespace, so every build that points at the same primary feed URL derives
+// the same base — and therefore the same per-feed ids — across rebuilds.
+func DefaultBaseUUID(mainFeedURL string) string {
+	return uuid.NewSHA1(uuid.NameSpaceURL, []byte(mainFeedURL)).String()
+}
+
+// FeedUUID derives the Atom <id> UUID for one generated feed from the base
+// UUID and the feed's (platform, status, locale) coordinates.
+//
+// The canonical feed — default tree, English — keeps base unchanged so that
+// existing subscribers of news.atom.xml see no id change.  Every other feed
+// receives a UUIDv5 of base over the name "platform/status/locale", which is
+// stable across rebuilds and distinct for every combination.  An empty locale
+// is treated as "en"; status is ignored for the default tree because every
+// status of the default tree writes the same output file.
+//
+// An error is returned when base is not a valid UUID.
+func FeedUUID(base, platform, status, locale string) (string, error) {
+	ns, err := uuid.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("FeedUUID: invalid base UUID %q: %w", base, err)
+	}
+	name, canonical := feedIDName(platform, status, locale)
+	if canonical {
+		return ns.String(), nil
+	}
+	return uuid.NewSHA1(ns, []byte(name)).String(), nil
+}
+
+// feedIDName returns the "platform/status/locale" name FeedUUID derives the
+// id of a feed from, and whether the feed is the canonical one (default
+// tree, English), which keeps the base id unchanged.
+func feedIDName(platform, status, locale string) (name string, canonical bool) {
+	if locale == "" {
+		locale = "en"
+	}
+	if platform == "" {
+		if strings.EqualFold(locale, "en") {
+			return "", true
+		}
+		status = ""
+	}
+	return platform + "/" + status + "/" + locale, false
+}
+
+// Feed id strategies for IDStrategy.Kind.
+const (
+	// IDStrategyUUID5 gives every feed a urn:uuid: id derived from a base
+	// UUID with FeedUUID, stable across rebuilds.  It is the default.
+	IDStrategyUUID5 = "uuid5"
+	// IDStrategyUUIDRandom gives every feed a new random urn:uuid: id on
+	// every build.
+	IDStrategyUUIDRandom = "uuid-random"
+	// IDStrategyTagURI gives every feed a tag: URI (RFC 4151) minted by the
+	// operator, e.g. "tag:example.org,2025-01-01:news/mac/beta/de"; the
+	// canonical feed is plainly "...:news".
+	IDStrategyTagURI = "tag-uri"
+)
+
+// IDStrategy selects how the Atom <id> of each generated feed is formed.
+// Only feed ids are affected: every entry keeps the id attribute its author
+// gave the <article>.
+type IDStrategy struct {
+	// Kind is one of the IDStrategy* constants; empty means IDStrategyUUID5.
+	Kind string
+	// Base is the base UUID of IDStrategyUUID5; see FeedUUID.
+	Base string
+	// Authority and Date form the tagging entity of IDStrategyTagURI: a
+	// domain name or email address the operator controlled on Date, which is
+	// written 2006, 2006-01, or 2006-01-02.
+	Authority string
+	Date      string
+}
+
+// tagAuthorityRe matches the authority of a tag URI: a DNS name or an email
+// address (RFC 4151 §2.1).
+var tagAuthorityRe = regexp.MustCompile(`^([A-Za-z0-9._%+-]+@)?[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]*[A-Za-z0-9])?)*$`)
+
+// Validate reports whether s can form feed ids.
+func (s IDStrategy) Validate() error {
+	switch s.Kind {
+	case "", IDStrategyUUID5:
+		if _, err := uuid.Parse(s.Base); err != nil {
+			return fmt.Errorf("IDStrategy: invalid base UUID %q: %w", s.Base, err)
+		}
+	case IDStrategyUUIDRandom:
+	case IDStrategyTagURI:
+		if !tagAuthorityRe.MatchString(s.Authority) {
+			return fmt.Errorf("IDStrategy: tag authority %q is not a domain name or email address", s.Authority)
+		}
+		if !validTagDate(s.Date) {
+			return fmt.Errorf("IDStrategy: tag date %q: want 2006, 2006-01, or 2006-01-02", s.Date)
+		}
+	default:
+		return fmt.Errorf("IDStrategy: unknown strategy %q (want %q, %q, or %q)",
+			s.Kind, IDStrategyUUID5, IDStrategyUUIDRandom, IDStrategyTagURI)
+	}
+	return nil
+}
+
+// validTagDate reports whether date is a tag URI date.
+func validTagDate(date string) bool {
+	for _, layout := range []string{"2006", "2006-01", "2006-01-02"} {
+		if _, err := time.Parse(layout, date); err == nil && len(date) == len(layout) {
+			return true
+		}
+	}
+	return false
+}
+
+// FeedID returns the complete Atom id of the feed for (platform, status,
+// locale) under s, for NewsBuilder.FeedID.
+func (s IDStrategy) FeedID(platform, status, locale string) (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	switch s.Kind {
+	case IDStrategyUUIDRandom:
+		return "urn:uuid:" + uuid.NewString(), nil
+	case IDStrategyTagURI:
+		id := "tag:" + s.Authority + "," + s.Date + ":news"
+		if name, canonical := feedIDName(platform, status, locale); !canonical {
+			// The default tree has no platform or status: "news/de".
+			for _, part := range strings.Split(name, "/") {
+				if part != "" {
+					id += "/" + part
+				}
+			}
+		}
+		return id, nil
+	}
+	id, err := FeedUUID(s.Base, platform, status, locale)
+	if err != nil {
+		return "", err
+	}
+	return "urn:uuid:" + id, nil
+}
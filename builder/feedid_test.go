@@ -0,0 +1,142 @@
+package newsbuilder
+
+import (
+	"strings"
+	"testing"
+)
+
+const testBaseUUID = "6ba7b810-9dad-11d1-80b4-00c04fd430c8"
+
+// TestFeedUUID_CanonicalKeepsBase verifies that the default-tree English feed
+// keeps the base UUID so existing subscribers see no id change.
+func TestFeedUUID_CanonicalKeepsBase(t *testing.T) {
+	for _, locale := range []string{"", "en"} {
+		got, err := FeedUUID(testBaseUUID, "", "", locale)
+		if err != nil {
+			t.Fatalf("FeedUUID: %v", err)
+		}
+		if got != testBaseUUID {
+			t.Errorf("FeedUUID(base, \"\", \"\", %q) = %q; want base %q", locale, got, testBaseUUID)
+		}
+	}
+}
+
+// TestFeedUUID_StableAndDistinct verifies that derived ids are deterministic
+// across calls and differ for every (platform, status, locale) combination.
+func TestFeedUUID_StableAndDistinct(t *testing.T) {
+	coords := [][3]string{
+		{"", "", "de"},
+		{"mac", "stable", "en"},
+		{"mac", "beta", "en"},
+		{"mac", "stable", "de"},
+		{"win", "stable", "en"},
+	}
+	seen := map[string][3]string{testBaseUUID: {"", "", "en"}}
+	for _, c := range coords {
+		first, err := FeedUUID(testBaseUUID, c[0], c[1], c[2])
+		if err != nil {
+			t.Fatalf("FeedUUID(%v): %v", c, err)
+		}
+		second, _ := FeedUUID(testBaseUUID, c[0], c[1], c[2])
+		if first != second {
+			t.Errorf("FeedUUID(%v) not stable: %q then %q", c, first, second)
+		}
+		if prev, dup := seen[first]; dup {
+			t.Errorf("FeedUUID(%v) = %q collides with %v", c, first, prev)
+		}
+		seen[first] = c
+	}
+}
+
+// TestFeedUUID_DefaultTreeIgnoresStatus verifies that --status does not change
+// the id of default-tree feeds, which share one output file per locale.
+func TestFeedUUID_DefaultTreeIgnoresStatus(t *testing.T) {
+	a, _ := FeedUUID(testBaseUUID, "", "", "de")
+	b, _ := FeedUUID(testBaseUUID, "", "stable", "de")
+	if a != b {
+		t.Errorf("default-tree de feed id depends on status: %q vs %q", a, b)
+	}
+}
+
+// TestFeedUUID_InvalidBase verifies that a non-UUID base is rejected.
+func TestFeedUUID_InvalidBase(t *testing.T) {
+	if _, err := FeedUUID("not-a-uuid", "mac", "stable", "en"); err == nil {
+		t.Fatal("expected error for invalid base UUID, got nil")
+	}
+}
+
+// TestDefaultBaseUUID_Deterministic verifies that the fallback base depends
+// only on the main feed URL.
+func TestDefaultBaseUUID_Deterministic(t *testing.T) {
+	a := DefaultBaseUUID("http://example.i2p/news.atom.xml")
+	b := DefaultBaseUUID("http://example.i2p/news.atom.xml")
+	c := DefaultBaseUUID("http://other.i2p/news.atom.xml")
+	if a != b {
+		t.Errorf("DefaultBaseUUID not deterministic: %q vs %q", a, b)
+	}
+	if a == c {
+		t.Errorf("DefaultBaseUUID ignores the feed URL: both %q", a)
+	}
+}
+
+// TestIDStrategy_FeedID verifies the ids each strategy forms: uuid5 matches
+// FeedUUID, uuid-random differs on every call, and tag-uri follows RFC 4151
+// with the canonical feed named plainly "news".
+func TestIDStrategy_FeedID(t *testing.T) {
+	uuid5 := IDStrategy{Base: testBaseUUID}
+	got, err := uuid5.FeedID("mac", "beta", "de")
+	if err != nil {
+		t.Fatalf("FeedID: %v", err)
+	}
+	want, _ := FeedUUID(testBaseUUID, "mac", "beta", "de")
+	if got != "urn:uuid:"+want {
+		t.Errorf("uuid5 FeedID = %q, want urn:uuid:%s", got, want)
+	}
+
+	random := IDStrategy{Kind: IDStrategyUUIDRandom}
+	a, _ := random.FeedID("", "", "en")
+	b, _ := random.FeedID("", "", "en")
+	if a == b || !strings.HasPrefix(a, "urn:uuid:") {
+		t.Errorf("uuid-random FeedID = %q then %q, want two different urn:uuid: ids", a, b)
+	}
+
+	tag := IDStrategy{Kind: IDStrategyTagURI, Authority: "example.org", Date: "2025-01-01"}
+	for _, tt := range []struct{ platform, status, locale, want string }{
+		{"", "", "en", "tag:example.org,2025-01-01:news"},
+		{"", "beta", "de", "tag:example.org,2025-01-01:news/de"},
+		{"mac", "beta", "de", "tag:example.org,2025-01-01:news/mac/beta/de"},
+	} {
+		if got, err := tag.FeedID(tt.platform, tt.status, tt.locale); err != nil || got != tt.want {
+			t.Errorf("tag-uri FeedID(%q, %q, %q) = %q, %v; want %q", tt.platform, tt.status, tt.locale, got, err, tt.want)
+		}
+	}
+}
+
+// TestIDStrategy_Validate verifies that strategies that cannot form valid
+// ids are rejected.
+func TestIDStrategy_Validate(t *testing.T) {
+	valid := []IDStrategy{
+		{Base: testBaseUUID},
+		{Kind: IDStrategyUUIDRandom},
+		{Kind: IDStrategyTagURI, Authority: "example.org", Date: "2025"},
+		{Kind: IDStrategyTagURI, Authority: "news@mail.i2p", Date: "2025-06"},
+	}
+	for _, s := range valid {
+		if err := s.Validate(); err != nil {
+			t.Errorf("Validate(%+v) = %v, want nil", s, err)
+		}
+	}
+	invalid := []IDStrategy{
+		{Base: "not-a-uuid"},
+		{Kind: "sequential"},
+		{Kind: IDStrategyTagURI, Date: "2025"},
+		{Kind: IDStrategyTagURI, Authority: "example.org"},
+		{Kind: IDStrategyTagURI, Authority: "example.org", Date: "2025-1-1"},
+		{Kind: IDStrategyTagURI, Authority: "exa mple.org", Date: "2025"},
+	}
+	for _, s := range invalid {
+		if err := s.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", s)
+		}
+	}
+}
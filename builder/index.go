@@ -0,0 +1,167 @@
+// Package newsbuilder — index of generated feeds.
+package newsbuilder
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Index file names written into BuildDir by WriteFeedIndex.
+const (
+	IndexOPMLName = "index.opml"
+	IndexHTMLName = "index.html"
+)
+
+// FeedIndexEntry describes one generated feed for the feed index.
+type FeedIndexEntry struct {
+	// Path is the feed location relative to BuildDir, e.g.
+	// "win/beta/news_de.atom.xml".  It is written with forward slashes.
+	Path string
+	// Platform and Status are empty for the default feed tree.
+	Platform string
+	Status   string
+	// Locale is the BCP 47 tag of the feed, e.g. "en" or "pt-BR".
+	Locale string
+}
+
+// channel returns "platform/status" for platform feeds and "default" for the
+// top-level tree.
+func (e FeedIndexEntry) channel() string {
+	if e.Platform == "" {
+		return "default"
+	}
+	return e.Platform + "/" + e.Status
+}
+
+// opmlDoc, opmlHead, and opmlOutline model the subset of OPML 2.0 written by
+// WriteFeedIndex.  Platform, status, and locale are carried as extra outline
+// attributes, which OPML explicitly permits, so mirrors can select feeds
+// without parsing their paths.
+type opmlDoc struct {
+	XMLName xml.Name      `xml:"opml"`
+	Version string        `xml:"version,attr"`
+	Head    opmlHead      `xml:"head"`
+	Body    []opmlOutline `xml:"body>outline"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated"`
+}
+
+type opmlOutline struct {
+	Type     string `xml:"type,attr"`
+	Text     string `xml:"text,attr"`
+	XMLURL   string `xml:"xmlUrl,attr"`
+	HTMLURL  string `xml:"htmlUrl,attr,omitempty"`
+	Language string `xml:"language,attr"`
+	Platform string `xml:"platform,attr,omitempty"`
+	Status   string `xml:"status,attr,omitempty"`
+	Category string `xml:"category,attr"`
+}
+
+// sortFeedIndex orders entries by channel (default tree first) and then by
+// locale, with "en" ahead of the translations, so the index is identical on
+// every rebuild regardless of the order the feeds were produced in.
+func sortFeedIndex(entries []FeedIndexEntry) []FeedIndexEntry {
+	out := append([]FeedIndexEntry(nil), entries...)
+	sort.SliceStable(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if (a.Platform == "") != (b.Platform == "") {
+			return a.Platform == ""
+		}
+		if a.channel() != b.channel() {
+			return a.channel() < b.channel()
+		}
+		if (a.Locale == "en") != (b.Locale == "en") {
+			return a.Locale == "en"
+		}
+		return a.Locale < b.Locale
+	})
+	return out
+}
+
+// FeedIndexOPML renders entries as an OPML 2.0 document.  title labels the
+// index and every outline; siteURL, when non-empty, becomes each outline's
+// htmlUrl.  xmlUrl values are the entry paths, relative to the index itself,
+// so the index stays valid wherever BuildDir is served or mirrored.
+func FeedIndexOPML(entries []FeedIndexEntry, title, siteURL string, created time.Time) ([]byte, error) {
+	doc := opmlDoc{
+		Version: "2.0",
+		Head:    opmlHead{Title: title, DateCreated: created.UTC().Format(time.RFC1123Z)},
+	}
+	for _, e := range sortFeedIndex(entries) {
+		doc.Body = append(doc.Body, opmlOutline{
+			Type:     "rss",
+			Text:     fmt.Sprintf("%s (%s, %s)", title, e.channel(), e.Locale),
+			XMLURL:   e.Path,
+			HTMLURL:  siteURL,
+			Language: e.Locale,
+			Platform: e.Platform,
+			Status:   e.Status,
+			Category: "/" + e.channel() + "/" + e.Locale,
+		})
+	}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("FeedIndexOPML: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// feedIndexTemplate is the human-readable counterpart of the OPML index.
+var feedIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Machine-readable index: <a href="` + IndexOPMLName + `">` + IndexOPMLName + `</a></p>
+<table>
+<tr><th>Platform</th><th>Status</th><th>Locale</th><th>Feed</th></tr>
+{{range .Entries}}<tr><td>{{if .Platform}}{{.Platform}}{{else}}default{{end}}</td><td>{{.Status}}</td><td>{{.Locale}}</td><td><a href="{{.Path}}">{{.Path}}</a></td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// FeedIndexHTML renders entries as a plain HTML table linking every feed.
+func FeedIndexHTML(entries []FeedIndexEntry, title string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := feedIndexTemplate.Execute(&buf, struct {
+		Title   string
+		Entries []FeedIndexEntry
+	}{title, sortFeedIndex(entries)})
+	if err != nil {
+		return nil, fmt.Errorf("FeedIndexHTML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFeedIndex writes IndexOPMLName and IndexHTMLName into buildDir listing
+// entries.  See FeedIndexOPML for the meaning of title and siteURL.
+func WriteFeedIndex(buildDir string, entries []FeedIndexEntry, title, siteURL string) error {
+	opml, err := FeedIndexOPML(entries, title, siteURL, time.Now())
+	if err != nil {
+		return err
+	}
+	page, err := FeedIndexHTML(entries, title)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(buildDir, IndexOPMLName), opml, 0o644); err != nil {
+		return fmt.Errorf("WriteFeedIndex: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(buildDir, IndexHTMLName), page, 0o644); err != nil {
+		return fmt.Errorf("WriteFeedIndex: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package newsbuilder
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func indexFixture() []FeedIndexEntry {
+	return []FeedIndexEntry{
+		{Path: "win/beta/news_de.atom.xml", Platform: "win", Status: "beta", Locale: "de"},
+		{Path: "news_de.atom.xml", Locale: "de"},
+		{Path: "win/beta/news.atom.xml", Platform: "win", Status: "beta", Locale: "en"},
+		{Path: "news.atom.xml", Locale: "en"},
+	}
+}
+
+// TestFeedIndexOPML_OutlinesAndMetadata verifies that every feed appears as an
+// outline carrying its platform/status/locale and that the order is stable:
+// default tree first, English first within a channel.
+func TestFeedIndexOPML_OutlinesAndMetadata(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	out, err := FeedIndexOPML(indexFixture(), "I2P News", "http://i2p-projekt.i2p", created)
+	if err != nil {
+		t.Fatalf("FeedIndexOPML: %v", err)
+	}
+	var doc opmlDoc
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("output is not valid XML: %v\n%s", err, out)
+	}
+	if doc.Version != "2.0" || doc.Head.Title != "I2P News" {
+		t.Errorf("version/title = %q/%q", doc.Version, doc.Head.Title)
+	}
+	wantPaths := []string{"news.atom.xml", "news_de.atom.xml", "win/beta/news.atom.xml", "win/beta/news_de.atom.xml"}
+	if len(doc.Body) != len(wantPaths) {
+		t.Fatalf("got %d outlines, want %d", len(doc.Body), len(wantPaths))
+	}
+	for i, want := range wantPaths {
+		if doc.Body[i].XMLURL != want {
+			t.Errorf("outline %d xmlUrl = %q, want %q", i, doc.Body[i].XMLURL, want)
+		}
+	}
+	last := doc.Body[3]
+	if last.Platform != "win" || last.Status != "beta" || last.Language != "de" || last.Category != "/win/beta/de" {
+		t.Errorf("win/beta/de outline = %+v", last)
+	}
+	if first := doc.Body[0]; first.Platform != "" || first.Category != "/default/en" || first.HTMLURL != "http://i2p-projekt.i2p" {
+		t.Errorf("default/en outline = %+v", first)
+	}
+}
+
+// TestFeedIndexHTML_EscapesAndLinks verifies that the HTML index links every
+// feed and escapes the title.
+func TestFeedIndexHTML_EscapesAndLinks(t *testing.T) {
+	out, err := FeedIndexHTML(indexFixture(), "News <&> Feeds")
+	if err != nil {
+		t.Fatalf("FeedIndexHTML: %v", err)
+	}
+	page := string(out)
+	if strings.Contains(page, "News <&> Feeds") {
+		t.Error("title was not HTML-escaped")
+	}
+	for _, e := range indexFixture() {
+		if !strings.Contains(page, `href="`+e.Path+`"`) {
+			t.Errorf("missing link to %s", e.Path)
+		}
+	}
+	if !strings.Contains(page, `href="`+IndexOPMLName+`"`) {
+		t.Error("missing link to the OPML index")
+	}
+}
+
+// TestWriteFeedIndex_WritesBothFiles verifies that WriteFeedIndex creates the
+// OPML and HTML indexes in the build directory.
+func TestWriteFeedIndex_WritesBothFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := WriteFeedIndex(dir, indexFixture(), "I2P News", ""); err != nil {
+		t.Fatalf("WriteFeedIndex: %v", err)
+	}
+	for _, name := range []string{IndexOPMLName, IndexHTMLName} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("read %s: %v", name, err)
+		}
+		if !strings.Contains(string(data), "win/beta/news_de.atom.xml") {
+			t.Errorf("%s does not list win/beta/news_de.atom.xml", name)
+		}
+	}
+}
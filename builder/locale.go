@@ -2,6 +2,7 @@
 package newsbuilder
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,8 +28,12 @@ import (
 //	LocaleFromPath("data/translations/entries.de.html") → "de"
 //	LocaleFromPath("data/translations/entries.pt_BR.html") → "pt-BR"
 //	LocaleFromPath("data/translations/entries.zh_TW.html") → "zh-TW"
+//	LocaleFromPath(`C:\news\data\entries.fr.html`)       → "fr"
 func LocaleFromPath(path string) string {
-	base := filepath.Base(path) // "entries.de.html"
+	// Split on either separator (see SlashPath) so a Windows path names the
+	// same file on every OS.
+	base := SlashPath(path)
+	base = base[strings.LastIndex(base, "/")+1:] // "entries.de.html"
 	parts := strings.SplitN(base, ".", 3)
 	// Must be exactly three dot-delimited segments: "entries", locale, "html".
 	if len(parts) != 3 || parts[0] != "entries" || parts[2] != "html" {
@@ -55,26 +60,97 @@ func LocaleFromPath(path string) string {
 // "entries.{locale}.html" file found directly inside dir (non-recursive).
 // Files whose base name does not match the three-segment pattern are silently
 // skipped, so other HTML files co-located in the same directory are never
-// mistaken for translation sources.  An empty or non-existent directory
-// returns nil without error — callers treat that as "no translations available".
+// mistaken for translation sources.  An "entries.{locale}.d" fragment
+// directory without a file next to it is returned as the path of that file,
+// which newsfeed.Feed reads the fragments of; see newsfeed.FragmentDir.  An
+// empty or non-existent directory returns nil without error — callers treat
+// that as "no translations available".
 func DetectTranslationFiles(dir string) []string {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil
 	}
 	var paths []string
+	seen := make(map[string]bool)
 	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
 		name := e.Name()
 		// Must match exactly "entries.{locale}.html" — three dot segments,
-		// first is "entries", last is "html".
+		// first is "entries", last is "html" — or "entries.{locale}.d" for
+		// a fragment directory.
 		parts := strings.SplitN(name, ".", 3)
-		if len(parts) != 3 || parts[0] != "entries" || parts[2] != "html" || parts[1] == "" {
+		if len(parts) != 3 || parts[0] != "entries" || parts[1] == "" {
+			continue
+		}
+		switch {
+		case !e.IsDir() && parts[2] == "html":
+		case e.IsDir() && parts[2] == "d":
+			name = "entries." + parts[1] + ".html"
+		default:
 			continue
 		}
-		paths = append(paths, filepath.Join(dir, name))
+		if !seen[name] {
+			seen[name] = true
+			paths = append(paths, filepath.Join(dir, name))
+		}
 	}
 	return paths
 }
+
+// ParseLocaleFallbacks parses "locale=fallback" specs such as "es-AR=es" or
+// "zh=zh-CN" into a map from locale to its next fallback.  Both sides are
+// normalised with LocaleFromPath's rules, so "pt_BR" and "pt-BR" are the
+// same key.  Chains are formed by listing each step: "es-AR=es" plus
+// "es=pt" gives es-AR → es → pt; English is always the final fallback and
+// need not be listed.  A locale may appear on the left only once.
+func ParseLocaleFallbacks(specs []string) (map[string]string, error) {
+	fallbacks := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "=")
+		from, to = normalizeLocale(from), normalizeLocale(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("ParseLocaleFallbacks: %q is not of the form locale=fallback", spec)
+		}
+		if from == to {
+			return nil, fmt.Errorf("ParseLocaleFallbacks: %q falls back to itself", spec)
+		}
+		if prev, dup := fallbacks[from]; dup {
+			return nil, fmt.Errorf("ParseLocaleFallbacks: %s has two fallbacks, %s and %s", from, prev, to)
+		}
+		fallbacks[from] = to
+	}
+	return fallbacks, nil
+}
+
+// normalizeLocale returns the canonical BCP 47 form of a locale written with
+// either separator, or "" for a blank value.
+func normalizeLocale(locale string) string {
+	raw := strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if raw == "" {
+		return ""
+	}
+	if tag, err := language.Parse(raw); err == nil {
+		return tag.String()
+	}
+	return raw
+}
+
+// LocaleFallbackChain returns the fallbacks of locale in order, following
+// fallbacks until a locale without one, English, or a cycle is reached.
+// locale itself and "en" are never included: English is the base feed that
+// every translation is already merged with.
+func LocaleFallbackChain(locale string, fallbacks map[string]string) []string {
+	var chain []string
+	seen := map[string]bool{locale: true}
+	for next, ok := fallbacks[locale]; ok && next != "en" && !seen[next]; next, ok = fallbacks[next] {
+		seen[next] = true
+		chain = append(chain, next)
+	}
+	return chain
+}
+
+// LocaleFileName returns the on-disk locale segment for locale as used in
+// "entries.{locale}.html" and "news_{locale}.atom.xml", e.g. "pt_BR" for
+// "pt-BR".
+func LocaleFileName(locale string) string {
+	return strings.ReplaceAll(locale, "-", "_")
+}
@@ -0,0 +1,171 @@
+// Package newsbuilder — boilerplate message catalog.
+package newsbuilder
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+)
+
+// Messages are the boilerplate strings newsgo generates around the news
+// itself: on entries pages, directory listings, landing pages, and stats
+// charts.  Articles are translated in entries.{locale}.html; these strings
+// come from the catalog instead, so a translated feed is not surrounded by
+// English.
+type Messages struct {
+	// Source labels the link to an article's source on entries pages.
+	Source string
+	// DirectoryListing heads the files of a directory listing.
+	DirectoryListing string
+	// TotalRequests and UpdatesHandled label the total bars of the stats
+	// charts.
+	TotalRequests  string
+	UpdatesHandled string
+	// LandingIntro introduces the feeds on the landing page.  It may hold
+	// markup.
+	LandingIntro template.HTML
+	// Language, Feed, Signed, and Details head the columns of the landing
+	// page's feed tables.
+	Language string
+	Feed     string
+	Signed   string
+	Details  string
+	// NoFeeds replaces the tables when no feed has been published.
+	NoFeeds string
+	// Downloads heads the download chart of the landing page, and
+	// DownloadsAlt is the chart's alternative text.
+	Downloads    string
+	DownloadsAlt string
+}
+
+// messageCatalog holds the Messages of each locale, in the normalised form of
+// LocaleFromPath.  Every entry is complete; a locale without an entry uses
+// its base language, or else English.
+var messageCatalog = map[string]Messages{
+	"en": {
+		Source:           "source",
+		DirectoryListing: "Directory Listing:",
+		TotalRequests:    "Total Requests",
+		UpdatesHandled:   "Approx. Updates Handled",
+		LandingIntro:     "Atom feeds of I2P news and router updates.  Routers fetch the signed\n<code>.su3</code> files; the <code>.atom.xml</code> feeds can be read with any\nfeed reader.",
+		Language:         "Language",
+		Feed:             "Feed",
+		Signed:           "Signed",
+		Details:          "Details",
+		NoFeeds:          "No feeds have been published yet.",
+		Downloads:        "Downloads",
+		DownloadsAlt:     "su3 downloads by language",
+	},
+	"de": {
+		Source:           "Quelle",
+		DirectoryListing: "Verzeichnisinhalt:",
+		TotalRequests:    "Anfragen insgesamt",
+		UpdatesHandled:   "Ausgelieferte Updates (ca.)",
+		LandingIntro:     "Atom-Feeds der I2P-Neuigkeiten und Router-Updates.  Router laden die\nsignierten <code>.su3</code>-Dateien; die <code>.atom.xml</code>-Feeds können mit\njedem Feedreader gelesen werden.",
+		Language:         "Sprache",
+		Feed:             "Feed",
+		Signed:           "Signiert",
+		Details:          "Details",
+		NoFeeds:          "Es wurden noch keine Feeds veröffentlicht.",
+		Downloads:        "Downloads",
+		DownloadsAlt:     "su3-Downloads nach Sprache",
+	},
+	"es": {
+		Source:           "fuente",
+		DirectoryListing: "Contenido del directorio:",
+		TotalRequests:    "Solicitudes totales",
+		UpdatesHandled:   "Actualizaciones servidas (aprox.)",
+		LandingIntro:     "Feeds Atom de las noticias de I2P y de las actualizaciones del router.  Los\nrouters descargan los archivos <code>.su3</code> firmados; los feeds\n<code>.atom.xml</code> se pueden leer con cualquier lector de feeds.",
+		Language:         "Idioma",
+		Feed:             "Feed",
+		Signed:           "Firmado",
+		Details:          "Detalles",
+		NoFeeds:          "Todavía no se ha publicado ningún feed.",
+		Downloads:        "Descargas",
+		DownloadsAlt:     "descargas de su3 por idioma",
+	},
+	"fr": {
+		Source:           "source",
+		DirectoryListing: "Contenu du répertoire :",
+		TotalRequests:    "Total des requêtes",
+		UpdatesHandled:   "Mises à jour servies (approx.)",
+		LandingIntro:     "Flux Atom des nouvelles d’I2P et des mises à jour du routeur.  Les routeurs\ntéléchargent les fichiers <code>.su3</code> signés ; les flux\n<code>.atom.xml</code> se lisent avec n’importe quel lecteur de flux.",
+		Language:         "Langue",
+		Feed:             "Flux",
+		Signed:           "Signé",
+		Details:          "Détails",
+		NoFeeds:          "Aucun flux n’a encore été publié.",
+		Downloads:        "Téléchargements",
+		DownloadsAlt:     "téléchargements su3 par langue",
+	},
+	"it": {
+		Source:           "fonte",
+		DirectoryListing: "Contenuto della directory:",
+		TotalRequests:    "Richieste totali",
+		UpdatesHandled:   "Aggiornamenti serviti (circa)",
+		LandingIntro:     "Feed Atom delle notizie di I2P e degli aggiornamenti del router.  I router\nscaricano i file <code>.su3</code> firmati; i feed <code>.atom.xml</code> si\npossono leggere con qualsiasi lettore di feed.",
+		Language:         "Lingua",
+		Feed:             "Feed",
+		Signed:           "Firmato",
+		Details:          "Dettagli",
+		NoFeeds:          "Nessun feed è stato ancora pubblicato.",
+		Downloads:        "Download",
+		DownloadsAlt:     "download su3 per lingua",
+	},
+	"pt": {
+		Source:           "fonte",
+		DirectoryListing: "Conteúdo do diretório:",
+		TotalRequests:    "Total de pedidos",
+		UpdatesHandled:   "Atualizações servidas (aprox.)",
+		LandingIntro:     "Feeds Atom das notícias do I2P e das atualizações do roteador.  Os roteadores\nbaixam os arquivos <code>.su3</code> assinados; os feeds <code>.atom.xml</code>\npodem ser lidos com qualquer leitor de feeds.",
+		Language:         "Idioma",
+		Feed:             "Feed",
+		Signed:           "Assinado",
+		Details:          "Detalhes",
+		NoFeeds:          "Nenhum feed foi publicado ainda.",
+		Downloads:        "Downloads",
+		DownloadsAlt:     "downloads de su3 por idioma",
+	},
+	"ru": {
+		Source:           "источник",
+		DirectoryListing: "Содержимое каталога:",
+		TotalRequests:    "Всего запросов",
+		UpdatesHandled:   "Выдано обновлений (прибл.)",
+		LandingIntro:     "Atom-ленты новостей I2P и обновлений роутера.  Роутеры загружают\nподписанные файлы <code>.su3</code>; ленты <code>.atom.xml</code> можно читать\nв любой программе для чтения лент.",
+		Language:         "Язык",
+		Feed:             "Лента",
+		Signed:           "Подпись",
+		Details:          "Подробности",
+		NoFeeds:          "Ленты ещё не опубликованы.",
+		Downloads:        "Загрузки",
+		DownloadsAlt:     "загрузки su3 по языкам",
+	},
+}
+
+// MessagesFor returns the Messages of locale, written with either separator
+// like the results of LocaleFromPath: those of the locale itself, else of its
+// base language ("pt" for "pt-BR"), else English.
+func MessagesFor(locale string) Messages {
+	locale = normalizeLocale(locale)
+	if m, ok := messageCatalog[locale]; ok {
+		return m
+	}
+	base, _, _ := strings.Cut(locale, "-")
+	if m, ok := messageCatalog[base]; ok {
+		return m
+	}
+	return messageCatalog["en"]
+}
+
+// MessageLocales returns the locales of the catalog, English first, for
+// negotiating the language of generated pages.
+func MessageLocales() []string {
+	locales := make([]string, 0, len(messageCatalog))
+	for locale := range messageCatalog {
+		if locale != "en" {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales)
+	return append([]string{"en"}, locales...)
+}
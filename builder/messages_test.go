@@ -0,0 +1,34 @@
+package newsbuilder
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMessagesFor verifies the fallback from a locale to its base language
+// and to English, and that every catalog entry is complete.
+func TestMessagesFor(t *testing.T) {
+	for locale, want := range map[string]string{
+		"":      "Directory Listing:",
+		"en":    "Directory Listing:",
+		"de":    "Verzeichnisinhalt:",
+		"pt_BR": "Conteúdo do diretório:",
+		"pt-BR": "Conteúdo do diretório:",
+		"ja":    "Directory Listing:",
+	} {
+		if got := MessagesFor(locale).DirectoryListing; got != want {
+			t.Errorf("MessagesFor(%q).DirectoryListing = %q, want %q", locale, got, want)
+		}
+	}
+	if locales := MessageLocales(); locales[0] != "en" || len(locales) != len(messageCatalog) {
+		t.Errorf("MessageLocales() = %q, want English first and every locale", locales)
+	}
+	for locale, m := range messageCatalog {
+		v := reflect.ValueOf(m)
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).String() == "" {
+				t.Errorf("catalog %s: %s is empty", locale, v.Type().Field(i).Name)
+			}
+		}
+	}
+}
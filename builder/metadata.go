@@ -0,0 +1,122 @@
+// Package newsbuilder — per-feed JSON metadata sidecars.
+package newsbuilder
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FeedMetadata summarises one built feed.  It is written next to the feed as
+// a JSON sidecar (see SidecarPath) so that directory listings and freshness
+// monitoring can report on a feed without parsing its XML.
+type FeedMetadata struct {
+	// Feed is the base name of the feed file, e.g. "news_de.atom.xml".
+	Feed string `json:"feed"`
+	// Locale is the BCP 47 tag of the feed, e.g. "en" or "pt-BR".
+	Locale string `json:"locale"`
+	// Entries is the number of <entry> elements in the feed.
+	Entries int `json:"entries"`
+	// NewestEntry is the newest article updated/published date in RFC 3339
+	// form, or empty when no article carries a parseable date.
+	NewestEntry string `json:"newest_entry,omitempty"`
+	// NextExpiry is the earliest expires date of the articles in the feed
+	// in RFC 3339 form, when the feed is due to change without any of its
+	// inputs changing, or empty when no article expires.
+	NextExpiry string `json:"next_expiry,omitempty"`
+	// ReleaseVersion is the version of the <i2p:release> in the feed.
+	ReleaseVersion string `json:"release_version"`
+	// Updated is the feed-level <updated> time in RFC 3339 form.
+	Updated string `json:"updated"`
+	// Built is the wall-clock time of the build in RFC 3339 form.  Unlike
+	// Updated it moves on every rebuild, whatever the TimestampSource.
+	Built string `json:"built"`
+	// SHA256 is the hex digest of the feed file.
+	SHA256 string `json:"sha256"`
+	// OverBudget counts the entries, and the feed itself, over their size
+	// budget; see SizeBudget.  The violations are logged by Build.
+	OverBudget int `json:"over_budget,omitempty"`
+	// GitRef and GitCommit name the git ref, and the commit it resolved
+	// to, the feed was built from when the build read its data from git
+	// (newsgo build --git-ref); both are empty for a working-tree build.
+	GitRef    string `json:"git_ref,omitempty"`
+	GitCommit string `json:"git_commit,omitempty"`
+}
+
+// SidecarPath returns the metadata sidecar path for the feed at feedPath: the
+// ".atom.xml" suffix replaced by ".json", so news_de.atom.xml is described by
+// news_de.json.
+func SidecarPath(feedPath string) string {
+	return strings.TrimSuffix(feedPath, ".atom.xml") + ".json"
+}
+
+// Metadata returns the metadata of feed, the document returned by the last
+// successful Build.  Feed is left empty; WriteFeedMetadata fills it in from
+// the path the document is written to.
+func (nb *NewsBuilder) Metadata(feed string) FeedMetadata {
+	m := nb.built
+	m.SHA256 = fmt.Sprintf("%x", sha256.Sum256([]byte(feed)))
+	return m
+}
+
+// recordMetadata stores the metadata of a successful Build in nb.built.
+func (nb *NewsBuilder) recordMetadata(lang string, entries int, newest time.Time, hasNewest bool, expiry time.Time, hasExpiry bool, version string, updated, buildTime time.Time) {
+	nb.built = FeedMetadata{
+		Locale:         lang,
+		Entries:        entries,
+		ReleaseVersion: version,
+		Updated:        updated.UTC().Format(time.RFC3339),
+		Built:          buildTime.UTC().Format(time.RFC3339),
+	}
+	if hasNewest {
+		nb.built.NewestEntry = newest.UTC().Format(time.RFC3339)
+	}
+	if hasExpiry {
+		nb.built.NextExpiry = expiry.UTC().Format(time.RFC3339)
+	}
+}
+
+// WriteFeedMetadata writes m, with Feed set to the base name of feedPath, to
+// SidecarPath(feedPath).
+func WriteFeedMetadata(feedPath string, m FeedMetadata) error {
+	m.Feed = filepath.Base(feedPath)
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("WriteFeedMetadata: %w", err)
+	}
+	if err := os.WriteFile(SidecarPath(feedPath), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("WriteFeedMetadata: %w", err)
+	}
+	return nil
+}
+
+// BuiltTime returns Built as a time.  ok is false when it is missing or
+// unparseable.
+func (m FeedMetadata) BuiltTime() (t time.Time, ok bool) {
+	t, err := time.Parse(time.RFC3339, m.Built)
+	return t, err == nil
+}
+
+// NextExpiryTime returns NextExpiry as a time.  ok is false when it is
+// missing or unparseable.
+func (m FeedMetadata) NextExpiryTime() (t time.Time, ok bool) {
+	t, err := time.Parse(time.RFC3339, m.NextExpiry)
+	return t, err == nil
+}
+
+// ReadFeedMetadata reads the sidecar of the feed at feedPath.
+func ReadFeedMetadata(feedPath string) (FeedMetadata, error) {
+	var m FeedMetadata
+	data, err := os.ReadFile(SidecarPath(feedPath))
+	if err != nil {
+		return m, fmt.Errorf("ReadFeedMetadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("ReadFeedMetadata: %s: %w", SidecarPath(feedPath), err)
+	}
+	return m, nil
+}
@@ -0,0 +1,68 @@
+package newsbuilder
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSidecarPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"build/news.atom.xml":                "build/news.json",
+		"build/win/beta/news_de.atom.xml":    "build/win/beta/news_de.json",
+		"build/win/beta/news_pt_BR.atom.xml": "build/win/beta/news_pt_BR.json",
+	} {
+		if got := SidecarPath(in); got != want {
+			t.Errorf("SidecarPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestMetadata_WriteRead verifies that the metadata of a build describes the
+// built feed and round-trips through its sidecar.
+func TestMetadata_WriteRead(t *testing.T) {
+	dir := t.TempDir()
+	nb := writeFixtures(t, dir)
+	nb.Language = "de"
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	feedPath := filepath.Join(dir, "news_de.atom.xml")
+	if err := os.WriteFile(feedPath, []byte(feed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFeedMetadata(feedPath, nb.Metadata(feed)); err != nil {
+		t.Fatalf("WriteFeedMetadata: %v", err)
+	}
+	m, err := ReadFeedMetadata(feedPath)
+	if err != nil {
+		t.Fatalf("ReadFeedMetadata: %v", err)
+	}
+	want := FeedMetadata{
+		Feed:           "news_de.atom.xml",
+		Locale:         "de",
+		Entries:        1,
+		NewestEntry:    "2024-01-02T00:00:00Z",
+		ReleaseVersion: "2.0.0",
+		Updated:        m.Updated,
+		Built:          m.Built,
+		SHA256:         fmt.Sprintf("%x", sha256.Sum256([]byte(feed))),
+	}
+	if m != want {
+		t.Errorf("metadata = %+v, want %+v", m, want)
+	}
+	built, ok := m.BuiltTime()
+	if !ok || time.Since(built) > time.Minute {
+		t.Errorf("Built = %q, want the build time", m.Built)
+	}
+}
+
+func TestReadFeedMetadata_Missing(t *testing.T) {
+	if _, err := ReadFeedMetadata(filepath.Join(t.TempDir(), "news.atom.xml")); err == nil {
+		t.Error("ReadFeedMetadata without a sidecar: expected error")
+	}
+}
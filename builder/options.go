@@ -0,0 +1,143 @@
+// Package newsbuilder — immutable options and concurrency-safe builds.
+package newsbuilder
+
+import (
+	"context"
+	"crypto/x509"
+	"time"
+
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+)
+
+// Options is the complete configuration of one feed build.  The fields mirror
+// those of NewsBuilder and its Feed; see there for their meaning.  Options is
+// used exactly as given: start from DefaultOptions to get the defaults that
+// Builder sets.
+type Options struct {
+	EntriesHTMLPath          string
+	BaseEntriesHTMLPath      string
+	FallbackEntriesHTMLPaths []string
+	StrictChars              bool
+	ReleasesJSON             string
+	BlocklistXML             string
+	Language                 string
+	URNID                    string
+	FeedID                   string
+	Title                    string
+	SiteURL                  string
+	MainFeed                 string
+	BackupFeed               string
+	Subtitle                 string
+	IncludeDrafts            bool
+	TimestampSource          string
+	BlocklistCerts           []*x509.Certificate
+	BlocklistTTL             time.Duration
+	BlocklistMaxSize         int64
+	BlocklistURL             string
+	Budget                   SizeBudget
+	Hub                      string
+	Channel                  string
+	ChannelTitlePrefix       string
+	Rights                   string
+	LicenseURI               string
+	Icon                     string
+	Logo                     string
+	EntriesPageURL           string
+}
+
+// DefaultOptions returns the Options equivalent of Builder(newsFile,
+// releasesJSON, blocklistXML).  Like Builder it leaves URNID empty.
+func DefaultOptions(newsFile, releasesJSON, blocklistXML string) Options {
+	nb := Builder(newsFile, releasesJSON, blocklistXML)
+	return Options{
+		EntriesHTMLPath: newsFile,
+		ReleasesJSON:    releasesJSON,
+		BlocklistXML:    blocklistXML,
+		Title:           nb.TITLE,
+		SiteURL:         nb.SITEURL,
+		MainFeed:        nb.MAINFEED,
+		BackupFeed:      nb.BACKUPFEED,
+		Subtitle:        nb.SUBTITLE,
+	}
+}
+
+// clone returns a copy of o that shares no slices with it.
+func (o Options) clone() Options {
+	o.FallbackEntriesHTMLPaths = append([]string(nil), o.FallbackEntriesHTMLPaths...)
+	o.BlocklistCerts = append([]*x509.Certificate(nil), o.BlocklistCerts...)
+	return o
+}
+
+// FeedBuilder builds one feed from immutable Options.  Unlike NewsBuilder,
+// whose fields are set between calls and whose Build loads the entries into
+// the receiver, a FeedBuilder keeps no per-build state: each Build works on
+// its own NewsBuilder, so one FeedBuilder may be used from any number of
+// goroutines at once.
+type FeedBuilder struct {
+	opts Options
+}
+
+// New returns a FeedBuilder for opts.  opts is copied, so later changes to
+// the caller's slices do not affect the builder.
+func New(opts Options) *FeedBuilder {
+	return &FeedBuilder{opts: opts.clone()}
+}
+
+// Options returns a copy of the builder's options, e.g. as the starting point
+// for the options of a sibling feed.
+func (b *FeedBuilder) Options() Options {
+	return b.opts.clone()
+}
+
+// newsBuilder returns a fresh NewsBuilder configured from b.opts.
+func (b *FeedBuilder) newsBuilder() *NewsBuilder {
+	o := b.opts.clone()
+	return &NewsBuilder{
+		Feed: newsfeed.Feed{
+			EntriesHTMLPath:          o.EntriesHTMLPath,
+			BaseEntriesHTMLPath:      o.BaseEntriesHTMLPath,
+			FallbackEntriesHTMLPaths: o.FallbackEntriesHTMLPaths,
+			StrictChars:              o.StrictChars,
+		},
+		Language:           o.Language,
+		ReleasesJson:       o.ReleasesJSON,
+		BlocklistXML:       o.BlocklistXML,
+		URNID:              o.URNID,
+		FeedID:             o.FeedID,
+		TITLE:              o.Title,
+		SITEURL:            o.SiteURL,
+		MAINFEED:           o.MainFeed,
+		BACKUPFEED:         o.BackupFeed,
+		SUBTITLE:           o.Subtitle,
+		IncludeDrafts:      o.IncludeDrafts,
+		TimestampSource:    o.TimestampSource,
+		BlocklistCerts:     o.BlocklistCerts,
+		BlocklistTTL:       o.BlocklistTTL,
+		BlocklistMaxSize:   o.BlocklistMaxSize,
+		BlocklistURL:       o.BlocklistURL,
+		Budget:             o.Budget,
+		Hub:                o.Hub,
+		Channel:            o.Channel,
+		ChannelTitlePrefix: o.ChannelTitlePrefix,
+		Rights:             o.Rights,
+		LicenseURI:         o.LicenseURI,
+		Icon:               o.Icon,
+		Logo:               o.Logo,
+		EntriesPageURL:     o.EntriesPageURL,
+	}
+}
+
+// Build builds the feed and returns it together with its metadata, whose
+// SHA256 describes the returned document.  It stops with ctx's error when ctx
+// is done before the build completes.
+func (b *FeedBuilder) Build(ctx context.Context) (string, FeedMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return "", FeedMetadata{}, err
+	}
+	nb := b.newsBuilder()
+	feed, err := nb.build(ctx)
+	if err != nil {
+		return "", FeedMetadata{}, err
+	}
+	return feed, nb.Metadata(feed), nil
+}
@@ -0,0 +1,80 @@
+package newsbuilder
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestFeedBuilder_ParallelBuilds verifies that one FeedBuilder can build
+// feeds concurrently and that each build sees only its own options.
+func TestFeedBuilder_ParallelBuilds(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	opts := DefaultOptions(nb.Feed.EntriesHTMLPath, nb.ReleasesJson, nb.BlocklistXML)
+	opts.URNID = nb.URNID
+
+	langs := []string{"de", "fr", "es", "it", "ru", "zh"}
+	builders := make([]*FeedBuilder, len(langs))
+	for i, lang := range langs {
+		o := opts
+		o.Language = lang
+		builders[i] = New(o)
+	}
+	shared := New(opts)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4*len(langs))
+	for round := 0; round < 4; round++ {
+		for i, lang := range langs {
+			wg.Add(2)
+			go func(b *FeedBuilder, lang string) {
+				defer wg.Done()
+				feed, m, err := b.Build(context.Background())
+				switch {
+				case err != nil:
+					errs <- err
+				case m.Locale != lang || !strings.Contains(feed, `xml:lang="`+lang+`"`):
+					errs <- errors.New("feed built for the wrong language " + m.Locale + ", want " + lang)
+				}
+			}(builders[i], lang)
+			go func() {
+				defer wg.Done()
+				if _, m, err := shared.Build(context.Background()); err != nil {
+					errs <- err
+				} else if m.Entries != 1 {
+					errs <- errors.New("shared build lost its entries")
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestFeedBuilder_Cancelled verifies that Build stops with the context's
+// error once the context is done.
+func TestFeedBuilder_Cancelled(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	b := New(DefaultOptions(nb.Feed.EntriesHTMLPath, nb.ReleasesJson, nb.BlocklistXML))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, err := b.Build(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Build(cancelled ctx) error = %v, want context.Canceled", err)
+	}
+}
+
+// TestFeedBuilder_OptionsCopied verifies that New copies the caller's
+// slices, so changing them afterwards does not change the builder.
+func TestFeedBuilder_OptionsCopied(t *testing.T) {
+	fallbacks := []string{"a.html", "b.html"}
+	b := New(Options{FallbackEntriesHTMLPaths: fallbacks})
+	fallbacks[0] = "changed.html"
+	if got := b.Options().FallbackEntriesHTMLPaths[0]; got != "a.html" {
+		t.Errorf("FallbackEntriesHTMLPaths[0] = %q after caller change, want a.html", got)
+	}
+}
@@ -0,0 +1,57 @@
+// Package newsbuilder — per-directory feed metadata overrides.
+package newsbuilder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FeedOverridesName is the file in a platform/status data directory whose
+// fields replace the global feed metadata for every feed built from that
+// directory, e.g. data/mac/beta/feed.json.
+const FeedOverridesName = "feed.json"
+
+// FeedOverrides holds the feed metadata a FeedOverridesName file may set.
+// Empty fields keep the global value.
+type FeedOverrides struct {
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	SiteURL  string `json:"site_url"`
+}
+
+// LoadFeedOverrides reads FeedOverridesName from dataDir.  A missing file
+// yields empty overrides.  Unknown fields are an error, so that a misspelt
+// key does not silently leave the generic title in place.
+func LoadFeedOverrides(dataDir string) (FeedOverrides, error) {
+	var o FeedOverrides
+	path := filepath.Join(dataDir, FeedOverridesName)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return o, fmt.Errorf("LoadFeedOverrides: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&o); err != nil {
+		return o, fmt.Errorf("LoadFeedOverrides: %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// Apply sets the non-empty fields of o on nb.
+func (o FeedOverrides) Apply(nb *NewsBuilder) {
+	if o.Title != "" {
+		nb.TITLE = o.Title
+	}
+	if o.Subtitle != "" {
+		nb.SUBTITLE = o.Subtitle
+	}
+	if o.SiteURL != "" {
+		nb.SITEURL = o.SiteURL
+	}
+}
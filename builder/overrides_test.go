@@ -0,0 +1,40 @@
+package newsbuilder
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestLoadFeedOverrides verifies that a missing feed.json yields no
+// overrides, that set fields replace the builder's values and empty ones keep
+// them, and that unknown keys are rejected.
+func TestLoadFeedOverrides(t *testing.T) {
+	dir := t.TempDir()
+	o, err := LoadFeedOverrides(dir)
+	if err != nil || o != (FeedOverrides{}) {
+		t.Fatalf("missing feed.json: %+v, %v; want empty overrides", o, err)
+	}
+
+	path := filepath.Join(dir, FeedOverridesName)
+	if err := os.WriteFile(path, []byte(`{"title": "I2P macOS Beta News", "site_url": "https://i2p.example/mac"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	o, err = LoadFeedOverrides(dir)
+	if err != nil {
+		t.Fatalf("LoadFeedOverrides: %v", err)
+	}
+	nb := Builder("entries.html", "releases.json", "blocklist.xml")
+	nb.SUBTITLE = "global subtitle"
+	o.Apply(nb)
+	if nb.TITLE != "I2P macOS Beta News" || nb.SITEURL != "https://i2p.example/mac" || nb.SUBTITLE != "global subtitle" {
+		t.Errorf("after Apply: title %q, site %q, subtitle %q", nb.TITLE, nb.SITEURL, nb.SUBTITLE)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"titel": "typo"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFeedOverrides(dir); err == nil {
+		t.Error("unknown key accepted; want an error")
+	}
+}
@@ -1,7 +1,10 @@
 // Package newsbuilder — platform and release-status enumeration helpers.
 package newsbuilder
 
-import "path/filepath"
+import (
+	"path/filepath"
+	"strings"
+)
 
 // KnownPlatforms returns the canonical set of non-default platform keys in a
 // deterministic order.  The unnamed default tree (the top-level data directory)
@@ -19,6 +22,12 @@ func KnownStatuses() []string {
 	return []string{"stable", "beta", "rc", "alpha"}
 }
 
+// IsPreReleaseStatus reports whether status names a pre-release channel:
+// one of KnownStatuses other than "stable".
+func IsPreReleaseStatus(status string) bool {
+	return status != "" && status != "stable"
+}
+
 // PlatformDataDir returns the data sub-directory for (dataRoot, platform,
 // status).  When platform is empty the top-level dataRoot is returned
 // unchanged, preserving the default (unnamed) feed path.  All named
@@ -31,3 +40,13 @@ func PlatformDataDir(dataRoot, platform, status string) string {
 	}
 	return filepath.Join(dataRoot, platform, status)
 }
+
+// SlashPath returns p with every backslash and slash turned into "/", so that
+// paths written on Windows, e.g. in a config file shared with a Windows
+// editor, split into the same components on every OS.  Drive letters and UNC
+// hosts are kept: C:\news\data becomes C:/news/data and \\srv\share\build
+// becomes //srv/share/build.  On Unix a backslash is therefore never taken as
+// part of a file name.
+func SlashPath(p string) string {
+	return strings.ReplaceAll(filepath.ToSlash(p), `\`, "/")
+}
@@ -115,3 +115,22 @@ func TestPlatformDataDir(t *testing.T) {
 		})
 	}
 }
+
+// TestSlashPath verifies that SlashPath treats both separators alike and
+// keeps drive letters and UNC hosts, and that LocaleFromPath therefore reads
+// the locale of a Windows path on every OS.
+func TestSlashPath(t *testing.T) {
+	for in, want := range map[string]string{
+		`C:\news\data`:         "C:/news/data",
+		`\\srv\share\build`:    "//srv/share/build",
+		`data/translations\de`: "data/translations/de",
+		"data/entries.html":    "data/entries.html",
+	} {
+		if got := SlashPath(in); got != want {
+			t.Errorf("SlashPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := LocaleFromPath(`C:\news\data\translations\entries.pt_BR.html`); got != "pt-BR" {
+		t.Errorf("LocaleFromPath(Windows path) = %q, want pt-BR", got)
+	}
+}
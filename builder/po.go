@@ -0,0 +1,157 @@
+// Package newsbuilder — minimal gettext PO reader and writer.
+package newsbuilder
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// poEntry is one message of a PO or POT file.  Only the fields used by the
+// translations round-trip are modelled: plural forms and translator comments
+// are not needed because every unit is a single string keyed by msgctxt.
+// Comments are the extracted ("#.") comments, which carry the editorial notes
+// of the unit's article to translators.
+type poEntry struct {
+	Context  string
+	ID       string
+	Str      string
+	Fuzzy    bool
+	Comments []string
+}
+
+// poQuote renders s as one or more PO string lines.  Multi-line values are
+// written in the conventional form: an empty first line followed by one line
+// per "\n"-terminated segment, which keeps diffs of the file readable.
+func poQuote(s string) string {
+	if !strings.Contains(s, "\n") || s == "\n" {
+		return poEscape(s)
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	var b strings.Builder
+	b.WriteString(`""`)
+	for _, l := range lines {
+		b.WriteString("\n")
+		b.WriteString(poEscape(l))
+	}
+	return b.String()
+}
+
+// poEscape returns s as a single double-quoted PO string.
+func poEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`, "\r", `\r`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// writePO writes header followed by entries to w.  header holds the
+// "Key: value" lines of the PO header entry, in order.
+func writePO(w io.Writer, header []string, entries []poEntry) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "msgid \"\"\nmsgstr %s\n", poQuote(strings.Join(header, "\n")+"\n"))
+	for _, e := range entries {
+		bw.WriteString("\n")
+		for _, c := range e.Comments {
+			for _, line := range strings.Split(c, "\n") {
+				bw.WriteString(strings.TrimRight("#. "+strings.TrimSpace(line), " ") + "\n")
+			}
+		}
+		if e.Fuzzy {
+			bw.WriteString("#, fuzzy\n")
+		}
+		fmt.Fprintf(bw, "msgctxt %s\nmsgid %s\nmsgstr %s\n", poQuote(e.Context), poQuote(e.ID), poQuote(e.Str))
+	}
+	return bw.Flush()
+}
+
+// readPO parses the PO file in r.  The header entry (empty msgid without a
+// context) is dropped.  Each line of an extracted comment becomes one of
+// Comments.  Obsolete "#~" entries and plural forms are ignored.
+func readPO(r io.Reader) ([]poEntry, error) {
+	var (
+		entries []poEntry
+		cur     poEntry
+		field   *string // the keyword whose string continuation lines follow
+		started bool
+		lineNo  int
+	)
+	flush := func() {
+		if started && (cur.ID != "" || cur.Context != "") {
+			entries = append(entries, cur)
+		}
+		cur, field, started = poEntry{}, nil, false
+	}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "#,"):
+			// A flags comment starts a new entry when the previous one
+			// was not separated by a blank line.
+			if started {
+				flush()
+			}
+			for _, flag := range strings.Split(line[2:], ",") {
+				if strings.TrimSpace(flag) == "fuzzy" {
+					cur.Fuzzy = true
+				}
+			}
+		case strings.HasPrefix(line, "#."):
+			// An extracted comment starts a new entry like a flags
+			// comment; its lines are kept one comment each.
+			if started {
+				flush()
+			}
+			cur.Comments = append(cur.Comments, strings.TrimSpace(line[2:]))
+		case strings.HasPrefix(line, "#"):
+			// Translator, reference, and obsolete comments.
+		case strings.HasPrefix(line, `"`):
+			if field == nil {
+				return nil, fmt.Errorf("readPO: line %d: string without keyword", lineNo)
+			}
+			s, err := strconv.Unquote(line)
+			if err != nil {
+				return nil, fmt.Errorf("readPO: line %d: %w", lineNo, err)
+			}
+			*field += s
+		default:
+			keyword, rest, _ := strings.Cut(line, " ")
+			s, err := strconv.Unquote(strings.TrimSpace(rest))
+			if err != nil {
+				return nil, fmt.Errorf("readPO: line %d: %w", lineNo, err)
+			}
+			switch keyword {
+			case "msgctxt":
+				if started && (cur.ID != "" || cur.Str != "") {
+					flush()
+				}
+				cur.Context, field = s, &cur.Context
+			case "msgid":
+				if started && cur.ID != "" {
+					flush()
+				}
+				cur.ID, field = s, &cur.ID
+			case "msgstr":
+				cur.Str, field = s, &cur.Str
+			default:
+				// msgid_plural, msgstr[n]: not produced by export.
+				field = nil
+				continue
+			}
+			started = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("readPO: %w", err)
+	}
+	flush()
+	return entries, nil
+}
@@ -0,0 +1,108 @@
+// Package newsbuilder — the releases.json format.
+package newsbuilder
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Releases is the format of releases.json: an array of releases, of which
+// the first describes the current router release advertised in the feeds.
+// JSONtoXML reads the file field by field so that each mistake gets its own
+// error; these types describe the same format for schema generation.
+type Releases []Release
+
+// Release is one release of releases.json, emitted as <i2p:release>.
+type Release struct {
+	Date           string         `json:"date" description:"release date, e.g. 2022-11-21"`
+	Version        string         `json:"version" description:"router version of the release, emitted as <i2p:version>"`
+	MinVersion     string         `json:"minVersion" description:"oldest router version that may update to this release"`
+	MinJavaVersion string         `json:"minJavaVersion" description:"oldest Java version the release runs on, e.g. 1.8"`
+	Updates        ReleaseUpdates `json:"updates" description:"update files of the release, by type"`
+	Revoked        []string       `json:"revoked,omitempty" pattern:"^[0-9]+(\\.[0-9]+)*(-[0-9]+)?$" description:"router versions revoked, e.g. for a security flaw, each emitted as <i2p:revoke version>"`
+	Signers        []string       `json:"signers,omitempty" pattern:"^\\S+$" description:"su3 signer IDs the feeds may be signed by, e.g. zzz@mail.i2p, each emitted as <i2p:signer id> for fetchers to check"`
+}
+
+// ReleaseUpdates holds the update files of a Release.
+type ReleaseUpdates struct {
+	SU3 ReleaseSU3 `json:"su3" description:"the signed su3 router update, emitted as <i2p:update type=\"su3\">"`
+}
+
+// ReleaseSU3 is the su3 router update of a Release.
+type ReleaseSU3 struct {
+	Torrent string         `json:"torrent" description:"magnet link of the update torrent"`
+	URL     []string       `json:"url" description:"download URLs of the update, emitted as <i2p:url> in order"`
+	Hashes  *ReleaseHashes `json:"hashes,omitempty" description:"digests of the update file, emitted as <i2p:hash>"`
+}
+
+// ReleaseHashes are the digests of a ReleaseSU3; see extractSU3Hashes.
+type ReleaseHashes struct {
+	SHA256 string `json:"sha256,omitempty" pattern:"^[0-9a-fA-F]{64}$" description:"hex SHA-256 digest, as printed by sha256sum"`
+	SHA512 string `json:"sha512,omitempty" pattern:"^[0-9a-fA-F]{128}$" description:"hex SHA-512 digest, as printed by sha512sum"`
+}
+
+// revokedVersion matches a router version in the revoked array of a release,
+// e.g. "2.3.0" or "0.9.62-1".
+var revokedVersion = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*(-[0-9]+)?$`)
+
+// extractRevoked returns the versions of the optional revoked array of
+// release, whose own version is version, in their order.  Each must be a
+// router version given once, and a release cannot revoke itself, so a typo
+// cannot tell routers to distrust the update they are offered.
+func extractRevoked(release map[string]interface{}, version string) ([]string, error) {
+	raw, ok := release["revoked"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSONtoXML: field \"revoked\" is not an array")
+	}
+	seen := make(map[string]bool, len(list))
+	revoked := make([]string, 0, len(list))
+	for i, v := range list {
+		s, ok := v.(string)
+		if !ok || !revokedVersion.MatchString(s) {
+			return nil, fmt.Errorf("JSONtoXML: revoked[%d] is not a router version such as \"2.3.0\"", i)
+		}
+		if s == version {
+			return nil, fmt.Errorf("JSONtoXML: revoked[%d]: the release cannot revoke its own version %s", i, s)
+		}
+		if seen[s] {
+			return nil, fmt.Errorf("JSONtoXML: revoked[%d]: version %s revoked twice", i, s)
+		}
+		seen[s] = true
+		revoked = append(revoked, s)
+	}
+	return revoked, nil
+}
+
+// extractSigners returns the signer IDs of the optional signers array of
+// release, in their order.  Each must be a non-empty ID without whitespace,
+// as su3 signer IDs are, given once.
+func extractSigners(release map[string]interface{}) ([]string, error) {
+	raw, ok := release["signers"]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("JSONtoXML: field \"signers\" is not an array")
+	}
+	seen := make(map[string]bool, len(list))
+	signers := make([]string, 0, len(list))
+	for i, v := range list {
+		s, ok := v.(string)
+		if !ok || s == "" || strings.IndexFunc(s, unicode.IsSpace) >= 0 {
+			return nil, fmt.Errorf("JSONtoXML: signers[%d] is not a signer ID such as \"zzz@mail.i2p\"", i)
+		}
+		if seen[s] {
+			return nil, fmt.Errorf("JSONtoXML: signers[%d]: signer %s listed twice", i, s)
+		}
+		seen[s] = true
+		signers = append(signers, s)
+	}
+	return signers, nil
+}
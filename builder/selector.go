@@ -0,0 +1,83 @@
+// Package newsbuilder — selection of a sub-tree of built feeds.
+package newsbuilder
+
+import (
+	"path"
+	"strings"
+)
+
+// FeedSelector selects part of a build tree by platform, release status,
+// and language, so that one platform can be signed or served on its own
+// during a staged rollout.  Platform and Status select directories the way
+// build --platform and --status select data directories: Platform keeps
+// only the {platform}/ sub-tree, and Status keeps the default tree and the
+// {platform}/{status}/ sub-trees of that status.  Langs keeps only the feeds
+// of those locales, "en" being the canonical news feed.  Empty fields select
+// everything.
+type FeedSelector struct {
+	Platform string
+	Status   string
+	Langs    []string
+}
+
+// IsZero reports whether s selects everything.
+func (s FeedSelector) IsZero() bool {
+	return s.Platform == "" && s.Status == "" && len(s.Langs) == 0
+}
+
+// FeedOutputLocale returns the locale of a feed output file name — an Atom
+// feed, its su3, its metadata sidecar, or its entries page — e.g. "en" for
+// "news.su3" and "pt-BR" for "news_pt_BR.atom.xml".  ok is false for any
+// other file.
+func FeedOutputLocale(name string) (locale string, ok bool) {
+	for _, suffix := range []string{".atom.xml", ".su3", ".json", ".html"} {
+		base, found := strings.CutSuffix(name, suffix)
+		if !found {
+			continue
+		}
+		if base == "news" {
+			return "en", true
+		}
+		if raw, found := strings.CutPrefix(base, "news_"); found && raw != "" {
+			return normalizeLocale(raw), true
+		}
+	}
+	return "", false
+}
+
+// Selects reports whether the file or directory at rel, a slash-separated
+// path relative to the root of a build tree, is part of the selection.
+// Files that are not feed outputs are selected whenever their directory is.
+func (s FeedSelector) Selects(rel string, isDir bool) bool {
+	rel = path.Clean(strings.TrimPrefix(rel, "/"))
+	if rel == "." {
+		return true
+	}
+	dirs := strings.Split(rel, "/")
+	name := ""
+	if !isDir {
+		name, dirs = dirs[len(dirs)-1], dirs[:len(dirs)-1]
+	}
+	locale, isFeed := FeedOutputLocale(name)
+	if s.Platform != "" {
+		if len(dirs) > 0 && dirs[0] != s.Platform {
+			return false
+		}
+		if len(dirs) == 0 && isFeed {
+			// A feed of the default tree, not of the platform.
+			return false
+		}
+	}
+	if s.Status != "" && len(dirs) > 1 && dirs[1] != s.Status {
+		return false
+	}
+	if isFeed && len(s.Langs) > 0 {
+		for _, l := range s.Langs {
+			if normalizeLocale(l) == locale {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
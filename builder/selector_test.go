@@ -0,0 +1,55 @@
+package newsbuilder
+
+import "testing"
+
+func TestFeedOutputLocale(t *testing.T) {
+	for name, want := range map[string]string{
+		"news.atom.xml":       "en",
+		"news.su3":            "en",
+		"news.json":           "en",
+		"news_de.atom.xml":    "de",
+		"news_pt_BR.su3":      "pt-BR",
+		"news_fr.html":        "fr",
+		"index.html":          "",
+		"news_.atom.xml":      "",
+		"stats.json":          "",
+		"news.atom.xml.1.tmp": "",
+	} {
+		got, ok := FeedOutputLocale(name)
+		if got != want || ok != (want != "") {
+			t.Errorf("FeedOutputLocale(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+}
+
+func TestFeedSelector_Selects(t *testing.T) {
+	cases := []struct {
+		sel   FeedSelector
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{FeedSelector{}, "mac/beta/news_de.su3", false, true},
+		{FeedSelector{Platform: "mac"}, "", true, true},
+		{FeedSelector{Platform: "mac"}, "mac", true, true},
+		{FeedSelector{Platform: "mac"}, "win", true, false},
+		{FeedSelector{Platform: "mac"}, "mac/beta/news.atom.xml", false, true},
+		{FeedSelector{Platform: "mac"}, "win/beta/news.atom.xml", false, false},
+		{FeedSelector{Platform: "mac"}, "news.atom.xml", false, false},
+		{FeedSelector{Platform: "mac"}, "style.css", false, true},
+		{FeedSelector{Status: "beta"}, "news.su3", false, true},
+		{FeedSelector{Status: "beta"}, "win/beta", true, true},
+		{FeedSelector{Status: "beta"}, "win/stable", true, false},
+		{FeedSelector{Status: "beta"}, "win/stable/news.su3", false, false},
+		{FeedSelector{Langs: []string{"de", "pt_BR"}}, "news_de.atom.xml", false, true},
+		{FeedSelector{Langs: []string{"de", "pt_BR"}}, "mac/beta/news_pt_BR.su3", false, true},
+		{FeedSelector{Langs: []string{"de", "pt_BR"}}, "news.atom.xml", false, false},
+		{FeedSelector{Langs: []string{"en"}}, "/news.json", false, true},
+		{FeedSelector{Langs: []string{"en"}}, "mac/beta", true, true},
+	}
+	for _, c := range cases {
+		if got := c.sel.Selects(c.rel, c.isDir); got != c.want {
+			t.Errorf("%+v.Selects(%q, %v) = %v, want %v", c.sel, c.rel, c.isDir, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,392 @@
+// Package newsbuilder — translation export and import.
+package newsbuilder
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	newslogger "github.com/go-i2p/newsgo/logger"
+	"golang.org/x/net/html"
+)
+
+// TranslationTemplateName is the POT file written by ExportTranslations.  It
+// holds every translatable unit of the canonical entries.html with an empty
+// msgstr, for upload to Weblate as the translation template.
+const TranslationTemplateName = "entries.pot"
+
+// Translation unit kinds.  Each article contributes one unit of each kind;
+// the unit's msgctxt is "<article id>#<kind>" so that units stay attached to
+// their article when entries are added, removed, or reordered.
+const (
+	unitHeader  = "header"
+	unitTitle   = "title"
+	unitSummary = "summary"
+	unitBody    = "body"
+)
+
+// sourceArticle is one <article> of an entries HTML file, reduced to the
+// parts that are either translated or carried over unchanged.
+type sourceArticle struct {
+	Attr    []html.Attribute
+	ID      string
+	Title   string
+	Updated string
+	Summary string
+	Body    string
+	// Notes are the editorial notes of the article, exported as extracted
+	// comments of each of its units; see newsfeed.EditorialNotes.
+	Notes []string
+}
+
+// sourceEntries is the translatable content of an entries HTML file.
+type sourceEntries struct {
+	Header   string
+	Articles []sourceArticle
+	// headerFound is set once a file with a <header> element was read.
+	headerFound bool
+}
+
+// readSourceEntries parses the entries at path: the HTML file itself and then
+// the files of its fragment directory in filename order, as the build reads
+// them (see newsfeed.FragmentDir).  Either may be missing, but not both.  The
+// header is that of the first file with one.  Articles without an id
+// attribute are skipped with a log line: without an id a translation cannot
+// be matched back to its article.
+func readSourceEntries(path string) (*sourceEntries, error) {
+	fragments, err := newsfeed.FragmentPaths(path)
+	if err != nil {
+		return nil, fmt.Errorf("readSourceEntries: %w", err)
+	}
+	files := append([]string{path}, fragments...)
+	if _, err := os.Stat(path); err != nil && len(fragments) > 0 {
+		files = fragments
+	}
+	se := &sourceEntries{}
+	for _, file := range files {
+		if err := se.readFile(file); err != nil {
+			return nil, err
+		}
+	}
+	return se, nil
+}
+
+// readFile adds the header, unless se already has one, and the articles of
+// the single entries HTML file at path to se; see readSourceEntries.
+func (se *sourceEntries) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("readSourceEntries: %w", err)
+	}
+	doc, err := html.Parse(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("readSourceEntries: %s: %w", path, err)
+	}
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "header":
+				if !se.headerFound {
+					se.Header = strings.TrimSpace(nodeText(n))
+					se.headerFound = true
+				}
+				return
+			case "article":
+				a := sourceArticle{Attr: n.Attr, ID: attrValue(n, "id"), Title: attrValue(n, "title"), Updated: attrValue(n, "updated"), Notes: newsfeed.EditorialNotes(n)}
+				if a.ID == "" {
+					newslogger.Printf("readSourceEntries: %s: skipping <article> %q without id", path, a.Title)
+					return
+				}
+				var body bytes.Buffer
+				// Only the first <details> child holds the summary, as in
+				// newsfeed; later ones are part of the body.
+				meta := childElement(n, "details")
+				for c := n.FirstChild; c != nil; c = c.NextSibling {
+					if c == meta {
+						if s := childElement(c, "summary"); s != nil {
+							a.Summary = strings.TrimSpace(nodeText(s))
+						}
+						continue
+					}
+					// The notes travel as comments of the units, not
+					// inside the text to translate.
+					if err := newsfeed.RenderWithoutComments(&body, c); err != nil {
+						log.Printf("readSourceEntries: html.Render error: %v", err)
+					}
+				}
+				a.Body = strings.TrimSpace(body.String())
+				se.Articles = append(se.Articles, a)
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+	return nil
+}
+
+// attrValue returns the value of the attribute key on n, or "".
+func attrValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// childElement returns the first direct child element of n named tag.
+func childElement(n *html.Node, tag string) *html.Node {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type == html.ElementNode && c.Data == tag {
+			return c
+		}
+	}
+	return nil
+}
+
+// nodeText returns the concatenated text content of n.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(nodeText(c))
+	}
+	return b.String()
+}
+
+// units returns the translation units of se as msgctxt → source text, in
+// file order.  Empty source strings are not units: there is nothing to
+// translate.  Every unit of an article carries its editorial notes, so a note
+// to translators is seen whichever unit they open.
+func (se *sourceEntries) units() []poEntry {
+	var out []poEntry
+	if se.Header != "" {
+		out = append(out, poEntry{Context: unitHeader, ID: se.Header})
+	}
+	for _, a := range se.Articles {
+		for _, u := range []struct{ kind, text string }{
+			{unitTitle, a.Title}, {unitSummary, a.Summary}, {unitBody, a.Body},
+		} {
+			if u.text != "" {
+				out = append(out, poEntry{Context: a.ID + "#" + u.kind, ID: u.text, Comments: a.Notes})
+			}
+		}
+	}
+	return out
+}
+
+// translationFileLocale returns the locale segment of an
+// "entries.{locale}.html" file name in its on-disk (underscore) form.
+func translationFileLocale(path string) string {
+	return strings.SplitN(filepath.Base(path), ".", 3)[1]
+}
+
+// ExportTranslations writes TranslationTemplateName and one "{locale}.po"
+// file per entries.{locale}.html in translationsDir to outDir.  Each PO file
+// carries the existing translation of every unit whose article is present in
+// the locale file; a translation whose article's updated attribute differs
+// from the source is marked fuzzy so translators see that the English text
+// changed.  The returned slice lists the files written.
+func ExportTranslations(entriesPath, translationsDir, outDir string) ([]string, error) {
+	src, err := readSourceEntries(entriesPath)
+	if err != nil {
+		return nil, fmt.Errorf("ExportTranslations: %w", err)
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, fmt.Errorf("ExportTranslations: %w", err)
+	}
+	units := src.units()
+	var written []string
+	write := func(name string, header []string, entries []poEntry) error {
+		var buf bytes.Buffer
+		if err := writePO(&buf, header, entries); err != nil {
+			return err
+		}
+		path := filepath.Join(outDir, name)
+		if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+			return fmt.Errorf("ExportTranslations: %w", err)
+		}
+		written = append(written, path)
+		return nil
+	}
+	header := []string{"Content-Type: text/plain; charset=UTF-8", "Content-Transfer-Encoding: 8bit"}
+	if err := write(TranslationTemplateName, header, units); err != nil {
+		return written, err
+	}
+
+	updated := make(map[string]string, len(src.Articles))
+	for _, a := range src.Articles {
+		updated[a.ID] = a.Updated
+	}
+	files := DetectTranslationFiles(translationsDir)
+	sort.Strings(files)
+	for _, path := range files {
+		tr, err := readSourceEntries(path)
+		if err != nil {
+			return written, fmt.Errorf("ExportTranslations: %w", err)
+		}
+		byID := make(map[string]sourceArticle, len(tr.Articles))
+		for _, a := range tr.Articles {
+			byID[a.ID] = a
+		}
+		entries := make([]poEntry, len(units))
+		for i, u := range units {
+			entries[i] = u
+			if u.Context == unitHeader {
+				entries[i].Str = tr.Header
+				continue
+			}
+			id, kind := splitUnitContext(u.Context)
+			a, ok := byID[id]
+			if !ok {
+				continue
+			}
+			switch kind {
+			case unitTitle:
+				entries[i].Str = a.Title
+			case unitSummary:
+				entries[i].Str = a.Summary
+			case unitBody:
+				entries[i].Str = a.Body
+			}
+			entries[i].Fuzzy = entries[i].Str != "" && a.Updated != updated[id]
+		}
+		locale := translationFileLocale(path)
+		h := append([]string{"Language: " + locale}, header...)
+		if err := write(locale+".po", h, entries); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// splitUnitContext splits a "<article id>#<kind>" msgctxt.  The kind is
+// taken after the last "#" so that ids containing "#" still round-trip.
+func splitUnitContext(ctx string) (id, kind string) {
+	i := strings.LastIndex(ctx, "#")
+	if i < 0 {
+		return ctx, ""
+	}
+	return ctx[:i], ctx[i+1:]
+}
+
+// TranslationImport summarises the entries.{locale}.html written for one PO
+// file by ImportTranslations.
+type TranslationImport struct {
+	Locale string
+	Path   string
+	// Articles is the number of fully translated articles written; Skipped
+	// counts articles left out because a unit was untranslated or fuzzy.
+	Articles int
+	Skipped  int
+}
+
+// ImportTranslations converts every "{locale}.po" file in fromDir back into
+// an entries.{locale}.html in intoDir, overwriting any existing file.  An
+// article is written only when all of its units have a non-fuzzy
+// translation; its other attributes (id, href, author, dates, ...) are copied
+// from the canonical entries.html at entriesPath, so the translated file
+// never drifts from the source metadata.  Partially translated articles are
+// left out and are served from the English base file by the build.
+func ImportTranslations(entriesPath, fromDir, intoDir string) ([]TranslationImport, error) {
+	src, err := readSourceEntries(entriesPath)
+	if err != nil {
+		return nil, fmt.Errorf("ImportTranslations: %w", err)
+	}
+	poFiles, err := filepath.Glob(filepath.Join(fromDir, "*.po"))
+	if err != nil {
+		return nil, fmt.Errorf("ImportTranslations: %w", err)
+	}
+	if len(poFiles) == 0 {
+		return nil, fmt.Errorf("ImportTranslations: no .po files in %s", fromDir)
+	}
+	sort.Strings(poFiles)
+	if err := os.MkdirAll(intoDir, 0o755); err != nil {
+		return nil, fmt.Errorf("ImportTranslations: %w", err)
+	}
+	var results []TranslationImport
+	for _, poPath := range poFiles {
+		f, err := os.Open(poPath)
+		if err != nil {
+			return results, fmt.Errorf("ImportTranslations: %w", err)
+		}
+		entries, err := readPO(f)
+		f.Close()
+		if err != nil {
+			return results, fmt.Errorf("ImportTranslations: %s: %w", poPath, err)
+		}
+		translated := make(map[string]string, len(entries))
+		for _, e := range entries {
+			if !e.Fuzzy && e.Str != "" {
+				translated[e.Context] = e.Str
+			}
+		}
+		// Weblate names files by locale code, either "pt_BR" or "pt-BR";
+		// translation files always use the underscore form.
+		locale := strings.ReplaceAll(strings.TrimSuffix(filepath.Base(poPath), ".po"), "-", "_")
+		res := TranslationImport{Locale: locale, Path: filepath.Join(intoDir, "entries."+locale+".html")}
+		doc := renderTranslatedEntries(src, translated, &res)
+		if err := os.WriteFile(res.Path, doc, 0o644); err != nil {
+			return results, fmt.Errorf("ImportTranslations: %w", err)
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// renderTranslatedEntries writes the entries HTML for one locale and records
+// article counts in res.
+func renderTranslatedEntries(src *sourceEntries, translated map[string]string, res *TranslationImport) []byte {
+	var b bytes.Buffer
+	b.WriteString("<html><body>\n")
+	if h, ok := translated[unitHeader]; ok {
+		fmt.Fprintf(&b, "<header>%s</header>\n", html.EscapeString(h))
+	}
+	for _, a := range src.Articles {
+		tr := func(kind, source string) (string, bool) {
+			if source == "" {
+				return "", true
+			}
+			s, ok := translated[a.ID+"#"+kind]
+			return s, ok
+		}
+		title, okTitle := tr(unitTitle, a.Title)
+		summary, okSummary := tr(unitSummary, a.Summary)
+		body, okBody := tr(unitBody, a.Body)
+		if !okTitle || !okSummary || !okBody {
+			res.Skipped++
+			continue
+		}
+		res.Articles++
+		b.WriteString("<article")
+		for _, attr := range a.Attr {
+			val := attr.Val
+			if attr.Key == "title" {
+				val = title
+			}
+			fmt.Fprintf(&b, " %s=\"%s\"", attr.Key, html.EscapeString(val))
+		}
+		b.WriteString(">\n")
+		if a.Summary != "" {
+			fmt.Fprintf(&b, "<details><summary>%s</summary></details>\n", html.EscapeString(summary))
+		}
+		if body != "" {
+			b.WriteString(body)
+			b.WriteString("\n")
+		}
+		b.WriteString("</article>\n")
+	}
+	b.WriteString("</body></html>\n")
+	return b.Bytes()
+}
@@ -0,0 +1,319 @@
+package newsbuilder
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+)
+
+const translationSource = `<html><body>
+<header>I2P News</header>
+<article id="urn:test:1" title="Release &amp; more" href="http://example.com/1" author="A" published="2024-01-01" updated="2024-01-02">
+<details><summary>First summary</summary></details>
+<p>First body</p>
+<p>Second <b>line</b></p>
+</article>
+<article id="urn:test:2" title="Second" href="http://example.com/2" author="B" published="2024-02-01" updated="2024-02-02">
+<details><summary>Second summary</summary></details>
+<p>Other body</p>
+</article>
+</body></html>
+`
+
+const translationDE = `<html><body>
+<header>I2P Neuigkeiten</header>
+<article id="urn:test:1" title="Veröffentlichung" href="http://example.com/1" author="A" published="2024-01-01" updated="2024-01-02">
+<details><summary>Erste Zusammenfassung</summary></details>
+<p>Erster Text</p>
+</article>
+<article id="urn:test:2" title="Zweite" href="http://example.com/2" author="B" published="2024-02-01" updated="2024-01-15">
+<details><summary>Zweite Zusammenfassung</summary></details>
+<p>Anderer Text</p>
+</article>
+</body></html>
+`
+
+// writeTranslationFixtures writes the canonical entries.html and a German
+// translation and returns their paths.
+func writeTranslationFixtures(t *testing.T, dir string) (entries, transDir string) {
+	t.Helper()
+	entries = filepath.Join(dir, "entries.html")
+	transDir = filepath.Join(dir, "translations")
+	if err := os.MkdirAll(transDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entries, []byte(translationSource), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(transDir, "entries.de.html"), []byte(translationDE), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return entries, transDir
+}
+
+// readPOFile parses the PO file at path into msgctxt → entry.
+func readPOFile(t *testing.T, path string) map[string]poEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	entries, err := readPO(f)
+	if err != nil {
+		t.Fatalf("readPO(%s): %v", path, err)
+	}
+	m := make(map[string]poEntry, len(entries))
+	for _, e := range entries {
+		m[e.Context] = e
+	}
+	return m
+}
+
+// TestExportTranslations verifies the template and the per-locale PO file,
+// including fuzzy marking of translations older than the source article.
+func TestExportTranslations(t *testing.T) {
+	dir := t.TempDir()
+	entries, transDir := writeTranslationFixtures(t, dir)
+	out := filepath.Join(dir, "export")
+	written, err := ExportTranslations(entries, transDir, out)
+	if err != nil {
+		t.Fatalf("ExportTranslations: %v", err)
+	}
+	if len(written) != 2 {
+		t.Fatalf("written = %v, want entries.pot and de.po", written)
+	}
+
+	pot := readPOFile(t, filepath.Join(out, TranslationTemplateName))
+	if got := pot["urn:test:1#title"].ID; got != "Release & more" {
+		t.Errorf("title msgid = %q, want unescaped source title", got)
+	}
+	if got := pot["urn:test:1#body"].ID; !strings.Contains(got, `<p>Second <b>line</b></p>`) || !strings.Contains(got, "\n") {
+		t.Errorf("body msgid = %q, want multi-line HTML body", got)
+	}
+	if pot["urn:test:1#title"].Str != "" {
+		t.Error("template msgstr must be empty")
+	}
+
+	de := readPOFile(t, filepath.Join(out, "de.po"))
+	if e := de[unitHeader]; e.Str != "I2P Neuigkeiten" {
+		t.Errorf("header msgstr = %q", e.Str)
+	}
+	if e := de["urn:test:1#summary"]; e.Str != "Erste Zusammenfassung" || e.Fuzzy {
+		t.Errorf("urn:test:1 summary = %+v, want current translation", e)
+	}
+	if e := de["urn:test:2#title"]; e.Str != "Zweite" || !e.Fuzzy {
+		t.Errorf("urn:test:2 title = %+v, want fuzzy translation (updated differs)", e)
+	}
+}
+
+// TestExportTranslations_Notes verifies that the HTML comments of an article
+// are exported as extracted comments of its units rather than inside the
+// body to translate.
+func TestExportTranslations_Notes(t *testing.T) {
+	dir := t.TempDir()
+	entries := filepath.Join(dir, "entries.html")
+	src := strings.Replace(translationSource, "<p>Other body</p>", "<!-- keep \"SAM\" in English -->\n<p>Other <!-- 2.5.0? -->body</p>", 1)
+	if err := os.WriteFile(entries, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "export")
+	if _, err := ExportTranslations(entries, filepath.Join(dir, "none"), out); err != nil {
+		t.Fatalf("ExportTranslations: %v", err)
+	}
+	pot := readPOFile(t, filepath.Join(out, TranslationTemplateName))
+	body := pot["urn:test:2#body"]
+	if strings.Contains(body.ID, "<!--") || !strings.Contains(body.ID, "<p>Other body</p>") {
+		t.Errorf("body msgid = %q, want the body without comments", body.ID)
+	}
+	want := []string{`keep "SAM" in English`, "2.5.0?"}
+	for _, kind := range []string{unitTitle, unitSummary, unitBody} {
+		if got := pot["urn:test:2#"+kind].Comments; strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("%s comments = %q, want %q", kind, got, want)
+		}
+	}
+	if got := pot["urn:test:1#body"].Comments; len(got) != 0 {
+		t.Errorf("urn:test:1 comments = %q, want none", got)
+	}
+}
+
+// TestReadSourceEntries_MultipleDetails verifies that only the first
+// <details> child of an article supplies the summary unit and that further
+// <details> blocks stay in the body unit.
+func TestReadSourceEntries_MultipleDetails(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "entries.html")
+	src := `<html><body><article id="urn:test:1" title="T" updated="2024-01-02">
+<details><summary>The summary</summary></details>
+<p>Body</p>
+<details><summary>Changelog</summary><p>fix</p></details>
+</article></body></html>`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	se, err := readSourceEntries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(se.Articles) != 1 {
+		t.Fatalf("articles = %d, want 1", len(se.Articles))
+	}
+	a := se.Articles[0]
+	if a.Summary != "The summary" {
+		t.Errorf("Summary = %q, want %q", a.Summary, "The summary")
+	}
+	if !strings.Contains(a.Body, "<summary>Changelog</summary>") || strings.Contains(a.Body, "The summary") {
+		t.Errorf("Body = %q, want the second <details> block only", a.Body)
+	}
+}
+
+// TestReadSourceEntries_Fragments verifies that the articles of the fragment
+// directory follow those of the entries file, and that an article kept only
+// in a fragment is exported and imported like any other.
+func TestReadSourceEntries_Fragments(t *testing.T) {
+	dir := t.TempDir()
+	entries := filepath.Join(dir, "entries.html")
+	if err := os.WriteFile(entries, []byte(`<html><body><header>News</header>
+<article id="urn:test:1" title="One" updated="2024-01-01"><details><summary>S1</summary></details><p>B1</p></article>
+</body></html>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fragDir := newsfeed.FragmentDir(entries)
+	if err := os.Mkdir(fragDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fragDir, "2.html"), []byte(`<header>Ignored</header>
+<article id="urn:test:2" title="Two" updated="2024-01-02"><details><summary>S2</summary></details><p>B2</p></article>`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	se, err := readSourceEntries(entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if se.Header != "News" || len(se.Articles) != 2 || se.Articles[1].ID != "urn:test:2" {
+		t.Fatalf("header %q, articles %+v; want News and both articles in order", se.Header, se.Articles)
+	}
+
+	export := filepath.Join(dir, "export")
+	if _, err := ExportTranslations(entries, filepath.Join(dir, "translations"), export); err != nil {
+		t.Fatalf("ExportTranslations: %v", err)
+	}
+	pot, err := os.ReadFile(filepath.Join(export, TranslationTemplateName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(pot), `msgctxt "urn:test:2#body"`) {
+		t.Errorf("%s lacks the units of the fragment-only article:\n%s", TranslationTemplateName, pot)
+	}
+	po := `msgctxt "urn:test:2#title"
+msgid "Two"
+msgstr "Zwei"
+
+msgctxt "urn:test:2#summary"
+msgid "S2"
+msgstr "Z2"
+
+msgctxt "urn:test:2#body"
+msgid "<p>B2</p>"
+msgstr "<p>T2</p>"
+`
+	if err := os.WriteFile(filepath.Join(export, "de.po"), []byte(po), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	results, err := ImportTranslations(entries, export, filepath.Join(dir, "imported"))
+	if err != nil {
+		t.Fatalf("ImportTranslations: %v", err)
+	}
+	if len(results) != 1 || results[0].Articles != 1 {
+		t.Fatalf("results = %+v, want the fragment-only article imported", results)
+	}
+	data, err := os.ReadFile(results[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `id="urn:test:2"`) || !strings.Contains(string(data), "Zwei") {
+		t.Errorf("imported entries lack the translated fragment-only article:\n%s", data)
+	}
+}
+
+// TestImportTranslations_RoundTrip verifies that export followed by import
+// reproduces the current translations, that fuzzy articles are left out, and
+// that the result parses as an entries file with source metadata.
+func TestImportTranslations_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	entries, transDir := writeTranslationFixtures(t, dir)
+	export := filepath.Join(dir, "export")
+	if _, err := ExportTranslations(entries, transDir, export); err != nil {
+		t.Fatalf("ExportTranslations: %v", err)
+	}
+	into := filepath.Join(dir, "imported")
+	results, err := ImportTranslations(entries, export, into)
+	if err != nil {
+		t.Fatalf("ImportTranslations: %v", err)
+	}
+	if len(results) != 1 || results[0].Locale != "de" || results[0].Articles != 1 || results[0].Skipped != 1 {
+		t.Fatalf("results = %+v, want de with 1 article and 1 skipped", results)
+	}
+
+	f := newsfeed.Feed{EntriesHTMLPath: results[0].Path}
+	if err := f.LoadHTML(); err != nil {
+		t.Fatalf("LoadHTML: %v", err)
+	}
+	if f.HeaderTitle != "I2P Neuigkeiten" || f.Length() != 1 {
+		t.Fatalf("header %q, %d articles; want translated header and 1 article", f.HeaderTitle, f.Length())
+	}
+	a := f.Article(0)
+	if a.UID != "urn:test:1" || a.Title != "Veröffentlichung" || a.Link != "http://example.com/1" || a.UpdatedDate != "2024-01-02T00:00:00Z" {
+		t.Errorf("article = %+v, want translated title with source metadata", a)
+	}
+	if a.Summary != "Erste Zusammenfassung" || !strings.Contains(a.Content(), "Erster Text") {
+		t.Errorf("summary %q / content %q not translated", a.Summary, a.Content())
+	}
+}
+
+// TestImportTranslations_NoPOFiles verifies that an empty export directory is
+// reported instead of silently writing nothing.
+func TestImportTranslations_NoPOFiles(t *testing.T) {
+	dir := t.TempDir()
+	entries, _ := writeTranslationFixtures(t, dir)
+	if _, err := ImportTranslations(entries, t.TempDir(), filepath.Join(dir, "out")); err == nil {
+		t.Fatal("expected error for directory without .po files")
+	}
+}
+
+// TestReadPO verifies parsing of the PO features produced by Weblate:
+// multi-line strings, escapes, flags, comments, and the header entry.
+func TestReadPO(t *testing.T) {
+	const po = `# Translators
+msgid ""
+msgstr ""
+"Language: fr\n"
+
+#: entries.html
+#, fuzzy, html-format
+msgctxt "a#body"
+msgid ""
+"<p>one</p>\n"
+"<p>\"two\"</p>"
+msgstr "<p>un</p>\n<p>deux</p>"
+
+msgctxt "a#title"
+msgid "Title"
+msgstr "Titre"
+`
+	entries, err := readPO(strings.NewReader(po))
+	if err != nil {
+		t.Fatalf("readPO: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	if e := entries[0]; e.Context != "a#body" || e.ID != "<p>one</p>\n<p>\"two\"</p>" || e.Str != "<p>un</p>\n<p>deux</p>" || !e.Fuzzy {
+		t.Errorf("entry 0 = %+v", e)
+	}
+	if e := entries[1]; e.Context != "a#title" || e.Str != "Titre" || e.Fuzzy {
+		t.Errorf("entry 1 = %+v", e)
+	}
+}
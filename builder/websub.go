@@ -0,0 +1,41 @@
+package newsbuilder
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// PublishToHub notifies the WebSub hub at hub that the feeds at topics have
+// changed, so that it fetches them and pushes the update to their
+// subscribers.  topics are the rel="self" URLs of the feeds, which is what
+// subscribers subscribe to.  The notification is the conventional publish
+// ping: a form POST with hub.mode=publish and one hub.url per topic.  Any 2xx
+// response is success.  client may be nil to use http.DefaultClient.
+func PublishToHub(ctx context.Context, client *http.Client, hub string, topics []string) error {
+	if len(topics) == 0 {
+		return nil
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	form := url.Values{"hub.mode": {"publish"}, "hub.url": topics}
+	rq, err := http.NewRequestWithContext(ctx, http.MethodPost, hub, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("PublishToHub: %w", err)
+	}
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(rq)
+	if err != nil {
+		return fmt.Errorf("PublishToHub: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("PublishToHub: %s answered %s: %s", hub, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
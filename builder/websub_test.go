@@ -0,0 +1,62 @@
+package newsbuilder
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestBuild_HubLink verifies that a configured hub is advertised with a
+// rel="hub" link and that none is emitted otherwise.
+func TestBuild_HubLink(t *testing.T) {
+	nb := writeFixtures(t, t.TempDir())
+	nb.Hub = "https://hub.example.org/?a=1&b=2"
+	feed, err := nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if want := `<link href="https://hub.example.org/?a=1&amp;b=2" rel="hub"/>`; !strings.Contains(feed, want) {
+		t.Errorf("feed lacks %s:\n%s", want, feed)
+	}
+
+	nb = writeFixtures(t, t.TempDir())
+	feed, err = nb.Build()
+	if err != nil {
+		t.Fatalf("Build() failed: %v", err)
+	}
+	if strings.Contains(feed, `rel="hub"`) {
+		t.Errorf("feed without Hub has a hub link:\n%s", feed)
+	}
+}
+
+// TestPublishToHub verifies the publish ping sent to the hub and that a
+// non-2xx answer is an error.
+func TestPublishToHub(t *testing.T) {
+	status := http.StatusNoContent
+	var got []string
+	hub := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, rq *http.Request) {
+		if err := rq.ParseForm(); err != nil || rq.Method != http.MethodPost || rq.PostForm.Get("hub.mode") != "publish" {
+			rw.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		got = rq.PostForm["hub.url"]
+		rw.WriteHeader(status)
+	}))
+	defer hub.Close()
+
+	topics := []string{"https://news.example.org/news.atom.xml", "https://news.example.org/news_de.atom.xml"}
+	if err := PublishToHub(context.Background(), nil, hub.URL, topics); err != nil {
+		t.Fatalf("PublishToHub: %v", err)
+	}
+	if !reflect.DeepEqual(got, topics) {
+		t.Errorf("hub.url = %q, want %q", got, topics)
+	}
+
+	status = http.StatusTooManyRequests
+	if err := PublishToHub(context.Background(), hub.Client(), hub.URL, topics); err == nil {
+		t.Error("PublishToHub: want error for 429")
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+	server "github.com/go-i2p/newsgo/server"
+)
+
+// drainTimeout bounds how long a graceful stop waits for in-flight requests.
+const drainTimeout = 30 * time.Second
+
+// listeners holds the http.Servers started by serveHTTP and serveGarlic so
+// that a graceful stop can drain them.
+var listeners struct {
+	sync.Mutex
+	servers []*http.Server
+}
+
+// trackServer registers srv with listeners.
+func trackServer(srv *http.Server) {
+	listeners.Lock()
+	listeners.servers = append(listeners.servers, srv)
+	listeners.Unlock()
+}
+
+// drainListeners stops every tracked server from accepting connections and
+// waits, until ctx is done, for their in-flight requests to finish.
+func drainListeners(ctx context.Context) {
+	listeners.Lock()
+	servers := listeners.servers
+	listeners.servers = nil
+	listeners.Unlock()
+	var wg sync.WaitGroup
+	for _, srv := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				log.Printf("drainListeners: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// stopOnce makes stopServer run once when a signal and /admin/shutdown race.
+var stopOnce sync.Once
+
+// stopServer drains the listeners, flushes the request log, persists the
+// stats of s and exits.  It is shared by the signal handler and
+// /admin/shutdown.
+func stopServer(s *server.NewsServer) {
+	stopOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+		drainListeners(ctx)
+		cancel()
+		if s.RequestLog != nil {
+			s.RequestLog.Flush()
+		}
+		// Log any stats persistence failure so operators know the
+		// download counters were lost (e.g. read-only stats file).
+		if err := s.Stats.Save(); err != nil {
+			log.Printf("Stats.Save: %v", err)
+		}
+		os.Exit(0)
+	})
+}
+
+// reloadServer persists the stats of s and drops its cached pages, so that a
+// rebuilt tree is listed at once.
+func reloadServer(s *server.NewsServer) error {
+	if err := s.Stats.Save(); err != nil {
+		return fmt.Errorf("Stats.Save: %w", err)
+	}
+	s.FlushCaches()
+	return nil
+}
+
+// readAdminToken returns the bearer token stored in path, without
+// surrounding whitespace.
+func readAdminToken(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("--admin needs --admin-token-file")
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("--admin-token-file: %w", err)
+	}
+	token := strings.TrimSpace(string(b))
+	if token == "" {
+		return "", fmt.Errorf("--admin-token-file %q is empty", path)
+	}
+	return token, nil
+}
+
+// startAdminListener starts the --admin listener of the serve command in the
+// background when addr is set.  Like --pprof it must be a loopback address;
+// a bad address or token file is fatal before any other listener starts.
+func startAdminListener(s *server.NewsServer, addr, tokenFile string) {
+	if addr == "" {
+		return
+	}
+	if err := checkLoopbackAddr("--admin", addr); err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+	token, err := readAdminToken(tokenFile)
+	if err != nil {
+		log.Fatalf("serve: %v", err)
+	}
+	h := &server.AdminHandler{
+		Token:    token,
+		Shutdown: func() { stopServer(s) },
+		Reload:   func() error { return reloadServer(s) },
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		log.Fatalf("serve: --admin: %v", err)
+	}
+	newslogger.Printf("serveAdmin: %s and %s on http://%s", server.AdminShutdownPath, server.AdminReloadPath, ln.Addr())
+	srv := &http.Server{Handler: h, ReadHeaderTimeout: 30 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("serveAdmin: %v (admin listener disabled)", err)
+		}
+	}()
+}
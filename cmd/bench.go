@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	signer "github.com/go-i2p/newsgo/signer"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// benchCmd represents the bench command
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Time the build and sign pipeline over the full locale matrix",
+	Long: `bench builds and signs every feed of a data tree, for the default tree and
+every platform and status, --iterations times into a temporary directory and
+times each stage separately:
+
+  parse   loading and parsing the entries files
+  build   rendering the header, blocklist, release, and entries
+  format  formatting the finished feed
+  sign    packaging and signing the su3, and verifying it
+
+It prints the fastest, mean, and slowest run of each stage over all feeds.
+Save a run with --save and compare a later one against it with --baseline to
+catch performance regressions before a release.
+
+Without --signingkey a throwaway RSA-4096 key is generated, like the ones
+setup creates.
+
+Examples:
+  newsgo bench --data data/ --iterations 5 --save bench.json
+  newsgo bench --data data/ --baseline bench.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		viper.Unmarshal(c)
+
+		// The flags below share their names with build and sign, so they
+		// are not bound to viper (see init) and are read from this
+		// command's own flag set instead.
+		flags := cmd.Flags()
+		dataDir, _ := flags.GetString("data")
+		iterations, _ := flags.GetInt("iterations")
+		keyPath, _ := flags.GetString("signingkey")
+		certPath, _ := flags.GetString("signercert")
+		signerID, _ := flags.GetString("signerid")
+		baselinePath, _ := flags.GetString("baseline")
+		savePath, _ := flags.GetString("save")
+		if iterations < 1 {
+			log.Fatalf("bench: --iterations must be at least 1, got %d", iterations)
+		}
+
+		var baseline *benchReport
+		if baselinePath != "" {
+			b, err := readBenchReport(baselinePath)
+			if err != nil {
+				log.Fatalf("bench: --baseline: %v", err)
+			}
+			baseline = &b
+		}
+
+		work, err := os.MkdirTemp("", "newsgo-bench-")
+		if err != nil {
+			log.Fatalf("bench: %v", err)
+		}
+		defer os.RemoveAll(work)
+		ns, err := benchSigner(keyPath, certPath, signerID, work)
+		if err != nil {
+			log.Fatalf("bench: %v", err)
+		}
+
+		c.NewsFile = dataDir
+		c.ReleaseJsonFile = filepath.Join(dataDir, "releases.json")
+		c.BlockList = filepath.Join(dataDir, "blocklist.xml")
+		c.TranslationsDir = ""
+		report, err := runBench(iterations, ns, filepath.Join(work, "build"))
+		if err != nil {
+			log.Fatalf("bench: %v", err)
+		}
+		printBenchReport(os.Stdout, report, baseline)
+		if savePath != "" {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				log.Fatalf("bench: %v", err)
+			}
+			if err := os.WriteFile(savePath, append(data, '\n'), 0o644); err != nil {
+				log.Fatalf("bench: --save: %v", err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+
+	benchCmd.Flags().String("data", "data", "data tree to build, as for build --newsfile")
+	benchCmd.Flags().Int("iterations", 5, "number of times the whole locale matrix is built and signed")
+	benchCmd.Flags().String("signingkey", "", "key to sign with, as for sign; empty generates a throwaway RSA-4096 key")
+	benchCmd.Flags().String("signercert", "", "PEM certificate for --signingkey; without one every su3 is verified against a self-signed certificate, which costs a further signature per feed")
+	benchCmd.Flags().String("signerid", "bench@example.i2p", "ID to sign with")
+	benchCmd.Flags().String("baseline", "", "report saved by an earlier --save to compare the mean of each stage against")
+	benchCmd.Flags().String("save", "", "write the report to this file as JSON, for a later --baseline")
+	// None of the flags are bound to viper: data, signingkey, signercert, and
+	// signerid would repoint the keys of build and sign at this command.
+}
+
+// Stages bench times, in pipeline order.
+const (
+	benchParse  = "parse"
+	benchBuild  = "build"
+	benchFormat = "format"
+	benchSign   = "sign"
+)
+
+var benchStages = []string{benchParse, benchBuild, benchFormat, benchSign}
+
+// benchStats summarises one stage over all iterations.  Each sample is the
+// time the stage took for every feed of the matrix together.
+type benchStats struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	Max  time.Duration `json:"max"`
+}
+
+// benchReport is the result of a bench run, as printed and saved.
+type benchReport struct {
+	Feeds      int                   `json:"feeds"`
+	Iterations int                   `json:"iterations"`
+	Stages     map[string]benchStats `json:"stages"`
+}
+
+// readBenchReport reads a report written by --save.
+func readBenchReport(path string) (benchReport, error) {
+	var r benchReport
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return r, err
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// benchSigner returns the NewsSigner bench signs with: the key at keyPath
+// with the certificate at certPath, or, when keyPath is empty, a key and
+// certificate generated into dir.
+func benchSigner(keyPath, certPath, signerID, dir string) (*signer.NewsSigner, error) {
+	if keyPath == "" {
+		keyPath = filepath.Join(dir, "bench_key.pem")
+		certPath = filepath.Join(dir, "bench.crt")
+		if err := generateSigningKey(keyPath, certPath, signerID); err != nil {
+			return nil, err
+		}
+	}
+	key, err := loadKey(keyPath, "", "", signerID)
+	if err != nil {
+		return nil, err
+	}
+	ns := &signer.NewsSigner{SignerID: signerID, SigningKey: key}
+	if certPath != "" {
+		certs, err := newsfetch.LoadCertificates([]string{certPath})
+		if err != nil {
+			return nil, err
+		}
+		ns.Certificate = certs[0]
+	}
+	return ns, nil
+}
+
+// runBench builds and signs every feed of the data tree named by the current
+// config into buildDir iterations times, and summarises the time each stage
+// took per iteration.  Any feed failing to build or sign fails the run, since
+// its timings would not be comparable.
+func runBench(iterations int, ns *signer.NewsSigner, buildDir string) (benchReport, error) {
+	report := benchReport{Iterations: iterations, Stages: make(map[string]benchStats)}
+	samples := make(map[string][]time.Duration)
+	for i := 0; i < iterations; i++ {
+		totals, feeds, err := benchIteration(ns, buildDir)
+		if err != nil {
+			return report, err
+		}
+		report.Feeds = feeds
+		for _, stage := range benchStages {
+			samples[stage] = append(samples[stage], totals[stage])
+		}
+	}
+	if report.Feeds == 0 {
+		return report, fmt.Errorf("no feeds to build in %s", c.NewsFile)
+	}
+	for stage, durations := range samples {
+		report.Stages[stage] = benchSummary(durations)
+	}
+	return report, nil
+}
+
+// benchIteration builds and signs every feed once, returning the total time
+// of each stage and the number of feeds.
+func benchIteration(ns *signer.NewsSigner, buildDir string) (map[string]time.Duration, int, error) {
+	totals := make(map[string]time.Duration)
+	feeds := 0
+	for _, pr := range collectBuildPairs("", "") {
+		plan, ok := planPlatform(pr.platform, pr.status)
+		if !ok {
+			continue
+		}
+		var overrides builder.FeedOverrides
+		if pr.platform != "" {
+			var err error
+			if overrides, err = builder.LoadFeedOverrides(plan.dataDir); err != nil {
+				return nil, 0, err
+			}
+		}
+		for _, src := range plan.sources {
+			news := platformBuilder(src, plan.releasesPath, plan.blocklistPath, plan.canonicalEntries, pr.platform, pr.status, overrides)
+			feed, err := news.Build()
+			if err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", src.Path, err)
+			}
+			out := filepath.Join(buildDir, feedOutputFilename(src, plan.dataDir, pr.platform, pr.status))
+			if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+				return nil, 0, err
+			}
+			if err := os.WriteFile(out, []byte(feed), 0o644); err != nil {
+				return nil, 0, err
+			}
+			start := time.Now()
+			if err := ns.CreateSu3(out); err != nil {
+				return nil, 0, fmt.Errorf("%s: %w", out, err)
+			}
+			t := news.Timings()
+			totals[benchParse] += t.Parse
+			totals[benchBuild] += t.Render
+			totals[benchFormat] += t.Format
+			totals[benchSign] += time.Since(start)
+			feeds++
+		}
+	}
+	return totals, feeds, nil
+}
+
+// benchSummary returns the fastest, mean, and slowest of durations, which must
+// not be empty.
+func benchSummary(durations []time.Duration) benchStats {
+	s := benchStats{Min: durations[0], Max: durations[0]}
+	var sum time.Duration
+	for _, d := range durations {
+		s.Min = min(s.Min, d)
+		s.Max = max(s.Max, d)
+		sum += d
+	}
+	s.Mean = sum / time.Duration(len(durations))
+	return s
+}
+
+// printBenchReport writes one row per stage and a total row to w.  With a
+// baseline each row also shows the baseline mean and the change of the mean
+// against it.
+func printBenchReport(w io.Writer, r benchReport, baseline *benchReport) {
+	fmt.Fprintf(w, "%d feed(s), %d iteration(s)\n", r.Feeds, r.Iterations)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "STAGE\tMIN\tMEAN\tMAX"
+	if baseline != nil {
+		header += "\tBASELINE\tCHANGE"
+	}
+	fmt.Fprintln(tw, header)
+	var total, baseTotal benchStats
+	row := func(name string, s, base benchStats) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s", name, roundDuration(s.Min), roundDuration(s.Mean), roundDuration(s.Max))
+		if baseline != nil {
+			fmt.Fprintf(tw, "\t%s\t%s", roundDuration(base.Mean), benchChange(base.Mean, s.Mean))
+		}
+		fmt.Fprintln(tw)
+	}
+	for _, stage := range benchStages {
+		s := r.Stages[stage]
+		var base benchStats
+		if baseline != nil {
+			base = baseline.Stages[stage]
+		}
+		row(stage, s, base)
+		total.Min += s.Min
+		total.Mean += s.Mean
+		total.Max += s.Max
+		baseTotal.Mean += base.Mean
+	}
+	row("total", total, baseTotal)
+	tw.Flush()
+}
+
+// roundDuration rounds d for display.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// benchChange formats the relative change from base to d, e.g. "+12.5%", or "-"
+// when there is no base to compare with.
+func benchChange(base, d time.Duration) string {
+	if base <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", 100*(float64(d)-float64(base))/float64(base))
+}
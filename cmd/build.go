@@ -1,13 +1,23 @@
 package cmd
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	builder "github.com/go-i2p/newsgo/builder"
-	"github.com/google/uuid"
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	newslogger "github.com/go-i2p/newsgo/logger"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -32,20 +42,88 @@ var buildCmd = &cobra.Command{
 		if bd, err := cmd.Flags().GetString("builddir"); err == nil {
 			c.BuildDir = bd
 		}
+		selectionFlags(cmd)
+
+		buildSource = gitSource{}
+		if c.GitRef != "" {
+			src, cleanup, err := checkoutGitRef(c.GitRef, c.Repo)
+			if err != nil {
+				log.Fatalf("build: --git-ref: %v", err)
+			}
+			// log.Fatalf below skips this, leaving the checkout in the
+			// temporary directory; only a completed build cleans up.
+			defer cleanup()
+			buildSource = src
+			newslogger.Printf("build: building from %s (%s)", src.Ref, src.Commit)
+		}
 
 		f, e := os.Stat(c.NewsFile)
 		if e != nil {
 			log.Fatalf("build: stat %s: %v", c.NewsFile, e)
 		}
+		// signercert and cert-min-days are shared with signCmd, whose viper
+		// binding wins; see builddir above.
+		if cmd.Flags().Changed("signercert") {
+			c.SignerCert, _ = cmd.Flags().GetString("signercert")
+		}
+		if cmd.Flags().Changed("cert-min-days") {
+			c.CertMinDays, _ = cmd.Flags().GetInt("cert-min-days")
+		}
+		if err := checkCertExpiry("build", c.SignerCert, c.CertMinDays, time.Now()); err != nil {
+			log.Fatalf("build: %v", err)
+		}
+		blocklistCerts = nil
+		if len(c.BlocklistCerts) > 0 {
+			certs, err := newsfetch.LoadCertificates(c.BlocklistCerts)
+			if err != nil {
+				log.Fatalf("build: --blocklist-certs: %v", err)
+			}
+			blocklistCerts = certs
+		}
+		fallbacks, err := builder.ParseLocaleFallbacks(c.LocaleFallbacks)
+		if err != nil {
+			log.Fatalf("build: --locale-fallback: %v", err)
+		}
+		localeFallbacks = fallbacks
+		cutoff, err := parseChangeCutoff(c.ChangedSince, c.SinceLastBuild)
+		if err != nil {
+			log.Fatalf("build: %v", err)
+		}
+		buildCutoff = cutoff
+		if err := idStrategy().Validate(); err != nil {
+			log.Fatalf("build: --id-strategy: %v", err)
+		}
+		if feedIcon, err = feedImage(c.FeedIcon); err != nil {
+			log.Fatalf("build: --feedicon: %v", err)
+		}
+		if feedLogo, err = feedImage(c.FeedLogo); err != nil {
+			log.Fatalf("build: --feedlogo: %v", err)
+		}
+		builtFeeds = nil
+		overBudgetFeeds = 0
+		unchangedFeeds = 0
+		buildReport = newRunReport("build", resultBuilt, resultSkipped, resultErrored)
 		if !f.IsDir() {
 			// Single-file mode: unchanged behaviour.
 			build(c.NewsFile)
-			return
+		} else {
+			// Directory mode: determine the (platform, status) pairs to build.
+			for _, pr := range collectBuildPairs(c.Platform, c.Status) {
+				buildPlatform(pr.platform, pr.status)
+			}
 		}
-
-		// Directory mode: determine the (platform, status) pairs to build.
-		for _, pr := range collectBuildPairs(c.Platform, c.Status) {
-			buildPlatform(pr.platform, pr.status)
+		writeFeedIndex()
+		written := len(builtFeeds) - unchangedFeeds
+		var note string
+		if overBudgetFeeds > 0 {
+			note = fmt.Sprintf("%d feed(s) over size budget", overBudgetFeeds)
+		}
+		buildReport.print(os.Stdout, useColor(os.Stdout), note)
+		if c.StrictSize && overBudgetFeeds > 0 {
+			log.Fatalf("build: %d feed(s) over size budget (--strict-size)", overBudgetFeeds)
+		}
+		if c.WebSubHub != "" && c.WebSubPing && written > 0 {
+			publishToHub()
 		}
 	},
 }
@@ -62,14 +140,42 @@ func init() {
 	buildCmd.Flags().String("releasejson", "data/releases.json", "json file describing an update to pass to news generator")
 	buildCmd.Flags().String("feedtitle", "I2P News", "title to use for the RSS feed to pass to news generator")
 	buildCmd.Flags().String("feedsubtitle", "News feed, and router updates", "subtitle to use for the RSS feed to pass to news generator")
+	buildCmd.Flags().String("rights", "", "rights statement of the feeds, e.g. \"Copyright The I2P Project, CC BY-SA 4.0\"; articles may set their own with a license attribute")
+	buildCmd.Flags().String("license-uri", "", "URL of the license of the feeds, advertised with a rel=\"license\" link")
+	buildCmd.Flags().String("feedicon", "", "small square image of the feeds, e.g. a favicon, as a URL or a local file copied into --builddir")
+	buildCmd.Flags().String("feedlogo", "", "larger image of the feeds, twice as wide as high, as a URL or a local file copied into --builddir")
+	buildCmd.Flags().String("channel-title-prefix", "[{status}] ", "prefix of the title of beta, rc and alpha feeds; {status} is replaced by the status")
 	buildCmd.Flags().String("feedsite", "http://i2p-projekt.i2p", "site for the RSS feed to pass to news generator")
 	buildCmd.Flags().String("feedmain", defaultFeedURL(), "Primary newsfeed for updates to pass to news generator")
 	buildCmd.Flags().String("feedbackup", "http://dn3tvalnjz432qkqsvpfdqrwpqkw3ye4n4i2uyfr4jexvo3sp5ka.b32.i2p/news/news.atom.xml", "Backup newsfeed for updates to pass to news generator")
 	// Flag name matches README: --feeduri (was incorrectly "feeduid").
 	// config.Conf.FeedUuid carries the mapstructure:"feeduri" tag.
-	buildCmd.Flags().String("feeduri", "", "UUID to use for the RSS feed to pass to news generator. Random if omitted")
+	buildCmd.Flags().String("feeduri", "", "base UUID for the feed ids; per-locale/platform ids are derived from it. Derived from --feedmain if omitted")
+	buildCmd.Flags().String("id-strategy", builder.IDStrategyUUID5, "how feed ids are formed: uuid5 (derived from --feeduri), uuid-random (new on every build), or tag-uri (from --tag-authority and --tag-date)")
+	buildCmd.Flags().String("tag-authority", "", "domain name or email address minting the feed ids with --id-strategy tag-uri, e.g. example.org")
+	buildCmd.Flags().String("tag-date", "", "date on which --tag-authority was held, for --id-strategy tag-uri, e.g. 2025-01-01")
 	buildCmd.Flags().String("builddir", "build", "Build directory to output feeds to")
 	buildCmd.Flags().String("translationsdir", "", "Directory containing entries.{locale}.html translation files. Defaults to the 'translations' subdirectory of --newsfile when omitted")
+	buildCmd.Flags().Bool("include-drafts", false, "include articles marked draft=\"true\" in the built feeds")
+	buildCmd.Flags().String("timestamp-source", builder.TimestampBuildTime, "value of the feed <updated> element: 'build' (build time) or 'newest-entry' (newest article date, only changes when entries change)")
+	buildCmd.Flags().StringSlice("blocklist-certs", nil, "PEM certificates trusted to sign the blocklist; when set, every blocklist needs a valid detached signature in <blockfile>.sig")
+	buildCmd.Flags().StringSlice("locale-fallback", nil, "locale=fallback pairs, e.g. es-AR=es,zh=zh-CN; a locale without a translation file is built from its fallback, and articles missing from a translation come from its fallback before English")
+	buildCmd.Flags().Duration("blocklist-ttl", 0, "when positive, stamp the blocklist with updated (file mtime) and expires (mtime + ttl) attributes")
+	buildCmd.Flags().Int64("blocklist-max-size", builder.DefaultBlocklistMaxSize, "largest blocklist in bytes inlined in the feeds; a larger one is referenced by --blocklist-url, or fails the build without one. 0 disables the limit")
+	buildCmd.Flags().String("blocklist-url", "", "URL the blocklist is published at, referenced with <i2p:blocklist href> instead of inlining a blocklist over --blocklist-max-size")
+	buildCmd.Flags().Int("max-entry-size", builder.DefaultMaxEntrySize, "warn about any rendered <entry> larger than this many bytes; 0 disables the check")
+	buildCmd.Flags().Int("max-feed-size", builder.DefaultMaxFeedSize, "warn about any feed larger than this many bytes; 0 disables the check")
+	buildCmd.Flags().String("signercert", "", "PEM certificate the feeds will be signed with; the build fails when it expires within --cert-min-days, and warns within 90 days")
+	buildCmd.Flags().Int("cert-min-days", defaultCertMinDays, "fail the build when --signercert expires within this many days; an expired certificate always fails")
+	buildCmd.Flags().Bool("strict-size", false, "fail the build instead of warning when an entry or feed exceeds its size budget")
+	buildCmd.Flags().Bool("strict-chars", false, "fail the build on an article holding control characters or other characters illegal in XML 1.0 instead of stripping them with a warning")
+	buildCmd.Flags().String("websub-hub", "", "WebSub hub URL to advertise with a rel=\"hub\" link in every feed and to notify after the build")
+	buildCmd.Flags().String("changed-since", "", "only rewrite feeds with an input file modified after this RFC 3339 timestamp or date, or an article expired since their last build; other feeds are left as they are")
+	buildCmd.Flags().Bool("since-last-build", false, "only rewrite feeds with an input file modified, or an article expired, after the feed's last build, as recorded in its metadata sidecar; build without it after changing any other flag")
+	buildCmd.Flags().String("git-ref", "", "build from the data tree as of this commit, tag or branch of --repo instead of the working tree; the ref is recorded in the metadata sidecars")
+	buildCmd.Flags().String("repo", ".", "git repository --git-ref is read from; the data paths must lie inside it")
+	buildCmd.Flags().Bool("websub-ping", true, "notify --websub-hub that the feeds changed once they are written; disable when the build is published later")
+	buildCmd.Flags().Bool("entries-page", false, "write an HTML page of the entries next to every feed (news_de.atom.xml → news_de.html) and link each entry to its anchor on it")
 	// Note: samaddr is registered on serveCmd inside cmd/serve.go; do NOT
 	// re-register it here — pflag panics on duplicate flag definitions.
 
@@ -163,9 +269,9 @@ func resolveReleasesPath(dataDir string, isDefault bool, globalPath, platform, s
 	}
 	if _, err := os.Stat(path); err != nil {
 		if isDefault {
-			log.Printf("build: skipping default tree: releases.json not found at %s", path)
+			newslogger.Printf("build: skipping default tree: releases.json not found at %s", path)
 		} else {
-			log.Printf("build: skipping %s/%s: no releases.json found (platform or global)", platform, status)
+			newslogger.Printf("build: skipping %s/%s: no releases.json found (platform or global)", platform, status)
 		}
 		return path, false
 	}
@@ -186,13 +292,14 @@ func resolveBlocklistPath(dataDir string, isDefault bool, globalPath string) str
 // resolveEntriesPath returns the entries.html to use as the primary source for
 // a platform build. For the default tree the canonical entries.html is returned
 // directly. For named platforms a platform-specific entries.html is used when
-// it exists; otherwise the canonical file is returned as the fallback.
+// it or its entries.d fragment directory exists; otherwise the canonical file
+// is returned as the fallback.
 func resolveEntriesPath(dataDir, canonicalEntries string, isDefault bool) string {
 	if isDefault {
 		return canonicalEntries
 	}
 	platformEntries := filepath.Join(dataDir, "entries.html")
-	if _, err := os.Stat(platformEntries); err == nil {
+	if newsfeed.EntriesExist(platformEntries) {
 		return platformEntries
 	}
 	return canonicalEntries
@@ -232,7 +339,42 @@ func resolveTranslationsDir(dataDir string, isDefault bool, newsFile, configTran
 // feed: when a platform-specific entries.html exists it is loaded first and
 // the global entries.html is appended via Feed.BaseEntriesHTMLPath; when no
 // platform entries.html is present the global file is used directly.
+//
+// A feed.json in a named platform's data directory overrides the global feed
+// title, subtitle, and site URL for all of its feeds (see
+// builder.FeedOverrides).
 func buildPlatform(platform, status string) {
+	plan, ok := planPlatform(platform, status)
+	if !ok {
+		return
+	}
+	var overrides builder.FeedOverrides
+	if platform != "" {
+		var err error
+		if overrides, err = builder.LoadFeedOverrides(plan.dataDir); err != nil {
+			log.Fatalf("build: %v", err)
+		}
+	}
+	for _, src := range plan.sources {
+		buildForPlatform(src, plan.dataDir, plan.releasesPath, plan.blocklistPath, plan.canonicalEntries, platform, status, overrides)
+	}
+}
+
+// platformPlan holds the resolved inputs of one (platform, status) build:
+// the files shared by all of its feeds and one localeSource per feed, the
+// canonical English feed first.
+type platformPlan struct {
+	dataDir          string
+	releasesPath     string
+	blocklistPath    string
+	canonicalEntries string
+	sources          []localeSource
+}
+
+// planPlatform resolves the inputs buildPlatform uses for (platform, status)
+// from the current config.  ok is false when the combination is not built:
+// a named platform without a data directory, or no releases.json.
+func planPlatform(platform, status string) (plan platformPlan, ok bool) {
 	dataDir := builder.PlatformDataDir(c.NewsFile, platform, status)
 	isDefault := platform == ""
 
@@ -240,27 +382,104 @@ func buildPlatform(platform, status string) {
 	// directory means the combination has not been set up yet — skip silently.
 	if !isDefault {
 		if _, err := os.Stat(dataDir); err != nil {
-			return
+			return plan, false
 		}
 	}
 
 	releasesPath, ok := resolveReleasesPath(dataDir, isDefault, c.ReleaseJsonFile, platform, status)
 	if !ok {
-		return
+		return plan, false
 	}
 
-	blocklistPath := resolveBlocklistPath(dataDir, isDefault, c.BlockList)
 	canonicalEntries := filepath.Join(c.NewsFile, "entries.html")
 	entriesPath := resolveEntriesPath(dataDir, canonicalEntries, isDefault)
 	transDir := resolveTranslationsDir(dataDir, isDefault, c.NewsFile, c.TranslationsDir)
+	plan = platformPlan{
+		dataDir:          dataDir,
+		releasesPath:     releasesPath,
+		blocklistPath:    resolveBlocklistPath(dataDir, isDefault, c.BlockList),
+		canonicalEntries: canonicalEntries,
+		// The canonical English feed, then the per-locale feeds, including
+		// aliases served from their fallback.
+		sources: append([]localeSource{{Path: entriesPath, Locale: "en"}},
+			localeSources(builder.DetectTranslationFiles(transDir), localeFallbacks)...),
+	}
+	return plan, true
+}
+
+// feedOutputFilename returns the path, relative to BuildDir, of the feed
+// built from src for (platform, status).
+func feedOutputFilename(src localeSource, dataDir, platform, status string) string {
+	return localeOutputFilename(outputFilenameForPlatform(src.Path, dataDir, platform, status), src)
+}
 
-	// Build canonical English feed.
-	buildForPlatform(entriesPath, dataDir, releasesPath, blocklistPath, canonicalEntries, platform, status)
+// localeFallbacks holds the parsed --locale-fallback map for the current
+// build command.
+var localeFallbacks map[string]string
 
-	// Build per-locale feeds.
-	for _, tf := range builder.DetectTranslationFiles(transDir) {
-		buildForPlatform(tf, dataDir, releasesPath, blocklistPath, canonicalEntries, platform, status)
+// localeSource is one locale feed to build.  Path is the primary entries
+// file, Locale the feed's BCP 47 tag, and Fallbacks the entries files of the
+// locale's fallback chain.  For an alias without its own translation file,
+// Path is the first fallback file that exists and Locale still names the
+// alias, so the feed is written as news_{alias}.atom.xml.
+type localeSource struct {
+	Path      string
+	Locale    string
+	Fallbacks []string
+}
+
+// localeSources pairs every translation file with the files of its fallback
+// chain and adds an alias source for each fallbacks key that has no file of
+// its own but whose chain reaches one.  Aliases whose chain reaches no file
+// are skipped: the English feed already covers them.
+func localeSources(files []string, fallbacks map[string]string) []localeSource {
+	byLocale := make(map[string]string, len(files))
+	for _, tf := range files {
+		byLocale[builder.LocaleFromPath(tf)] = tf
+	}
+	chainFiles := func(locale string) []string {
+		var paths []string
+		for _, fb := range builder.LocaleFallbackChain(locale, fallbacks) {
+			if path, ok := byLocale[fb]; ok {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+	var sources []localeSource
+	for _, tf := range files {
+		locale := builder.LocaleFromPath(tf)
+		sources = append(sources, localeSource{Path: tf, Locale: locale, Fallbacks: chainFiles(locale)})
+	}
+	aliases := make([]string, 0, len(fallbacks))
+	for locale := range fallbacks {
+		if _, ok := byLocale[locale]; !ok && locale != "en" {
+			aliases = append(aliases, locale)
+		}
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		chain := chainFiles(alias)
+		if len(chain) == 0 {
+			continue
+		}
+		sources = append(sources, localeSource{Path: chain[0], Locale: alias, Fallbacks: chain[1:]})
 	}
+	return sources
+}
+
+// localeOutputFilename returns the output name for src given the name
+// outputFilename derived from its primary file.  For an alias the locale
+// segment of that name is replaced with the alias, keeping the directory.
+func localeOutputFilename(filename string, src localeSource) string {
+	if builder.LocaleFromPath(src.Path) == src.Locale {
+		return filename
+	}
+	// The segment as spelled in the source file name, which outputFilename
+	// carried over verbatim (e.g. "zh_CN" from entries.zh_CN.html).
+	own := strings.SplitN(filepath.Base(src.Path), ".", 3)[1]
+	name := strings.Replace(filepath.Base(filename), "news_"+own+".", "news_"+builder.LocaleFileName(src.Locale)+".", 1)
+	return filepath.Join(filepath.Dir(filename), name)
 }
 
 // buildForPlatform is the per-file build step used by buildPlatform.  It is
@@ -268,38 +487,268 @@ func buildPlatform(platform, status string) {
 // releasesPath, blocklistPath, and platform/status parameters instead of
 // reading them from the global config directly.
 //
+// src names the primary entries file, the feed locale, and the fallback
+// files merged ahead of the English base (see localeSources).
 // blocklistPath is the already-resolved blocklist file (platform-specific when
 // present, global jar-feed blocklist otherwise); releasesPath likewise.
 // canonicalEntries is the global jar-feed entries.html; it is set as
-// Feed.BaseEntriesHTMLPath whenever newsFile differs from it so that global
-// articles are always merged into the per-platform output.
-func buildForPlatform(newsFile, dataDir, releasesPath, blocklistPath, canonicalEntries, platform, status string) {
-	news := builder.Builder(newsFile, releasesPath, blocklistPath)
-	news.Language = builder.LocaleFromPath(newsFile)
-	news.TITLE = c.FeedTitle
-	news.SITEURL = c.FeedSite
-	news.MAINFEED = c.FeedMain
-	news.BACKUPFEED = c.FeedBackup
-	news.SUBTITLE = c.FeedSubtitle
-	if c.FeedUuid != "" {
-		news.URNID = c.FeedUuid
-	} else {
-		news.URNID = uuid.NewString()
+// Feed.BaseEntriesHTMLPath whenever src.Path differs from it so that global
+// articles are always merged into the per-platform output.  overrides replaces
+// the global feed metadata where set.
+func buildForPlatform(src localeSource, dataDir, releasesPath, blocklistPath, canonicalEntries, platform, status string, overrides builder.FeedOverrides) {
+	filename := feedOutputFilename(src, dataDir, platform, status)
+	inputs := feedInputs(src, dataDir, releasesPath, blocklistPath, canonicalEntries, platform)
+	if buildCutoff.unchanged(filepath.Join(c.BuildDir, filename), inputs) {
+		keepUnchangedFeed(filename, platform, status, src.Locale)
+		return
 	}
-	if newsFile != canonicalEntries {
-		news.Feed.BaseEntriesHTMLPath = canonicalEntries
+	news := platformBuilder(src, releasesPath, blocklistPath, canonicalEntries, platform, status, overrides)
+	if c.EntriesPage {
+		news.EntriesPageURL = entriesPageURL(filename)
 	}
 	if feed, err := news.Build(); err != nil {
 		log.Printf("Build error: %s", err)
+		if hint := buildErrorHint(err); hint != "" {
+			log.Printf("build: hint: %s", hint)
+		}
+		if errors.Is(err, builder.ErrOverBudget) {
+			overBudgetFeeds++
+		}
+		buildReport.add(filename, resultErrored, err)
 	} else {
-		filename := outputFilenameForPlatform(newsFile, dataDir, platform, status)
 		if err := os.MkdirAll(filepath.Join(c.BuildDir, filepath.Dir(filename)), 0o755); err != nil {
 			log.Fatalf("build: mkdir %s: %v", filepath.Join(c.BuildDir, filepath.Dir(filename)), err)
 		}
 		if err = os.WriteFile(filepath.Join(c.BuildDir, filename), []byte(feed), 0o644); err != nil {
 			log.Fatalf("build: write %s: %v", filepath.Join(c.BuildDir, filename), err)
 		}
+		meta := news.Metadata(feed)
+		meta.GitRef, meta.GitCommit = buildSource.Ref, buildSource.Commit
+		if err := builder.WriteFeedMetadata(filepath.Join(c.BuildDir, filename), meta); err != nil {
+			log.Fatalf("build: %v", err)
+		}
+		if meta.OverBudget > 0 {
+			overBudgetFeeds++
+		}
+		if c.EntriesPage {
+			writeEntriesPage(news, filename)
+		}
+		recordBuiltFeed(filename, platform, status, news.Language)
+	}
+}
+
+// platformBuilder returns the NewsBuilder of the feed buildForPlatform
+// builds from src, configured from the current config.
+func platformBuilder(src localeSource, releasesPath, blocklistPath, canonicalEntries, platform, status string, overrides builder.FeedOverrides) *builder.NewsBuilder {
+	news := builder.Builder(src.Path, releasesPath, blocklistPath)
+	news.Language = src.Locale
+	news.Feed.FallbackEntriesHTMLPaths = src.Fallbacks
+	news.Feed.StrictChars = c.StrictChars
+	news.TITLE = c.FeedTitle
+	news.SITEURL = c.FeedSite
+	news.MAINFEED = c.FeedMain
+	news.BACKUPFEED = c.FeedBackup
+	news.SUBTITLE = c.FeedSubtitle
+	news.Rights = c.Rights
+	news.LicenseURI = c.LicenseURI
+	news.Icon = feedIcon
+	news.Logo = feedLogo
+	overrides.Apply(news)
+	news.IncludeDrafts = c.IncludeDrafts
+	news.TimestampSource = c.TimestampSource
+	news.BlocklistCerts = blocklistCerts
+	news.BlocklistTTL = c.BlocklistTTL
+	news.BlocklistMaxSize = c.BlocklistMaxSize
+	news.BlocklistURL = c.BlocklistURL
+	news.Budget = sizeBudget()
+	news.Hub = c.WebSubHub
+	news.FeedID = feedID(platform, status, news.Language)
+	if builder.IsPreReleaseStatus(status) {
+		news.Channel = status
+		news.ChannelTitlePrefix = c.ChannelTitlePrefix
 	}
+	if src.Path != canonicalEntries {
+		news.Feed.BaseEntriesHTMLPath = canonicalEntries
+	}
+	return news
+}
+
+// entriesPageURL returns the URL of the entries page of the feed written to
+// filename, relative to BuildDir: the page path resolved against --feedmain,
+// which names a feed at the root of the build tree.  When --feedmain is not an
+// absolute URL the base name of the page is returned, which Atom readers
+// resolve against the location of the feed itself.
+func entriesPageURL(filename string) string {
+	page := filepath.ToSlash(builder.EntriesPagePath(filename))
+	base, err := url.Parse(c.FeedMain)
+	if err != nil || !base.IsAbs() {
+		return path.Base(page)
+	}
+	return base.ResolveReference(&url.URL{Path: page}).String()
+}
+
+// feedIcon and feedLogo hold the <icon> and <logo> URLs of the feeds of the
+// current build command; see feedImage.
+var feedIcon, feedLogo string
+
+// feedImage returns the URL of the feed image named by value: value itself
+// when it is an absolute URL, otherwise the URL of the local file value once
+// copied into the root of BuildDir, resolved against --feedmain like
+// entriesPageURL.  An empty value returns "".
+func feedImage(value string) (string, error) {
+	if u, err := url.Parse(value); value == "" || (err == nil && u.IsAbs()) {
+		return value, nil
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.Base(value)
+	if err := os.MkdirAll(c.BuildDir, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(c.BuildDir, name), data, 0o644); err != nil {
+		return "", err
+	}
+	base, err := url.Parse(c.FeedMain)
+	if err != nil || !base.IsAbs() {
+		return name, nil
+	}
+	return base.ResolveReference(&url.URL{Path: name}).String(), nil
+}
+
+// writeEntriesPage writes the entries page of the feed news has just built to
+// filename, relative to BuildDir, with the feed's suffix replaced.
+func writeEntriesPage(news *builder.NewsBuilder, filename string) {
+	page, err := news.EntriesPage()
+	if err != nil {
+		log.Fatalf("build: %v", err)
+	}
+	pagePath := builder.EntriesPagePath(filepath.Join(c.BuildDir, filename))
+	if err := os.WriteFile(pagePath, page, 0o644); err != nil {
+		log.Fatalf("build: write %s: %v", pagePath, err)
+	}
+}
+
+// buildErrorHint returns advice on fixing the entries file that made a
+// build fail with err, or "" when err is not about the entries file.
+func buildErrorHint(err error) string {
+	var missing *newsfeed.ErrMissingAttribute
+	switch {
+	case errors.Is(err, newsfeed.ErrNoArticles):
+		return "check --newsfile; every entries file needs at least one <article> element"
+	case errors.As(err, &missing) && missing.Name == "id":
+		return "give every <article> a unique id attribute, e.g. id=\"urn:uuid:...\""
+	case errors.As(err, &missing):
+		return fmt.Sprintf("add the %s attribute to <article id=%q>", missing.Name, missing.ArticleID)
+	case errors.Is(err, newsfeed.ErrBadDate):
+		return "write article dates as RFC 3339 (2006-01-02T15:04:05Z), 2006-01-02, or e.g. January 2, 2006, and check that the day exists"
+	case errors.Is(err, newsfeed.ErrInvalidChars):
+		return "remove the listed characters from the article, e.g. vertical tabs and form feeds pasted from a word processor, or build without --strict-chars to strip them"
+	case errors.Is(err, newsfeed.ErrInvalidExtensions):
+		return "fix the <script type=\"application/atom+xml\"> block of the article: it may hold only well-formed extension elements"
+	}
+	return ""
+}
+
+// blocklistCerts holds the parsed --blocklist-certs for the current build
+// command so that each per-platform build does not re-read them.
+var blocklistCerts []*x509.Certificate
+
+// builtFeeds collects every feed written during the current build command so
+// that writeFeedIndex can list them once the build loop has finished.
+var builtFeeds []builder.FeedIndexEntry
+
+// buildReport collects the result of every feed of the current build command
+// for the table printed at its end.
+var buildReport *runReport
+
+// overBudgetFeeds counts the feeds of the current build command that exceeded
+// a size budget, whether written with a warning or rejected by --strict-size.
+var overBudgetFeeds int
+
+// sizeBudget returns the builder.SizeBudget configured by --max-entry-size,
+// --max-feed-size, and --strict-size.
+func sizeBudget() builder.SizeBudget {
+	return builder.SizeBudget{
+		MaxEntry: c.MaxEntrySize,
+		MaxFeed:  c.MaxFeedSize,
+		Strict:   c.StrictSize,
+	}
+}
+
+// hubTimeout bounds the publish ping sent to the WebSub hub after a build.
+const hubTimeout = 30 * time.Second
+
+// publishToHub notifies the --websub-hub hub that the feed at --feedmain,
+// the rel="self" URL of every built feed, has changed.  The feeds are already
+// written, so a failed ping is logged rather than fatal.
+func publishToHub() {
+	ctx, cancel := context.WithTimeout(context.Background(), hubTimeout)
+	defer cancel()
+	if err := builder.PublishToHub(ctx, nil, c.WebSubHub, []string{c.FeedMain}); err != nil {
+		log.Printf("build: %v", err)
+		return
+	}
+	newslogger.Printf("build: notified WebSub hub %s", c.WebSubHub)
+}
+
+// recordBuiltFeed adds the feed written to filename (relative to BuildDir) to
+// builtFeeds.
+func recordBuiltFeed(filename, platform, status, locale string) {
+	newslogger.Verbosef("build: wrote %s", filepath.Join(c.BuildDir, filename))
+	buildReport.add(filename, resultBuilt, nil)
+	builtFeeds = append(builtFeeds, builder.FeedIndexEntry{
+		Path:     filepath.ToSlash(filename),
+		Platform: platform,
+		Status:   status,
+		Locale:   locale,
+	})
+}
+
+// writeFeedIndex writes the OPML and HTML indexes of builtFeeds into BuildDir.
+// A build restricted by --platform or --status only sees part of the feed
+// set, and a build that produced no feeds sees none of it; both leave any
+// existing index untouched rather than replace it with a partial one.
+func writeFeedIndex() {
+	if len(builtFeeds) == 0 {
+		return
+	}
+	if c.Platform != "" || c.Status != "" {
+		newslogger.Printf("build: --platform/--status set; not rewriting %s", builder.IndexOPMLName)
+		return
+	}
+	if err := builder.WriteFeedIndex(c.BuildDir, builtFeeds, c.FeedTitle, c.FeedSite); err != nil {
+		log.Fatalf("build: feed index: %v", err)
+	}
+}
+
+// idStrategy returns the builder.IDStrategy selected by --id-strategy.  The
+// base UUID of the default strategy is the --feeduri value when one was
+// given, otherwise one derived from --feedmain, so the ids are identical on
+// every rebuild and feed readers never see a spurious new feed.
+func idStrategy() builder.IDStrategy {
+	base := c.FeedUuid
+	if base == "" {
+		base = builder.DefaultBaseUUID(c.FeedMain)
+	}
+	return builder.IDStrategy{
+		Kind:      c.IDStrategy,
+		Base:      base,
+		Authority: c.TagAuthority,
+		Date:      c.TagDate,
+	}
+}
+
+// feedID returns the Atom id of the feed identified by (platform, status,
+// locale) under idStrategy.  An invalid strategy is a configuration error
+// and aborts the build.
+func feedID(platform, status, locale string) string {
+	id, err := idStrategy().FeedID(platform, status, locale)
+	if err != nil {
+		log.Fatalf("build: %v", err)
+	}
+	return id
 }
 
 func build(newsFile string) {
@@ -313,13 +762,19 @@ func build(newsFile string) {
 	news.MAINFEED = c.FeedMain
 	news.BACKUPFEED = c.FeedBackup
 	news.SUBTITLE = c.FeedSubtitle
-	// Use the user-supplied UUID when provided; generate a random one only
-	// when none was given (the previous code had this condition inverted).
-	if c.FeedUuid != "" {
-		news.URNID = c.FeedUuid
-	} else {
-		news.URNID = uuid.NewString()
-	}
+	news.Rights = c.Rights
+	news.LicenseURI = c.LicenseURI
+	news.Icon = feedIcon
+	news.Logo = feedLogo
+	news.IncludeDrafts = c.IncludeDrafts
+	news.TimestampSource = c.TimestampSource
+	news.BlocklistCerts = blocklistCerts
+	news.BlocklistTTL = c.BlocklistTTL
+	news.BlocklistMaxSize = c.BlocklistMaxSize
+	news.BlocklistURL = c.BlocklistURL
+	news.Budget = sizeBudget()
+	news.Hub = c.WebSubHub
+	news.FeedID = feedID("", "", news.Language)
 
 	// BaseEntriesHTMLPath is the root entries.html that acts as the merge
 	// baseline for locale/overlay files.  When build() is called in single-
@@ -337,20 +792,54 @@ func build(newsFile string) {
 	if newsFile != base {
 		news.Feed.BaseEntriesHTMLPath = base
 	}
+	// Fallback translations are looked up next to the single file.
+	for _, fb := range builder.LocaleFallbackChain(news.Language, localeFallbacks) {
+		path := filepath.Join(filepath.Dir(newsFile), "entries."+builder.LocaleFileName(fb)+".html")
+		if _, err := os.Stat(path); err == nil {
+			news.Feed.FallbackEntriesHTMLPaths = append(news.Feed.FallbackEntriesHTMLPaths, path)
+		}
+	}
+	// Output filename is derived from the individual file being processed
+	// (newsFile), not from the root directory flag (c.NewsFile).  Using
+	// c.NewsFile caused every file in the walk to map to the same output
+	// path, silently overwriting all but the last feed.
+	filename := outputFilename(newsFile, c.NewsFile)
+	inputs := append(entriesInputs(append([]string{newsFile, news.Feed.BaseEntriesHTMLPath}, news.Feed.FallbackEntriesHTMLPaths...)), c.ReleaseJsonFile, c.BlockList, c.BlockList+".sig")
+	if buildCutoff.unchanged(filepath.Join(c.BuildDir, filename), inputs) {
+		keepUnchangedFeed(filename, "", "", news.Language)
+		return
+	}
+	if c.EntriesPage {
+		news.EntriesPageURL = entriesPageURL(filename)
+	}
 	if feed, err := news.Build(); err != nil {
 		log.Printf("Build error: %s", err)
+		if hint := buildErrorHint(err); hint != "" {
+			log.Printf("build: hint: %s", hint)
+		}
+		if errors.Is(err, builder.ErrOverBudget) {
+			overBudgetFeeds++
+		}
+		buildReport.add(filename, resultErrored, err)
 	} else {
-		// Output filename is derived from the individual file being processed
-		// (newsFile), not from the root directory flag (c.NewsFile).  Using
-		// c.NewsFile caused every file in the walk to map to the same output
-		// path, silently overwriting all but the last feed.
-		filename := outputFilename(newsFile, c.NewsFile)
 		if err := os.MkdirAll(filepath.Join(c.BuildDir, filepath.Dir(filename)), 0o755); err != nil {
 			log.Fatalf("build: mkdir %s: %v", filepath.Join(c.BuildDir, filepath.Dir(filename)), err)
 		}
 		if err = os.WriteFile(filepath.Join(c.BuildDir, filename), []byte(feed), 0o644); err != nil {
 			log.Fatalf("build: write %s: %v", filepath.Join(c.BuildDir, filename), err)
 		}
+		meta := news.Metadata(feed)
+		meta.GitRef, meta.GitCommit = buildSource.Ref, buildSource.Commit
+		if err := builder.WriteFeedMetadata(filepath.Join(c.BuildDir, filename), meta); err != nil {
+			log.Fatalf("build: %v", err)
+		}
+		if meta.OverBudget > 0 {
+			overBudgetFeeds++
+		}
+		if c.EntriesPage {
+			writeEntriesPage(news, filename)
+		}
+		recordBuiltFeed(filename, "", "", news.Language)
 	}
 }
 
@@ -381,18 +870,43 @@ func outputFilenameForPlatform(newsFile, newsRoot, platform, status string) stri
 // When newsFile is outside newsRoot (e.g. a custom --translationsdir that is
 // not a subdirectory of --newsfile), filepath.Rel returns a path with leading
 // ".." components.  In that case, and when the relative path cannot be
-// computed at all, the function falls back to the base name only so the output
-// always lands at the top level of BuildDir with the expected locale suffix.
+// computed at all (e.g. on another drive), the function falls back to the base
+// name only so the output always lands at the top level of BuildDir with the
+// expected locale suffix.
+//
+// Both paths may use either separator, with drive letters or UNC hosts (see
+// builder.SlashPath).  The result is split into whole components: a
+// "translations" directory is dropped and only the file name is renamed, so
+// directories such as "mytranslations" or "entries.d" are kept verbatim.  The
+// result uses the OS separator.
 func outputFilename(newsFile, newsRoot string) string {
-	rel, err := filepath.Rel(newsRoot, newsFile)
-	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
-		rel = filepath.Base(newsFile)
-	}
-	f := strings.Replace(rel, ".html", ".atom.xml", -1)
-	f = strings.Replace(f, "entries.", "news_", -1)
-	// Use "translations/" (with path separator) instead of bare "translations"
-	// to avoid producing a leading separator that makes filepath.Join treat the
-	// result as an absolute path.
-	f = strings.Replace(f, "translations"+string(filepath.Separator), "", -1)
-	return strings.Replace(f, "news_atom", "news.atom", -1)
+	file, root := builder.SlashPath(newsFile), builder.SlashPath(newsRoot)
+	rel, err := filepath.Rel(filepath.FromSlash(root), filepath.FromSlash(file))
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if err != nil || rel == "." || parts[0] == ".." {
+		parts = []string{path.Base(file)}
+	}
+	// Drop the "translations" component so that translations land next
+	// to the feed they translate.
+	out := make([]string, 0, len(parts))
+	for _, dir := range parts[:len(parts)-1] {
+		if dir != "translations" {
+			out = append(out, dir)
+		}
+	}
+	return filepath.Join(append(out, feedFileName(parts[len(parts)-1]))...)
+}
+
+// feedFileName returns the output file name of the entries file name:
+// entries.html becomes news.atom.xml and entries.{locale}.html becomes
+// news_{locale}.atom.xml.
+func feedFileName(name string) string {
+	name = strings.TrimSuffix(name, ".html") + ".atom.xml"
+	if name == "entries.atom.xml" {
+		return "news.atom.xml"
+	}
+	if rest, ok := strings.CutPrefix(name, "entries."); ok {
+		return "news_" + rest
+	}
+	return name
 }
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// changeCutoff selects the feeds a build rewrites.  The zero value rewrites
+// every feed.  With since set (--changed-since) a feed is rewritten only when
+// one of its inputs was modified after since; with lastBuild set
+// (--since-last-build) only when one was modified after the feed's own last
+// build, as recorded in its metadata sidecar.  A feed without an output, or
+// without a readable sidecar under lastBuild, is always written, and so is a
+// feed whose sidecar records an article expiry that has passed since, as
+// the feed must then lose the article although no input changed.
+type changeCutoff struct {
+	since     time.Time
+	lastBuild bool
+}
+
+// buildCutoff is the changeCutoff of the current build command.
+var buildCutoff changeCutoff
+
+// unchangedFeeds counts the feeds of the current build command that
+// buildCutoff left untouched.
+var unchangedFeeds int
+
+// parseChangeCutoff returns the changeCutoff for --changed-since and
+// --since-last-build.  changedSince is an RFC 3339 timestamp or a date
+// (2006-01-02, midnight UTC); empty means unset.
+func parseChangeCutoff(changedSince string, sinceLastBuild bool) (changeCutoff, error) {
+	if changedSince == "" {
+		return changeCutoff{lastBuild: sinceLastBuild}, nil
+	}
+	if sinceLastBuild {
+		return changeCutoff{}, fmt.Errorf("--changed-since and --since-last-build are mutually exclusive")
+	}
+	t, err := time.Parse(time.RFC3339, changedSince)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, changedSince); err != nil {
+			return changeCutoff{}, fmt.Errorf("--changed-since %q: want an RFC 3339 timestamp or a date (2006-01-02)", changedSince)
+		}
+	}
+	return changeCutoff{since: t}, nil
+}
+
+// unchanged reports whether the feed at output, a path in BuildDir, can be
+// kept as it is because none of inputs was modified after the cutoff.
+// Inputs that do not exist are optional files, such as a feed.json or a
+// blocklist signature, and are ignored.
+func (cc changeCutoff) unchanged(output string, inputs []string) bool {
+	if cc.since.IsZero() && !cc.lastBuild {
+		return false
+	}
+	if _, err := os.Stat(output); err != nil {
+		return false
+	}
+	cutoff := cc.since
+	m, err := builder.ReadFeedMetadata(output)
+	if err == nil {
+		if expiry, ok := m.NextExpiryTime(); ok && !time.Now().Before(expiry) {
+			return false
+		}
+	}
+	if cc.lastBuild {
+		if err != nil {
+			return false
+		}
+		built, ok := m.BuiltTime()
+		if !ok {
+			return false
+		}
+		cutoff = built
+	}
+	for _, in := range inputs {
+		if in == "" {
+			continue
+		}
+		fi, err := os.Stat(in)
+		if err != nil {
+			continue
+		}
+		if fi.ModTime().After(cutoff) {
+			return false
+		}
+	}
+	return true
+}
+
+// feedInputs returns the files a feed built from src for a platform data
+// directory reads; see buildForPlatform.
+func feedInputs(src localeSource, dataDir, releasesPath, blocklistPath, canonicalEntries, platform string) []string {
+	inputs := entriesInputs(append([]string{src.Path, canonicalEntries}, src.Fallbacks...))
+	inputs = append(inputs, releasesPath, blocklistPath, blocklistPath+".sig")
+	if platform != "" {
+		inputs = append(inputs, filepath.Join(dataDir, builder.FeedOverridesName))
+	}
+	return inputs
+}
+
+// entriesInputs returns the entries files at paths, each followed by its
+// fragment directory, whose modification time changes when a fragment is
+// added or removed, and the fragments in it.  Empty paths are skipped.
+func entriesInputs(paths []string) []string {
+	var inputs []string
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		inputs = append(inputs, path, newsfeed.FragmentDir(path))
+		fragments, _ := newsfeed.FragmentPaths(path)
+		inputs = append(inputs, fragments...)
+	}
+	return inputs
+}
+
+// keepUnchangedFeed records the feed at filename (relative to BuildDir), left
+// untouched by buildCutoff, so that the feed index still lists it.
+func keepUnchangedFeed(filename, platform, status, locale string) {
+	newslogger.Verbosef("build: %s is up to date", filepath.Join(c.BuildDir, filename))
+	unchangedFeeds++
+	buildReport.add(filename, resultSkipped, nil)
+	builtFeeds = append(builtFeeds, builder.FeedIndexEntry{
+		Path:     filepath.ToSlash(filename),
+		Platform: platform,
+		Status:   status,
+		Locale:   locale,
+	})
+}
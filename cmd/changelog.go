@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// changelogCmd represents the changelog command
+var changelogCmd = &cobra.Command{
+	Use:   "changelog",
+	Short: "Draft a release news article from git history or a CHANGELOG",
+	Long: `changelog drafts a new <article> for the entries file from the changes of a
+release, so the news entry no longer has to be written from scratch on
+release day.  The changes are the subjects of the commits in
+--since..--until of --repo, merges and fixup!/squash! commits left out, or,
+with --changelog-file, the bullet items of a CHANGELOG section: the one whose
+heading names --release, or else the first.
+
+The article is written to standard output with a fresh urn:uuid id, the
+current time as its published and updated dates, and draft="true", so build
+leaves it out of the feeds until it has been edited and the attribute
+removed.  A comment above it records the source it was drafted from: the
+full commit hashes of the range, or the SHA-256 digest of the CHANGELOG, so
+reviewers can tell exactly which changes the text covers.
+
+--template names an html/template file to render instead of the built-in
+one.  It is executed with .ID, .Title, .Href, .Author, .Published, .Release,
+.Source and .Items, whose elements have .Subject, and .Commit when drafted
+from git.
+
+Examples:
+  newsgo changelog --since v2.6.0 --release 2.7.0 > data/entries.d/2.7.0.html
+  newsgo changelog --changelog-file CHANGELOG.md --release 2.7.0
+  newsgo changelog --since v2.6.0 --template changelog.tmpl`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		flags := cmd.Flags()
+		since, _ := flags.GetString("since")
+		until, _ := flags.GetString("until")
+		repo, _ := flags.GetString("repo")
+		file, _ := flags.GetString("changelog-file")
+		tmplFile, _ := flags.GetString("template")
+		d := changelogData{ID: "urn:uuid:" + uuid.NewString()}
+		d.Release, _ = flags.GetString("release")
+		d.Title, _ = flags.GetString("title")
+		d.Href, _ = flags.GetString("href")
+		d.Author, _ = flags.GetString("author")
+		d.Published = time.Now().UTC().Format(time.RFC3339)
+		if d.Title == "" {
+			d.Title = "Release notes"
+			if d.Release != "" {
+				d.Title = d.Release + " Released"
+			}
+		}
+
+		var err error
+		switch {
+		case file != "" && since != "":
+			log.Fatalf("changelog: --since and --changelog-file exclude each other")
+		case file != "":
+			d.Items, d.Source, err = changelogFromFile(file, d.Release)
+		case since != "":
+			d.Items, d.Source, err = changelogFromGit(repo, since, until)
+		default:
+			log.Fatalf("changelog: give --since or --changelog-file")
+		}
+		if err != nil {
+			log.Fatalf("changelog: %v", err)
+		}
+		if len(d.Items) == 0 {
+			log.Fatalf("changelog: no changes found in %s", d.Source)
+		}
+
+		tmpl := defaultChangelogTemplate
+		if tmplFile != "" {
+			if tmpl, err = template.ParseFiles(tmplFile); err != nil {
+				log.Fatalf("changelog: --template: %v", err)
+			}
+		}
+		if err := tmpl.Execute(os.Stdout, d); err != nil {
+			log.Fatalf("changelog: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().String("since", "", "git ref of the previous release; the changes are the commits after it")
+	changelogCmd.Flags().String("until", "HEAD", "git ref of the new release, with --since")
+	changelogCmd.Flags().String("repo", ".", "git repository to read the commits of, with --since")
+	changelogCmd.Flags().String("changelog-file", "", "CHANGELOG to take the changes from instead of git")
+	changelogCmd.Flags().String("release", "", "version of the new release, for the title and the CHANGELOG section")
+	changelogCmd.Flags().String("title", "", "article title (default \"<release> Released\")")
+	changelogCmd.Flags().String("href", "http://i2p-projekt.i2p/", "link of the article")
+	changelogCmd.Flags().String("author", "newsgo", "author of the article")
+	changelogCmd.Flags().String("template", "", "html/template file to render the article with instead of the built-in one")
+}
+
+// changelogItem is one change of a drafted article.
+type changelogItem struct {
+	Subject string
+	Commit  string // abbreviated commit hash, or "" for a CHANGELOG item
+}
+
+// changelogData is what the changelog template is executed with.
+type changelogData struct {
+	ID        string
+	Title     string
+	Href      string
+	Author    string
+	Published string
+	Release   string
+	Source    string
+	Items     []changelogItem
+}
+
+// defaultChangelogTemplate renders the draft article the way setup scaffolds
+// one, with the changes as a list.
+var defaultChangelogTemplate = template.Must(template.New("changelog").Parse(`<!-- drafted by newsgo changelog from {{.Source}} -->
+<article id="{{.ID}}" title="{{.Title}}" href="{{.Href}}" author="{{.Author}}" published="{{.Published}}" updated="{{.Published}}" draft="true">
+<details>
+<summary>{{.Title}}</summary>
+</details>
+<ul>
+{{- range .Items}}
+<li>{{.Subject}}</li>
+{{- end}}
+</ul>
+</article>
+`))
+
+// changelogFromGit returns the changes of the commits in since..until of
+// repo, oldest first, and the range as full commit hashes.
+func changelogFromGit(repo, since, until string) ([]changelogItem, string, error) {
+	from, err := resolveGitRef(repo, since)
+	if err != nil {
+		return nil, "", err
+	}
+	to, err := resolveGitRef(repo, until)
+	if err != nil {
+		return nil, "", err
+	}
+	out, err := runGit(repo, "log", "--no-merges", "--reverse", "--format=%h%x00%s", from+".."+to)
+	if err != nil {
+		return nil, "", err
+	}
+	source := fmt.Sprintf("git %s..%s (%s..%s)", since, until, from, to)
+	return parseGitLog(out), source, nil
+}
+
+// parseGitLog parses git log output in the "%h%x00%s" format, one commit per
+// line, into changes.  fixup! and squash! commits are left out, as they are
+// folded into another commit and say nothing new.
+func parseGitLog(out []byte) []changelogItem {
+	var items []changelogItem
+	for _, line := range strings.Split(string(out), "\n") {
+		hash, subject, ok := strings.Cut(line, "\x00")
+		subject = strings.TrimSpace(subject)
+		if !ok || subject == "" || strings.HasPrefix(subject, "fixup!") || strings.HasPrefix(subject, "squash!") {
+			continue
+		}
+		items = append(items, changelogItem{Subject: subject, Commit: hash})
+	}
+	return items
+}
+
+// changelogFromFile returns the changes of the section of the CHANGELOG at
+// path for release, and the file with its SHA-256 digest.
+func changelogFromFile(path, release string) ([]changelogItem, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	items, err := parseChangelog(bytes.NewReader(data), release)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	sum := sha256.Sum256(data)
+	return items, fmt.Sprintf("%s (sha256 %s)", path, hex.EncodeToString(sum[:])), nil
+}
+
+// parseChangelog returns the bullet items of the section of a CHANGELOG for
+// release: the first section whose heading names it, or the first section
+// when release is "".  A section runs from a heading line, one starting with
+// "#" or underlined with "=" or "-", to the next.  Items start with "-", "*"
+// or "+"; indented lines continue the item above them.
+func parseChangelog(r io.Reader, release string) ([]changelogItem, error) {
+	var lines []string
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		lines = append(lines, strings.TrimRight(sc.Text(), " \t\r"))
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	heading := func(i int) bool {
+		if strings.HasPrefix(lines[i], "#") {
+			return true
+		}
+		if i+1 < len(lines) && lines[i] != "" {
+			u := lines[i+1]
+			return len(u) >= 3 && (strings.Trim(u, "=") == "" || strings.Trim(u, "-") == "")
+		}
+		return false
+	}
+	start := -1
+	for i := range lines {
+		if heading(i) && (release == "" || strings.Contains(lines[i], release)) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		if release == "" {
+			return nil, fmt.Errorf("parseChangelog: no section heading")
+		}
+		return nil, fmt.Errorf("parseChangelog: no section for release %s", release)
+	}
+	var items []changelogItem
+	for i := start + 1; i < len(lines); i++ {
+		line := lines[i]
+		if heading(i) {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "" || strings.Trim(trimmed, "=-") == "":
+			continue
+		case line == trimmed && len(trimmed) > 1 && strings.ContainsRune("-*+", rune(trimmed[0])) && trimmed[1] == ' ':
+			items = append(items, changelogItem{Subject: strings.TrimSpace(trimmed[2:])})
+		case line != trimmed && len(items) > 0:
+			items[len(items)-1].Subject += " " + strings.TrimLeft(trimmed, "-*+ ")
+		}
+	}
+	return items, nil
+}
@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	"github.com/spf13/cobra"
+)
+
+// cleanCmd represents the clean command
+var cleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove build outputs that the current data tree no longer produces",
+	Long: `clean works out which feeds a full build of --newsfile would produce and
+removes every other feed output from --builddir: the .atom.xml, its signed
+.su3, its .json metadata sidecar, and its .html entries page.  Outputs of
+platforms, channels, and locales removed from the data tree would otherwise
+keep being signed and served.  Directories left empty are removed too.
+
+Only feed outputs (news.* and news_{locale}.*) are considered; the feed
+index, stats files, and anything else in --builddir are left alone.
+
+Example:
+  newsgo clean --builddir build --newsfile data --dry-run`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Every flag below shares its name with a build flag.  They are not
+		// bound to viper (see init) and are read from this command's own flag
+		// set instead.
+		flags := cmd.Flags()
+		c.BuildDir, _ = flags.GetString("builddir")
+		c.NewsFile, _ = flags.GetString("newsfile")
+		c.ReleaseJsonFile, _ = flags.GetString("releasejson")
+		c.TranslationsDir, _ = flags.GetString("translationsdir")
+		specs, _ := flags.GetStringSlice("locale-fallback")
+		dryRun, _ := flags.GetBool("dry-run")
+
+		if f, err := os.Stat(c.NewsFile); err != nil {
+			log.Fatalf("clean: stat %s: %v", c.NewsFile, err)
+		} else if !f.IsDir() {
+			log.Fatalf("clean: --newsfile must be a data directory, got %s", c.NewsFile)
+		}
+		fallbacks, err := builder.ParseLocaleFallbacks(specs)
+		if err != nil {
+			log.Fatalf("clean: --locale-fallback: %v", err)
+		}
+		localeFallbacks = fallbacks
+
+		expected := expectedFeeds()
+		if len(expected) == 0 {
+			// Almost certainly a wrong --newsfile or --releasejson rather
+			// than a data tree that really produces nothing.
+			log.Fatalf("clean: %s produces no feeds; refusing to remove every output", c.NewsFile)
+		}
+		stale, err := staleOutputs(c.BuildDir, expected)
+		if err != nil {
+			log.Fatalf("clean: %v", err)
+		}
+		if err := removeOutputs(os.Stdout, c.BuildDir, stale, dryRun); err != nil {
+			log.Fatalf("clean: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cleanCmd)
+
+	cleanCmd.Flags().String("builddir", "build", "build directory to remove stale feed outputs from")
+	cleanCmd.Flags().String("newsfile", "data", "data directory whose full build defines the outputs to keep")
+	cleanCmd.Flags().String("releasejson", "data/releases.json", "releases.json of the default feed tree, as passed to build")
+	cleanCmd.Flags().String("translationsdir", "", "translations directory of the default feed tree, as passed to build")
+	cleanCmd.Flags().StringSlice("locale-fallback", nil, "locale=fallback pairs, as passed to build; alias feeds are kept")
+	cleanCmd.Flags().Bool("dry-run", false, "report the stale outputs without removing them")
+
+	// No flag is bound to viper: builddir, newsfile, releasejson,
+	// translationsdir, and locale-fallback are build's keys, and binding them
+	// here would repoint them at this command's flags (see the BindPFlags
+	// collision notes in build.go).
+}
+
+// expectedFeeds returns the feeds a full directory-mode build of c.NewsFile
+// would write, relative to BuildDir and without their ".atom.xml" suffix.
+func expectedFeeds() map[string]bool {
+	expected := map[string]bool{}
+	for _, pr := range collectBuildPairs("", "") {
+		plan, ok := planPlatform(pr.platform, pr.status)
+		if !ok {
+			continue
+		}
+		for _, src := range plan.sources {
+			name := feedOutputFilename(src, plan.dataDir, pr.platform, pr.status)
+			expected[filepath.ToSlash(strings.TrimSuffix(name, ".atom.xml"))] = true
+		}
+	}
+	return expected
+}
+
+// feedOutputSuffixes are the files written for each feed: the Atom XML by
+// build, its metadata sidecar and entries page by build, and the su3 by sign.
+var feedOutputSuffixes = []string{".atom.xml", ".su3", ".json", ".html"}
+
+// feedOutputBase returns the feed name of a feed output file, e.g. "news_de"
+// for "news_de.su3", and whether name is a feed output at all.
+func feedOutputBase(name string) (string, bool) {
+	for _, suffix := range feedOutputSuffixes {
+		if !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		base := strings.TrimSuffix(name, suffix)
+		if base == "news" || strings.HasPrefix(base, "news_") {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// staleOutputs walks buildDir and returns the feed outputs whose feed is not
+// in expected, sorted.
+func staleOutputs(buildDir string, expected map[string]bool) ([]string, error) {
+	var stale []string
+	err := filepath.Walk(buildDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		base, ok := feedOutputBase(info.Name())
+		if !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(buildDir, filepath.Join(filepath.Dir(path), base))
+		if err != nil {
+			return err
+		}
+		if !expected[filepath.ToSlash(rel)] {
+			stale = append(stale, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(stale)
+	return stale, nil
+}
+
+// removeOutputs removes every path in stale, reporting each one to w, and
+// then removes the directories under buildDir that were left empty.  With
+// dryRun it only reports what it would remove.
+func removeOutputs(w io.Writer, buildDir string, stale []string, dryRun bool) error {
+	dirs := map[string]bool{}
+	for _, path := range stale {
+		if dryRun {
+			fmt.Fprintf(w, "would remove %s\n", path)
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "removed %s\n", path)
+		// containsDir rather than a separator-suffixed prefix, which a
+		// drive or UNC share root such as \\srv\share\ already ends in.
+		root := filepath.Clean(buildDir)
+		for dir := filepath.Dir(path); dir != root && containsDir(root, dir); dir = filepath.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	// Deepest first, so a parent is only tried once its children are gone.
+	ordered := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		ordered = append(ordered, dir)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return len(ordered[i]) > len(ordered[j]) })
+	for _, dir := range ordered {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+			if err := os.Remove(dir); err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "removed %s%c\n", dir, filepath.Separator)
+		}
+	}
+	return nil
+}
@@ -1,7 +1,9 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
@@ -10,20 +12,31 @@ import (
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/json"
 	"encoding/pem"
+	"errors"
+	"fmt"
 	"io"
 	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	builder "github.com/go-i2p/newsgo/builder"
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	"github.com/go-i2p/newsgo/config"
 	newsfetch "github.com/go-i2p/newsgo/fetch"
+	newslogger "github.com/go-i2p/newsgo/logger"
+	server "github.com/go-i2p/newsgo/server"
+	signer "github.com/go-i2p/newsgo/signer"
 	"github.com/go-i2p/onramp"
 	"github.com/spf13/viper"
 	"i2pgit.org/go-i2p/reseed-tools/su3"
@@ -131,25 +144,75 @@ func TestCheckPortListening_UnreachablePort(t *testing.T) {
 // the guard must NOT fire.  Only when both are false/empty should it fire.
 func TestNoListenerConfigured(t *testing.T) {
 	tests := []struct {
-		name string
-		host string
-		i2p  bool
-		want bool
+		name  string
+		hosts []string
+		i2p   bool
+		want  bool
 	}{
-		{"both disabled — no listener", "", false, true},
-		{"clearnet only — listener present", "127.0.0.1", false, false},
-		{"i2p only — listener present", "", true, false},
-		{"both enabled — listeners present", "127.0.0.1", true, false},
+		{"both disabled — no listener", nil, false, true},
+		{"clearnet only — listener present", []string{"127.0.0.1"}, false, false},
+		{"i2p only — listener present", nil, true, false},
+		{"both enabled — listeners present", []string{"127.0.0.1"}, true, false},
+		{"several hosts — listeners present", []string{"127.0.0.1", "::1"}, false, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := noListenerConfigured(tt.host, tt.i2p); got != tt.want {
-				t.Errorf("noListenerConfigured(%q, %v) = %v, want %v", tt.host, tt.i2p, got, tt.want)
+			if got := noListenerConfigured(tt.hosts, tt.i2p); got != tt.want {
+				t.Errorf("noListenerConfigured(%q, %v) = %v, want %v", tt.hosts, tt.i2p, got, tt.want)
 			}
 		})
 	}
 }
 
+// TestReadOnlyConf verifies that --readonly keeps stats in memory unless
+// --statsfile is given, refuses a stats file inside a served tree, and
+// refuses --pprof and --symlinks follow.
+func TestReadOnlyConf(t *testing.T) {
+	dir := t.TempDir()
+	conf := &config.Conf{NewsDir: filepath.Join(dir, "build"), StatsFile: filepath.Join(dir, "build", "stats.json")}
+	must(t, readOnlyConf(conf, false))
+	if conf.StatsFile != "" {
+		t.Errorf("StatsFile = %q by default, want none", conf.StatsFile)
+	}
+	conf.StatsFile = filepath.Join(dir, "tmpfs", "stats.json")
+	must(t, readOnlyConf(conf, true))
+	if conf.StatsFile == "" {
+		t.Error("a --statsfile outside the served tree was dropped")
+	}
+	for _, bad := range []*config.Conf{
+		{NewsDir: conf.NewsDir, StatsFile: filepath.Join(conf.NewsDir, "stats.json")},
+		{NewsDir: conf.NewsDir, StatsFile: filepath.Join(dir, "beta", "stats.json"), Routes: []string{"/beta=" + filepath.Join(dir, "beta")}},
+		{NewsDir: conf.NewsDir, PProf: "127.0.0.1:6060"},
+		{NewsDir: conf.NewsDir, Symlinks: server.SymlinksFollow},
+	} {
+		if err := readOnlyConf(bad, true); err == nil {
+			t.Errorf("readOnlyConf(%+v) accepted", bad)
+		}
+	}
+	sibling := &config.Conf{NewsDir: conf.NewsDir, StatsFile: conf.NewsDir + "-old/stats.json"}
+	if err := readOnlyConf(sibling, true); err != nil {
+		t.Errorf("readOnlyConf refused a --statsfile in a sibling sharing the prefix: %v", err)
+	}
+}
+
+// TestClearnetHosts verifies that --host takes comma-separated and repeated
+// hosts, as the flag, the config file and the environment give them.
+func TestClearnetHosts(t *testing.T) {
+	got, err := clearnetHosts([]string{"127.0.0.1, [::1]", "", "0.0.0.0"})
+	if err != nil {
+		t.Fatalf("clearnetHosts: %v", err)
+	}
+	if want := []string{"127.0.0.1", "::1", "0.0.0.0"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("clearnetHosts = %q, want %q", got, want)
+	}
+	if got, err := clearnetHosts([]string{""}); err != nil || len(got) != 0 {
+		t.Errorf("clearnetHosts(\"\") = %q, %v; want no hosts", got, err)
+	}
+	if _, err := clearnetHosts([]string{"127.0.0.1", "127.0.0.1"}); err == nil {
+		t.Error("clearnetHosts accepted a host given twice")
+	}
+}
+
 // TestResolveOverrideFile validates the "platform-specific overrides global
 // when present" helper used for both releases.json and blocklist.xml.
 func TestResolveOverrideFile(t *testing.T) {
@@ -239,6 +302,95 @@ func TestBuildPlatform_UsesGlobalReleasesWhenPlatformAbsent(t *testing.T) {
 	if _, err := os.Stat(out); err != nil {
 		t.Errorf("expected %s to be produced when platform releases.json absent (global fallback); stat: %v", out, err)
 	}
+	if m, err := builder.ReadFeedMetadata(out); err != nil || m.Feed != "news.atom.xml" || m.Locale != "en" {
+		t.Errorf("metadata sidecar of %s = %+v, %v; want one describing the feed", out, m, err)
+	}
+}
+
+// TestBuildPlatform_EntriesPage verifies that --entries-page writes the page
+// next to the feed and links its entries to the page under --feedmain.
+func TestBuildPlatform_EntriesPage(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "mac", "stable", true, false)
+	buildDir := t.TempDir()
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.BuildDir = buildDir
+	c.FeedTitle = "Test"
+	c.FeedSite = "http://example.com"
+	c.FeedMain = "http://example.com/news/news.atom.xml"
+	c.FeedSubtitle = "sub"
+	c.FeedUuid = "00000000-0000-0000-0000-000000000001"
+	c.TranslationsDir = ""
+	c.EntriesPage = true
+
+	buildPlatform("mac", "stable")
+
+	feed, err := os.ReadFile(filepath.Join(buildDir, "mac", "stable", "news.atom.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(feed), `href="http://example.com/news/mac/stable/news.html#entry-`) {
+		t.Errorf("feed does not link the entries page:\n%s", feed)
+	}
+	if _, err := os.Stat(filepath.Join(buildDir, "mac", "stable", "news.html")); err != nil {
+		t.Errorf("entries page not written: %v", err)
+	}
+}
+
+// TestEntriesPageURL verifies that the page URL is resolved against
+// --feedmain, and falls back to the page's base name without an absolute
+// --feedmain.
+func TestEntriesPageURL(t *testing.T) {
+	prev := *c
+	defer func() { *c = prev }()
+	tests := []struct {
+		feedMain, filename, want string
+	}{
+		{"http://example.i2p/news.atom.xml", "news_de.atom.xml", "http://example.i2p/news_de.html"},
+		{"http://example.i2p/news/news.su3", filepath.Join("win", "beta", "news.atom.xml"), "http://example.i2p/news/win/beta/news.html"},
+		{"news.atom.xml", filepath.Join("win", "beta", "news_fr.atom.xml"), "news_fr.html"},
+	}
+	for _, tt := range tests {
+		c.FeedMain = tt.feedMain
+		if got := entriesPageURL(tt.filename); got != tt.want {
+			t.Errorf("entriesPageURL(%q) with --feedmain %q = %q, want %q", tt.filename, tt.feedMain, got, tt.want)
+		}
+	}
+}
+
+// TestFeedImage verifies that a URL is used as given and that a local image
+// is copied into the build directory and addressed next to --feedmain.
+func TestFeedImage(t *testing.T) {
+	prev := *c
+	defer func() { *c = prev }()
+	c.BuildDir = filepath.Join(t.TempDir(), "build")
+	c.FeedMain = "http://example.i2p/news/news.atom.xml"
+	for _, value := range []string{"", "http://example.i2p/favicon.ico"} {
+		if got, err := feedImage(value); err != nil || got != value {
+			t.Errorf("feedImage(%q) = %q, %v; want it unchanged", value, got, err)
+		}
+	}
+	src := filepath.Join(t.TempDir(), "icon.png")
+	if err := os.WriteFile(src, []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := feedImage(src)
+	if err != nil {
+		t.Fatalf("feedImage: %v", err)
+	}
+	if want := "http://example.i2p/news/icon.png"; got != want {
+		t.Errorf("feedImage = %q, want %q", got, want)
+	}
+	if data, err := os.ReadFile(filepath.Join(c.BuildDir, "icon.png")); err != nil || string(data) != "png" {
+		t.Errorf("copied image = %q, %v", data, err)
+	}
+	if _, err := feedImage(filepath.Join(t.TempDir(), "missing.png")); err == nil {
+		t.Error("feedImage of a missing file succeeded")
+	}
 }
 
 // TestBuildPlatform_UsesPlatformBlocklistWhenPresent verifies that the feed
@@ -356,6 +508,73 @@ func TestBuildPlatform_GlobalEntriesMergedIntoPlatformFeed(t *testing.T) {
 	}
 }
 
+// TestBuildPlatform_LocaleFallbacks verifies that a configured regional
+// locale without its own translation is built from its fallback under its
+// own name and language, and that an alias reaching no file is skipped.
+func TestBuildPlatform_LocaleFallbacks(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "mac", "stable", false, false)
+	buildDir := t.TempDir()
+	transDir := filepath.Join(root, "translations")
+	must(t, os.MkdirAll(transDir, 0o755))
+	const esEntries = `<html><body><header>H</header><article id="urn:es:1" title="Hola" href="http://x.com" author="A" published="2025-01-01" updated="2025-01-02"><details><summary>S</summary></details><p>es</p></article></body></html>`
+	must(t, os.WriteFile(filepath.Join(transDir, "entries.es.html"), []byte(esEntries), 0o644))
+
+	prev := *c
+	prevFallbacks := localeFallbacks
+	defer func() { *c = prev; localeFallbacks = prevFallbacks }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.BuildDir = buildDir
+	c.FeedMain = "http://example.com/news.atom.xml"
+	c.FeedUuid = "00000000-0000-0000-0000-000000000005"
+	c.TranslationsDir = ""
+	fallbacks, err := builder.ParseLocaleFallbacks([]string{"es-AR=es", "zh=zh-CN"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	localeFallbacks = fallbacks
+
+	buildPlatform("", "")
+
+	data, err := os.ReadFile(filepath.Join(buildDir, "news_es_AR.atom.xml"))
+	if err != nil {
+		t.Fatalf("alias feed not written: %v", err)
+	}
+	if !strings.Contains(string(data), `xml:lang="es-AR"`) || !strings.Contains(string(data), "urn:es:1") {
+		t.Errorf("alias feed should carry es-AR language and the es articles:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(buildDir, "news_zh.atom.xml")); err == nil {
+		t.Error("news_zh.atom.xml written although no zh-CN translation exists")
+	}
+}
+
+// TestLocaleSources verifies fallback file lists for translations and alias
+// sources.
+func TestLocaleSources(t *testing.T) {
+	files := []string{"t/entries.es.html", "t/entries.es_AR.html", "t/entries.zh_CN.html"}
+	fallbacks := map[string]string{"es-AR": "es", "es-MX": "es", "zh": "zh-CN", "zh-HK": "zh"}
+	var got []string
+	for _, src := range localeSources(files, fallbacks) {
+		got = append(got, src.Locale+"<"+src.Path+"+"+strings.Join(src.Fallbacks, ","))
+	}
+	want := []string{
+		"es<t/entries.es.html+",
+		"es-AR<t/entries.es_AR.html+t/entries.es.html",
+		"zh-CN<t/entries.zh_CN.html+",
+		"es-MX<t/entries.es.html+",
+		"zh<t/entries.zh_CN.html+",
+		"zh-HK<t/entries.zh_CN.html+",
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("localeSources =\n%q\nwant\n%q", got, want)
+	}
+	alias := localeSource{Path: "t/entries.zh_CN.html", Locale: "zh-HK"}
+	if name := localeOutputFilename(filepath.Join("win", "beta", "news_zh_CN.atom.xml"), alias); name != filepath.Join("win", "beta", "news_zh_HK.atom.xml") {
+		t.Errorf("localeOutputFilename = %q", name)
+	}
+}
+
 // TestOutputFilename validates that build output paths are computed relative to
 // the walk root so that source-directory components (e.g. "data/") are not
 // propagated into BuildDir.
@@ -403,6 +622,35 @@ func TestOutputFilename(t *testing.T) {
 	}
 }
 
+// TestOutputFilename_Separators verifies that outputFilename splits Windows
+// paths, with drive letters, UNC hosts, or mixed separators, into the same
+// components on every OS, and that it renames only the file name and drops
+// only a whole "translations" component.
+func TestOutputFilename_Separators(t *testing.T) {
+	tests := []struct {
+		newsFile, newsRoot, want string
+	}{
+		{`C:\news\data\entries.html`, `C:\news\data`, "news.atom.xml"},
+		{`C:\news\data\translations\entries.de.html`, `C:\news\data`, "news_de.atom.xml"},
+		{`C:/news/data\translations/entries.pt_BR.html`, `C:\news\data\`, "news_pt_BR.atom.xml"},
+		{`\\srv\share\data\translations\de\entries.html`, `\\srv\share\data`, filepath.Join("de", "news.atom.xml")},
+		// Another drive: fall back to the base name.
+		{`D:\i18n\entries.fr.html`, `C:\news\data`, "news_fr.atom.xml"},
+		{filepath.Join("data", "mytranslations", "entries.de.html"), "data", filepath.Join("mytranslations", "news_de.atom.xml")},
+		{filepath.Join("data", "entries.d", "entries.html"), "data", filepath.Join("entries.d", "news.atom.xml")},
+		{filepath.Join("data", "pages.html.d", "feed.html"), "data", filepath.Join("pages.html.d", "feed.atom.xml")},
+	}
+	for _, tt := range tests {
+		if got := outputFilename(tt.newsFile, tt.newsRoot); got != tt.want {
+			t.Errorf("outputFilename(%q, %q) = %q, want %q", tt.newsFile, tt.newsRoot, got, tt.want)
+		}
+	}
+	got := outputFilenameForPlatform(`C:\news\data\translations\entries.de.html`, `C:\news\data\win\beta`, "win", "beta")
+	if want := filepath.Join("win", "beta", "news_de.atom.xml"); got != want {
+		t.Errorf("outputFilenameForPlatform(win, beta) = %q, want %q", got, want)
+	}
+}
+
 // TestOutputFilenameForPlatform validates the platform/status namespacing
 // wrapper.  For the default (empty or "linux") platform the result must be
 // identical to outputFilename.  For all other platforms the output must be
@@ -532,6 +780,56 @@ func TestSign_ReturnsErrorForMissingKey(t *testing.T) {
 	}
 }
 
+// TestSign_SkipsCurrentSu3 verifies that Sign leaves a su3 that already holds
+// the feed's content untouched, re-signs it when the feed changes, and always
+// re-signs with --force.
+func TestSign_SkipsCurrentSu3(t *testing.T) {
+	dir := t.TempDir()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	keyPath := filepath.Join(dir, "key.pem")
+	must(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0o600))
+	feed := filepath.Join(dir, "news.atom.xml")
+	su3Path := filepath.Join(dir, "news.su3")
+	must(t, os.WriteFile(feed, []byte("<feed>one</feed>"), 0o644))
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.SigningKey = keyPath
+	c.SignerId = "skip@example.i2p"
+	c.SignerCert = ""
+	c.Force = false
+
+	must(t, Sign(feed))
+	old := time.Now().Add(-time.Hour).Truncate(time.Second)
+	signedAt := func() time.Time {
+		t.Helper()
+		fi, err := os.Stat(su3Path)
+		must(t, err)
+		return fi.ModTime()
+	}
+
+	must(t, os.Chtimes(su3Path, old, old))
+	must(t, Sign(feed))
+	if !signedAt().Equal(old) {
+		t.Error("Sign rewrote a su3 that was already current")
+	}
+
+	c.Force = true
+	must(t, Sign(feed))
+	if signedAt().Equal(old) {
+		t.Error("Sign with --force did not rewrite the su3")
+	}
+
+	c.Force = false
+	must(t, os.Chtimes(su3Path, old, old))
+	must(t, os.WriteFile(feed, []byte("<feed>two</feed>"), 0o644))
+	must(t, Sign(feed))
+	if signedAt().Equal(old) {
+		t.Error("Sign did not re-sign a changed feed")
+	}
+}
+
 // TestFetchCmd_SamAddrFlagRegistered verifies that --samaddr is registered on
 // the fetch subcommand, so that "newsgo fetch --samaddr <addr>" works as
 // documented in the README rather than returning "unknown flag: --samaddr".
@@ -640,7 +938,7 @@ func TestFetchURLs_NoStdout(t *testing.T) {
 	os.Stdout = pw
 
 	f := newsfetch.NewFetcherFromClient(ts.Client())
-	fetchErr := fetchURLs(f, []string{url}, nil, outDir)
+	_, fetchErr := fetchURLs(f, []string{url}, nil, outDir)
 
 	// Restore stdout before any assertions so test output is not swallowed.
 	pw.Close()
@@ -1191,3 +1489,1542 @@ func TestCollectBuildPairs_NoFlags(t *testing.T) {
 		t.Errorf("pairs[0] = {%q, %q}, want {\"\", \"\"}", pairs[0].platform, pairs[0].status)
 	}
 }
+
+// TestMirrorStatus_Record verifies that a failed refresh keeps the previous
+// success time and that a later success clears the error.
+func TestMirrorStatus_Record(t *testing.T) {
+	m := &mirrorStatus{}
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+
+	m.record(t0, t1, nil)
+	m.record(t1, t1.Add(time.Hour), io.ErrUnexpectedEOF)
+	if !m.LastSuccess.Equal(t0) || !m.LastAttempt.Equal(t1) {
+		t.Errorf("after failure: success=%v attempt=%v, want %v and %v", m.LastSuccess, m.LastAttempt, t0, t1)
+	}
+	if m.LastError != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("LastError = %q, want %q", m.LastError, io.ErrUnexpectedEOF.Error())
+	}
+	if !m.NextRun.Equal(t1.Add(time.Hour)) {
+		t.Errorf("NextRun = %v, want %v", m.NextRun, t1.Add(time.Hour))
+	}
+
+	m.record(t1.Add(time.Hour), t1.Add(2*time.Hour), nil)
+	if m.LastError != "" || !m.LastSuccess.Equal(t1.Add(time.Hour)) {
+		t.Errorf("after recovery: error=%q success=%v", m.LastError, m.LastSuccess)
+	}
+}
+
+// TestMirrorHandler_Routes verifies that the status path returns the JSON
+// report and every other path reaches the news server.
+func TestMirrorHandler_Routes(t *testing.T) {
+	dir := t.TempDir()
+	must(t, os.WriteFile(filepath.Join(dir, "news.atom.xml"), []byte("<feed/>"), 0o644))
+	s := server.Serve(dir, filepath.Join(dir, "stats.json"))
+	status := &mirrorStatus{Upstream: []string{"http://example.i2p/news.su3"}, Every: "6h0m0s"}
+	h := mirrorHandler(s, status)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, mirrorStatusPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status endpoint: code %d", rec.Code)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("status endpoint: invalid JSON: %v", err)
+	}
+	if got["every"] != "6h0m0s" {
+		t.Errorf("every = %v, want 6h0m0s", got["every"])
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/news.atom.xml", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "<feed/>") {
+		t.Errorf("news file: code %d body %q", rec.Code, rec.Body.String())
+	}
+}
+
+// TestRunMirrorLoop_RefreshesUntilStopped verifies that runMirrorLoop fetches
+// immediately, keeps refreshing on the interval, and returns once stop closes.
+func TestRunMirrorLoop_RefreshesUntilStopped(t *testing.T) {
+	su3Data := makeSu3ForCmd(t, []byte("<feed>mirror</feed>"))
+	var hits int
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits++
+		mu.Unlock()
+		w.Write(su3Data)
+	}))
+	defer ts.Close()
+
+	outDir := t.TempDir()
+	status := &mirrorStatus{}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	f := newsfetch.NewFetcherFromClient(ts.Client())
+	go func() {
+		runMirrorLoop(f, []string{ts.URL + "/news.su3"}, nil, outDir, 10*time.Millisecond, status, stop)
+		close(done)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		mu.Lock()
+		n := hits
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		select {
+		case <-deadline:
+			t.Fatalf("only %d refreshes before deadline", n)
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runMirrorLoop did not return after stop was closed")
+	}
+
+	if _, err := os.Stat(filepath.Join(outDir, "news.atom.xml")); err != nil {
+		t.Errorf("mirrored feed not written: %v", err)
+	}
+	status.mu.RLock()
+	defer status.mu.RUnlock()
+	if status.LastSuccess.IsZero() || status.LastError != "" {
+		t.Errorf("status = success %v, error %q", status.LastSuccess, status.LastError)
+	}
+}
+
+// writeSignedTree builds a minimal build directory with a default feed and a
+// platform feed, both signed, and returns the directory and the certificate
+// for the signing key.
+func writeSignedTree(t *testing.T) (string, *x509.Certificate) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tree@example.i2p"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	must(t, err)
+	cert, err := x509.ParseCertificate(der)
+	must(t, err)
+
+	dir := t.TempDir()
+	ns := &signer.NewsSigner{SignerID: "tree@example.i2p", SigningKey: key}
+	for _, name := range []string{"news.atom.xml", "win/beta/news.atom.xml"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		must(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		must(t, os.WriteFile(path, []byte(`<feed xmlns="http://www.w3.org/2005/Atom"><title>`+name+`</title></feed>`), 0o644))
+		must(t, ns.CreateSu3(path))
+	}
+	return dir, cert
+}
+
+// TestVerifyTree_AllPass verifies that a freshly signed tree passes every
+// check and that the matrix reports no failures.
+func TestVerifyTree_AllPass(t *testing.T) {
+	dir, cert := writeSignedTree(t)
+	results, err := verifyTree(dir, []*x509.Certificate{cert})
+	must(t, err)
+	if len(results) != 2 || results[0].Name != "news" || results[1].Name != "win/beta/news" {
+		t.Fatalf("results = %+v, want news and win/beta/news", results)
+	}
+	var out bytes.Buffer
+	if failed := printVerifyMatrix(&out, results); failed != 0 {
+		t.Errorf("printVerifyMatrix reported %d failures:\n%s", failed, out.String())
+	}
+	if !strings.Contains(out.String(), "FEED") || strings.Contains(out.String(), "FAIL") {
+		t.Errorf("unexpected matrix:\n%s", out.String())
+	}
+}
+
+// TestVerifyTree_DetectsProblems verifies each check independently: a wrong
+// certificate fails sig, an edited .atom.xml fails content, malformed XML
+// fails atom, and an unsigned feed fails sig and content.
+func TestVerifyTree_DetectsProblems(t *testing.T) {
+	dir, cert := writeSignedTree(t)
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	tmpl := &x509.Certificate{SerialNumber: big.NewInt(2), NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &other.PublicKey, other)
+	must(t, err)
+	wrong, err := x509.ParseCertificate(der)
+	must(t, err)
+	results, err := verifyTree(dir, []*x509.Certificate{wrong})
+	must(t, err)
+	for _, r := range results {
+		if r.Sig.ok() {
+			t.Errorf("%s: sig passed with the wrong certificate", r.Name)
+		}
+	}
+
+	must(t, os.WriteFile(filepath.Join(dir, "news.atom.xml"), []byte(`<feed xmlns="http://www.w3.org/2005/Atom"><title>edited</title></feed>`), 0o644))
+	must(t, os.WriteFile(filepath.Join(dir, "win", "beta", "news.atom.xml"), []byte(`<feed xmlns="http://www.w3.org/2005/Atom">`), 0o644))
+	must(t, os.WriteFile(filepath.Join(dir, "news_de.atom.xml"), []byte(`<feed xmlns="http://www.w3.org/2005/Atom"/>`), 0o644))
+	results, err = verifyTree(dir, []*x509.Certificate{cert})
+	must(t, err)
+	byName := map[string]feedVerifyResult{}
+	for _, r := range results {
+		byName[r.Name] = r
+	}
+	if r := byName["news"]; !r.Sig.ok() || r.Content.ok() || !r.Atom.ok() {
+		t.Errorf("edited feed: %+v, want only content to fail", r)
+	}
+	if r := byName["win/beta/news"]; r.Atom.ok() || r.Content.ok() {
+		t.Errorf("truncated feed: %+v, want atom and content to fail", r)
+	}
+	if r := byName["news_de"]; r.Sig.ok() || r.Content.ok() || !r.Atom.ok() {
+		t.Errorf("unsigned feed: %+v, want sig and content to fail", r)
+	}
+	var out bytes.Buffer
+	if failed := printVerifyMatrix(&out, results); failed != 3 {
+		t.Errorf("printVerifyMatrix reported %d failures, want 3:\n%s", failed, out.String())
+	}
+}
+
+// TestFeedStatus verifies that status reads every feed's sidecar, reports a
+// feed without one, and applies --max-age to the build time.
+func TestFeedStatus(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for name, built := range map[string]time.Time{
+		"news.atom.xml":          now.Add(-time.Hour),
+		"win/beta/news.atom.xml": now.Add(-48 * time.Hour),
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		must(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		must(t, os.WriteFile(path, []byte("<feed/>"), 0o644))
+		must(t, builder.WriteFeedMetadata(path, builder.FeedMetadata{Locale: "en", Entries: 3, Built: built.Format(time.RFC3339)}))
+	}
+	must(t, os.WriteFile(filepath.Join(dir, "news_de.atom.xml"), []byte("<feed/>"), 0o644))
+
+	results, err := feedStatuses(dir)
+	must(t, err)
+	if len(results) != 3 || results[0].Name != "news.atom.xml" || results[1].Name != "news_de.atom.xml" || results[2].Name != "win/beta/news.atom.xml" {
+		t.Fatalf("results = %+v, want news, news_de and win/beta/news", results)
+	}
+	if results[1].Err == nil {
+		t.Error("news_de.atom.xml has no sidecar but no error was reported")
+	}
+
+	var out bytes.Buffer
+	if stale := printFeedStatus(&out, results, 0, now); stale != 1 {
+		t.Errorf("without --max-age: %d stale, want 1 (missing sidecar):\n%s", stale, out.String())
+	}
+	out.Reset()
+	if stale := printFeedStatus(&out, results, 24*time.Hour, now); stale != 2 {
+		t.Errorf("--max-age 24h: %d stale, want 2:\n%s", stale, out.String())
+	}
+	if !strings.Contains(out.String(), "win/beta/news.atom.xml: built 48h0m0s ago") {
+		t.Errorf("missing max-age reason:\n%s", out.String())
+	}
+}
+
+// TestClean_RemovesOrphans verifies that clean keeps the outputs of the feeds
+// the data tree still produces and removes those of a removed platform and a
+// removed translation, along with the directories they leave empty.
+func TestClean_RemovesOrphans(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "mac", "stable", false, false)
+	must(t, os.MkdirAll(filepath.Join(root, "translations"), 0o755))
+	must(t, os.WriteFile(filepath.Join(root, "translations", "entries.de.html"), []byte(`<html><body></body></html>`), 0o644))
+	buildDir := t.TempDir()
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.TranslationsDir = ""
+	localeFallbacks = nil
+
+	keep := []string{"news.atom.xml", "news.su3", "news.json", "news_de.atom.xml", "mac/stable/news.atom.xml", "mac/stable/news_de.su3", "index.opml", "stats.json"}
+	orphans := []string{"news_fr.atom.xml", "news_fr.su3", "news_fr.json", "win/beta/news.atom.xml", "win/beta/news.su3"}
+	for _, name := range append(append([]string(nil), keep...), orphans...) {
+		path := filepath.Join(buildDir, filepath.FromSlash(name))
+		must(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		must(t, os.WriteFile(path, []byte("x"), 0o644))
+	}
+
+	stale, err := staleOutputs(buildDir, expectedFeeds())
+	must(t, err)
+	var got []string
+	for _, path := range stale {
+		rel, err := filepath.Rel(buildDir, path)
+		must(t, err)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	want := []string{"news_fr.atom.xml", "news_fr.json", "news_fr.su3", "win/beta/news.atom.xml", "win/beta/news.su3"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("staleOutputs = %v, want %v", got, want)
+	}
+
+	var out bytes.Buffer
+	must(t, removeOutputs(&out, buildDir, stale, true))
+	if _, err := os.Stat(filepath.Join(buildDir, "news_fr.su3")); err != nil {
+		t.Errorf("--dry-run removed news_fr.su3: %v", err)
+	}
+	must(t, removeOutputs(&out, buildDir, stale, false))
+	for _, name := range orphans {
+		if _, err := os.Stat(filepath.Join(buildDir, filepath.FromSlash(name))); err == nil {
+			t.Errorf("%s was not removed", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(buildDir, "win")); err == nil {
+		t.Error("empty win/ directory was not removed")
+	}
+	for _, name := range keep {
+		if _, err := os.Stat(filepath.Join(buildDir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+}
+
+// TestCheckAtomWellFormed verifies the root element check.
+func TestCheckAtomWellFormed(t *testing.T) {
+	tests := []struct {
+		doc  string
+		want bool
+	}{
+		{`<?xml version="1.0"?><feed xmlns="http://www.w3.org/2005/Atom"><entry/></feed>`, true},
+		{`<feed><entry/></feed>`, false},
+		{`<rss xmlns="http://www.w3.org/2005/Atom"/>`, false},
+		{`<feed xmlns="http://www.w3.org/2005/Atom"><entry></feed>`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		if got := checkAtomWellFormed([]byte(tt.doc)) == nil; got != tt.want {
+			t.Errorf("checkAtomWellFormed(%q) ok = %v, want %v", tt.doc, got, tt.want)
+		}
+	}
+}
+
+// TestNewHTTPServer_Tuning verifies that the clearnet server picks up the
+// idle timeout and that --disable-http2 installs an empty TLSNextProto.
+func TestNewHTTPServer_Tuning(t *testing.T) {
+	prev := *c
+	defer func() { *c = prev }()
+
+	c.IdleTimeout = 0
+	c.DisableHTTP2 = false
+	srv := newHTTPServer(http.NotFoundHandler(), nil)
+	if srv.IdleTimeout != defaultIdleTimeout {
+		t.Errorf("IdleTimeout = %s, want default %s", srv.IdleTimeout, defaultIdleTimeout)
+	}
+	if srv.TLSNextProto != nil {
+		t.Error("TLSNextProto set although HTTP/2 is enabled")
+	}
+
+	c.IdleTimeout = 5 * time.Minute
+	c.DisableHTTP2 = true
+	srv = newHTTPServer(http.NotFoundHandler(), nil)
+	if srv.IdleTimeout != 5*time.Minute {
+		t.Errorf("IdleTimeout = %s, want 5m", srv.IdleTimeout)
+	}
+	if srv.TLSNextProto == nil || len(srv.TLSNextProto) != 0 {
+		t.Error("--disable-http2 did not install an empty TLSNextProto")
+	}
+}
+
+// TestDebugListener verifies that --pprof only accepts loopback addresses
+// and that the debug mux serves profiles and expvar variables.
+func TestDebugListener(t *testing.T) {
+	for addr, ok := range map[string]bool{
+		"127.0.0.1:6060": true,
+		"[::1]:6060":     true,
+		"localhost:6060": true,
+		"0.0.0.0:6060":   false,
+		":6060":          false,
+		"192.0.2.1:6060": false,
+		"127.0.0.1":      false,
+	} {
+		if err := checkDebugAddr(addr); (err == nil) != ok {
+			t.Errorf("checkDebugAddr(%q) = %v, want ok=%v", addr, err, ok)
+		}
+	}
+	srv := httptest.NewServer(debugMux())
+	defer srv.Close()
+	for _, path := range []string{"/debug/vars", "/debug/pprof/", "/debug/pprof/goroutine?debug=1"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: %s", path, resp.Status)
+		}
+	}
+}
+
+// TestServeHTTP_TLSFlagsMustBePaired verifies that a lone --tlscert is
+// rejected before a listener is opened.
+func TestServeHTTP_TLSFlagsMustBePaired(t *testing.T) {
+	prev := *c
+	defer func() { *c = prev }()
+	c.TLSCert, c.TLSKey = "cert.pem", ""
+	if err := serveHTTP(http.NotFoundHandler(), "127.0.0.1", "0", nil); err == nil {
+		t.Fatal("expected error for --tlscert without --tlskey")
+	}
+}
+
+// TestAdminListener verifies the --admin flag checks, reading the token file,
+// and that draining the tracked listeners makes serveHTTP return cleanly.
+func TestAdminListener(t *testing.T) {
+	if err := checkLoopbackAddr("--admin", "0.0.0.0:6061"); err == nil || !strings.Contains(err.Error(), "--admin") {
+		t.Errorf("checkLoopbackAddr(0.0.0.0:6061) = %v, want an --admin error", err)
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "token")
+	if _, err := readAdminToken(""); err == nil {
+		t.Error("readAdminToken(\"\"): expected error")
+	}
+	if _, err := readAdminToken(path); err == nil {
+		t.Error("readAdminToken(missing): expected error")
+	}
+	if err := os.WriteFile(path, []byte(" \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readAdminToken(path); err == nil {
+		t.Error("readAdminToken(blank): expected error")
+	}
+	if err := os.WriteFile(path, []byte("s3cret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if token, err := readAdminToken(path); err != nil || token != "s3cret" {
+		t.Errorf("readAdminToken = %q, %v; want s3cret", token, err)
+	}
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.TLSCert, c.TLSKey = "", ""
+	done := make(chan error, 1)
+	go func() { done <- serveHTTP(http.NotFoundHandler(), "127.0.0.1", "0", nil) }()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		listeners.Lock()
+		n := len(listeners.servers)
+		listeners.Unlock()
+		if n > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("serveHTTP did not register its server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	drainListeners(ctx)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("serveHTTP after drain = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("serveHTTP did not return after drainListeners")
+	}
+}
+
+// TestExpandURLTemplate verifies placeholder expansion, the news.su3 name of
+// the English feed, the mirrored output layout, and missing selector values.
+func TestExpandURLTemplate(t *testing.T) {
+	got, err := expandURLTemplate("http://news.i2p/news/{platform}/{status}/news_{lang}.su3",
+		[]string{"win"}, []string{"stable", "beta"}, []string{"en", "pt-BR"})
+	if err != nil {
+		t.Fatalf("expandURLTemplate: %v", err)
+	}
+	want := []fetchTarget{
+		{"http://news.i2p/news/win/stable/news.su3", filepath.Join("win", "stable", "news.atom.xml")},
+		{"http://news.i2p/news/win/stable/news_pt_BR.su3", filepath.Join("win", "stable", "news_pt_BR.atom.xml")},
+		{"http://news.i2p/news/win/beta/news.su3", filepath.Join("win", "beta", "news.atom.xml")},
+		{"http://news.i2p/news/win/beta/news_pt_BR.su3", filepath.Join("win", "beta", "news_pt_BR.atom.xml")},
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expandURLTemplate =\n%v\nwant\n%v", got, want)
+	}
+
+	got, err = expandURLTemplate("http://news.i2p/news_{lang}.su3", nil, nil, []string{"de"})
+	if err != nil || len(got) != 1 || got[0].Out != "news_de.atom.xml" {
+		t.Errorf("lang-only template = %v, %v", got, err)
+	}
+	got, err = expandURLTemplate("http://news.i2p/{status}/news_{lang}.su3?lang={lang}", nil, []string{"beta"}, []string{"en", "pt-BR"})
+	if err != nil || len(got) != 2 || got[0].URL != "http://news.i2p/beta/news.su3?lang=en" ||
+		got[0].Out != filepath.Join("beta", "news.atom.xml") || got[1].Out != filepath.Join("beta", "news_pt_BR.atom.xml") {
+		t.Errorf("template with a lang query = %v, %v", got, err)
+	}
+	if _, err := expandURLTemplate("http://news.i2p/{platform}/news.su3", nil, nil, nil); err == nil {
+		t.Error("expected error for {platform} without --platforms")
+	}
+}
+
+// TestWithLangParam verifies that --lang-param sets the lang query parameter
+// of every URL, replacing one already there and keeping other parameters.
+func TestWithLangParam(t *testing.T) {
+	urls := []string{"http://news.i2p/news.su3", "http://backup.i2p/news.su3?lang=en&x=1"}
+	if got, err := withLangParam(urls, ""); err != nil || fmt.Sprint(got) != fmt.Sprint(urls) {
+		t.Errorf("withLangParam without a lang = %v, %v; want the URLs unchanged", got, err)
+	}
+	got, err := withLangParam(urls, "de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"http://news.i2p/news.su3?lang=de", "http://backup.i2p/news.su3?lang=de&x=1"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("withLangParam = %v, want %v", got, want)
+	}
+	if out := outFilename(got[1]); out != "news.atom.xml" {
+		t.Errorf("outFilename(%q) = %q, want news.atom.xml", got[1], out)
+	}
+}
+
+// TestFetchTargets_PartialFailure verifies that fetchTargets writes every
+// available variant into the mirrored layout and tolerates missing ones.
+func TestFetchTargets_PartialFailure(t *testing.T) {
+	su3Data := makeSu3ForCmd(t, []byte("<feed>variant</feed>"))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/beta/") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(su3Data)
+	}))
+	defer ts.Close()
+
+	targets, err := expandURLTemplate(ts.URL+"/{platform}/{status}/news_{lang}.su3",
+		[]string{"mac"}, []string{"stable", "beta"}, []string{"en", "de"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	f := newsfetch.NewFetcherFromClient(ts.Client())
+	if _, err := fetchTargets(f, targets, nil, outDir); err != nil {
+		t.Fatalf("fetchTargets: %v", err)
+	}
+	for _, name := range []string{"news.atom.xml", "news_de.atom.xml"} {
+		if _, err := os.Stat(filepath.Join(outDir, "mac", "stable", name)); err != nil {
+			t.Errorf("mac/stable/%s not written: %v", name, err)
+		}
+	}
+
+	ts.Close()
+	if _, err := fetchTargets(f, targets, nil, t.TempDir()); err == nil {
+		t.Error("expected error when no target can be fetched")
+	}
+}
+
+// TestCheckSAM verifies that only a listener answering the SAMv3 HELLO
+// handshake passes, and that a missing or foreign listener is a warning.
+func TestCheckSAM(t *testing.T) {
+	serveOnce := func(reply string) string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		must(t, err)
+		t.Cleanup(func() { ln.Close() })
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			buf := make([]byte, 64)
+			conn.Read(buf)
+			io.WriteString(conn, reply)
+		}()
+		return ln.Addr().String()
+	}
+	if ch := checkSAM(serveOnce("HELLO REPLY RESULT=OK VERSION=3.3\n")); ch.Status != doctorOK {
+		t.Errorf("SAM bridge: %+v, want ok", ch)
+	}
+	if ch := checkSAM(serveOnce("HTTP/1.1 400 Bad Request\r\n")); ch.Status != doctorWarn || ch.Fix == "" {
+		t.Errorf("non-SAM listener: %+v, want warn with a fix", ch)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	must(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+	if ch := checkSAM(addr); ch.Status != doctorWarn {
+		t.Errorf("nothing listening: %+v, want warn", ch)
+	}
+}
+
+// TestCheckSignerCert verifies the key match and expiry checks.
+func TestCheckSignerCert(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	now := time.Now()
+	writeCert := func(notAfter time.Time) string {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "doctor@example.i2p"},
+			NotBefore:    now.Add(-48 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		must(t, err)
+		path := filepath.Join(t.TempDir(), "signer.crt")
+		must(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+		return path
+	}
+	valid := writeCert(now.Add(365 * 24 * time.Hour))
+	for _, tt := range []struct {
+		name string
+		path string
+		key  crypto.Signer
+		want string
+	}{
+		{"unset", "", key, doctorOK},
+		{"valid", valid, key, doctorOK},
+		{"key unknown", valid, nil, doctorOK},
+		{"wrong key", valid, other, doctorFail},
+		{"expiring", writeCert(now.Add(7 * 24 * time.Hour)), key, doctorWarn},
+		{"expired", writeCert(now.Add(-time.Hour)), key, doctorFail},
+		{"missing", filepath.Join(t.TempDir(), "none.crt"), key, doctorFail},
+	} {
+		if ch := checkSignerCert(tt.path, tt.key, now); ch.Status != tt.want {
+			t.Errorf("%s: %+v, want %s", tt.name, ch, tt.want)
+		}
+	}
+}
+
+// TestSignerCertExpiry verifies that build, sign and status refuse a signer
+// certificate that has expired or expires within --cert-min-days.
+func TestSignerCertExpiry(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	must(t, err)
+	now := time.Now()
+	writeCert := func(notAfter time.Time) string {
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "expiry@example.i2p"},
+			NotBefore:    now.Add(-48 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+		must(t, err)
+		path := filepath.Join(t.TempDir(), "signer.crt")
+		must(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644))
+		return path
+	}
+	soon := writeCert(now.Add(10 * 24 * time.Hour))
+	for _, tt := range []struct {
+		name    string
+		path    string
+		minDays int
+		ok      bool
+	}{
+		{"valid", writeCert(now.Add(365 * 24 * time.Hour)), 14, true},
+		{"within notice", writeCert(now.Add(60 * 24 * time.Hour)), 14, true},
+		{"within min days", soon, 14, false},
+		{"min days lowered", soon, 7, true},
+		{"expired", writeCert(now.Add(-time.Hour)), 0, false},
+		{"missing", filepath.Join(t.TempDir(), "none.crt"), 0, false},
+	} {
+		if _, err := signerCertExpiry(tt.path, tt.minDays, now); (err == nil) != tt.ok {
+			t.Errorf("%s: signerCertExpiry = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+	if err := checkCertExpiry("build", "", 14, now); err != nil {
+		t.Errorf("checkCertExpiry without a certificate = %v, want nil", err)
+	}
+
+	var out bytes.Buffer
+	if !printCertExpiry(&out, soon, 7, now) || !strings.Contains(out.String(), "expires on "+now.Add(10*24*time.Hour).Format(time.DateOnly)) {
+		t.Errorf("printCertExpiry = %q, want the expiry date", out.String())
+	}
+	out.Reset()
+	if printCertExpiry(&out, soon, 14, now) || !strings.Contains(out.String(), "within --cert-min-days 14") {
+		t.Errorf("printCertExpiry = %q, want a failure within --cert-min-days", out.String())
+	}
+}
+
+// TestDoctor_EnvironmentChecks verifies the data, writable-path, and port
+// checks and that printDoctor counts only failures.
+func TestDoctor_EnvironmentChecks(t *testing.T) {
+	data := t.TempDir()
+	if ch := checkDataDir(data); ch.Status != doctorFail {
+		t.Errorf("empty data dir: %+v, want fail", ch)
+	}
+	must(t, os.WriteFile(filepath.Join(data, "entries.html"), []byte("<html></html>"), 0o644))
+	must(t, os.WriteFile(filepath.Join(data, "releases.json"), []byte("[]"), 0o644))
+	must(t, os.MkdirAll(filepath.Join(data, "win", "beta"), 0o755))
+	if ch := checkDataDir(data); ch.Status != doctorOK {
+		t.Errorf("valid data dir: %+v, want ok", ch)
+	}
+	must(t, os.Mkdir(filepath.Join(data, "windows"), 0o755))
+	if ch := checkDataDir(data); ch.Status != doctorWarn || !strings.Contains(ch.Detail, "windows") {
+		t.Errorf("unknown platform dir: %+v, want warn naming it", ch)
+	}
+
+	if ch := checkWritableDir("builddir", filepath.Join(t.TempDir(), "not", "yet")); ch.Status != doctorOK {
+		t.Errorf("missing build dir under a writable parent: %+v, want ok", ch)
+	}
+	if ch := checkStatsFile(filepath.Join(t.TempDir(), "stats.json")); ch.Status != doctorOK {
+		t.Errorf("new stats file: %+v, want ok", ch)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	must(t, err)
+	defer ln.Close()
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	busy := checkPortFree(host, port)
+	if busy.Status != doctorFail || busy.Fix == "" {
+		t.Errorf("port in use: %+v, want fail with a fix", busy)
+	}
+
+	var buf bytes.Buffer
+	checks := []doctorCheck{{Name: "a", Status: doctorOK}, {Name: "b", Status: doctorWarn, Fix: "do b"}, busy}
+	if failed := printDoctor(&buf, checks); failed != 1 {
+		t.Errorf("printDoctor failed = %d, want 1", failed)
+	}
+	if out := buf.String(); !strings.Contains(out, "fix b: do b") || !strings.Contains(out, "fix port: ") {
+		t.Errorf("printDoctor output missing fixes:\n%s", out)
+	}
+}
+
+// TestConfigurePins verifies --tofu validation and that warn mode is passed
+// to the fetcher.
+func TestConfigurePins(t *testing.T) {
+	_, cert := writeSignedTree(t)
+	certs := []*x509.Certificate{cert}
+	pinFile := filepath.Join(t.TempDir(), "pins.json")
+
+	f := newsfetch.NewFetcherFromClient(http.DefaultClient)
+	must(t, configurePins(f, tofuOff, pinFile, certs))
+	if f.Pins != nil {
+		t.Error("--tofu off enabled pinning")
+	}
+	if err := configurePins(f, "strict", pinFile, certs); err == nil {
+		t.Error("unknown --tofu mode accepted")
+	}
+	if err := configurePins(f, tofuEnforce, pinFile, nil); err == nil {
+		t.Error("--tofu without trusted certificates accepted")
+	}
+	must(t, configurePins(f, tofuWarn, pinFile, certs))
+	if f.Pins == nil || !f.PinWarnOnly {
+		t.Errorf("--tofu warn: Pins = %v, PinWarnOnly = %v; want a store in warn-only mode", f.Pins, f.PinWarnOnly)
+	}
+}
+
+// TestBuildPlatform_FeedOverrides verifies that feed.json in a platform data
+// directory replaces the global feed title for that platform's feeds only.
+func TestBuildPlatform_FeedOverrides(t *testing.T) {
+	root, platDir := makeMinimalDataDir(t, "mac", "beta", true, false)
+	must(t, os.WriteFile(filepath.Join(platDir, builder.FeedOverridesName), []byte(`{"title": "I2P macOS Beta News"}`), 0o644))
+	buildDir := t.TempDir()
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.BuildDir = buildDir
+	c.FeedTitle = "I2P News"
+	c.FeedUuid = "00000000-0000-0000-0000-000000000005"
+	c.TranslationsDir = ""
+
+	buildPlatform("mac", "beta")
+	buildPlatform("", "")
+
+	for path, want := range map[string]string{
+		filepath.Join(buildDir, "mac", "beta", "news.atom.xml"): "I2P macOS Beta News",
+		filepath.Join(buildDir, "news.atom.xml"):                "I2P News",
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s missing title %q", path, want)
+		}
+	}
+	if data, _ := os.ReadFile(filepath.Join(buildDir, "news.atom.xml")); strings.Contains(string(data), "macOS") {
+		t.Error("mac/beta feed.json leaked into the default feed")
+	}
+}
+
+// TestLogLevel verifies the mapping of --quiet and --verbose to output levels
+// and that the root command rejects both together.
+func TestLogLevel(t *testing.T) {
+	for _, tc := range []struct {
+		quiet, verbose bool
+		want           newslogger.Level
+	}{
+		{false, false, newslogger.Normal},
+		{true, false, newslogger.Quiet},
+		{false, true, newslogger.Verbose},
+		{true, true, newslogger.Quiet},
+	} {
+		if got := logLevel(tc.quiet, tc.verbose); got != tc.want {
+			t.Errorf("logLevel(%v, %v) = %d, want %d", tc.quiet, tc.verbose, got, tc.want)
+		}
+	}
+	for _, name := range []string{"quiet", "verbose"} {
+		if LookupFlag("", name) == nil {
+			t.Errorf("persistent flag --%s not registered", name)
+		}
+	}
+	t.Cleanup(func() {
+		for _, name := range []string{"quiet", "verbose"} {
+			f := LookupFlag("", name)
+			f.Value.Set("false")
+			f.Changed = false
+		}
+		newslogger.SetLevel(newslogger.Normal)
+	})
+	if err := ExecuteWithArgs([]string{"status", "--quiet", "--verbose"}); err == nil {
+		t.Error("--quiet --verbose: want an error, got nil")
+	}
+}
+
+// TestBuildPlatform_SizeBudget verifies that feeds over --max-entry-size are
+// counted and still written, and that --strict-size rejects them.
+func TestBuildPlatform_SizeBudget(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "", "", false, false)
+
+	prev, prevOver := *c, overBudgetFeeds
+	defer func() { *c, overBudgetFeeds = prev, prevOver }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.FeedUuid = "00000000-0000-0000-0000-000000000006"
+	c.TranslationsDir = ""
+	c.MaxEntrySize = 16
+
+	for _, strict := range []bool{false, true} {
+		c.BuildDir = t.TempDir()
+		c.StrictSize = strict
+		overBudgetFeeds = 0
+		buildPlatform("", "")
+		if overBudgetFeeds == 0 {
+			t.Errorf("strict=%v: overBudgetFeeds = 0, want the default feed counted", strict)
+		}
+		_, err := os.Stat(filepath.Join(c.BuildDir, "news.atom.xml"))
+		if written := err == nil; written == strict {
+			t.Errorf("strict=%v: news.atom.xml written = %v", strict, written)
+		}
+	}
+}
+
+// TestBuildPlatform_WebSubHub verifies that --websub-hub is advertised in
+// the built feed and that publishToHub notifies the hub of --feedmain.
+func TestBuildPlatform_WebSubHub(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "", "", false, false)
+	var topics []string
+	hub := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, rq *http.Request) {
+		rq.ParseForm()
+		topics = rq.PostForm["hub.url"]
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer hub.Close()
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.FeedUuid = "00000000-0000-0000-0000-000000000007"
+	c.FeedMain = "https://news.example.org/news.atom.xml"
+	c.TranslationsDir = ""
+	c.BuildDir = t.TempDir()
+	c.WebSubHub = hub.URL
+
+	buildPlatform("", "")
+	feed, err := os.ReadFile(filepath.Join(c.BuildDir, "news.atom.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<link href="` + hub.URL + `" rel="hub"/>`; !strings.Contains(string(feed), want) {
+		t.Errorf("feed lacks %s", want)
+	}
+	publishToHub()
+	if len(topics) != 1 || topics[0] != c.FeedMain {
+		t.Errorf("hub notified of %q, want [%s]", topics, c.FeedMain)
+	}
+}
+
+// TestExportEntries verifies that fetched feeds are converted into an entries
+// data tree mirroring the fetched layout.
+func TestExportEntries(t *testing.T) {
+	const atom = `<feed xmlns="http://www.w3.org/2005/Atom"><title>Upstream</title>` +
+		`<entry><id>urn:up:1</id><title>Up</title><summary>S</summary>` +
+		`<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>B</p></div></content></entry></feed>`
+	outDir, exportDir := t.TempDir(), t.TempDir()
+	feeds := []string{
+		filepath.Join(outDir, "news.atom.xml"),
+		filepath.Join(outDir, "win", "beta", "news_pt_BR.atom.xml"),
+		filepath.Join(outDir, "fetched.atom.xml"),
+	}
+	for _, f := range feeds {
+		must(t, os.MkdirAll(filepath.Dir(f), 0o755))
+		must(t, os.WriteFile(f, []byte(atom), 0o644))
+	}
+	if err := exportEntries(feeds, outDir, exportDir); err != nil {
+		t.Fatalf("exportEntries: %v", err)
+	}
+	for _, rel := range []string{"entries.html", filepath.Join("win", "beta", "entries.pt_BR.html"), "fetched.html"} {
+		data, err := os.ReadFile(filepath.Join(exportDir, rel))
+		if err != nil {
+			t.Errorf("%s not exported: %v", rel, err)
+			continue
+		}
+		if !strings.Contains(string(data), `<article id="urn:up:1" title="Up">`) {
+			t.Errorf("%s does not hold the upstream article:\n%s", rel, data)
+		}
+	}
+}
+
+// TestCheckoutGitRef verifies that --git-ref points the data paths at a
+// checkout of the commit, whatever the working tree holds since, and that
+// paths outside --repo are rejected.
+func TestCheckoutGitRef(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(repo, append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries := filepath.Join(repo, "data", "entries.html")
+	if err := os.MkdirAll(filepath.Dir(entries), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(entries, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git("init", "-q")
+	git("add", ".")
+	git("commit", "-qm", "v1")
+	git("tag", "v1")
+	if err := os.WriteFile(entries, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.NewsFile = filepath.Join(repo, "data")
+	c.BlockList = filepath.Join(repo, "data", "blocklist.xml")
+	c.ReleaseJsonFile = ""
+	c.TranslationsDir = ""
+	src, cleanup, err := checkoutGitRef("v1", repo)
+	if err != nil {
+		t.Fatalf("checkoutGitRef: %v", err)
+	}
+	defer cleanup()
+	if src.Ref != "v1" || len(src.Commit) != 40 {
+		t.Errorf("gitSource = %+v, want ref v1 and a full commit hash", src)
+	}
+	if data, err := os.ReadFile(filepath.Join(c.NewsFile, "entries.html")); err != nil || string(data) != "v1" {
+		t.Errorf("checked out entries.html = %q, %v; want v1", data, err)
+	}
+	if filepath.Dir(c.BlockList) != c.NewsFile {
+		t.Errorf("BlockList = %s, want it rebased next to %s", c.BlockList, c.NewsFile)
+	}
+
+	c.NewsFile = t.TempDir()
+	if _, _, err := checkoutGitRef("v1", repo); err == nil {
+		t.Error("checkoutGitRef accepted a --newsfile outside --repo")
+	}
+	if _, _, err := checkoutGitRef("no-such-ref", repo); err == nil {
+		t.Error("checkoutGitRef accepted an unknown ref")
+	}
+}
+
+// TestParseChangeCutoff verifies the accepted --changed-since forms and that
+// the two cutoff flags exclude each other.
+func TestParseChangeCutoff(t *testing.T) {
+	cc, err := parseChangeCutoff("2025-06-01T00:00:00Z", false)
+	if err != nil || !cc.since.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("RFC 3339 cutoff = %+v, %v", cc, err)
+	}
+	cc, err = parseChangeCutoff("2025-06-01", false)
+	if err != nil || !cc.since.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("date cutoff = %+v, %v", cc, err)
+	}
+	if _, err := parseChangeCutoff("yesterday", false); err == nil {
+		t.Error("parseChangeCutoff accepted an unparseable cutoff")
+	}
+	if _, err := parseChangeCutoff("2025-06-01", true); err == nil {
+		t.Error("parseChangeCutoff accepted both --changed-since and --since-last-build")
+	}
+}
+
+// TestBuildPlatform_SinceLastBuild verifies that --since-last-build leaves a
+// feed whose inputs are older than its last build untouched, still lists it
+// in the index, and rebuilds it once an input changes or an article in it
+// expires.
+func TestBuildPlatform_SinceLastBuild(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "mac", "stable", false, false)
+	buildDir := t.TempDir()
+
+	prev := *c
+	defer func() { *c = prev }()
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.BuildDir = buildDir
+	c.FeedMain = "http://example.com/news.atom.xml"
+	c.FeedUuid = "00000000-0000-0000-0000-000000000001"
+	c.TranslationsDir = ""
+	defer func() { buildCutoff, builtFeeds, unchangedFeeds = changeCutoff{}, nil, 0 }()
+
+	// Date the inputs well before the first build, whose sidecar records
+	// the build time with one-second resolution.
+	old := time.Now().Add(-time.Hour)
+	for _, f := range []string{"entries.html", "releases.json", "blocklist.xml"} {
+		must(t, os.Chtimes(filepath.Join(root, f), old, old))
+	}
+	buildPlatform("mac", "stable")
+	out := filepath.Join(buildDir, "mac", "stable", "news.atom.xml")
+	must(t, os.WriteFile(out, []byte("kept"), 0o644))
+
+	buildCutoff, builtFeeds, unchangedFeeds = changeCutoff{lastBuild: true}, nil, 0
+	buildPlatform("mac", "stable")
+	if data, _ := os.ReadFile(out); string(data) != "kept" {
+		t.Errorf("feed with unchanged inputs was rebuilt")
+	}
+	if unchangedFeeds != 1 || len(builtFeeds) != 1 {
+		t.Errorf("unchangedFeeds = %d, builtFeeds = %d; want 1 and 1", unchangedFeeds, len(builtFeeds))
+	}
+
+	future := time.Now().Add(time.Hour)
+	must(t, os.Chtimes(filepath.Join(root, "entries.html"), future, future))
+	builtFeeds, unchangedFeeds = nil, 0
+	buildPlatform("mac", "stable")
+	if data, _ := os.ReadFile(out); string(data) == "kept" {
+		t.Errorf("feed was not rebuilt after entries.html changed")
+	}
+	if unchangedFeeds != 0 {
+		t.Errorf("unchangedFeeds = %d after an input changed, want 0", unchangedFeeds)
+	}
+
+	// An article that expired since the last build must leave the feed
+	// although no input changed.
+	must(t, os.Chtimes(filepath.Join(root, "entries.html"), old, old))
+	m, err := builder.ReadFeedMetadata(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.NextExpiry = time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	must(t, builder.WriteFeedMetadata(out, m))
+	must(t, os.WriteFile(out, []byte("kept"), 0o644))
+	builtFeeds, unchangedFeeds = nil, 0
+	buildPlatform("mac", "stable")
+	if data, _ := os.ReadFile(out); string(data) == "kept" {
+		t.Errorf("feed was not rebuilt after an article in it expired")
+	}
+}
+
+// TestSetup verifies that the answers setup reads end up in a config file
+// the commands can read, that the generated key and certificate match, and
+// that the scaffolded data tree builds.
+func TestSetup(t *testing.T) {
+	dir := t.TempDir()
+	prevBits := setupKeyBits
+	defer func() { setupKeyBits = prevBits }()
+	setupKeyBits = 1024
+
+	key := filepath.Join(dir, "signing_key.pem")
+	answers := strings.Join([]string{
+		"",              // signer ID is required: asked again
+		"news@mail.i2p", // signer ID
+		key,             // signing key
+		"maybe",         // not a yes/no answer: asked again
+		"",              // generate the key (default yes)
+		"",              // certificate: news_at_mail.i2p.crt
+		"http://example.i2p/news.atom.xml",
+		"", // no backup feed
+		"", // default site
+		filepath.Join(dir, "data"),
+		filepath.Join(dir, "build"),
+	}, "\n")
+	var out bytes.Buffer
+	a, err := askSetup(bufio.NewReader(strings.NewReader(answers)), &out)
+	if err != nil {
+		t.Fatalf("askSetup: %v\n%s", err, out.String())
+	}
+	if a.SignerID != "news@mail.i2p" || a.SignerCert != "news_at_mail.i2p.crt" || !a.GenerateKey || a.FeedBackup != "" {
+		t.Fatalf("answers = %+v", a)
+	}
+	a.SignerCert = filepath.Join(dir, a.SignerCert)
+	if err := applySetup(a); err != nil {
+		t.Fatalf("applySetup: %v", err)
+	}
+	cfg := filepath.Join(dir, "newsgo.yaml")
+	if err := writeSetupConfig(cfg, a); err != nil {
+		t.Fatalf("writeSetupConfig: %v", err)
+	}
+
+	sk, err := loadPrivateKey(key)
+	if err != nil {
+		t.Fatalf("loadPrivateKey: %v", err)
+	}
+	certs, err := newsfetch.LoadCertificates([]string{a.SignerCert})
+	if err != nil {
+		t.Fatalf("LoadCertificates: %v", err)
+	}
+	if !sk.Public().(*rsa.PublicKey).Equal(certs[0].PublicKey) || certs[0].Subject.CommonName != "news@mail.i2p" {
+		t.Errorf("certificate %v does not match the generated key", certs[0].Subject)
+	}
+	if err := generateSigningKey(key, a.SignerCert, a.SignerID); err == nil {
+		t.Error("generateSigningKey overwrote an existing key")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(cfg)
+	if err := v.ReadInConfig(); err != nil {
+		t.Fatalf("ReadInConfig: %v", err)
+	}
+	if got := v.GetString("signerid"); got != "news@mail.i2p" {
+		t.Errorf("signerid = %q", got)
+	}
+	if got := v.GetString("releasejson"); got != filepath.Join(dir, "data", "releases.json") {
+		t.Errorf("releasejson = %q", got)
+	}
+	if got := v.GetString("newsdir"); got != filepath.Join(dir, "build") {
+		t.Errorf("newsdir = %q", got)
+	}
+
+	data := filepath.Join(dir, "data")
+	nb := builder.Builder(filepath.Join(data, "entries.html"), filepath.Join(data, "releases.json"), filepath.Join(data, "blocklist.xml"))
+	if _, err := nb.Build(); err != nil {
+		t.Errorf("building the scaffolded data tree: %v", err)
+	}
+}
+
+// TestBuildErrorHint verifies that build failures caused by the entries file
+// get advice matching their category, and that other failures get none.
+func TestBuildErrorHint(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("Build: %w", newsfeed.ErrNoArticles), "<article>"},
+		{fmt.Errorf("Build: %w", &newsfeed.ErrMissingAttribute{Name: "updated", ArticleID: "urn:x"}), `add the updated attribute to <article id="urn:x">`},
+		{fmt.Errorf("Build: %w", &newsfeed.ErrMissingAttribute{Name: "id"}), "unique id"},
+		{fmt.Errorf("Build: article: %w", newsfeed.ErrBadDate), "RFC 3339"},
+		{fmt.Errorf("Build: %w", newsfeed.ErrInvalidExtensions), "application/atom+xml"},
+		{fmt.Errorf("Build: %w", builder.ErrOverBudget), ""},
+	}
+	for _, tt := range tests {
+		got := buildErrorHint(tt.err)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("buildErrorHint(%v) = %q, want it to contain %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// TestFeedID_Strategy verifies that feedID follows --id-strategy and keeps
+// the derived urn:uuid: ids by default.
+func TestFeedID_Strategy(t *testing.T) {
+	prev := *c
+	defer func() { *c = prev }()
+	c.FeedMain = "http://example.i2p/news.atom.xml"
+	c.FeedUuid = ""
+	c.IDStrategy = ""
+	want, err := builder.FeedUUID(builder.DefaultBaseUUID(c.FeedMain), "mac", "stable", "de")
+	must(t, err)
+	if got := feedID("mac", "stable", "de"); got != "urn:uuid:"+want {
+		t.Errorf("default feedID = %q, want urn:uuid:%s", got, want)
+	}
+	c.IDStrategy, c.TagAuthority, c.TagDate = builder.IDStrategyTagURI, "example.org", "2025-01-01"
+	if got := feedID("mac", "stable", "de"); got != "tag:example.org,2025-01-01:news/mac/stable/de" {
+		t.Errorf("tag-uri feedID = %q", got)
+	}
+}
+
+func TestImportNewsxml(t *testing.T) {
+	repo := t.TempDir()
+	entry := `<html><body><header>I2P News</header>
+<article id="urn:uuid:%s" title="Release" href="http://i2p-projekt.i2p/" author="zzz" published="2023-06-21" updated="2023-06-22T10:00:00Z">
+<details><summary>Summary</summary></details><p>Body</p></article></body></html>`
+	files := map[string]string{
+		"data/entries.html":                    fmt.Sprintf(entry, "1"),
+		"data/releases.json":                   "[]",
+		"data/blocklist.xml":                   "",
+		"data/translations/entries.de.html":    fmt.Sprintf(entry, "1"),
+		"data/translations/entries.pt-BR.html": fmt.Sprintf(entry, "2"),
+		"data/translations/README":             "notes",
+		"data/win/beta/releases.json":          "[]",
+		"data/win/beta/entries.html":           strings.Replace(fmt.Sprintf(entry, "3"), ` updated="2023-06-22T10:00:00Z"`, "", 1),
+		"data/unknown/beta/releases.json":      "[]",
+		"data/.git/config":                     "",
+		"etc/su3.vars":                         "SIGNER=you@mail.i2p",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src := newsxmlDataDir(repo)
+	plan, skipped, err := newsxmlImportPlan(src)
+	if err != nil {
+		t.Fatalf("newsxmlImportPlan: %v", err)
+	}
+	var dsts []string
+	for _, f := range plan {
+		dsts = append(dsts, filepath.ToSlash(f.Dst))
+	}
+	wantDsts := []string{
+		"blocklist.xml", "entries.html", "releases.json",
+		"translations/entries.de.html", "translations/entries.pt_BR.html",
+		"win/beta/entries.html", "win/beta/releases.json",
+	}
+	if !reflect.DeepEqual(dsts, wantDsts) {
+		t.Errorf("plan = %v, want %v", dsts, wantDsts)
+	}
+	if len(skipped) != 2 {
+		t.Errorf("skipped = %v, want translations/README and unknown/beta/releases.json", skipped)
+	}
+
+	into := filepath.Join(t.TempDir(), "data")
+	if err := os.MkdirAll(into, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(into, "releases.json"), []byte("kept"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	copied, err := importNewsxml(&out, src, into, plan, false)
+	if err != nil {
+		t.Fatalf("importNewsxml: %v", err)
+	}
+	if copied != len(plan)-1 || !strings.Contains(out.String(), "Keeping existing") {
+		t.Errorf("copied %d of %d, output %q", copied, len(plan), out.String())
+	}
+	if got, _ := os.ReadFile(filepath.Join(into, "releases.json")); string(got) != "kept" {
+		t.Errorf("releases.json replaced without force: %q", got)
+	}
+	got, err := os.ReadFile(filepath.Join(into, "translations", "entries.pt_BR.html"))
+	if err != nil || string(got) != files["data/translations/entries.pt-BR.html"] {
+		t.Errorf("entries.pt_BR.html = %q, %v; want a byte-for-byte copy", got, err)
+	}
+
+	out.Reset()
+	if problems := checkImportedEntries(&out, into, plan); problems != 1 {
+		t.Errorf("problems = %d, want 1 (win/beta article without updated)\n%s", problems, out.String())
+	}
+	if !strings.Contains(out.String(), "missing updated attribute") {
+		t.Errorf("output %q does not name the missing attribute", out.String())
+	}
+
+	if _, err := importNewsxml(&out, src, into, plan, true); err != nil {
+		t.Fatalf("importNewsxml force: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(into, "releases.json")); string(got) != "[]" {
+		t.Errorf("releases.json = %q after --force", got)
+	}
+}
+
+// TestCompareVersions verifies the ordering of release versions, with and
+// without a leading "v", pre-releases, and differing lengths.
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"v0.1.0", "0.1.0", 0},
+		{"v0.1.0", "v0.2.0", -1},
+		{"v0.10.0", "v0.9.3", 1},
+		{"v1.2", "v1.2.0", 0},
+		{"v1.2.0-rc1", "v1.2.0", -1},
+		{"v1.2.0-rc2", "v1.2.0-rc1", 1},
+		{"v1.2.0+dirty", "v1.2.0", 0},
+	} {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestLatestRelease verifies that version --check reads the newest newsgo
+// release from a GitHub release feed, a news feed that also announces router
+// releases, and a JSON release document, and how it reports the result.
+func TestLatestRelease(t *testing.T) {
+	docs := map[string]string{
+		"/releases.atom": `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><title>v0.1.2</title><link rel="alternate" href="https://github.com/go-i2p/newsgo/releases/tag/v0.1.2"/></entry>
+  <entry><title>Bugfixes</title><link rel="alternate" href="https://github.com/go-i2p/newsgo/releases/tag/v0.2.0"/></entry>
+  <entry><title>v0.1.10</title><link rel="alternate" href="https://github.com/go-i2p/newsgo/releases/tag/v0.1.10"/></entry>
+</feed>`,
+		"/news.atom.xml": `<feed xmlns="http://www.w3.org/2005/Atom">
+  <entry><title>2.7.0 Release</title><link href="http://i2p-projekt.i2p/en/blog/post/2.7.0"/></entry>
+  <entry><title>newsgo 0.3.0 for mirror operators</title><link href="http://i2p-projekt.i2p/en/blog/post/newsgo"/></entry>
+</feed>`,
+		"/latest.json": `{"tag_name": "v0.4.0", "html_url": "https://github.com/go-i2p/newsgo/releases/tag/v0.4.0"}`,
+		"/router.atom": `<feed xmlns="http://www.w3.org/2005/Atom"><entry><title>2.7.0 Release</title></entry></feed>`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, rq *http.Request) {
+		doc, ok := docs[rq.URL.Path]
+		if !ok {
+			http.NotFound(rw, rq)
+			return
+		}
+		io.WriteString(rw, doc)
+	}))
+	defer srv.Close()
+	f := newsfetch.NewFetcherFromClient(srv.Client())
+
+	for path, want := range map[string]string{
+		"/releases.atom": "v0.2.0",
+		"/news.atom.xml": "0.3.0",
+		"/latest.json":   "v0.4.0",
+	} {
+		rel, err := latestRelease(f, srv.URL+path, nil)
+		if err != nil || rel.Version != want {
+			t.Errorf("latestRelease(%s) = %+v, %v; want version %s", path, rel, err, want)
+		}
+	}
+	if _, err := latestRelease(f, srv.URL+"/router.atom", nil); !errors.Is(err, errNoRelease) {
+		t.Errorf("latestRelease(router-only feed) = %v, want errNoRelease", err)
+	}
+
+	latest := release{Version: "v0.2.0", URL: "https://example.org/v0.2.0"}
+	for current, want := range map[string]bool{"v0.1.2": true, "v0.2.0": false, "v0.3.0": false, "devel": false} {
+		if outdated, report := compareRelease(current, latest); outdated != want {
+			t.Errorf("compareRelease(%q) = %v (%s), want outdated=%v", current, outdated, report, want)
+		}
+	}
+}
+
+// TestRunBench verifies that bench builds and signs every feed of the matrix
+// on each iteration and reports every stage, and that the report compares
+// against a baseline.
+func TestRunBench(t *testing.T) {
+	root, _ := makeMinimalDataDir(t, "mac", "stable", false, false)
+	work := t.TempDir()
+	prevBits := setupKeyBits
+	prev := *c
+	defer func() { setupKeyBits = prevBits; *c = prev }()
+	setupKeyBits = 1024
+	c.NewsFile = root
+	c.ReleaseJsonFile = filepath.Join(root, "releases.json")
+	c.BlockList = filepath.Join(root, "blocklist.xml")
+	c.TranslationsDir = ""
+
+	ns, err := benchSigner("", "", "bench@example.i2p", work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := runBench(2, ns, filepath.Join(work, "build"))
+	if err != nil {
+		t.Fatalf("runBench: %v", err)
+	}
+	// The default tree and mac/stable.
+	if report.Feeds != 2 || report.Iterations != 2 {
+		t.Errorf("report covers %d feed(s) over %d iteration(s), want 2 over 2", report.Feeds, report.Iterations)
+	}
+	for _, stage := range benchStages {
+		if s := report.Stages[stage]; s.Mean <= 0 || s.Min > s.Mean || s.Mean > s.Max {
+			t.Errorf("stage %s: %+v", stage, s)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(work, "build", "mac", "stable", "news.su3")); err != nil {
+		t.Errorf("mac/stable feed not signed: %v", err)
+	}
+
+	baseline := benchReport{Stages: map[string]benchStats{benchSign: {Mean: report.Stages[benchSign].Mean / 2}}}
+	var out bytes.Buffer
+	printBenchReport(&out, report, &baseline)
+	if !strings.Contains(out.String(), "+100.0%") || !strings.Contains(out.String(), "BASELINE") {
+		t.Errorf("report does not compare against the baseline:\n%s", out.String())
+	}
+}
+
+// TestRunReport verifies the per-channel table, the errored feeds, and the
+// final line of a runReport, and that colors are only added when asked for.
+func TestRunReport(t *testing.T) {
+	r := newRunReport("build", resultBuilt, resultSkipped, resultErrored)
+	r.add("news.atom.xml", resultBuilt, nil)
+	r.add("news_de.atom.xml", resultSkipped, nil)
+	r.add(filepath.Join("mac", "stable", "news.atom.xml"), resultBuilt, nil)
+	r.add(filepath.Join("mac", "stable", "news_fr.atom.xml"), resultErrored, errors.New("no articles"))
+	var nilReport *runReport
+	nilReport.add("news.atom.xml", resultBuilt, nil)
+
+	var buf bytes.Buffer
+	r.print(&buf, false, "1 feed(s) over size budget")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"CHANNEL     BUILT  SKIPPED  ERRORED",
+		"(default)   1      1        0",
+		"mac/stable  1      0        1",
+		"total       2      1        1",
+		"errored mac/stable/news_fr.atom.xml: no articles",
+	}
+	if len(lines) != len(want)+1 {
+		t.Fatalf("report has %d lines, want %d:\n%s", len(lines), len(want)+1, buf.String())
+	}
+	for i, w := range want {
+		if lines[i] != w {
+			t.Errorf("line %d = %q, want %q", i, lines[i], w)
+		}
+	}
+	last := lines[len(lines)-1]
+	if !strings.HasPrefix(last, "build: 2 built, 1 skipped, 1 errored in ") || !strings.HasSuffix(last, "; 1 feed(s) over size budget") {
+		t.Errorf("final line = %q", last)
+	}
+	if strings.Contains(buf.String(), "\x1b[") {
+		t.Error("uncolored report contains escape codes")
+	}
+
+	buf.Reset()
+	r.print(&buf, true, "")
+	if !strings.Contains(buf.String(), "\x1b[31m1      \x1b[0m") {
+		t.Errorf("colored report does not color the errored count:\n%q", buf.String())
+	}
+	t.Setenv("NO_COLOR", "1")
+	if useColor(os.Stdout) {
+		t.Error("useColor = true with NO_COLOR set")
+	}
+}
+
+// TestSchema checks the generated schemas: config keys are named as viper
+// reads them and described by their flags, and releases.json requires every
+// field JSONtoXML needs but the hashes.
+func TestSchema(t *testing.T) {
+	cfg := configSchema()
+	if *cfg.AdditionalProperties {
+		t.Error("config schema accepts unknown keys")
+	}
+	for key, typ := range map[string]string{"trustedcerts": "array", "blocklist-max-size": "integer", "every": "string", "newsdir": "string", "quiet": "boolean"} {
+		p := cfg.Properties[key]
+		if p == nil || p.Type != typ {
+			t.Errorf("config key %q = %+v, want type %s", key, p, typ)
+		}
+	}
+	if p := cfg.Properties["trustedcerts"]; p != nil && !strings.Contains(p.Description, "PEM certificate") {
+		t.Errorf("trustedcerts description = %q, want the flag help", p.Description)
+	}
+	if p := cfg.Properties["every"]; p != nil && p.Pattern != durationPattern {
+		t.Errorf("every pattern = %q, want a duration", p.Pattern)
+	}
+
+	rel := releasesSchema()
+	if rel.Type != "array" || rel.MinItems != 1 || rel.Items == nil {
+		t.Fatalf("releases schema = %+v, want a non-empty array", rel)
+	}
+	if got := strings.Join(rel.Items.Required, ","); got != "date,version,minVersion,minJavaVersion,updates" {
+		t.Errorf("release required = %s", got)
+	}
+	su3 := rel.Items.Properties["updates"].Properties["su3"]
+	if got := strings.Join(su3.Required, ","); got != "torrent,url" {
+		t.Errorf("su3 required = %s, want torrent,url", got)
+	}
+	if p := su3.Properties["hashes"].Properties["sha256"]; p == nil || p.Pattern == "" {
+		t.Errorf("sha256 schema = %+v, want a hex pattern", p)
+	}
+	if p := rel.Items.Properties["revoked"]; p == nil || p.Items == nil || p.Items.Pattern == "" {
+		t.Errorf("revoked schema = %+v, want an array of version patterns", p)
+	}
+	if p := rel.Items.Properties["signers"]; p == nil || p.Items == nil || p.Items.Pattern != `^\S+$` {
+		t.Errorf("signers schema = %+v, want an array of signer ID patterns", p)
+	}
+	if _, err := json.Marshal(rel); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestParseChangelog verifies that the section of the requested release is
+// picked from a CHANGELOG, with continuation lines joined to their item.
+func TestParseChangelog(t *testing.T) {
+	const changelog = `# Changelog
+
+## 2.7.0
+- Faster reseeding
+- New tunnel build
+  handling for congested routers
+* Fixed the console <em>theme</em>
+
+2.6.0
+-----
+- Older change
+`
+	items, err := parseChangelog(strings.NewReader(changelog), "2.7.0")
+	if err != nil {
+		t.Fatalf("parseChangelog: %v", err)
+	}
+	want := []string{"Faster reseeding", "New tunnel build handling for congested routers", "Fixed the console <em>theme</em>"}
+	if len(items) != len(want) {
+		t.Fatalf("items = %+v, want %q", items, want)
+	}
+	for i, it := range items {
+		if it.Subject != want[i] {
+			t.Errorf("item %d = %q, want %q", i, it.Subject, want[i])
+		}
+	}
+	if items, err := parseChangelog(strings.NewReader(changelog), "2.6.0"); err != nil || len(items) != 1 || items[0].Subject != "Older change" {
+		t.Errorf("underlined 2.6.0 section = %+v, %v; want [Older change]", items, err)
+	}
+	if _, err := parseChangelog(strings.NewReader(changelog), "9.9.9"); err == nil {
+		t.Error("parseChangelog accepted a release without a section")
+	}
+}
+
+// TestChangelogFromGit verifies that the drafted article lists the commits of
+// the range, without fixups, records the range as full hashes, and is a draft
+// build can parse.
+func TestChangelogFromGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	repo := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		if _, err := runGit(repo, append([]string{"-c", "user.name=t", "-c", "user.email=t@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "Release 2.6.0")
+	git("tag", "v2.6.0")
+	git("commit", "-q", "--allow-empty", "-m", "Add <b>faster</b> reseeding")
+	git("commit", "-q", "--allow-empty", "-m", "fixup! Add <b>faster</b> reseeding")
+	git("commit", "-q", "--allow-empty", "-m", "Fix console theme")
+
+	items, source, err := changelogFromGit(repo, "v2.6.0", "HEAD")
+	if err != nil {
+		t.Fatalf("changelogFromGit: %v", err)
+	}
+	if len(items) != 2 || items[0].Subject != "Add <b>faster</b> reseeding" || items[1].Subject != "Fix console theme" {
+		t.Fatalf("items = %+v, want the two commits oldest first", items)
+	}
+	head, err := resolveGitRef(repo, "HEAD")
+	must(t, err)
+	if !strings.Contains(source, head) {
+		t.Errorf("source %q does not name HEAD as %s", source, head)
+	}
+
+	var out bytes.Buffer
+	d := changelogData{ID: "urn:uuid:0f2c6d8e-8a51-4b7e-9c1d-3e5f7a9b1c2d", Title: "2.7.0 Released", Href: "http://i2p-projekt.i2p/", Author: "newsgo", Published: "2025-06-01T00:00:00Z", Source: source, Items: items}
+	must(t, defaultChangelogTemplate.Execute(&out, d))
+	entries := filepath.Join(t.TempDir(), "entries.html")
+	must(t, os.WriteFile(entries, out.Bytes(), 0o644))
+	feed := &newsfeed.Feed{EntriesHTMLPath: entries}
+	must(t, feed.LoadHTML())
+	if feed.Length() != 1 {
+		t.Fatalf("drafted %d articles, want 1:\n%s", feed.Length(), out.String())
+	}
+	a := feed.Article(0)
+	if !a.Draft || a.Title != "2.7.0 Released" || a.UpdatedDate == "" {
+		t.Errorf("article = %+v, want a dated draft titled 2.7.0 Released", a)
+	}
+	if content := a.Content(); !strings.Contains(content, "&lt;b&gt;faster") {
+		t.Errorf("content %q does not hold the escaped commit subject", content)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestOutputFilename_Windows verifies the Windows-only path rules
+// outputFilename inherits from filepath.Rel: drive letters and UNC hosts
+// compare case-insensitively, and a UNC share root contains its build tree.
+func TestOutputFilename_Windows(t *testing.T) {
+	tests := []struct {
+		newsFile, newsRoot, want string
+	}{
+		{`c:\News\Data\translations\entries.de.html`, `C:\news\data`, "news_de.atom.xml"},
+		{`\\SRV\share\data\win\beta\entries.html`, `\\srv\share\data`, `win\beta\news.atom.xml`},
+		{`\\srv\share\entries.html`, `\\srv\share\`, "news.atom.xml"},
+		{`\\srv\other\data\entries.fr.html`, `\\srv\share\data`, "news_fr.atom.xml"},
+	}
+	for _, tt := range tests {
+		if got := outputFilename(tt.newsFile, tt.newsRoot); got != tt.want {
+			t.Errorf("outputFilename(%q, %q) = %q, want %q", tt.newsFile, tt.newsRoot, got, tt.want)
+		}
+	}
+}
+
+// TestContainsDir_Windows verifies that containsDir, which clean uses to
+// prune emptied directories, accepts drive and UNC share roots as build
+// directories and keeps other shares and drives out.
+func TestContainsDir_Windows(t *testing.T) {
+	tests := []struct {
+		dir, path string
+		want      bool
+	}{
+		{`\\srv\share`, `\\srv\share\build\win`, true},
+		{`\\srv\share\build`, `\\srv\share\build\win\beta`, true},
+		{`\\srv\share\build`, `\\srv\other\build\win`, false},
+		{`C:\`, `C:\build\win`, true},
+		{`C:\build`, `D:\build\win`, false},
+	}
+	for _, tt := range tests {
+		if got := containsDir(filepath.Clean(tt.dir), tt.path); got != tt.want {
+			t.Errorf("containsDir(%q, %q) = %v, want %v", tt.dir, tt.path, got, tt.want)
+		}
+	}
+}
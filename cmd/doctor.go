@@ -0,0 +1,375 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	"github.com/go-i2p/onramp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Check the environment newsgo runs in and suggest fixes",
+	Long: `doctor checks the things that most often stop newsgo from working:
+
+  sam        a SAMv3 gateway answers on --samaddr
+  key        --signingkey can be loaded
+  cert       --signercert can be read, matches the key, and has not expired
+  data       --newsfile has entries.html, releases.json, and known platform dirs
+  builddir   --builddir is writable
+  statsfile  --statsfile can be written
+  port       --host:--port is free for serve, for each host
+
+Each check prints ok, warn, or fail, and every problem comes with a
+suggested fix.  doctor exits non-zero if any check fails.  Settings not given
+on the command line are taken from the config file and environment, as the
+other commands would see them.
+
+Example:
+  newsgo doctor --signingkey signing_key.pem --signercert news.crt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		// Every flag shares its name with a flag of serve, build, or sign, so
+		// none is bound to viper (see init); doctorSetting reads them.
+		setting := func(name string) string { return doctorSetting(cmd, name) }
+		now := time.Now()
+
+		checks := []doctorCheck{checkSAM(setting("samaddr"))}
+		key, keyCheck := checkSigningKey(setting("signingkey"), setting("keystorepass"), setting("keyentrypass"), setting("signerid"))
+		checks = append(checks,
+			keyCheck,
+			checkSignerCert(setting("signercert"), key, now),
+			checkDataDir(setting("newsfile")),
+			checkWritableDir("builddir", setting("builddir")),
+			checkStatsFile(setting("statsfile")),
+		)
+		hosts, err := clearnetHosts(doctorHosts(cmd))
+		if err != nil {
+			log.Fatalf("doctor: --host: %v", err)
+		}
+		for _, host := range hosts {
+			checks = append(checks, checkPortFree(host, setting("port")))
+		}
+		if failed := printDoctor(os.Stdout, checks); failed > 0 {
+			log.Fatalf("doctor: %d check(s) failed", failed)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().String("samaddr", onramp.SAM_ADDR, "SAMv3 gateway address to check")
+	doctorCmd.Flags().String("signingkey", "signing_key.pem", "signing key to check, as passed to sign")
+	doctorCmd.Flags().String("keystorepass", "", "keystore password, as passed to sign")
+	doctorCmd.Flags().String("keyentrypass", "", "keystore entry password, as passed to sign")
+	doctorCmd.Flags().String("signerid", "null@example.i2p", "signer ID, as passed to sign (the keystore alias)")
+	doctorCmd.Flags().String("signercert", "", "signer certificate to check, as passed to sign")
+	doctorCmd.Flags().String("newsfile", "data", "data directory or entries file to check, as passed to build")
+	doctorCmd.Flags().String("builddir", "build", "build directory to check")
+	doctorCmd.Flags().String("statsfile", "build/stats.json", "stats file to check, as passed to serve")
+	doctorCmd.Flags().StringSlice("host", []string{"127.0.0.1"}, "hosts serve listens on, each checked")
+	doctorCmd.Flags().String("port", "9696", "port serve listens on")
+
+	// No flag is bound to viper: every name above is already a key of serve,
+	// build, or sign, and binding it here would repoint that key at this
+	// command's flag (see the BindPFlags collision notes in build.go).
+}
+
+// doctorSetting returns the value of the doctor flag name: the flag itself
+// when given on the command line, otherwise the configured value of the key
+// of the same name, which falls back to the owning command's flag default.
+func doctorSetting(cmd *cobra.Command, name string) string {
+	flag := cmd.Flags().Lookup(name)
+	if flag.Changed || !viper.IsSet(name) {
+		return flag.Value.String()
+	}
+	return viper.GetString(name)
+}
+
+// doctorHosts returns the --host list of doctor, read like doctorSetting.
+func doctorHosts(cmd *cobra.Command) []string {
+	if !cmd.Flags().Changed("host") && viper.IsSet("host") {
+		return viper.GetStringSlice("host")
+	}
+	hosts, _ := cmd.Flags().GetStringSlice("host")
+	return hosts
+}
+
+// Outcomes of a doctor check.
+const (
+	doctorOK   = "ok"
+	doctorWarn = "warn"
+	doctorFail = "fail"
+)
+
+// doctorCheck is the outcome of one doctor check.  Fix is the suggested
+// remedy and is empty for passing checks.
+type doctorCheck struct {
+	Name   string
+	Status string
+	Detail string
+	Fix    string
+}
+
+// samProbeTimeout bounds both the connection to the SAM gateway and the wait
+// for its HELLO reply.
+const samProbeTimeout = 3 * time.Second
+
+// checkSAM reports whether a SAMv3 gateway answers the protocol handshake at
+// addr.  Unlike checkPortListening it tells a SAM bridge apart from any other
+// process that happens to hold the port.  SAM is only needed to serve or
+// fetch over I2P, so a missing gateway is a warning.
+func checkSAM(addr string) doctorCheck {
+	check := doctorCheck{Name: "sam"}
+	fix := "start an I2P router with the SAM bridge enabled (i2pd: [sam] enabled = true; Java I2P: start the SAM application bridge on /configclients), or point --samaddr at it"
+	conn, err := net.DialTimeout("tcp", addr, samProbeTimeout)
+	if err != nil {
+		check.Status, check.Detail, check.Fix = doctorWarn, fmt.Sprintf("nothing listening on %s: %v", addr, err), fix
+		return check
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(samProbeTimeout))
+	if _, err := io.WriteString(conn, "HELLO VERSION MIN=3.0 MAX=3.3\n"); err != nil {
+		check.Status, check.Detail, check.Fix = doctorWarn, fmt.Sprintf("%s: %v", addr, err), fix
+		return check
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	reply = strings.TrimSpace(reply)
+	switch {
+	case err != nil && reply == "":
+		check.Status, check.Detail = doctorWarn, fmt.Sprintf("%s accepted a connection but sent no SAM reply: %v", addr, err)
+		check.Fix = "the process on " + addr + " is probably not a SAM bridge; " + fix
+	case !strings.HasPrefix(reply, "HELLO REPLY") || !strings.Contains(reply, "RESULT=OK"):
+		check.Status, check.Detail = doctorWarn, fmt.Sprintf("%s answered %q instead of a SAMv3 HELLO", addr, reply)
+		check.Fix = "the process on " + addr + " is not a SAMv3 bridge; " + fix
+	default:
+		check.Status, check.Detail = doctorOK, fmt.Sprintf("SAM bridge at %s: %s", addr, reply)
+	}
+	return check
+}
+
+// checkSigningKey loads the signing key the way sign does and returns it, or
+// nil when it cannot be loaded.
+func checkSigningKey(path, storePassword, entryPassword, alias string) (crypto.Signer, doctorCheck) {
+	check := doctorCheck{Name: "key"}
+	key, err := loadKey(path, storePassword, entryPassword, alias)
+	switch {
+	case os.IsNotExist(err):
+		check.Status, check.Detail = doctorFail, fmt.Sprintf("%s does not exist", path)
+		check.Fix = "create a key (e.g. openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:4096 -out " + path + ") or pass --signingkey"
+	case err != nil:
+		check.Status, check.Detail = doctorFail, fmt.Sprintf("%s: %v", path, err)
+		check.Fix = "use a PEM private key, or a keystore with the right --keystorepass, --keyentrypass, and --signerid alias"
+	default:
+		check.Status, check.Detail = doctorOK, fmt.Sprintf("%s: %T", path, key)
+	}
+	return key, check
+}
+
+// certExpiryWarning is how long before its NotAfter a signer certificate is
+// reported as about to expire.
+const certExpiryWarning = 30 * 24 * time.Hour
+
+// checkSignerCert checks the signer certificate at path: that it loads, that
+// its public key is the public half of key (when key is known), and that it
+// is valid at now.  An unset path passes, as sign then uses a self-signed
+// certificate.
+func checkSignerCert(path string, key crypto.Signer, now time.Time) doctorCheck {
+	check := doctorCheck{Name: "cert"}
+	if path == "" {
+		check.Status, check.Detail = doctorOK, "--signercert not set; sign verifies against a self-signed certificate for the key"
+		return check
+	}
+	certs, err := newsfetch.LoadCertificates([]string{path})
+	if err != nil {
+		check.Status, check.Detail = doctorFail, err.Error()
+		check.Fix = "pass the PEM certificate routers use to verify the feed with --signercert"
+		return check
+	}
+	cert := certs[0]
+	if key != nil {
+		if eq, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); ok && !eq.Equal(cert.PublicKey) {
+			check.Status, check.Detail = doctorFail, fmt.Sprintf("%s does not match the signing key", path)
+			check.Fix = "every signed su3 would fail verification; pass the certificate issued for --signingkey"
+			return check
+		}
+	}
+	switch {
+	case now.After(cert.NotAfter):
+		check.Status, check.Detail = doctorFail, fmt.Sprintf("%s expired on %s", path, cert.NotAfter.Format(time.DateOnly))
+		check.Fix = "issue a new certificate for the signing key and distribute it to routers before signing again"
+	case now.Before(cert.NotBefore):
+		check.Status, check.Detail = doctorFail, fmt.Sprintf("%s is not valid until %s", path, cert.NotBefore.Format(time.DateOnly))
+		check.Fix = "check the system clock, or wait until the certificate becomes valid"
+	case cert.NotAfter.Sub(now) < certExpiryWarning:
+		check.Status, check.Detail = doctorWarn, fmt.Sprintf("%s expires on %s", path, cert.NotAfter.Format(time.DateOnly))
+		check.Fix = "issue a new certificate now; routers need it before the old one expires"
+	default:
+		check.Status, check.Detail = doctorOK, fmt.Sprintf("%s valid until %s", path, cert.NotAfter.Format(time.DateOnly))
+	}
+	return check
+}
+
+// checkDataDir checks that newsFile is an entries file, or a data directory
+// with entries.html and releases.json whose subdirectories are all known
+// platforms (or translations).
+func checkDataDir(newsFile string) doctorCheck {
+	check := doctorCheck{Name: "data"}
+	info, err := os.Stat(newsFile)
+	if err != nil {
+		check.Status, check.Detail = doctorFail, err.Error()
+		check.Fix = "pass the data directory (or entries.html) with --newsfile"
+		return check
+	}
+	if !info.IsDir() {
+		check.Status, check.Detail = doctorOK, fmt.Sprintf("%s is a single entries file", newsFile)
+		return check
+	}
+	var missing []string
+	if !newsfeed.EntriesExist(filepath.Join(newsFile, "entries.html")) {
+		missing = append(missing, "entries.html")
+	}
+	if _, err := os.Stat(filepath.Join(newsFile, "releases.json")); err != nil {
+		missing = append(missing, "releases.json")
+	}
+	if len(missing) > 0 {
+		check.Status, check.Detail = doctorFail, fmt.Sprintf("%s has no %s", newsFile, strings.Join(missing, " or "))
+		check.Fix = "every data directory needs entries.html (or an entries.d directory) and releases.json at its root; check --newsfile"
+		return check
+	}
+	entries, err := os.ReadDir(newsFile)
+	if err != nil {
+		check.Status, check.Detail = doctorFail, err.Error()
+		check.Fix = "make " + newsFile + " readable by this user"
+		return check
+	}
+	var unknown []string
+	for _, e := range entries {
+		if e.IsDir() && e.Name() != "translations" && e.Name() != "entries.d" && !contains(builder.KnownPlatforms(), e.Name()) {
+			unknown = append(unknown, e.Name())
+		}
+	}
+	if len(unknown) > 0 {
+		check.Status, check.Detail = doctorWarn, fmt.Sprintf("%s: unknown directories %s are not built", newsFile, strings.Join(unknown, ", "))
+		check.Fix = "platform directories must be one of " + strings.Join(builder.KnownPlatforms(), ", ")
+		return check
+	}
+	check.Status, check.Detail = doctorOK, newsFile
+	return check
+}
+
+// contains reports whether list includes s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// checkWritableDir checks that files can be created in dir by creating and
+// removing a temporary file.  A missing dir passes when its nearest existing
+// parent is writable, as build creates it.
+func checkWritableDir(name, dir string) doctorCheck {
+	check := doctorCheck{Name: name}
+	target := dir
+	for {
+		if _, err := os.Stat(target); err == nil {
+			break
+		}
+		parent := filepath.Dir(target)
+		if parent == target {
+			break
+		}
+		target = parent
+	}
+	f, err := os.CreateTemp(target, ".newsgo-doctor-*")
+	if err != nil {
+		check.Status, check.Detail = doctorFail, fmt.Sprintf("%s is not writable: %v", target, err)
+		check.Fix = "create " + dir + " and make it writable by the user running newsgo"
+		return check
+	}
+	f.Close()
+	os.Remove(f.Name())
+	check.Status, check.Detail = doctorOK, dir
+	if target != dir {
+		check.Detail = fmt.Sprintf("%s does not exist yet; %s is writable", dir, target)
+	}
+	return check
+}
+
+// checkStatsFile checks that the stats file can be saved: its directory must
+// be writable and an existing file must be writable too.
+func checkStatsFile(path string) doctorCheck {
+	check := checkWritableDir("statsfile", filepath.Dir(path))
+	if check.Status != doctorOK {
+		check.Fix = "stats would be lost on shutdown; make " + filepath.Dir(path) + " writable or pass --statsfile"
+		return check
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	switch {
+	case os.IsNotExist(err):
+		check.Detail = path + " will be created on the first save"
+	case err != nil:
+		check.Status, check.Detail = doctorFail, err.Error()
+		check.Fix = "stats would be lost on shutdown; make " + path + " writable or pass --statsfile"
+	default:
+		f.Close()
+		check.Detail = path
+	}
+	return check
+}
+
+// checkPortFree checks that serve could listen on host:port.
+func checkPortFree(host, port string) doctorCheck {
+	check := doctorCheck{Name: "port"}
+	addr := net.JoinHostPort(host, port)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		check.Status, check.Detail = doctorFail, err.Error()
+		check.Fix = "another process (perhaps a running newsgo serve) holds " + addr + "; stop it or pass a different --port"
+		return check
+	}
+	ln.Close()
+	check.Status, check.Detail = doctorOK, addr+" is free"
+	return check
+}
+
+// printDoctor writes one row per check to w followed by the suggested fix for
+// every check that did not pass, and returns the number of failed checks.
+func printDoctor(w io.Writer, checks []doctorCheck) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDETAIL")
+	failed := 0
+	var fixes []string
+	for _, ch := range checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", ch.Name, ch.Status, ch.Detail)
+		if ch.Status == doctorFail {
+			failed++
+		}
+		if ch.Fix != "" {
+			fixes = append(fixes, fmt.Sprintf("%s: %s", ch.Name, ch.Fix))
+		}
+	}
+	tw.Flush()
+	for _, fix := range fixes {
+		fmt.Fprintln(w, "fix", fix)
+	}
+	return failed
+}
@@ -2,13 +2,17 @@ package cmd
 
 import (
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	builder "github.com/go-i2p/newsgo/builder"
 	newsfetch "github.com/go-i2p/newsgo/fetch"
+	newslogger "github.com/go-i2p/newsgo/logger"
 	"github.com/go-i2p/onramp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -32,7 +36,29 @@ Examples:
   newsgo fetch --newsurl <url> --trustedcerts /path/to/news.crt
 
   # Try a primary URL then a backup:
-  newsgo fetch --newsurl <primary> --newsurls <backup1>,<backup2>`,
+  newsgo fetch --newsurl <primary> --newsurls <backup1>,<backup2>
+
+  # Fetch every platform/status/language variant into the build layout:
+  newsgo fetch --template 'http://<host>/news/{platform}/{status}/news_{lang}.su3' \
+    --platforms win,mac --statuses stable,beta --langs en,de,pt_BR
+
+  # Refuse a feed whose signer differs from the one seen on the first fetch:
+  newsgo fetch --newsurl <url> --trustedcerts certs/ --tofu enforce
+
+  # Verify against every .crt and .pem file of a directory:
+  newsgo fetch --newsurl <url> --trustdir /etc/newsgo/trusted/
+
+  # Also mirror the router updates the feed advertises:
+  newsgo fetch --newsurl <url> --trustedcerts certs/ \
+    --follow-updates --update-certs router-certs/ --update-dir updates/
+
+  # Fetch clearnet backups through a local I2PTunnel HTTP outproxy client,
+  # never directly:
+  newsgo fetch --newsurl <url> --newsurls https://example.org/news.su3 \
+    --outproxy http://127.0.0.1:4444 --default-transport outproxy
+
+  # Bootstrap a data tree from the upstream entries:
+  newsgo fetch --newsurl <url> --trustedcerts certs/ --export-entries data/imported/`,
 	Run: func(cmd *cobra.Command, args []string) {
 		viper.Unmarshal(c)
 
@@ -47,32 +73,100 @@ Examples:
 			c.SamAddr = sa
 		}
 
-		urls := collectURLs(c.NewsURL, c.NewsURLs)
-		if len(urls) == 0 {
-			log.Fatal("fetch: no URL supplied; use --newsurl or --newsurls")
+		var targets []fetchTarget
+		if c.URLTemplate != "" {
+			expanded, err := expandURLTemplate(c.URLTemplate, c.Platforms, c.Statuses, c.Langs)
+			if err != nil {
+				log.Fatalf("fetch: %v", err)
+			}
+			targets = expanded
+		}
+		urls, err := withLangParam(collectURLs(c.NewsURL, c.NewsURLs), c.LangParam)
+		if err != nil {
+			log.Fatalf("fetch: --lang-param: %v", err)
+		}
+		if len(urls) == 0 && len(targets) == 0 {
+			log.Fatal("fetch: no URL supplied; use --newsurl, --newsurls, or --template")
+		}
+		all := append([]string(nil), urls...)
+		for _, t := range targets {
+			all = append(all, t.URL)
+		}
+		policy, err := transportPolicy(c.TransportRules, c.DefaultTransport)
+		if err != nil {
+			log.Fatalf("fetch: %v", err)
+		}
+		if err := validateURLs(all, policy); err != nil {
+			log.Fatalf("fetch: %v", err)
 		}
 
-		var certs []*x509.Certificate
-		if !c.SkipVerify && len(c.TrustedCerts) > 0 {
-			loaded, err := newsfetch.LoadCertificates(c.TrustedCerts)
+		trusted, err := loadTrustedCerts(c.TrustedCerts, c.TrustDir, c.SkipVerify)
+		if err != nil {
+			log.Fatalf("fetch: load certificates: %v", err)
+		}
+		certs := trusted.all()
+
+		var updateCerts []*x509.Certificate
+		if c.FollowUpdates && !c.SkipVerify {
+			if len(c.UpdateCerts) == 0 {
+				log.Fatal("fetch: --follow-updates needs --update-certs to verify router updates")
+			}
+			loaded, err := newsfetch.LoadCertificates(c.UpdateCerts)
 			if err != nil {
-				log.Fatalf("fetch: load certificates: %v", err)
+				log.Fatalf("fetch: load update certificates: %v", err)
 			}
-			certs = loaded
+			updateCerts = loaded
 		}
 
-		fetcher, err := newsfetch.NewFetcher(c.SamAddr)
+		var fetcher *newsfetch.Fetcher
+		if c.Outproxy == "" && len(policy.Rules) == 0 && policy.Default == "" {
+			fetcher, err = newsfetch.NewFetcher(c.SamAddr)
+		} else {
+			fetcher, err = newsfetch.NewPolicyFetcher(c.SamAddr, c.Outproxy, policy)
+		}
 		if err != nil {
 			log.Fatalf("fetch: create fetcher: %v", err)
 		}
 		defer newsfetch.CloseSharedGarlic()
+		fetcher.WriteMeta = c.WriteMeta
+		fetcher.MaxSize = c.MaxSize
+		fetcher.CacheBust = c.CacheBust
+		if c.History < 0 {
+			log.Fatalf("fetch: --history must not be negative, got %d", c.History)
+		}
+		fetcher.History = c.History
+		if err := configurePins(fetcher, c.TOFU, c.PinFile, certs); err != nil {
+			log.Fatalf("fetch: %v", err)
+		}
 
 		if err := os.MkdirAll(c.OutDir, 0o755); err != nil {
 			log.Fatalf("fetch: create outdir %s: %v", c.OutDir, err)
 		}
 
-		if err := fetchURLs(fetcher, urls, certs, c.OutDir); err != nil {
-			log.Fatalf("fetch: %v", err)
+		var fetched []string
+		if len(urls) > 0 {
+			outPath, err := fetchURLs(fetcher, urls, certs, c.OutDir)
+			if err != nil {
+				log.Fatalf("fetch: %v", err)
+			}
+			fetched = append(fetched, outPath)
+		}
+		if len(targets) > 0 {
+			outPaths, err := fetchTargets(fetcher, targets, certs, c.OutDir)
+			if err != nil {
+				log.Fatalf("fetch: %v", err)
+			}
+			fetched = append(fetched, outPaths...)
+		}
+		if c.FollowUpdates {
+			if err := followUpdates(fetcher, fetched, updateCerts, c.UpdateDir); err != nil {
+				log.Fatalf("fetch: --follow-updates: %v", err)
+			}
+		}
+		if c.ExportEntries != "" {
+			if err := exportEntries(fetched, c.OutDir, c.ExportEntries); err != nil {
+				log.Fatalf("fetch: --export-entries: %v", err)
+			}
 		}
 	},
 }
@@ -84,15 +178,152 @@ func init() {
 	fetchCmd.Flags().StringSlice("newsurls", nil, "additional/backup news feed URLs (tried in order after --newsurl)")
 	fetchCmd.Flags().String("outdir", "build", "directory to write unpacked Atom XML files to")
 	fetchCmd.Flags().StringSlice("trustedcerts", nil, "PEM certificate files whose public keys are trusted to verify su3 signatures")
+	fetchCmd.Flags().String("trustdir", "", "directory every *.crt and *.pem file of which is trusted to verify su3 signatures, like I2P's certificates/news directory")
 	fetchCmd.Flags().Bool("skipverify", false, "skip su3 signature verification (not recommended for production)")
 	// --samaddr is also registered here (not only on serveCmd) because the
 	// README documents it as a fetch option.  Using the same default as
 	// serve.go (onramp.SAM_ADDR) so both commands behave consistently.
 	fetchCmd.Flags().String("samaddr", onramp.SAM_ADDR, "advanced: SAMv3 gateway address for I2P fetches")
 
+	fetchCmd.Flags().String("template", "", "su3 URL template with {platform}, {status}, and {lang} placeholders, expanded over --platforms, --statuses, and --langs")
+	fetchCmd.Flags().StringSlice("platforms", nil, "values for {platform} in --template")
+	fetchCmd.Flags().StringSlice("statuses", nil, "values for {status} in --template")
+	fetchCmd.Flags().StringSlice("langs", nil, "values for {lang} in --template; \"en\" selects the canonical news.su3")
+	fetchCmd.Flags().String("tofu", tofuOff, "pin the signer of each URL on first use: off, enforce (refuse a changed signer), or warn (log it)")
+	fetchCmd.Flags().String("pinfile", "", "file the --tofu signer pins are kept in (default $HOME/.newsgo-pins.json)")
+	fetchCmd.Flags().Bool("write-meta", true, "write a .meta.json next to each fetched feed with its URL, fetch time, signer, certificate fingerprint, su3 version and digests")
+	fetchCmd.Flags().Bool("follow-updates", false, "also fetch the router update su3s advertised by the <i2p:update> elements of each fetched feed into --update-dir")
+	fetchCmd.Flags().String("update-dir", "updates", "directory --follow-updates stores router updates in, as {version}/{name}.su3")
+	fetchCmd.Flags().StringSlice("update-certs", nil, "PEM certificate files trusted to verify router update su3s; required by --follow-updates unless --skipverify")
+	fetchCmd.Flags().Int64("max-size", newsfetch.DefaultMaxSize, "refuse any response body larger than this many bytes, so a broken or malicious upstream cannot exhaust memory or disk; 0 disables the limit")
+	fetchCmd.Flags().String("outproxy", "", "HTTP proxy URL the outproxy transport fetches clearnet hosts through, e.g. a local I2PTunnel HTTP client (http://127.0.0.1:4444) or an outproxy destination (http://exit.example.i2p)")
+	fetchCmd.Flags().StringSlice("transport-rule", nil, "pattern=transport pairs choosing the transport (i2p, outproxy, or clearnet) of matching hosts, e.g. *.example.org=outproxy; the first match wins")
+	fetchCmd.Flags().String("default-transport", "", "transport of clearnet hosts no --transport-rule matches: outproxy or clearnet; empty refuses them")
+	fetchCmd.Flags().String("lang-param", "", "set the lang query parameter of --newsurl and --newsurls to this locale, as routers do, so the upstream counts the fetch under it; --template URLs can carry ?lang={lang} instead")
+	fetchCmd.Flags().Bool("cache-bust", false, "ask HTTP caches between newsgo and the news server, such as an outproxy's, not to answer from a stored copy, with no-cache headers and a changing "+newsfetch.CacheBustParam+" query parameter")
+	fetchCmd.Flags().Int("history", 0, "keep this many previous versions of each fetched feed in .history/ next to it, for newsgo history restore; 0 keeps none")
+	fetchCmd.Flags().String("export-entries", "", "also convert each fetched feed into an entries.html data tree under this directory")
+
 	viper.BindPFlags(fetchCmd.Flags())
 }
 
+// Modes of --tofu.
+const (
+	tofuOff     = "off"
+	tofuEnforce = "enforce"
+	tofuWarn    = "warn"
+)
+
+// configurePins enables trust-on-first-use signer pinning on f for the --tofu
+// mode, keeping the pins in pinFile ($HOME/.newsgo-pins.json when empty).
+// Pinning needs a verified signer, so it requires certs.
+func configurePins(f *newsfetch.Fetcher, mode, pinFile string, certs []*x509.Certificate) error {
+	switch mode {
+	case "", tofuOff:
+		return nil
+	case tofuEnforce, tofuWarn:
+	default:
+		return fmt.Errorf("--tofu must be %s, %s, or %s, got %q", tofuOff, tofuEnforce, tofuWarn, mode)
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("--tofu %s needs --trustedcerts or --trustdir (and no --skipverify) to know the signer", mode)
+	}
+	if pinFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("--pinfile not set and no home directory: %w", err)
+		}
+		pinFile = filepath.Join(home, ".newsgo-pins.json")
+	}
+	pins, err := newsfetch.LoadPinStore(pinFile)
+	if err != nil {
+		return err
+	}
+	f.Pins = pins
+	f.PinWarnOnly = mode == tofuWarn
+	return nil
+}
+
+// trustedCerts are the certificates su3 signatures are verified against: those
+// of the --trustedcerts files and, with --trustdir, those of the trust
+// directory as last loaded.  Its methods work on a nil trustedCerts, which
+// trusts nobody.
+type trustedCerts struct {
+	files []*x509.Certificate
+	dir   *newsfetch.TrustDir
+}
+
+// loadTrustedCerts loads the certificates of paths and of the trust directory
+// dir, either of which may be empty.  It returns nil when skipVerify is set or
+// neither is given.
+func loadTrustedCerts(paths []string, dir string, skipVerify bool) (*trustedCerts, error) {
+	if skipVerify || (len(paths) == 0 && dir == "") {
+		return nil, nil
+	}
+	t := &trustedCerts{}
+	if len(paths) > 0 {
+		loaded, err := newsfetch.LoadCertificates(paths)
+		if err != nil {
+			return nil, err
+		}
+		t.files = loaded
+	}
+	if dir != "" {
+		td, err := newsfetch.OpenTrustDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		t.dir = td
+	}
+	return t, nil
+}
+
+// all returns every trusted certificate.
+func (t *trustedCerts) all() []*x509.Certificate {
+	if t == nil {
+		return nil
+	}
+	certs := append([]*x509.Certificate(nil), t.files...)
+	if t.dir != nil {
+		certs = append(certs, t.dir.Certificates()...)
+	}
+	return certs
+}
+
+// reload reloads the trust directory if it changed since it was last loaded,
+// logging the reload or why it failed; the certificates loaded before stay
+// trusted when it fails.
+func (t *trustedCerts) reload(verb string) {
+	if t == nil || t.dir == nil {
+		return
+	}
+	changed, err := t.dir.Reload()
+	switch {
+	case err != nil:
+		log.Printf("%s: reload --trustdir %s: %v; keeping the certificates loaded before", verb, t.dir.Dir, err)
+	case changed:
+		newslogger.Printf("%s: reloaded %d certificate(s) from --trustdir %s", verb, len(t.dir.Certificates()), t.dir.Dir)
+	}
+}
+
+// withLangParam returns urls with their lang query parameter set to lang, or
+// urls unchanged when lang is empty.  A lang already in a URL is kept unless
+// lang replaces it.
+func withLangParam(urls []string, lang string) ([]string, error) {
+	if lang == "" {
+		return urls, nil
+	}
+	out := make([]string, len(urls))
+	for i, u := range urls {
+		withLang, err := newsfetch.SetLang(u, lang)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = withLang
+	}
+	return out, nil
+}
+
 // collectURLs merges the single primary URL with the slice of backup URLs,
 // deduplicating while preserving order.
 func collectURLs(primary string, backups []string) []string {
@@ -116,9 +347,43 @@ func collectURLs(primary string, backups []string) []string {
 	return result
 }
 
-// outFilename derives the output filename for a fetched su3 URL.
-// "news.su3" → "news.atom.xml"; other names → "fetched.atom.xml".
+// transportPolicy builds the newsfetch.TransportPolicy of --transport-rule
+// and --default-transport.
+func transportPolicy(rules []string, def string) (newsfetch.TransportPolicy, error) {
+	parsed, err := newsfetch.ParseTransportRules(rules)
+	if err != nil {
+		return newsfetch.TransportPolicy{}, err
+	}
+	switch def {
+	case "", newsfetch.TransportOutproxy, newsfetch.TransportClearnet:
+	default:
+		return newsfetch.TransportPolicy{}, fmt.Errorf("--default-transport must be %s or %s, got %q", newsfetch.TransportOutproxy, newsfetch.TransportClearnet, def)
+	}
+	return newsfetch.TransportPolicy{Rules: parsed, Default: def}, nil
+}
+
+// validateURLs checks every URL fetch or mirror would fetch against the
+// transport policy assigns to its host before any SAM session is opened; see
+// newsfetch.ValidateURL.  The zero policy fetches everything over I2P.  It
+// reports all invalid URLs at once.
+func validateURLs(urls []string, policy newsfetch.TransportPolicy) error {
+	var errs []error
+	for _, u := range urls {
+		if err := policy.ValidateURL(u); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// outFilename derives the output filename for a fetched su3 URL, ignoring
+// its query: "news.su3?lang=de" → "news.atom.xml"; other names →
+// "fetched.atom.xml".
 func outFilename(url string) string {
+	// The query, e.g. ?lang=de, names no file.
+	if i := strings.IndexAny(url, "?#"); i >= 0 {
+		url = url[:i]
+	}
 	base := filepath.Base(url)
 	if strings.HasSuffix(base, ".su3") {
 		base = strings.TrimSuffix(base, ".su3") + ".atom.xml"
@@ -129,23 +394,193 @@ func outFilename(url string) string {
 }
 
 // fetchURLs attempts to fetch each URL in order.  On the first successful
-// fetch-verify-unpack it writes the output and returns nil.  If all URLs fail,
-// all errors are aggregated and returned.
-func fetchURLs(f *newsfetch.Fetcher, urls []string, certs []*x509.Certificate, outDir string) error {
+// fetch-verify-unpack it writes the output and returns its path.  If all URLs
+// fail, all errors are aggregated and returned.
+//
+// Downloads are streamed to disk and verified from the file, so memory use
+// stays bounded even for multi-megabyte router update su3 files.
+func fetchURLs(f *newsfetch.Fetcher, urls []string, certs []*x509.Certificate, outDir string) (string, error) {
 	var errs []string
 	for _, url := range urls {
-		content, err := f.FetchAndParse(url, certs)
+		outPath := filepath.Join(outDir, outFilename(url))
+		n, err := f.FetchAndUnpackFile(url, outPath, certs)
 		if err != nil {
-			log.Printf("fetch: %s: %v (trying next URL)", url, err)
+			newslogger.Printf("fetch: %s: %v (trying next URL)", url, err)
 			errs = append(errs, fmt.Sprintf("%s: %v", url, err))
 			continue
 		}
-		outPath := filepath.Join(outDir, outFilename(url))
-		if err := os.WriteFile(outPath, content, 0o644); err != nil {
-			return fmt.Errorf("write %s: %w", outPath, err)
+		newslogger.Printf("fetch: saved %d bytes to %s", n, outPath)
+		return outPath, nil
+	}
+	return "", fmt.Errorf("all URLs failed: %s", strings.Join(errs, "; "))
+}
+
+// fetchTarget is one su3 URL expanded from --template and the path, relative
+// to the output directory, its unpacked feed is written to.
+type fetchTarget struct {
+	URL string
+	Out string
+}
+
+// expandURLTemplate expands tmpl over every combination of platforms,
+// statuses, and langs.  Only the placeholders present in tmpl are expanded,
+// and each one present needs at least one value.  The canonical English feed
+// is news.su3 rather than news_en.su3, so for lang "en" a "_{lang}" suffix is
+// dropped.  Output paths mirror the URL path from the first segment holding a
+// placeholder, so ".../news/{platform}/{status}/news_{lang}.su3" unpacks to
+// "{platform}/{status}/news_{lang}.atom.xml", the layout written by build.
+func expandURLTemplate(tmpl string, platforms, statuses, langs []string) ([]fetchTarget, error) {
+	axes := []struct {
+		placeholder string
+		values      []string
+		flag        string
+	}{
+		{"{platform}", platforms, "--platforms"},
+		{"{status}", statuses, "--statuses"},
+		{"{lang}", langs, "--langs"},
+	}
+	urls := []string{tmpl}
+	for _, axis := range axes {
+		if !strings.Contains(tmpl, axis.placeholder) {
+			continue
 		}
-		log.Printf("fetch: saved %d bytes to %s", len(content), outPath)
-		return nil
+		if len(axis.values) == 0 {
+			return nil, fmt.Errorf("--template uses %s but %s is empty", axis.placeholder, axis.flag)
+		}
+		var next []string
+		for _, u := range urls {
+			for _, v := range axis.values {
+				v = strings.TrimSpace(v)
+				expanded := u
+				if axis.placeholder == "{lang}" {
+					if v == "en" {
+						expanded = strings.Replace(expanded, "_{lang}", "", -1)
+					}
+					v = builder.LocaleFileName(v)
+				}
+				next = append(next, strings.Replace(expanded, axis.placeholder, v, -1))
+			}
+		}
+		urls = next
+	}
+
+	// The output path starts at the first template path segment holding a
+	// placeholder; a template without placeholders maps to its base name.
+	segments := strings.Split(tmpl, "/")
+	first := len(segments) - 1
+	for i, seg := range segments {
+		if strings.Contains(seg, "{") {
+			first = i
+			break
+		}
+	}
+	keep := len(segments) - first
+	var targets []fetchTarget
+	for _, u := range urls {
+		parts := strings.Split(u, "/")
+		rel := path.Join(path.Dir(path.Join(parts[len(parts)-keep:]...)), outFilename(u))
+		targets = append(targets, fetchTarget{URL: u, Out: filepath.FromSlash(rel)})
+	}
+	return targets, nil
+}
+
+// fetchTargets fetches every target with f, writing each unpacked feed under
+// outDir, and returns the paths written.  A failed target is logged and
+// skipped, since not every platform/status/language combination is
+// published; an error is returned only when no target succeeded.
+func fetchTargets(f *newsfetch.Fetcher, targets []fetchTarget, certs []*x509.Certificate, outDir string) ([]string, error) {
+	var written []string
+	for _, t := range targets {
+		outPath := filepath.Join(outDir, t.Out)
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return written, fmt.Errorf("create %s: %w", filepath.Dir(outPath), err)
+		}
+		n, err := f.FetchAndUnpackFile(t.URL, outPath, certs)
+		if err != nil {
+			newslogger.Printf("fetch: %s: %v", t.URL, err)
+			continue
+		}
+		newslogger.Verbosef("fetch: saved %d bytes to %s", n, outPath)
+		written = append(written, outPath)
+	}
+	newslogger.Printf("fetch: %d of %d template URLs fetched", len(written), len(targets))
+	if len(written) == 0 {
+		return nil, fmt.Errorf("none of the %d template URLs could be fetched", len(targets))
+	}
+	return written, nil
+}
+
+// entriesFilename returns the entries file that corresponds to the feed file
+// name: "news.atom.xml" is "entries.html" and "news_de.atom.xml" is
+// "entries.de.html", the inverse of the names build writes.  Any other
+// ".atom.xml" name keeps its stem, e.g. "fetched.html".
+func entriesFilename(feedName string) string {
+	stem := strings.TrimSuffix(feedName, ".atom.xml")
+	switch {
+	case stem == "news":
+		return "entries.html"
+	case strings.HasPrefix(stem, "news_"):
+		return "entries." + strings.TrimPrefix(stem, "news_") + ".html"
+	default:
+		return stem + ".html"
+	}
+}
+
+// exportEntries converts each fetched feed, a path under outDir, into an
+// entries file at the same relative directory under exportDir, so that a
+// template fetch of win/beta/news_de.atom.xml yields win/beta/entries.de.html.
+func exportEntries(feeds []string, outDir, exportDir string) error {
+	for _, feed := range feeds {
+		rel, err := filepath.Rel(outDir, feed)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(exportDir, filepath.Dir(rel), entriesFilename(filepath.Base(rel)))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return err
+		}
+		n, err := builder.ExportEntries(feed, dst)
+		if err != nil {
+			return err
+		}
+		newslogger.Printf("fetch: exported %d article(s) to %s", n, dst)
+	}
+	return nil
+}
+
+// followUpdates stores the router updates advertised by the fetched feeds in
+// dir, verified against certs, fetching each advertised update once.  An
+// update that cannot be fetched from any of its URLs is an error after the
+// remaining updates were tried.
+func followUpdates(f *newsfetch.Fetcher, feeds []string, certs []*x509.Certificate, dir string) error {
+	seen := make(map[string]bool)
+	failed := 0
+	for _, feed := range feeds {
+		data, err := os.ReadFile(feed)
+		if err != nil {
+			return err
+		}
+		updates, err := newsfetch.ParseUpdates(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", feed, err)
+		}
+		for _, u := range updates {
+			key := u.Version + "\x00" + strings.Join(u.URLs, "\x00")
+			if u.Type != "su3" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			path, err := f.FetchUpdate(u, dir, certs)
+			if err != nil {
+				log.Printf("fetch: router update %s: %v", u.Version, err)
+				failed++
+				continue
+			}
+			newslogger.Printf("fetch: router update %s stored in %s", u.Version, path)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d router update(s) could not be fetched", failed)
 	}
-	return fmt.Errorf("all URLs failed: %s", strings.Join(errs, "; "))
+	return nil
 }
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitSource describes the git commit a build reads its data tree from
+// (--git-ref); the zero value means the working tree.
+type gitSource struct {
+	Ref    string // the ref as given, e.g. "v2.7.0"
+	Commit string // the full hash Ref resolved to
+}
+
+// buildSource is the gitSource of the current build command.
+var buildSource gitSource
+
+// runGit runs git with args in the repository repo and returns its standard
+// output.  A failure carries git's standard error.
+func runGit(repo string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", repo}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// resolveGitRef returns the full hash of the commit ref names in repo.
+func resolveGitRef(repo, ref string) (string, error) {
+	out, err := runGit(repo, "rev-parse", "--verify", "--end-of-options", ref+"^{commit}")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// checkoutGitCommit writes the tree of commit in repo into dir, which must
+// exist.  It uses git archive rather than a worktree so that the repository
+// is left without any trace of the build.
+func checkoutGitCommit(repo, commit, dir string) error {
+	out, err := runGit(repo, "archive", "--format=tar", commit)
+	if err != nil {
+		return err
+	}
+	tr := tar.NewReader(bytes.NewReader(out))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("git archive: %w", err)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(hdr.Name))
+		if !containsDir(dir, target) {
+			return fmt.Errorf("git archive: %q escapes the checkout", hdr.Name)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			f, err := os.Create(target)
+			if err != nil {
+				return err
+			}
+			_, err = io.Copy(f, tr)
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			// The data tree is read as plain files; a link could point
+			// anywhere on the build host, so it is not recreated.
+		}
+	}
+}
+
+// containsDir reports whether path is dir or lies below it.
+func containsDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// rebasePath maps path, a path into the working tree of repo, to the same
+// path in the checkout at dir.  A relative path is taken relative to the
+// current directory, like every other path flag.  An empty path is returned
+// unchanged; ok is false for a path outside repo, which has no counterpart in
+// the checkout.
+func rebasePath(path, repo, dir string) (rebased string, ok bool) {
+	if path == "" {
+		return "", true
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path, false
+	}
+	absRepo, err := filepath.Abs(repo)
+	if err != nil || !containsDir(absRepo, abs) {
+		return path, false
+	}
+	rel, _ := filepath.Rel(absRepo, abs)
+	return filepath.Join(dir, rel), true
+}
+
+// checkoutGitRef checks out --git-ref of --repo into a temporary directory
+// and points the data paths of c at it.  It returns the gitSource of the
+// build and a function that removes the checkout.
+func checkoutGitRef(ref, repo string) (gitSource, func(), error) {
+	commit, err := resolveGitRef(repo, ref)
+	if err != nil {
+		return gitSource{}, nil, err
+	}
+	dir, err := os.MkdirTemp("", "newsgo-build-")
+	if err != nil {
+		return gitSource{}, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	if err := checkoutGitCommit(repo, commit, dir); err != nil {
+		cleanup()
+		return gitSource{}, nil, err
+	}
+	for _, p := range []*string{&c.NewsFile, &c.BlockList, &c.ReleaseJsonFile, &c.TranslationsDir} {
+		rebased, ok := rebasePath(*p, repo, dir)
+		if !ok {
+			cleanup()
+			return gitSource{}, nil, fmt.Errorf("%s is outside --repo %s", *p, repo)
+		}
+		*p = rebased
+	}
+	return gitSource{Ref: ref, Commit: commit}, cleanup, nil
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	newslogger "github.com/go-i2p/newsgo/logger"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd groups the fetch history subcommands.
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List and restore previous versions of fetched feeds",
+	Long: `history works on the previous versions fetch and mirror keep of every feed
+they unpack with --history N, in .history/<name>/ next to the feed.  A
+version is recorded whenever the feed changes, the oldest pruned beyond N.
+
+  list     prints the versions of a feed, newest first
+  restore  puts a version back in place of the feed
+
+Restoring also records the digest of the feed it replaces, and fetch and
+mirror with --history refuse that content until the upstream publishes
+something else, so a bad upstream publish can be rolled back locally while
+the upstream fixes it.  Restoring the newest version undoes a rollback.
+
+Example:
+  newsgo history list build/news.atom.xml
+  newsgo history restore build/news.atom.xml 20250601T000000.000Z-0123456789abcdef.atom.xml`,
+}
+
+var historyListCmd = &cobra.Command{
+	Use:   "list <feed>",
+	Short: "Print the kept versions of a fetched feed, newest first",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		versions, err := newsfetch.ListHistory(args[0])
+		if err != nil {
+			log.Fatalf("history: %v", err)
+		}
+		if len(versions) == 0 {
+			log.Fatalf("history: no versions of %s are kept; fetch it with --history", args[0])
+		}
+		printHistory(os.Stdout, versions)
+	},
+}
+
+var historyRestoreCmd = &cobra.Command{
+	Use:   "restore <feed> <version>",
+	Short: "Replace a fetched feed with one of its kept versions",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		v, err := newsfetch.RestoreHistory(args[0], args[1])
+		if err != nil {
+			log.Fatalf("history: %v", err)
+		}
+		newslogger.Printf("history: restored %s from the version fetched %s", args[0], v.Fetched.Format(time.RFC3339))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyListCmd, historyRestoreCmd)
+}
+
+// printHistory writes versions as a table, marking the one the feed holds,
+// with the signer of each version whose FetchMeta sidecar was kept.
+func printHistory(w io.Writer, versions []newsfetch.HistoryVersion) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tFETCHED\tSIGNER\t")
+	for _, v := range versions {
+		signer := "-"
+		if m, err := newsfetch.ReadFetchMeta(v.Path); err == nil && m.SignerID != "" {
+			signer = m.SignerID
+		}
+		mark := ""
+		if v.Current {
+			mark = "current"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", v.Name, v.Fetched.Format(time.RFC3339), signer, mark)
+	}
+	tw.Flush()
+}
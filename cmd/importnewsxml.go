@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	newsfeed "github.com/go-i2p/newsgo/builder/feed"
+	newslogger "github.com/go-i2p/newsgo/logger"
+	"github.com/spf13/cobra"
+)
+
+// importNewsxmlCmd represents the import-newsxml command
+var importNewsxmlCmd = &cobra.Command{
+	Use:   "import-newsxml <repository>",
+	Short: "Convert an i2p.newsxml repository into a newsgo data directory",
+	Long: `import-newsxml copies the news sources of an i2p.newsxml checkout into a
+newsgo data directory, so an existing operator can switch without
+restructuring anything by hand.  The argument is the repository root or its
+data/ directory.  These files are imported, for the default tree and for
+every <platform>/<status>/ sub-tree:
+
+  entries.html                      the English entries
+  translations/entries.{lang}.html  the translated entries
+  releases.json                     the release metadata
+  blocklist.xml                     the blocklist
+
+Files are copied byte for byte, so article ids and dates are kept as they
+are.  Translation file names are normalised to the underscore form newsgo
+writes (entries.pt-BR.html becomes entries.pt_BR.html).  Every imported
+entries file is then checked the way build checks it, and articles build
+would reject are reported.  Existing files in --into are left alone unless
+--force is given; anything else in the repository is skipped.
+
+Example:
+  newsgo import-newsxml ../i2p.newsxml --into data
+  newsgo build --newsfile data`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		into, _ := cmd.Flags().GetString("into")
+		force, _ := cmd.Flags().GetBool("force")
+		src := newsxmlDataDir(args[0])
+		plan, skipped, err := newsxmlImportPlan(src)
+		if err != nil {
+			log.Fatalf("import-newsxml: %v", err)
+		}
+		for _, rel := range skipped {
+			newslogger.Verbosef("import-newsxml: skipping %s", filepath.Join(src, rel))
+		}
+		if len(plan) == 0 {
+			log.Fatalf("import-newsxml: no i2p.newsxml sources found in %s", src)
+		}
+		copied, err := importNewsxml(os.Stdout, src, into, plan, force)
+		if err != nil {
+			log.Fatalf("import-newsxml: %v", err)
+		}
+		newslogger.Printf("import-newsxml: imported %d of %d file(s) into %s", copied, len(plan), into)
+		if problems := checkImportedEntries(os.Stdout, into, plan); problems > 0 {
+			log.Fatalf("import-newsxml: %d article(s) must be fixed before newsgo build accepts them", problems)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importNewsxmlCmd)
+
+	importNewsxmlCmd.Flags().String("into", "data", "newsgo data directory to write the imported sources to")
+	importNewsxmlCmd.Flags().Bool("force", false, "replace files that already exist in --into")
+}
+
+// newsxmlDataDir returns the data directory of the i2p.newsxml checkout at
+// repo: its data/ sub-directory, or repo itself when it has none (the data
+// directory was passed directly).
+func newsxmlDataDir(repo string) string {
+	data := filepath.Join(repo, "data")
+	if fi, err := os.Stat(data); err == nil && fi.IsDir() {
+		return data
+	}
+	return repo
+}
+
+// newsxmlImport maps one source file of an i2p.newsxml data directory to its
+// place in the newsgo data directory; both paths are relative.
+type newsxmlImport struct {
+	Src, Dst string
+}
+
+// newsxmlImportPlan walks the i2p.newsxml data directory src and returns the
+// files to import, in path order, and the relative paths of the files it does
+// not recognise.
+func newsxmlImportPlan(src string) (plan []newsxmlImport, skipped []string, err error) {
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != src && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if dst, ok := newsxmlDest(rel); ok {
+			plan = append(plan, newsxmlImport{Src: rel, Dst: dst})
+		} else {
+			skipped = append(skipped, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("newsxmlImportPlan: %w", err)
+	}
+	return plan, skipped, nil
+}
+
+// newsxmlDest returns where the i2p.newsxml source file at rel, relative to
+// its data directory, goes in the newsgo data directory.  ok is false for a
+// file newsgo does not read.
+func newsxmlDest(rel string) (dst string, ok bool) {
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	var prefix []string
+	if len(parts) > 2 && contains(builder.KnownPlatforms(), parts[0]) && contains(builder.KnownStatuses(), parts[1]) {
+		prefix, parts = parts[:2], parts[2:]
+	}
+	switch {
+	case len(parts) == 1 && contains([]string{"entries.html", "releases.json", "blocklist.xml"}, parts[0]):
+	case len(parts) == 2 && parts[0] == "translations":
+		name, ok := newsxmlTranslationName(parts[1])
+		if !ok {
+			return "", false
+		}
+		parts = []string{"translations", name}
+	default:
+		return "", false
+	}
+	return filepath.Join(append(prefix, parts...)...), true
+}
+
+// newsxmlTranslationName returns the newsgo name of the translation file
+// name: "entries.{lang}.html" with the locale written with underscores, the
+// form translations import writes.
+func newsxmlTranslationName(name string) (string, bool) {
+	parts := strings.SplitN(name, ".", 3)
+	if len(parts) != 3 || parts[0] != "entries" || parts[2] != "html" || parts[1] == "" {
+		return "", false
+	}
+	return "entries." + strings.ReplaceAll(parts[1], "-", "_") + ".html", true
+}
+
+// importNewsxml copies the files of plan from src to dst and returns how many
+// it wrote.  Files that already exist in dst are kept unless force is set.
+func importNewsxml(w io.Writer, src, dst string, plan []newsxmlImport, force bool) (int, error) {
+	copied := 0
+	for _, f := range plan {
+		to := filepath.Join(dst, f.Dst)
+		if _, err := os.Stat(to); err == nil && !force {
+			fmt.Fprintf(w, "Keeping existing %s.\n", to)
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, f.Src))
+		if err != nil {
+			return copied, fmt.Errorf("importNewsxml: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+			return copied, fmt.Errorf("importNewsxml: %w", err)
+		}
+		if err := os.WriteFile(to, data, 0o644); err != nil {
+			return copied, fmt.Errorf("importNewsxml: %w", err)
+		}
+		newslogger.Verbosef("import-newsxml: %s -> %s", filepath.Join(src, f.Src), to)
+		copied++
+	}
+	return copied, nil
+}
+
+// checkImportedEntries loads every entries file of plan from the newsgo data
+// directory dir and writes one line to w for each article build would
+// reject.  Translated articles whose id is not among the English entries of
+// their tree, which build would publish as separate entries, are logged as
+// warnings.  It returns the number of rejected articles.
+func checkImportedEntries(w io.Writer, dir string, plan []newsxmlImport) int {
+	// English ids per tree, keyed by the tree's directory ("." for the
+	// default tree), and the translated ids per translation file.
+	english := make(map[string]map[string]bool)
+	translated := make(map[string][]string)
+	problems := 0
+	for _, f := range plan {
+		if !strings.HasSuffix(f.Dst, ".html") {
+			continue
+		}
+		feed := &newsfeed.Feed{EntriesHTMLPath: filepath.Join(dir, f.Dst)}
+		if err := feed.LoadHTML(); err != nil {
+			fmt.Fprintf(w, "%s: %v\n", f.Dst, err)
+			problems++
+			continue
+		}
+		tree := filepath.Dir(f.Dst)
+		isTranslation := filepath.Base(tree) == "translations"
+		if !isTranslation {
+			english[tree] = make(map[string]bool)
+		}
+		for art := range feed.Articles() {
+			if err := art.Validate(); err != nil {
+				fmt.Fprintf(w, "%s: %v\n", f.Dst, err)
+				problems++
+				continue
+			}
+			if isTranslation {
+				translated[f.Dst] = append(translated[f.Dst], art.UID)
+			} else {
+				english[tree][art.UID] = true
+			}
+		}
+	}
+	files := make([]string, 0, len(translated))
+	for rel := range translated {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	for _, rel := range files {
+		known, ok := english[filepath.Dir(filepath.Dir(rel))]
+		if !ok {
+			// Translations without English entries beside them translate
+			// the default tree, as in build.
+			known, ok = english["."]
+		}
+		if !ok {
+			continue
+		}
+		for _, id := range translated[rel] {
+			if !known[id] {
+				newslogger.Printf("import-newsxml: %s: article %q is not in the English entries", rel, id)
+			}
+		}
+	}
+	return problems
+}
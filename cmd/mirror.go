@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	newslogger "github.com/go-i2p/newsgo/logger"
+	server "github.com/go-i2p/newsgo/server"
+	"github.com/go-i2p/onramp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// mirrorStatusPath is the URL path of the JSON upstream-freshness report
+// served alongside the mirrored files when --serve is set.
+const mirrorStatusPath = "/mirror-status.json"
+
+// mirrorCmd represents the mirror command
+var mirrorCmd = &cobra.Command{
+	Use:   "mirror",
+	Short: "Periodically fetch an upstream news feed and optionally serve it",
+	Long: `mirror runs the fetch command on a schedule and, with --serve, the news
+server in the same process.  Both share --outdir and a single SAMv3 session.
+
+With --serve, ` + mirrorStatusPath + ` reports the upstream URLs, the time of
+the last attempt, last success and next refresh, and the last error.
+
+Examples:
+  # Refresh every 6 hours and serve the result on 127.0.0.1:9696 and I2P:
+  newsgo mirror --upstream http://tc73n4kivdroccekirco7rhgxdg5f3cjvbaapabupeyzrqwv5guq.b32.i2p/news/news.su3 --every 6h --serve --i2p
+
+  # Fetch only, verifying against a trusted certificate:
+  newsgo mirror --upstream <url> --trustedcerts /path/to/news.crt
+
+  # Verify against every certificate of a directory, picking up added and
+  # removed certificates at the next refresh without a restart:
+  newsgo mirror --upstream <url> --trustdir /etc/newsgo/trusted/
+
+  # Keep the last 10 versions of the feed, to roll back a bad publish:
+  newsgo mirror --upstream <url> --trustedcerts news.crt --history 10
+  newsgo history list build/news.atom.xml`,
+	Run: func(cmd *cobra.Command, args []string) {
+		viper.Unmarshal(c)
+
+		// Every flag below except --upstream/--every shares its name with a
+		// flag on fetch or serve.  They are deliberately not bound to viper
+		// (see init) and are read from this command's own flag set instead.
+		flags := cmd.Flags()
+		c.OutDir, _ = flags.GetString("outdir")
+		c.TrustedCerts, _ = flags.GetStringSlice("trustedcerts")
+		c.TrustDir, _ = flags.GetString("trustdir")
+		c.SkipVerify, _ = flags.GetBool("skipverify")
+		c.SamAddr, _ = flags.GetString("samaddr")
+		c.Host, _ = flags.GetStringSlice("host")
+		c.Port, _ = flags.GetString("port")
+		c.I2P, _ = flags.GetBool("i2p")
+		c.StatsFile, _ = flags.GetString("statsfile")
+		c.ContentValidators, _ = flags.GetBool("content-validators")
+		c.TOFU, _ = flags.GetString("tofu")
+		c.PinFile, _ = flags.GetString("pinfile")
+		c.PProf, _ = flags.GetString("pprof")
+		c.MaxSize, _ = flags.GetInt64("max-size")
+		c.LangParam, _ = flags.GetString("lang-param")
+		c.CacheBust, _ = flags.GetBool("cache-bust")
+		c.History, _ = flags.GetInt("history")
+		serve, _ := flags.GetBool("serve")
+
+		urls, err := withLangParam(collectURLs("", c.Upstream), c.LangParam)
+		if err != nil {
+			log.Fatalf("mirror: --lang-param: %v", err)
+		}
+		if len(urls) == 0 {
+			log.Fatal("mirror: no upstream supplied; use --upstream")
+		}
+		if err := validateURLs(urls, newsfetch.TransportPolicy{}); err != nil {
+			log.Fatalf("mirror: %v", err)
+		}
+		if c.Every <= 0 {
+			log.Fatalf("mirror: --every must be positive, got %s", c.Every)
+		}
+		if c.History < 0 {
+			log.Fatalf("mirror: --history must not be negative, got %d", c.History)
+		}
+		if c.Host, err = clearnetHosts(c.Host); err != nil {
+			log.Fatalf("mirror: --host: %v", err)
+		}
+		if serve && noListenerConfigured(c.Host, c.I2P) {
+			log.Fatalf("mirror: no listener configured: --host is empty and --i2p is false; at least one must be enabled")
+		}
+
+		trusted, err := loadTrustedCerts(c.TrustedCerts, c.TrustDir, c.SkipVerify)
+		if err != nil {
+			log.Fatalf("mirror: load certificates: %v", err)
+		}
+		if err := os.MkdirAll(c.OutDir, 0o755); err != nil {
+			log.Fatalf("mirror: create outdir %s: %v", c.OutDir, err)
+		}
+
+		startDebugListener("mirror", c.PProf)
+
+		garlic, err := newsfetch.SharedGarlic(c.SamAddr)
+		if err != nil {
+			log.Fatalf("mirror: %v", err)
+		}
+		defer newsfetch.CloseSharedGarlic()
+		fetcher := newsfetch.NewFetcherFromGarlic(garlic)
+		fetcher.MaxSize = c.MaxSize
+		fetcher.CacheBust = c.CacheBust
+		fetcher.History = c.History
+		if err := configurePins(fetcher, c.TOFU, c.PinFile, trusted.all()); err != nil {
+			log.Fatalf("mirror: %v", err)
+		}
+		status := &mirrorStatus{Upstream: urls, Every: c.Every.String()}
+
+		var s *server.NewsServer
+		if serve {
+			s = server.Serve(c.OutDir, c.StatsFile)
+			s.ContentValidators = c.ContentValidators
+			s.Hidden = server.DefaultHidden
+			startMirrorListeners(mirrorHandler(s, status), s.Protocols.ConnState, garlic)
+		}
+
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+		stop := make(chan struct{})
+		go func() {
+			sig := <-sigCh
+			newslogger.Printf("captured: %v", sig)
+			close(stop)
+		}()
+
+		runMirrorLoop(fetcher, urls, trusted, c.OutDir, c.Every, status, stop)
+		if s != nil {
+			if err := s.Stats.Save(); err != nil {
+				log.Printf("Stats.Save: %v", err)
+			}
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+
+	mirrorCmd.Flags().StringSlice("upstream", nil, "upstream .su3 news feed URLs, tried in order on every refresh")
+	mirrorCmd.Flags().Duration("every", 6*time.Hour, "interval between upstream refreshes")
+	mirrorCmd.Flags().Bool("serve", false, "also serve --outdir over HTTP (and I2P with --i2p) with a "+mirrorStatusPath+" report")
+	mirrorCmd.Flags().String("outdir", "build", "directory to write fetched Atom XML files to and serve from")
+	mirrorCmd.Flags().StringSlice("trustedcerts", nil, "PEM certificate files whose public keys are trusted to verify su3 signatures")
+	mirrorCmd.Flags().String("trustdir", "", "directory every *.crt and *.pem file of which is trusted to verify su3 signatures; reloaded before a refresh whenever it changes")
+	mirrorCmd.Flags().Bool("skipverify", false, "skip su3 signature verification (not recommended for production)")
+	mirrorCmd.Flags().String("samaddr", onramp.SAM_ADDR, "advanced: SAMv3 gateway address shared by fetching and the I2P listener")
+	mirrorCmd.Flags().StringSlice("host", []string{"127.0.0.1"}, "hosts to serve news files on when --serve is set, comma-separated or repeated; one listener per host")
+	mirrorCmd.Flags().String("port", "9696", "port to serve news files on when --serve is set")
+	mirrorCmd.Flags().Bool("i2p", false, "also serve news files to I2P on the shared SAM session when --serve is set")
+	mirrorCmd.Flags().String("statsfile", "build/stats.json", "file to store download stats in when --serve is set")
+	mirrorCmd.Flags().String("lang-param", "", "set the lang query parameter of every --upstream URL to this locale, so the upstream counts the mirror's fetches under it")
+	mirrorCmd.Flags().Bool("cache-bust", false, "ask HTTP caches between the mirror and the upstream not to answer from a stored copy; see fetch --cache-bust")
+	mirrorCmd.Flags().Int("history", 0, "keep this many previous versions of each mirrored feed in .history/ next to it, so a bad upstream publish can be rolled back with newsgo history restore; 0 keeps none")
+	mirrorCmd.Flags().Int64("max-size", newsfetch.DefaultMaxSize, "refuse any upstream response body larger than this many bytes; 0 disables the limit")
+	mirrorCmd.Flags().Bool("content-validators", false, "derive ETag and Last-Modified from file content so refreshes that fetch an unchanged feed keep returning 304")
+	mirrorCmd.Flags().String("tofu", tofuOff, "pin the upstream signer on first use: off, enforce (skip refreshes from a changed signer), or warn (log them)")
+	mirrorCmd.Flags().String("pprof", "", "loopback host:port, e.g. 127.0.0.1:6060, to serve net/http/pprof profiles and expvar variables on for debugging")
+	mirrorCmd.Flags().String("pinfile", "", "file the --tofu signer pins are kept in (default $HOME/.newsgo-pins.json)")
+
+	// Only the mirror-specific flags are bound.  Binding the whole flag set
+	// would repoint the shared viper keys (outdir, samaddr, host, ...) at
+	// this command's flags and break fetch and serve; see the BindPFlags
+	// collision notes in build.go and fetch.go.
+	viper.BindPFlag("upstream", mirrorCmd.Flags().Lookup("upstream"))
+	viper.BindPFlag("every", mirrorCmd.Flags().Lookup("every"))
+}
+
+// mirrorStatus records upstream freshness for the mirror status endpoint.
+// All methods are safe for concurrent use.
+type mirrorStatus struct {
+	mu          sync.RWMutex
+	Upstream    []string  `json:"upstream"`
+	Every       string    `json:"every"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success"`
+	LastError   string    `json:"last_error,omitempty"`
+	NextRun     time.Time `json:"next_run"`
+}
+
+// record stores the outcome of one refresh attempt made at t and the time of
+// the next scheduled attempt.
+func (m *mirrorStatus) record(t, next time.Time, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.LastAttempt = t
+	m.NextRun = next
+	if err != nil {
+		m.LastError = err.Error()
+		return
+	}
+	m.LastSuccess = t
+	m.LastError = ""
+}
+
+// ServeHTTP writes the current status as JSON.
+func (m *mirrorStatus) ServeHTTP(rw http.ResponseWriter, rq *http.Request) {
+	m.mu.RLock()
+	data, err := json.MarshalIndent(m, "", "  ")
+	m.mu.RUnlock()
+	if err != nil {
+		http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Write(data) //nolint:errcheck
+}
+
+// mirrorHandler routes mirrorStatusPath to status and every other request to
+// the news server.
+func mirrorHandler(s *server.NewsServer, status *mirrorStatus) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(mirrorStatusPath, status)
+	mux.Handle("/", s)
+	return mux
+}
+
+// startMirrorListeners starts a clearnet listener per --host and the I2P
+// listener on the shared garlic session (when --i2p is set).  connState is
+// passed to serveHTTP for the clearnet listeners.
+func startMirrorListeners(h http.Handler, connState func(net.Conn, http.ConnState), garlic *onramp.Garlic) {
+	startHTTPListeners(h, c.Host, c.Port, connState)
+	if c.I2P {
+		go func() {
+			if err := serveGarlic(h, garlic); err != nil {
+				log.Printf("serveI2P: %v (I2P listener disabled)", err)
+			}
+		}()
+	}
+}
+
+// runMirrorLoop refreshes the mirror immediately and then every interval
+// until stop is closed.  Each refresh reloads the trust directory of trusted
+// if it changed and is then a full fetchURLs pass over urls; failures are
+// recorded in status and logged but never end the loop.
+func runMirrorLoop(f *newsfetch.Fetcher, urls []string, trusted *trustedCerts, outDir string, every time.Duration, status *mirrorStatus, stop <-chan struct{}) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		trusted.reload("mirror")
+		_, err := fetchURLs(f, urls, trusted.all(), outDir)
+		if err != nil {
+			log.Printf("mirror: refresh failed: %v", err)
+		}
+		now := time.Now().UTC()
+		status.record(now, now.Add(every), err)
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
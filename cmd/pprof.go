@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"expvar"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// debugMux returns the handler of the --pprof listener: the net/http/pprof
+// profiles under /debug/pprof/ and the expvar variables, including memstats,
+// at /debug/vars.  It is a mux of its own so that the profiles are never
+// reachable through the news listeners.
+func debugMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
+
+// checkDebugAddr returns an error unless addr is host:port with a loopback
+// host.  Profiles expose memory contents and command lines, so the debug
+// listener must never be reachable from the network.
+func checkDebugAddr(addr string) error {
+	return checkLoopbackAddr("--pprof", addr)
+}
+
+// checkLoopbackAddr returns an error, naming flag, unless addr is host:port
+// with a loopback host.
+func checkLoopbackAddr(flag, addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("%s %q: %w", flag, addr, err)
+	}
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return fmt.Errorf("%s %q: host must be a loopback address such as 127.0.0.1", flag, addr)
+	}
+	return nil
+}
+
+// startDebugListener starts the --pprof listener of command cmdName in the
+// background when addr is set.  A non-loopback addr is fatal before any other
+// listener starts; a listener that fails later only disables debugging.
+func startDebugListener(cmdName, addr string) {
+	if addr == "" {
+		return
+	}
+	if err := checkDebugAddr(addr); err != nil {
+		log.Fatalf("%s: %v", cmdName, err)
+	}
+	go func() {
+		if err := serveDebug(addr); err != nil {
+			log.Printf("serveDebug: %v (debug listener disabled)", err)
+		}
+	}()
+}
+
+// serveDebug serves debugMux on the loopback address addr.
+func serveDebug(addr string) error {
+	if err := checkDebugAddr(addr); err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	newslogger.Printf("serveDebug: pprof and expvar on http://%s/debug/", ln.Addr())
+	srv := &http.Server{Handler: debugMux(), ReadHeaderTimeout: 30 * time.Second}
+	return srv.Serve(ln)
+}
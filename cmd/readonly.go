@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/go-i2p/newsgo/config"
+	server "github.com/go-i2p/newsgo/server"
+)
+
+// readOnlyConf applies --readonly to the serve settings in conf before the
+// server is built.  Stats are kept in memory only unless statsFileSet reports
+// that --statsfile was given, on the command line or in the config file, and
+// then it must lie outside --newsdir and the --route directories, e.g. on a
+// tmpfs, so nothing is ever written into a served tree.  --pprof is refused,
+// as it exposes the memory of the process, and so is --symlinks follow.
+func readOnlyConf(conf *config.Conf, statsFileSet bool) error {
+	if conf.PProf != "" {
+		return fmt.Errorf("--pprof is refused on a read-only mirror")
+	}
+	if conf.Symlinks == server.SymlinksFollow {
+		return fmt.Errorf("--symlinks %s is refused on a read-only mirror", server.SymlinksFollow)
+	}
+	if !statsFileSet {
+		conf.StatsFile = ""
+		return nil
+	}
+	dirs := []string{conf.NewsDir}
+	// A malformed --route is reported when the routes are parsed.
+	if routes, err := server.ParseRoutes(conf.Routes); err == nil {
+		for _, r := range routes {
+			dirs = append(dirs, r.Dir)
+		}
+	}
+	stats, err := filepath.Abs(conf.StatsFile)
+	if err != nil {
+		return fmt.Errorf("--statsfile: %w", err)
+	}
+	for _, dir := range dirs {
+		if abs, err := filepath.Abs(dir); err == nil && containsDir(abs, stats) {
+			return fmt.Errorf("--statsfile %s lies in the served tree %s; give a path outside it, e.g. on a tmpfs, or none to keep stats in memory", conf.StatsFile, dir)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// Results of a feed in a runReport.
+const (
+	resultBuilt   = "built"
+	resultSigned  = "signed"
+	resultSkipped = "skipped"
+	resultErrored = "errored"
+)
+
+// defaultChannel is the runReport row of the feeds of the default tree.
+const defaultChannel = "(default)"
+
+// runReport collects the result of every feed of a build or sign run for the
+// table printed when the run finishes.  Its methods do nothing on a nil
+// runReport, so helpers that record results also work outside a command run.
+type runReport struct {
+	// verb names the command in the final line, e.g. "build".
+	verb string
+	// results are the columns of the table, in order.
+	results []string
+	start   time.Time
+	// counts holds the number of feeds per channel and result.
+	counts map[string]map[string]int
+	// failures holds one "file: error" line per errored feed.
+	failures []string
+}
+
+// newRunReport returns an empty runReport for verb with the results columns,
+// timing the run from now.
+func newRunReport(verb string, results ...string) *runReport {
+	return &runReport{verb: verb, results: results, start: time.Now(), counts: make(map[string]map[string]int)}
+}
+
+// add records result for the feed file, relative to BuildDir; err is the
+// reason of a resultErrored.
+func (r *runReport) add(file, result string, err error) {
+	if r == nil {
+		return
+	}
+	channel := filepath.ToSlash(filepath.Dir(file))
+	if channel == "." {
+		channel = defaultChannel
+	}
+	if r.counts[channel] == nil {
+		r.counts[channel] = make(map[string]int)
+	}
+	r.counts[channel][result]++
+	if result == resultErrored && err != nil {
+		r.failures = append(r.failures, fmt.Sprintf("%s: %v", filepath.ToSlash(file), err))
+	}
+}
+
+// total returns the number of feeds recorded with result.
+func (r *runReport) total(result string) int {
+	n := 0
+	for _, counts := range r.counts {
+		n += counts[result]
+	}
+	return n
+}
+
+// print writes the report to w unless the log level is Quiet: a table of the
+// results per channel with a total row, the errored feeds, and a final line
+// with the totals, note when not empty, and the duration of the run.  With
+// color the counts are colored by result.
+func (r *runReport) print(w io.Writer, color bool, note string) {
+	if r == nil || newslogger.CurrentLevel() < newslogger.Normal {
+		return
+	}
+	channels := make([]string, 0, len(r.counts))
+	for channel := range r.counts {
+		if channel != defaultChannel {
+			channels = append(channels, channel)
+		}
+	}
+	sort.Strings(channels)
+	if r.counts[defaultChannel] != nil {
+		channels = append([]string{defaultChannel}, channels...)
+	}
+	rows := [][]string{append([]string{"CHANNEL"}, upper(r.results)...)}
+	for _, channel := range channels {
+		row := []string{channel}
+		for _, result := range r.results {
+			row = append(row, fmt.Sprint(r.counts[channel][result]))
+		}
+		rows = append(rows, row)
+	}
+	if len(channels) > 1 {
+		row := []string{"total"}
+		for _, result := range r.results {
+			row = append(row, fmt.Sprint(r.total(result)))
+		}
+		rows = append(rows, row)
+	}
+	// Cells are padded before they are colored, so that the escape codes do
+	// not upset the alignment.
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], len(cell))
+		}
+	}
+	for n, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = fmt.Sprintf("%-*s", widths[i], cell)
+			if n > 0 && i > 0 && cell != "0" {
+				cells[i] = paint(cells[i], r.results[i-1], color)
+			}
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+	}
+	for _, failure := range r.failures {
+		fmt.Fprintln(w, paint(resultErrored, resultErrored, color)+" "+failure)
+	}
+	totals := make([]string, len(r.results))
+	for i, result := range r.results {
+		totals[i] = fmt.Sprintf("%d %s", r.total(result), result)
+	}
+	line := fmt.Sprintf("%s: %s in %s", r.verb, strings.Join(totals, ", "), roundDuration(time.Since(r.start)))
+	if note != "" {
+		line += "; " + note
+	}
+	fmt.Fprintln(w, line)
+}
+
+// upper returns s with every element upper-cased.
+func upper(s []string) []string {
+	out := make([]string, len(s))
+	for i, v := range s {
+		out[i] = strings.ToUpper(v)
+	}
+	return out
+}
+
+// resultColors are the ANSI SGR codes of the results.
+var resultColors = map[string]string{
+	resultBuilt:   "32", // green
+	resultSigned:  "32",
+	resultSkipped: "33", // yellow
+	resultErrored: "31", // red
+}
+
+// paint returns s in the color of result when color is set.
+func paint(s, result string, color bool) string {
+	code, ok := resultColors[result]
+	if !color || !ok {
+		return s
+	}
+	return "\x1b[" + code + "m" + s + "\x1b[0m"
+}
+
+// useColor reports whether output to f may be colored: f is a terminal, and
+// neither NO_COLOR (https://no-color.org) nor TERM=dumb asks for plain text.
+func useColor(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
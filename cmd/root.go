@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/go-i2p/newsgo/config"
+	newslogger "github.com/go-i2p/newsgo/logger"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
@@ -40,8 +41,9 @@ func ExecuteWithArgs(args []string) error {
 }
 
 // LookupFlag looks up a flag on the named sub-command.  commandName must be
-// one of "serve", "build", "sign", or "fetch"; use "" to look up a persistent
-// root flag.  Returns nil when the command or flag is not found.
+// one of "serve", "build", "sign", "fetch", "mirror", "verify-tree", "status",
+// or "clean"; use "" to look up a persistent root flag.  Returns nil when the
+// command or flag is not found.
 func LookupFlag(commandName, flagName string) *pflag.Flag {
 	if commandName == "" {
 		return rootCmd.PersistentFlags().Lookup(flagName)
@@ -60,6 +62,24 @@ func init() {
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.newsgo.yaml)")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "log errors only")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "log per-file detail in addition to summaries")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
+	viper.BindPFlag("quiet", rootCmd.PersistentFlags().Lookup("quiet"))
+	viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
+}
+
+// logLevel maps the quiet and verbose settings to a newslogger.Level.  Quiet
+// wins when a config file or the environment sets both.
+func logLevel(quiet, verbose bool) newslogger.Level {
+	switch {
+	case quiet:
+		return newslogger.Quiet
+	case verbose:
+		return newslogger.Verbose
+	default:
+		return newslogger.Normal
+	}
 }
 
 // initConfig reads in config file and ENV variables if set.
@@ -86,8 +106,11 @@ func initConfig() {
 	viper.SetEnvPrefix("newsgo")
 	viper.AutomaticEnv() // read in environment variables that match
 
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
+	// If a config file is found, read it in.  The level is set afterwards so
+	// that quiet and verbose may also come from the config file.
+	err := viper.ReadInConfig()
+	newslogger.SetLevel(logLevel(viper.GetBool("quiet"), viper.GetBool("verbose")))
+	if err == nil && newslogger.CurrentLevel() >= newslogger.Normal {
 		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	}
 }
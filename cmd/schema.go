@@ -0,0 +1,210 @@
+package cmd
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	"github.com/go-i2p/newsgo/config"
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema config|releases",
+	Short: "Print the JSON Schema of the config file or of releases.json",
+	Long: `schema prints a JSON Schema (draft 2020-12) document generated from the Go
+types newsgo reads the file into, so editors and CI can check the file before
+it reaches a build.
+
+  config    the config file (--config), in JSON or YAML; every key is the
+            name of the flag it sets, described by the flag's help
+  releases  the releases.json of a data directory
+
+Examples:
+  newsgo schema config > newsgo.schema.json
+  newsgo schema releases > releases.schema.json
+  check-jsonschema --schemafile releases.schema.json data/releases.json`,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"config", "releases"},
+	Run: func(cmd *cobra.Command, args []string) {
+		var s *jsonSchema
+		switch args[0] {
+		case "config":
+			s = configSchema()
+		case "releases":
+			s = releasesSchema()
+		}
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			log.Fatalf("schema: %v", err)
+		}
+		os.Stdout.Write(append(data, '\n'))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+// jsonSchema is a JSON Schema node with the keywords the generated schemas
+// use.
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	MinItems             int                    `json:"minItems,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+}
+
+// jsonSchemaDialect is the $schema of the generated documents.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the time.ParseDuration strings viper decodes
+// durations from, e.g. "6h" or "1h30m".
+const durationPattern = `^(0|([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$`
+
+// schemaField names a struct field in a schema: its key, whether it is
+// required, and false to leave the field out.
+type schemaField func(f reflect.StructField) (key string, required, ok bool)
+
+// schemaFor returns the schema of values of type t.  Struct fields are named
+// by field and may carry description and pattern tags; the pattern of a slice
+// field constrains its elements.
+func schemaFor(t reflect.Type, field schemaField) *jsonSchema {
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return &jsonSchema{Type: "string", Pattern: durationPattern}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaFor(t.Elem(), field)
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaFor(t.Elem(), field)}
+	case reflect.Struct:
+		s := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			key, required, ok := field(f)
+			if !ok {
+				continue
+			}
+			p := schemaFor(f.Type, field)
+			p.Description = f.Tag.Get("description")
+			if pattern := f.Tag.Get("pattern"); pattern != "" {
+				// The pattern of a list applies to its elements.
+				if p.Items != nil {
+					p.Items.Pattern = pattern
+				} else {
+					p.Pattern = pattern
+				}
+			}
+			s.Properties[key] = p
+			if required {
+				s.Required = append(s.Required, key)
+			}
+		}
+		return s
+	}
+	// Maps, interfaces and the like are not used by the described types.
+	return &jsonSchema{}
+}
+
+// configField names a config.Conf field by its mapstructure tag, or else its
+// lower-cased name as viper matches it.  No key is required.
+func configField(f reflect.StructField) (string, bool, bool) {
+	key, _, _ := strings.Cut(f.Tag.Get("mapstructure"), ",")
+	if key == "-" {
+		return "", false, false
+	}
+	if key == "" {
+		key = strings.ToLower(f.Name)
+	}
+	return key, false, true
+}
+
+// jsonField names a field by its json tag; fields without omitempty are
+// required.
+func jsonField(f reflect.StructField) (string, bool, bool) {
+	name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, !strings.Contains(","+opts+",", ",omitempty,"), true
+}
+
+// configSchema returns the schema of the config file: the keys of
+// config.Conf and the persistent --quiet and --verbose, each described by the
+// help of the flag it sets.  Unknown keys are refused, as they are most likely
+// misspelt.
+func configSchema() *jsonSchema {
+	s := schemaFor(reflect.TypeOf(config.Conf{}), configField)
+	s.Schema = jsonSchemaDialect
+	s.Title = "newsgo config file"
+	s.Description = "Settings read from --config. Each key sets the flag of the same name; flags given on the command line win."
+	for _, name := range []string{"quiet", "verbose"} {
+		s.Properties[name] = &jsonSchema{Type: "boolean"}
+	}
+	for key, p := range s.Properties {
+		if usage := flagUsage(rootCmd, key); usage != "" {
+			p.Description = usage
+		}
+	}
+	no := false
+	s.AdditionalProperties = &no
+	return s
+}
+
+// flagUsage returns the help of the first flag named name on cmd or any of
+// its subcommands, or "" when there is none.
+func flagUsage(cmd *cobra.Command, name string) string {
+	if f := cmd.Flags().Lookup(name); f != nil {
+		return f.Usage
+	}
+	if f := cmd.PersistentFlags().Lookup(name); f != nil {
+		return f.Usage
+	}
+	for _, sub := range cmd.Commands() {
+		if usage := flagUsage(sub, name); usage != "" {
+			return usage
+		}
+	}
+	return ""
+}
+
+// releasesSchema returns the schema of releases.json, from builder.Releases.
+// The hash types are closed, since the build refuses unknown ones.
+func releasesSchema() *jsonSchema {
+	s := schemaFor(reflect.TypeOf(builder.Releases{}), jsonField)
+	s.Schema = jsonSchemaDialect
+	s.Title = "newsgo releases.json"
+	s.Description = "Router releases of a data directory; the first is advertised in the feeds as <i2p:release>."
+	s.MinItems = 1
+	hashes := s.Items.Properties["updates"].Properties["su3"].Properties["hashes"]
+	no := false
+	hashes.AdditionalProperties = &no
+	return s
+}
@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	builder "github.com/go-i2p/newsgo/builder"
+	"github.com/spf13/cobra"
+)
+
+// selectionFlags reads --platform, --status, and --lang into c from the
+// flag set of cmd when they were given there.  build, sign, and serve all
+// register these flags, and viper binds each name to whichever command
+// registered it last, so the value of the running command must be read from
+// its own flag set; values from the config file are kept otherwise.
+func selectionFlags(cmd *cobra.Command) {
+	flags := cmd.Flags()
+	if flags.Changed("platform") {
+		c.Platform, _ = flags.GetString("platform")
+	}
+	if flags.Changed("status") {
+		c.Status, _ = flags.GetString("status")
+	}
+	if flags.Changed("lang") {
+		c.Lang, _ = flags.GetStringSlice("lang")
+	}
+}
+
+// feedSelector returns the selection of --platform, --status, and --lang.
+func feedSelector() builder.FeedSelector {
+	return builder.FeedSelector{Platform: c.Platform, Status: c.Status, Langs: c.Lang}
+}
@@ -1,15 +1,23 @@
 package cmd
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"html/template"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	newslogger "github.com/go-i2p/newsgo/logger"
 	server "github.com/go-i2p/newsgo/server"
+	stats "github.com/go-i2p/newsgo/server/stats"
 	"github.com/go-i2p/onramp"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -21,7 +29,99 @@ var serveCmd = &cobra.Command{
 	Short: "Serve newsfeeds from a directory",
 	Run: func(cmd *cobra.Command, args []string) {
 		viper.Unmarshal(c)
+		selectionFlags(cmd)
+		if c.ReadOnly {
+			if err := readOnlyConf(c, viper.IsSet("statsfile")); err != nil {
+				log.Fatalf("serve: --readonly: %v", err)
+			}
+		}
 		s := server.Serve(c.NewsDir, c.StatsFile)
+		s.Select = feedSelector()
+		s.SiteURL = c.SiteURL
+		s.ContentValidators = c.ContentValidators
+		s.ListingETags = c.ListingETags
+		s.HotlinkProtection = c.HotlinkProtection
+		s.AllowedReferers = c.AllowedReferers
+		aliases, err := stats.ParseLangAliases(c.LangAliases)
+		if err != nil {
+			log.Fatalf("serve: --lang-alias: %v", err)
+		}
+		s.Stats.SetLangAliases(aliases)
+		s.Stats.DownloadWindow = c.DownloadWindow
+		routes, err := server.ParseRoutes(c.Routes)
+		if err != nil {
+			log.Fatalf("serve: --route: %v", err)
+		}
+		s.Routes = routes
+		pathAliases, err := server.ParseAliases(c.Aliases)
+		if err != nil {
+			log.Fatalf("serve: --alias: %v", err)
+		}
+		s.Aliases = pathAliases
+		hidden, err := server.ParseHidden(c.Hide)
+		if err != nil {
+			log.Fatalf("serve: --hide: %v", err)
+		}
+		s.Hidden = hidden
+		s.MaxListingDepth = c.MaxListingDepth
+		s.ResolveCurrent = c.NewsDirCurrent
+		s.NoListings = c.ReadOnly
+		symlinks, err := server.ParseSymlinkPolicy(c.Symlinks, c.SymlinkAllow)
+		if err != nil {
+			log.Fatalf("serve: --symlinks: %v", err)
+		}
+		s.Symlinks = symlinks
+		if c.AccessLog {
+			sample, err := server.ParseLogSample(c.LogSample)
+			if err != nil {
+				log.Fatalf("serve: --log-sample: %v", err)
+			}
+			if c.LogFlush <= 0 {
+				log.Fatalf("serve: --log-flush must be positive")
+			}
+			s.RequestLog = &server.RequestLog{Sample: sample}
+			go s.RequestLog.Run(c.LogFlush, nil)
+		}
+		switch {
+		case c.LandingTemplate != "":
+			tmpl, err := template.ParseFiles(c.LandingTemplate)
+			if err != nil {
+				log.Fatalf("serve: --landing-template: %v", err)
+			}
+			s.LandingPage = tmpl
+		case c.Landing:
+			s.LandingPage = server.DefaultLandingPage
+		}
+		if c.SecurityHeaders || len(c.SecurityHeader) > 0 {
+			base := http.Header(nil)
+			if c.SecurityHeaders {
+				base = server.DefaultSecurityHeaders
+			}
+			headers, err := server.ParseSecurityHeaders(base, c.SecurityHeader)
+			if err != nil {
+				log.Fatalf("serve: --security-header: %v", err)
+			}
+			s.SecurityHeaders = headers
+		}
+		if len(c.Allow) > 0 {
+			allow, err := server.ParseAllowlist(c.Allow)
+			if err != nil {
+				log.Fatalf("serve: --allow: %v", err)
+			}
+			s.Allowlist = allow
+		}
+		if c.WebSub {
+			if c.SiteURL == "" {
+				log.Fatalf("serve: --websub needs --siteurl, the base of the hub and topic URLs")
+			}
+			s.Hub = server.NewHub(s)
+			go func() {
+				if err := s.Hub.Watch(context.Background()); err != nil {
+					log.Printf("serve: websub: %v (hub will not see feed changes)", err)
+				}
+			}()
+			newslogger.Printf("serve: WebSub hub at %s", s.Hub.URL())
+		}
 
 		// Probe for a SAM gateway lazily — only when actually serving and
 		// only when the user has not already passed --i2p=true.  Probing at
@@ -31,6 +131,12 @@ var serveCmd = &cobra.Command{
 			c.I2P = isSamAround()
 		}
 
+		hosts, err := clearnetHosts(c.Host)
+		if err != nil {
+			log.Fatalf("serve: --host: %v", err)
+		}
+		c.Host = hosts
+
 		// Fail fast rather than spinning forever with no listeners.
 		// The default for --host is "127.0.0.1" (never empty), so this
 		// condition only fires on deliberate misconfiguration.
@@ -38,17 +144,9 @@ var serveCmd = &cobra.Command{
 			log.Fatalf("serve: no listener configured: --host is empty and --i2p is false; at least one must be enabled")
 		}
 
-		if c.Host != "" {
-			go func() {
-				// log.Fatalf produces a human-readable message and exits
-				// cleanly (exit code 1) instead of printing a raw panic
-				// traceback.  The most common cause is the TCP port already
-				// being bound, which is a routine operational error.
-				if err := serveHTTP(s, c.Host, c.Port); err != nil {
-					log.Fatalf("serveHTTP: %v", err)
-				}
-			}()
-		}
+		startDebugListener("serve", c.PProf)
+		startAdminListener(s, c.Admin, c.AdminTokenFile)
+		startHTTPListeners(s, c.Host, c.Port, s.Protocols.ConnState)
 		if c.I2P {
 			go func() {
 				// Use Printf (not Fatalf): I2P auto-detection is best-effort.
@@ -66,20 +164,15 @@ var serveCmd = &cobra.Command{
 		// Kubernetes pod termination) so stats are persisted on any graceful stop.
 		signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 		go func() {
-			for sig := range sigCh {
-				log.Println("captured:", sig)
-				// Log any stats persistence failure so operators know the
-				// download counters were lost (e.g. read-only stats file).
-				if err := s.Stats.Save(); err != nil {
-					log.Printf("Stats.Save: %v", err)
-				}
-				os.Exit(0)
-			}
+			sig := <-sigCh
+			newslogger.Printf("captured: %v", sig)
+			stopServer(s)
 		}()
+		handleStatsSignals(s)
 		i := 0
 		for {
 			time.Sleep(time.Minute)
-			log.Printf("Running for %d minutes.", i)
+			newslogger.Verbosef("Running for %d minutes.", i)
 			i++
 		}
 	},
@@ -89,16 +182,58 @@ func init() {
 	rootCmd.AddCommand(serveCmd)
 
 	serveCmd.Flags().String("newsdir", "build", "directory to serve news from")
+	serveCmd.Flags().Bool("newsdir-current", false, "treat --newsdir and the --route directories as symlinks that are repointed atomically to publish a new tree; each request resolves them once")
 	serveCmd.Flags().String("statsfile", "build/stats.json", "file to store stats in")
 	// --host and --port match the README and main.go flag names.
 	// The previous --http flag (combined host:port string) is removed.
-	serveCmd.Flags().String("host", "127.0.0.1", "host to serve news files on")
+	serveCmd.Flags().StringSlice("host", []string{"127.0.0.1"}, "hosts to serve news files on, comma-separated or repeated, e.g. 127.0.0.1,::1; one listener is started per host. Empty disables the clearnet listeners")
 	serveCmd.Flags().String("port", "9696", "port to serve news files on")
 	// --i2p matches the README boolean flag name.
 	// --samaddr is an advanced override for the SAM gateway address; it does
 	// not replace --i2p as the primary I2P toggle.
 	serveCmd.Flags().Bool("i2p", false, "serve news files directly to I2P using SAMv3")
 	serveCmd.Flags().String("samaddr", onramp.SAM_ADDR, "advanced: SAMv3 gateway address when --i2p is enabled")
+	// Clearnet listener tuning.  HTTP/2 needs TLS; without --tlscert and
+	// --tlskey the listener serves plain HTTP/1.1.
+	serveCmd.Flags().String("tlscert", "", "PEM certificate for serving HTTPS (and HTTP/2) on the clearnet listener; requires --tlskey")
+	serveCmd.Flags().String("tlskey", "", "PEM private key for --tlscert")
+	serveCmd.Flags().Duration("idletimeout", defaultIdleTimeout, "how long to keep an idle keep-alive connection open on the clearnet listener")
+	serveCmd.Flags().Bool("disable-keepalive", false, "close every clearnet connection after one request")
+	serveCmd.Flags().Bool("disable-http2", false, "serve only HTTP/1.1 even over TLS")
+	// --siteurl is only meaningful for clearnet-exposed mirrors; I2P routers
+	// never consult robots.txt or sitemaps.
+	serveCmd.Flags().String("siteurl", "", "public base URL of a clearnet mirror; enables generated /sitemap.xml and /robots.txt")
+	serveCmd.Flags().Bool("content-validators", false, "derive ETag and Last-Modified from file content instead of mtime, so rebuilt but unchanged feeds keep returning 304")
+	serveCmd.Flags().Bool("listing-etags", false, "give directory listings a weak ETag from their entries' names and mtimes and answer matching If-None-Match requests with 304")
+
+	serveCmd.Flags().Bool("hotlink-protection", false, "answer 403 to requests referred by other sites for anything but su3 files and Atom feeds")
+	serveCmd.Flags().StringSlice("allowed-referers", nil, "further referring hosts allowed with --hotlink-protection, e.g. geti2p.net,*.i2p.net")
+
+	serveCmd.Flags().StringSlice("lang-alias", nil, "from=to pairs merging language stats buckets, e.g. de_DE=de,pt=pt_BR")
+	serveCmd.Flags().Duration("download-window", stats.DefaultDownloadWindow, "count a su3 download only once per client and file within this window; 0 counts every request")
+	serveCmd.Flags().StringSlice("route", nil, "prefix=dir pairs serving further build trees under URL prefixes, e.g. /beta=build-beta; --newsdir serves everything else")
+	serveCmd.Flags().StringSlice("alias", nil, "from=to URL path pairs answering legacy paths with files of the current layout, e.g. /news/news.su3=/news.su3; a from ending in / rewrites every path below it")
+	serveCmd.Flags().String("admin", "", "loopback host:port, e.g. 127.0.0.1:6061, to answer POST /admin/shutdown and /admin/reload on for orchestrators and service wrappers; requires --admin-token-file")
+	serveCmd.Flags().String("admin-token-file", "", "file holding the bearer token that --admin requests must present")
+	serveCmd.Flags().String("pprof", "", "loopback host:port, e.g. 127.0.0.1:6060, to serve net/http/pprof profiles and expvar variables on for debugging")
+	serveCmd.Flags().Bool("websub", false, "run a minimal WebSub hub at /websub so clearnet subscribers get Atom feeds pushed when they change; requires --siteurl")
+	serveCmd.Flags().StringSlice("hide", server.DefaultHidden, "name patterns of files and directories to leave out of listings and answer 404 for, e.g. '.*,*.tmp,*.bak'; the stats files are always hidden")
+	serveCmd.Flags().Int("max-listing-depth", 0, "deepest directory level to render listings for, e.g. 1 lists mac/ but not mac/stable/; files are still served. 0 lists every level")
+	serveCmd.Flags().Bool("landing", false, "serve a landing page listing the feeds by channel and language, with the download chart, in place of the root directory listing")
+	serveCmd.Flags().String("landing-template", "", "html/template file to render the landing page from instead of the built-in one; implies --landing")
+	serveCmd.Flags().String("platform", "", "serve only this platform's feeds (linux|mac|mac-arm64|win|android|ios); other platforms and the default tree's feeds answer 404")
+	serveCmd.Flags().String("status", "", "serve only this release channel (stable|beta|rc|alpha) of each platform, with the default tree")
+	serveCmd.Flags().StringSlice("lang", nil, "serve only the feeds of these locales, e.g. en,de,pt_BR; en is the canonical news feed")
+	serveCmd.Flags().Bool("access-log", false, "log every request with its status; runs of identical requests are sampled with --log-sample")
+	serveCmd.Flags().StringSlice("log-sample", []string{"2xx=100", "3xx=100", "4xx=100", "5xx=10"}, "class=N pairs logging only 1 in N identical requests of a status class between flushes, e.g. 4xx=1000; 1 logs every request")
+	serveCmd.Flags().Duration("log-flush", server.DefaultLogFlush, "how often to log a summary count of the requests --log-sample left out")
+	serveCmd.Flags().Bool("security-headers", false, "add Content-Security-Policy, X-Content-Type-Options, Referrer-Policy, and X-Frame-Options headers to listings, the landing page, and .html files; feeds and su3 files are left alone")
+	// A StringArray, not a StringSlice: header values may contain commas.
+	serveCmd.Flags().StringArray("security-header", nil, "Name=Value pair adding or replacing an HTML response header, e.g. \"Content-Security-Policy=default-src 'self'\"; repeat for several headers. An empty value removes a --security-headers default")
+	serveCmd.Flags().Bool("readonly", false, "harden an exposed public mirror: keep stats in memory unless --statsfile is given outside the served trees, answer 404 for directory listings, and refuse --pprof and --symlinks follow")
+	serveCmd.Flags().String("symlinks", server.SymlinksInside, "symlinks below the served trees to follow: inside (only those resolving inside the tree or a --symlink-allow directory), deny (none), or follow (all); refused ones answer 404")
+	serveCmd.Flags().StringSlice("symlink-allow", nil, "further directories symlinks may lead into with --symlinks inside, e.g. a shared su3 store")
+	serveCmd.Flags().StringSlice("allow", nil, "serve only clients matching these IP addresses, CIDR blocks, .b32.i2p addresses, or destinations and answer 403 to everyone else")
 
 	viper.BindPFlags(serveCmd.Flags())
 }
@@ -135,20 +270,104 @@ func checkPortListening(addr string) bool {
 
 // noListenerConfigured reports whether the serve command would start with zero
 // active listeners. It is extracted as a named function so the condition can
-// be unit-tested without invoking log.Fatalf. Returns true only when host is
-// the empty string (--host "") AND i2p is false — both clearnet and I2P
-// listeners are disabled simultaneously.
-func noListenerConfigured(host string, i2p bool) bool {
-	return host == "" && !i2p
+// be unit-tested without invoking log.Fatalf. Returns true only when hosts is
+// empty (--host "") AND i2p is false — both clearnet and I2P listeners are
+// disabled simultaneously.
+func noListenerConfigured(hosts []string, i2p bool) bool {
+	return len(hosts) == 0 && !i2p
+}
+
+// clearnetHosts returns the hosts of --host to listen on.  Each entry may
+// itself be a comma-separated list, as the config file and environment give
+// it; blank entries are dropped and IPv6 brackets removed.  A host given
+// twice is refused, as its second listener could never bind.
+func clearnetHosts(entries []string) ([]string, error) {
+	var hosts []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		for _, host := range strings.Split(entry, ",") {
+			host = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(host), "["), "]")
+			if host == "" {
+				continue
+			}
+			if seen[host] {
+				return nil, fmt.Errorf("clearnetHosts: %s given twice", host)
+			}
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// startHTTPListeners starts one clearnet listener per host on port, all
+// serving h.  Each listener runs on its own and reports its own error, naming
+// its address.  log.Fatalf produces a human-readable message and exits
+// cleanly (exit code 1) instead of printing a raw panic traceback.  The most
+// common cause is the TCP port already being bound on one of the hosts,
+// which is a routine operational error.
+func startHTTPListeners(h http.Handler, hosts []string, port string, connState func(net.Conn, http.ConnState)) {
+	for _, host := range hosts {
+		go func() {
+			if err := serveHTTP(h, host, port, connState); err != nil {
+				log.Fatalf("serveHTTP %s: %v", net.JoinHostPort(host, port), err)
+			}
+		}()
+	}
+}
+
+// defaultIdleTimeout is how long an idle keep-alive connection is held open
+// when --idletimeout is not set.
+const defaultIdleTimeout = 120 * time.Second
+
+// newHTTPServer returns the clearnet http.Server for h, tuned from the global
+// config.  Keep-alives are on and idle connections are held for --idletimeout
+// so routers polling a busy mirror reuse connections instead of paying a TCP
+// (and TLS) handshake per request.  HTTP/2 is negotiated automatically over
+// TLS unless --disable-http2 is set.  connState, when non-nil, is installed as
+// the server's ConnState hook.
+func newHTTPServer(h http.Handler, connState func(net.Conn, http.ConnState)) *http.Server {
+	idle := c.IdleTimeout
+	if idle <= 0 {
+		idle = defaultIdleTimeout
+	}
+	srv := &http.Server{
+		Handler:           h,
+		ReadHeaderTimeout: 30 * time.Second,
+		IdleTimeout:       idle,
+		ConnState:         connState,
+	}
+	srv.SetKeepAlivesEnabled(!c.DisableKeepAlive)
+	if c.DisableHTTP2 {
+		// A non-nil, empty TLSNextProto is net/http's documented switch for
+		// turning off its built-in HTTP/2 support.
+		srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+	}
+	return srv
 }
 
-// serveHTTP starts an HTTP listener on host:port and serves s.
-func serveHTTP(s *server.NewsServer, host, port string) error {
+// serveHTTP starts an HTTP listener on host:port and serves s.  When both
+// --tlscert and --tlskey are set the listener speaks HTTPS (and HTTP/2).
+func serveHTTP(s http.Handler, host, port string, connState func(net.Conn, http.ConnState)) error {
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		return fmt.Errorf("serveHTTP: --tlscert and --tlskey must be set together")
+	}
 	ln, err := net.Listen("tcp", net.JoinHostPort(host, port))
 	if err != nil {
 		return err
 	}
-	return http.Serve(ln, s)
+	newslogger.Printf("serveHTTP: listening on %s", ln.Addr())
+	srv := newHTTPServer(s, connState)
+	trackServer(srv)
+	if c.TLSCert != "" {
+		err = srv.ServeTLS(ln, c.TLSCert, c.TLSKey)
+	} else {
+		err = srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
 // serveI2P starts a SAMv3 garlic listener and serves s over I2P.
@@ -168,10 +387,21 @@ func serveI2P(s *server.NewsServer, samAddr string) error {
 		garlic = &onramp.Garlic{}
 	}
 	defer garlic.Close()
+	return serveGarlic(s, garlic)
+}
+
+// serveGarlic listens on an existing garlic session and serves h over I2P.
+// The caller keeps ownership of garlic; only the listener is closed here.
+func serveGarlic(h http.Handler, garlic *onramp.Garlic) error {
 	ln, err := garlic.Listen()
 	if err != nil {
 		return err
 	}
 	defer ln.Close()
-	return http.Serve(ln, s)
+	srv := &http.Server{Handler: h}
+	trackServer(srv)
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
 }
@@ -0,0 +1,343 @@
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// setupCmd represents the setup command
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Interactively create a config file, signing key, and data tree",
+	Long: `setup asks for the settings a new news operator needs and writes them to
+the config file (--config, default $HOME/.newsgo.yaml):
+
+  signer ID     the ID su3 files are signed with, e.g. you@mail.i2p
+  signing key   the private key to sign with; generated when it does not exist
+  feed URLs     the URLs routers fetch the feed from, and the news site
+  data dir      the directory build reads entries.html and releases.json from
+  build dir     the directory build writes feeds to and serve publishes
+
+Press Enter to accept the default shown in brackets.  A missing signing key
+is generated as a 4096-bit RSA key with a self-signed certificate, the
+pair routers need to verify the feed.  The data directory is scaffolded with
+a sample entries.html, releases.json, and blocklist.xml; existing files are
+left alone.  An existing config file is only replaced with --force.
+
+Example:
+  newsgo setup
+  newsgo doctor`,
+	Run: func(cmd *cobra.Command, args []string) {
+		path := cfgFile
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				log.Fatalf("setup: %v", err)
+			}
+			path = filepath.Join(home, ".newsgo.yaml")
+		}
+		force, _ := cmd.Flags().GetBool("force")
+		if _, err := os.Stat(path); err == nil && !force {
+			log.Fatalf("setup: %s already exists; pass --force to replace it", path)
+		}
+		in := bufio.NewReader(os.Stdin)
+		a, err := askSetup(in, os.Stdout)
+		if err != nil {
+			log.Fatalf("setup: %v", err)
+		}
+		if err := applySetup(a); err != nil {
+			log.Fatalf("setup: %v", err)
+		}
+		if err := writeSetupConfig(path, a); err != nil {
+			log.Fatalf("setup: %v", err)
+		}
+		fmt.Printf("Wrote %s.  Edit %s, then run newsgo doctor and newsgo build.\n",
+			path, filepath.Join(a.DataDir, "releases.json"))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(setupCmd)
+
+	setupCmd.Flags().Bool("force", false, "replace an existing config file")
+
+	// --force is not bound to viper: sign binds a "force" key of its own,
+	// and the answers are written to the config file rather than read from
+	// it (see the BindPFlags collision notes in build.go).
+}
+
+// setupAnswers holds the settings setup asks for.
+type setupAnswers struct {
+	SignerID   string
+	SigningKey string
+	SignerCert string
+	// GenerateKey is set when SigningKey does not exist yet and the operator
+	// agreed to have it generated, together with SignerCert.
+	GenerateKey bool
+	FeedMain    string
+	FeedBackup  string
+	FeedSite    string
+	DataDir     string
+	BuildDir    string
+}
+
+// setupKeyBits is the size of the RSA key setup generates: RSA-4096 with
+// SHA-512 is the su3 signature type routers expect for news feeds.
+var setupKeyBits = 4096
+
+// ask prints question with its default to out and returns the line read
+// from in, or def when the line is empty.  An input that ends without a
+// final newline is accepted; an input that has ended entirely is an error,
+// so that setup does not silently write a config of defaults.
+func ask(in *bufio.Reader, out io.Writer, question, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(out, "%s [%s]: ", question, def)
+	} else {
+		fmt.Fprintf(out, "%s: ", question)
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && !(errors.Is(err, io.EOF) && line != "") {
+		return "", fmt.Errorf("reading answer to %q: %w", question, err)
+	}
+	if line = strings.TrimSpace(line); line != "" {
+		return line, nil
+	}
+	return def, nil
+}
+
+// askYesNo is ask for a yes/no question.  An empty answer is def; anything
+// but y, yes, n, or no asks again.
+func askYesNo(in *bufio.Reader, out io.Writer, question string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	answer, err := ask(in, out, question+" ("+hint+")", "")
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return def, nil
+	case "y", "yes":
+		return true, nil
+	case "n", "no":
+		return false, nil
+	}
+	return askYesNo(in, out, question, def)
+}
+
+// signerCertName returns the certificate file name I2P uses for signerID,
+// e.g. "you_at_mail.i2p.crt" for "you@mail.i2p".
+func signerCertName(signerID string) string {
+	return strings.ReplaceAll(signerID, "@", "_at_") + ".crt"
+}
+
+// askSetup asks the setup questions on out and reads the answers from in.
+func askSetup(in *bufio.Reader, out io.Writer) (setupAnswers, error) {
+	var a setupAnswers
+	var err error
+	questions := []struct {
+		dst      *string
+		question string
+		def      func() string
+		optional bool
+	}{
+		{&a.SignerID, "Signer ID (the ID routers know your key by)", func() string { return "" }, false},
+		{&a.SigningKey, "Signing key", func() string { return "signing_key.pem" }, false},
+		{&a.SignerCert, "Signer certificate", func() string { return signerCertName(a.SignerID) }, false},
+		{&a.FeedMain, "Feed URL routers fetch", defaultFeedURL, false},
+		{&a.FeedBackup, "Backup feed URL (empty for none)", func() string { return "" }, true},
+		{&a.FeedSite, "News site URL", func() string { return "http://i2p-projekt.i2p" }, false},
+		{&a.DataDir, "Data directory", func() string { return "data" }, false},
+		{&a.BuildDir, "Build directory", func() string { return "build" }, false},
+	}
+	for _, q := range questions {
+		for {
+			if *q.dst, err = ask(in, out, q.question, q.def()); err != nil {
+				return a, err
+			}
+			if *q.dst != "" || q.optional {
+				break
+			}
+		}
+		if q.dst != &a.SigningKey {
+			continue
+		}
+		if _, err := os.Stat(a.SigningKey); err == nil {
+			continue
+		}
+		if keystoreExts[strings.ToLower(filepath.Ext(a.SigningKey))] {
+			fmt.Fprintf(out, "%s does not exist; setup only generates PEM keys, so create the keystore yourself.\n", a.SigningKey)
+			continue
+		}
+		if a.GenerateKey, err = askYesNo(in, out, a.SigningKey+" does not exist. Generate a new signing key", true); err != nil {
+			return a, err
+		}
+	}
+	return a, nil
+}
+
+// applySetup generates the signing key when asked to and scaffolds the data
+// tree.
+func applySetup(a setupAnswers) error {
+	if a.GenerateKey {
+		if err := generateSigningKey(a.SigningKey, a.SignerCert, a.SignerID); err != nil {
+			return err
+		}
+		fmt.Printf("Generated %s and %s.  Keep the key secret; give the certificate to the routers that fetch your feed.\n", a.SigningKey, a.SignerCert)
+	}
+	return scaffoldDataTree(a.DataDir, time.Now())
+}
+
+// generateSigningKey writes a new RSA key to keyPath as PKCS#1 PEM, readable
+// only by the owner, and a self-signed certificate for it with the common
+// name signerID to certPath.  Neither file may exist yet.
+func generateSigningKey(keyPath, certPath, signerID string) error {
+	key, err := rsa.GenerateKey(rand.Reader, setupKeyBits)
+	if err != nil {
+		return fmt.Errorf("generateSigningKey: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("generateSigningKey: %w", err)
+	}
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: signerID, OrganizationalUnit: []string{"I2P News"}},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return fmt.Errorf("generateSigningKey: %w", err)
+	}
+	if err := writeNewPEM(keyPath, 0o600, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key)); err != nil {
+		return fmt.Errorf("generateSigningKey: %w", err)
+	}
+	if err := writeNewPEM(certPath, 0o644, "CERTIFICATE", der); err != nil {
+		return fmt.Errorf("generateSigningKey: %w", err)
+	}
+	return nil
+}
+
+// writeNewPEM writes a single PEM block to path, which must not exist.
+func writeNewPEM(path string, perm os.FileMode, blockType string, der []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	err = pem.Encode(f, &pem.Block{Type: blockType, Bytes: der})
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// scaffoldEntries is the sample entries.html setup writes; %s are the
+// article id and the date.
+const scaffoldEntries = `<html>
+<body>
+<header>I2P News</header>
+<article id="urn:uuid:%s" title="Welcome" href="http://i2p-projekt.i2p/" author="newsgo" published="%s" updated="%s">
+<details><summary>This news feed is up and running.</summary></details>
+<p>Replace this article with your own news.</p>
+</article>
+</body>
+</html>
+`
+
+// scaffoldReleases is the sample releases.json setup writes.  Its version is
+// older than any router, so a feed built before it is edited offers no
+// update.
+const scaffoldReleases = `[{
+  "date": "2000-01-01",
+  "version": "0.0.0",
+  "minVersion": "0.9.9",
+  "minJavaVersion": "1.8",
+  "updates": {
+    "su3": {
+      "torrent": "magnet:?xt=urn:btih:0000000000000000000000000000000000000000",
+      "url": []
+    }
+  }
+}]
+`
+
+// scaffoldDataTree creates dataDir with a sample entries.html dated now, a
+// releases.json, and an empty blocklist.xml.  Files that already exist are
+// left alone.
+func scaffoldDataTree(dataDir string, now time.Time) error {
+	if err := os.MkdirAll(dataDir, 0o755); err != nil {
+		return fmt.Errorf("scaffoldDataTree: %w", err)
+	}
+	date := now.UTC().Format(time.DateOnly)
+	files := []struct{ name, content string }{
+		{"entries.html", fmt.Sprintf(scaffoldEntries, uuid.NewString(), date, date)},
+		{"releases.json", scaffoldReleases},
+		{"blocklist.xml", ""},
+	}
+	for _, f := range files {
+		path := filepath.Join(dataDir, f.name)
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("Keeping existing %s.\n", path)
+			continue
+		}
+		if err := os.WriteFile(path, []byte(f.content), 0o644); err != nil {
+			return fmt.Errorf("scaffoldDataTree: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeSetupConfig writes the answers to the config file at path under the
+// keys of the flags they answer, replacing any existing file.
+func writeSetupConfig(path string, a setupAnswers) error {
+	v := viper.New()
+	v.Set("signerid", a.SignerID)
+	v.Set("signingkey", a.SigningKey)
+	v.Set("signercert", a.SignerCert)
+	v.Set("feedmain", a.FeedMain)
+	v.Set("feedbackup", a.FeedBackup)
+	v.Set("feedsite", a.FeedSite)
+	v.Set("newsfile", a.DataDir)
+	v.Set("releasejson", filepath.Join(a.DataDir, "releases.json"))
+	v.Set("blockfile", filepath.Join(a.DataDir, "blocklist.xml"))
+	v.Set("builddir", a.BuildDir)
+	v.Set("newsdir", a.BuildDir)
+	v.Set("statsfile", filepath.Join(a.BuildDir, "stats.json"))
+	if !contains(viper.SupportedExts, strings.TrimPrefix(filepath.Ext(path), ".")) {
+		// Like initConfig, read a file without a known extension as YAML.
+		v.SetConfigType("yaml")
+	}
+	if err := v.WriteConfigAs(path); err != nil {
+		return fmt.Errorf("writeSetupConfig: %w", err)
+	}
+	return nil
+}
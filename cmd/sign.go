@@ -9,7 +9,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	newslogger "github.com/go-i2p/newsgo/logger"
 	signer "github.com/go-i2p/newsgo/signer"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -28,30 +31,65 @@ var signCmd = &cobra.Command{
 	Short: "Sign newsfeeds with local keys",
 	Run: func(cmd *cobra.Command, args []string) {
 		viper.Unmarshal(c)
+		selectionFlags(cmd)
+		sel := feedSelector()
 
 		// Sign walks the build output directory for .atom.xml feeds produced
 		// by the build command.  Walking the source directory for .html files
 		// would call CreateSu3 on them; because CreateSu3 derives the output
 		// path by replacing ".atom.xml" with ".su3", a .html input path is
 		// unchanged and the source file is overwritten with binary su3 data.
+		if err := checkCertExpiry("sign", c.SignerCert, c.CertMinDays, time.Now()); err != nil {
+			log.Fatalf("sign: %v", err)
+		}
 		f, e := os.Stat(c.BuildDir)
 		if e != nil {
 			log.Fatalf("sign: stat %s: %v", c.BuildDir, e)
 		}
+		// Every feed is attempted, but any failure — including a su3 that
+		// does not verify after signing — makes the command exit non-zero so
+		// that a publish script never ships a partially signed tree.
+		report := newRunReport("sign", resultSigned, resultSkipped, resultErrored)
+		failed := 0
+		// sign records the result of signing the feed at path as file,
+		// relative to the build directory.
+		sign := func(path, file string) {
+			ok, err := signFeed(path)
+			switch {
+			case err != nil:
+				log.Printf("Sign(%s): %v", path, err)
+				failed++
+				report.add(file, resultErrored, err)
+			case ok:
+				report.add(file, resultSigned, nil)
+			default:
+				report.add(file, resultSkipped, nil)
+			}
+		}
 		if f.IsDir() {
 			err := filepath.Walk(c.BuildDir,
 				func(path string, info os.FileInfo, err error) error {
 					if err != nil {
 						return err
 					}
+					// --platform, --status, and --lang sign only part of the
+					// tree, e.g. one platform during a staged rollout.
+					if rel, err := filepath.Rel(c.BuildDir, path); err == nil && !sel.Selects(filepath.ToSlash(rel), info.IsDir()) {
+						if info.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
 					if strings.HasSuffix(path, ".atom.xml") {
 						// Capture and log the error so that a key-load failure,
 						// su3 marshal error, or write error is visible to the
 						// operator.  The walk continues so that other feed files
 						// are still attempted, but the non-zero result is surfaced.
-						if err := Sign(path); err != nil {
-							log.Printf("Sign(%s): %v", path, err)
+						rel, err := filepath.Rel(c.BuildDir, path)
+						if err != nil {
+							rel = path
 						}
+						sign(path, rel)
 					}
 					return nil
 				})
@@ -63,9 +101,11 @@ var signCmd = &cobra.Command{
 			// that key-load failures, su3 marshal errors, and write errors
 			// are visible to the operator — consistent with the directory
 			// walk path above which logs Sign() errors.
-			if err := Sign(c.BuildDir); err != nil {
-				log.Printf("Sign(%s): %v", c.BuildDir, err)
-			}
+			sign(c.BuildDir, filepath.Base(c.BuildDir))
+		}
+		report.print(os.Stdout, useColor(os.Stdout), "")
+		if failed > 0 {
+			log.Fatalf("sign: %d feed(s) failed to sign or verify", failed)
 		}
 	},
 }
@@ -82,6 +122,13 @@ func init() {
 	// builddir must match the flag registered by buildCmd so that the sign
 	// command operates on the same output directory where feeds were written.
 	signCmd.Flags().String("builddir", "build", "Build directory containing .atom.xml feeds to sign")
+	signCmd.Flags().String("signercert", "", "PEM certificate for the signing key; each su3 is verified against it after signing (default: a self-signed certificate for the key)")
+	signCmd.Flags().Int("cert-min-days", defaultCertMinDays, "refuse to sign when --signercert expires within this many days; an expired certificate is always refused. Expiry within 90 days is warned about")
+	signCmd.Flags().Bool("force", false, "re-sign every feed, even when its su3 already holds the same content signed by the same key")
+	signCmd.Flags().Bool("compress", false, "gzip each feed inside its su3, marked as gzipped XML, to shrink downloads over I2P")
+	signCmd.Flags().String("platform", "", "sign only this platform's feeds (linux|mac|mac-arm64|win|android|ios); empty = all")
+	signCmd.Flags().String("status", "", "sign only this release channel (stable|beta|rc|alpha) of each platform; empty = all")
+	signCmd.Flags().StringSlice("lang", nil, "sign only the feeds of these locales, e.g. en,de,pt_BR; en is the canonical news feed; empty = all")
 
 	viper.BindPFlags(signCmd.Flags())
 }
@@ -143,16 +190,86 @@ func loadKey(path, storePassword, entryPassword, alias string) (crypto.Signer, e
 
 // Sign loads the configured private key and signs the Atom XML feed at
 // xmlfeed, producing a co-located .su3 file. It returns any error encountered
-// during key loading or su3 creation. Supports RSA (PKCS#1 and PKCS#8),
-// ECDSA (P-256, P-384, P-521), Ed25519, Java KeyStore, and PKCS#12.
+// during key loading, su3 creation, or the post-signing verification against
+// --signercert. A feed whose su3 already embeds its current content, signed
+// by the configured key, is skipped unless --force is set. Supports RSA
+// (PKCS#1 and PKCS#8), ECDSA (P-256, P-384, P-521), Ed25519, Java KeyStore,
+// and PKCS#12.
 func Sign(xmlfeed string) error {
+	_, err := signFeed(xmlfeed)
+	return err
+}
+
+// signFeed implements Sign and reports whether xmlfeed was signed (false when
+// its su3 was current and skipped).
+func signFeed(xmlfeed string) (bool, error) {
 	sk, err := loadKey(c.SigningKey, c.KeystorePass, c.KeyEntryPass, c.SignerId)
 	if err != nil {
-		return err
+		return false, err
 	}
 	newsSigner := signer.NewsSigner{
 		SignerID:   c.SignerId,
 		SigningKey: sk,
+		Compress:   c.Compress,
+	}
+	if c.SignerCert != "" {
+		certs, err := newsfetch.LoadCertificates([]string{c.SignerCert})
+		if err != nil {
+			return false, err
+		}
+		newsSigner.Certificate = certs[0]
+	}
+	if !c.Force && newsSigner.Su3Current(xmlfeed) {
+		newslogger.Verbosef("Sign(%s): su3 is current; skipping (use --force to re-sign)", xmlfeed)
+		return false, nil
+	}
+	if err := newsSigner.CreateSu3(xmlfeed); err != nil {
+		return false, err
+	}
+	newslogger.Verbosef("Sign(%s): signed", xmlfeed)
+	return true, nil
+}
+
+// certExpiryNotice is how long before its NotAfter build and sign start
+// warning that the signer certificate is about to expire: long enough to
+// issue a new one and ship it to routers in a release.
+const certExpiryNotice = 90 * 24 * time.Hour
+
+// defaultCertMinDays is the default --cert-min-days.
+const defaultCertMinDays = 14
+
+// signerCertExpiry returns the NotAfter of the signer certificate at path.
+// It fails when the certificate has expired at now or expires within minDays
+// days of it: routers refuse a feed signed by an expired certificate, so
+// every router would silently stop receiving news and updates.
+func signerCertExpiry(path string, minDays int, now time.Time) (time.Time, error) {
+	certs, err := newsfetch.LoadCertificates([]string{path})
+	if err != nil {
+		return time.Time{}, err
+	}
+	notAfter := certs[0].NotAfter
+	switch left := notAfter.Sub(now); {
+	case left <= 0:
+		return notAfter, fmt.Errorf("signer certificate %s expired on %s", path, notAfter.Format(time.DateOnly))
+	case left < time.Duration(minDays)*24*time.Hour:
+		return notAfter, fmt.Errorf("signer certificate %s expires on %s, within --cert-min-days %d", path, notAfter.Format(time.DateOnly), minDays)
+	}
+	return notAfter, nil
+}
+
+// checkCertExpiry checks the signer certificate at path, when set, with
+// signerCertExpiry and logs a warning from verb when it expires within
+// certExpiryNotice.
+func checkCertExpiry(verb, path string, minDays int, now time.Time) error {
+	if path == "" {
+		return nil
+	}
+	notAfter, err := signerCertExpiry(path, minDays, now)
+	if err != nil {
+		return err
+	}
+	if left := notAfter.Sub(now); left < certExpiryNotice {
+		newslogger.Printf("%s: warning: signer certificate %s expires on %s, in %d days; issue a new one and distribute it to routers", verb, path, notAfter.Format(time.DateOnly), int(left.Hours()/24))
 	}
-	return newsSigner.CreateSu3(xmlfeed)
+	return nil
 }
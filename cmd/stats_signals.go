@@ -0,0 +1,42 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+	server "github.com/go-i2p/newsgo/server"
+)
+
+// handleStatsSignals lets an operator checkpoint the download stats of a
+// running server: SIGUSR1 saves them and logs a summary, SIGUSR2 archives
+// them and starts counting from zero.
+func handleStatsSignals(s *server.NewsServer) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for sig := range sigCh {
+			newslogger.Printf("captured: %v", sig)
+			switch sig {
+			case syscall.SIGUSR1:
+				if err := s.Stats.Save(); err != nil {
+					log.Printf("Stats.Save: %v", err)
+				}
+				newslogger.Printf("stats: %s", s.Stats.Summary())
+			case syscall.SIGUSR2:
+				newslogger.Printf("stats: %s", s.Stats.Summary())
+				archive, err := s.Stats.Archive(time.Now())
+				if err != nil {
+					log.Printf("Stats.Archive: %v", err)
+					continue
+				}
+				newslogger.Printf("stats: archived to %s and reset", archive)
+			}
+		}
+	}()
+}
@@ -0,0 +1,6 @@
+package cmd
+
+import server "github.com/go-i2p/newsgo/server"
+
+// handleStatsSignals does nothing: Windows has no SIGUSR1 or SIGUSR2.
+func handleStatsSignals(s *server.NewsServer) {}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	"github.com/spf13/cobra"
+)
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status [builddir]",
+	Short: "Report the entry count, newest entry, and age of every built feed",
+	Long: `status reads the JSON metadata sidecar that build writes next to every
+feed (news.atom.xml is described by news.json) and prints one row per feed
+with its locale, entry count, newest entry, release version, and build time.
+
+With --max-age it exits non-zero when any feed was built longer ago than the
+given duration or has no sidecar, so it can drive freshness monitoring
+without parsing the feeds themselves.
+
+When --signercert is set, from the flag or the config file, status also
+prints when the signer certificate expires, and exits non-zero once it
+expires within --cert-min-days: an expired news signer breaks updates for
+every router.
+
+Example:
+  newsgo status build/ --max-age 24h --signercert news.crt`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "build"
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		maxAge, _ := cmd.Flags().GetDuration("max-age")
+		results, err := feedStatuses(dir)
+		if err != nil {
+			log.Fatalf("status: %v", err)
+		}
+		if len(results) == 0 {
+			log.Fatalf("status: no feeds found in %s", dir)
+		}
+		now := time.Now()
+		stale := printFeedStatus(os.Stdout, results, maxAge, now)
+		// signercert and cert-min-days share their names with flags of sign,
+		// so they are not bound to viper; doctorSetting reads them.
+		certOK := true
+		if path := doctorSetting(cmd, "signercert"); path != "" {
+			minDays, err := strconv.Atoi(doctorSetting(cmd, "cert-min-days"))
+			if err != nil {
+				log.Fatalf("status: --cert-min-days: %v", err)
+			}
+			certOK = printCertExpiry(os.Stdout, path, minDays, now)
+		}
+		if stale > 0 {
+			log.Fatalf("status: %d of %d feed(s) stale or without metadata", stale, len(results))
+		}
+		if !certOK {
+			log.Fatal("status: signer certificate expired or about to")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+
+	statusCmd.Flags().Duration("max-age", 0, "exit non-zero when a feed was built longer ago than this; 0 disables the check")
+	statusCmd.Flags().String("signercert", "", "signer certificate whose expiry date is reported, as passed to sign")
+	statusCmd.Flags().Int("cert-min-days", defaultCertMinDays, "exit non-zero when --signercert expires within this many days")
+}
+
+// feedStatus is the sidecar metadata of one feed, identified by its path
+// relative to the build directory.  Err is set when the sidecar is missing or
+// unreadable.
+type feedStatus struct {
+	Name string
+	Meta builder.FeedMetadata
+	Err  error
+}
+
+// feedStatuses walks dir and reads the sidecar of every .atom.xml feed.
+// Results are sorted by name.
+func feedStatuses(dir string) ([]feedStatus, error) {
+	var results []feedStatus
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Dot-files are in-progress temporaries, never published feeds.
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") || !strings.HasSuffix(path, ".atom.xml") {
+			return nil
+		}
+		s := feedStatus{Name: path}
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			s.Name = filepath.ToSlash(rel)
+		}
+		s.Meta, s.Err = builder.ReadFeedMetadata(path)
+		results = append(results, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// printFeedStatus writes one row per feed to w followed by the reason for
+// every stale feed, and returns the number of stale feeds.  A feed is stale
+// when it has no readable sidecar or, with a positive maxAge, was built more
+// than maxAge before now.
+func printFeedStatus(w io.Writer, results []feedStatus, maxAge time.Duration, now time.Time) int {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEED\tLOCALE\tENTRIES\tNEWEST\tRELEASE\tBUILT")
+	stale := 0
+	var reasons []string
+	for _, r := range results {
+		if r.Err != nil {
+			fmt.Fprintf(tw, "%s\t-\t-\t-\t-\t-\n", r.Name)
+			stale++
+			reasons = append(reasons, fmt.Sprintf("%s: %v", r.Name, r.Err))
+			continue
+		}
+		m := r.Meta
+		newest := m.NewestEntry
+		if newest == "" {
+			newest = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\n", r.Name, m.Locale, m.Entries, newest, m.ReleaseVersion, m.Built)
+		if maxAge <= 0 {
+			continue
+		}
+		built, ok := m.BuiltTime()
+		switch {
+		case !ok:
+			stale++
+			reasons = append(reasons, fmt.Sprintf("%s: unparseable build time %q", r.Name, m.Built))
+		case now.Sub(built) > maxAge:
+			stale++
+			reasons = append(reasons, fmt.Sprintf("%s: built %s ago, more than --max-age %s", r.Name, now.Sub(built).Round(time.Second), maxAge))
+		}
+	}
+	tw.Flush()
+	for _, reason := range reasons {
+		fmt.Fprintln(w, reason)
+	}
+	return stale
+}
+
+// printCertExpiry writes when the signer certificate at path expires to w,
+// and the reason it fails signerCertExpiry, if it does.  It reports whether
+// the certificate passed.
+func printCertExpiry(w io.Writer, path string, minDays int, now time.Time) bool {
+	notAfter, err := signerCertExpiry(path, minDays, now)
+	if notAfter.After(now) {
+		fmt.Fprintf(w, "signer certificate %s expires on %s, in %d days\n", path, notAfter.Format(time.DateOnly), int(notAfter.Sub(now).Hours()/24))
+	}
+	if err != nil {
+		fmt.Fprintln(w, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	"github.com/spf13/cobra"
+)
+
+// translationsCmd groups the translation round-trip subcommands.
+var translationsCmd = &cobra.Command{
+	Use:   "translations",
+	Short: "Convert entries translations to and from gettext PO files for Weblate",
+	Long: `translations converts between entries.{locale}.html files and the
+gettext PO files used by Weblate.
+
+  export  writes entries.pot and one {locale}.po per existing translation
+  import  writes entries.{locale}.html for every {locale}.po
+
+Each article contributes a title, summary, and body unit keyed by its id.
+Article metadata (href, author, dates) always comes from --source; an article
+is imported only when all of its units are translated and not fuzzy.
+
+Example:
+  newsgo translations export --from data/translations/ --into weblate-export/
+  newsgo translations import --from weblate-export/ --into data/translations/`,
+}
+
+var translationsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write entries.pot and {locale}.po files from the entries translations",
+	Run: func(cmd *cobra.Command, args []string) {
+		source, from, into := translationsFlags(cmd)
+		written, err := builder.ExportTranslations(source, from, into)
+		if err != nil {
+			log.Fatalf("translations export: %v", err)
+		}
+		for _, path := range written {
+			fmt.Println(path)
+		}
+	},
+}
+
+var translationsImportCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Write entries.{locale}.html files from translated {locale}.po files",
+	Run: func(cmd *cobra.Command, args []string) {
+		source, from, into := translationsFlags(cmd)
+		results, err := builder.ImportTranslations(source, from, into)
+		if err != nil {
+			log.Fatalf("translations import: %v", err)
+		}
+		for _, r := range results {
+			fmt.Printf("%s: %d article(s), %d untranslated or fuzzy\n", r.Path, r.Articles, r.Skipped)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(translationsCmd)
+	translationsCmd.AddCommand(translationsExportCmd, translationsImportCmd)
+
+	// These flags are not bound to viper: "from" and "into" mean different
+	// directories for export and import, so a config-file value could only
+	// ever be right for one of them.
+	translationsExportCmd.Flags().String("source", "data/entries.html", "canonical English entries file the units are extracted from")
+	translationsExportCmd.Flags().String("from", "data/translations", "directory containing entries.{locale}.html translation files")
+	translationsExportCmd.Flags().String("into", "weblate-export", "directory to write entries.pot and {locale}.po files to")
+	translationsImportCmd.Flags().String("source", "data/entries.html", "canonical English entries file that supplies article metadata")
+	translationsImportCmd.Flags().String("from", "weblate-export", "directory containing translated {locale}.po files")
+	translationsImportCmd.Flags().String("into", "data/translations", "directory to write entries.{locale}.html files to")
+}
+
+// translationsFlags returns the --source, --from, and --into values of cmd.
+func translationsFlags(cmd *cobra.Command) (source, from, into string) {
+	source, _ = cmd.Flags().GetString("source")
+	from, _ = cmd.Flags().GetString("from")
+	into, _ = cmd.Flags().GetString("into")
+	return source, from, into
+}
@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	"github.com/spf13/cobra"
+)
+
+// atomNamespace is the XML namespace every built feed's root <feed> must use.
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// verifyTreeCmd represents the verify-tree command
+var verifyTreeCmd = &cobra.Command{
+	Use:   "verify-tree [builddir]",
+	Short: "Verify every signed feed in a build directory before publishing",
+	Long: `verify-tree walks a build directory and, for every feed, checks that:
+
+  sig     the .su3 signature verifies against one of --trustedcerts
+  content the .su3 content is byte-identical to the sibling .atom.xml
+  atom    the .atom.xml is well-formed XML with an Atom <feed> root
+
+It prints a pass/fail matrix and exits non-zero if any check fails, so it can
+gate a publish step.  A feed with only one of the .su3 and .atom.xml files
+fails the checks that need the missing file.
+
+Example:
+  newsgo verify-tree build/ --trustedcerts news.crt`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "build"
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		// --trustedcerts is shared with fetch and mirror; it is read from this
+		// command's flag set and not bound to viper (see mirror.go).
+		paths, _ := cmd.Flags().GetStringSlice("trustedcerts")
+		if len(paths) == 0 {
+			log.Fatal("verify-tree: --trustedcerts is required")
+		}
+		certs, err := newsfetch.LoadCertificates(paths)
+		if err != nil {
+			log.Fatalf("verify-tree: load certificates: %v", err)
+		}
+		results, err := verifyTree(dir, certs)
+		if err != nil {
+			log.Fatalf("verify-tree: %v", err)
+		}
+		if failed := printVerifyMatrix(os.Stdout, results); failed > 0 {
+			log.Fatalf("verify-tree: %d of %d feed(s) failed", failed, len(results))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifyTreeCmd)
+
+	verifyTreeCmd.Flags().StringSlice("trustedcerts", nil, "PEM certificate files whose public keys are trusted to verify su3 signatures")
+}
+
+// feedCheck is the outcome of one verify-tree check.  An empty Err means the
+// check passed.
+type feedCheck struct {
+	Err string
+}
+
+func (fc feedCheck) ok() bool { return fc.Err == "" }
+
+// feedVerifyResult holds the verify-tree checks for one feed, identified by
+// its path relative to the build directory without the .su3/.atom.xml suffix.
+type feedVerifyResult struct {
+	Name    string
+	Sig     feedCheck
+	Content feedCheck
+	Atom    feedCheck
+}
+
+func (r feedVerifyResult) ok() bool {
+	return r.Sig.ok() && r.Content.ok() && r.Atom.ok()
+}
+
+// verifyTree walks dir and checks every feed found as a .su3 file, a
+// .atom.xml file, or both.  Results are sorted by name.
+func verifyTree(dir string, certs []*x509.Certificate) ([]feedVerifyResult, error) {
+	names := map[string]bool{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		// Dot-files are in-progress temporaries (see newsfetch.FetchToFile),
+		// never published feeds.
+		if info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+			return nil
+		}
+		switch {
+		case strings.HasSuffix(path, ".su3"):
+			names[strings.TrimSuffix(path, ".su3")] = true
+		case strings.HasSuffix(path, ".atom.xml"):
+			names[strings.TrimSuffix(path, ".atom.xml")] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	var results []feedVerifyResult
+	for base := range names {
+		r := verifyFeed(base, certs)
+		if rel, err := filepath.Rel(dir, base); err == nil {
+			r.Name = filepath.ToSlash(rel)
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+// verifyFeed runs every check for the feed whose files are base+".su3" and
+// base+".atom.xml".
+func verifyFeed(base string, certs []*x509.Certificate) feedVerifyResult {
+	r := feedVerifyResult{Name: base}
+	atom, atomErr := os.ReadFile(base + ".atom.xml")
+	if atomErr != nil {
+		r.Atom.Err = "missing .atom.xml"
+	} else if err := checkAtomWellFormed(atom); err != nil {
+		r.Atom.Err = err.Error()
+	}
+
+	data, err := os.ReadFile(base + ".su3")
+	if err != nil {
+		r.Sig.Err = "missing .su3"
+		r.Content.Err = "missing .su3"
+		return r
+	}
+	content, err := newsfetch.VerifyAndUnpack(data, certs)
+	if err != nil {
+		r.Sig.Err = err.Error()
+		r.Content.Err = "not checked"
+		return r
+	}
+	switch {
+	case atomErr != nil:
+		r.Content.Err = "missing .atom.xml"
+	case !bytes.Equal(content, atom):
+		r.Content.Err = fmt.Sprintf("su3 content (%d bytes) differs from .atom.xml (%d bytes)", len(content), len(atom))
+	}
+	return r
+}
+
+// checkAtomWellFormed reports an error unless data is well-formed XML whose
+// root element is an Atom <feed>.
+func checkAtomWellFormed(data []byte) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	root := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("malformed XML: %w", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok && !root {
+			if se.Name.Space != atomNamespace || se.Name.Local != "feed" {
+				return fmt.Errorf("root element is {%s}%s, want Atom feed", se.Name.Space, se.Name.Local)
+			}
+			root = true
+		}
+	}
+	if !root {
+		return fmt.Errorf("no root element")
+	}
+	return nil
+}
+
+// printVerifyMatrix writes one row per feed to w followed by the reason for
+// every failed check, and returns the number of feeds with a failure.
+func printVerifyMatrix(w io.Writer, results []feedVerifyResult) int {
+	mark := func(fc feedCheck) string {
+		if fc.ok() {
+			return "PASS"
+		}
+		return "FAIL"
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FEED\tSIG\tCONTENT\tATOM")
+	failed := 0
+	var reasons []string
+	for _, r := range results {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, mark(r.Sig), mark(r.Content), mark(r.Atom))
+		if r.ok() {
+			continue
+		}
+		failed++
+		for _, c := range []struct {
+			label string
+			check feedCheck
+		}{{"sig", r.Sig}, {"content", r.Content}, {"atom", r.Atom}} {
+			if !c.check.ok() {
+				reasons = append(reasons, fmt.Sprintf("%s: %s: %s", r.Name, c.label, c.check.Err))
+			}
+		}
+	}
+	tw.Flush()
+	for _, reason := range reasons {
+		fmt.Fprintln(w, reason)
+	}
+	return failed
+}
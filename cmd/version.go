@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	newsfetch "github.com/go-i2p/newsgo/fetch"
+	"github.com/go-i2p/onramp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Version is the newsgo release the binary was built from.  Release builds
+// set it with -ldflags "-X github.com/go-i2p/newsgo/cmd.Version=v0.2.0";
+// otherwise the module version recorded by go install is used.
+var Version = ""
+
+// defaultCheckURL is the release feed version --check reads by default.
+const defaultCheckURL = "https://github.com/go-i2p/newsgo/releases.atom"
+
+// checkTimeout bounds the clearnet request of version --check.  I2P requests
+// use the Fetcher's own, longer timeout.
+const checkTimeout = 30 * time.Second
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print the newsgo version and optionally check for a newer release",
+	Long: `version prints the version of this newsgo binary.
+
+With --check it also fetches the latest release from --check-url and reports
+whether this binary is outdated, exiting non-zero if it is.  --check-url may
+name:
+
+  an Atom feed    such as the GitHub release feed (the default) or a news
+                  feed, plain or as a .su3; entries whose title or link
+                  mentions newsgo are read, and the newest version wins
+  a JSON document with a "tag_name" (the GitHub release API) or "version"
+
+.i2p URLs are fetched over SAMv3, others over the clearnet.
+
+Examples:
+  newsgo version
+  newsgo version --check
+  newsgo version --check --check-url http://<host>.b32.i2p/news/news.su3 --trustedcerts news.crt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		viper.Unmarshal(c)
+		current := currentVersion()
+		fmt.Printf("newsgo %s\n", current)
+		check, _ := cmd.Flags().GetBool("check")
+		if !check {
+			return
+		}
+		// --samaddr and --trustedcerts are shared with fetch and serve, so
+		// they are read from this command's flags rather than from viper.
+		samAddr, _ := cmd.Flags().GetString("samaddr")
+		certPaths, _ := cmd.Flags().GetStringSlice("trustedcerts")
+		var certs []*x509.Certificate
+		if len(certPaths) > 0 {
+			loaded, err := newsfetch.LoadCertificates(certPaths)
+			if err != nil {
+				log.Fatalf("version: load certificates: %v", err)
+			}
+			certs = loaded
+		}
+		fetcher, err := checkFetcher(c.CheckURL, samAddr)
+		if err != nil {
+			log.Fatalf("version: %v", err)
+		}
+		defer newsfetch.CloseSharedGarlic()
+		latest, err := latestRelease(fetcher, c.CheckURL, certs)
+		if err != nil {
+			log.Fatalf("version: --check: %v", err)
+		}
+		outdated, report := compareRelease(current, latest)
+		fmt.Println(report)
+		if outdated {
+			newsfetch.CloseSharedGarlic()
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+	versionCmd.Flags().Bool("check", false, "fetch the latest release from --check-url and report whether this binary is outdated; exits 1 if it is")
+	versionCmd.Flags().String("check-url", defaultCheckURL, "release feed (Atom or su3) or JSON release document to check; .i2p URLs are fetched over SAMv3")
+	versionCmd.Flags().String("samaddr", onramp.SAM_ADDR, "advanced: SAMv3 gateway address for an .i2p --check-url")
+	versionCmd.Flags().StringSlice("trustedcerts", nil, "PEM certificate files to verify a .su3 --check-url with; unverified when empty")
+	// Only check-url is bound: samaddr and trustedcerts collide with the
+	// flags of fetch and serve, and check is read from the flag set.
+	viper.BindPFlag("check-url", versionCmd.Flags().Lookup("check-url"))
+}
+
+// currentVersion returns Version, or the module version of the binary when
+// Version is not set, or "devel" for a build from a source checkout.  A
+// checkout stamped with a v0.0.0 pseudo-version also counts as "devel".
+func currentVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" || info.Main.Version == "(devel)" || strings.HasPrefix(info.Main.Version, "v0.0.0-") {
+		return "devel"
+	}
+	return info.Main.Version
+}
+
+// checkFetcher returns the Fetcher for rawURL: one on a SAM session at
+// samAddr for an .i2p host, a clearnet one otherwise.
+func checkFetcher(rawURL, samAddr string) (*newsfetch.Fetcher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("--check-url: %w", err)
+	}
+	transport := newsfetch.TransportClearnet
+	if strings.HasSuffix(strings.ToLower(u.Hostname()), ".i2p") {
+		transport = newsfetch.TransportI2P
+	}
+	if err := newsfetch.ValidateURL(rawURL, transport); err != nil {
+		return nil, fmt.Errorf("--check-url: %w", err)
+	}
+	if transport == newsfetch.TransportI2P {
+		return newsfetch.NewFetcher(samAddr)
+	}
+	return newsfetch.NewFetcherFromClient(&http.Client{Timeout: checkTimeout}), nil
+}
+
+// errNoRelease reports release information that names no newsgo version.
+var errNoRelease = errors.New("no newsgo release found")
+
+// release is the latest release found by latestRelease.
+type release struct {
+	Version string
+	URL     string
+}
+
+// latestRelease fetches rawURL with f and returns the newest release it
+// describes.  A su3 body is unpacked, and verified when certs is not empty.
+func latestRelease(f *newsfetch.Fetcher, rawURL string, certs []*x509.Certificate) (release, error) {
+	data, err := f.Fetch(rawURL)
+	if err != nil {
+		return release{}, err
+	}
+	if bytes.HasPrefix(data, []byte("I2Psu3")) {
+		if data, err = newsfetch.VerifyAndUnpack(data, certs); err != nil {
+			return release{}, err
+		}
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return parseReleaseJSON(trimmed)
+	}
+	return parseReleaseFeed(trimmed)
+}
+
+// parseReleaseJSON reads a GitHub release API document or any object with a
+// "version" field.
+func parseReleaseJSON(data []byte) (release, error) {
+	var doc struct {
+		TagName string `json:"tag_name"`
+		Version string `json:"version"`
+		HTMLURL string `json:"html_url"`
+		URL     string `json:"url"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return release{}, fmt.Errorf("parse release JSON: %w", err)
+	}
+	rel := release{Version: doc.TagName, URL: doc.HTMLURL}
+	if rel.Version == "" {
+		rel.Version = doc.Version
+	}
+	if rel.URL == "" {
+		rel.URL = doc.URL
+	}
+	if rel.Version == "" {
+		return release{}, errNoRelease
+	}
+	return rel, nil
+}
+
+// releaseFeed is the part of an Atom feed parseReleaseFeed reads.
+type releaseFeed struct {
+	Entries []struct {
+		Title string `xml:"http://www.w3.org/2005/Atom title"`
+		Links []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"http://www.w3.org/2005/Atom link"`
+	} `xml:"http://www.w3.org/2005/Atom entry"`
+}
+
+// versionPattern matches a version such as "v0.2.0" or "1.4.0-rc1".
+var versionPattern = regexp.MustCompile(`v?\d+(\.\d+)+(-[0-9A-Za-z.]+)?`)
+
+// parseReleaseFeed returns the newest newsgo release of an Atom feed.  Only
+// entries whose title or a link mentions newsgo count, so a news feed that
+// also announces router releases is read correctly; a GitHub release feed
+// qualifies through its links.  The version is taken from the title, or else
+// from the last element of the link.
+func parseReleaseFeed(data []byte) (release, error) {
+	var feed releaseFeed
+	if err := xml.Unmarshal(data, &feed); err != nil {
+		return release{}, fmt.Errorf("parse release feed: %w", err)
+	}
+	var latest release
+	for _, e := range feed.Entries {
+		link, mentioned := "", strings.Contains(strings.ToLower(e.Title), "newsgo")
+		for _, l := range e.Links {
+			if l.Rel == "" || l.Rel == "alternate" {
+				link = l.Href
+			}
+			mentioned = mentioned || strings.Contains(strings.ToLower(l.Href), "newsgo")
+		}
+		if !mentioned {
+			continue
+		}
+		v := versionPattern.FindString(e.Title)
+		if v == "" {
+			v = versionPattern.FindString(path.Base(link))
+		}
+		if v != "" && (latest.Version == "" || compareVersions(v, latest.Version) > 0) {
+			latest = release{Version: v, URL: link}
+		}
+	}
+	if latest.Version == "" {
+		return release{}, errNoRelease
+	}
+	return latest, nil
+}
+
+// compareRelease reports whether current is older than latest, with a
+// one-line report for the operator.  A development build is never reported
+// outdated, since its version says nothing about its age.
+func compareRelease(current string, latest release) (outdated bool, report string) {
+	where := ""
+	if latest.URL != "" {
+		where = " (" + latest.URL + ")"
+	}
+	switch {
+	case current == "devel":
+		return false, fmt.Sprintf("development build; the latest release is %s%s", latest.Version, where)
+	case compareVersions(current, latest.Version) < 0:
+		return true, fmt.Sprintf("outdated: the latest release is %s%s", latest.Version, where)
+	default:
+		return false, fmt.Sprintf("up to date: the latest release is %s", latest.Version)
+	}
+}
+
+// compareVersions compares two dotted versions numerically, ignoring a
+// leading "v", and returns -1, 0, or +1.  A pre-release ("1.0.0-rc1") sorts
+// before its release; build metadata ("+...") is ignored.
+func compareVersions(a, b string) int {
+	splitVersion := func(v string) ([]string, string) {
+		v, _, _ = strings.Cut(strings.TrimPrefix(v, "v"), "+")
+		v, pre, _ := strings.Cut(v, "-")
+		return strings.Split(v, "."), pre
+	}
+	an, apre := splitVersion(a)
+	bn, bpre := splitVersion(b)
+	for i := 0; i < len(an) || i < len(bn); i++ {
+		var x, y int
+		if i < len(an) {
+			x, _ = strconv.Atoi(an[i])
+		}
+		if i < len(bn) {
+			y, _ = strconv.Atoi(bn[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case apre == bpre:
+		return 0
+	case apre == "":
+		return 1
+	case bpre == "":
+		return -1
+	case apre < bpre:
+		return -1
+	default:
+		return 1
+	}
+}
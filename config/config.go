@@ -2,6 +2,8 @@
 // flags and viper configuration values into a single typed structure.
 package config
 
+import "time"
+
 // Conf holds the configuration values populated by viper from cobra flags,
 // environment variables, or a config file.
 //
@@ -12,11 +14,12 @@ type Conf struct {
 	NewsDir string
 	// StatsFile is stored at the path given by --statsfile.
 	StatsFile string `mapstructure:"statsfile"`
-	// Host and Port are the TCP address components for the HTTP listener,
-	// matching the --host / --port flags documented in the README.
+	// Host and Port are the TCP address components for the HTTP listeners,
+	// matching the --host / --port flags documented in the README.  One
+	// listener is started on Port for each host.
 	// (The previous Http field combined them into a host:port string and is
 	// no longer used.)
-	Host string
+	Host []string
 	Port string
 	// I2P enables SAMv3 co-hosting when true.  Corresponds to --i2p bool,
 	// which matches the README flag name.
@@ -68,6 +71,9 @@ type Conf struct {
 	// TrustedCerts lists PEM certificate files used to verify su3 signatures.
 	// An empty slice skips signature verification.
 	TrustedCerts []string `mapstructure:"trustedcerts"`
+	// TrustDir is a directory every *.crt and *.pem file of which is trusted
+	// along with TrustedCerts (--trustdir); mirror reloads it when it changes.
+	TrustDir string `mapstructure:"trustdir"`
 	// SkipVerify disables su3 signature verification when true.
 	SkipVerify bool `mapstructure:"skipverify"`
 
@@ -81,4 +87,273 @@ type Conf struct {
 	// Recognised values: "stable", "beta", "alpha", "rc".
 	// Empty string means build all statuses found under the platform directory.
 	Status string `mapstructure:"status"`
+
+	// IncludeDrafts emits articles marked draft="true" in built feeds
+	// (--include-drafts).  Drafts are skipped by default.
+	IncludeDrafts bool `mapstructure:"include-drafts"`
+
+	// TimestampSource selects the feed <updated> value (--timestamp-source):
+	// "build" for the build time or "newest-entry" for the newest article date.
+	TimestampSource string `mapstructure:"timestamp-source"`
+
+	// Upstream lists the su3 URLs the mirror command refreshes from, primary
+	// first (--upstream).
+	Upstream []string `mapstructure:"upstream"`
+
+	// Every is the interval between mirror refreshes (--every).
+	Every time.Duration `mapstructure:"every"`
+
+	// SiteURL is the public base URL of a clearnet mirror (--siteurl).  When
+	// set, serve generates /sitemap.xml and /robots.txt.
+	SiteURL string `mapstructure:"siteurl"`
+
+	// SignerCert is the PEM certificate each signed su3 is verified against
+	// (--signercert).  Empty means a self-signed certificate for the key.
+	SignerCert string `mapstructure:"signercert"`
+
+	// CertMinDays is how many days before the expiry of SignerCert build and
+	// sign start failing (--cert-min-days).  An expired certificate always
+	// fails.
+	CertMinDays int `mapstructure:"cert-min-days"`
+
+	// Clearnet listener tuning for serve.  TLSCert and TLSKey enable HTTPS,
+	// over which HTTP/2 is negotiated unless DisableHTTP2 is set.
+	TLSCert          string        `mapstructure:"tlscert"`
+	TLSKey           string        `mapstructure:"tlskey"`
+	IdleTimeout      time.Duration `mapstructure:"idletimeout"`
+	DisableKeepAlive bool          `mapstructure:"disable-keepalive"`
+	DisableHTTP2     bool          `mapstructure:"disable-http2"`
+
+	// BlocklistCerts lists PEM certificates trusted to sign the blocklist
+	// (--blocklist-certs).  When non-empty a detached <blockfile>.sig is
+	// required and verified before the blocklist is included.
+	BlocklistCerts []string `mapstructure:"blocklist-certs"`
+
+	// BlocklistTTL, when positive, adds updated/expires attributes to the
+	// blocklist (--blocklist-ttl).
+	BlocklistTTL time.Duration `mapstructure:"blocklist-ttl"`
+
+	// BlocklistMaxSize is the largest blocklist inlined in the feeds, in
+	// bytes (--blocklist-max-size); 0 disables the limit.  A larger one is
+	// referenced by BlocklistURL or fails the build.
+	BlocklistMaxSize int64 `mapstructure:"blocklist-max-size"`
+
+	// BlocklistURL is where the blocklist is published, referenced by an
+	// <i2p:blocklist href> when it exceeds BlocklistMaxSize (--blocklist-url).
+	BlocklistURL string `mapstructure:"blocklist-url"`
+
+	// LocaleFallbacks lists "locale=fallback" pairs (--locale-fallback), e.g.
+	// "es-AR=es" or "zh=zh-CN".  See newsbuilder.ParseLocaleFallbacks.
+	LocaleFallbacks []string `mapstructure:"locale-fallback"`
+
+	// URLTemplate is an su3 URL with {platform}, {status}, and {lang}
+	// placeholders that fetch expands over Platforms, Statuses, and Langs
+	// (--template, --platforms, --statuses, --langs).
+	URLTemplate string   `mapstructure:"template"`
+	Platforms   []string `mapstructure:"platforms"`
+	Statuses    []string `mapstructure:"statuses"`
+	Langs       []string `mapstructure:"langs"`
+
+	// ContentValidators derives ETag and Last-Modified from file content
+	// rather than mtime (--content-validators).
+	ContentValidators bool `mapstructure:"content-validators"`
+
+	// ListingETags answers conditional requests for unchanged directory
+	// listings with 304 (--listing-etags).
+	ListingETags bool `mapstructure:"listing-etags"`
+
+	// Force re-signs every feed, even one whose su3 is already current
+	// (sign --force).
+	Force bool `mapstructure:"force"`
+
+	// Compress gzips each feed inside its su3 (sign --compress).
+	Compress bool `mapstructure:"compress"`
+
+	// TOFU selects trust-on-first-use signer pinning for fetch and mirror
+	// (--tofu): "off", "enforce", or "warn".  Pins are kept in PinFile
+	// (--pinfile).
+	TOFU    string `mapstructure:"tofu"`
+	PinFile string `mapstructure:"pinfile"`
+
+	// HotlinkProtection rejects requests referred by other sites for
+	// anything but su3 files and Atom feeds (--hotlink-protection), except
+	// from AllowedReferers (--allowed-referers).
+	HotlinkProtection bool     `mapstructure:"hotlink-protection"`
+	AllowedReferers   []string `mapstructure:"allowed-referers"`
+
+	// LangAliases lists "from=to" pairs merging language stats buckets
+	// (--lang-alias).  See newsstats.ParseLangAliases.
+	LangAliases []string `mapstructure:"lang-alias"`
+
+	// Routes lists "prefix=dir" pairs serving further build trees under URL
+	// path prefixes (--route).  See newsserver.ParseRoutes.
+	Routes []string `mapstructure:"route"`
+
+	// Aliases lists "from=to" URL path pairs (--alias) answering legacy paths
+	// with files of the current layout.  See newsserver.ParseAliases.
+	Aliases []string `mapstructure:"alias"`
+
+	// MaxEntrySize and MaxFeedSize are the size budgets in bytes of each
+	// rendered entry and of each feed (--max-entry-size, --max-feed-size);
+	// StrictSize fails the build when one is exceeded (--strict-size).
+	MaxEntrySize int  `mapstructure:"max-entry-size"`
+	MaxFeedSize  int  `mapstructure:"max-feed-size"`
+	StrictSize   bool `mapstructure:"strict-size"`
+
+	// StrictChars fails the build on an article holding characters illegal
+	// in XML 1.0 instead of stripping them (--strict-chars).
+	StrictChars bool `mapstructure:"strict-chars"`
+
+	// ExportEntries is the directory fetch converts fetched feeds into an
+	// entries.html data tree under (--export-entries); empty disables it.
+	ExportEntries string `mapstructure:"export-entries"`
+
+	// Allow restricts serve to clients matching these patterns (--allow);
+	// empty serves everyone.  See newsserver.ParseAllowlist.
+	Allow []string `mapstructure:"allow"`
+
+	// WebSubHub is the WebSub hub advertised in built feeds (--websub-hub);
+	// WebSubPing notifies it after each build (--websub-ping).
+	WebSubHub  string `mapstructure:"websub-hub"`
+	WebSubPing bool   `mapstructure:"websub-ping"`
+
+	// WebSub runs the built-in WebSub hub of serve (--websub).
+	WebSub bool `mapstructure:"websub"`
+
+	// PProf is the loopback address of the serve debug listener with
+	// net/http/pprof and expvar (--pprof); empty disables it.
+	PProf string `mapstructure:"pprof"`
+
+	// Hide lists name patterns serve leaves out of listings and answers 404
+	// for (--hide); MaxListingDepth limits the depth of directory listings
+	// (--max-listing-depth).  See newsserver.ParseHidden.
+	Hide            []string `mapstructure:"hide"`
+	MaxListingDepth int      `mapstructure:"max-listing-depth"`
+
+	// GitRef builds from this git commit, tag or branch of Repo instead of
+	// the working tree (--git-ref, --repo).
+	GitRef string `mapstructure:"git-ref"`
+	Repo   string `mapstructure:"repo"`
+
+	// ChangedSince and SinceLastBuild restrict build to the feeds with an
+	// input modified after a cutoff (--changed-since, --since-last-build).
+	ChangedSince   string `mapstructure:"changed-since"`
+	SinceLastBuild bool   `mapstructure:"since-last-build"`
+
+	// WriteMeta makes fetch write a newsfetch.FetchMeta sidecar next to each
+	// fetched feed (--write-meta).
+	WriteMeta bool `mapstructure:"write-meta"`
+
+	// Landing replaces the root listing of serve with a landing page
+	// (--landing), rendered from LandingTemplate when set
+	// (--landing-template).
+	Landing         bool   `mapstructure:"landing"`
+	LandingTemplate string `mapstructure:"landing-template"`
+
+	// ChannelTitlePrefix is put in front of the title of pre-release feeds,
+	// with {status} replaced by the release status.
+	ChannelTitlePrefix string `mapstructure:"channel-title-prefix"`
+
+	// DownloadWindow counts a su3 download only once per client and file
+	// within the window (--download-window).
+	DownloadWindow time.Duration `mapstructure:"download-window"`
+
+	// IDStrategy selects how feed ids are formed (--id-strategy); see
+	// builder.IDStrategy.  TagAuthority and TagDate mint tag: URIs.
+	IDStrategy   string `mapstructure:"id-strategy"`
+	TagAuthority string `mapstructure:"tag-authority"`
+	TagDate      string `mapstructure:"tag-date"`
+
+	// AccessLog makes serve log every request (--access-log), sampling runs
+	// of identical requests per status class (--log-sample) and flushing the
+	// counts of sampled requests every LogFlush (--log-flush).  See
+	// newsserver.RequestLog.
+	AccessLog bool          `mapstructure:"access-log"`
+	LogSample []string      `mapstructure:"log-sample"`
+	LogFlush  time.Duration `mapstructure:"log-flush"`
+
+	// FollowUpdates makes fetch also store the router update su3s the fetched
+	// feeds advertise in UpdateDir (--follow-updates, --update-dir), verified
+	// against UpdateCerts (--update-certs).
+	FollowUpdates bool     `mapstructure:"follow-updates"`
+	UpdateDir     string   `mapstructure:"update-dir"`
+	UpdateCerts   []string `mapstructure:"update-certs"`
+
+	// Lang restricts sign and serve to the feeds of these locales (--lang);
+	// with Platform and Status it forms a newsbuilder.FeedSelector.
+	Lang []string `mapstructure:"lang"`
+
+	// Rights is the feed-level <rights> statement (--rights) and LicenseURI
+	// the URL of the license, linked with rel="license" (--license-uri).
+	Rights     string `mapstructure:"rights"`
+	LicenseURI string `mapstructure:"license-uri"`
+
+	// FeedIcon and FeedLogo are the <icon> and <logo> of the feeds
+	// (--feedicon, --feedlogo): URLs, or local images copied into BuildDir.
+	FeedIcon string `mapstructure:"feedicon"`
+	FeedLogo string `mapstructure:"feedlogo"`
+
+	// MaxSize is the largest response body fetch and mirror accept, in bytes
+	// (--max-size); 0 disables the limit.
+	MaxSize int64 `mapstructure:"max-size"`
+
+	// LangParam sets the lang query parameter of the fetched URLs
+	// (--lang-param), as routers do, so the upstream counts fetches by
+	// language.
+	LangParam string `mapstructure:"lang-param"`
+
+	// CacheBust makes fetches bypass HTTP caches on the way (--cache-bust).
+	CacheBust bool `mapstructure:"cache-bust"`
+
+	// Admin is the loopback address of the serve listener answering
+	// /admin/shutdown and /admin/reload (--admin); empty disables it.
+	Admin string `mapstructure:"admin"`
+	// AdminTokenFile holds the bearer token of the admin listener
+	// (--admin-token-file).
+	AdminTokenFile string `mapstructure:"admin-token-file"`
+
+	// CheckURL is the release feed or document version --check reads
+	// (--check-url).
+	CheckURL string `mapstructure:"check-url"`
+
+	// SecurityHeaders adds server.DefaultSecurityHeaders to the HTML
+	// responses of serve (--security-headers).
+	SecurityHeaders bool `mapstructure:"security-headers"`
+	// SecurityHeader lists Name=Value pairs adding to or overriding them
+	// (--security-header).
+	SecurityHeader []string `mapstructure:"security-header"`
+
+	// EntriesPage writes an HTML entries page next to every built feed and
+	// links each entry to its anchor on it (--entries-page).
+	EntriesPage bool `mapstructure:"entries-page"`
+
+	// NewsDirCurrent resolves NewsDir and the route directories, "current"
+	// symlinks repointed by the release pipeline, once per request
+	// (--newsdir-current).
+	NewsDirCurrent bool `mapstructure:"newsdir-current"`
+
+	// ReadOnly hardens serve for exposed public mirrors (--readonly): no
+	// stats are written into the served tree, directories are not listed,
+	// symlinks may not leave it, and the debug listener is refused.
+	ReadOnly bool `mapstructure:"readonly"`
+
+	// Symlinks is the mode of serve's symlink policy (--symlinks) and
+	// SymlinkAllow the further directories symlinks may lead into
+	// (--symlink-allow).  See newsserver.SymlinkPolicy.
+	Symlinks     string   `mapstructure:"symlinks"`
+	SymlinkAllow []string `mapstructure:"symlink-allow"`
+
+	// Outproxy is the HTTP proxy fetch reaches clearnet hosts through
+	// (--outproxy).  TransportRules lists "pattern=transport" pairs choosing
+	// the transport per host (--transport-rule), and DefaultTransport is the
+	// transport of clearnet hosts no rule matches (--default-transport).  See
+	// newsfetch.TransportPolicy.
+	Outproxy         string   `mapstructure:"outproxy"`
+	TransportRules   []string `mapstructure:"transport-rule"`
+	DefaultTransport string   `mapstructure:"default-transport"`
+
+	// History is how many previous versions of each fetched feed fetch and
+	// mirror keep for rolling back (--history); 0 keeps none.  See
+	// newsfetch.Fetcher.History.
+	History int `mapstructure:"history"`
 }
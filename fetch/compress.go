@@ -0,0 +1,30 @@
+package newsfetch
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// su3FileTypeXMLGZ is the su3 file type of gzipped XML content, as written by
+// sign --compress.
+const su3FileTypeXMLGZ = 3
+
+// contentReader returns the unpacked content of an su3 file of type fileType
+// read from r: r itself, or the decompressed stream for gzipped XML.  The
+// decompressed stream is limited to DefaultMaxSize bytes, so a small su3
+// cannot unpack into an unbounded file.  name identifies the file in errors.
+func contentReader(r io.Reader, fileType uint8, name string) (io.Reader, error) {
+	if fileType != su3FileTypeXMLGZ {
+		return r, nil
+	}
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: decompress %s: %w", name, err)
+	}
+	return &maxSizeReader{
+		r:         zr,
+		remaining: DefaultMaxSize,
+		err:       fmt.Errorf("newsfetch: decompress %s: more than %d bytes: %w", name, DefaultMaxSize, ErrTooLarge),
+	}, nil
+}
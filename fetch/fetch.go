@@ -7,6 +7,7 @@
 package newsfetch
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
@@ -73,6 +74,19 @@ func initSharedGarlic(samAddr string) (*onramp.Garlic, error) {
 	return sharedGarlic, garlicErr
 }
 
+// SharedGarlic returns the package-level Garlic session, opening it on first
+// use exactly as NewFetcher does.  It lets other subsystems in the same
+// process — for example an I2P news listener — reuse the fetch session
+// instead of opening a second one.  The session remains owned by this package
+// and is closed by CloseSharedGarlic; callers must not Close it themselves.
+func SharedGarlic(samAddr string) (*onramp.Garlic, error) {
+	g, err := initSharedGarlic(samAddr)
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: init garlic: %w", err)
+	}
+	return g, nil
+}
+
 // CloseSharedGarlic closes the package-level Garlic session.  Call this once
 // all Fetchers are no longer needed (e.g. in a defer after the fetch command
 // completes).  It is safe to call even if the session was never opened.
@@ -96,6 +110,29 @@ func CloseSharedGarlic() {
 // session.
 type Fetcher struct {
 	client *http.Client
+	// Pins, when set, pins the signer of every verified su3 to its URL on
+	// first use; see PinStore.  It only applies to FetchAndUnpackFile with a
+	// non-empty certificate list.
+	Pins *PinStore
+	// PinWarnOnly makes a signer change a logged warning instead of an
+	// error.  The existing pin is kept either way.
+	PinWarnOnly bool
+	// WriteMeta makes FetchAndUnpackFile record the origin and signer of
+	// every unpacked file in a FetchMeta sidecar; see MetaPath.
+	WriteMeta bool
+	// MaxSize is the largest response body, in bytes, any fetch accepts; a
+	// larger body fails with ErrTooLarge before more than MaxSize bytes are
+	// buffered or written.  The constructors set DefaultMaxSize; 0 disables
+	// the limit.
+	MaxSize int64
+	// CacheBust makes every request bypass HTTP caches between newsgo and
+	// the news server, such as an outproxy's, so a fetch never gets a stale
+	// feed; see CacheBustParam.
+	CacheBust bool
+	// History is how many previous versions of every file FetchAndUnpackFile
+	// unpacks are kept in its HistoryDir, so that a bad upstream publish can
+	// be rolled back locally with RestoreHistory; 0 keeps none.
+	History int
 }
 
 // transportFromGarlic builds an *http.Transport that routes connections
@@ -135,6 +172,7 @@ func NewFetcherFromGarlic(g *onramp.Garlic) *Fetcher {
 			Transport: transportFromGarlic(g),
 			Timeout:   5 * time.Minute,
 		},
+		MaxSize: DefaultMaxSize,
 	}
 }
 
@@ -143,14 +181,15 @@ func NewFetcherFromGarlic(g *onramp.Garlic) *Fetcher {
 // *httptest.Server's client to route requests to a local test server without
 // opening a real I2P connection.
 func NewFetcherFromClient(c *http.Client) *Fetcher {
-	return &Fetcher{client: c}
+	return &Fetcher{client: c, MaxSize: DefaultMaxSize}
 }
 
 // Fetch performs an HTTP GET of url over I2P and returns the raw response body.
 // The caller is responsible for closing any resources; the returned bytes are a
-// complete copy of the response body.
+// complete copy of the response body.  A body larger than f.MaxSize fails with
+// ErrTooLarge.
 func (f *Fetcher) Fetch(url string) ([]byte, error) {
-	resp, err := f.client.Get(url)
+	resp, err := f.get(url)
 	if err != nil {
 		return nil, fmt.Errorf("newsfetch: GET %s: %w", url, err)
 	}
@@ -158,7 +197,14 @@ func (f *Fetcher) Fetch(url string) ([]byte, error) {
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("newsfetch: GET %s: unexpected status %s", url, resp.Status)
 	}
-	data, err := io.ReadAll(resp.Body)
+	body, err := f.limitBody(url, resp)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(body)
+	if errors.Is(err, ErrTooLarge) {
+		return nil, err
+	}
 	if err != nil {
 		return nil, fmt.Errorf("newsfetch: read body %s: %w", url, err)
 	}
@@ -170,23 +216,24 @@ const su3Magic = "I2Psu3"
 
 // verifySignatureAgainstCerts checks whether the cryptographic signature of f
 // is valid under at least one of the trusted X.509 certificates in certs.
-// It returns nil on the first successful match, or a wrapped error if no
-// certificate verifies the signature.
-func verifySignatureAgainstCerts(f *su3.File, certs []*x509.Certificate) error {
+// It returns the first certificate that verifies the signature, or a wrapped
+// error if none does.
+func verifySignatureAgainstCerts(f *su3.File, certs []*x509.Certificate) (*x509.Certificate, error) {
 	var lastErr error
 	for _, c := range certs {
 		if err := f.VerifySignature(c); err == nil {
-			return nil
+			return c, nil
 		} else {
 			lastErr = err
 		}
 	}
-	return fmt.Errorf("newsfetch: signature verification failed: %w", lastErr)
+	return nil, fmt.Errorf("newsfetch: signature verification failed: %w", lastErr)
 }
 
 // VerifyAndUnpack parses the raw su3 bytes, optionally verifies the signature
 // against one of the provided trusted X.509 certificates, and returns the
-// inner content bytes (the Atom XML payload).
+// inner content bytes (the Atom XML payload), decompressed when the su3
+// holds gzipped XML.
 //
 // certs may be nil or empty, in which case signature verification is skipped.
 // When certs are supplied the signature must be valid under at least one of
@@ -200,11 +247,22 @@ func VerifyAndUnpack(data []byte, certs []*x509.Certificate) ([]byte, error) {
 		return nil, fmt.Errorf("newsfetch: unmarshal su3: %w", err)
 	}
 	if len(certs) > 0 {
-		if err := verifySignatureAgainstCerts(f, certs); err != nil {
+		if _, err := verifySignatureAgainstCerts(f, certs); err != nil {
 			return nil, err
 		}
 	}
-	return f.Content, nil
+	if f.FileType != su3FileTypeXMLGZ {
+		return f.Content, nil
+	}
+	r, err := contentReader(bytes.NewReader(f.Content), f.FileType, "su3 content")
+	if err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: decompress su3 content: %w", err)
+	}
+	return content, nil
 }
 
 // FetchAndParse fetches the su3 file at url, verifies it with certs (if any),
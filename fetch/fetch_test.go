@@ -1,10 +1,13 @@
 package newsfetch
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/binary"
 	"encoding/pem"
 	"errors"
 	"math/big"
@@ -208,6 +211,62 @@ func TestFetcher_FetchHTTP_NotFound(t *testing.T) {
 	}
 }
 
+// TestFetcher_MaxSize verifies that Fetch and FetchToFile refuse bodies over
+// MaxSize, whether announced by Content-Length or only streamed, leave an
+// existing destination file alone, and accept a body of exactly MaxSize.
+func TestFetcher_MaxSize(t *testing.T) {
+	const limit = 1024
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/exact":
+			w.Write(make([]byte, limit))
+		case "/large":
+			w.Header().Set("Content-Length", "4096")
+			w.Write(make([]byte, 4096))
+		case "/stream":
+			// Flushing first sends the body chunked, without a
+			// Content-Length to refuse it by.
+			w.(http.Flusher).Flush()
+			for i := 0; i < 8; i++ {
+				w.Write(make([]byte, 512))
+			}
+		}
+	}))
+	defer ts.Close()
+	f := NewFetcherFromClient(ts.Client())
+	if f.MaxSize != DefaultMaxSize {
+		t.Errorf("MaxSize = %d, want DefaultMaxSize", f.MaxSize)
+	}
+	f.MaxSize = limit
+
+	if data, err := f.Fetch(ts.URL + "/exact"); err != nil || len(data) != limit {
+		t.Errorf("Fetch(/exact) = %d bytes, %v; want %d bytes", len(data), err, limit)
+	}
+	dst := filepath.Join(t.TempDir(), "news.su3")
+	if err := os.WriteFile(dst, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{"/large", "/stream"} {
+		if _, err := f.Fetch(ts.URL + path); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Fetch(%s) = %v, want ErrTooLarge", path, err)
+		}
+		if _, _, err := f.FetchToFile(ts.URL+path, dst); !errors.Is(err, ErrTooLarge) {
+			t.Errorf("FetchToFile(%s) = %v, want ErrTooLarge", path, err)
+		}
+		if got, _ := os.ReadFile(dst); string(got) != "previous" {
+			t.Errorf("FetchToFile(%s) replaced the existing file", path)
+		}
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(dst)); len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+
+	f.MaxSize = 0
+	if data, err := f.Fetch(ts.URL + "/stream"); err != nil || len(data) != 4096 {
+		t.Errorf("Fetch(/stream) without a limit = %d bytes, %v", len(data), err)
+	}
+}
+
 // TestNewFetcherFromGarlic_Construction verifies that NewFetcherFromGarlic
 // accepts a caller-supplied *onramp.Garlic and returns a non-nil Fetcher
 // without opening a SAM session (the zero-value Garlic is valid for
@@ -328,3 +387,146 @@ func TestNewFetcher_AfterClose_ErrorWrapsErrGarlicClosed(t *testing.T) {
 		t.Errorf("expected errors.Is(err, ErrGarlicClosed) to be true; got: %v", err)
 	}
 }
+
+// TestVerifyAndUnpackFile_ValidCert verifies that the streaming verifier
+// accepts an su3 signed by a trusted certificate and writes its content.
+func TestVerifyAndUnpackFile_ValidCert(t *testing.T) {
+	want := []byte("<feed>streamed</feed>")
+	data, cert, _ := makeSu3Bytes(t, want)
+	dir := t.TempDir()
+	su3Path := filepath.Join(dir, "news.su3")
+	if err := os.WriteFile(su3Path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "news.atom.xml")
+	n, err := VerifyAndUnpackFile(su3Path, outPath, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("VerifyAndUnpackFile: %v", err)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) || n != int64(len(want)) {
+		t.Errorf("content = %q (%d bytes); want %q", got, n, want)
+	}
+}
+
+// TestVerifyAndUnpack_Gzipped verifies that gzipped XML content, as written
+// by sign --compress, is decompressed by both the in-memory and the streaming
+// unpacker.
+func TestVerifyAndUnpack_Gzipped(t *testing.T) {
+	want := []byte("<feed>compressed</feed>")
+	_, cert, key := makeSu3Bytes(t, []byte("<feed/>"))
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(want)
+	zw.Close()
+	f := su3.New()
+	f.FileType = su3FileTypeXMLGZ
+	f.ContentType = su3.ContentTypeNews
+	f.Content = buf.Bytes()
+	f.SignerID = []byte("test-signer@example.i2p")
+	if err := f.Sign(key); err != nil {
+		t.Fatalf("sign su3: %v", err)
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal su3: %v", err)
+	}
+
+	got, err := VerifyAndUnpack(data, []*x509.Certificate{cert})
+	if err != nil || string(got) != string(want) {
+		t.Errorf("VerifyAndUnpack = %q, %v; want %q", got, err, want)
+	}
+	dir := t.TempDir()
+	su3Path := filepath.Join(dir, "news.su3")
+	if err := os.WriteFile(su3Path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "news.atom.xml")
+	n, err := VerifyAndUnpackFile(su3Path, outPath, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatalf("VerifyAndUnpackFile: %v", err)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != string(want) || n != int64(len(want)) {
+		t.Errorf("unpacked file = %q (%d bytes); want %q", got, n, want)
+	}
+}
+
+// TestVerifyAndUnpackFile_WrongCert verifies that the streaming verifier
+// rejects an su3 signed by an untrusted key and writes no output.
+func TestVerifyAndUnpackFile_WrongCert(t *testing.T) {
+	data, _, _ := makeSu3Bytes(t, []byte("<feed/>"))
+	_, otherCert, _ := makeSu3Bytes(t, []byte("<feed/>"))
+	dir := t.TempDir()
+	su3Path := filepath.Join(dir, "news.su3")
+	if err := os.WriteFile(su3Path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outPath := filepath.Join(dir, "news.atom.xml")
+	if _, err := VerifyAndUnpackFile(su3Path, outPath, []*x509.Certificate{otherCert}); err == nil {
+		t.Fatal("expected verification error for wrong certificate, got nil")
+	}
+	if _, err := os.Stat(outPath); err == nil {
+		t.Error("output written despite failed verification")
+	}
+}
+
+// TestVerifyAndUnpackFile_Tampered verifies that a modified content byte is
+// detected by the streamed digest.
+func TestVerifyAndUnpackFile_Tampered(t *testing.T) {
+	data, cert, _ := makeSu3Bytes(t, []byte("<feed>original</feed>"))
+	data[len(data)-int(binary.BigEndian.Uint16(data[10:12]))-2] ^= 0xFF // flip a content byte
+	dir := t.TempDir()
+	su3Path := filepath.Join(dir, "news.su3")
+	if err := os.WriteFile(su3Path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyAndUnpackFile(su3Path, filepath.Join(dir, "out.xml"), []*x509.Certificate{cert}); err == nil {
+		t.Fatal("expected verification error for tampered content, got nil")
+	}
+}
+
+// TestVerifyAndUnpackFile_Truncated verifies that a file shorter than its
+// header describes is rejected before any verification is attempted.
+func TestVerifyAndUnpackFile_Truncated(t *testing.T) {
+	data, _, _ := makeSu3Bytes(t, []byte("<feed/>"))
+	dir := t.TempDir()
+	su3Path := filepath.Join(dir, "news.su3")
+	if err := os.WriteFile(su3Path, data[:len(data)-10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyAndUnpackFile(su3Path, filepath.Join(dir, "out.xml"), nil); err == nil {
+		t.Fatal("expected error for truncated su3, got nil")
+	}
+}
+
+// TestFetcher_FetchAndUnpackFile exercises the streaming pipeline end to end
+// against a plain HTTP test server and checks no temporary files are left.
+func TestFetcher_FetchAndUnpackFile(t *testing.T) {
+	want := []byte("<feed>stream-pipeline</feed>")
+	su3Data, cert, _ := makeSu3Bytes(t, want)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(su3Data)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "news.atom.xml")
+	f := NewFetcherFromClient(ts.Client())
+	if _, err := f.FetchAndUnpackFile(ts.URL+"/news.su3", outPath, []*x509.Certificate{cert}); err != nil {
+		t.Fatalf("FetchAndUnpackFile: %v", err)
+	}
+	got, err := os.ReadFile(outPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("content = %q; want %q", got, want)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("outdir contains %d entries; want only news.atom.xml", len(entries))
+	}
+}
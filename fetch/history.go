@@ -0,0 +1,266 @@
+package newsfetch
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrRolledBack is returned by FetchAndUnpackFile when the upstream still
+// serves a version that was rolled back from with RestoreHistory.  The
+// restored file is kept until the upstream publishes something else.
+var ErrRolledBack = errors.New("upstream still serves the version rolled back from")
+
+// historyStamp is the time layout that starts the name of every version in a
+// history directory, so that names sort oldest first.
+const historyStamp = "20060102T150405.000Z"
+
+// rejectedFile is the name of the list of rolled-back digests in a history
+// directory.
+const rejectedFile = "rejected"
+
+// HistoryVersion is one previous version of an unpacked file, kept by a
+// Fetcher with History set.
+type HistoryVersion struct {
+	// Name is the file name of the version in HistoryDir: its fetch time
+	// and the start of its digest, e.g.
+	// "20250601T000000.000Z-0123456789abcdef.atom.xml".
+	Name string
+	// Path is the path of the version.
+	Path string
+	// Fetched is when the version was fetched.
+	Fetched time.Time
+	// SHA256 is the leading 16 hex digits of the digest of the version.
+	SHA256 string
+	// Current reports whether the unpacked file holds this version.
+	Current bool
+}
+
+// HistoryDir returns the directory the previous versions of the unpacked file
+// at path are kept in: .history/<name> next to it, so that serve, which hides
+// dotfiles by default, does not publish them.
+func HistoryDir(path string) string {
+	return filepath.Join(filepath.Dir(path), ".history", filepath.Base(path))
+}
+
+// historyExt returns the extension of the unpacked file at path that history
+// versions keep, ".atom.xml" for a feed, so that MetaPath names their
+// sidecars as it does the file's.
+func historyExt(path string) string {
+	if strings.HasSuffix(path, ".atom.xml") {
+		return ".atom.xml"
+	}
+	return filepath.Ext(path)
+}
+
+// ListHistory returns the versions kept of the unpacked file at path, newest
+// first.  A file without history has none.
+func ListHistory(path string) ([]HistoryVersion, error) {
+	dir := HistoryDir(path)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: read history: %w", err)
+	}
+	current, err := fileSHA256(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("newsfetch: read history: %w", err)
+	}
+	ext := historyExt(path)
+	var versions []HistoryVersion
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		stamp, sum, ok := strings.Cut(strings.TrimSuffix(name, ext), "-")
+		t, err := time.Parse(historyStamp, stamp)
+		if !ok || err != nil {
+			continue
+		}
+		versions = append(versions, HistoryVersion{
+			Name:    name,
+			Path:    filepath.Join(dir, name),
+			Fetched: t,
+			SHA256:  sum,
+			Current: current != "" && strings.HasPrefix(current, sum),
+		})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].Name > versions[j].Name })
+	return versions, nil
+}
+
+// recordHistory copies the unpacked file at path, whose hex digest is sum,
+// into its history as fetched at t, along with its FetchMeta sidecar if any,
+// and prunes the history to its keep newest versions.  A file identical to
+// the newest version is not recorded again, so refreshes of an unchanged
+// feed do not push older versions out.
+func recordHistory(path, sum string, t time.Time, keep int) error {
+	versions, err := ListHistory(path)
+	if err != nil {
+		return err
+	}
+	short := sum[:16]
+	if len(versions) == 0 || versions[0].SHA256 != short {
+		dir := HistoryDir(path)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("newsfetch: create history: %w", err)
+		}
+		// Names must sort in fetch order even for fetches within a
+		// millisecond of each other.
+		t = t.UTC().Truncate(time.Millisecond)
+		if len(versions) > 0 && !t.After(versions[0].Fetched) {
+			t = versions[0].Fetched.Add(time.Millisecond)
+		}
+		name := t.Format(historyStamp) + "-" + short + historyExt(path)
+		dst := filepath.Join(dir, name)
+		if err := copyFileAtomic(dst, path); err != nil {
+			return fmt.Errorf("newsfetch: record history: %w", err)
+		}
+		if err := copyFileAtomic(MetaPath(dst), MetaPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("newsfetch: record history: %w", err)
+		}
+		versions = append([]HistoryVersion{{Name: name, Path: dst}}, versions...)
+	}
+	for _, v := range versions[min(keep, len(versions)):] {
+		if err := os.Remove(v.Path); err != nil {
+			return fmt.Errorf("newsfetch: prune history: %w", err)
+		}
+		os.Remove(MetaPath(v.Path))
+	}
+	return nil
+}
+
+// RestoreHistory replaces the unpacked file at path with its version named
+// name, as listed by ListHistory, and its FetchMeta sidecar with that of the
+// version.  The digest of the file replaced is added to the rejected list of
+// the history, so that a Fetcher with History set refuses it with
+// ErrRolledBack rather than putting it back at the next refresh.
+func RestoreHistory(path, name string) (HistoryVersion, error) {
+	versions, err := ListHistory(path)
+	if err != nil {
+		return HistoryVersion{}, err
+	}
+	var v HistoryVersion
+	for _, cand := range versions {
+		if cand.Name == name {
+			v = cand
+		}
+	}
+	if v.Name == "" {
+		return v, fmt.Errorf("newsfetch: no version %s in %s", name, HistoryDir(path))
+	}
+	current, err := fileSHA256(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return v, fmt.Errorf("newsfetch: restore: %w", err)
+	}
+	rejected, err := readRejected(path)
+	if err != nil {
+		return v, err
+	}
+	if current != "" && !strings.HasPrefix(current, v.SHA256) {
+		rejected[current] = true
+	}
+	for sum := range rejected {
+		if strings.HasPrefix(sum, v.SHA256) {
+			delete(rejected, sum)
+		}
+	}
+	if err := writeRejected(path, rejected); err != nil {
+		return v, err
+	}
+	if err := copyFileAtomic(path, v.Path); err != nil {
+		return v, fmt.Errorf("newsfetch: restore: %w", err)
+	}
+	err = copyFileAtomic(MetaPath(path), MetaPath(v.Path))
+	if errors.Is(err, os.ErrNotExist) {
+		// A sidecar describing the replaced file would now be wrong.
+		err = os.Remove(MetaPath(path))
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	}
+	if err != nil {
+		return v, fmt.Errorf("newsfetch: restore: %w", err)
+	}
+	return v, nil
+}
+
+// readRejected returns the set of rolled-back digests of the unpacked file at
+// path, kept one per line in the history directory.
+func readRejected(path string) (map[string]bool, error) {
+	rejected := make(map[string]bool)
+	file, err := os.Open(filepath.Join(HistoryDir(path), rejectedFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return rejected, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: read rejected digests: %w", err)
+	}
+	defer file.Close()
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		if sum := strings.TrimSpace(sc.Text()); sum != "" {
+			rejected[sum] = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("newsfetch: read rejected digests: %w", err)
+	}
+	return rejected, nil
+}
+
+// writeRejected replaces the rolled-back digests of the unpacked file at path
+// with rejected, removing the list when it is empty.
+func writeRejected(path string, rejected map[string]bool) error {
+	list := filepath.Join(HistoryDir(path), rejectedFile)
+	if len(rejected) == 0 {
+		if err := os.Remove(list); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("newsfetch: write rejected digests: %w", err)
+		}
+		return nil
+	}
+	sums := make([]string, 0, len(rejected))
+	for sum := range rejected {
+		sums = append(sums, sum)
+	}
+	sort.Strings(sums)
+	if _, err := writeFileAtomic(list, strings.NewReader(strings.Join(sums, "\n")+"\n")); err != nil {
+		return fmt.Errorf("newsfetch: write rejected digests: %w", err)
+	}
+	return nil
+}
+
+// copyFileAtomic copies the file at src to dst; see writeFileAtomic.
+func copyFileAtomic(dst, src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = writeFileAtomic(dst, file)
+	return err
+}
+
+// fileSHA256 returns the hex SHA-256 digest of the file at path.
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
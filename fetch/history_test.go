@@ -0,0 +1,73 @@
+package newsfetch
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFetchAndUnpackFile_History verifies that changed feeds are recorded and
+// pruned to the configured number of versions, that a restored version is
+// kept while the upstream serves the one rolled back from, and that a new
+// upstream publish replaces it again.
+func TestFetchAndUnpackFile_History(t *testing.T) {
+	var serve []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(serve)
+	}))
+	defer ts.Close()
+	f := NewFetcherFromClient(ts.Client())
+	f.WriteMeta = true
+	f.History = 2
+	outPath := filepath.Join(t.TempDir(), "news.atom.xml")
+	publish := func(content string) error {
+		t.Helper()
+		data, cert, _ := makeSu3Bytes(t, []byte(content))
+		serve = data
+		_, err := f.FetchAndUnpackFile(ts.URL+"/news.su3", outPath, []*x509.Certificate{cert})
+		return err
+	}
+	holds := func(want string) {
+		t.Helper()
+		if got, err := os.ReadFile(outPath); err != nil || string(got) != want {
+			t.Errorf("feed = %q, %v; want %q", got, err, want)
+		}
+	}
+
+	for _, content := range []string{"<feed>1</feed>", "<feed>2</feed>", "<feed>2</feed>", "<feed>3</feed>"} {
+		if err := publish(content); err != nil {
+			t.Fatalf("FetchAndUnpackFile of %s: %v", content, err)
+		}
+	}
+	versions, err := ListHistory(outPath)
+	if err != nil {
+		t.Fatalf("ListHistory: %v", err)
+	}
+	if len(versions) != 2 || !versions[0].Current || versions[1].Current {
+		t.Fatalf("history = %+v, want versions 3 (current) and 2", versions)
+	}
+	if _, err := ReadFetchMeta(versions[1].Path); err != nil {
+		t.Errorf("version sidecar: %v", err)
+	}
+
+	if _, err := RestoreHistory(outPath, versions[1].Name); err != nil {
+		t.Fatalf("RestoreHistory: %v", err)
+	}
+	holds("<feed>2</feed>")
+	if err := publish("<feed>3</feed>"); !errors.Is(err, ErrRolledBack) {
+		t.Errorf("refetch of the rolled-back version = %v, want ErrRolledBack", err)
+	}
+	holds("<feed>2</feed>")
+	if err := publish("<feed>4</feed>"); err != nil {
+		t.Fatalf("FetchAndUnpackFile of a new publish: %v", err)
+	}
+	holds("<feed>4</feed>")
+
+	if _, err := RestoreHistory(outPath, "20250601T000000.000Z-0123456789abcdef.atom.xml"); err == nil {
+		t.Error("RestoreHistory accepted an unknown version")
+	}
+}
@@ -0,0 +1,57 @@
+package newsfetch
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultMaxSize is the MaxSize of the Fetchers the constructors return:
+// 64 MiB, far above any news feed or router update.
+const DefaultMaxSize = 64 << 20
+
+// ErrTooLarge is returned when a response body is larger than the
+// Fetcher's MaxSize.  Callers may detect it with errors.Is.
+var ErrTooLarge = errors.New("response body exceeds the maximum size")
+
+// limitBody returns the body of resp limited to f.MaxSize bytes.  A body
+// whose Content-Length already exceeds the limit is refused before anything
+// is read; otherwise reading past the limit fails with ErrTooLarge, so a
+// body without a truthful Content-Length is cut off too.
+func (f *Fetcher) limitBody(url string, resp *http.Response) (io.Reader, error) {
+	if f.MaxSize <= 0 {
+		return resp.Body, nil
+	}
+	err := fmt.Errorf("newsfetch: GET %s: more than %d bytes: %w", url, f.MaxSize, ErrTooLarge)
+	if resp.ContentLength > f.MaxSize {
+		return nil, err
+	}
+	return &maxSizeReader{r: resp.Body, remaining: f.MaxSize, err: err}, nil
+}
+
+// maxSizeReader reads from r until more than remaining bytes arrive, and
+// then fails with err.
+type maxSizeReader struct {
+	r         io.Reader
+	remaining int64
+	err       error
+}
+
+func (m *maxSizeReader) Read(p []byte) (int, error) {
+	if m.remaining < 0 {
+		return 0, m.err
+	}
+	// Read one byte more than allowed to tell a body of exactly the limit
+	// from a longer one.
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+	n, err := m.r.Read(p)
+	if int64(n) > m.remaining {
+		n, m.remaining = int(m.remaining), -1
+		return n, m.err
+	}
+	m.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,73 @@
+package newsfetch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// FetchMeta records where an unpacked file came from and who signed it, so
+// that a mirror audit can tell who signed what is being served without the
+// su3 files.  It is written next to the file as a JSON sidecar; see MetaPath.
+type FetchMeta struct {
+	// URL is the su3 URL the file was unpacked from.
+	URL string `json:"url"`
+	// Fetched is when the download started.
+	Fetched time.Time `json:"fetched"`
+	// SignerID is the signer ID embedded in the su3.  It is only a claim
+	// unless Verified is set.
+	SignerID string `json:"signer_id"`
+	// Fingerprint is the CertFingerprint of the trusted certificate that
+	// verified the signature; empty when the su3 was not verified.
+	Fingerprint string `json:"fingerprint,omitempty"`
+	// Verified reports whether the signature was checked against trusted
+	// certificates.
+	Verified bool `json:"verified"`
+	// SignerUnlisted reports that the feed lists the signers it may be
+	// signed by and SignerID is not among them; see CheckListedSigner.
+	SignerUnlisted bool `json:"signer_unlisted,omitempty"`
+	// Version is the version field of the su3: the signing time, in seconds
+	// since the epoch, for news files.
+	Version string `json:"version"`
+	// SHA256 is the hex digest of the unpacked file, Su3SHA256 that of the
+	// su3 it was unpacked from.
+	SHA256    string `json:"sha256"`
+	Su3SHA256 string `json:"su3_sha256"`
+}
+
+// MetaPath returns the sidecar path for the unpacked file at path: a
+// ".atom.xml" suffix replaced by ".meta.json", so news_de.atom.xml is
+// described by news_de.meta.json.  Any other name gets ".meta.json" appended.
+// The name differs from the build metadata sidecar (news_de.json), so a fetch
+// into a build directory leaves that in place.
+func MetaPath(path string) string {
+	return strings.TrimSuffix(path, ".atom.xml") + ".meta.json"
+}
+
+// WriteFetchMeta writes m to MetaPath(path).
+func WriteFetchMeta(path string, m FetchMeta) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("newsfetch: encode metadata: %w", err)
+	}
+	if _, err := writeFileAtomic(MetaPath(path), bytes.NewReader(append(data, '\n'))); err != nil {
+		return fmt.Errorf("newsfetch: write metadata: %w", err)
+	}
+	return nil
+}
+
+// ReadFetchMeta reads the sidecar of the unpacked file at path.
+func ReadFetchMeta(path string) (FetchMeta, error) {
+	var m FetchMeta
+	data, err := os.ReadFile(MetaPath(path))
+	if err != nil {
+		return m, fmt.Errorf("newsfetch: read metadata: %w", err)
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, fmt.Errorf("newsfetch: parse metadata %s: %w", MetaPath(path), err)
+	}
+	return m, nil
+}
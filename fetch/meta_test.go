@@ -0,0 +1,79 @@
+package newsfetch
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFetchAndUnpackFile_WriteMeta verifies that WriteMeta records the URL,
+// signer, certificate fingerprint, version and digests of a verified fetch,
+// and that an unverified fetch is recorded as such.
+func TestFetchAndUnpackFile_WriteMeta(t *testing.T) {
+	content := []byte("<feed>meta</feed>")
+	su3Data, cert, _ := makeSu3Bytes(t, content)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(su3Data)
+	}))
+	defer ts.Close()
+
+	outPath := filepath.Join(t.TempDir(), "news.atom.xml")
+	f := NewFetcherFromClient(ts.Client())
+	f.WriteMeta = true
+	url := ts.URL + "/news.su3"
+	if _, err := f.FetchAndUnpackFile(url, outPath, []*x509.Certificate{cert}); err != nil {
+		t.Fatalf("FetchAndUnpackFile: %v", err)
+	}
+	if got := MetaPath(outPath); filepath.Base(got) != "news.meta.json" {
+		t.Errorf("MetaPath = %s, want news.meta.json", got)
+	}
+	m, err := ReadFetchMeta(outPath)
+	if err != nil {
+		t.Fatalf("ReadFetchMeta: %v", err)
+	}
+	want := FetchMeta{
+		URL:         url,
+		Fetched:     m.Fetched,
+		SignerID:    "test-signer@example.i2p",
+		Fingerprint: CertFingerprint(cert),
+		Verified:    true,
+		Version:     "", // makeSu3Bytes leaves the version empty; the padding is trimmed
+		SHA256:      fmt.Sprintf("%x", sha256.Sum256(content)),
+		Su3SHA256:   fmt.Sprintf("%x", sha256.Sum256(su3Data)),
+	}
+	if m != want {
+		t.Errorf("metadata = %+v\nwant %+v", m, want)
+	}
+	if m.Fetched.IsZero() {
+		t.Errorf("metadata lacks the fetch time: %+v", m)
+	}
+
+	if _, err := f.FetchAndUnpackFile(url, outPath, nil); err != nil {
+		t.Fatalf("FetchAndUnpackFile without certificates: %v", err)
+	}
+	if m, err := ReadFetchMeta(outPath); err != nil || m.Verified || m.Fingerprint != "" || m.SignerID == "" {
+		t.Errorf("unverified metadata = %+v, %v; want the claimed signer only", m, err)
+	}
+}
+
+// TestFetchAndUnpackFile_NoMetaByDefault verifies that no sidecar is written
+// unless WriteMeta is set.
+func TestFetchAndUnpackFile_NoMetaByDefault(t *testing.T) {
+	su3Data, _, _ := makeSu3Bytes(t, []byte("<feed/>"))
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(su3Data)
+	}))
+	defer ts.Close()
+	outPath := filepath.Join(t.TempDir(), "news.atom.xml")
+	if _, err := NewFetcherFromClient(ts.Client()).FetchAndUnpackFile(ts.URL+"/news.su3", outPath, nil); err != nil {
+		t.Fatalf("FetchAndUnpackFile: %v", err)
+	}
+	if _, err := os.Stat(MetaPath(outPath)); !os.IsNotExist(err) {
+		t.Errorf("sidecar written without WriteMeta: %v", err)
+	}
+}
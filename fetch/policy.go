@@ -0,0 +1,198 @@
+package newsfetch
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TransportRule assigns Transport to the hosts matching Pattern: an exact
+// host name, "*.example.org", which matches example.org and all of its
+// subdomains, or "*", which matches every host.
+type TransportRule struct {
+	Pattern   string
+	Transport string
+}
+
+// TransportPolicy chooses the transport of every request a policy Fetcher
+// makes, redirects included.  The first rule matching the host wins; a host
+// matching none is fetched over I2P when it is an .i2p host and over Default
+// otherwise.  An empty Default refuses such hosts, so no request leaves
+// through the clearnet unless a rule or Default says so.
+type TransportPolicy struct {
+	Rules   []TransportRule
+	Default string
+}
+
+// ParseTransportRules parses "pattern=transport" specs such as
+// "*.example.org=outproxy" or "*=clearnet".
+func ParseTransportRules(specs []string) ([]TransportRule, error) {
+	rules := make([]TransportRule, 0, len(specs))
+	for _, spec := range specs {
+		pattern, transport, ok := strings.Cut(spec, "=")
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		transport = strings.TrimSpace(transport)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("newsfetch: %q is not pattern=transport", spec)
+		}
+		switch transport {
+		case TransportI2P, TransportOutproxy, TransportClearnet:
+		default:
+			return nil, fmt.Errorf("newsfetch: %q: unknown transport %q (want %s, %s or %s)", spec, transport, TransportI2P, TransportOutproxy, TransportClearnet)
+		}
+		rules = append(rules, TransportRule{Pattern: pattern, Transport: transport})
+	}
+	return rules, nil
+}
+
+// TransportFor returns the transport p assigns to host, or "" when p
+// refuses it.
+func (p TransportPolicy) TransportFor(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, r := range p.Rules {
+		if matchHost(host, r.Pattern) {
+			return r.Transport
+		}
+	}
+	if strings.HasSuffix(host, ".i2p") {
+		return TransportI2P
+	}
+	return p.Default
+}
+
+// ValidateURL checks rawURL with the package-level ValidateURL for the
+// transport p assigns to its host.  A host p refuses is reported as one that
+// cannot be reached over I2P.
+func (p TransportPolicy) ValidateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("newsfetch: %w", err)
+	}
+	transport := p.TransportFor(u.Hostname())
+	if transport == "" {
+		transport = TransportI2P
+	}
+	return ValidateURL(rawURL, transport)
+}
+
+// matchHost reports whether host matches pattern; see TransportRule.
+func matchHost(host, pattern string) bool {
+	if pattern == "*" {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return host == suffix || strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// policyTransport is the http.RoundTripper of a policy Fetcher.  The I2P
+// transport, and an outproxy that is itself an .i2p host, use the shared
+// Garlic session, which is opened on the first request that needs it.
+type policyTransport struct {
+	policy   TransportPolicy
+	samAddr  string
+	outproxy *url.URL
+	clearnet *http.Transport
+
+	garlicOnce sync.Once
+	garlic     *http.Transport
+	garlicErr  error
+
+	proxiedOnce sync.Once
+	proxied     *http.Transport
+	proxiedErr  error
+}
+
+// NewPolicyFetcher returns a Fetcher that fetches each URL over the
+// transport policy assigns to its host.  outproxy is the URL of the HTTP
+// proxy TransportOutproxy goes through, such as a local I2PTunnel HTTP
+// client ("http://127.0.0.1:4444") or an outproxy destination
+// ("http://exit.example.i2p"), which is then reached over SAM.  samAddr is
+// as for NewFetcher; no SAM session is opened until a request needs one.
+func NewPolicyFetcher(samAddr, outproxy string, policy TransportPolicy) (*Fetcher, error) {
+	t := &policyTransport{
+		policy:   policy,
+		samAddr:  samAddr,
+		clearnet: http.DefaultTransport.(*http.Transport).Clone(),
+	}
+	if outproxy != "" {
+		u, err := url.Parse(outproxy)
+		if err != nil {
+			return nil, fmt.Errorf("newsfetch: outproxy: %w", err)
+		}
+		if u.Scheme != "http" || u.Host == "" {
+			return nil, fmt.Errorf("newsfetch: outproxy %q must be an http://host:port URL", outproxy)
+		}
+		t.outproxy = u
+	}
+	return &Fetcher{
+		client:  &http.Client{Transport: t, Timeout: 5 * time.Minute},
+		MaxSize: DefaultMaxSize,
+	}, nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *policyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	transport := t.policy.TransportFor(host)
+	if transport == "" {
+		return nil, fmt.Errorf("newsfetch: %s: no transport allowed for %s", req.URL, host)
+	}
+	if err := ValidateURL(req.URL.String(), transport); err != nil {
+		return nil, err
+	}
+	var rt http.RoundTripper
+	switch transport {
+	case TransportClearnet:
+		rt = t.clearnet
+	case TransportOutproxy:
+		if t.outproxy == nil {
+			return nil, fmt.Errorf("newsfetch: %s: %s is fetched through the outproxy, but none is configured", req.URL, host)
+		}
+		t.proxiedOnce.Do(t.initProxied)
+		if t.proxiedErr != nil {
+			return nil, t.proxiedErr
+		}
+		rt = t.proxied
+	default:
+		t.garlicOnce.Do(t.initGarlic)
+		if t.garlicErr != nil {
+			return nil, t.garlicErr
+		}
+		rt = t.garlic
+	}
+	return rt.RoundTrip(req)
+}
+
+// initGarlic builds the I2P transport on the shared Garlic session.
+func (t *policyTransport) initGarlic() {
+	g, err := initSharedGarlic(t.samAddr)
+	if err != nil {
+		t.garlicErr = fmt.Errorf("newsfetch: init garlic: %w", err)
+		return
+	}
+	t.garlic = transportFromGarlic(g)
+}
+
+// initProxied builds the outproxy transport.  An outproxy on an .i2p host is
+// dialled over the I2P transport, any other one directly, which is how a
+// local I2PTunnel HTTP client is reached.
+func (t *policyTransport) initProxied() {
+	if strings.HasSuffix(strings.ToLower(t.outproxy.Hostname()), ".i2p") {
+		t.garlicOnce.Do(t.initGarlic)
+		if t.garlicErr != nil {
+			t.proxiedErr = t.garlicErr
+			return
+		}
+		t.proxied = t.garlic.Clone()
+	} else {
+		t.proxied = t.clearnet.Clone()
+		t.proxied.DialContext = (&net.Dialer{Timeout: 30 * time.Second}).DialContext
+	}
+	t.proxied.Proxy = http.ProxyURL(t.outproxy)
+}
@@ -0,0 +1,103 @@
+package newsfetch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTransportPolicy_TransportFor covers rule order, wildcard patterns, and
+// the fallback to I2P for .i2p hosts and to Default for the rest.
+func TestTransportPolicy_TransportFor(t *testing.T) {
+	rules, err := ParseTransportRules([]string{"direct.example.org=clearnet", "*.Example.org=outproxy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := TransportPolicy{Rules: rules}
+	for host, want := range map[string]string{
+		"direct.example.org": TransportClearnet,
+		"example.org":        TransportOutproxy,
+		"www.example.org.":   TransportOutproxy,
+		"notexample.org":     "",
+		"stats.i2p":          TransportI2P,
+	} {
+		if got := p.TransportFor(host); got != want {
+			t.Errorf("TransportFor(%q) = %q, want %q", host, got, want)
+		}
+	}
+	p.Default = TransportOutproxy
+	if got := p.TransportFor("geti2p.net"); got != TransportOutproxy {
+		t.Errorf("TransportFor with Default = %q, want %q", got, TransportOutproxy)
+	}
+}
+
+// TestParseTransportRules_Invalid rejects malformed specs and unknown
+// transports.
+func TestParseTransportRules_Invalid(t *testing.T) {
+	for _, spec := range []string{"example.org", "=clearnet", "example.org=tor"} {
+		if _, err := ParseTransportRules([]string{spec}); err == nil {
+			t.Errorf("ParseTransportRules(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+// TestPolicyFetcher_Outproxy checks that an outproxy host is fetched through
+// the configured proxy rather than directly, and that a host the policy
+// refuses is never contacted.
+func TestPolicyFetcher_Outproxy(t *testing.T) {
+	var proxied string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		io.WriteString(w, "via proxy")
+	}))
+	defer proxy.Close()
+
+	rules, err := ParseTransportRules([]string{"*.example.org=outproxy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := NewPolicyFetcher("", proxy.URL, TransportPolicy{Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Fetch("http://news.example.org/news.su3")
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if string(data) != "via proxy" || proxied != "http://news.example.org/news.su3" {
+		t.Errorf("got %q via %q, want the proxy to fetch the absolute URL", data, proxied)
+	}
+
+	proxied = ""
+	if _, err := f.Fetch("http://other.example.com/news.su3"); err == nil || !strings.Contains(err.Error(), "no transport allowed") {
+		t.Errorf("Fetch of a refused host: err = %v, want no transport allowed", err)
+	}
+	if proxied != "" {
+		t.Errorf("refused host reached the proxy as %q", proxied)
+	}
+}
+
+// TestPolicyFetcher_Clearnet checks that a clearnet rule fetches directly.
+func TestPolicyFetcher_Clearnet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "direct")
+	}))
+	defer ts.Close()
+
+	f, err := NewPolicyFetcher("", "", TransportPolicy{Default: TransportClearnet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := f.Fetch(ts.URL)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if string(data) != "direct" {
+		t.Errorf("Fetch = %q, want %q", data, "direct")
+	}
+	if _, err := NewPolicyFetcher("", "socks5://127.0.0.1:4447", TransportPolicy{}); err == nil {
+		t.Error("NewPolicyFetcher accepted a non-http outproxy")
+	}
+}
@@ -0,0 +1,53 @@
+package newsfetch
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// CacheBustParam is the query parameter a Fetcher with CacheBust set adds to
+// every request, with a value that differs each time.
+const CacheBustParam = "nocache"
+
+// SetLang returns rawURL with its lang query parameter set to lang, keeping
+// the other parameters.  Routers fetch news.su3?lang=de so that the news
+// server can count its readers by language; a mirror fetching on behalf of a
+// locale sets it too, so the counts of the upstream stay meaningful.
+func SetLang(rawURL, lang string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("newsfetch: %w", err)
+	}
+	q := u.Query()
+	q.Set("lang", lang)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// get performs an HTTP GET of rawURL.  With f.CacheBust set the request asks
+// every cache on the way not to answer from a stored copy: it carries
+// Cache-Control and Pragma no-cache headers, which HTTP proxies honour, and a
+// fresh CacheBustParam, which defeats the ones that ignore them.  The
+// parameter is not part of the URL as far as pins and metadata go.
+func (f *Fetcher) get(rawURL string) (*http.Response, error) {
+	if !f.CacheBust {
+		return f.client.Get(rawURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set(CacheBustParam, strconv.FormatInt(time.Now().UnixNano(), 36))
+	u.RawQuery = q.Encode()
+	rq, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	rq.Header.Set("Cache-Control", "no-cache")
+	rq.Header.Set("Pragma", "no-cache")
+	return f.client.Do(rq)
+}
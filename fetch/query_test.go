@@ -0,0 +1,54 @@
+package newsfetch
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestFetcher_CacheBust checks that CacheBust sends no-cache headers and a
+// fresh CacheBustParam while keeping the query of the URL.
+func TestFetcher_CacheBust(t *testing.T) {
+	var seen []*http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	f := NewFetcherFromClient(ts.Client())
+	if _, err := f.Fetch(ts.URL + "/news.su3?lang=de"); err != nil {
+		t.Fatal(err)
+	}
+	if r := seen[0]; r.URL.Query().Has(CacheBustParam) || r.Header.Get("Cache-Control") != "" {
+		t.Errorf("request without CacheBust = %s %v, want it unchanged", r.URL, r.Header)
+	}
+
+	f.CacheBust = true
+	for i := 0; i < 2; i++ {
+		if _, err := f.Fetch(ts.URL + "/news.su3?lang=de"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := seen[1], seen[2]
+	if first.URL.Query().Get("lang") != "de" || first.Header.Get("Cache-Control") != "no-cache" || first.Header.Get("Pragma") != "no-cache" {
+		t.Errorf("cache-busted request = %s %v, want lang=de and no-cache headers", first.URL, first.Header)
+	}
+	if a, b := first.URL.Query().Get(CacheBustParam), second.URL.Query().Get(CacheBustParam); a == "" || a == b {
+		t.Errorf("%s = %q then %q, want a fresh value each time", CacheBustParam, a, b)
+	}
+}
+
+// TestSetLang checks that SetLang sets or replaces the lang parameter.
+func TestSetLang(t *testing.T) {
+	for in, want := range map[string]string{
+		"http://news.i2p/news.su3":              "http://news.i2p/news.su3?lang=pt_BR",
+		"http://news.i2p/news.su3?lang=en&v=2":  "http://news.i2p/news.su3?lang=pt_BR&v=2",
+		"http://news.i2p/news.su3?v=2#fragment": "http://news.i2p/news.su3?lang=pt_BR&v=2#fragment",
+	} {
+		if got, err := SetLang(in, "pt_BR"); err != nil || got != want {
+			t.Errorf("SetLang(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package newsfetch
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ErrSignerNotListed is wrapped by the error CheckListedSigner returns when
+// a feed lists the signer IDs it may be signed by and the su3 it came in was
+// signed by another.  Callers may detect it with errors.Is.
+var ErrSignerNotListed = errors.New("su3 signer not listed by the feed")
+
+// ParseSigners returns the signer IDs the <i2p:signer id> elements of the
+// <i2p:release> elements of the Atom feed atom list, in feed order.  A feed
+// that lists none yields no signers and no error.
+func ParseSigners(atom []byte) ([]string, error) {
+	return parseSigners(bytes.NewReader(atom))
+}
+
+// parseSigners implements ParseSigners on a stream, so an unpacked file is
+// not read into memory to be checked.
+func parseSigners(r io.Reader) ([]string, error) {
+	var feed struct {
+		Releases []struct {
+			Signers []struct {
+				ID string `xml:"id,attr"`
+			} `xml:"http://geti2p.net/en/docs/spec/updates signer"`
+		} `xml:"http://geti2p.net/en/docs/spec/updates release"`
+	}
+	if err := xml.NewDecoder(r).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("newsfetch: parse feed: %w", err)
+	}
+	var signers []string
+	for _, rel := range feed.Releases {
+		for _, s := range rel.Signers {
+			if id := strings.TrimSpace(s.ID); id != "" {
+				signers = append(signers, id)
+			}
+		}
+	}
+	return signers, nil
+}
+
+// CheckListedSigner checks signerID, the signer ID of an su3, against the
+// signers the feed it carried lists; see ParseSigners.  A feed that lists
+// none passes.  The list is only as trustworthy as the feed, so this adds to
+// signature verification rather than replacing it: a key compromised for one
+// signer still cannot publish as itself where the feed names only another.
+func CheckListedSigner(signers []string, signerID string) error {
+	if len(signers) == 0 {
+		return nil
+	}
+	for _, s := range signers {
+		if s == signerID {
+			return nil
+		}
+	}
+	return fmt.Errorf("newsfetch: signed by %q, the feed lists %s: %w", signerID, strings.Join(signers, ", "), ErrSignerNotListed)
+}
+
+// checkFeedSigner cross-checks the signer of the su3 the file at path was
+// unpacked from against the signers the file lists.  A file that is not a
+// feed is not checked.
+func checkFeedSigner(path, signerID string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("newsfetch: open %s: %w", path, err)
+	}
+	defer file.Close()
+	signers, err := parseSigners(file)
+	if err != nil {
+		return nil
+	}
+	return CheckListedSigner(signers, signerID)
+}
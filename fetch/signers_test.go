@@ -0,0 +1,78 @@
+package newsfetch
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestParseSigners verifies that the <i2p:signer> IDs of a release are
+// returned in order and that a feed without them lists none.
+func TestParseSigners(t *testing.T) {
+	atom := []byte(`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:i2p="http://geti2p.net/en/docs/spec/updates">` +
+		`<i2p:release date="2022-11-21"><i2p:version>2.0.0</i2p:version>` +
+		`<i2p:signer id="zzz@mail.i2p"/><i2p:signer id=" idk@mail.i2p "/><i2p:signer id=""/></i2p:release></feed>`)
+	got, err := ParseSigners(atom)
+	if err != nil {
+		t.Fatalf("ParseSigners: %v", err)
+	}
+	if want := []string{"zzz@mail.i2p", "idk@mail.i2p"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSigners = %q, want %q", got, want)
+	}
+	none, err := ParseSigners([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"/>`))
+	if err != nil || none != nil {
+		t.Errorf("ParseSigners of a feed without signers = %q, %v; want none", none, err)
+	}
+	if err := CheckListedSigner(nil, "anyone@mail.i2p"); err != nil {
+		t.Errorf("CheckListedSigner without a list: %v", err)
+	}
+	if err := CheckListedSigner(none, "mallory@mail.i2p"); err != nil {
+		t.Errorf("CheckListedSigner with an empty list: %v", err)
+	}
+	if err := CheckListedSigner([]string{"zzz@mail.i2p"}, "mallory@mail.i2p"); !errors.Is(err, ErrSignerNotListed) {
+		t.Errorf("CheckListedSigner of an unlisted signer = %v, want ErrSignerNotListed", err)
+	}
+}
+
+// TestFetchAndUnpackFile_SignerUnlisted verifies that a feed signed by a
+// signer it does not list is still written, with the mismatch recorded in
+// its metadata, and that a listed signer is not flagged.
+func TestFetchAndUnpackFile_SignerUnlisted(t *testing.T) {
+	feed := func(signer string) []byte {
+		return []byte(`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:i2p="http://geti2p.net/en/docs/spec/updates">` +
+			`<i2p:release><i2p:signer id="` + signer + `"/></i2p:release></feed>`)
+	}
+	var serve []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(serve)
+	}))
+	defer ts.Close()
+	f := NewFetcherFromClient(ts.Client())
+	f.WriteMeta = true
+	outPath := filepath.Join(t.TempDir(), "news.atom.xml")
+
+	for _, tc := range []struct {
+		signer   string
+		unlisted bool
+	}{
+		{"zzz@mail.i2p", true},
+		{"test-signer@example.i2p", false},
+	} {
+		data, cert, _ := makeSu3Bytes(t, feed(tc.signer))
+		serve = data
+		if _, err := f.FetchAndUnpackFile(ts.URL+"/news.su3", outPath, []*x509.Certificate{cert}); err != nil {
+			t.Fatalf("FetchAndUnpackFile of a feed listing %s: %v", tc.signer, err)
+		}
+		m, err := ReadFetchMeta(outPath)
+		if err != nil {
+			t.Fatalf("ReadFetchMeta: %v", err)
+		}
+		if m.SignerUnlisted != tc.unlisted {
+			t.Errorf("feed listing %s signed by %s: SignerUnlisted = %v, want %v", tc.signer, m.SignerID, m.SignerUnlisted, tc.unlisted)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package newsfetch
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+	"i2pgit.org/go-i2p/reseed-tools/su3"
+)
+
+// su3HeaderLen is the size of the fixed su3 header that precedes the
+// variable-length version string, signer ID, content, and signature.
+const su3HeaderLen = 40
+
+// rsaSigHashes maps the su3 RSA signature types to their digest algorithm.
+// RSA signatures are PKCS#1 v1.5 over the digest of every byte from the start
+// of the file to the end of the content, so they can be verified from a
+// streamed hash without holding the file in memory.  Every I2P news and
+// router-update signer uses one of these types.
+var rsaSigHashes = map[uint16]crypto.Hash{
+	4: crypto.SHA256, // RSA_SHA256_2048
+	5: crypto.SHA384, // RSA_SHA384_3072
+	6: crypto.SHA512, // RSA_SHA512_4096
+}
+
+// su3Header holds the fields of an su3 file header needed to locate and
+// verify its sections without reading the whole file.
+type su3Header struct {
+	SigType     uint16
+	SigLen      uint16
+	VersionLen  uint8
+	SignerIDLen uint8
+	ContentLen  uint64
+	FileType    uint8
+	ContentType uint8
+}
+
+// contentOffset returns the byte offset of the content section.
+func (h *su3Header) contentOffset() int64 {
+	return su3HeaderLen + int64(h.VersionLen) + int64(h.SignerIDLen)
+}
+
+// signedLen returns the number of leading bytes covered by the signature.
+func (h *su3Header) signedLen() int64 {
+	return h.contentOffset() + int64(h.ContentLen)
+}
+
+// readSu3Header decodes the fixed 40-byte su3 header from r.  Only the header
+// is consumed; r is left positioned at the start of the version string.
+func readSu3Header(r io.Reader) (*su3Header, error) {
+	var b [su3HeaderLen]byte
+	if _, err := io.ReadFull(r, b[:]); err != nil {
+		return nil, fmt.Errorf("newsfetch: data is not a valid su3 file (short header): %w", err)
+	}
+	if string(b[:len(su3Magic)]) != su3Magic {
+		return nil, fmt.Errorf("newsfetch: data is not a valid su3 file (missing magic header)")
+	}
+	return &su3Header{
+		SigType:     binary.BigEndian.Uint16(b[8:10]),
+		SigLen:      binary.BigEndian.Uint16(b[10:12]),
+		VersionLen:  b[13],
+		SignerIDLen: b[15],
+		ContentLen:  binary.BigEndian.Uint64(b[16:24]),
+		FileType:    b[25],
+		ContentType: b[27],
+	}, nil
+}
+
+// FetchToFile performs an HTTP GET of url and streams the response body to
+// dst without buffering it in memory.  The body is written to a temporary
+// file in dst's directory and renamed into place only after the download
+// completes, so a failed or interrupted fetch never leaves a truncated dst.
+// It returns the number of bytes written and their SHA-256 hex digest.  A body
+// larger than f.MaxSize fails with ErrTooLarge and leaves dst untouched.
+func (f *Fetcher) FetchToFile(url, dst string) (n int64, sum string, err error) {
+	resp, err := f.get(url)
+	if err != nil {
+		return 0, "", fmt.Errorf("newsfetch: GET %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, "", fmt.Errorf("newsfetch: GET %s: unexpected status %s", url, resp.Status)
+	}
+	body, err := f.limitBody(url, resp)
+	if err != nil {
+		return 0, "", err
+	}
+	h := sha256.New()
+	n, err = writeFileAtomic(dst, io.TeeReader(body, h))
+	if errors.Is(err, ErrTooLarge) {
+		return n, "", err
+	}
+	if err != nil {
+		return n, "", fmt.Errorf("newsfetch: read body %s: %w", url, err)
+	}
+	return n, fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// writeFileAtomic copies r into a temporary file next to dst and renames it
+// to dst once the copy succeeds.  The temporary file is removed on failure.
+func writeFileAtomic(dst string, r io.Reader) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(tmp, r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dst)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return n, err
+	}
+	return n, nil
+}
+
+// VerifyAndUnpackFile is the streaming counterpart of VerifyAndUnpack.  It
+// verifies the su3 file at su3Path against certs (skipped when certs is
+// empty) and writes the inner content to outPath, decompressed when it is
+// gzipped XML, returning the number of content bytes written.  Memory use is
+// bounded regardless of file size for RSA-signed files; other signature types
+// are verified by the su3 library, which needs the whole file in memory.
+func VerifyAndUnpackFile(su3Path, outPath string, certs []*x509.Certificate) (int64, error) {
+	n, _, err := verifyAndUnpackFile(su3Path, outPath, certs, nil)
+	return n, err
+}
+
+// verifyAndUnpackFile implements VerifyAndUnpackFile and also returns what it
+// learnt about the file, with the URL, fetch time and su3 digest left for the
+// caller to fill in.  When certs is not empty and check is non-nil, check is
+// called with the signer of the verified file before any content is written;
+// an error from check aborts the unpack.
+func verifyAndUnpackFile(su3Path, outPath string, certs []*x509.Certificate, check func(Pin) error) (int64, FetchMeta, error) {
+	file, err := os.Open(su3Path)
+	if err != nil {
+		return 0, FetchMeta{}, fmt.Errorf("newsfetch: open %s: %w", su3Path, err)
+	}
+	defer file.Close()
+	hdr, meta, err := verifySu3File(file, certs, check)
+	if err != nil {
+		return 0, meta, err
+	}
+	content, err := contentReader(io.NewSectionReader(file, hdr.contentOffset(), int64(hdr.ContentLen)), hdr.FileType, su3Path)
+	if err != nil {
+		return 0, meta, err
+	}
+	h := sha256.New()
+	n, err := writeFileAtomic(outPath, io.TeeReader(content, h))
+	if err != nil {
+		return n, meta, fmt.Errorf("newsfetch: write %s: %w", outPath, err)
+	}
+	meta.SHA256 = fmt.Sprintf("%x", h.Sum(nil))
+	return n, meta, nil
+}
+
+// verifySu3File reads the header, version, and signer ID of the su3 file,
+// checks its size, and verifies it against certs (skipped when certs is
+// empty), calling check as verifyAndUnpackFile describes.
+func verifySu3File(file *os.File, certs []*x509.Certificate, check func(Pin) error) (*su3Header, FetchMeta, error) {
+	var meta FetchMeta
+	fi, err := file.Stat()
+	if err != nil {
+		return nil, meta, fmt.Errorf("newsfetch: stat %s: %w", file.Name(), err)
+	}
+	hdr, err := readSu3Header(file)
+	if err != nil {
+		return nil, meta, err
+	}
+	if want := hdr.signedLen() + int64(hdr.SigLen); fi.Size() != want {
+		return nil, meta, fmt.Errorf("newsfetch: su3 file %s is %d bytes; header describes %d", file.Name(), fi.Size(), want)
+	}
+	// The version string and signer ID follow the header back to back.
+	fields := make([]byte, int(hdr.VersionLen)+int(hdr.SignerIDLen))
+	if _, err := file.ReadAt(fields, su3HeaderLen); err != nil {
+		return nil, meta, fmt.Errorf("newsfetch: read version and signer ID %s: %w", file.Name(), err)
+	}
+	// The version is NUL-padded to at least 16 bytes.
+	meta.Version = strings.TrimRight(string(fields[:hdr.VersionLen]), "\x00")
+	meta.SignerID = string(fields[hdr.VersionLen:])
+	if len(certs) > 0 {
+		cert, err := verifyFileSignature(file, hdr, certs)
+		if err != nil {
+			return nil, meta, err
+		}
+		meta.Verified = true
+		meta.Fingerprint = CertFingerprint(cert)
+		if check != nil {
+			if err := check(Pin{SignerID: meta.SignerID, Fingerprint: meta.Fingerprint}); err != nil {
+				return nil, meta, err
+			}
+		}
+	}
+	return hdr, meta, nil
+}
+
+// verifyFileSignature checks the signature of the su3 file described by hdr
+// against certs and returns the certificate that verifies it.  RSA
+// signatures are verified from a streamed digest; any other signature type
+// is verified by the su3 library on the full file.
+func verifyFileSignature(file *os.File, hdr *su3Header, certs []*x509.Certificate) (*x509.Certificate, error) {
+	hash, ok := rsaSigHashes[hdr.SigType]
+	if !ok {
+		newslogger.Verbosef("newsfetch: signature type %d has no streaming verifier; verifying %s in memory", hdr.SigType, file.Name())
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("newsfetch: read %s: %w", file.Name(), err)
+		}
+		f := su3.New()
+		if err := f.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("newsfetch: unmarshal su3: %w", err)
+		}
+		return verifySignatureAgainstCerts(f, certs)
+	}
+	h := hash.New()
+	if _, err := io.Copy(h, io.NewSectionReader(file, 0, hdr.signedLen())); err != nil {
+		return nil, fmt.Errorf("newsfetch: hash %s: %w", file.Name(), err)
+	}
+	digest := h.Sum(nil)
+	sig := make([]byte, hdr.SigLen)
+	if _, err := file.ReadAt(sig, hdr.signedLen()); err != nil {
+		return nil, fmt.Errorf("newsfetch: read signature %s: %w", file.Name(), err)
+	}
+	lastErr := fmt.Errorf("no RSA certificate among %d trusted certificates", len(certs))
+	for _, c := range certs {
+		pub, ok := c.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			continue
+		}
+		if err := verifyRSADigest(pub, hash, digest, sig); err == nil {
+			return c, nil
+		} else {
+			lastErr = err
+		}
+	}
+	return nil, fmt.Errorf("newsfetch: signature verification failed: %w", lastErr)
+}
+
+// verifyRSADigest verifies an su3 RSA signature over digest.  I2P signs the
+// bare digest without the ASN.1 DigestInfo prefix (Java "NONEwithRSA" over a
+// precomputed hash), which corresponds to crypto.Hash(0) here; the standard
+// DigestInfo form is accepted as well for files produced by other tooling.
+func verifyRSADigest(pub *rsa.PublicKey, hash crypto.Hash, digest, sig []byte) error {
+	if err := rsa.VerifyPKCS1v15(pub, crypto.Hash(0), digest, sig); err == nil {
+		return nil
+	}
+	return rsa.VerifyPKCS1v15(pub, hash, digest, sig)
+}
+
+// FetchAndUnpackFile is the streaming counterpart of FetchAndParse.  It
+// downloads the su3 at url to a temporary file in outPath's directory,
+// verifies it with certs (if any), writes the inner content to outPath, and
+// removes the downloaded su3.  It returns the number of content bytes written.
+// With f.Pins set, the signer of the verified su3 must match the one pinned
+// for url; see PinStore.Check.  A feed that lists its signers is
+// cross-checked against the su3 signer, and a mismatch logged; see
+// CheckListedSigner.  With f.WriteMeta set, a FetchMeta is written to
+// MetaPath(outPath) once outPath is written.  With f.History set, outPath is
+// then recorded in its history, and content rolled back from with
+// RestoreHistory is refused with ErrRolledBack.
+func (f *Fetcher) FetchAndUnpackFile(url, outPath string, certs []*x509.Certificate) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(outPath), ".newsfetch-*.su3")
+	if err != nil {
+		return 0, fmt.Errorf("newsfetch: create temp file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	fetched := time.Now().UTC()
+	_, su3Sum, err := f.FetchToFile(url, tmp.Name())
+	if err != nil {
+		return 0, err
+	}
+	var check func(Pin) error
+	if f.Pins != nil {
+		check = func(p Pin) error {
+			err := f.Pins.Check(url, p)
+			if err != nil && f.PinWarnOnly && errors.Is(err, ErrSignerChanged) {
+				newslogger.Printf("newsfetch: warning: %v", err)
+				return nil
+			}
+			return err
+		}
+	}
+	// With History set the content is unpacked beside outPath first, so a
+	// rolled-back version never replaces the restored file.
+	dst := outPath
+	if f.History > 0 {
+		dst = strings.TrimSuffix(tmp.Name(), ".su3") + historyExt(outPath)
+		defer os.Remove(dst)
+	}
+	n, meta, err := verifyAndUnpackFile(tmp.Name(), dst, certs, check)
+	if err != nil {
+		return n, err
+	}
+	if f.History > 0 {
+		rejected, err := readRejected(outPath)
+		if err != nil {
+			return n, err
+		}
+		if rejected[meta.SHA256] {
+			return n, fmt.Errorf("newsfetch: %s: %w; keeping %s", url, ErrRolledBack, outPath)
+		}
+		if err := os.Rename(dst, outPath); err != nil {
+			return n, fmt.Errorf("newsfetch: write %s: %w", outPath, err)
+		}
+	}
+	if err := checkFeedSigner(outPath, meta.SignerID); err != nil {
+		newslogger.Printf("newsfetch: warning: %s: %v", url, err)
+		meta.SignerUnlisted = errors.Is(err, ErrSignerNotListed)
+	}
+	if f.WriteMeta {
+		meta.URL, meta.Fetched, meta.Su3SHA256 = url, fetched, su3Sum
+		if err := WriteFetchMeta(outPath, meta); err != nil {
+			return n, err
+		}
+	}
+	if f.History > 0 {
+		if err := recordHistory(outPath, meta.SHA256, fetched, f.History); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,114 @@
+package newsfetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrSignerChanged is wrapped by the error PinStore.Check returns when a URL
+// is signed by a different signer than the one pinned for it.  Callers may
+// detect it with errors.Is.
+var ErrSignerChanged = errors.New("su3 signer changed since first use")
+
+// Pin identifies the signer of an su3: the signer ID embedded in the file and
+// the SHA-256 fingerprint of the trusted certificate that verified it.
+// FirstSeen is when the pin was recorded.
+type Pin struct {
+	SignerID    string    `json:"signer_id"`
+	Fingerprint string    `json:"fingerprint"`
+	FirstSeen   time.Time `json:"first_seen"`
+}
+
+// matches reports whether p and q name the same signer.
+func (p Pin) matches(q Pin) bool {
+	return p.SignerID == q.SignerID && p.Fingerprint == q.Fingerprint
+}
+
+// CertFingerprint returns the lowercase hex SHA-256 of cert's DER encoding.
+func CertFingerprint(cert *x509.Certificate) string {
+	return fmt.Sprintf("%x", sha256.Sum256(cert.Raw))
+}
+
+// PinStore records trust-on-first-use signer pins per URL in a JSON file.
+// The first verified fetch of a URL pins its signer; later fetches of that
+// URL must be signed by the same signer.  This protects a long-running mirror
+// that trusts several certificates against an upstream whose key is swapped
+// for another trusted one.  A PinStore is safe for concurrent use.
+type PinStore struct {
+	path string
+	mu   sync.Mutex
+	pins map[string]Pin
+}
+
+// LoadPinStore loads the pin file at path.  A missing file yields an empty
+// store that is created on the first pin.
+func LoadPinStore(path string) (*PinStore, error) {
+	s := &PinStore{path: path, pins: map[string]Pin{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: read pin file: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.pins); err != nil {
+		return nil, fmt.Errorf("newsfetch: parse pin file %s: %w", path, err)
+	}
+	if s.pins == nil {
+		s.pins = map[string]Pin{}
+	}
+	return s, nil
+}
+
+// Pinned returns the pin recorded for url, if any.
+func (s *PinStore) Pinned(url string) (Pin, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pins[url]
+	return p, ok
+}
+
+// Check compares the signer p of a verified su3 fetched from url with the
+// pin for url.  Without a pin, p is pinned and the pin file is saved.  A
+// different signer yields an error wrapping ErrSignerChanged and leaves the
+// pin unchanged; remove the URL from the pin file to accept a new signer.
+func (s *PinStore) Check(url string, p Pin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pinned, ok := s.pins[url]; ok {
+		if pinned.matches(p) {
+			return nil
+		}
+		return fmt.Errorf("newsfetch: %s: %w: pinned %s (%s) on %s, got %s (%s)",
+			url, ErrSignerChanged, pinned.SignerID, pinned.Fingerprint,
+			pinned.FirstSeen.Format(time.DateOnly), p.SignerID, p.Fingerprint)
+	}
+	if p.FirstSeen.IsZero() {
+		p.FirstSeen = time.Now().UTC()
+	}
+	s.pins[url] = p
+	if err := s.save(); err != nil {
+		delete(s.pins, url)
+		return err
+	}
+	return nil
+}
+
+// save writes the pins to the pin file.  s.mu must be held.
+func (s *PinStore) save() error {
+	data, err := json.MarshalIndent(s.pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("newsfetch: encode pins: %w", err)
+	}
+	if _, err := writeFileAtomic(s.path, bytes.NewReader(append(data, '\n'))); err != nil {
+		return fmt.Errorf("newsfetch: write pin file: %w", err)
+	}
+	return nil
+}
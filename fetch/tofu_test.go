@@ -0,0 +1,85 @@
+package newsfetch
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestFetchAndUnpackFile_PinsSigner verifies that the first verified fetch
+// pins its signer, that the same signer keeps passing, and that a feed
+// signed by another trusted certificate is rejected without being written,
+// or only logged in warn-only mode.
+func TestFetchAndUnpackFile_PinsSigner(t *testing.T) {
+	first, firstCert, _ := makeSu3Bytes(t, []byte("<feed>first</feed>"))
+	swapped, swappedCert, _ := makeSu3Bytes(t, []byte("<feed>swapped</feed>"))
+	serve := first
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(serve)
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	pinPath := filepath.Join(dir, "pins.json")
+	pins, err := LoadPinStore(pinPath)
+	if err != nil {
+		t.Fatalf("LoadPinStore(missing): %v", err)
+	}
+	f := NewFetcherFromClient(ts.Client())
+	f.Pins = pins
+	url := ts.URL + "/news.su3"
+	outPath := filepath.Join(dir, "news.atom.xml")
+	certs := []*x509.Certificate{firstCert, swappedCert}
+
+	for i := 0; i < 2; i++ {
+		if _, err := f.FetchAndUnpackFile(url, outPath, certs); err != nil {
+			t.Fatalf("fetch %d from pinned signer: %v", i, err)
+		}
+	}
+
+	// The pin survives a reload from disk.
+	reloaded, err := LoadPinStore(pinPath)
+	if err != nil {
+		t.Fatalf("LoadPinStore: %v", err)
+	}
+	pin, ok := reloaded.Pinned(url)
+	if !ok || pin.SignerID != "test-signer@example.i2p" || pin.Fingerprint != CertFingerprint(firstCert) || pin.FirstSeen.IsZero() {
+		t.Fatalf("pin = %+v, %v; want the first signer", pin, ok)
+	}
+
+	serve = swapped
+	f.Pins = reloaded
+	if _, err := f.FetchAndUnpackFile(url, outPath, certs); !errors.Is(err, ErrSignerChanged) {
+		t.Fatalf("fetch from swapped signer: err = %v, want ErrSignerChanged", err)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != "<feed>first</feed>" {
+		t.Errorf("output = %q after rejected fetch; want the previous feed", got)
+	}
+
+	f.PinWarnOnly = true
+	if _, err := f.FetchAndUnpackFile(url, outPath, certs); err != nil {
+		t.Fatalf("warn-only fetch from swapped signer: %v", err)
+	}
+	if got, _ := os.ReadFile(outPath); string(got) != "<feed>swapped</feed>" {
+		t.Errorf("output = %q in warn-only mode; want the new feed", got)
+	}
+	if pin, _ := reloaded.Pinned(url); pin.Fingerprint != CertFingerprint(firstCert) {
+		t.Error("warn-only mode replaced the pin; it must keep the first signer")
+	}
+}
+
+// TestLoadPinStore_Corrupt verifies that an unparseable pin file is an
+// error rather than a silently empty store, which would re-pin every URL.
+func TestLoadPinStore_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pins.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPinStore(path); err == nil {
+		t.Error("LoadPinStore(corrupt) = nil error, want a parse error")
+	}
+}
@@ -0,0 +1,142 @@
+package newsfetch
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TrustDirFiles returns the certificate files of dir, sorted by name: every
+// regular file ending in .crt or .pem, like the certificates/news directory of
+// an I2P router.  Subdirectories and other files are ignored.
+func TrustDirFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("newsfetch: read trust directory: %w", err)
+	}
+	var paths []string
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".crt" && ext != ".pem") {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// LoadTrustDir loads every certificate file of dir; see TrustDirFiles.  A
+// directory without any certificate file, or with a file holding none, is an
+// error.
+func LoadTrustDir(dir string) ([]*x509.Certificate, error) {
+	paths, err := TrustDirFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("newsfetch: no .crt or .pem files in trust directory %s", dir)
+	}
+	return loadTrustDirFiles(paths)
+}
+
+// loadTrustDirFiles loads the certificates of paths.  Unlike
+// LoadCertificates it fails on a file that holds no certificate, naming it,
+// since in a trust directory that is a half-written or misplaced file rather
+// than one to skip.
+func loadTrustDirFiles(paths []string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("newsfetch: read cert file %s: %w", path, err)
+		}
+		parsed, err := parseCertificatesFromPEM(raw, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(parsed) == 0 {
+			return nil, fmt.Errorf("newsfetch: no certificate in trust directory file %s", path)
+		}
+		certs = append(certs, parsed...)
+	}
+	return certs, nil
+}
+
+// TrustDir keeps the certificates of a trust directory loaded for a
+// long-running process, reloading them when the directory changes so that a
+// signer can be added or retired without a restart.  All methods are safe
+// for concurrent use.
+type TrustDir struct {
+	// Dir is the directory the certificates are loaded from.
+	Dir string
+
+	mu    sync.Mutex
+	certs []*x509.Certificate
+	// stamp summarises the names, sizes and modification times of the
+	// certificate files the certs were loaded from.
+	stamp string
+}
+
+// OpenTrustDir loads the certificates of dir into a new TrustDir.
+func OpenTrustDir(dir string) (*TrustDir, error) {
+	t := &TrustDir{Dir: dir}
+	if _, err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Certificates returns the certificates loaded by the last successful Reload.
+func (t *TrustDir) Certificates() []*x509.Certificate {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.certs
+}
+
+// Reload reloads the certificates when a certificate file of the directory
+// was added, removed, or modified since they were last loaded, and reports
+// whether it did.  On error the certificates loaded before are kept, so a
+// half-written file does not leave the process trusting nobody.
+func (t *TrustDir) Reload() (bool, error) {
+	paths, err := TrustDirFiles(t.Dir)
+	if err != nil {
+		return false, err
+	}
+	stamp, err := trustDirStamp(paths)
+	if err != nil {
+		return false, err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.certs != nil && stamp == t.stamp {
+		return false, nil
+	}
+	if len(paths) == 0 {
+		return false, fmt.Errorf("newsfetch: no .crt or .pem files in trust directory %s", t.Dir)
+	}
+	certs, err := loadTrustDirFiles(paths)
+	if err != nil {
+		return false, err
+	}
+	t.certs, t.stamp = certs, stamp
+	return true, nil
+}
+
+// trustDirStamp returns a string that changes whenever one of paths is
+// replaced, resized, or touched, or the set of paths changes.
+func trustDirStamp(paths []string) (string, error) {
+	var b strings.Builder
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("newsfetch: stat cert file: %w", err)
+		}
+		fmt.Fprintf(&b, "%s\x00%d\x00%d\n", path, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,87 @@
+package newsfetch
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed PEM certificate for cn to path.
+func writeTestCert(t *testing.T, path, cn string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(7),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestTrustDir loads the .crt and .pem files of a directory, ignoring other
+// files, and reloads them only once the directory changes.
+func TestTrustDir(t *testing.T) {
+	dir := t.TempDir()
+	writeTestCert(t, filepath.Join(dir, "a_at_example.i2p.crt"), "a@example.i2p")
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("not a cert"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "old.crt"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	td, err := OpenTrustDir(dir)
+	if err != nil {
+		t.Fatalf("OpenTrustDir: %v", err)
+	}
+	if n := len(td.Certificates()); n != 1 {
+		t.Fatalf("loaded %d certificates, want 1", n)
+	}
+	if changed, err := td.Reload(); err != nil || changed {
+		t.Fatalf("Reload of an unchanged directory = %v, %v; want false, nil", changed, err)
+	}
+
+	writeTestCert(t, filepath.Join(dir, "b_at_example.i2p.pem"), "b@example.i2p")
+	if changed, err := td.Reload(); err != nil || !changed {
+		t.Fatalf("Reload after adding a cert = %v, %v; want true, nil", changed, err)
+	}
+	if n := len(td.Certificates()); n != 2 {
+		t.Fatalf("loaded %d certificates after reload, want 2", n)
+	}
+
+	// A broken file fails the reload but keeps the certificates loaded before.
+	if err := os.WriteFile(filepath.Join(dir, "c.crt"), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := td.Reload(); err == nil {
+		t.Error("Reload accepted a broken certificate file")
+	}
+	if n := len(td.Certificates()); n != 2 {
+		t.Errorf("failed reload left %d certificates, want 2", n)
+	}
+}
+
+// TestLoadTrustDir_Empty checks that a directory without certificates is
+// refused rather than silently trusting nobody.
+func TestLoadTrustDir_Empty(t *testing.T) {
+	if _, err := LoadTrustDir(t.TempDir()); err == nil {
+		t.Fatal("LoadTrustDir of an empty directory succeeded, want error")
+	}
+}
@@ -0,0 +1,219 @@
+package newsfetch
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// su3 content types a router update may carry.  Routers accept both; any
+// other content type (news, reseed, plugin) is not a router update.
+const (
+	su3ContentUnknown = 0
+	su3ContentRouter  = 1
+)
+
+// ErrUpdateMismatch is returned when a fetched router update su3 is not the
+// update the feed advertised: its content type is not a router update, its
+// version differs from the <i2p:version> of the release, or its digest
+// differs from an <i2p:hash> of the update.  Callers may detect it with
+// errors.Is.
+var ErrUpdateMismatch = errors.New("su3 is not the advertised router update")
+
+// Update is a router update advertised by the <i2p:release> of a news feed.
+type Update struct {
+	// Version is the router version of the release, e.g. "2.4.0".
+	Version string
+	// Type is the update type, e.g. "su3".
+	Type string
+	// URLs are the download locations of the update, in feed order.
+	URLs []string
+	// Torrent is the magnet link of the update, if any.
+	Torrent string
+	// Hashes maps the hash types of the <i2p:hash> elements of the update,
+	// e.g. "sha256", to their lower-case hex digests.  It is nil when the
+	// feed publishes none.
+	Hashes map[string]string
+}
+
+// releaseXML mirrors the <i2p:release> element of a news feed, in the I2P
+// news extensions namespace.
+type releaseXML struct {
+	Version string `xml:"http://geti2p.net/en/docs/spec/updates version"`
+	Updates []struct {
+		Type    string `xml:"type,attr"`
+		Torrent struct {
+			Href string `xml:"href,attr"`
+		} `xml:"http://geti2p.net/en/docs/spec/updates torrent"`
+		URLs []struct {
+			Href string `xml:"href,attr"`
+		} `xml:"http://geti2p.net/en/docs/spec/updates url"`
+		Hashes []struct {
+			Type   string `xml:"type,attr"`
+			Digest string `xml:",chardata"`
+		} `xml:"http://geti2p.net/en/docs/spec/updates hash"`
+	} `xml:"http://geti2p.net/en/docs/spec/updates update"`
+}
+
+// ParseUpdates returns the router updates advertised by the <i2p:release>
+// elements of the Atom feed atom, one per <i2p:update>.  A feed without a
+// release yields no updates and no error.
+func ParseUpdates(atom []byte) ([]Update, error) {
+	var feed struct {
+		Releases []releaseXML `xml:"http://geti2p.net/en/docs/spec/updates release"`
+	}
+	if err := xml.NewDecoder(bytes.NewReader(atom)).Decode(&feed); err != nil {
+		return nil, fmt.Errorf("newsfetch: parse feed: %w", err)
+	}
+	var updates []Update
+	for _, r := range feed.Releases {
+		version := strings.TrimSpace(r.Version)
+		for _, u := range r.Updates {
+			up := Update{Version: version, Type: u.Type, Torrent: u.Torrent.Href}
+			for _, l := range u.URLs {
+				if href := strings.TrimSpace(l.Href); href != "" {
+					up.URLs = append(up.URLs, href)
+				}
+			}
+			for _, h := range u.Hashes {
+				if up.Hashes == nil {
+					up.Hashes = map[string]string{}
+				}
+				up.Hashes[strings.ToLower(strings.TrimSpace(h.Type))] = strings.ToLower(strings.TrimSpace(h.Digest))
+			}
+			updates = append(updates, up)
+		}
+	}
+	return updates, nil
+}
+
+// UpdatePath returns where FetchUpdate stores the update fetched from
+// rawURL: dir/{version}/{name}, where name is the last element of the URL
+// path, e.g. "mirror/2.4.0/i2pupdate.su3".
+func UpdatePath(dir string, u Update, rawURL string) (string, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("newsfetch: update URL %q: %w", rawURL, err)
+	}
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" || !strings.HasSuffix(name, ".su3") {
+		return "", fmt.Errorf("newsfetch: update URL %q does not name an su3 file", rawURL)
+	}
+	if u.Version == "" || u.Version != filepath.Base(u.Version) || strings.HasPrefix(u.Version, ".") {
+		return "", fmt.Errorf("newsfetch: release version %q cannot be used as a directory name", u.Version)
+	}
+	return filepath.Join(dir, u.Version, name), nil
+}
+
+// updateHashes are the <i2p:hash> types VerifyUpdateFile checks.  Digests
+// of other types are ignored.
+var updateHashes = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// VerifyUpdateFile verifies the router update su3 at su3Path against certs
+// and checks that it is a router update of version u.Version whose digests
+// match u.Hashes.  With certs empty only the header, content type, version,
+// and digests are checked.
+func VerifyUpdateFile(su3Path string, u Update, certs []*x509.Certificate) (FetchMeta, error) {
+	file, err := os.Open(su3Path)
+	if err != nil {
+		return FetchMeta{}, fmt.Errorf("newsfetch: open %s: %w", su3Path, err)
+	}
+	defer file.Close()
+	hdr, meta, err := verifySu3File(file, certs, nil)
+	if err != nil {
+		return meta, err
+	}
+	if hdr.ContentType != su3ContentUnknown && hdr.ContentType != su3ContentRouter {
+		return meta, fmt.Errorf("newsfetch: %s has su3 content type %d: %w", su3Path, hdr.ContentType, ErrUpdateMismatch)
+	}
+	if meta.Version != u.Version {
+		return meta, fmt.Errorf("newsfetch: %s is version %q, the feed advertises %q: %w", su3Path, meta.Version, u.Version, ErrUpdateMismatch)
+	}
+	for typ, want := range u.Hashes {
+		newHash, ok := updateHashes[typ]
+		if !ok {
+			continue
+		}
+		h := newHash()
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return meta, fmt.Errorf("newsfetch: %s: %w", su3Path, err)
+		}
+		if _, err := io.Copy(h, file); err != nil {
+			return meta, fmt.Errorf("newsfetch: %s: %w", su3Path, err)
+		}
+		if got := hex.EncodeToString(h.Sum(nil)); got != want {
+			return meta, fmt.Errorf("newsfetch: %s has %s %s, the feed advertises %s: %w", su3Path, typ, got, want, ErrUpdateMismatch)
+		}
+	}
+	return meta, nil
+}
+
+// FetchUpdate stores the su3 of the router update u in dir for a local
+// update mirror, trying each of u.URLs in turn, and returns its path; see
+// UpdatePath.  The download is verified with VerifyUpdateFile before it
+// replaces anything, so a failed fetch never leaves an unverified update in
+// dir.  An update already stored in dir that verifies is not fetched again.
+func (f *Fetcher) FetchUpdate(u Update, dir string, certs []*x509.Certificate) (string, error) {
+	if u.Type != "su3" {
+		return "", fmt.Errorf("newsfetch: update type %q is not su3", u.Type)
+	}
+	if len(u.URLs) == 0 {
+		return "", fmt.Errorf("newsfetch: update %s has no URL", u.Version)
+	}
+	var lastErr error
+	for _, rawURL := range u.URLs {
+		dst, err := UpdatePath(dir, u, rawURL)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if _, err := VerifyUpdateFile(dst, u, certs); err == nil {
+			return dst, nil
+		}
+		if err := f.fetchUpdate(u, rawURL, dst, certs); err != nil {
+			lastErr = err
+			continue
+		}
+		return dst, nil
+	}
+	return "", lastErr
+}
+
+// fetchUpdate downloads rawURL to a temporary file next to dst, verifies it,
+// and renames it to dst.
+func (f *Fetcher) fetchUpdate(u Update, rawURL, dst string, certs []*x509.Certificate) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("newsfetch: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".newsfetch-*.su3")
+	if err != nil {
+		return fmt.Errorf("newsfetch: create temp file: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if _, _, err := f.FetchToFile(rawURL, tmp.Name()); err != nil {
+		return err
+	}
+	if _, err := VerifyUpdateFile(tmp.Name(), u, certs); err != nil {
+		return fmt.Errorf("newsfetch: %s: %w", rawURL, err)
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("newsfetch: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package newsfetch
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"i2pgit.org/go-i2p/reseed-tools/su3"
+)
+
+// makeUpdateSu3 signs a router update su3 of version with key.
+func makeUpdateSu3(t *testing.T, key *rsa.PrivateKey, version string, contentType uint8) []byte {
+	t.Helper()
+	f := su3.New()
+	f.FileType = su3.FileTypeZIP
+	f.ContentType = contentType
+	f.Version = []byte(version)
+	f.Content = []byte("PK\x03\x04 router update")
+	f.SignerID = []byte("test-signer@example.i2p")
+	if err := f.Sign(key); err != nil {
+		t.Fatalf("sign su3: %v", err)
+	}
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatalf("marshal su3: %v", err)
+	}
+	return data
+}
+
+func TestParseUpdates(t *testing.T) {
+	feed := `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:i2p="http://geti2p.net/en/docs/spec/updates">
+<title>I2P News</title>
+<i2p:release date="2023-06-21" minVersion="0.9.9" minJavaVersion="1.8">
+<i2p:version>2.4.0</i2p:version>
+<i2p:update type="su3"><i2p:torrent href="magnet:?xt=urn:btih:abc"/><i2p:url href="http://a.i2p/i2pupdate.su3"/><i2p:url href=" http://b.i2p/i2pupdate.su3 "/><i2p:hash type="sha256"> ABCDEF </i2p:hash></i2p:update>
+</i2p:release>
+<entry><id>urn:uuid:1</id><title>x</title></entry>
+</feed>`
+	got, err := ParseUpdates([]byte(feed))
+	if err != nil {
+		t.Fatalf("ParseUpdates: %v", err)
+	}
+	want := []Update{{
+		Version: "2.4.0",
+		Type:    "su3",
+		URLs:    []string{"http://a.i2p/i2pupdate.su3", "http://b.i2p/i2pupdate.su3"},
+		Torrent: "magnet:?xt=urn:btih:abc",
+		Hashes:  map[string]string{"sha256": "abcdef"},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseUpdates = %+v, want %+v", got, want)
+	}
+
+	got, err = ParseUpdates([]byte(`<feed xmlns="http://www.w3.org/2005/Atom"><title>x</title></feed>`))
+	if err != nil || len(got) != 0 {
+		t.Errorf("feed without release: %v, %v", got, err)
+	}
+	if _, err := ParseUpdates([]byte("<feed>")); err == nil {
+		t.Error("ParseUpdates accepted a truncated feed")
+	}
+}
+
+func TestUpdatePath(t *testing.T) {
+	u := Update{Version: "2.4.0"}
+	got, err := UpdatePath("mirror", u, "http://a.i2p/i2p/i2pupdate.su3?x=1")
+	if err != nil || got != filepath.Join("mirror", "2.4.0", "i2pupdate.su3") {
+		t.Errorf("UpdatePath = %q, %v", got, err)
+	}
+	if _, err := UpdatePath("mirror", u, "http://a.i2p/"); err == nil {
+		t.Error("UpdatePath accepted a URL without an su3 file name")
+	}
+	for _, v := range []string{"", "..", "../2.4.0", "a/b"} {
+		if _, err := UpdatePath("mirror", Update{Version: v}, "http://a.i2p/i2pupdate.su3"); err == nil {
+			t.Errorf("UpdatePath accepted version %q", v)
+		}
+	}
+}
+
+func TestFetcher_FetchUpdate(t *testing.T) {
+	_, cert, key := makeSu3Bytes(t, []byte("<feed/>"))
+	certs := []*x509.Certificate{cert}
+	good := makeUpdateSu3(t, key, "2.4.0", su3ContentRouter)
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/old/i2pupdate.su3":
+			w.Write(makeUpdateSu3(t, key, "2.3.0", su3ContentRouter))
+		case "/news/i2pupdate.su3":
+			w.Write(makeUpdateSu3(t, key, "2.4.0", su3.ContentTypeNews))
+		case "/i2pupdate.su3":
+			w.Write(good)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	f := NewFetcherFromClient(ts.Client())
+	dir := t.TempDir()
+
+	for _, p := range []string{"/old/i2pupdate.su3", "/news/i2pupdate.su3"} {
+		u := Update{Version: "2.4.0", Type: "su3", URLs: []string{ts.URL + p}}
+		if _, err := f.FetchUpdate(u, dir, certs); !errors.Is(err, ErrUpdateMismatch) {
+			t.Errorf("FetchUpdate(%s) = %v, want ErrUpdateMismatch", p, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "2.4.0", "i2pupdate.su3")); !os.IsNotExist(err) {
+		t.Fatalf("a mismatched update was stored: %v", err)
+	}
+
+	u := Update{Version: "2.4.0", Type: "su3", URLs: []string{ts.URL + "/missing/i2pupdate.su3", ts.URL + "/i2pupdate.su3"}}
+	path, err := f.FetchUpdate(u, dir, certs)
+	if err != nil {
+		t.Fatalf("FetchUpdate: %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != string(good) {
+		t.Errorf("stored update differs from the served one")
+	}
+	hits.Store(0)
+	if _, err := f.FetchUpdate(u, dir, certs); err != nil || hits.Load() != 0 {
+		t.Errorf("second FetchUpdate: %v, %d request(s); want the stored update reused", err, hits.Load())
+	}
+
+	sum := sha256.Sum256(good)
+	u.Hashes = map[string]string{"sha256": hex.EncodeToString(sum[:]), "md5": "ignored"}
+	if _, err := VerifyUpdateFile(path, u, certs); err != nil {
+		t.Errorf("VerifyUpdateFile with the advertised sha256: %v", err)
+	}
+	u.Hashes["sha256"] = strings.Repeat("0", 64)
+	if _, err := VerifyUpdateFile(path, u, certs); !errors.Is(err, ErrUpdateMismatch) {
+		t.Errorf("VerifyUpdateFile with another sha256 = %v, want ErrUpdateMismatch", err)
+	}
+	u.Hashes = nil
+
+	_, other, _ := makeSu3Bytes(t, []byte("<feed/>"))
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.FetchUpdate(u, dir, []*x509.Certificate{other}); err == nil {
+		t.Error("FetchUpdate stored an update signed by an untrusted key")
+	}
+}
@@ -0,0 +1,93 @@
+package newsfetch
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Transports a news URL can be fetched over; see ValidateURL.
+const (
+	// TransportI2P fetches through a SAM session, which only reaches .i2p
+	// hosts.  Every Fetcher built on a Garlic session uses it.
+	TransportI2P = "i2p"
+	// TransportClearnet fetches with an ordinary HTTP client, which cannot
+	// reach .i2p hosts.
+	TransportClearnet = "clearnet"
+	// TransportOutproxy fetches clearnet hosts through an I2P HTTP outproxy,
+	// so that newsgo never connects to them directly.
+	TransportOutproxy = "outproxy"
+)
+
+// b32Len is the length of the base32 label of a .b32.i2p address: a SHA-256
+// destination hash in 52 characters.  Labels of 56 characters or more are
+// encrypted lease set addresses, which carry a key and flags instead.
+const (
+	b32Len          = 52
+	b32EncryptedLen = 56
+)
+
+// ValidateURL checks that rawURL is a news URL that can be fetched over
+// transport, without any DNS or SAM lookup.  A Garlic session hands back a
+// dead connection for a clearnet host and a clearnet client fails to resolve
+// an .i2p host, both only after a long timeout, so misconfigured URLs are
+// better rejected before any tunnel is opened.  It also checks that a
+// .b32.i2p address is well formed.
+func ValidateURL(rawURL, transport string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("newsfetch: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("newsfetch: %s: scheme must be http or https", rawURL)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("newsfetch: %s: missing host", rawURL)
+	}
+	i2pHost := strings.HasSuffix(host, ".i2p")
+	switch transport {
+	case TransportI2P:
+		if net.ParseIP(host) != nil {
+			return fmt.Errorf("newsfetch: %s: IP address %s cannot be reached over I2P", rawURL, host)
+		}
+		if !i2pHost {
+			return fmt.Errorf("newsfetch: %s: %s is not an .i2p host and cannot be reached over I2P", rawURL, host)
+		}
+	case TransportClearnet, TransportOutproxy:
+		if i2pHost {
+			return fmt.Errorf("newsfetch: %s: %s is an I2P host and cannot be reached over the %s", rawURL, host, transport)
+		}
+		return nil
+	default:
+		return fmt.Errorf("newsfetch: unknown transport %q", transport)
+	}
+	if label, ok := strings.CutSuffix(host, ".b32.i2p"); ok {
+		if err := checkB32(label); err != nil {
+			return fmt.Errorf("newsfetch: %s: invalid .b32.i2p address: %w", rawURL, err)
+		}
+	}
+	return nil
+}
+
+// checkB32 checks the label of a .b32.i2p address.  A 52-character label
+// encodes exactly 256 bits, so the 4 low bits of its last character are
+// always zero: it ends in "a" or "q".  That catches most truncated or
+// mistyped addresses.
+func checkB32(label string) error {
+	for _, r := range label {
+		if (r < 'a' || r > 'z') && (r < '2' || r > '7') {
+			return fmt.Errorf("%q is not a base32 character", r)
+		}
+	}
+	switch {
+	case len(label) == b32Len:
+		if last := label[b32Len-1]; last != 'a' && last != 'q' {
+			return fmt.Errorf("a %d-character address must end in a or q", b32Len)
+		}
+	case len(label) < b32EncryptedLen:
+		return fmt.Errorf("%d characters; want %d, or at least %d for an encrypted lease set", len(label), b32Len, b32EncryptedLen)
+	}
+	return nil
+}
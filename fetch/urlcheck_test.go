@@ -0,0 +1,36 @@
+package newsfetch
+
+import "testing"
+
+// TestValidateURL covers transport mismatches and .b32.i2p syntax checks.
+func TestValidateURL(t *testing.T) {
+	const b32 = "tc73n4kivdroccekirco7rhgxdg5f3cjvbaapabupeyzrqwv5guq.b32.i2p"
+	for _, tt := range []struct {
+		url       string
+		transport string
+		ok        bool
+	}{
+		{"http://" + b32 + "/news/news.su3", TransportI2P, true},
+		{"http://DN3TVALNJZ432QKQSVPFDQRWPQKW3YE4N4I2UYFR4JEXVO3SP5KA.b32.i2p/news.su3", TransportI2P, true},
+		{"http://stats.i2p/news.su3", TransportI2P, true},
+		{"http://" + b32 + ":8080/news.su3", TransportI2P, true},
+		{"http://" + b32 + "/news.su3", TransportClearnet, false},
+		{"https://geti2p.net/news.su3", TransportI2P, false},
+		{"https://geti2p.net/news.su3", TransportClearnet, true},
+		{"http://127.0.0.1:7657/news.su3", TransportI2P, false},
+		{"ftp://" + b32 + "/news.su3", TransportI2P, false},
+		{"/news.su3", TransportI2P, false},
+		// Truncated, mistyped (the last character must be a or q), and
+		// non-base32 addresses.
+		{"http://tc73n4kivdroccekirco7rhgxdg5f3cjvbaapabupeyzrqwv5gu.b32.i2p/", TransportI2P, false},
+		{"http://tc73n4kivdroccekirco7rhgxdg5f3cjvbaapabupeyzrqwv5gub.b32.i2p/", TransportI2P, false},
+		{"http://tc73n4kivdroccekirco7rhgxdg5f3cjvbaapabupeyzrqwv5g1q.b32.i2p/", TransportI2P, false},
+		// Encrypted lease set addresses are longer.
+		{"http://ftnqzvvkdbbsvbqpqvaudagaagccqt3uc3mg2w6kkf2eduybv6maaaaa.b32.i2p/", TransportI2P, true},
+		{"http://" + b32 + "/news.su3", "tor", false},
+	} {
+		if err := ValidateURL(tt.url, tt.transport); (err == nil) != tt.ok {
+			t.Errorf("ValidateURL(%q, %q) = %v, want ok=%v", tt.url, tt.transport, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Package newslogger gates newsgo's informational log output by a global
+// verbosity level.  Errors are logged with the standard log package and are
+// never suppressed; warnings and summaries go through Printf and per-file
+// detail through Verbosef.
+package newslogger
+
+import (
+	"log"
+	"sync/atomic"
+)
+
+// Level selects how much informational output is logged.
+type Level int32
+
+const (
+	// Quiet logs errors only.
+	Quiet Level = iota - 1
+	// Normal logs errors, warnings, and one-line summaries.  It is the
+	// default.
+	Normal
+	// Verbose additionally logs per-file and per-request detail.
+	Verbose
+)
+
+// level holds the current Level.  Its zero value is Normal.
+var level atomic.Int32
+
+// SetLevel sets the verbosity level for the whole process.
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// CurrentLevel returns the verbosity level set by SetLevel.
+func CurrentLevel() Level {
+	return Level(level.Load())
+}
+
+// Printf logs a warning or summary unless the level is Quiet.
+func Printf(format string, v ...any) {
+	if CurrentLevel() >= Normal {
+		log.Printf(format, v...)
+	}
+}
+
+// Verbosef logs per-file or per-request detail when the level is Verbose.
+func Verbosef(format string, v ...any) {
+	if CurrentLevel() >= Verbose {
+		log.Printf(format, v...)
+	}
+}
@@ -0,0 +1,40 @@
+package newslogger
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+)
+
+func TestLevels(t *testing.T) {
+	var buf bytes.Buffer
+	flags := log.Flags()
+	log.SetOutput(&buf)
+	log.SetFlags(0)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+		SetLevel(Normal)
+	})
+
+	if got := CurrentLevel(); got != Normal {
+		t.Fatalf("default level = %d, want Normal", got)
+	}
+	for _, tc := range []struct {
+		level Level
+		want  string
+	}{
+		{Quiet, ""},
+		{Normal, "summary\n"},
+		{Verbose, "summary\ndetail\n"},
+	} {
+		buf.Reset()
+		SetLevel(tc.level)
+		Printf("summary")
+		Verbosef("detail")
+		if got := buf.String(); got != tc.want {
+			t.Errorf("level %d: logged %q, want %q", tc.level, got, tc.want)
+		}
+	}
+}
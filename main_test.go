@@ -28,7 +28,7 @@ func TestServeCmd_FlagNames(t *testing.T) {
 		flag    string
 		wantDef string
 	}{
-		{"host", "127.0.0.1"},
+		{"host", "[127.0.0.1]"},
 		{"port", "9696"},
 		{"i2p", "false"},
 		{"newsdir", "build"},
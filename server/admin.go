@@ -0,0 +1,108 @@
+package newsserver
+
+import (
+	"crypto/subtle"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// Paths answered by AdminHandler.
+const (
+	AdminShutdownPath = "/admin/shutdown"
+	AdminReloadPath   = "/admin/reload"
+)
+
+// AdminHandler answers the administrative endpoints that let orchestrators
+// and wrapper scripts control a running server over HTTP where signals are
+// awkward, e.g. for Windows services.  It is meant for a listener of its own
+// on a loopback address, never for the news listeners, and additionally
+// refuses clients that are not on the loopback interface.  Every request must
+// be a POST carrying "Authorization: Bearer <Token>".
+type AdminHandler struct {
+	// Token is the bearer token clients must present.  An empty Token
+	// refuses every request.
+	Token string
+	// Shutdown is called, after the response has been written, to drain
+	// and stop the server.  It need not return.
+	Shutdown func()
+	// Reload is called to persist state and drop cached pages so that a
+	// rebuilt tree is served at once.
+	Reload func() error
+}
+
+// ServeHTTP implements http.Handler.
+func (a *AdminHandler) ServeHTTP(rw http.ResponseWriter, rq *http.Request) {
+	if !loopbackClient(rq) {
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if !a.authorized(rq) {
+		rw.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(rw, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if rq.URL.Path != AdminShutdownPath && rq.URL.Path != AdminReloadPath {
+		http.NotFound(rw, rq)
+		return
+	}
+	if rq.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	newslogger.Printf("admin: %s from %s", rq.URL.Path, rq.RemoteAddr)
+	switch {
+	case rq.URL.Path == AdminShutdownPath && a.Shutdown != nil:
+		rw.WriteHeader(http.StatusAccepted)
+		rw.Write([]byte("shutting down\n"))
+		if f, ok := rw.(http.Flusher); ok {
+			f.Flush()
+		}
+		// Shutdown need not return, so run it after this response is
+		// on its way.
+		go a.Shutdown()
+	case rq.URL.Path == AdminReloadPath && a.Reload != nil:
+		if err := a.Reload(); err != nil {
+			log.Printf("admin: reload: %v", err)
+			http.Error(rw, "reload failed: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Write([]byte("reloaded\n"))
+	default:
+		http.Error(rw, "Not Implemented", http.StatusNotImplemented)
+	}
+}
+
+// authorized reports whether rq carries a.Token as its bearer token.  The
+// comparison takes constant time.
+func (a *AdminHandler) authorized(rq *http.Request) bool {
+	if a.Token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(rq.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(a.Token)) == 1
+}
+
+// loopbackClient reports whether rq came from a loopback address.
+func loopbackClient(rq *http.Request) bool {
+	host, _, err := net.SplitHostPort(rq.RemoteAddr)
+	if err != nil {
+		host = rq.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// FlushCaches drops the rendered directory listings, landing page and stats
+// charts so that the next request renders them from the tree as it is now.
+func (n *NewsServer) FlushCaches() {
+	globalListingCache.clear()
+	n.landingOnce.Do(func() { n.landing = newListingCache(listingTTL) })
+	n.landing.clear()
+	n.graphsOnce.Do(func() { n.graphs = newListingCache(graphTTL) })
+	n.graphs.clear()
+}
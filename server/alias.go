@@ -0,0 +1,93 @@
+package newsserver
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// Alias answers requests for the URL path From as if they were for To, so
+// that routers with a hard-coded legacy path such as /news/news.su3 get the
+// feed of the current layout.  A From ending in "/" is a prefix: every path
+// below it is rewritten to the same path below To, which then also ends in
+// "/".  The rewrite is internal; the client sees no redirect, which not
+// every old router follows.
+type Alias struct {
+	From string
+	To   string
+}
+
+// ParseAliases parses "from=to" specs such as "/news/news.su3=/news.su3" or
+// "/news/=/" into aliases ordered exact paths first and then longest prefix
+// first, so that the most specific alias wins.  Both paths must start with
+// "/", a prefix must be aliased to a prefix, and a path may be aliased only
+// once.
+func ParseAliases(specs []string) ([]Alias, error) {
+	aliases := make([]Alias, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("ParseAliases: %q is not from=to", spec)
+		}
+		if !strings.HasPrefix(from, "/") || !strings.HasPrefix(to, "/") {
+			return nil, fmt.Errorf("ParseAliases: %q: both paths must start with /", spec)
+		}
+		from, to = cleanAliasPath(from), cleanAliasPath(to)
+		if from == "/" {
+			return nil, fmt.Errorf("ParseAliases: %q aliases every path; serve the target directory instead", spec)
+		}
+		if strings.HasSuffix(from, "/") != strings.HasSuffix(to, "/") {
+			return nil, fmt.Errorf("ParseAliases: %q: a prefix ending in / must be aliased to a prefix, and a path to a path", spec)
+		}
+		if seen[from] {
+			return nil, fmt.Errorf("ParseAliases: %s aliased twice", from)
+		}
+		seen[from] = true
+		aliases = append(aliases, Alias{From: from, To: to})
+	}
+	sort.SliceStable(aliases, func(i, j int) bool {
+		pi, pj := strings.HasSuffix(aliases[i].From, "/"), strings.HasSuffix(aliases[j].From, "/")
+		if pi != pj {
+			return !pi
+		}
+		return len(aliases[i].From) > len(aliases[j].From)
+	})
+	return aliases, nil
+}
+
+// cleanAliasPath cleans p, keeping a trailing slash.
+func cleanAliasPath(p string) string {
+	clean := path.Clean(p)
+	if strings.HasSuffix(p, "/") && clean != "/" {
+		clean += "/"
+	}
+	return clean
+}
+
+// rewriteAlias returns rq with its URL path rewritten by the first of
+// n.Aliases that matches it, or rq unchanged.  Aliases are applied once and
+// do not chain.  The rewritten path goes through every check a request for
+// it would, so an alias cannot expose a hidden file.
+func (n *NewsServer) rewriteAlias(rq *http.Request) *http.Request {
+	for _, a := range n.Aliases {
+		var target string
+		switch {
+		case rq.URL.Path == a.From:
+			target = a.To
+		case strings.HasSuffix(a.From, "/") && strings.HasPrefix(rq.URL.Path, a.From):
+			target = a.To + strings.TrimPrefix(rq.URL.Path, a.From)
+		default:
+			continue
+		}
+		newslogger.Verbosef("ServeHTTP: alias %q served as %q", rq.URL.Path, target)
+		out := rq.Clone(rq.Context())
+		out.URL.Path, out.URL.RawPath = target, ""
+		return out
+	}
+	return rq
+}
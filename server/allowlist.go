@@ -0,0 +1,115 @@
+package newsserver
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// i2pB64 is the base64 alphabet of I2P destinations, which uses "-" and "~"
+// in place of "+" and "/".
+var i2pB64 = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-~")
+
+// b32Re matches a .b32.i2p host name: a plain destination hash (52
+// characters) or an encrypted lease set address (56 or more).
+var b32Re = regexp.MustCompile(`^[a-z2-7]{52,}\.b32\.i2p$`)
+
+// Allowlist restricts a server to known clients, such as the handful of test
+// routers of a staging mirror.  Clearnet clients are matched by IP address,
+// I2P clients by the .b32.i2p address of their destination.  A nil Allowlist
+// allows everyone.
+type Allowlist struct {
+	nets  []*net.IPNet
+	dests map[string]bool
+}
+
+// ParseAllowlist parses allowlist patterns: an IP address ("192.0.2.7"), a
+// CIDR block ("10.0.0.0/8"), a .b32.i2p address, or a full base64
+// destination, which is matched by its .b32.i2p address.  Host names other
+// than .b32.i2p addresses are rejected because they cannot be checked
+// against a client without a lookup.
+func ParseAllowlist(specs []string) (*Allowlist, error) {
+	a := &Allowlist{dests: make(map[string]bool)}
+	for _, spec := range specs {
+		p := strings.TrimSpace(spec)
+		if _, ipnet, err := net.ParseCIDR(p); err == nil {
+			a.nets = append(a.nets, ipnet)
+			continue
+		}
+		if ip := net.ParseIP(p); ip != nil {
+			a.nets = append(a.nets, singleIPNet(ip))
+			continue
+		}
+		if b32 := strings.ToLower(p); b32Re.MatchString(b32) {
+			a.dests[b32] = true
+			continue
+		}
+		if b32, ok := destinationB32(strings.TrimSuffix(p, ".i2p")); ok {
+			a.dests[b32] = true
+			continue
+		}
+		return nil, fmt.Errorf("ParseAllowlist: %q is not an IP address, CIDR block, .b32.i2p address, or destination", spec)
+	}
+	return a, nil
+}
+
+// singleIPNet returns the network holding only ip.
+func singleIPNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+// destinationB32 returns the .b32.i2p address of a base64 I2P destination:
+// the base32 SHA-256 of its binary form.
+func destinationB32(dest string) (string, bool) {
+	// A destination is at least 387 bytes, 516 base64 characters.
+	if len(dest) < 516 {
+		return "", false
+	}
+	raw, err := i2pB64.DecodeString(dest)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(raw)
+	b32 := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:])
+	return strings.ToLower(b32) + ".b32.i2p", true
+}
+
+// Allows reports whether rq comes from a client matching the allowlist.
+// The client is taken from rq.RemoteAddr, which is host:port for clearnet
+// connections and a .b32.i2p address or base64 destination for connections
+// accepted over SAM.  A request arriving from a loopback address is also
+// matched by the X-I2P-DestB32 header an I2PTunnel server tunnel adds, so an
+// allowlist works behind a tunnel too; the header is ignored from anywhere
+// else, where it could be forged.
+func (a *Allowlist) Allows(rq *http.Request) bool {
+	if a == nil {
+		return true
+	}
+	host := rq.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		for _, n := range a.nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		if ip.IsLoopback() {
+			return a.dests[strings.ToLower(rq.Header.Get("X-I2P-DestB32"))]
+		}
+		return false
+	}
+	if b32, ok := destinationB32(host); ok {
+		host = b32
+	}
+	return a.dests[strings.ToLower(host)]
+}
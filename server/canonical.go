@@ -0,0 +1,51 @@
+package newsserver
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// collapseSlashes returns urlPath with every run of slashes replaced by a
+// single slash, e.g. "/mac//stable/" becomes "/mac/stable/".
+func collapseSlashes(urlPath string) string {
+	if !strings.Contains(urlPath, "//") {
+		return urlPath
+	}
+	var b strings.Builder
+	b.Grow(len(urlPath))
+	for i := 0; i < len(urlPath); i++ {
+		if urlPath[i] == '/' && i > 0 && urlPath[i-1] == '/' {
+			continue
+		}
+		b.WriteByte(urlPath[i])
+	}
+	return b.String()
+}
+
+// slashPath returns the canonical URL path of file requested as urlPath:
+// directories end in a slash, so that the relative links of their listings
+// resolve inside them, and files do not.  ok is false when urlPath is
+// already canonical or file does not exist on disk.
+func slashPath(urlPath, file string) (canonical string, ok bool) {
+	fi, err := os.Stat(file)
+	if err != nil {
+		return "", false
+	}
+	trailing := strings.HasSuffix(urlPath, "/")
+	switch {
+	case fi.IsDir() && !trailing:
+		return urlPath + "/", true
+	case !fi.IsDir() && trailing:
+		return strings.TrimRight(urlPath, "/"), true
+	}
+	return "", false
+}
+
+// redirectCanonical answers rq with a permanent redirect to urlPath, keeping
+// the query.
+func redirectCanonical(rw http.ResponseWriter, rq *http.Request, urlPath string) {
+	u := *rq.URL
+	u.Path, u.RawPath = urlPath, ""
+	http.Redirect(rw, rq, u.RequestURI(), http.StatusMovedPermanently)
+}
@@ -0,0 +1,53 @@
+package newsserver
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+	stats "github.com/go-i2p/newsgo/server/stats"
+)
+
+// checksumSuffix is appended to the path of a file to request its SHA-256.
+const checksumSuffix = ".sha256"
+
+// checksumTarget returns the file whose checksum rq asks for, when it asks
+// for one: file itself with ?checksum=1, or file without its ".sha256"
+// suffix.  A ".sha256" file that exists on disk is served as it is.
+func checksumTarget(file string, rq *http.Request) (string, bool) {
+	if rq.URL.Query().Get("checksum") == "1" {
+		return file, true
+	}
+	if !strings.HasSuffix(file, checksumSuffix) {
+		return "", false
+	}
+	if _, err := os.Stat(file); err == nil {
+		return "", false
+	}
+	return strings.TrimSuffix(file, checksumSuffix), true
+}
+
+// serveChecksum answers with the SHA-256 of the regular file file in the
+// format of sha256sum, "<hex digest>  <name>", so that a mirror script can
+// check a download with sha256sum -c.  The digest comes from fileChecksum
+// and is the one the directory listings show.
+func (n *NewsServer) serveChecksum(file string, rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if fi, err := os.Stat(file); err != nil || !fi.Mode().IsRegular() {
+		newslogger.Verbosef("ServeHTTP: no checksum for %q", file)
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	sum, err := fileChecksum(file)
+	if err != nil {
+		log.Println("ServeHTTP:", err.Error())
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	n.Stats.IncrementClass(stats.ClassOther)
+	fmt.Fprintf(rw, "%s  %s\n", sum, filepath.Base(file))
+}
@@ -0,0 +1,54 @@
+package newsserver
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"path/filepath"
+)
+
+// rootsKey is the request context key of the roots resolved by
+// resolveRoots.
+type rootsKey struct{}
+
+// resolveRoots returns rq carrying the directories NewsDir and the route
+// directories resolve to when ResolveCurrent is set, and rq unchanged
+// otherwise.  Each root is resolved once, so a request is answered from a
+// single tree even if a symlink is repointed while it is served.  A root
+// that cannot be resolved, e.g. because its link is dangling for a moment,
+// is kept as it is.
+func (n *NewsServer) resolveRoots(rq *http.Request) *http.Request {
+	if !n.ResolveCurrent {
+		return rq
+	}
+	roots := make(map[string]string, len(n.Routes)+1)
+	for _, dir := range append([]string{n.NewsDir}, routeDirs(n.Routes)...) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			log.Printf("resolveRoots: %v", err)
+			resolved = dir
+		}
+		roots[dir] = resolved
+	}
+	return rq.WithContext(context.WithValue(rq.Context(), rootsKey{}, roots))
+}
+
+// servedRoot returns the directory rq is served from for the root dir: the
+// target resolveRoots found for it, or dir itself.
+func servedRoot(rq *http.Request, dir string) string {
+	if roots, ok := rq.Context().Value(rootsKey{}).(map[string]string); ok {
+		if resolved, ok := roots[dir]; ok {
+			return resolved
+		}
+	}
+	return dir
+}
+
+// routeDirs returns the directories of routes.
+func routeDirs(routes []Route) []string {
+	dirs := make([]string, 0, len(routes))
+	for _, r := range routes {
+		dirs = append(dirs, r.Dir)
+	}
+	return dirs
+}
@@ -0,0 +1,115 @@
+package newsserver
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	stats "github.com/go-i2p/newsgo/server/stats"
+)
+
+// graphTTL bounds how long a rendered stats chart is reused.  Dashboards that
+// embed several charts and refresh them together then cost one render each,
+// at the price of counts that lag by up to graphTTL.
+const graphTTL = 30 * time.Second
+
+// errGraphFilter reports a langstats.svg platform or status filter that
+// names no known platform or release status.
+var errGraphFilter = errors.New("unknown platform or status")
+
+// renderGraph returns the chart for the stats graph basename base, as PNG
+// when png is set and as SVG otherwise.  For statsGraphFilename the platform
+// and status query parameters restrict the chart to the matching channels
+// (see stats.NewsStats.GraphSVG); they must name a known platform and status,
+// which also bounds the number of cached charts.  The total bars are labelled
+// in locale (see pageLocale).  Charts are cached per filter, format, and
+// locale for graphTTL.
+func (n *NewsServer) renderGraph(base string, rq *http.Request, png bool, locale string) ([]byte, error) {
+	n.graphsOnce.Do(func() { n.graphs = newListingCache(graphTTL) })
+	msgs := builder.MessagesFor(locale)
+	labels := stats.Labels{TotalRequests: msgs.TotalRequests, UpdatesHandled: msgs.UpdatesHandled}
+	if base == contentStatsGraphFilename {
+		if png {
+			return n.graphs.do(base+"?png?"+locale, time.Time{}, func() ([]byte, error) {
+				return n.Stats.ClassGraphPNGIn(labels)
+			})
+		}
+		return n.graphs.do(base+"?"+locale, time.Time{}, func() ([]byte, error) {
+			return n.Stats.ClassGraphSVGIn(labels)
+		})
+	}
+	q := rq.URL.Query()
+	platform, status := q.Get("platform"), q.Get("status")
+	if (platform != "" && !contains(builder.KnownPlatforms(), platform)) || (status != "" && !contains(builder.KnownStatuses(), status)) {
+		return nil, fmt.Errorf("%w: platform=%q status=%q", errGraphFilter, platform, status)
+	}
+	if png {
+		return n.graphs.do(base+"?png?"+platform+"/"+status+"?"+locale, time.Time{}, func() ([]byte, error) {
+			return n.Stats.GraphPNGIn(labels, platform, status)
+		})
+	}
+	return n.graphs.do(base+"?"+platform+"/"+status+"?"+locale, time.Time{}, func() ([]byte, error) {
+		return n.Stats.GraphSVGIn(labels, platform, status)
+	})
+}
+
+// prefersPNG reports whether the Accept header accept ranks image/png above
+// image/svg+xml.  Each type takes the quality of the most specific media
+// range matching it, as in RFC 9110 §12.5.1.  SVG wins ties and an absent
+// header, so only clients that ask for PNG, such as router consoles that
+// cannot render SVG, get one.
+func prefersPNG(accept string) bool {
+	if accept == "" {
+		return false
+	}
+	return acceptQuality(accept, "image/png") > acceptQuality(accept, "image/svg+xml")
+}
+
+// acceptQuality returns the quality the Accept header accept gives the media
+// type typ: that of the most specific matching range, or 0 when none matches.
+func acceptQuality(accept, typ string) float64 {
+	major, _, _ := strings.Cut(typ, "/")
+	q, specificity := 0.0, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaRange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		var s int
+		switch mediaRange {
+		case typ:
+			s = 2
+		case major + "/*":
+			s = 1
+		case "*/*":
+			s = 0
+		default:
+			continue
+		}
+		if s <= specificity {
+			continue
+		}
+		specificity, q = s, 1
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+	}
+	return q
+}
+
+// contains reports whether list contains s.
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,194 @@
+package newsserver
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	builder "github.com/go-i2p/newsgo/builder"
+)
+
+// DefaultHidden are the name patterns serve hides unless told otherwise:
+// dotfiles, which include the temporaries of atomic writes, and *.tmp files.
+var DefaultHidden = []string{".*", "*.tmp"}
+
+// ParseHidden validates name patterns for NewsServer.Hidden.  A pattern uses
+// path.Match syntax and is matched against every component of a path, so it
+// must not contain "/".
+func ParseHidden(patterns []string) ([]string, error) {
+	out := make([]string, 0, len(patterns))
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if strings.Contains(p, "/") {
+			return nil, fmt.Errorf("ParseHidden: %q: patterns match single names and cannot contain /", p)
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, fmt.Errorf("ParseHidden: %q: %w", p, err)
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// hiddenName reports whether name, a single path component, matches one of
+// patterns.
+func hiddenName(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// statsFiles returns the absolute paths of the files Stats is persisted to,
+// followed by the filepath.Match pattern of its archives.  They are the
+// server's own state, not published content.
+func (n *NewsServer) statsFiles() []string {
+	if n.Stats.StateFile == "" {
+		return nil
+	}
+	var files []string
+	for _, f := range []string{n.Stats.StateFile, n.Stats.LastDownloadStateFile(), n.Stats.ClassStateFile(), n.Stats.ChannelStateFile(), n.Stats.TransferStateFile(), n.Stats.VisitStateFile(), n.Stats.ArchivePattern()} {
+		if abs, err := filepath.Abs(f); err == nil {
+			files = append(files, abs)
+		}
+	}
+	return files
+}
+
+// isStatsFile reports whether file is one of statsFiles or matches one.
+func isStatsFile(file string, statsFiles []string) bool {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	for _, f := range statsFiles {
+		if abs == f {
+			return true
+		}
+		if ok, _ := filepath.Match(f, abs); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hidden reports whether file, below root, is hidden from requests and
+// listings: a component of its path below root matches Hidden, it is one of
+// the stats files, or Select leaves it out.
+func (n *NewsServer) hidden(root, file string) bool {
+	if isStatsFile(file, n.statsFiles()) {
+		return true
+	}
+	rel, err := filepath.Rel(root, file)
+	if err != nil || rel == "." {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if hiddenName(part, n.Hidden) {
+			return true
+		}
+	}
+	if n.Select.IsZero() {
+		return false
+	}
+	fi, err := os.Stat(file)
+	return !n.Select.Selects(filepath.ToSlash(rel), err == nil && fi.IsDir())
+}
+
+// depth returns the number of directories from root down to dir: 0 for root
+// itself, 1 for "mac", 2 for "mac/stable".
+func depth(root, dir string) int {
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return strings.Count(filepath.ToSlash(rel), "/") + 1
+}
+
+// beyondDepth reports whether the directory dir below root is deeper than
+// MaxListingDepth and so is not listed.
+func (n *NewsServer) beyondDepth(root, dir string) bool {
+	return n.MaxListingDepth > 0 && depth(root, dir) > n.MaxListingDepth
+}
+
+// unlistedDir reports whether file is a directory below root that is not
+// listed because of MaxListingDepth, or any directory with NoListings but a
+// root whose listing LandingPage replaces.
+func (n *NewsServer) unlistedDir(root, file string) bool {
+	switch {
+	case n.NoListings:
+		if n.LandingPage != nil && depth(root, file) == 0 {
+			return false
+		}
+	case !n.beyondDepth(root, file):
+		return false
+	}
+	fi, err := os.Stat(file)
+	return err == nil && fi.IsDir()
+}
+
+// rootOf returns the directory a file served by n for rq lies under: the
+// deepest of NewsDir and the route directories, as served for rq, that
+// contains it.
+func (n *NewsServer) rootOf(rq *http.Request, file string) string {
+	root := servedRoot(rq, n.NewsDir)
+	for _, r := range n.Routes {
+		if dir := filepath.Clean(servedRoot(rq, r.Dir)); containsPath(dir, file) && len(dir) > len(filepath.Clean(root)) {
+			root = dir
+		}
+	}
+	return root
+}
+
+// listingFilter selects the entries a directory listing leaves out.
+type listingFilter struct {
+	hidden     []string // name patterns; see NewsServer.Hidden
+	statsFiles []string // absolute paths of the stats files
+	noSubdirs  bool     // the listing is at MaxListingDepth
+	// root is the root the listed directory lies under, for selector.
+	root     string
+	selector builder.FeedSelector // see NewsServer.Select
+	// locale is the locale of the listing's boilerplate; see pageLocale.
+	locale string
+}
+
+// listingFilter returns the filter for the listing of dir below root.
+func (n *NewsServer) listingFilter(root, dir string) listingFilter {
+	return listingFilter{
+		hidden:     n.Hidden,
+		statsFiles: n.statsFiles(),
+		noSubdirs:  n.MaxListingDepth > 0 && depth(root, dir) >= n.MaxListingDepth,
+		root:       root,
+		selector:   n.Select,
+	}
+}
+
+// omits reports whether the entry e of directory dir is left out.
+func (f listingFilter) omits(dir string, e os.DirEntry) bool {
+	if e.IsDir() && f.noSubdirs {
+		return true
+	}
+	path := filepath.Join(dir, e.Name())
+	if hiddenName(e.Name(), f.hidden) || isStatsFile(path, f.statsFiles) {
+		return true
+	}
+	if f.selector.IsZero() {
+		return false
+	}
+	rel, err := filepath.Rel(f.root, path)
+	return err == nil && !f.selector.Selects(filepath.ToSlash(rel), e.IsDir())
+}
+
+// key identifies the filter in listing cache keys, so that servers with
+// different filters never share a rendered listing.
+func (f listingFilter) key() string {
+	return fmt.Sprintf("%q %q %v %q %+v %q", f.hidden, f.statsFiles, f.noSubdirs, f.root, f.selector, f.locale)
+}
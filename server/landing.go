@@ -0,0 +1,205 @@
+package newsserver
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	builder "github.com/go-i2p/newsgo/builder"
+)
+
+// DefaultLandingPage is the landing page serve --landing renders in place of
+// the listing of each root directory.  It is executed with a LandingData.
+var DefaultLandingPage = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Messages.LandingIntro}}</p>
+{{range .Channels}}<h2>{{.Name}}</h2>
+<table>
+<tr><th>{{$.Messages.Language}}</th><th>{{$.Messages.Feed}}</th><th>{{$.Messages.Signed}}</th><th>{{$.Messages.Details}}</th></tr>
+{{range .Feeds}}<tr><td>{{.Locale}}</td><td><a href="{{.Path}}">{{.Path}}</a></td><td>{{if .Su3}}<a href="{{.Su3}}">su3</a>{{end}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+{{else}}<p>{{.Messages.NoFeeds}}</p>
+{{end}}<h2>{{.Messages.Downloads}}</h2>
+<p><img src="` + statsGraphFilename + `" alt="{{.Messages.DownloadsAlt}}"></p>
+</body>
+</html>
+`))
+
+// LandingData is what a landing page template is executed with.
+type LandingData struct {
+	// Title is the page title.
+	Title string
+	// Locale is the locale negotiated for the request, and Messages the
+	// boilerplate of the page in it; see builder.MessagesFor.
+	Locale   string
+	Messages builder.Messages
+	// Channels holds the feeds found below the root, grouped by channel:
+	// the default tree first, then the platform/status directories.
+	Channels []LandingChannel
+}
+
+// LandingChannel is one channel on the landing page.
+type LandingChannel struct {
+	// Name is "default" for the top-level tree, otherwise the feed directory
+	// relative to the root, e.g. "mac/stable".
+	Name  string
+	Feeds []LandingFeed
+}
+
+// LandingFeed is one feed on the landing page.  Paths are relative to the
+// root, with forward slashes, so links resolve under any route prefix.
+type LandingFeed struct {
+	Path   string
+	Locale string
+	// Su3 is the signed feed next to Path; empty when there is none.
+	Su3 string
+	// Description summarises the build metadata sidecar of the feed, e.g.
+	// "12 entries, updated 2025-06-01, release 2.7.0"; empty without one.
+	Description string
+}
+
+// landingTitle is the Title of the landing page.
+const landingTitle = "I2P News"
+
+// feedLocale returns the locale of a feed file name: "en" for news.atom.xml,
+// "pt-BR" for news_pt_BR.atom.xml.  ok is false for other names.
+func feedLocale(name string) (locale string, ok bool) {
+	if name == canonicalFeedName {
+		return "en", true
+	}
+	if !strings.HasPrefix(name, "news_") || !strings.HasSuffix(name, ".atom.xml") {
+		return "", false
+	}
+	return strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(name, "news_"), ".atom.xml"), "_", "-"), true
+}
+
+// feedDescription summarises the metadata sidecar of the feed at path.
+func feedDescription(path string) string {
+	m, err := builder.ReadFeedMetadata(path)
+	if err != nil {
+		return ""
+	}
+	desc := fmt.Sprintf("%d entries", m.Entries)
+	if t, err := time.Parse(time.RFC3339, m.Updated); err == nil {
+		desc += ", updated " + t.Format(time.DateOnly)
+	}
+	if m.ReleaseVersion != "" {
+		desc += ", release " + m.ReleaseVersion
+	}
+	return desc
+}
+
+// collectLandingFeeds walks root and returns the feeds below it grouped by
+// channel.  Entries omit reports, such as hidden files, are skipped.
+func collectLandingFeeds(root string, omit func(path string, d fs.DirEntry) bool) ([]LandingChannel, error) {
+	byChannel := map[string][]LandingFeed{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && omit(path, d) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		locale, ok := feedLocale(d.Name())
+		if !ok {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		feed := LandingFeed{
+			Path:        filepath.ToSlash(rel),
+			Locale:      locale,
+			Description: feedDescription(path),
+		}
+		su3 := strings.TrimSuffix(path, ".atom.xml") + ".su3"
+		if _, err := os.Stat(su3); err == nil {
+			feed.Su3 = strings.TrimSuffix(feed.Path, ".atom.xml") + ".su3"
+		}
+		channel := filepath.ToSlash(filepath.Dir(rel))
+		if channel == "." {
+			channel = "default"
+		}
+		byChannel[channel] = append(byChannel[channel], feed)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("collectLandingFeeds: %w", err)
+	}
+	names := make([]string, 0, len(byChannel))
+	for name := range byChannel {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "default") != (names[j] == "default") {
+			return names[i] == "default"
+		}
+		return names[i] < names[j]
+	})
+	channels := make([]LandingChannel, 0, len(names))
+	for _, name := range names {
+		feeds := byChannel[name]
+		sort.Slice(feeds, func(i, j int) bool {
+			if (feeds[i].Locale == "en") != (feeds[j].Locale == "en") {
+				return feeds[i].Locale == "en"
+			}
+			return feeds[i].Locale < feeds[j].Locale
+		})
+		channels = append(channels, LandingChannel{Name: name, Feeds: feeds})
+	}
+	return channels, nil
+}
+
+// isRoot reports whether dir is NewsDir or the directory of a route, as
+// served for rq, whose listings LandingPage replaces.
+func (n *NewsServer) isRoot(rq *http.Request, dir string) bool {
+	if filepath.Clean(dir) == filepath.Clean(servedRoot(rq, n.NewsDir)) {
+		return true
+	}
+	for _, r := range n.Routes {
+		if filepath.Clean(dir) == filepath.Clean(servedRoot(rq, r.Dir)) {
+			return true
+		}
+	}
+	return false
+}
+
+// renderLanding returns the landing page of the root directory root in
+// locale.  The page walks the whole tree, so like a listing it is cached for
+// listingTTL.
+func (n *NewsServer) renderLanding(root, locale string) ([]byte, error) {
+	n.landingOnce.Do(func() { n.landing = newListingCache(listingTTL) })
+	return n.landing.do(root+"\x00"+locale, time.Time{}, func() ([]byte, error) {
+		channels, err := collectLandingFeeds(root, func(path string, d fs.DirEntry) bool {
+			return n.hidden(root, path)
+		})
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := n.LandingPage.Execute(&buf, LandingData{Title: landingTitle, Locale: locale, Messages: builder.MessagesFor(locale), Channels: channels}); err != nil {
+			return nil, fmt.Errorf("renderLanding: %w", err)
+		}
+		return buf.Bytes(), nil
+	})
+}
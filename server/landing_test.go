@@ -0,0 +1,85 @@
+package newsserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeLandingTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"news.atom.xml":            "x",
+		"news.su3":                 "x",
+		"news.json":                `{"entries": 12, "updated": "2025-06-01T00:00:00Z", "release_version": "2.7.0"}`,
+		"news_pt_BR.atom.xml":      "x",
+		"mac/stable/news.atom.xml": "x",
+		".staging/news.atom.xml":   "x",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestLandingPage verifies that the root is answered with the landing page,
+// listing every feed by channel with its su3 and metadata but no hidden
+// ones, while subdirectories keep their listings.
+func TestLandingPage(t *testing.T) {
+	dir := writeLandingTree(t)
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Hidden: DefaultHidden, LandingPage: DefaultLandingPage}
+
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("GET /: status %d", rw.Code)
+	}
+	body := rw.Body.String()
+	for _, want := range []string{
+		`<a href="news.atom.xml">`,
+		`<a href="news.su3">su3</a>`,
+		"12 entries, updated 2025-06-01, release 2.7.0",
+		"<td>pt-BR</td>",
+		"<h2>mac/stable</h2>",
+		`<a href="mac/stable/news.atom.xml">`,
+		statsGraphFilename,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("landing page lacks %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, ".staging") {
+		t.Errorf("landing page lists a hidden feed:\n%s", body)
+	}
+	if strings.Index(body, "<h2>default</h2>") > strings.Index(body, "<h2>mac/stable</h2>") {
+		t.Errorf("default channel not listed first:\n%s", body)
+	}
+
+	rw = httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/mac/", nil))
+	if strings.Contains(rw.Body.String(), "<h2>Downloads</h2>") {
+		t.Errorf("subdirectory answered with the landing page")
+	}
+}
+
+// TestLandingPage_Disabled verifies that the root keeps its listing without
+// LandingPage.
+func TestLandingPage_Disabled(t *testing.T) {
+	dir := writeLandingTree(t)
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Contains(rw.Body.String(), "<h2>Downloads</h2>") {
+		t.Errorf("landing page served without LandingPage")
+	}
+}
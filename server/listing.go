@@ -0,0 +1,173 @@
+package newsserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// listingTTL bounds how long a rendered directory listing is reused.  The
+// directory mtime only changes when entries are added, removed, or renamed,
+// not when an existing file is rewritten in place, so the TTL caps how stale
+// the sizes and checksums shown in a cached listing can become.
+const listingTTL = 10 * time.Second
+
+// listingEntry is one rendered directory listing together with the directory
+// modification time it was rendered against and when it was rendered.
+type listingEntry struct {
+	modTime  time.Time
+	rendered time.Time
+	body     []byte
+}
+
+// listingCall is an in-flight render that concurrent requests for the same
+// directory wait on instead of starting their own.
+type listingCall struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+// listingCache coalesces concurrent renders of the same directory and reuses
+// the result for up to ttl, as long as the directory mtime is unchanged.  A
+// burst of crawler hits on a large directory therefore walks and hashes the
+// directory once rather than once per request.
+type listingCache struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	items    map[string]listingEntry
+	inflight map[string]*listingCall
+}
+
+// newListingCache returns an empty listingCache whose entries expire after ttl.
+func newListingCache(ttl time.Duration) *listingCache {
+	return &listingCache{
+		ttl:      ttl,
+		items:    make(map[string]listingEntry),
+		inflight: make(map[string]*listingCall),
+	}
+}
+
+// do returns the cached listing for dir when it was rendered against modTime
+// less than ttl ago.  Otherwise it calls render, sharing a single call among
+// all concurrent callers for dir, and caches a successful result.  Errors are
+// returned to every waiting caller but never cached.
+func (c *listingCache) do(dir string, modTime time.Time, render func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if entry, ok := c.items[dir]; ok && entry.modTime.Equal(modTime) && time.Since(entry.rendered) < c.ttl {
+		c.mu.Unlock()
+		return entry.body, nil
+	}
+	if call, ok := c.inflight[dir]; ok {
+		c.mu.Unlock()
+		<-call.done
+		return call.body, call.err
+	}
+	call := &listingCall{done: make(chan struct{})}
+	c.inflight[dir] = call
+	c.mu.Unlock()
+
+	call.body, call.err = render()
+
+	c.mu.Lock()
+	delete(c.inflight, dir)
+	if call.err == nil {
+		c.items[dir] = listingEntry{modTime: modTime, rendered: time.Now(), body: call.body}
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.body, call.err
+}
+
+// clear drops every cached entry.  Renders in flight still complete and are
+// returned to their waiting callers.
+func (c *listingCache) clear() {
+	c.mu.Lock()
+	c.items = make(map[string]listingEntry)
+	c.mu.Unlock()
+}
+
+// globalListingCache is the package-level instance used by renderDirectory.
+// Like globalChecksumCache it lives outside NewsServer so that it is shared by
+// every handler in the process.
+var globalListingCache = newListingCache(listingTTL)
+
+// renderDirectory returns the HTML directory listing for dir without the
+// entries filter omits, served from globalListingCache when possible.
+func renderDirectory(dir string, filter listingFilter) ([]byte, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("renderDirectory: stat %s: %w", dir, err)
+	}
+	return globalListingCache.do(dir+"\x00"+filter.key(), fi.ModTime(), func() ([]byte, error) {
+		content, err := openDirectory(dir, filter)
+		if err != nil {
+			return nil, err
+		}
+		return hTML(content), nil
+	})
+}
+
+// listingETag returns a weak ETag for the listing of dir under filter, built
+// from the names and modification times of the entries it lists.  It changes
+// whenever an entry is added, removed, renamed, or rewritten, without
+// rendering the listing or hashing any file.  The ETag is weak because the
+// listing is not compared byte for byte: sizes and digests shown for a file
+// rewritten within its mtime's resolution may differ.
+func listingETag(dir string, filter listingFilter) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("listingETag: %w", err)
+	}
+	h := sha256.New()
+	fmt.Fprintln(h, filter.key())
+	for _, entry := range entries {
+		if filter.omits(dir, entry) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Gone since ReadDir; openDirectory skips it too.
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\n", entry.Name(), info.ModTime().UnixNano())
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// etagMatches reports whether the If-None-Match header value header matches
+// etag under the weak comparison RFC 9110 prescribes for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag of the listing of dir and, when it matches the
+// If-None-Match header of rq, answers 304 Not Modified and reports true.
+// Listings carry "Cache-Control: no-cache" so that clients revalidate them on
+// every use instead of guessing a freshness lifetime.
+func notModified(dir string, filter listingFilter, rw http.ResponseWriter, rq *http.Request) (bool, error) {
+	etag, err := listingETag(dir, filter)
+	if err != nil {
+		return false, err
+	}
+	rw.Header().Set("ETag", etag)
+	rw.Header().Set("Cache-Control", "no-cache")
+	if inm := rq.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag) {
+		// A 304 carries no body, so it must not claim one.
+		rw.Header().Del("Content-Type")
+		rw.WriteHeader(http.StatusNotModified)
+		return true, nil
+	}
+	return false, nil
+}
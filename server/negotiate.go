@@ -0,0 +1,92 @@
+package newsserver
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	builder "github.com/go-i2p/newsgo/builder"
+	"golang.org/x/text/language"
+)
+
+// canonicalFeedName is the base name of the English feed in every feed
+// directory.  Requests for it are subject to language negotiation.
+const canonicalFeedName = "news.atom.xml"
+
+// feedLocales returns the locales with a news_{locale}.atom.xml next to the
+// canonical feed in dir, as language tags, together with the matching file
+// paths.  English, served by the canonical file itself, is always first so
+// that it is the matcher's default.
+func feedLocales(dir string) ([]language.Tag, []string) {
+	tags := []language.Tag{language.English}
+	files := []string{filepath.Join(dir, canonicalFeedName)}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return tags, files
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, "news_") || !strings.HasSuffix(name, ".atom.xml") {
+			continue
+		}
+		raw := strings.ReplaceAll(strings.TrimSuffix(strings.TrimPrefix(name, "news_"), ".atom.xml"), "_", "-")
+		tag, err := language.Parse(raw)
+		if err != nil {
+			continue
+		}
+		tags = append(tags, tag)
+		files = append(files, filepath.Join(dir, name))
+	}
+	return tags, files
+}
+
+// negotiateFeed returns the feed file to serve for a request that resolved to
+// file.  For the canonical news.atom.xml it picks the best translation by the
+// ?lang= query parameter, when present, or else the Accept-Language header,
+// and sets the Vary and Content-Language headers accordingly.  The localized
+// feed is served in place under the canonical URL, so clients need not know
+// the news_{locale}.atom.xml naming.  Any other file is returned unchanged.
+func negotiateFeed(file string, rw http.ResponseWriter, rq *http.Request) string {
+	if filepath.Base(file) != canonicalFeedName {
+		return file
+	}
+	tags, files := feedLocales(filepath.Dir(file))
+	index := negotiate(tags, rw, rq)
+	return files[index]
+}
+
+// negotiate returns the index of the best of tags for rq by the ?lang= query
+// parameter, when present, or else the Accept-Language header, falling back
+// to the first, and sets the Vary and Content-Language headers accordingly.
+func negotiate(tags []language.Tag, rw http.ResponseWriter, rq *http.Request) int {
+	// Caches must key the response on the negotiated header; ?lang= is part
+	// of the URL and needs no Vary entry.
+	rw.Header().Add("Vary", "Accept-Language")
+	var want []language.Tag
+	if lang := rq.URL.Query().Get("lang"); lang != "" {
+		if tag, err := language.Parse(strings.ReplaceAll(lang, "_", "-")); err == nil {
+			want = []language.Tag{tag}
+		}
+	} else if accept := rq.Header.Get("Accept-Language"); accept != "" {
+		want, _, _ = language.ParseAcceptLanguage(accept)
+	}
+	_, index, conf := language.NewMatcher(tags).Match(want...)
+	if conf == language.No {
+		index = 0
+	}
+	rw.Header().Set("Content-Language", tags[index].String())
+	return index
+}
+
+// pageLocale returns the locale of builder.MessageLocales whose boilerplate
+// the generated page or chart answering rq is rendered with, negotiated like
+// the canonical feed.
+func pageLocale(rw http.ResponseWriter, rq *http.Request) string {
+	locales := builder.MessageLocales()
+	tags := make([]language.Tag, len(locales))
+	for i, locale := range locales {
+		tags[i] = language.Make(locale)
+	}
+	return locales[negotiate(tags, rw, rq)]
+}
@@ -0,0 +1,105 @@
+package newsserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestServeHTTP_FeedLanguageNegotiation verifies that /news.atom.xml serves
+// the best matching translation by ?lang= or Accept-Language, falls back to
+// English, and always varies on Accept-Language.
+func TestServeHTTP_FeedLanguageNegotiation(t *testing.T) {
+	dir := t.TempDir()
+	for name, body := range map[string]string{
+		"news.atom.xml":       "english",
+		"news_de.atom.xml":    "deutsch",
+		"news_pt_BR.atom.xml": "portugues",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+
+	cases := []struct {
+		target, accept string
+		body, lang     string
+	}{
+		{"/news.atom.xml", "", "english", "en"},
+		{"/news.atom.xml", "de-CH, en;q=0.5", "deutsch", "de"},
+		{"/news.atom.xml", "pt-BR", "portugues", "pt-BR"},
+		{"/news.atom.xml", "ja", "english", "en"},
+		{"/news.atom.xml?lang=pt_BR", "de", "portugues", "pt-BR"},
+		{"/news.atom.xml?lang=xx", "de", "english", "en"},
+		{"/news_de.atom.xml", "pt-BR", "deutsch", ""},
+	}
+	for _, tc := range cases {
+		rq := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.accept != "" {
+			rq.Header.Set("Accept-Language", tc.accept)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, rq)
+		if rec.Code != http.StatusOK || rec.Body.String() != tc.body {
+			t.Errorf("%s (Accept-Language %q): code %d body %q, want %q", tc.target, tc.accept, rec.Code, rec.Body.String(), tc.body)
+		}
+		if got := rec.Header().Get("Content-Language"); got != tc.lang {
+			t.Errorf("%s (Accept-Language %q): Content-Language %q, want %q", tc.target, tc.accept, got, tc.lang)
+		}
+		if vary := rec.Header().Get("Vary"); (tc.lang != "") != strings.Contains(vary, "Accept-Language") {
+			t.Errorf("%s: Vary = %q", tc.target, vary)
+		}
+	}
+}
+
+// TestServeHTTP_PageMessages verifies that directory listings and the landing
+// page are rendered with the boilerplate of the negotiated locale, and that
+// each locale is cached apart.
+func TestServeHTTP_PageMessages(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "news.atom.xml"), []byte("english"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	get := func(target, accept string) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			rq.Header.Set("Accept-Language", accept)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, rq)
+		return rec
+	}
+	cases := []struct {
+		target, accept, lang, want string
+	}{
+		{"/", "", "en", "Directory Listing:"},
+		{"/", "de-AT, en;q=0.5", "de", "Verzeichnisinhalt:"},
+		{"/?lang=pt_BR", "de", "pt", "Conteúdo do diretório:"},
+		{"/", "ja", "en", "Directory Listing:"},
+	}
+	for _, tc := range cases {
+		rec := get(tc.target, tc.accept)
+		if !strings.Contains(rec.Body.String(), tc.want) {
+			t.Errorf("%s (Accept-Language %q): listing lacks %q:\n%s", tc.target, tc.accept, tc.want, rec.Body.String())
+		}
+		if got := rec.Header().Get("Content-Language"); got != tc.lang {
+			t.Errorf("%s (Accept-Language %q): Content-Language %q, want %q", tc.target, tc.accept, got, tc.lang)
+		}
+		if !strings.Contains(rec.Header().Get("Vary"), "Accept-Language") {
+			t.Errorf("%s: Vary = %q, want Accept-Language", tc.target, rec.Header().Get("Vary"))
+		}
+	}
+
+	s.LandingPage = DefaultLandingPage
+	if body := get("/", "fr-CA").Body.String(); !strings.Contains(body, "<th>Langue</th>") || !strings.Contains(body, `<html lang="fr">`) {
+		t.Errorf("French landing page:\n%s", body)
+	}
+	if body := get("/", "").Body.String(); !strings.Contains(body, "<th>Language</th>") {
+		t.Errorf("English landing page:\n%s", body)
+	}
+}
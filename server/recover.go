@@ -0,0 +1,32 @@
+package newsserver
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// recoverPanic is deferred by ServeHTTP.  It turns a panic while answering
+// rq into a logged stack trace with the request it happened on, a count in
+// Protocols, and a 500 Internal Server Error, so that one bad request or
+// file cannot take the connection, or the server, down with it.  A response
+// already under way cannot become an error any more; its connection is
+// aborted instead, so the client does not take a truncated body for a
+// complete one.  http.ErrAbortHandler is passed on untouched.
+func (n *NewsServer) recoverPanic(sw *statusWriter, rq *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+	n.Protocols.Panic()
+	log.Printf("ServeHTTP: panic serving %s %q to %s: %v\n%s", rq.Method, rq.URL.Path, rq.RemoteAddr, v, debug.Stack())
+	if sw.status != 0 {
+		panic(http.ErrAbortHandler)
+	}
+	sw.Header().Del("Content-Length")
+	sw.Header().Del("Content-Encoding")
+	http.Error(sw, "Internal Server Error", http.StatusInternalServerError)
+}
@@ -0,0 +1,84 @@
+package newsserver
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// hotlinkExempt reports whether files of media type ftype may be fetched from
+// any referring site.  Routers and feed readers fetch su3 files and Atom feeds
+// directly, and other sites linking to them is the point of publishing them.
+func hotlinkExempt(ftype string) bool {
+	return ftype == "application/x-i2p-su3-news" || ftype == "application/atom+xml"
+}
+
+// hostOf returns the lowercased host of a URL or host:port string, without
+// the port.
+func hostOf(s string) string {
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		s = u.Host
+	}
+	if h, _, err := net.SplitHostPort(s); err == nil {
+		s = h
+	}
+	return strings.ToLower(strings.TrimSuffix(s, "."))
+}
+
+// hostAllowed reports whether host matches one of patterns.  A pattern is an
+// exact host name or "*.example.org", which matches example.org and all of
+// its subdomains.
+func hostAllowed(host string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if host == suffix || strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+// refererAllowed applies the HotlinkProtection policy to a request for a file
+// of media type ftype.  Requests without a Referer or Origin header (direct
+// downloads, routers, privacy-conscious browsers) are always allowed, as are
+// exempt media types.  Otherwise the referring host must be this server's own
+// host, the host of SiteURL, or match AllowedReferers.
+func (n *NewsServer) refererAllowed(ftype string, rq *http.Request) bool {
+	if !n.HotlinkProtection || hotlinkExempt(ftype) {
+		return true
+	}
+	ref := rq.Header.Get("Origin")
+	if ref == "" || ref == "null" {
+		ref = rq.Header.Get("Referer")
+	}
+	if ref == "" {
+		return true
+	}
+	host := hostOf(ref)
+	if host == hostOf(rq.Host) || (n.SiteURL != "" && host == hostOf(n.SiteURL)) {
+		return true
+	}
+	return hostAllowed(host, n.AllowedReferers)
+}
+
+// entryReferer reports whether rq entered the mirror from another site or
+// from no site at all, rather than by following a link between the
+// mirror's own pages, and returns the referring host, "" without one.
+func (n *NewsServer) entryReferer(rq *http.Request) (string, bool) {
+	ref := rq.Header.Get("Referer")
+	if ref == "" {
+		return "", true
+	}
+	host := hostOf(ref)
+	if host == hostOf(rq.Host) || (n.SiteURL != "" && host == hostOf(n.SiteURL)) {
+		return "", false
+	}
+	return host, true
+}
@@ -0,0 +1,205 @@
+package newsserver
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// DefaultLogFlush is how often serve flushes the counts of sampled requests
+// unless told otherwise.
+const DefaultLogFlush = time.Minute
+
+// RequestLog logs one line per request, except that runs of identical
+// requests — the same method, path, and status — are sampled, so a flood
+// shows up as a few lines and a summary rather than one line per request.
+type RequestLog struct {
+	// Sample maps a status class, 2 for 2xx through 5 for 5xx, to N: of the
+	// identical requests of that class seen between two flushes only the
+	// 1st, N+1st, 2N+1st, and so on are logged.  A class without an entry,
+	// or with N of 1 or less, logs every request.  See ParseLogSample.
+	Sample map[int]int
+	// Printf writes a log line; newslogger.Printf when nil.
+	Printf func(format string, v ...any)
+
+	mu   sync.Mutex
+	runs map[requestKey]*requestRun
+}
+
+// requestKey identifies a run of identical requests.
+type requestKey struct {
+	method, path string
+	status       int
+}
+
+// requestRun counts the requests of one run since the last flush.
+type requestRun struct {
+	seen, logged int
+}
+
+// ParseLogSample parses "class=N" specs such as "4xx=100" into the Sample
+// map of a RequestLog.  The class is one of 2xx, 3xx, 4xx, and 5xx; N must
+// be at least 1.
+func ParseLogSample(specs []string) (map[int]int, error) {
+	sample := make(map[int]int, len(specs))
+	for _, spec := range specs {
+		class, n, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("ParseLogSample: %q is not class=N", spec)
+		}
+		class = strings.ToLower(strings.TrimSpace(class))
+		if len(class) != 3 || class[1:] != "xx" || class[0] < '2' || class[0] > '5' {
+			return nil, fmt.Errorf("ParseLogSample: %q: class must be 2xx, 3xx, 4xx, or 5xx", spec)
+		}
+		every, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil || every < 1 {
+			return nil, fmt.Errorf("ParseLogSample: %q: N must be a positive number", spec)
+		}
+		sample[int(class[0]-'0')] = every
+	}
+	return sample, nil
+}
+
+// printf writes a line through Printf.
+func (l *RequestLog) printf(format string, v ...any) {
+	if l.Printf != nil {
+		l.Printf(format, v...)
+		return
+	}
+	newslogger.Printf(format, v...)
+}
+
+// Log records a request answered with status, logging it unless it is
+// sampled out.
+func (l *RequestLog) Log(rq *http.Request, status int) {
+	key := requestKey{method: rq.Method, path: rq.URL.Path, status: status}
+	every := l.Sample[status/100]
+	l.mu.Lock()
+	if l.runs == nil {
+		l.runs = make(map[requestKey]*requestRun)
+	}
+	run, ok := l.runs[key]
+	if !ok {
+		run = &requestRun{}
+		l.runs[key] = run
+	}
+	run.seen++
+	log := every <= 1 || (run.seen-1)%every == 0
+	if log {
+		run.logged++
+	}
+	l.mu.Unlock()
+	if log {
+		l.printf("%s %s %s %d", rq.RemoteAddr, key.method, key.path, key.status)
+	}
+}
+
+// Flush logs one summary line for every run with requests that were sampled
+// out since the last flush, most requests first, and starts counting anew.
+func (l *RequestLog) Flush() {
+	l.mu.Lock()
+	runs := l.runs
+	l.runs = nil
+	l.mu.Unlock()
+	type summary struct {
+		requestKey
+		requestRun
+	}
+	var sampled []summary
+	for key, run := range runs {
+		if run.seen > run.logged {
+			sampled = append(sampled, summary{key, *run})
+		}
+	}
+	sort.Slice(sampled, func(i, j int) bool {
+		if sampled[i].seen != sampled[j].seen {
+			return sampled[i].seen > sampled[j].seen
+		}
+		a, b := sampled[i].requestKey, sampled[j].requestKey
+		if a.path != b.path {
+			return a.path < b.path
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	for _, s := range sampled {
+		l.printf("%d x %s %s %d (%d logged)", s.seen, s.method, s.path, s.status, s.logged)
+	}
+}
+
+// Run calls Flush every interval until stop is closed, then flushes once
+// more.
+func (l *RequestLog) Run(interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			l.Flush()
+		case <-stop:
+			l.Flush()
+			return
+		}
+	}
+}
+
+// statusWriter records the status written through an http.ResponseWriter.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	// firstByte is when the response started, for NewsServer.Latency.
+	firstByte time.Time
+	// class is the stats content class of the response, set by ServeFile;
+	// responses without one are not observed in NewsServer.Latency.
+	class string
+}
+
+// started records the start of the response.
+func (w *statusWriter) started() {
+	if w.firstByte.IsZero() {
+		w.firstByte = time.Now()
+	}
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.started()
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.started()
+	return w.ResponseWriter.Write(b)
+}
+
+// ReadFrom keeps the io.ReaderFrom fast path of the wrapped writer, which
+// lets su3 downloads use sendfile.
+func (w *statusWriter) ReadFrom(r io.Reader) (int64, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.started()
+	if rf, ok := w.ResponseWriter.(io.ReaderFrom); ok {
+		return rf.ReadFrom(r)
+	}
+	return io.Copy(struct{ io.Writer }{w.ResponseWriter}, r)
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,62 @@
+package newsserver
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Route serves the build tree in Dir under the URL path prefix Prefix, so
+// that several trees — separate statuses, or trees signed with different
+// keys — can share one destination.
+type Route struct {
+	Prefix string
+	Dir    string
+}
+
+// ParseRoutes parses "prefix=dir" specs such as "/beta=build-beta" into
+// routes ordered longest prefix first, so that the most specific route wins.
+// A prefix must start with "/", must not be "/" itself (NewsDir serves the
+// root), and may be given only once.
+func ParseRoutes(specs []string) ([]Route, error) {
+	routes := make([]Route, 0, len(specs))
+	seen := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		prefix, dir, ok := strings.Cut(spec, "=")
+		if !ok || dir == "" {
+			return nil, fmt.Errorf("ParseRoutes: %q is not prefix=dir", spec)
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("ParseRoutes: prefix %q must start with /", prefix)
+		}
+		prefix = path.Clean(prefix)
+		if prefix == "/" {
+			return nil, fmt.Errorf("ParseRoutes: %q routes the root; use the news directory instead", spec)
+		}
+		if seen[prefix] {
+			return nil, fmt.Errorf("ParseRoutes: prefix %s routed twice", prefix)
+		}
+		seen[prefix] = true
+		routes = append(routes, Route{Prefix: prefix, Dir: dir})
+	}
+	sort.SliceStable(routes, func(i, j int) bool {
+		return len(routes[i].Prefix) > len(routes[j].Prefix)
+	})
+	return routes, nil
+}
+
+// route returns the directory that serves urlPath and the part of urlPath
+// below it: the first of Routes whose prefix is urlPath or a parent of it,
+// or NewsDir and urlPath unchanged.
+func (n *NewsServer) route(urlPath string) (dir, rest string) {
+	for _, r := range n.Routes {
+		if urlPath == r.Prefix {
+			return r.Dir, "/"
+		}
+		if rest, ok := strings.CutPrefix(urlPath, r.Prefix+"/"); ok {
+			return r.Dir, "/" + rest
+		}
+	}
+	return n.NewsDir, urlPath
+}
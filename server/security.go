@@ -0,0 +1,50 @@
+package newsserver
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultSecurityHeaders are the headers --security-headers adds to HTML
+// responses: directory listings, the landing page, and .html files.  The
+// policy allows the pages' own images and inline styles and nothing else.
+// Referrer-Policy keeps the Referer between the mirror's own pages, which
+// the visit stats rely on (see entryReferer), and drops it for other sites.
+var DefaultSecurityHeaders = http.Header{
+	"Content-Security-Policy": {"default-src 'none'; img-src 'self'; style-src 'self' 'unsafe-inline'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"},
+	"X-Content-Type-Options":  {"nosniff"},
+	"Referrer-Policy":         {"same-origin"},
+	"X-Frame-Options":         {"DENY"},
+}
+
+// ParseSecurityHeaders returns base with the "Name=Value" pairs of specs
+// applied, e.g. "Content-Security-Policy=default-src 'self'".  A pair
+// replaces the header of the same name; an empty value, as in
+// "X-Frame-Options=", removes it.  base itself is not modified.
+func ParseSecurityHeaders(base http.Header, specs []string) (http.Header, error) {
+	headers := base.Clone()
+	if headers == nil {
+		headers = http.Header{}
+	}
+	for _, spec := range specs {
+		name, value, ok := strings.Cut(spec, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t:") {
+			return nil, fmt.Errorf("ParseSecurityHeaders: %q is not a Name=Value pair", spec)
+		}
+		if value = strings.TrimSpace(value); value == "" {
+			headers.Del(name)
+			continue
+		}
+		headers.Set(name, value)
+	}
+	return headers, nil
+}
+
+// setSecurityHeaders adds SecurityHeaders to the HTML response rw.
+func (n *NewsServer) setSecurityHeaders(rw http.ResponseWriter) {
+	for name, values := range n.SecurityHeaders {
+		rw.Header()[name] = values
+	}
+}
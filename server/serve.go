@@ -4,7 +4,9 @@ package newsserver
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
 	"log"
 	"mime"
@@ -15,6 +17,8 @@ import (
 	"sync"
 	"time"
 
+	builder "github.com/go-i2p/newsgo/builder"
+	newslogger "github.com/go-i2p/newsgo/logger"
 	stats "github.com/go-i2p/newsgo/server/stats"
 	"gitlab.com/golang-commonmark/markdown"
 )
@@ -27,6 +31,35 @@ import (
 // receive HTTP 404 if no matching file exists.
 const statsGraphFilename = "langstats.svg"
 
+// langStatsFilename is the URL-path basename of the per-language download
+// counts and last download times, rendered from NewsServer.Stats as JSON.  It
+// does not exist on disk.
+const langStatsFilename = "langstats.json"
+
+// protocolStatsFilename is the URL-path basename of the JSON protocol usage
+// counters rendered from NewsServer.Protocols.  Like statsGraphFilename it is
+// generated on demand and never exists on disk.
+const protocolStatsFilename = "protocols.json"
+
+// contentStatsGraphFilename and contentStatsFilename are the URL-path
+// basenames of the per-content-class request counts, rendered from
+// NewsServer.Stats as an SVG bar chart and as JSON respectively.  Neither
+// exists on disk.
+const (
+	contentStatsGraphFilename = "contentstats.svg"
+	contentStatsFilename      = "contentstats.json"
+)
+
+// latencyStatsFilename is the URL-path basename of the request latency
+// histograms per content class, rendered from NewsServer.Latency as JSON.  It
+// does not exist on disk.
+const latencyStatsFilename = "latency.json"
+
+// visitStatsFilename is the URL-path basename of the machine and human
+// request totals and the entry pages and referers of human page views,
+// rendered from NewsServer.Stats as JSON.  It does not exist on disk.
+const visitStatsFilename = "visits.json"
+
 // checksumEntry holds a single cached SHA-256 digest together with the file
 // modification time used to detect stale entries.
 type checksumEntry struct {
@@ -72,6 +105,89 @@ var globalChecksumCache = &checksumCache{
 type NewsServer struct {
 	NewsDir string
 	Stats   stats.NewsStats
+	// Protocols counts requests per HTTP version and connections accepted.
+	// Pass Protocols.ConnState to http.Server.ConnState to count connections.
+	Protocols stats.ProtocolStats
+	// Latency keeps request latency histograms per content class, served as
+	// latency.json.
+	Latency stats.LatencyStats
+	// SiteURL is the public base URL of a clearnet mirror, e.g.
+	// "https://news.example.org".  When set, /sitemap.xml and /robots.txt are
+	// generated for crawlers unless NewsDir provides its own.
+	SiteURL string
+	// ContentValidators derives the ETag and Last-Modified of static files
+	// from their SHA-256 digest instead of the file mtime, so a rebuild that
+	// rewrites identical feeds keeps answering conditional GETs with 304.
+	ContentValidators bool
+	// ListingETags gives directory listings a weak ETag derived from the
+	// names and modification times of their entries and answers a matching
+	// If-None-Match with 304 Not Modified, so mirrors polling a listing do
+	// not download it again while it is unchanged.
+	ListingETags bool
+	// HotlinkProtection answers 403 Forbidden to requests referred by other
+	// sites for anything but su3 files and Atom feeds, so pages, charts, and
+	// other assets of a clearnet mirror cannot be embedded elsewhere.  The
+	// server's own host and the host of SiteURL are always allowed.
+	HotlinkProtection bool
+	// AllowedReferers lists further referring hosts allowed under
+	// HotlinkProtection, as exact names or "*.example.org" patterns.
+	AllowedReferers []string
+	// Routes serves further build trees under URL path prefixes, e.g. a beta
+	// tree under /beta.  A request under a route's prefix is resolved against
+	// the route's Dir with the prefix removed; see ParseRoutes.
+	Routes []Route
+	// Aliases answer legacy URL paths, such as the /news/news.su3 of older
+	// routers, with the files of the current layout; see ParseAliases.
+	Aliases []Alias
+	// Allowlist, when non-nil, answers 403 Forbidden to every client it does
+	// not allow, for private staging mirrors; see ParseAllowlist.
+	Allowlist *Allowlist
+	// Hub, when non-nil, is a built-in WebSub hub answering at WebSubPath
+	// and advertised in the Link headers of every Atom feed; see NewHub.
+	Hub *Hub
+	// Hidden lists name patterns, such as ".*" or "*.tmp", of files and
+	// directories that are left out of listings and answered 404 Not Found;
+	// see ParseHidden.  The stats files are always hidden.
+	Hidden []string
+	// MaxListingDepth, when positive, is the deepest directory below a root
+	// that is listed: 1 lists "mac/" but not "mac/stable/".  Deeper listings
+	// are answered 404; the files in them are still served.
+	MaxListingDepth int
+	// LandingPage, when non-nil, replaces the listing of NewsDir and of
+	// every route directory with a page rendered from it, listing the feeds
+	// of the tree; see DefaultLandingPage and LandingData.  Subdirectories
+	// keep their listings.
+	LandingPage *template.Template
+	// Select, when not zero, restricts the served tree to the feeds of one
+	// platform, status, or set of languages: everything else is left out of
+	// listings and answered 404 Not Found, like Hidden.
+	Select builder.FeedSelector
+	// RequestLog, when non-nil, logs every request with its status, sampling
+	// floods of identical requests; see RequestLog.
+	RequestLog *RequestLog
+	// SecurityHeaders are added to HTML responses only: directory listings,
+	// the landing page, and .html files.  Feeds, su3 files, charts, and
+	// stats are left alone.  See DefaultSecurityHeaders.
+	SecurityHeaders http.Header
+	// ResolveCurrent treats NewsDir and the route directories as "current"
+	// symlinks that a release pipeline repoints atomically once a new tree
+	// is built and signed.  Each request resolves them once and is served
+	// entirely from their targets, so no client sees a mix of two trees.
+	ResolveCurrent bool
+	// NoListings answers 404 Not Found to every directory instead of listing
+	// it.  A root whose listing LandingPage replaces keeps its landing page.
+	NoListings bool
+	// Symlinks decides which symlinks below NewsDir and the route
+	// directories are followed; by default only those that stay inside the
+	// tree.  See SymlinkPolicy.
+	Symlinks SymlinkPolicy
+
+	// landing caches rendered landing pages; see renderLanding.
+	landingOnce sync.Once
+	landing     *listingCache
+	// graphs caches rendered stats charts; see renderGraph.
+	graphsOnce sync.Once
+	graphs     *listingCache
 }
 
 var serveTest http.Handler = &NewsServer{}
@@ -85,30 +201,121 @@ func containsPath(root, target string) bool {
 	}
 	// Prefix match: target must start with root followed by the OS path
 	// separator so that a root of "/srv/news" does not falsely contain
-	// "/srv/news-extra/secret".
-	return strings.HasPrefix(target, root+string(filepath.Separator))
+	// "/srv/news-extra/secret".  A filesystem, drive, or UNC share root
+	// (/, C:\, \\srv\share\) already ends in the separator.
+	if !strings.HasSuffix(root, string(filepath.Separator)) {
+		root += string(filepath.Separator)
+	}
+	return strings.HasPrefix(target, root)
 }
 
-// ServeHTTP implements http.Handler. It resolves the request URL path against
-// NewsDir, rejects path traversal attempts, and delegates to ServeFile.
+// ServeHTTP implements http.Handler. It rejects clients Allowlist does not
+// allow, resolves the request URL path against NewsDir, or the directory of
+// the matching route, rejects path traversal attempts, substitutes the
+// negotiated translation for news.atom.xml, and delegates to ServeFile.
+// A panic is recovered and answered 500; see recoverPanic.  The request is
+// logged to RequestLog when set.
 func (n *NewsServer) ServeHTTP(rw http.ResponseWriter, rq *http.Request) {
-	path := rq.URL.Path
-	file := filepath.Join(n.NewsDir, path)
-	// Reject any request whose resolved path escapes NewsDir.  filepath.Join
+	start := time.Now()
+	sw := &statusWriter{ResponseWriter: rw}
+	defer func() {
+		if sw.class == "" {
+			return
+		}
+		end := time.Now()
+		firstByte := end
+		if !sw.firstByte.IsZero() {
+			firstByte = sw.firstByte
+		}
+		n.Latency.Observe(sw.class, firstByte.Sub(start), end.Sub(start))
+	}()
+	if n.RequestLog != nil {
+		defer func() {
+			if sw.status == 0 {
+				sw.status = http.StatusOK
+			}
+			n.RequestLog.Log(rq, sw.status)
+		}()
+	}
+	defer n.recoverPanic(sw, rq)
+	n.serveHTTP(sw, rq)
+}
+
+// serveHTTP answers rq; see ServeHTTP.
+func (n *NewsServer) serveHTTP(rw http.ResponseWriter, rq *http.Request) {
+	n.Protocols.Observe(rq)
+	rq = n.resolveRoots(rq)
+	if !n.Allowlist.Allows(rq) {
+		newslogger.Verbosef("ServeHTTP: client not allowlisted: %q %q", rq.RemoteAddr, rq.URL.Path)
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if canonical := collapseSlashes(rq.URL.Path); canonical != rq.URL.Path {
+		redirectCanonical(rw, rq, canonical)
+		return
+	}
+	rq = n.rewriteAlias(rq)
+	if n.Hub != nil && rq.URL.Path == WebSubPath {
+		n.Hub.ServeHTTP(rw, rq)
+		return
+	}
+	if n.serveSiteFile(rw, rq) {
+		return
+	}
+	root, path := n.route(rq.URL.Path)
+	root = servedRoot(rq, root)
+	file := filepath.Join(root, path)
+	// Reject any request whose resolved path escapes its root.  filepath.Join
 	// calls filepath.Clean which resolves ".." components, so comparing the
-	// cleaned result against the cleaned NewsDir root is sufficient.
-	newsDir := filepath.Clean(n.NewsDir)
+	// cleaned result against the cleaned root is sufficient.
+	newsDir := filepath.Clean(root)
 	if !containsPath(newsDir, file) {
 		log.Printf("ServeHTTP: path traversal rejected: %q", rq.URL.Path)
 		http.Error(rw, "Bad Request", http.StatusBadRequest)
 		return
 	}
+	// A checksum request is checked against the file it names, so a hidden
+	// or unselected file has no checksum either.
+	target, checksum := checksumTarget(file, rq)
+	if checksum {
+		file = target
+	}
+	if n.hidden(newsDir, file) || n.unlistedDir(newsDir, file) {
+		newslogger.Verbosef("ServeHTTP: hidden path: %q", rq.URL.Path)
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if n.symlinkRefused(rw, rq, newsDir, file) {
+		return
+	}
+	if checksum {
+		n.serveChecksum(file, rw)
+		return
+	}
+	if canonical, ok := slashPath(rq.URL.Path, file); ok {
+		redirectCanonical(rw, rq, canonical)
+		return
+	}
+	file = negotiateFeed(file, rw, rq)
 	if err := fileCheck(file); err != nil {
-		log.Println("ServeHTTP:", err.Error())
+		newslogger.Verbosef("ServeHTTP: %v", err)
 		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
 		rw.WriteHeader(http.StatusNotFound)
 		return
 	}
+	// The negotiated translation is a file of its own, checked again.
+	if n.symlinkRefused(rw, rq, newsDir, file) {
+		return
+	}
+	if ftype, err := fileType(file); err == nil && !n.refererAllowed(ftype, rq) {
+		newslogger.Verbosef("ServeHTTP: hotlink rejected: %q from %q", rq.URL.Path, rq.Referer())
+		http.Error(rw, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if n.Hub != nil && strings.HasSuffix(file, ".atom.xml") {
+		n.Hub.advertise(rw, rq.URL.Path, file)
+	}
 	if err := n.ServeFile(file, rq, rw); err != nil {
 		log.Println("ServeHTTP:", err.Error())
 		// Reset Content-Type so that error responses do not carry a feed-
@@ -126,7 +333,10 @@ func fileCheck(file string) error {
 	// statsGraphFilename is generated on-demand by Stats.Graph and never
 	// written to disk, so skip the existence check for that one name only.
 	// Every other *.svg path (and all other extensions) must pass os.Stat.
-	if filepath.Base(file) == statsGraphFilename {
+	// protocolStatsFilename and the content stats are likewise rendered
+	// from memory.
+	switch filepath.Base(file) {
+	case statsGraphFilename, langStatsFilename, protocolStatsFilename, latencyStatsFilename, contentStatsGraphFilename, contentStatsFilename, visitStatsFilename:
 		return nil
 	}
 	if _, err := os.Stat(file); err != nil {
@@ -157,6 +367,12 @@ func fileType(file string) (string, error) {
 		return "application/rss+xml", nil
 	case ".svg":
 		return "image/svg+xml", nil
+	case checksumSuffix:
+		// Published checksums, as served for ".sha256" requests.
+		return "text/plain; charset=utf-8", nil
+	case ".opml":
+		// The builder's feed index; not in Go's MIME table.
+		return "text/x-opml", nil
 	default:
 		// Consult the OS / Go built-in MIME type database before falling back
 		// to application/octet-stream. This ensures that CSS, JS, PNG and
@@ -205,15 +421,18 @@ func fileChecksum(path string) (string, error) {
 
 // buildDirectoryHeader generates the Markdown heading block for a directory
 // listing page: the base name of wd as the title, a ruler of equal signs, and
-// fixed preamble lines including the language-stats SVG and a bold section label.
-func buildDirectoryHeader(wd string) string {
+// fixed preamble lines including the language-stats SVG and a bold section
+// label, the DirectoryListing of msgs.
+func buildDirectoryHeader(wd string, msgs builder.Messages) string {
 	base := filepath.Base(wd)
 	header := fmt.Sprintf("%s\n", base)
 	header += fmt.Sprintf("%s\n", head(len(base)))
 	header += fmt.Sprintf("%s\n", "")
 	header += fmt.Sprintf("%s\n", "![language stats](langstats.svg)")
 	header += fmt.Sprintf("%s\n", "")
-	header += fmt.Sprintf("%s\n", "**Directory Listing:**")
+	header += fmt.Sprintf("%s\n", "![content type stats](contentstats.svg)")
+	header += fmt.Sprintf("%s\n", "")
+	header += fmt.Sprintf("**%s**\n", msgs.DirectoryListing)
 	header += fmt.Sprintf("%s\n", "")
 	return header
 }
@@ -237,20 +456,47 @@ func formatEntryLine(wd string, entry os.DirEntry, info os.FileInfo) string {
 		log.Println("Listing error:", err)
 		sum = "(checksum unavailable)"
 	}
-	return fmt.Sprintf(" - [%s](%s) : `%d` : `%s` - `%s`\n", entry.Name(), entry.Name(), info.Size(), info.Mode(), sum)
+	line := fmt.Sprintf(" - [%s](%s) : `%d` : `%s` - `%s`", entry.Name(), entry.Name(), info.Size(), info.Mode(), sum)
+	return line + feedSummary(xname) + "\n"
 }
 
-// openDirectory returns a Markdown directory listing for wd. It returns an
-// error rather than calling log.Fatal so that callers inside HTTP handlers
-// can surface a proper HTTP error response instead of killing the process.
-func openDirectory(wd string) (string, error) {
+// feedSummary returns the entry count, newest entry, and release version from
+// the metadata sidecar of the feed at path, formatted for appending to its
+// listing line.  Files that are not feeds, or feeds without a readable
+// sidecar, get an empty summary.
+func feedSummary(path string) string {
+	if !strings.HasSuffix(path, ".atom.xml") {
+		return ""
+	}
+	m, err := builder.ReadFeedMetadata(path)
+	if err != nil {
+		return ""
+	}
+	summary := fmt.Sprintf(" : %d entries", m.Entries)
+	if m.NewestEntry != "" {
+		summary += ", newest " + m.NewestEntry
+	}
+	if m.ReleaseVersion != "" {
+		summary += ", release " + m.ReleaseVersion
+	}
+	return summary
+}
+
+// openDirectory returns a Markdown directory listing for wd without the
+// entries filter omits. It returns an error rather than calling log.Fatal so
+// that callers inside HTTP handlers can surface a proper HTTP error response
+// instead of killing the process.
+func openDirectory(wd string, filter listingFilter) (string, error) {
 	files, err := os.ReadDir(wd)
 	if err != nil {
 		return "", fmt.Errorf("openDirectory: %w", err)
 	}
-	log.Println("Navigating directory:", wd)
-	readme := buildDirectoryHeader(wd)
+	newslogger.Verbosef("Navigating directory: %s", wd)
+	readme := buildDirectoryHeader(wd, builder.MessagesFor(filter.locale))
 	for _, entry := range files {
+		if filter.omits(wd, entry) {
+			continue
+		}
 		info, err := entry.Info()
 		if err != nil {
 			log.Println("Listing: stat error:", err)
@@ -274,17 +520,63 @@ func head(num int) string {
 	return r
 }
 
-// serveDirectory generates a Markdown directory listing for file, converts it
-// to HTML, and writes the result to rw.
-func serveDirectory(file string, rw http.ResponseWriter) error {
-	content, err := openDirectory(file)
+// serveDirectory writes the HTML directory listing for file, filtered by
+// filter, to rw.  Listings are rendered through renderDirectory, which
+// coalesces concurrent requests for the same directory and briefly caches the
+// result.  With etags set the listing carries a weak ETag, and a request
+// whose If-None-Match matches it is answered 304 Not Modified without
+// rendering it.
+func serveDirectory(file string, filter listingFilter, etags bool, rw http.ResponseWriter, rq *http.Request) error {
+	if etags {
+		done, err := notModified(file, filter, rw, rq)
+		if err != nil {
+			return fmt.Errorf("ServeFile: %w", err)
+		}
+		if done {
+			return nil
+		}
+	}
+	body, err := renderDirectory(file, filter)
 	if err != nil {
 		return fmt.Errorf("ServeFile: %w", err)
 	}
-	rw.Write(hTML(content)) //nolint:errcheck
+	rw.Write(body) //nolint:errcheck
 	return nil
 }
 
+// contentStamp records when a file was first seen with a given digest.
+type contentStamp struct {
+	sum     string
+	modTime time.Time
+}
+
+// contentStamps maps file paths to the first-seen time of their current
+// content.  Like globalChecksumCache it lives for the process lifetime.
+var contentStamps = struct {
+	mu    sync.Mutex
+	items map[string]contentStamp
+}{items: make(map[string]contentStamp)}
+
+// contentValidators returns a strong ETag built from the SHA-256 digest of the
+// file at path and the modification time of its current content: the mtime
+// of the first observation of this digest, which a rewrite with identical
+// bytes does not advance.  The digest comes from fileChecksum, so it is only
+// recomputed when the mtime changes.
+func contentValidators(path string, fi os.FileInfo) (etag string, modTime time.Time, err error) {
+	sum, err := fileChecksum(path)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	contentStamps.mu.Lock()
+	defer contentStamps.mu.Unlock()
+	prev, ok := contentStamps.items[path]
+	if !ok || prev.sum != sum {
+		prev = contentStamp{sum: sum, modTime: fi.ModTime()}
+		contentStamps.items[path] = prev
+	}
+	return `"` + sum + `"`, prev.modTime, nil
+}
+
 // serveStaticFile streams the regular file at path to rw using
 // http.ServeContent, which:
 //
@@ -295,7 +587,21 @@ func serveDirectory(file string, rw http.ResponseWriter) error {
 //
 // The Content-Type header must already be set on rw before this is called
 // (ServeFile does this); http.ServeContent will not override an existing value.
-func serveStaticFile(file, ftype string, rw http.ResponseWriter, rq *http.Request) error {
+//
+// When validators is true the ETag and Last-Modified come from
+// contentValidators; http.ServeContent then evaluates If-None-Match against
+// the ETag.
+//
+// rw must keep the io.ReaderFrom of net/http's ResponseWriter and the body
+// must be the *os.File itself.  ServeContent copies the body with io.CopyN,
+// and that ReadFrom hands an *os.File source to the kernel's sendfile on TCP
+// listeners.  ServeHTTP always wraps rw in a statusWriter, which forwards
+// ReadFrom, and Unwrap for http.ResponseController, so sendfile is kept.  A
+// wrapper that hides ReadFrom, or a body other than an *os.File, silently
+// falls back to a userspace copy through a 32 KiB buffer for every su3
+// download.  Garlic (SAM) connections are not kernel sockets, so on the I2P
+// listener the copy is always done in userspace.
+func serveStaticFile(file, ftype string, validators bool, rw http.ResponseWriter, rq *http.Request) error {
 	f, err := os.Open(file)
 	if err != nil {
 		return fmt.Errorf("ServeFile: %s", err)
@@ -305,16 +611,44 @@ func serveStaticFile(file, ftype string, rw http.ResponseWriter, rq *http.Reques
 	if err != nil {
 		return fmt.Errorf("ServeFile: stat %s: %w", file, err)
 	}
-	log.Println("ServeFile:", file, ftype)
+	modTime := fi.ModTime()
+	if validators {
+		etag, contentTime, err := contentValidators(file, fi)
+		if err != nil {
+			return fmt.Errorf("ServeFile: %w", err)
+		}
+		rw.Header().Set("ETag", etag)
+		modTime = contentTime
+	}
+	newslogger.Verbosef("ServeFile: %s %s", file, ftype)
 	// http.ServeContent streams content and handles conditional/range GETs.
 	// It uses the Content-Type already set in rw.Header() and will not sniff
 	// or override it.
-	http.ServeContent(rw, rq, filepath.Base(file), fi.ModTime(), f)
+	http.ServeContent(rw, rq, filepath.Base(file), modTime, f)
 	return nil
 }
 
+// contentClass returns the stats content class of a request for a file of
+// media type ftype; isDir reports whether the path is a directory listing.
+func contentClass(ftype string, isDir bool) string {
+	switch {
+	case isDir:
+		return stats.ClassListing
+	case ftype == "application/atom+xml":
+		return stats.ClassAtom
+	case ftype == "application/x-i2p-su3-news":
+		return stats.ClassSu3
+	case ftype == "image/svg+xml":
+		return stats.ClassSVG
+	case ftype == "text/html":
+		return stats.ClassPage
+	default:
+		return stats.ClassOther
+	}
+}
+
 // ServeFile determines the content type of file, increments su3 download
-// statistics when appropriate, writes the Content-Type header, and either
+// and content-class statistics, writes the Content-Type header, and either
 // renders an HTML directory listing or streams the file contents to rw.
 func (n *NewsServer) ServeFile(file string, rq *http.Request, rw http.ResponseWriter) error {
 	ftype, err := fileType(file)
@@ -332,16 +666,81 @@ func (n *NewsServer) ServeFile(file string, rq *http.Request, rw http.ResponseWr
 	// Only the canonical stats-graph basename is rendered dynamically.
 	// An actual .svg file on disk (e.g. a logo) should be served as a
 	// static file through the normal path below.
-	if filepath.Base(file) == statsGraphFilename {
-		// Graph buffers the render internally; it only writes to rw when
-		// rendering succeeds, so a failure here means no bytes have been
-		// committed yet and we can safely send an HTTP 500 response.
-		if err := n.Stats.Graph(rw); err != nil {
+	if base := filepath.Base(file); base == statsGraphFilename || base == contentStatsGraphFilename {
+		n.Stats.IncrementClass(stats.ClassSVG)
+		// The chart is rendered into memory, so a failure here means no
+		// bytes have been committed yet and we can still send an error.
+		// Charts are SVG unless the client asks for PNG, so caches must
+		// key them on Accept.
+		rw.Header().Add("Vary", "Accept")
+		png := prefersPNG(rq.Header.Get("Accept"))
+		img, err := n.renderGraph(base, rq, png, pageLocale(rw, rq))
+		switch {
+		case errors.Is(err, errGraphFilter):
+			rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			rw.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(rw, "Bad Request:", err)
+		case err != nil:
 			log.Printf("ServeFile: stats graph render failed: %v", err)
 			rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
 			rw.WriteHeader(http.StatusInternalServerError)
 			fmt.Fprintln(rw, "Internal Server Error")
+		default:
+			if png {
+				rw.Header().Set("Content-Type", "image/png")
+			}
+			rw.Write(img) //nolint:errcheck
+		}
+		return nil
+	}
+	if filepath.Base(file) == protocolStatsFilename {
+		n.Stats.IncrementClass(stats.ClassOther)
+		data, err := n.Protocols.JSON()
+		if err != nil {
+			return fmt.Errorf("ServeFile: protocol stats: %w", err)
 		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(data) //nolint:errcheck
+		return nil
+	}
+	if filepath.Base(file) == latencyStatsFilename {
+		n.Stats.IncrementClass(stats.ClassOther)
+		data, err := n.Latency.JSON()
+		if err != nil {
+			return fmt.Errorf("ServeFile: latency stats: %w", err)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(data) //nolint:errcheck
+		return nil
+	}
+	if filepath.Base(file) == langStatsFilename {
+		n.Stats.IncrementClass(stats.ClassOther)
+		data, err := n.Stats.LangsJSON()
+		if err != nil {
+			return fmt.Errorf("ServeFile: language stats: %w", err)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(data) //nolint:errcheck
+		return nil
+	}
+	if filepath.Base(file) == visitStatsFilename {
+		n.Stats.IncrementClass(stats.ClassOther)
+		data, err := n.Stats.VisitsJSON()
+		if err != nil {
+			return fmt.Errorf("ServeFile: visit stats: %w", err)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(data) //nolint:errcheck
+		return nil
+	}
+	if filepath.Base(file) == contentStatsFilename {
+		n.Stats.IncrementClass(stats.ClassOther)
+		data, err := n.Stats.ClassesJSON()
+		if err != nil {
+			return fmt.Errorf("ServeFile: content stats: %w", err)
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(data) //nolint:errcheck
 		return nil
 	}
 	// Check whether the path is a directory. The os.Stat error must not be
@@ -351,20 +750,53 @@ func (n *NewsServer) ServeFile(file string, rq *http.Request, rw http.ResponseWr
 	if err != nil {
 		return fmt.Errorf("ServeFile: stat %s: %w", file, err)
 	}
+	landing := f.IsDir() && n.LandingPage != nil && n.isRoot(rq, file)
+	class := contentClass(ftype, f.IsDir())
+	if landing {
+		class = stats.ClassLanding
+	}
+	n.Stats.IncrementClass(class)
+	if sw, ok := rw.(*statusWriter); ok {
+		sw.class = class
+	}
+	if stats.Audience(class) == stats.AudienceHuman {
+		if referer, ok := n.entryReferer(rq); ok {
+			n.Stats.IncrementVisit(rq.URL.Path, referer)
+		}
+	}
+	if landing || f.IsDir() {
+		// A directory name has no extension to take the type from.
+		rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	if landing || f.IsDir() || ftype == "text/html" {
+		n.setSecurityHeaders(rw)
+	}
+	if landing {
+		body, err := n.renderLanding(file, pageLocale(rw, rq))
+		if err != nil {
+			return fmt.Errorf("ServeFile: %w", err)
+		}
+		rw.Write(body) //nolint:errcheck
+		return nil
+	}
 	if f.IsDir() {
-		return serveDirectory(file, rw)
+		filter := n.listingFilter(n.rootOf(rq, file), file)
+		filter.locale = pageLocale(rw, rq)
+		return serveDirectory(file, filter, n.ListingETags, rw, rq)
 	}
-	return serveStaticFile(file, ftype, rw, rq)
+	return serveStaticFile(file, ftype, n.ContentValidators, rw, rq)
 }
 
 // Serve constructs a NewsServer rooted at newsDir and loads any previously
 // persisted download statistics from newsStats. Both paths are stored on the
-// returned server; newsStats is also passed to stats.NewsStats.Load.
+// returned server; newsStats is also passed to stats.NewsStats.Load.  Repeat
+// downloads are deduplicated over stats.DefaultDownloadWindow.
 func Serve(newsDir, newsStats string) *NewsServer {
 	s := &NewsServer{
 		NewsDir: newsDir,
 		Stats: stats.NewsStats{
-			StateFile: newsStats,
+			StateFile:      newsStats,
+			DownloadWindow: stats.DefaultDownloadWindow,
 		},
 	}
 	s.Stats.Load()
@@ -3,21 +3,25 @@ package newsserver
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	builder "github.com/go-i2p/newsgo/builder"
 	stats "github.com/go-i2p/newsgo/server/stats"
 )
 
 func TestOpenDirectory_MissingDir(t *testing.T) {
-	_, err := openDirectory("/nonexistent/directory/path")
+	_, err := openDirectory("/nonexistent/directory/path", listingFilter{})
 	if err == nil {
 		t.Fatal("expected error for missing directory, got nil")
 	}
@@ -57,7 +61,7 @@ func TestOpenDirectory_ValidDir(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, "test.xml"), []byte("<feed/>"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	listing, err := openDirectory(dir)
+	listing, err := openDirectory(dir, listingFilter{})
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -117,13 +121,64 @@ func TestServeHTTP_DirectoryListing(t *testing.T) {
 	}
 	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
 	rw := httptest.NewRecorder()
-	rq := httptest.NewRequest(http.MethodGet, "/subdir", nil)
+	rq := httptest.NewRequest(http.MethodGet, "/subdir/", nil)
 	s.ServeHTTP(rw, rq)
 	if rw.Code != http.StatusOK {
 		t.Errorf("expected 200, got %d", rw.Code)
 	}
 }
 
+// TestServeHTTP_CanonicalSlashes verifies that directories are redirected to
+// their slash form, files to their slash-less form, and duplicate slashes
+// collapsed, in the main tree and under a route, keeping the query.
+func TestServeHTTP_CanonicalSlashes(t *testing.T) {
+	main, beta := t.TempDir(), t.TempDir()
+	for _, dir := range []string{main, beta} {
+		locale := filepath.Join(dir, "mac", "stable")
+		if err := os.MkdirAll(locale, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(locale, "news_de.atom.xml"), []byte("de"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	routes, err := ParseRoutes([]string{"/beta=" + beta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: main, Stats: statsForTest(main), Routes: routes}
+	for _, tt := range []struct {
+		path     string
+		want     int
+		location string
+	}{
+		{"/", http.StatusOK, ""},
+		{"/mac", http.StatusMovedPermanently, "/mac/"},
+		{"/mac/stable", http.StatusMovedPermanently, "/mac/stable/"},
+		{"/mac/stable?lang=de", http.StatusMovedPermanently, "/mac/stable/?lang=de"},
+		{"/mac/stable/", http.StatusOK, ""},
+		{"/mac//stable/", http.StatusMovedPermanently, "/mac/stable/"},
+		{"//mac///stable", http.StatusMovedPermanently, "/mac/stable"},
+		{"/mac/stable/news_de.atom.xml", http.StatusOK, ""},
+		{"/mac/stable/news_de.atom.xml/", http.StatusMovedPermanently, "/mac/stable/news_de.atom.xml"},
+		{"/mac/stable//news_de.atom.xml", http.StatusMovedPermanently, "/mac/stable/news_de.atom.xml"},
+		{"/beta", http.StatusMovedPermanently, "/beta/"},
+		{"/beta/mac/stable", http.StatusMovedPermanently, "/beta/mac/stable/"},
+		{"/beta//mac/stable/", http.StatusMovedPermanently, "/beta/mac/stable/"},
+		{"/beta/mac/stable/", http.StatusOK, ""},
+		{"/missing/", http.StatusNotFound, ""},
+	} {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rw.Code != tt.want {
+			t.Errorf("GET %s: code %d, want %d", tt.path, rw.Code, tt.want)
+		}
+		if got := rw.Header().Get("Location"); got != tt.location {
+			t.Errorf("GET %s: Location %q, want %q", tt.path, got, tt.location)
+		}
+	}
+}
+
 // TestFileType_AtomXML verifies that ".atom.xml" files are detected as Atom
 // feeds and NOT as generic XML. filepath.Ext returns ".xml" for these files,
 // so the old case ".atom.xml" switch arm was unreachable dead code. The fix
@@ -140,6 +195,7 @@ func TestFileType_AtomXML(t *testing.T) {
 		{"index.html", "text/html", false},
 		{"update.su3", "application/x-i2p-su3-news", false},
 		{"langstats.svg", "image/svg+xml", false},
+		{"index.opml", "text/x-opml", false},
 	}
 	for _, tt := range tests {
 		got, err := fileType(tt.file)
@@ -179,6 +235,8 @@ func TestContainsPath(t *testing.T) {
 		{"/srv/news", "/etc/passwd", false},
 		// One level above root.
 		{"/srv/news", "/srv", false},
+		// A filesystem root already ends in the separator.
+		{"/", "/srv/news/news.atom.xml", true},
 	}
 	for _, tt := range tests {
 		got := containsPath(tt.root, tt.target)
@@ -231,7 +289,7 @@ func TestOpenDirectory_FileLinksAreRelative(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	listing, err := openDirectory(sub)
+	listing, err := openDirectory(sub, listingFilter{})
 	if err != nil {
 		t.Fatalf("openDirectory: %v", err)
 	}
@@ -305,6 +363,42 @@ func TestServeHTTP_LangStatsSVG_Returns200(t *testing.T) {
 	}
 }
 
+// TestServeHTTP_StatsGraphPNG verifies that the stats graphs are PNG for a
+// client that prefers image/png and SVG otherwise, and vary on Accept.
+func TestServeHTTP_StatsGraphPNG(t *testing.T) {
+	dir := t.TempDir()
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	for _, tt := range []struct {
+		path, accept, want string
+	}{
+		{"/" + statsGraphFilename, "image/png", "image/png"},
+		{"/" + statsGraphFilename, "image/png,image/*;q=0.8", "image/png"},
+		{"/" + contentStatsGraphFilename, "image/png", "image/png"},
+		{"/" + statsGraphFilename, "", "image/svg+xml"},
+		{"/" + statsGraphFilename, "*/*", "image/svg+xml"},
+		{"/" + statsGraphFilename, "image/svg+xml,image/png;q=0.9", "image/svg+xml"},
+	} {
+		rw := httptest.NewRecorder()
+		rq := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			rq.Header.Set("Accept", tt.accept)
+		}
+		s.ServeHTTP(rw, rq)
+		if rw.Code != http.StatusOK {
+			t.Fatalf("GET %s (Accept %q): status %d", tt.path, tt.accept, rw.Code)
+		}
+		if ct := rw.Header().Get("Content-Type"); !strings.HasPrefix(ct, tt.want) {
+			t.Errorf("GET %s (Accept %q): Content-Type %q, want %s", tt.path, tt.accept, ct, tt.want)
+		}
+		if png := strings.HasPrefix(rw.Body.String(), "\x89PNG"); png != (tt.want == "image/png") {
+			t.Errorf("GET %s (Accept %q): body is PNG = %v, want Content-Type %s", tt.path, tt.accept, png, tt.want)
+		}
+		if !strings.Contains(strings.Join(rw.Header().Values("Vary"), ","), "Accept") {
+			t.Errorf("GET %s: Vary = %q, want Accept", tt.path, rw.Header().Values("Vary"))
+		}
+	}
+}
+
 // TestServeHTTP_ExistingSVGFile_Served verifies that a real *.svg file present
 // on disk is served as a static file (not hijacked by Stats.Graph) when its
 // name is not langstats.svg.
@@ -381,6 +475,108 @@ func TestServeHTTP_ConditionalGET_NotModified(t *testing.T) {
 	}
 }
 
+// TestServeHTTP_ContentValidators verifies that with ContentValidators a
+// rewrite with identical bytes keeps the ETag and Last-Modified, so both
+// kinds of conditional GET still return 304, while changed content does not.
+func TestServeHTTP_ContentValidators(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "news.atom.xml")
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	write := func(body string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(fpath, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(fpath, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), ContentValidators: true}
+	get := func(header, value string) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest(http.MethodGet, "/news.atom.xml", nil)
+		if header != "" {
+			rq.Header.Set(header, value)
+		}
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, rq)
+		return rw
+	}
+
+	write("<feed/>", first)
+	rw := get("", "")
+	etag, lastMod := rw.Header().Get("ETag"), rw.Header().Get("Last-Modified")
+	if rw.Code != http.StatusOK || etag == "" || lastMod != first.Format(http.TimeFormat) {
+		t.Fatalf("first GET: code %d ETag %q Last-Modified %q", rw.Code, etag, lastMod)
+	}
+
+	// Rebuild with identical content.
+	write("<feed/>", first.Add(time.Hour))
+	if rw := get("If-None-Match", etag); rw.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match after identical rewrite: got %d, want 304", rw.Code)
+	}
+	if rw := get("If-Modified-Since", lastMod); rw.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since after identical rewrite: got %d, want 304", rw.Code)
+	}
+
+	// Real change.
+	write("<feed>new</feed>", first.Add(2*time.Hour))
+	rw = get("If-None-Match", etag)
+	if rw.Code != http.StatusOK || rw.Header().Get("ETag") == etag {
+		t.Errorf("after content change: code %d ETag %q, want 200 with a new ETag", rw.Code, rw.Header().Get("ETag"))
+	}
+}
+
+// TestServeHTTP_ListingETags verifies that with ListingETags an unchanged
+// listing answers a matching If-None-Match with 304 and that adding or
+// rewriting an entry changes its ETag.
+func TestServeHTTP_ListingETags(t *testing.T) {
+	dir := t.TempDir()
+	fpath := filepath.Join(dir, "news.atom.xml")
+	if err := os.WriteFile(fpath, []byte("<feed/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), ListingETags: true}
+	get := func(inm string) *httptest.ResponseRecorder {
+		rq := httptest.NewRequest(http.MethodGet, "/", nil)
+		if inm != "" {
+			rq.Header.Set("If-None-Match", inm)
+		}
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, rq)
+		return rw
+	}
+
+	rw := get("")
+	etag := rw.Header().Get("ETag")
+	if rw.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"`) || rw.Header().Get("Cache-Control") != "no-cache" {
+		t.Fatalf("first GET: code %d ETag %q Cache-Control %q", rw.Code, etag, rw.Header().Get("Cache-Control"))
+	}
+	if rw := get(`"other", ` + strings.TrimPrefix(etag, "W/")); rw.Code != http.StatusNotModified || rw.Body.Len() != 0 {
+		t.Errorf("If-None-Match on unchanged listing: got %d with %d bytes, want an empty 304", rw.Code, rw.Body.Len())
+	}
+
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(fpath, later, later); err != nil {
+		t.Fatal(err)
+	}
+	rw = get(etag)
+	if rw.Code != http.StatusOK || rw.Header().Get("ETag") == etag {
+		t.Errorf("after rewrite: code %d ETag %q, want 200 with a new ETag", rw.Code, rw.Header().Get("ETag"))
+	}
+	etag = rw.Header().Get("ETag")
+	if err := os.WriteFile(filepath.Join(dir, "news.su3"), []byte("su3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rw := get(etag); rw.Code != http.StatusOK {
+		t.Errorf("after adding an entry: got %d, want 200", rw.Code)
+	}
+
+	s.ListingETags = false
+	if rw := get(etag); rw.Code != http.StatusOK || rw.Header().Get("ETag") != "" {
+		t.Errorf("without ListingETags: code %d ETag %q, want 200 without an ETag", rw.Code, rw.Header().Get("ETag"))
+	}
+}
+
 // TestServeHTTP_RangeRequest verifies that the server returns HTTP 206 Partial
 // Content for a well-formed Range request. Before the fix, serveStaticFile
 // used rw.Write which ignores Range headers entirely and always returns 200
@@ -650,7 +846,7 @@ func TestOpenDirectory_ListingIncludesChecksum(t *testing.T) {
 	if err := os.WriteFile(filepath.Join(dir, "news.atom.xml"), []byte("<feed/>"), 0o644); err != nil {
 		t.Fatal(err)
 	}
-	listing, err := openDirectory(dir)
+	listing, err := openDirectory(dir, listingFilter{})
 	if err != nil {
 		t.Fatalf("openDirectory: %v", err)
 	}
@@ -670,6 +866,33 @@ func TestOpenDirectory_ListingIncludesChecksum(t *testing.T) {
 	}
 }
 
+// TestOpenDirectory_ListingIncludesFeedSummary verifies that a feed with a
+// metadata sidecar is listed with its entry count, newest entry, and release.
+func TestOpenDirectory_ListingIncludesFeedSummary(t *testing.T) {
+	dir := t.TempDir()
+	feed := filepath.Join(dir, "news.atom.xml")
+	if err := os.WriteFile(feed, []byte("<feed/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := builder.FeedMetadata{Entries: 7, NewestEntry: "2024-01-02T00:00:00Z", ReleaseVersion: "2.5.0"}
+	if err := builder.WriteFeedMetadata(feed, m); err != nil {
+		t.Fatal(err)
+	}
+	listing, err := openDirectory(dir, listingFilter{})
+	if err != nil {
+		t.Fatalf("openDirectory: %v", err)
+	}
+	want := ": 7 entries, newest 2024-01-02T00:00:00Z, release 2.5.0"
+	for _, line := range strings.Split(listing, "\n") {
+		if strings.Contains(line, "[news.atom.xml]") && !strings.HasSuffix(line, want) {
+			t.Errorf("feed line = %q, want suffix %q", line, want)
+		}
+		if strings.Contains(line, "[news.json]") && strings.Contains(line, "entries") {
+			t.Errorf("sidecar line carries a feed summary: %q", line)
+		}
+	}
+}
+
 // ---------------------------------------------------------------------------
 // fileType MIME fallback (AUDIT.md: "fileType() returns text/html for
 // unrecognised extensions")
@@ -761,3 +984,1144 @@ func statsForTest(dir string) stats.NewsStats {
 		DownloadLangs: make(map[string]int),
 	}
 }
+
+// TestListingCache_CoalescesConcurrentRenders verifies that concurrent
+// requests for the same directory share a single render.
+func TestListingCache_CoalescesConcurrentRenders(t *testing.T) {
+	c := newListingCache(time.Hour)
+	modTime := time.Now()
+	var (
+		mu      sync.Mutex
+		renders int
+	)
+	release := make(chan struct{})
+	render := func() ([]byte, error) {
+		mu.Lock()
+		renders++
+		mu.Unlock()
+		<-release
+		return []byte("listing"), nil
+	}
+
+	const callers = 16
+	var wg sync.WaitGroup
+	results := make(chan string, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := c.do("/dir", modTime, render)
+			if err != nil {
+				t.Errorf("do: %v", err)
+			}
+			results <- string(body)
+		}()
+	}
+	// Give the goroutines time to queue up behind the first render.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for body := range results {
+		if body != "listing" {
+			t.Errorf("caller got %q; want %q", body, "listing")
+		}
+	}
+	if renders != 1 {
+		t.Errorf("render called %d times for %d concurrent callers; want 1", renders, callers)
+	}
+}
+
+// TestListingCache_InvalidatedOnModTime verifies that a cached listing is
+// discarded when the directory mtime changes and reused while it does not.
+func TestListingCache_InvalidatedOnModTime(t *testing.T) {
+	c := newListingCache(time.Hour)
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte(fmt.Sprintf("v%d", renders)), nil
+	}
+	t0 := time.Now()
+	c.do("/dir", t0, render)
+	if body, _ := c.do("/dir", t0, render); string(body) != "v1" {
+		t.Errorf("second call with same mtime = %q; want cached v1", body)
+	}
+	if body, _ := c.do("/dir", t0.Add(time.Second), render); string(body) != "v2" {
+		t.Errorf("call with new mtime = %q; want fresh v2", body)
+	}
+}
+
+// TestListingCache_ExpiresAfterTTL verifies that entries older than the TTL
+// are re-rendered even when the directory mtime is unchanged.
+func TestListingCache_ExpiresAfterTTL(t *testing.T) {
+	c := newListingCache(0)
+	renders := 0
+	render := func() ([]byte, error) {
+		renders++
+		return []byte("x"), nil
+	}
+	t0 := time.Now()
+	c.do("/dir", t0, render)
+	c.do("/dir", t0, render)
+	if renders != 2 {
+		t.Errorf("render called %d times with zero TTL; want 2", renders)
+	}
+}
+
+// TestListingCache_ErrorsNotCached verifies that a failed render is retried
+// on the next request instead of being served from the cache.
+func TestListingCache_ErrorsNotCached(t *testing.T) {
+	c := newListingCache(time.Hour)
+	fail := true
+	render := func() ([]byte, error) {
+		if fail {
+			return nil, fmt.Errorf("boom")
+		}
+		return []byte("ok"), nil
+	}
+	t0 := time.Now()
+	if _, err := c.do("/dir", t0, render); err == nil {
+		t.Fatal("expected render error, got nil")
+	}
+	fail = false
+	if body, err := c.do("/dir", t0, render); err != nil || string(body) != "ok" {
+		t.Errorf("retry after error = (%q, %v); want (\"ok\", nil)", body, err)
+	}
+}
+
+// readerFromRecorder is an httptest.ResponseRecorder that also implements
+// io.ReaderFrom, like net/http's real ResponseWriter, and records the source
+// it was handed so tests can check the sendfile-eligible path is taken.
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	src io.Reader
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.src = src
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+// TestServeHTTP_Su3UsesReaderFrom verifies that su3 downloads reach the
+// ResponseWriter's ReadFrom with the *os.File as the underlying source, which
+// is what allows net/http to use sendfile on TCP listeners.
+func TestServeHTTP_Su3UsesReaderFrom(t *testing.T) {
+	dir := t.TempDir()
+	content := bytes.Repeat([]byte("su3"), 4096)
+	if err := os.WriteFile(filepath.Join(dir, "news.su3"), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	rw := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/news.su3", nil))
+
+	if rw.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rw.Code)
+	}
+	if !bytes.Equal(rw.Body.Bytes(), content) {
+		t.Fatalf("body mismatch: got %d bytes, want %d", rw.Body.Len(), len(content))
+	}
+	src := rw.src
+	if lr, ok := src.(*io.LimitedReader); ok {
+		src = lr.R
+	}
+	if _, ok := src.(*os.File); !ok {
+		t.Errorf("ReadFrom source = %T; want *os.File (optionally inside *io.LimitedReader)", rw.src)
+	}
+}
+
+// writerOnly hides every method of the wrapped ResponseWriter except the
+// http.ResponseWriter interface, disabling the io.ReaderFrom fast path.
+type writerOnly struct{ http.ResponseWriter }
+
+// benchmarkSu3Download serves a 4 MiB news.su3 over a loopback TCP listener
+// and downloads it b.N times.  wrap, when non-nil, wraps the server-side
+// ResponseWriter before it reaches NewsServer.
+func benchmarkSu3Download(b *testing.B, wrap func(http.ResponseWriter) http.ResponseWriter) {
+	dir := b.TempDir()
+	content := bytes.Repeat([]byte{0xA5}, 4<<20)
+	if err := os.WriteFile(filepath.Join(dir, "news.su3"), content, 0o644); err != nil {
+		b.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	ts := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, rq *http.Request) {
+		if wrap != nil {
+			rw = wrap(rw)
+		}
+		s.ServeHTTP(rw, rq)
+	}))
+	defer ts.Close()
+	client := ts.Client()
+
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := client.Get(ts.URL + "/news.su3")
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// BenchmarkSu3Download_Sendfile measures the default path, where net/http may
+// use sendfile because the ResponseWriter is unwrapped.
+func BenchmarkSu3Download_Sendfile(b *testing.B) {
+	benchmarkSu3Download(b, nil)
+}
+
+// BenchmarkSu3Download_UserspaceCopy measures the same download with the
+// ReaderFrom fast path hidden, for comparison with BenchmarkSu3Download_Sendfile.
+func BenchmarkSu3Download_UserspaceCopy(b *testing.B) {
+	benchmarkSu3Download(b, func(rw http.ResponseWriter) http.ResponseWriter {
+		return writerOnly{rw}
+	})
+}
+
+// TestServeHTTP_ProtocolStats verifies that protocols.json is generated from
+// memory and counts every request, including the one asking for it.
+func TestServeHTTP_ProtocolStats(t *testing.T) {
+	dir := t.TempDir()
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/"+protocolStatsFilename, nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("GET /%s: expected 200, got %d", protocolStatsFilename, rw.Code)
+	}
+	if ct := rw.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if !strings.Contains(rw.Body.String(), `"HTTP/1.1":2`) {
+		t.Errorf("body = %s, want HTTP/1.1 count of 2", rw.Body.String())
+	}
+}
+
+// TestServeHTTP_LatencyStats verifies that latency.json reports first-byte
+// and total histograms per content class and leaves out the stats endpoints.
+func TestServeHTTP_LatencyStats(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "news.su3"), []byte("su3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/news.su3", nil))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/"+protocolStatsFilename, nil))
+
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/"+latencyStatsFilename, nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("GET /%s: expected 200, got %d", latencyStatsFilename, rw.Code)
+	}
+	var got map[string]struct {
+		Count     int `json:"count"`
+		FirstByte struct {
+			Buckets []struct {
+				LE    string `json:"le"`
+				Count int    `json:"count"`
+			} `json:"buckets"`
+		} `json:"first_byte"`
+	}
+	if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+		t.Fatalf("latency.json: %v\n%s", err, rw.Body.String())
+	}
+	if len(got) != 2 || got[stats.ClassSu3].Count != 1 || got[stats.ClassListing].Count != 1 {
+		t.Fatalf("latency.json = %s, want one su3 and one listing request", rw.Body.String())
+	}
+	buckets := got[stats.ClassSu3].FirstByte.Buckets
+	if last := buckets[len(buckets)-1]; last.LE != "+Inf" || last.Count != 1 {
+		t.Errorf("last su3 bucket = %+v, want +Inf counting 1", last)
+	}
+}
+
+// TestRecoverPanic verifies that a panicking request is answered 500,
+// counted in protocols.json, and that a panic after the response started
+// aborts the connection instead.
+func TestRecoverPanic(t *testing.T) {
+	dir := t.TempDir()
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	rq := httptest.NewRequest(http.MethodGet, "/news.su3", nil)
+
+	rw := httptest.NewRecorder()
+	func() {
+		defer s.recoverPanic(&statusWriter{ResponseWriter: rw}, rq)
+		panic("boom")
+	}()
+	if rw.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rw.Code)
+	}
+
+	var aborted any
+	func() {
+		defer func() { aborted = recover() }()
+		sw := &statusWriter{ResponseWriter: httptest.NewRecorder()}
+		defer s.recoverPanic(sw, rq)
+		sw.Write([]byte("partial"))
+		panic("boom")
+	}()
+	if aborted != http.ErrAbortHandler {
+		t.Errorf("panic after the response started: got %v, want http.ErrAbortHandler", aborted)
+	}
+
+	data, err := s.Protocols.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"panics":2`) {
+		t.Errorf("protocols.json = %s, want 2 panics", data)
+	}
+}
+
+// TestServeHTTP_ContentStats verifies that requests are counted per content
+// class and reported by contentstats.json.
+func TestServeHTTP_ContentStats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.atom.xml", "news.su3", "logo.svg", "style.css"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	for _, path := range []string{"/news.atom.xml", "/news.atom.xml", "/news.su3", "/logo.svg", "/style.css", "/", "/missing.su3"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/"+contentStatsFilename, nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("GET /%s: expected 200, got %d", contentStatsFilename, rw.Code)
+	}
+	if ct := rw.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	// "other" includes style.css and the contentstats.json request itself.
+	for _, want := range []string{`"atom": 2`, `"su3": 1`, `"svg": 1`, `"listing": 1`, `"other": 2`} {
+		if !strings.Contains(rw.Body.String(), want) {
+			t.Errorf("body = %s, want %s", rw.Body.String(), want)
+		}
+	}
+
+	rw = httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/"+contentStatsGraphFilename, nil))
+	if rw.Code != http.StatusOK || !strings.Contains(rw.Body.String(), "<svg") {
+		t.Errorf("GET /%s: code %d, body %q", contentStatsGraphFilename, rw.Code, rw.Body.String())
+	}
+}
+
+func TestServeHTTP_VisitStats(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.su3", "about.html"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	for _, rq := range []struct{ path, referer string }{
+		{"/news.su3", ""},
+		{"/", ""},
+		{"/about.html", "http://example.com/"},
+		// Following the listing's link is not a new visit.
+		{"/about.html", "http://news.example.org/"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, rq.path, nil)
+		r.Host = "news.example.org"
+		if rq.referer != "" {
+			r.Header.Set("Referer", rq.referer)
+		}
+		s.ServeHTTP(httptest.NewRecorder(), r)
+	}
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/"+visitStatsFilename, nil))
+	if rw.Code != http.StatusOK {
+		t.Fatalf("GET /%s: expected 200, got %d", visitStatsFilename, rw.Code)
+	}
+	var got struct {
+		Machine, Human    int
+		Entries, Referers map[string]int
+	}
+	if err := json.Unmarshal(rw.Body.Bytes(), &got); err != nil {
+		t.Fatalf("GET /%s: %v\n%s", visitStatsFilename, err, rw.Body.String())
+	}
+	if got.Machine != 1 || got.Human != 3 {
+		t.Errorf("machine, human = %d, %d; want 1, 3", got.Machine, got.Human)
+	}
+	want := map[string]int{"/": 1, "/about.html": 1}
+	if !reflect.DeepEqual(got.Entries, want) {
+		t.Errorf("Entries = %v, want %v", got.Entries, want)
+	}
+	if want := map[string]int{stats.DirectReferer: 1, "example.com": 1}; !reflect.DeepEqual(got.Referers, want) {
+		t.Errorf("Referers = %v, want %v", got.Referers, want)
+	}
+}
+
+// TestServeHTTP_LangStatsFilter verifies that langstats.svg honours the
+// platform and status query parameters, rejects unknown values, and serves a
+// cached chart within graphTTL.
+func TestServeHTTP_LangStatsFilter(t *testing.T) {
+	dir := t.TempDir()
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	s.Stats.Increment(httptest.NewRequest(http.MethodGet, "/mac/stable/news.su3?lang=de", nil))
+
+	get := func(target string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, target, nil))
+		return rw
+	}
+	rw := get("/langstats.svg?platform=mac&status=stable")
+	if rw.Code != http.StatusOK || !strings.Contains(rw.Body.String(), "(mac/stable)") {
+		t.Fatalf("filtered chart: code %d, body %q", rw.Code, rw.Body.String())
+	}
+	if rw := get("/langstats.svg?platform=beos"); rw.Code != http.StatusBadRequest {
+		t.Errorf("unknown platform: expected 400, got %d", rw.Code)
+	}
+
+	// A download recorded within graphTTL is not yet in the cached chart.
+	first := rw.Body.String()
+	s.Stats.Increment(httptest.NewRequest(http.MethodGet, "/mac/stable/news.su3?lang=fr", nil))
+	if again := get("/langstats.svg?platform=mac&status=stable").Body.String(); again != first {
+		t.Error("chart re-rendered within graphTTL")
+	}
+	if other := get("/langstats.svg?platform=mac").Body.String(); !strings.Contains(other, ">fr<") {
+		t.Error("chart for a different filter was served from the cache")
+	}
+}
+
+// TestServeHTTP_HotlinkProtection verifies that foreign referers are refused
+// for pages and assets but not for su3 files and feeds, and that requests
+// without a referer, from the server's own host, or from an allowed host
+// pass.
+func TestServeHTTP_HotlinkProtection(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.atom.xml", "news.su3", "logo.svg", "index.html"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{
+		NewsDir:           dir,
+		Stats:             statsForTest(dir),
+		SiteURL:           "https://news.example.org",
+		HotlinkProtection: true,
+		AllowedReferers:   []string{"*.geti2p.net"},
+	}
+	for _, tt := range []struct {
+		path, header, value string
+		want                int
+	}{
+		{"/logo.svg", "", "", http.StatusOK},
+		{"/logo.svg", "Referer", "https://elsewhere.example.com/page", http.StatusForbidden},
+		{"/index.html", "Origin", "https://elsewhere.example.com", http.StatusForbidden},
+		{"/", "Referer", "https://elsewhere.example.com/", http.StatusForbidden},
+		{"/news.su3", "Referer", "https://elsewhere.example.com/page", http.StatusOK},
+		{"/news.atom.xml", "Referer", "https://elsewhere.example.com/page", http.StatusOK},
+		{"/logo.svg", "Referer", "http://mirror.test:8080/", http.StatusOK},
+		{"/logo.svg", "Referer", "https://news.example.org/win/", http.StatusOK},
+		{"/logo.svg", "Referer", "https://geti2p.net/en/", http.StatusOK},
+		{"/logo.svg", "Referer", "https://forum.geti2p.net/t/1", http.StatusOK},
+		{"/logo.svg", "Referer", "https://notgeti2p.net/", http.StatusForbidden},
+	} {
+		rq := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		rq.Host = "mirror.test:8080"
+		if tt.header != "" {
+			rq.Header.Set(tt.header, tt.value)
+		}
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, rq)
+		if rw.Code != tt.want {
+			t.Errorf("GET %s with %s %q: code %d, want %d", tt.path, tt.header, tt.value, rw.Code, tt.want)
+		}
+	}
+
+	s.HotlinkProtection = false
+	rq := httptest.NewRequest(http.MethodGet, "/logo.svg", nil)
+	rq.Header.Set("Referer", "https://elsewhere.example.com/page")
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, rq)
+	if rw.Code != http.StatusOK {
+		t.Errorf("protection off: code %d, want 200", rw.Code)
+	}
+}
+
+// TestParseRoutes covers route spec validation and longest-prefix ordering.
+func TestParseRoutes(t *testing.T) {
+	routes, err := ParseRoutes([]string{"/beta=build-beta", "/beta/mac/=build-mac", "/stable=build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := routes[0].Prefix; got != "/beta/mac" {
+		t.Errorf("first route %q, want the longest prefix /beta/mac", got)
+	}
+	for _, bad := range [][]string{
+		{"beta=build-beta"},
+		{"/beta"},
+		{"/beta="},
+		{"/=build"},
+		{"/beta=a", "/beta/=b"},
+	} {
+		if _, err := ParseRoutes(bad); err == nil {
+			t.Errorf("ParseRoutes(%q): want error", bad)
+		}
+	}
+}
+
+// TestServeHTTP_Routes verifies that route prefixes are served from their own
+// directories, that other paths still come from NewsDir, and that a route
+// cannot be used to escape its directory.
+func TestServeHTTP_Routes(t *testing.T) {
+	main, beta := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(main, "news.atom.xml"), []byte("stable"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(beta, "news.atom.xml"), []byte("beta"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	routes, err := ParseRoutes([]string{"/beta=" + beta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: main, Stats: statsForTest(main), Routes: routes}
+	for _, tt := range []struct {
+		path     string
+		want     int
+		wantBody string
+	}{
+		{"/news.atom.xml", http.StatusOK, "stable"},
+		{"/beta/news.atom.xml", http.StatusOK, "beta"},
+		{"/betamax/news.atom.xml", http.StatusNotFound, ""},
+		{"/beta/../../news.atom.xml", http.StatusBadRequest, ""},
+		{"/beta", http.StatusMovedPermanently, ""},
+		{"/beta/", http.StatusOK, ""},
+	} {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rw.Code != tt.want {
+			t.Errorf("GET %s: code %d, want %d", tt.path, rw.Code, tt.want)
+		}
+		if tt.wantBody != "" && rw.Body.String() != tt.wantBody {
+			t.Errorf("GET %s: body %q, want %q", tt.path, rw.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+// TestParseAliases checks the order and validation of path aliases.
+func TestParseAliases(t *testing.T) {
+	aliases, err := ParseAliases([]string{"/news/=/", "/news/news.su3=/mac/stable/news.su3", "/legacy/news/=/news/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := aliases[0].From + " " + aliases[1].From; got != "/news/news.su3 /legacy/news/" {
+		t.Errorf("aliases ordered %q, want the exact path then the longest prefix", got)
+	}
+	for _, bad := range [][]string{
+		{"news.su3=/news.su3"},
+		{"/news.su3"},
+		{"/=/news/"},
+		{"/news/=/news.su3"},
+		{"/a=/b", "/a=/c"},
+	} {
+		if _, err := ParseAliases(bad); err == nil {
+			t.Errorf("ParseAliases(%q): want error", bad)
+		}
+	}
+}
+
+// TestServeHTTP_Aliases verifies that legacy paths are answered with the
+// files they alias, without a redirect, and that an alias cannot reach a
+// hidden file.
+func TestServeHTTP_Aliases(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "news.su3"), []byte("su3"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "secret.xml"), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	aliases, err := ParseAliases([]string{"/news/news.su3=/news.su3", "/old/=/", "/leak.xml=/secret.xml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Aliases: aliases, Hidden: []string{"secret.xml"}}
+	for _, tt := range []struct {
+		path     string
+		want     int
+		wantBody string
+	}{
+		{"/news/news.su3", http.StatusOK, "su3"},
+		{"/old/news.su3", http.StatusOK, "su3"},
+		{"/leak.xml", http.StatusNotFound, ""},
+		{"/news/other.su3", http.StatusNotFound, ""},
+	} {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rw.Code != tt.want {
+			t.Errorf("GET %s: code %d, want %d", tt.path, rw.Code, tt.want)
+		}
+		if tt.wantBody != "" && rw.Body.String() != tt.wantBody {
+			t.Errorf("GET %s: body %q, want %q", tt.path, rw.Body.String(), tt.wantBody)
+		}
+	}
+}
+
+// TestServeHTTP_Allowlist verifies that an allowlist serves matching
+// clearnet and I2P clients, trusts X-I2P-DestB32 only from loopback, and
+// answers 403 to everyone else.
+func TestServeHTTP_Allowlist(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "news.atom.xml"), []byte("staging"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dest := strings.Repeat("A", 516)
+	destB32, ok := destinationB32(dest)
+	if !ok || !b32Re.MatchString(destB32) {
+		t.Fatalf("destinationB32: got %q, %v", destB32, ok)
+	}
+	other := strings.Repeat("b", 52) + ".b32.i2p"
+	allow, err := ParseAllowlist([]string{"192.0.2.7", "10.1.0.0/16", "2001:db8::/32", dest, strings.ToUpper(other)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Allowlist: allow}
+	for _, tt := range []struct {
+		remote, destHeader string
+		want               int
+	}{
+		{"192.0.2.7:4444", "", http.StatusOK},
+		{"192.0.2.8:4444", "", http.StatusForbidden},
+		{"10.1.200.3:80", "", http.StatusOK},
+		{"[2001:db8::1]:443", "", http.StatusOK},
+		{dest, "", http.StatusOK},
+		{destB32, "", http.StatusOK},
+		{other, "", http.StatusOK},
+		{strings.Repeat("c", 52) + ".b32.i2p", "", http.StatusForbidden},
+		{"127.0.0.1:5555", other, http.StatusOK},
+		{"127.0.0.1:5555", "", http.StatusForbidden},
+		{"192.0.2.8:4444", other, http.StatusForbidden},
+	} {
+		rq := httptest.NewRequest(http.MethodGet, "/news.atom.xml", nil)
+		rq.RemoteAddr = tt.remote
+		if tt.destHeader != "" {
+			rq.Header.Set("X-I2P-DestB32", tt.destHeader)
+		}
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, rq)
+		if rw.Code != tt.want {
+			t.Errorf("%s (X-I2P-DestB32 %q): code %d, want %d", tt.remote, tt.destHeader, rw.Code, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"example.org", "256.1.1.1", "short.b32.i2p", "10.0.0.0/33"} {
+		if _, err := ParseAllowlist([]string{bad}); err == nil {
+			t.Errorf("ParseAllowlist(%q): want error", bad)
+		}
+	}
+}
+
+// TestAdminHandler verifies that the admin endpoints refuse non-loopback
+// clients, missing or wrong tokens and non-POST methods, and that reload and
+// shutdown call their callbacks.
+func TestAdminHandler(t *testing.T) {
+	var reloads int
+	shutdown := make(chan struct{})
+	h := &AdminHandler{
+		Token:    "s3cret",
+		Shutdown: func() { close(shutdown) },
+		Reload:   func() error { reloads++; return nil },
+	}
+	do := func(method, path, remote, token string) int {
+		rq := httptest.NewRequest(method, path, nil)
+		rq.RemoteAddr = remote
+		if token != "" {
+			rq.Header.Set("Authorization", "Bearer "+token)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, rq)
+		return rw.Code
+	}
+	for _, tc := range []struct {
+		method, path, remote, token string
+		want                        int
+	}{
+		{"POST", AdminReloadPath, "192.0.2.1:1234", "s3cret", http.StatusForbidden},
+		{"POST", AdminReloadPath, "127.0.0.1:1234", "", http.StatusUnauthorized},
+		{"POST", AdminReloadPath, "127.0.0.1:1234", "wrong", http.StatusUnauthorized},
+		{"GET", AdminReloadPath, "127.0.0.1:1234", "s3cret", http.StatusMethodNotAllowed},
+		{"POST", "/admin/other", "127.0.0.1:1234", "s3cret", http.StatusNotFound},
+		{"POST", AdminReloadPath, "[::1]:1234", "s3cret", http.StatusOK},
+	} {
+		if got := do(tc.method, tc.path, tc.remote, tc.token); got != tc.want {
+			t.Errorf("%s %s from %s with %q = %d, want %d", tc.method, tc.path, tc.remote, tc.token, got, tc.want)
+		}
+	}
+	if reloads != 1 {
+		t.Errorf("Reload called %d times, want 1", reloads)
+	}
+	if got := do("POST", AdminShutdownPath, "127.0.0.1:1234", "s3cret"); got != http.StatusAccepted {
+		t.Errorf("POST %s = %d, want %d", AdminShutdownPath, got, http.StatusAccepted)
+	}
+	select {
+	case <-shutdown:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown was not called")
+	}
+
+	h.Token = ""
+	if got := do("POST", AdminReloadPath, "127.0.0.1:1234", ""); got != http.StatusUnauthorized {
+		t.Errorf("empty Token: POST %s = %d, want %d", AdminReloadPath, got, http.StatusUnauthorized)
+	}
+}
+
+// TestFlushCaches verifies that FlushCaches makes a listing reflect a new
+// file before the listing TTL runs out.
+func TestFlushCaches(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.su3"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := Serve(dir, filepath.Join(dir, "stats.json"))
+	list := func() string {
+		rw := httptest.NewRecorder()
+		n.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		return rw.Body.String()
+	}
+	// Pin the directory mtime so only a flush can invalidate the listing.
+	mtime := time.Now().Add(-time.Hour)
+	os.Chtimes(dir, mtime, mtime)
+	list()
+	if err := os.WriteFile(filepath.Join(dir, "b.su3"), []byte("b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(dir, mtime, mtime)
+	if body := list(); strings.Contains(body, "b.su3") {
+		t.Fatal("listing was not cached; the test cannot observe FlushCaches")
+	}
+	n.FlushCaches()
+	if body := list(); !strings.Contains(body, "b.su3") {
+		t.Errorf("listing after FlushCaches lacks b.su3:\n%s", body)
+	}
+}
+
+// TestServeHTTP_SecurityHeaders verifies that SecurityHeaders are sent with
+// listings, the landing page, and .html files but not with feeds or su3
+// files, and that listings are typed as HTML.
+func TestServeHTTP_SecurityHeaders(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.atom.xml", "news.su3", "index.html", "mac/stable/news.atom.xml"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), SecurityHeaders: DefaultSecurityHeaders}
+	get := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+		return rw
+	}
+	for path, want := range map[string]bool{
+		"/":              true,
+		"/mac/":          true,
+		"/index.html":    true,
+		"/news.atom.xml": false,
+		"/news.su3":      false,
+	} {
+		rw := get(path)
+		if rw.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d", path, rw.Code)
+		}
+		for name := range DefaultSecurityHeaders {
+			if got := rw.Header().Get(name) != ""; got != want {
+				t.Errorf("GET %s: %s present = %v, want %v", path, name, got, want)
+			}
+		}
+	}
+	if ct := get("/mac/").Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("listing Content-Type = %q, want text/html; charset=utf-8", ct)
+	}
+
+	s.LandingPage = DefaultLandingPage
+	if rw := get("/"); rw.Header().Get("Content-Security-Policy") == "" || !strings.HasPrefix(rw.Header().Get("Content-Type"), "text/html") {
+		t.Errorf("landing page headers = %v", rw.Header())
+	}
+}
+
+// TestParseSecurityHeaders verifies that pairs replace and remove headers
+// without modifying the base, and that malformed pairs are rejected.
+func TestParseSecurityHeaders(t *testing.T) {
+	got, err := ParseSecurityHeaders(DefaultSecurityHeaders, []string{
+		"Content-Security-Policy=default-src 'self'",
+		"X-Frame-Options=",
+		"Permissions-Policy=camera=(), microphone=()",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := got.Get("Content-Security-Policy"); v != "default-src 'self'" {
+		t.Errorf("Content-Security-Policy = %q", v)
+	}
+	if _, ok := got["X-Frame-Options"]; ok {
+		t.Error("X-Frame-Options not removed")
+	}
+	if v := got.Get("Permissions-Policy"); v != "camera=(), microphone=()" {
+		t.Errorf("Permissions-Policy = %q", v)
+	}
+	if got.Get("X-Content-Type-Options") != "nosniff" || DefaultSecurityHeaders.Get("X-Frame-Options") != "DENY" {
+		t.Error("defaults lost or DefaultSecurityHeaders modified")
+	}
+	if got, err := ParseSecurityHeaders(nil, []string{"X-Test=1"}); err != nil || got.Get("X-Test") != "1" {
+		t.Errorf("ParseSecurityHeaders(nil) = %v, %v", got, err)
+	}
+	for _, bad := range []string{"no-equals", "=value", "Bad Name=1", "Name:=1"} {
+		if _, err := ParseSecurityHeaders(nil, []string{bad}); err == nil {
+			t.Errorf("ParseSecurityHeaders(%q): want error", bad)
+		}
+	}
+}
+
+// TestServeHTTP_ResolveCurrent verifies that with ResolveCurrent a request
+// is served from the tree the current symlink points at when it arrives,
+// that repointing the link switches later requests to the new tree, and that
+// the resolved tree still gets the landing page of a root.
+func TestServeHTTP_ResolveCurrent(t *testing.T) {
+	base := t.TempDir()
+	for _, tree := range []string{"v1", "v2"} {
+		if err := os.MkdirAll(filepath.Join(base, tree), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(base, tree, "news.atom.xml"), []byte(tree), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	current := filepath.Join(base, "current")
+	repoint := func(tree string) {
+		tmp := current + ".new"
+		if err := os.Symlink(tree, tmp); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+		if err := os.Rename(tmp, current); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repoint("v1")
+	s := &NewsServer{NewsDir: current, Stats: statsForTest(t.TempDir()), ResolveCurrent: true}
+	get := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest("GET", path, nil))
+		return rw
+	}
+	if body := get("/news.atom.xml").Body.String(); body != "v1" {
+		t.Errorf("before repointing: body = %q, want v1", body)
+	}
+	repoint("v2")
+	if body := get("/news.atom.xml").Body.String(); body != "v2" {
+		t.Errorf("after repointing: body = %q, want v2", body)
+	}
+	s.LandingPage = DefaultLandingPage
+	if body := get("/").Body.String(); !strings.Contains(body, "<th>Language</th>") {
+		t.Errorf("root of the resolved tree is not the landing page:\n%s", body)
+	}
+}
+
+// TestServeHTTP_Hidden verifies that hidden names and the stats files are
+// left out of listings and answered 404, and that listings deeper than
+// MaxListingDepth are refused while their files are still served.
+func TestServeHTTP_Hidden(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.atom.xml", "stats.json", "stats.classes.json", ".news.atom.xml.123.tmp", "half.tmp", "mac/stable/news.atom.xml", ".git/config"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hidden, err := ParseHidden(DefaultHidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Hidden: hidden, MaxListingDepth: 1}
+	get := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		return rw
+	}
+	for path, want := range map[string]int{
+		"/news.atom.xml":            http.StatusOK,
+		"/stats.json":               http.StatusNotFound,
+		"/stats.classes.json":       http.StatusNotFound,
+		"/.news.atom.xml.123.tmp":   http.StatusNotFound,
+		"/half.tmp":                 http.StatusNotFound,
+		"/.git/config":              http.StatusNotFound,
+		"/mac/":                     http.StatusOK,
+		"/mac/stable/":              http.StatusNotFound,
+		"/mac/stable/news.atom.xml": http.StatusOK,
+	} {
+		if rw := get(path); rw.Code != want {
+			t.Errorf("GET %s: code %d, want %d", path, rw.Code, want)
+		}
+	}
+	root := get("/").Body.String()
+	if !strings.Contains(root, "news.atom.xml") {
+		t.Errorf("root listing lacks news.atom.xml:\n%s", root)
+	}
+	for _, name := range []string{"stats.json", "stats.classes.json", ".tmp", "half.tmp", ".git"} {
+		if strings.Contains(root, name) {
+			t.Errorf("root listing shows hidden %s:\n%s", name, root)
+		}
+	}
+	if mac := get("/mac/").Body.String(); strings.Contains(mac, "stable") {
+		t.Errorf("listing at MaxListingDepth links a deeper directory:\n%s", mac)
+	}
+
+	for _, bad := range [][]string{{"mac/*"}, {"[a"}} {
+		if _, err := ParseHidden(bad); err == nil {
+			t.Errorf("ParseHidden(%q): want error", bad)
+		}
+	}
+}
+
+func TestServeHTTP_Select(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.atom.xml", "news_de.atom.xml", "mac/beta/news.su3", "mac/beta/news_de.su3", "mac/stable/news.su3", "win/beta/news.su3"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Select: builder.FeedSelector{Platform: "mac", Status: "beta", Langs: []string{"de"}}}
+	get := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		return rw
+	}
+	for path, want := range map[string]int{
+		"/news_de.atom.xml":     http.StatusNotFound,
+		"/mac/beta/news_de.su3": http.StatusOK,
+		"/mac/beta/news.su3":    http.StatusNotFound,
+		"/mac/stable/news.su3":  http.StatusNotFound,
+		"/win/beta/news.su3":    http.StatusNotFound,
+		"/win/":                 http.StatusNotFound,
+		"/mac/beta/":            http.StatusOK,
+	} {
+		if rw := get(path); rw.Code != want {
+			t.Errorf("GET %s: code %d, want %d", path, rw.Code, want)
+		}
+	}
+	if root := get("/").Body.String(); strings.Contains(root, "win") || strings.Contains(root, "news_de.atom.xml") {
+		t.Errorf("root listing shows unselected entries:\n%s", root)
+	}
+	if mac := get("/mac/").Body.String(); strings.Contains(mac, "stable") || !strings.Contains(mac, "beta") {
+		t.Errorf("mac listing does not show only the beta channel:\n%s", mac)
+	}
+	if beta := get("/mac/beta/").Body.String(); strings.Contains(beta, `"news.su3`) || !strings.Contains(beta, "news_de.su3") {
+		t.Errorf("beta listing does not show only the de feed:\n%s", beta)
+	}
+}
+
+func TestServeHTTP_Checksum(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"news.su3":            "su3 bytes",
+		"mac/stable/news.su3": "mac su3 bytes",
+		"notes.txt.sha256":    "published checksum\n",
+		".hidden":             "secret",
+	} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hidden, err := ParseHidden(DefaultHidden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Hidden: hidden}
+	get := func(path string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		return rw
+	}
+	for path, want := range map[string]string{
+		"/news.su3.sha256":            fmt.Sprintf("%x  news.su3\n", sha256.Sum256([]byte("su3 bytes"))),
+		"/news.su3?checksum=1":        fmt.Sprintf("%x  news.su3\n", sha256.Sum256([]byte("su3 bytes"))),
+		"/mac/stable/news.su3.sha256": fmt.Sprintf("%x  news.su3\n", sha256.Sum256([]byte("mac su3 bytes"))),
+		"/notes.txt.sha256":           "published checksum\n",
+	} {
+		rw := get(path)
+		if rw.Code != http.StatusOK || rw.Body.String() != want {
+			t.Errorf("GET %s: %d %q, want %q", path, rw.Code, rw.Body.String(), want)
+		}
+		if ct := rw.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+			t.Errorf("GET %s: Content-Type %q, want text/plain", path, ct)
+		}
+	}
+	for _, path := range []string{"/missing.su3.sha256", "/.hidden.sha256", "/mac.sha256", "/mac/?checksum=1"} {
+		if rw := get(path); rw.Code != http.StatusNotFound {
+			t.Errorf("GET %s: code %d, want 404", path, rw.Code)
+		}
+	}
+}
+
+func TestParseLogSample(t *testing.T) {
+	sample, err := ParseLogSample([]string{"4xx=100", " 5XX = 10 "})
+	if err != nil {
+		t.Fatalf("ParseLogSample: %v", err)
+	}
+	if sample[4] != 100 || sample[5] != 10 || len(sample) != 2 {
+		t.Errorf("sample = %v", sample)
+	}
+	for _, bad := range []string{"4xx", "1xx=2", "404=2", "4xx=0", "4xx=many"} {
+		if _, err := ParseLogSample([]string{bad}); err == nil {
+			t.Errorf("ParseLogSample(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRequestLog(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "news.atom.xml"), []byte("<feed/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	rl := &RequestLog{
+		Sample: map[int]int{4: 10},
+		Printf: func(format string, v ...any) { lines = append(lines, fmt.Sprintf(format, v...)) },
+	}
+	s := &NewsServer{NewsDir: dir, RequestLog: rl}
+	for i := 0; i < 25; i++ {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing.su3", nil))
+	}
+	for i := 0; i < 2; i++ {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/news.atom.xml", nil))
+	}
+	// 404s: the 1st, 11th, and 21st are logged; every 200 is logged.
+	if len(lines) != 5 {
+		t.Fatalf("logged %d lines before flush, want 5:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	if !strings.HasSuffix(lines[0], "GET /missing.su3 404") || !strings.HasSuffix(lines[4], "GET /news.atom.xml 200") {
+		t.Errorf("lines = %q", lines)
+	}
+
+	lines = nil
+	rl.Flush()
+	if want := []string{"25 x GET /missing.su3 404 (3 logged)"}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("flush = %q, want %q", lines, want)
+	}
+	lines = nil
+	rl.Flush()
+	s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing.su3", nil))
+	if len(lines) != 1 {
+		t.Errorf("first request after a flush: logged %q, want one line", lines)
+	}
+}
+
+// TestServeHTTP_NoListings verifies that NoListings answers 404 for
+// directories but keeps the landing page.
+func TestServeHTTP_NoListings(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"news.atom.xml", "mac/news.atom.xml"} {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), NoListings: true}
+	get := func(path string) int {
+		rw := httptest.NewRecorder()
+		s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+		return rw.Code
+	}
+	for path, want := range map[string]int{
+		"/":                  http.StatusNotFound,
+		"/mac/":              http.StatusNotFound,
+		"/mac/news.atom.xml": http.StatusOK,
+	} {
+		if got := get(path); got != want {
+			t.Errorf("GET %s: code %d, want %d", path, got, want)
+		}
+	}
+	s.LandingPage = DefaultLandingPage
+	if got := get("/"); got != http.StatusOK {
+		t.Errorf("GET / with a landing page: code %d, want 200", got)
+	}
+	if got := get("/mac/"); got != http.StatusNotFound {
+		t.Errorf("GET /mac/ with a landing page: code %d, want 404", got)
+	}
+}
+
+// TestServeHTTP_Symlinks verifies that symlinked files and directories
+// leading out of the tree are refused by default, together with their
+// checksums, unless they lead into an allowed directory, that symlinks
+// inside the tree are followed unless denied, and that follow serves them
+// all.
+func TestServeHTTP_Symlinks(t *testing.T) {
+	dir := t.TempDir()
+	outside := t.TempDir()
+	shared := t.TempDir()
+	for _, name := range []string{filepath.Join(dir, "news.atom.xml"), filepath.Join(outside, "secret.txt"), filepath.Join(shared, "i2pupdate.su3")} {
+		if err := os.WriteFile(name, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"secret.txt":    filepath.Join(outside, "secret.txt"),
+		"etc":           outside,
+		"alias.xml":     "news.atom.xml",
+		"i2pupdate.su3": filepath.Join(shared, "i2pupdate.su3"),
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, link)); err != nil {
+			t.Skipf("symlinks unsupported: %v", err)
+		}
+	}
+	allowShared, err := ParseSymlinkPolicy(SymlinksInside, []string{shared})
+	if err != nil {
+		t.Fatalf("ParseSymlinkPolicy: %v", err)
+	}
+	const ok, refused = http.StatusOK, http.StatusNotFound
+	for _, tc := range []struct {
+		policy SymlinkPolicy
+		want   map[string]int
+	}{
+		{SymlinkPolicy{}, map[string]int{
+			"/news.atom.xml": ok, "/alias.xml": ok, "/secret.txt": refused, "/secret.txt.sha256": refused,
+			"/etc/": refused, "/etc/secret.txt": refused, "/i2pupdate.su3": refused,
+		}},
+		{allowShared, map[string]int{"/alias.xml": ok, "/secret.txt": refused, "/i2pupdate.su3": ok}},
+		{SymlinkPolicy{Mode: SymlinksDeny}, map[string]int{"/news.atom.xml": ok, "/alias.xml": refused, "/etc/secret.txt": refused}},
+		{SymlinkPolicy{Mode: SymlinksFollow}, map[string]int{"/alias.xml": ok, "/secret.txt": ok, "/etc/secret.txt": ok}},
+	} {
+		s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), Symlinks: tc.policy}
+		for path, want := range tc.want {
+			rw := httptest.NewRecorder()
+			s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+			if rw.Code != want {
+				t.Errorf("--symlinks %s: GET %s: code %d, want %d", tc.policy.mode(), path, rw.Code, want)
+			}
+		}
+	}
+
+	for _, bad := range []struct {
+		mode  string
+		allow []string
+	}{
+		{"sometimes", nil},
+		{SymlinksDeny, []string{shared}},
+		{SymlinksInside, []string{filepath.Join(shared, "missing")}},
+	} {
+		if _, err := ParseSymlinkPolicy(bad.mode, bad.allow); err == nil {
+			t.Errorf("ParseSymlinkPolicy(%q, %q): want error", bad.mode, bad.allow)
+		}
+	}
+}
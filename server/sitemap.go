@@ -0,0 +1,148 @@
+package newsserver
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Paths answered by NewsServer when SiteURL is set and no file of the same
+// name exists in NewsDir.
+const (
+	sitemapPath = "/sitemap.xml"
+	robotsPath  = "/robots.txt"
+)
+
+// sitemapURLSet and sitemapURL model the sitemaps.org 0.9 schema.
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// sitemapPage reports whether the tree entry at rel should be listed in the
+// sitemap.  Only human-readable pages are listed: directory listings and
+// HTML files.  Feeds, su3 files, and other machine artifacts are left out, as
+// are dot-files such as in-progress fetch temporaries.
+func sitemapPage(rel string, d fs.DirEntry) bool {
+	if strings.HasPrefix(d.Name(), ".") && rel != "." {
+		return false
+	}
+	return d.IsDir() || strings.HasSuffix(d.Name(), ".html")
+}
+
+// buildSitemap walks dir and returns a sitemap listing every page selected by
+// sitemapPage and not omitted by omit, with each location resolved against
+// siteURL.  Directories are listed with a trailing slash, matching the links
+// in the directory listings.  omit may be nil.
+func buildSitemap(dir, siteURL string, omit func(path string, d fs.DirEntry) bool) ([]byte, error) {
+	base := strings.TrimSuffix(siteURL, "/")
+	var set sitemapURLSet
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !sitemapPage(rel, d) || (omit != nil && omit(path, d)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		loc := "/"
+		if rel != "." {
+			loc += filepath.ToSlash(rel)
+			if d.IsDir() {
+				loc += "/"
+			}
+		}
+		set.URLs = append(set.URLs, sitemapURL{
+			Loc:     base + loc,
+			LastMod: info.ModTime().UTC().Format(time.RFC3339),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("buildSitemap: %w", err)
+	}
+	out, err := xml.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("buildSitemap: %w", err)
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
+
+// robotsTxt returns a robots.txt that lets crawlers index the human-readable
+// pages while keeping them away from the binary su3 artifacts, and points
+// them at the generated sitemap.
+func robotsTxt(siteURL string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "User-agent: *")
+	fmt.Fprintln(&buf, "Disallow: /*.su3$")
+	fmt.Fprintf(&buf, "Sitemap: %s%s\n", strings.TrimSuffix(siteURL, "/"), sitemapPath)
+	return buf.Bytes()
+}
+
+// serveSiteFile answers sitemapPath and robotsPath for clearnet mirrors.  It
+// returns false, leaving rw untouched, when SiteURL is unset, the path is
+// neither of the two, or NewsDir contains a real file of that name — an
+// operator-supplied robots.txt or sitemap.xml always wins.
+//
+// The sitemap walks the whole tree, so it is rendered through
+// globalListingCache keyed on the NewsDir mtime like a directory listing;
+// listingTTL bounds how stale it can become when only a subdirectory changes.
+func (n *NewsServer) serveSiteFile(rw http.ResponseWriter, rq *http.Request) bool {
+	if n.SiteURL == "" {
+		return false
+	}
+	path := rq.URL.Path
+	if path != sitemapPath && path != robotsPath {
+		return false
+	}
+	root := servedRoot(rq, n.NewsDir)
+	if _, err := os.Stat(filepath.Join(root, path)); err == nil {
+		return false
+	}
+	if path == robotsPath {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		rw.Write(robotsTxt(n.SiteURL)) //nolint:errcheck
+		return true
+	}
+	fi, err := os.Stat(root)
+	if err != nil {
+		http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+	filter := n.listingFilter(root, root)
+	key := "sitemap\x00" + root + "\x00" + n.SiteURL + "\x00" + filter.key() + "\x00" + strconv.Itoa(n.MaxListingDepth)
+	body, err := globalListingCache.do(key, fi.ModTime(), func() ([]byte, error) {
+		return buildSitemap(root, n.SiteURL, func(path string, d fs.DirEntry) bool {
+			return n.hidden(root, path) || (d.IsDir() && n.beyondDepth(root, path))
+		})
+	})
+	if err != nil {
+		http.Error(rw, "Internal Server Error", http.StatusInternalServerError)
+		return true
+	}
+	rw.Header().Set("Content-Type", "application/xml")
+	rw.Write(body) //nolint:errcheck
+	return true
+}
@@ -0,0 +1,124 @@
+package newsserver
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSitemapTree(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{
+		"news.atom.xml",
+		"news.su3",
+		"index.html",
+		"win/beta/news.su3",
+		"win/beta/notes.html",
+		".hidden/page.html",
+		".news.su3.123.tmp",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// TestBuildSitemap_HumanPagesOnly verifies that the sitemap lists directories
+// and HTML pages but no su3 files, feeds, or dot-files.
+func TestBuildSitemap_HumanPagesOnly(t *testing.T) {
+	dir := writeSitemapTree(t)
+	out, err := buildSitemap(dir, "https://news.example.org/", nil)
+	if err != nil {
+		t.Fatalf("buildSitemap: %v", err)
+	}
+	var set sitemapURLSet
+	if err := xml.Unmarshal(out, &set); err != nil {
+		t.Fatalf("invalid sitemap XML: %v\n%s", err, out)
+	}
+	got := map[string]bool{}
+	for _, u := range set.URLs {
+		got[u.Loc] = true
+		if u.LastMod == "" {
+			t.Errorf("%s has no lastmod", u.Loc)
+		}
+	}
+	for _, want := range []string{
+		"https://news.example.org/",
+		"https://news.example.org/index.html",
+		"https://news.example.org/win/",
+		"https://news.example.org/win/beta/",
+		"https://news.example.org/win/beta/notes.html",
+	} {
+		if !got[want] {
+			t.Errorf("sitemap is missing %s", want)
+		}
+	}
+	if len(got) != 5 {
+		t.Errorf("sitemap has %d URLs, want 5: %v", len(got), got)
+	}
+}
+
+// TestServeHTTP_SitemapAndRobots verifies that both files are generated when
+// SiteURL is set and that robots.txt keeps crawlers away from su3 files.
+func TestServeHTTP_SitemapAndRobots(t *testing.T) {
+	dir := writeSitemapTree(t)
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), SiteURL: "https://news.example.org"}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, "Disallow: /*.su3$") {
+		t.Errorf("robots.txt: code %d body %q", rec.Code, body)
+	}
+	if !strings.Contains(body, "Sitemap: https://news.example.org/sitemap.xml") {
+		t.Errorf("robots.txt does not reference the sitemap: %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/sitemap.xml", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "https://news.example.org/win/beta/") {
+		t.Errorf("sitemap.xml: code %d body %q", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/xml" {
+		t.Errorf("sitemap.xml Content-Type = %q, want application/xml", ct)
+	}
+}
+
+// TestServeHTTP_SitemapDisabledWithoutSiteURL verifies that nothing is
+// generated for I2P-only servers.
+func TestServeHTTP_SitemapDisabledWithoutSiteURL(t *testing.T) {
+	dir := writeSitemapTree(t)
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir)}
+	for _, path := range []string{"/robots.txt", "/sitemap.xml"} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("%s without SiteURL: code %d, want 404", path, rec.Code)
+		}
+	}
+}
+
+// TestServeHTTP_RobotsFileOnDiskWins verifies that an operator-supplied
+// robots.txt is served instead of the generated one.
+func TestServeHTTP_RobotsFileOnDiskWins(t *testing.T) {
+	dir := writeSitemapTree(t)
+	if err := os.WriteFile(filepath.Join(dir, "robots.txt"), []byte("User-agent: *\nDisallow: /\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), SiteURL: "https://news.example.org"}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+	if got := rec.Body.String(); got != "User-agent: *\nDisallow: /\n" {
+		t.Errorf("robots.txt = %q, want the on-disk file", got)
+	}
+}
@@ -0,0 +1,127 @@
+package newsstats
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Language buckets that are not taken from the request.  DefaultLang counts
+// requests without a lang value; UnknownLang counts values that are not
+// language tags at all, so that arbitrary query strings cannot grow the
+// stats without bound.
+const (
+	DefaultLang = "en_US"
+	UnknownLang = "unknown"
+)
+
+// langTagRe matches the shapes of language tag routers and browsers send:
+// "de", "de-DE", "pt_BR", "zh-Hant-TW".
+var langTagRe = regexp.MustCompile(`^[A-Za-z]{2,3}([-_][A-Za-z0-9]{2,8}){0,3}$`)
+
+// canonicalLang rewrites a language tag into the form the stats use: parts
+// joined by "_", the language lowercased, a four-letter script title-cased,
+// and a region uppercased, so "de-de", "de_DE", and "DE-DE" all become
+// "de_DE".  It returns UnknownLang for values that are not language tags.
+func canonicalLang(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !langTagRe.MatchString(raw) {
+		return UnknownLang
+	}
+	parts := strings.FieldsFunc(raw, func(r rune) bool { return r == '-' || r == '_' })
+	parts[0] = strings.ToLower(parts[0])
+	for i := 1; i < len(parts); i++ {
+		p := parts[i]
+		switch {
+		case len(p) == 4 && isAlpha(p):
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		case len(p) == 2 && isAlpha(p), len(p) == 3 && !isAlpha(p):
+			parts[i] = strings.ToUpper(p)
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+// isAlpha reports whether s consists of ASCII letters only.
+func isAlpha(s string) bool {
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// NormalizeLang returns the stats bucket for the lang value of a request:
+// DefaultLang when raw is empty, otherwise its canonical form (see
+// canonicalLang) mapped through aliases.  Alias keys and values are
+// canonicalized too, so "de-DE=de" and "de_DE=de" are the same alias.
+func NormalizeLang(raw string, aliases map[string]string) string {
+	if strings.TrimSpace(raw) == "" {
+		raw = DefaultLang
+	}
+	lang := canonicalLang(raw)
+	if to, ok := aliases[lang]; ok {
+		return to
+	}
+	return lang
+}
+
+// ParseLangAliases parses "from=to" pairs such as "de_DE=de" into the alias
+// map used by NormalizeLang, with both sides canonicalized.
+func ParseLangAliases(specs []string) (map[string]string, error) {
+	aliases := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		from, to, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("ParseLangAliases: %q is not from=to", spec)
+		}
+		cf, ct := canonicalLang(from), canonicalLang(to)
+		if cf == UnknownLang || ct == UnknownLang {
+			return nil, fmt.Errorf("ParseLangAliases: %q is not a pair of language tags", spec)
+		}
+		if cf != ct {
+			aliases[cf] = ct
+		}
+	}
+	return aliases, nil
+}
+
+// mergeLangs returns counts with every bucket renormalized under aliases and
+// the counts of buckets that now share a name summed.
+func mergeLangs(counts map[string]int, aliases map[string]string) map[string]int {
+	merged := make(map[string]int, len(counts))
+	for lang, v := range counts {
+		if lang != UnknownLang {
+			lang = NormalizeLang(lang, aliases)
+		}
+		merged[lang] += v
+	}
+	return merged
+}
+
+// SetLangAliases installs the alias map used by Increment and merges the
+// existing language buckets, overall and per channel, under it.  The merged
+// counts replace the old buckets in the stats files on the next Save.
+func (n *NewsStats) SetLangAliases(aliases map[string]string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.langAliases = aliases
+	n.normalizeLangsLocked()
+}
+
+// normalizeLangsLocked merges DownloadLangs, LastDownload, and ChannelLangs
+// under the current aliases.  n.mu must be held for writing.
+func (n *NewsStats) normalizeLangsLocked() {
+	if n.DownloadLangs != nil {
+		n.DownloadLangs = mergeLangs(n.DownloadLangs, n.langAliases)
+	}
+	if n.LastDownload != nil {
+		n.LastDownload = mergeLastDownload(n.LastDownload, n.langAliases)
+	}
+	for channel, langs := range n.ChannelLangs {
+		n.ChannelLangs[channel] = mergeLangs(langs, n.langAliases)
+	}
+}
@@ -0,0 +1,100 @@
+package newsstats
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LastDownloadStateFile returns the file the last download time of each
+// language is persisted to, e.g. "build/stats.lastdownload.json"; see
+// ClassStateFile.
+func (n *NewsStats) LastDownloadStateFile() string {
+	return n.siblingStateFile("lastdownload")
+}
+
+// langState is the JSON form of one language in LangsJSON.  LastDownload is
+// omitted for languages counted before last downloads were recorded.
+type langState struct {
+	Downloads    int        `json:"downloads"`
+	LastDownload *time.Time `json:"last_download,omitempty"`
+}
+
+// LangsJSON returns the download count and last download time of every
+// language as a JSON object keyed by language bucket.  A language whose last
+// download is long past while others keep updating is a hint that its feed
+// is broken.
+func (n *NewsStats) LangsJSON() ([]byte, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	out := make(map[string]langState, len(n.DownloadLangs))
+	for lang, v := range n.DownloadLangs {
+		s := langState{Downloads: v}
+		if t, ok := n.LastDownload[lang]; ok {
+			t := t.UTC()
+			s.LastDownload = &t
+		}
+		out[lang] = s
+	}
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// mergeLastDownload returns last with every bucket renormalized under aliases,
+// keeping the latest time of buckets that now share a name.
+func mergeLastDownload(last map[string]time.Time, aliases map[string]string) map[string]time.Time {
+	merged := make(map[string]time.Time, len(last))
+	for lang, t := range last {
+		if lang != UnknownLang {
+			lang = NormalizeLang(lang, aliases)
+		}
+		if t.After(merged[lang]) {
+			merged[lang] = t
+		}
+	}
+	return merged
+}
+
+// lastDownloadTitleLocked returns an SVG <title> element listing the last
+// download time of each language, which browsers show as the tooltip of the
+// chart, or nil when no time was recorded yet.  n.mu must be held.
+func (n *NewsStats) lastDownloadTitleLocked() []byte {
+	if len(n.LastDownload) == 0 {
+		return nil
+	}
+	langs := make([]string, 0, len(n.LastDownload))
+	for lang := range n.LastDownload {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	var b strings.Builder
+	b.WriteString("Last download by language:")
+	for _, lang := range langs {
+		fmt.Fprintf(&b, "\n%s: %s", lang, n.LastDownload[lang].UTC().Format("2006-01-02 15:04 MST"))
+	}
+	return []byte("<title>" + html.EscapeString(b.String()) + "</title>")
+}
+
+// withTitle inserts title as the first child of the root <svg> element of svg.
+// svg is returned unchanged when title is nil or it has no <svg> element.
+func withTitle(svg, title []byte) []byte {
+	if title == nil {
+		return svg
+	}
+	start := bytes.Index(svg, []byte("<svg"))
+	if start < 0 {
+		return svg
+	}
+	end := bytes.IndexByte(svg[start:], '>')
+	if end < 0 {
+		return svg
+	}
+	end += start + 1
+	out := make([]byte, 0, len(svg)+len(title))
+	out = append(out, svg[:end]...)
+	out = append(out, title...)
+	return append(out, svg[end:]...)
+}
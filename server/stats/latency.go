@@ -0,0 +1,136 @@
+package newsstats
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the latency histograms.  They span
+// a file answered from the page cache to a large su3 crawling through a slow
+// I2P tunnel.
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	25 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	10 * time.Second,
+	30 * time.Second,
+	2 * time.Minute,
+}
+
+// LatencyStats keeps request latency histograms per content class.  Each
+// request is observed twice: the time to its first byte, which is spent on
+// our disk and CPU, and its total time, which adds sending the body and so
+// the speed of the client's tunnels.  A class whose first bytes are quick but
+// whose totals are slow is limited by the network, not the server.  Like
+// ProtocolStats the histograms are not persisted; they cover the life of the
+// process.  The zero value is ready to use and all methods are safe for
+// concurrent use.
+type LatencyStats struct {
+	// mu protects classes.  It must not be copied after first use.
+	mu      sync.Mutex
+	classes map[string]*classLatency
+}
+
+// classLatency holds the histograms of one content class.
+type classLatency struct {
+	firstByte, total histogram
+}
+
+// histogram counts observations per LatencyBuckets bound, with one more
+// count for those above the last bound.
+type histogram struct {
+	counts []int
+	sum    time.Duration
+}
+
+// observe adds d to h.
+func (h *histogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]int, len(LatencyBuckets)+1)
+	}
+	i := 0
+	for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+		i++
+	}
+	h.counts[i]++
+	h.sum += d
+}
+
+// Observe records a request of class whose first byte was written after
+// firstByte and which finished after total.  A request that wrote nothing
+// has a firstByte equal to total.
+func (l *LatencyStats) Observe(class string, firstByte, total time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.classes == nil {
+		l.classes = make(map[string]*classLatency)
+	}
+	c := l.classes[class]
+	if c == nil {
+		c = &classLatency{}
+		l.classes[class] = c
+	}
+	c.firstByte.observe(firstByte)
+	c.total.observe(total)
+}
+
+// latencyBucket is one bucket of a histogram in LatencyStats.JSON.
+type latencyBucket struct {
+	LE    string `json:"le"`
+	Count int    `json:"count"`
+}
+
+// latencyHistogram is the JSON form of a histogram.
+type latencyHistogram struct {
+	SumSeconds float64         `json:"sum_seconds"`
+	Buckets    []latencyBucket `json:"buckets"`
+}
+
+// latencySnapshot is the JSON form of one content class.
+type latencySnapshot struct {
+	Count     int              `json:"count"`
+	FirstByte latencyHistogram `json:"first_byte"`
+	Total     latencyHistogram `json:"total"`
+}
+
+// snapshot returns the JSON form of h.  As in Prometheus, the buckets are
+// cumulative: each counts the observations up to its "le" bound in seconds,
+// and the last, "+Inf", counts them all.
+func (h *histogram) snapshot() latencyHistogram {
+	out := latencyHistogram{SumSeconds: h.sum.Seconds(), Buckets: make([]latencyBucket, 0, len(LatencyBuckets)+1)}
+	n := 0
+	for i := 0; i <= len(LatencyBuckets); i++ {
+		if h.counts != nil {
+			n += h.counts[i]
+		}
+		le := "+Inf"
+		if i < len(LatencyBuckets) {
+			le = strconv.FormatFloat(LatencyBuckets[i].Seconds(), 'f', -1, 64)
+		}
+		out.Buckets = append(out.Buckets, latencyBucket{LE: le, Count: n})
+	}
+	return out
+}
+
+// JSON returns the histograms as a JSON object keyed by content class, each
+// with the request "count" and the "first_byte" and "total" histograms.
+func (l *LatencyStats) JSON() ([]byte, error) {
+	l.mu.Lock()
+	out := make(map[string]latencySnapshot, len(l.classes))
+	for class, c := range l.classes {
+		first := c.firstByte.snapshot()
+		out[class] = latencySnapshot{
+			Count:     first.Buckets[len(first.Buckets)-1].Count,
+			FirstByte: first,
+			Total:     c.total.snapshot(),
+		}
+	}
+	l.mu.Unlock()
+	return json.MarshalIndent(out, "", "  ")
+}
@@ -0,0 +1,70 @@
+package newsstats
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ProtocolStats counts requests per HTTP protocol version and the number of
+// connections they arrived on.  Requests per connection shows how well
+// keep-alive and HTTP/2 multiplexing are working for a busy mirror.  Unlike
+// NewsStats the counts are not persisted; they cover the life of the process.
+// The zero value is ready to use and all methods are safe for concurrent use.
+type ProtocolStats struct {
+	// mu protects requests, connections, and panics. It must not be copied
+	// after first use.
+	mu          sync.Mutex
+	requests    map[string]int
+	connections int
+	panics      int
+}
+
+// Observe records one request under its protocol version, e.g. "HTTP/1.1" or
+// "HTTP/2.0".
+func (p *ProtocolStats) Observe(rq *http.Request) {
+	p.mu.Lock()
+	if p.requests == nil {
+		p.requests = make(map[string]int)
+	}
+	p.requests[rq.Proto]++
+	p.mu.Unlock()
+}
+
+// ConnState is an http.Server.ConnState hook that counts new connections.
+func (p *ProtocolStats) ConnState(_ net.Conn, state http.ConnState) {
+	if state != http.StateNew {
+		return
+	}
+	p.mu.Lock()
+	p.connections++
+	p.mu.Unlock()
+}
+
+// Panic counts a request whose handler panicked.
+func (p *ProtocolStats) Panic() {
+	p.mu.Lock()
+	p.panics++
+	p.mu.Unlock()
+}
+
+// protocolSnapshot is the JSON form written by ProtocolStats.JSON.
+type protocolSnapshot struct {
+	Requests    map[string]int `json:"requests"`
+	Connections int            `json:"connections"`
+	Panics      int            `json:"panics"`
+}
+
+// JSON returns the current counts as a JSON object with a "requests" map
+// keyed by protocol version, a "connections" total, and the number of
+// "panics" recovered from.
+func (p *ProtocolStats) JSON() ([]byte, error) {
+	p.mu.Lock()
+	snap := protocolSnapshot{Requests: make(map[string]int, len(p.requests)), Connections: p.connections, Panics: p.panics}
+	for k, v := range p.requests {
+		snap.Requests[k] = v
+	}
+	p.mu.Unlock()
+	return json.Marshal(snap)
+}
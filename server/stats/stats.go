@@ -1,15 +1,22 @@
-// Package newsstats tracks per-language su3 download counts and persists them
-// to a JSON file. All exported methods are safe for concurrent use.
+// Package newsstats tracks per-language su3 download counts and last
+// download times, overall and per platform/status channel, su3 transfers by
+// kind, per-content-class request counts, and the entry pages and referers
+// of human page views, and persists them to JSON files. All exported
+// methods are safe for concurrent use.
 package newsstats
 
 import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/wcharczuk/go-chart/v2"
 )
@@ -18,10 +25,82 @@ import (
 // JSON file. All exported methods are safe for concurrent use: reads hold a
 // shared read-lock while writes hold the exclusive write-lock.
 type NewsStats struct {
-	// mu protects DownloadLangs. It must not be copied after first use.
+	// mu protects DownloadLangs, LastDownload, ChannelLangs, ContentClasses,
+	// Transfers, Entries, Referers, langAliases, and recent. It must
+	// not be copied after first use.
 	mu            sync.RWMutex
 	DownloadLangs map[string]int
-	StateFile     string
+	// LastDownload holds when a download was last counted in each
+	// DownloadLangs bucket.  It is persisted next to StateFile; see
+	// LastDownloadStateFile.
+	LastDownload map[string]time.Time
+	// ChannelLangs counts su3 downloads per language for each platform
+	// channel, keyed "platform/status" (e.g. "mac/stable").  Downloads of
+	// the default feed tree are only in DownloadLangs.  It is persisted next
+	// to StateFile; see ChannelStateFile.
+	ChannelLangs map[string]map[string]int
+	// ContentClasses counts requests per content class (ClassAtom,
+	// ClassSu3, ...).  It is persisted next to StateFile; see ClassStateFile.
+	ContentClasses map[string]int
+	// Transfers counts su3 requests per transfer kind (TransferComplete,
+	// TransferPartial, TransferResumed).  It is persisted next to
+	// StateFile; see TransferStateFile.
+	Transfers map[string]int
+	// Entries counts the human page views that entered the mirror from
+	// another site or from no site at all, keyed by URL path, and Referers
+	// the same views keyed by referring host (DirectReferer without one).
+	// They are persisted next to StateFile; see VisitStateFile.
+	Entries   map[string]int
+	Referers  map[string]int
+	StateFile string
+	// DownloadWindow, when positive, counts a download of a su3 file only
+	// once per client within the window, so a client retrying or
+	// re-fetching a feed is not counted again.  Clients behind a tunnel or
+	// proxy without a destination header are not deduplicated; see
+	// downloadClient.  Transfers still counts every request.
+	DownloadWindow time.Duration
+	// langAliases maps canonical language buckets onto the bucket they are
+	// counted in; see SetLangAliases.
+	langAliases map[string]string
+	// recent holds when each client last had a download of each file
+	// counted, and swept when it was last pruned; see firstDownloadLocked.
+	recent map[string]time.Time
+	swept  time.Time
+}
+
+// Content classes recorded by IncrementClass.  ClassAtom counts clients that
+// read the unsigned Atom XML directly instead of the signed su3 file.
+const (
+	ClassAtom    = "atom"
+	ClassSu3     = "su3"
+	ClassListing = "listing"
+	ClassSVG     = "svg"
+	ClassOther   = "other"
+	// ClassLanding is the landing page, and ClassPage any other HTML page;
+	// with ClassListing they are the human page views, see Audience.
+	ClassLanding = "landing"
+	ClassPage    = "page"
+)
+
+// ClassStateFile returns the file the content-class counts are persisted to:
+// StateFile with ".classes" inserted before its extension, e.g.
+// "build/stats.classes.json".  The counts are kept out of StateFile itself so
+// that its existing language → count format is unchanged.
+func (n *NewsStats) ClassStateFile() string {
+	return n.siblingStateFile("classes")
+}
+
+// ChannelStateFile returns the file the per-channel language counts are
+// persisted to, e.g. "build/stats.channels.json"; see ClassStateFile.
+func (n *NewsStats) ChannelStateFile() string {
+	return n.siblingStateFile("channels")
+}
+
+// siblingStateFile returns StateFile with "."+kind inserted before its
+// extension.
+func (n *NewsStats) siblingStateFile(kind string) string {
+	ext := filepath.Ext(n.StateFile)
+	return strings.TrimSuffix(n.StateFile, ext) + "." + kind + ext
 }
 
 // Graph renders a bar chart of per-language download counts as SVG into rw.
@@ -30,34 +109,183 @@ type NewsStats struct {
 // The caller is responsible for writing an appropriate error response when a
 // non-nil error is returned; at that point no bytes have been written to rw.
 func (n *NewsStats) Graph(rw http.ResponseWriter) error {
+	return writeSVG(rw)(n.GraphSVG("", ""))
+}
+
+// Labels are the labels of the total bars of the charts, in one language.
+type Labels struct {
+	TotalRequests  string
+	UpdatesHandled string
+}
+
+// DefaultLabels are the English Labels, used by the charts unless others are
+// given.
+var DefaultLabels = Labels{TotalRequests: "Total Requests", UpdatesHandled: "Approx. Updates Handled"}
+
+// downloads returns the label of the total bar of the download charts.
+func (l Labels) downloads() string {
+	return l.TotalRequests + " / " + l.UpdatesHandled
+}
+
+// GraphSVG returns the bar chart of per-language download counts as SVG.
+// With a non-empty platform or status only the downloads of the matching
+// channels are counted: platform alone selects all of its statuses, status
+// alone that status on every platform.
+func (n *NewsStats) GraphSVG(platform, status string) ([]byte, error) {
+	return n.graph(formatSVG, platform, status, DefaultLabels)
+}
+
+// GraphPNG returns the chart of GraphSVG as a PNG image, for clients that
+// cannot render SVG.
+func (n *NewsStats) GraphPNG(platform, status string) ([]byte, error) {
+	return n.graph(formatPNG, platform, status, DefaultLabels)
+}
+
+// GraphSVGIn and GraphPNGIn return the charts of GraphSVG and GraphPNG with
+// labels in place of DefaultLabels.
+func (n *NewsStats) GraphSVGIn(labels Labels, platform, status string) ([]byte, error) {
+	return n.graph(formatSVG, platform, status, labels)
+}
+
+func (n *NewsStats) GraphPNGIn(labels Labels, platform, status string) ([]byte, error) {
+	return n.graph(formatPNG, platform, status, labels)
+}
+
+// graph renders the chart of GraphSVG in format.
+func (n *NewsStats) graph(format, platform, status string, labels Labels) ([]byte, error) {
+	if platform == "" && status == "" {
+		n.mu.RLock()
+		bars, total := countBars(n.DownloadLangs)
+		title := n.lastDownloadTitleLocked()
+		n.mu.RUnlock()
+		img, err := renderBars(format, "Downloads by language", "No download data yet", labels.downloads(), bars, total)
+		if err != nil || format != formatSVG {
+			return img, err
+		}
+		return withTitle(img, title), nil
+	}
+	counts := map[string]int{}
+	n.mu.RLock()
+	for channel, langs := range n.ChannelLangs {
+		p, st, _ := strings.Cut(channel, "/")
+		if (platform != "" && p != platform) || (status != "" && st != status) {
+			continue
+		}
+		for lang, v := range langs {
+			counts[lang] += v
+		}
+	}
+	bars, total := countBars(counts)
+	n.mu.RUnlock()
+	filter := platform + "/" + status
+	switch {
+	case platform == "":
+		filter = "*/" + status
+	case status == "":
+		filter = platform + "/*"
+	}
+	return renderBars(format, "Downloads by language ("+filter+")", "No download data yet for "+filter, labels.downloads(), bars, total)
+}
+
+// ClassGraph renders a bar chart of per-content-class request counts as SVG
+// into rw, with the same buffering and error contract as Graph.
+func (n *NewsStats) ClassGraph(rw http.ResponseWriter) error {
+	return writeSVG(rw)(n.ClassGraphSVG())
+}
+
+// ClassGraphSVG returns the bar chart of per-content-class request counts as
+// SVG.
+func (n *NewsStats) ClassGraphSVG() ([]byte, error) {
+	return n.classGraph(formatSVG, DefaultLabels)
+}
+
+// ClassGraphPNG returns the chart of ClassGraphSVG as a PNG image.
+func (n *NewsStats) ClassGraphPNG() ([]byte, error) {
+	return n.classGraph(formatPNG, DefaultLabels)
+}
+
+// ClassGraphSVGIn and ClassGraphPNGIn return the charts of ClassGraphSVG and
+// ClassGraphPNG with labels in place of DefaultLabels.
+func (n *NewsStats) ClassGraphSVGIn(labels Labels) ([]byte, error) {
+	return n.classGraph(formatSVG, labels)
+}
+
+func (n *NewsStats) ClassGraphPNGIn(labels Labels) ([]byte, error) {
+	return n.classGraph(formatPNG, labels)
+}
+
+// classGraph renders the chart of ClassGraphSVG in format.
+func (n *NewsStats) classGraph(format string, labels Labels) ([]byte, error) {
 	n.mu.RLock()
+	bars, total := countBars(n.ContentClasses)
+	totals := n.audienceTotalsLocked()
+	n.mu.RUnlock()
+	// Machine traffic and human page views are summed apart, so the chart
+	// shows whether anyone reads the mirror's pages at all.
+	bars = append(bars,
+		chart.Value{Value: float64(totals[AudienceMachine]), Label: "Machine (su3+atom)"},
+		chart.Value{Value: float64(totals[AudienceHuman]), Label: "Human (pages)"})
+	return renderBars(format, "Requests by content type", "No request data yet", labels.TotalRequests, bars, total)
+}
+
+// writeSVG returns a function that writes a rendered SVG to rw, or returns
+// the render error without touching rw.
+func writeSVG(rw http.ResponseWriter) func([]byte, error) error {
+	return func(svg []byte, err error) error {
+		if err != nil {
+			return err
+		}
+		_, err = rw.Write(svg)
+		return err
+	}
+}
+
+// countBars converts counts into chart bars, sorted by label so that the
+// chart is stable between renders, and returns the sum of all counts.
+// The caller must hold n.mu.
+func countBars(counts map[string]int) ([]chart.Value, int) {
 	bars := []chart.Value{
 		{Value: float64(0), Label: "baseline"},
 	}
-	log.Println("Graphing")
+	labels := make([]string, 0, len(counts))
+	for k := range counts {
+		labels = append(labels, k)
+	}
+	sort.Strings(labels)
 	total := 0
-	for k, v := range n.DownloadLangs {
-		log.Printf("Label: %s Value: %d", k, v)
-		total += v
-		bars = append(bars, chart.Value{Value: float64(v), Label: k})
+	for _, k := range labels {
+		total += counts[k]
+		bars = append(bars, chart.Value{Value: float64(counts[k]), Label: k})
 	}
-	n.mu.RUnlock()
-	bars = append(bars, chart.Value{Value: float64(total), Label: "Total Requests / Approx. Updates Handled"})
+	return bars, total
+}
+
+// Image formats of the rendered charts.
+const (
+	formatSVG = "svg"
+	formatPNG = "png"
+)
+
+// renderBars renders bars plus a totalLabel bar as a bar chart in format.
+func renderBars(format, title, noData, totalLabel string, bars []chart.Value, total int) ([]byte, error) {
+	bars = append(bars, chart.Value{Value: float64(total), Label: totalLabel})
 
 	// go-chart fails with "invalid data range; cannot be zero" when every bar
-	// value is 0 (i.e. no downloads have been recorded yet).  Return a minimal
+	// value is 0 (i.e. nothing has been recorded yet).  Return a minimal
 	// valid SVG placeholder so the stats page renders correctly on a
 	// freshly-started server rather than propagating an error.
 	if total == 0 {
-		const noDataSVG = `<svg xmlns="http://www.w3.org/2000/svg" width="400" height="256">` +
-			`<text x="200" y="128" text-anchor="middle" font-size="16">No download data yet</text>` +
+		if format == formatPNG {
+			return noDataPNG(noData)
+		}
+		noDataSVG := `<svg xmlns="http://www.w3.org/2000/svg" width="400" height="256">` +
+			`<text x="200" y="128" text-anchor="middle" font-size="16">` + noData + `</text>` +
 			`</svg>`
-		_, err := fmt.Fprint(rw, noDataSVG)
-		return err
+		return []byte(noDataSVG), nil
 	}
 
 	graph := chart.BarChart{
-		Title: "Downloads by language",
+		Title: title,
 		Background: chart.Style{
 			Padding: chart.Box{
 				Top:   40,
@@ -69,64 +297,380 @@ func (n *NewsStats) Graph(rw http.ResponseWriter) error {
 		BarWidth: 20,
 		Bars:     bars,
 	}
-	// Render into an in-memory buffer. Only copy to rw when rendering
-	// succeeds so that a failure cannot produce a 200 OK with a partial
-	// or empty SVG body.
+	provider := chart.SVG
+	if format == formatPNG {
+		provider = chart.PNG
+	}
+	// Render into an in-memory buffer so that a failure cannot produce a
+	// 200 OK with a partial or empty body.
 	var buf bytes.Buffer
-	if err := graph.Render(chart.SVG, &buf); err != nil {
-		return fmt.Errorf("Graph: render: %w", err)
+	if err := graph.Render(provider, &buf); err != nil {
+		return nil, fmt.Errorf("Graph: render: %w", err)
 	}
-	_, err := rw.Write(buf.Bytes())
-	return err
+	return buf.Bytes(), nil
 }
 
-// Increment records one su3 download. The lang query parameter selects the
-// language bucket; requests with no lang value are counted under "en_US".
-// Safe for concurrent use. Increment is safe to call on a zero-value
-// NewsStats — it initialises DownloadLangs lazily if Load was never called.
-func (n *NewsStats) Increment(rq *http.Request) {
-	q := rq.URL.Query()
-	lang := q.Get("lang")
-	if lang == "" {
-		lang = "en_US"
+// noDataPNG returns the PNG counterpart of the SVG placeholder of
+// renderBars: noData centred on a white 400x256 image.
+func noDataPNG(noData string) ([]byte, error) {
+	const width, height = 400, 256
+	r, err := chart.PNG(width, height)
+	if err != nil {
+		return nil, fmt.Errorf("Graph: render: %w", err)
+	}
+	r.SetFillColor(chart.ColorWhite)
+	r.MoveTo(0, 0)
+	r.LineTo(width, 0)
+	r.LineTo(width, height)
+	r.LineTo(0, height)
+	r.Close()
+	r.Fill()
+	font, err := chart.GetDefaultFont()
+	if err != nil {
+		return nil, fmt.Errorf("Graph: render: %w", err)
+	}
+	r.SetFont(font)
+	r.SetFontSize(16)
+	r.SetFontColor(chart.ColorBlack)
+	box := r.MeasureText(noData)
+	r.Text(noData, (width-box.Width())/2, (height+box.Height())/2)
+	var buf bytes.Buffer
+	if err := r.Save(&buf); err != nil {
+		return nil, fmt.Errorf("Graph: render: %w", err)
 	}
+	return buf.Bytes(), nil
+}
+
+// Increment records one su3 download. The lang query parameter, normalized
+// by NormalizeLang under the aliases set with SetLangAliases, selects the
+// language bucket; requests with no lang value are counted under DefaultLang.
+// The time of the download is recorded in LastDownload.
+// Downloads from a platform feed directory are also counted in ChannelLangs
+// (see requestChannel).
+//
+// Every request is counted in Transfers by its kind, but only the start of a
+// download is counted as one: a Range request resuming a transfer
+// (TransferResumed) is not, and neither is a repeat download by the same
+// client within DownloadWindow. Safe for concurrent use. Increment is safe to
+// call on a zero-value NewsStats — it initialises DownloadLangs lazily if
+// Load was never called.
+func (n *NewsStats) Increment(rq *http.Request) {
+	n.increment(rq, time.Now())
+}
+
+// increment implements Increment for a request received at now.
+func (n *NewsStats) increment(rq *http.Request, now time.Time) {
 	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Transfers == nil {
+		n.Transfers = make(map[string]int)
+	}
+	kind := transferKind(rq.Header.Get("Range"))
+	n.Transfers[kind]++
+	if kind == TransferResumed || !n.firstDownloadLocked(downloadClient(rq), path.Clean("/"+rq.URL.Path), now) {
+		return
+	}
+	lang := NormalizeLang(rq.URL.Query().Get("lang"), n.langAliases)
 	if n.DownloadLangs == nil {
 		// Lazily initialise the map so callers that construct NewsStats
 		// directly (without calling Load) never hit a nil-map panic.
 		n.DownloadLangs = make(map[string]int)
 	}
 	n.DownloadLangs[lang]++
+	if n.LastDownload == nil {
+		n.LastDownload = make(map[string]time.Time)
+	}
+	n.LastDownload[lang] = now
+	if channel := requestChannel(rq.URL.Path); channel != "" {
+		if n.ChannelLangs == nil {
+			n.ChannelLangs = make(map[string]map[string]int)
+		}
+		if n.ChannelLangs[channel] == nil {
+			n.ChannelLangs[channel] = make(map[string]int)
+		}
+		n.ChannelLangs[channel][lang]++
+	}
+}
+
+// requestChannel returns the "platform/status" channel of a su3 request path:
+// the last two directories above the file, as in "/mac/stable/news.su3" or
+// "/news/mac/stable/news.su3".  Paths with fewer than two directories belong
+// to the default feed tree and return "".
+func requestChannel(urlPath string) string {
+	dirs := strings.Split(strings.Trim(path.Dir(path.Clean("/"+urlPath)), "/"), "/")
+	if len(dirs) < 2 {
+		return ""
+	}
+	return dirs[len(dirs)-2] + "/" + dirs[len(dirs)-1]
+}
+
+// IncrementClass records one request for content of the given class. Like
+// Increment it is safe to call on a zero-value NewsStats.
+func (n *NewsStats) IncrementClass(class string) {
+	n.mu.Lock()
+	if n.ContentClasses == nil {
+		n.ContentClasses = make(map[string]int)
+	}
+	n.ContentClasses[class]++
 	n.mu.Unlock()
 }
 
-// Save persists the current download counts to StateFile as JSON.
+// ClassesJSON returns the per-content-class request counts as a JSON object.
+// Every class is present, with 0 for classes not requested yet, so that
+// consumers need not special-case missing keys.
+func (n *NewsStats) ClassesJSON() ([]byte, error) {
+	out := map[string]int{ClassAtom: 0, ClassSu3: 0, ClassListing: 0, ClassLanding: 0, ClassPage: 0, ClassSVG: 0, ClassOther: 0}
+	n.mu.RLock()
+	for k, v := range n.ContentClasses {
+		out[k] = v
+	}
+	n.mu.RUnlock()
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// Save persists the current download counts to StateFile, the last download
+// times to LastDownloadStateFile, the content class
+// counts to ClassStateFile, the per-channel counts to ChannelStateFile, the
+// transfer counts to TransferStateFile, and the visits to VisitStateFile as
+// JSON.  Each file is replaced atomically, so a failed Save never leaves a
+// truncated one behind.  Without a StateFile the counts are kept in memory
+// only and Save does nothing.
 // Safe for concurrent use: it holds a read lock while serialising.
 func (n *NewsStats) Save() error {
+	if n.StateFile == "" {
+		return nil
+	}
 	n.mu.RLock()
 	data, err := json.Marshal(n.DownloadLangs)
-	n.mu.RUnlock()
 	if err != nil {
+		n.mu.RUnlock()
+		return err
+	}
+	last, err := json.Marshal(n.LastDownload)
+	if err != nil {
+		n.mu.RUnlock()
+		return err
+	}
+	classes, err := json.Marshal(n.ContentClasses)
+	if err != nil {
+		n.mu.RUnlock()
+		return err
+	}
+	channels, err := json.Marshal(n.ChannelLangs)
+	if err != nil {
+		n.mu.RUnlock()
+		return err
+	}
+	transfers, err := json.Marshal(n.Transfers)
+	if err != nil {
+		n.mu.RUnlock()
 		return err
 	}
-	if err := os.WriteFile(n.StateFile, data, 0o644); err != nil {
+	visits, err := json.Marshal(visitState{Entries: n.Entries, Referers: n.Referers})
+	n.mu.RUnlock()
+	if err != nil {
 		return err
 	}
+	for _, f := range []struct {
+		path string
+		data []byte
+	}{
+		{n.StateFile, data},
+		{n.LastDownloadStateFile(), last},
+		{n.ClassStateFile(), classes},
+		{n.ChannelStateFile(), channels},
+		{n.TransferStateFile(), transfers},
+		{n.VisitStateFile(), visits},
+	} {
+		if err := writeFileAtomic(f.path, f.data); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it to path, so that a crash or a full disk never leaves a truncated state
+// file behind for Load.  The temporary file is removed on failure.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// archiveTimeFormat is the timestamp Archive inserts into archive names.
+const archiveTimeFormat = "20060102T150405Z"
+
+// ArchivePattern returns a filepath.Match pattern matching the StateFile
+// of every archive Archive writes, and their sibling files.
+func (n *NewsStats) ArchivePattern() string {
+	ext := filepath.Ext(n.StateFile)
+	return strings.TrimSuffix(n.StateFile, ext) + ".*" + ext
+}
+
+// Archive checkpoints the counts: it saves them like Save, but to a
+// StateFile with the UTC time now inserted before its extension, e.g.
+// "build/stats.20250601T120000Z.json" next to its sibling files, and then
+// starts every count again from zero and saves the empty state to
+// StateFile.  It returns the StateFile of the archive.  When the archive
+// cannot be written the counts are kept, and without a StateFile there is
+// nowhere to write it.
+func (n *NewsStats) Archive(now time.Time) (string, error) {
+	if n.StateFile == "" {
+		return "", fmt.Errorf("Archive: stats are not persisted")
+	}
+	ext := filepath.Ext(n.StateFile)
+	archive := &NewsStats{StateFile: strings.TrimSuffix(n.StateFile, ext) + "." + now.UTC().Format(archiveTimeFormat) + ext}
+	n.mu.Lock()
+	archive.DownloadLangs, n.DownloadLangs = n.DownloadLangs, make(map[string]int)
+	// The last download times are not counts: they are archived but kept,
+	// so a language that stopped updating before the archive still shows
+	// when it did.
+	archive.LastDownload = make(map[string]time.Time, len(n.LastDownload))
+	for lang, t := range n.LastDownload {
+		archive.LastDownload[lang] = t
+	}
+	archive.ContentClasses, n.ContentClasses = n.ContentClasses, make(map[string]int)
+	archive.ChannelLangs, n.ChannelLangs = n.ChannelLangs, make(map[string]map[string]int)
+	archive.Transfers, n.Transfers = n.Transfers, make(map[string]int)
+	archive.Entries, n.Entries = n.Entries, make(map[string]int)
+	archive.Referers, n.Referers = n.Referers, make(map[string]int)
+	n.mu.Unlock()
+	if err := archive.Save(); err != nil {
+		// Put the counts back, together with anything counted meanwhile.
+		n.mu.Lock()
+		addCounts(n.DownloadLangs, archive.DownloadLangs)
+		addCounts(n.ContentClasses, archive.ContentClasses)
+		addCounts(n.Transfers, archive.Transfers)
+		addCounts(n.Entries, archive.Entries)
+		addCounts(n.Referers, archive.Referers)
+		for channel, langs := range archive.ChannelLangs {
+			if n.ChannelLangs[channel] == nil {
+				n.ChannelLangs[channel] = make(map[string]int)
+			}
+			addCounts(n.ChannelLangs[channel], langs)
+		}
+		n.mu.Unlock()
+		return "", fmt.Errorf("Archive: %w", err)
+	}
+	if err := n.Save(); err != nil {
+		return archive.StateFile, fmt.Errorf("Archive: %w", err)
+	}
+	return archive.StateFile, nil
+}
+
+// addCounts adds every count of src to dst.
+func addCounts(dst, src map[string]int) {
+	for k, v := range src {
+		dst[k] += v
+	}
+}
+
+// Summary returns a one-line summary of the counts for the log, e.g.
+// "120 su3 downloads in 14 languages; su3 transfers: complete=118 partial=2
+// resumed=5; requests: atom=3 listing=2 su3=125".
+func (n *NewsStats) Summary() string {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	total := 0
+	for _, v := range n.DownloadLangs {
+		total += v
+	}
+	classes := make([]string, 0, len(n.ContentClasses))
+	for k, v := range n.ContentClasses {
+		classes = append(classes, fmt.Sprintf("%s=%d", k, v))
+	}
+	sort.Strings(classes)
+	return fmt.Sprintf("%d su3 downloads in %d languages; su3 transfers: %s=%d %s=%d %s=%d; requests: %s",
+		total, len(n.DownloadLangs),
+		TransferComplete, n.Transfers[TransferComplete], TransferPartial, n.Transfers[TransferPartial], TransferResumed, n.Transfers[TransferResumed],
+		strings.Join(classes, " "))
+}
+
 // Load reads persisted download stats from StateFile. It is safe under all
 // failure modes: missing file, malformed JSON, and a file containing the JSON
 // value "null" (which would otherwise unmarshal successfully into a nil map,
 // causing a panic on the next Increment call).
 //
+// Without a StateFile nothing is read and every count starts from zero.
+//
 // Load is typically called once during initialisation; the write lock ensures
 // safety if Load and Increment are ever called concurrently.
 func (n *NewsStats) Load() {
 	n.mu.Lock()
 	defer n.mu.Unlock()
 
-	data, err := os.ReadFile(n.StateFile)
+	// The last download times and the class, channel, transfer, and visit
+	// counts are optional: stats files written before they were tracked have
+	// no sibling files.  The same failure handling applies.
+	n.LastDownload = nil
+	if data, err := n.readState(n.LastDownloadStateFile()); err == nil {
+		if err := json.Unmarshal(data, &n.LastDownload); err != nil {
+			n.LastDownload = nil
+		}
+	}
+	if n.LastDownload == nil {
+		n.LastDownload = make(map[string]time.Time)
+	}
+	n.ContentClasses = nil
+	if data, err := n.readState(n.ClassStateFile()); err == nil {
+		if err := json.Unmarshal(data, &n.ContentClasses); err != nil {
+			n.ContentClasses = nil
+		}
+	}
+	if n.ContentClasses == nil {
+		n.ContentClasses = make(map[string]int)
+	}
+	n.ChannelLangs = nil
+	if data, err := n.readState(n.ChannelStateFile()); err == nil {
+		if err := json.Unmarshal(data, &n.ChannelLangs); err != nil {
+			n.ChannelLangs = nil
+		}
+	}
+	if n.ChannelLangs == nil {
+		n.ChannelLangs = make(map[string]map[string]int)
+	}
+	n.Transfers = nil
+	if data, err := n.readState(n.TransferStateFile()); err == nil {
+		if err := json.Unmarshal(data, &n.Transfers); err != nil {
+			n.Transfers = nil
+		}
+	}
+	if n.Transfers == nil {
+		n.Transfers = make(map[string]int)
+	}
+	var visits visitState
+	if data, err := n.readState(n.VisitStateFile()); err == nil {
+		if err := json.Unmarshal(data, &visits); err != nil {
+			visits = visitState{}
+		}
+	}
+	n.Entries, n.Referers = visits.Entries, visits.Referers
+	if n.Entries == nil {
+		n.Entries = make(map[string]int)
+	}
+	if n.Referers == nil {
+		n.Referers = make(map[string]int)
+	}
+
+	// Buckets written before language normalization (e.g. "de-DE" next to
+	// "de_DE") are merged once loaded, whichever way the stats file loads.
+	defer n.normalizeLangsLocked()
+
+	data, err := n.readState(n.StateFile)
 	if err != nil {
 		// File missing or unreadable — start with an empty map.
 		n.DownloadLangs = make(map[string]int)
@@ -143,3 +687,13 @@ func (n *NewsStats) Load() {
 		n.DownloadLangs = make(map[string]int)
 	}
 }
+
+// readState reads the state file path for Load.  Without a StateFile there
+// is none, as the sibling names derived from an empty StateFile are not
+// state files.
+func (n *NewsStats) readState(path string) ([]byte, error) {
+	if n.StateFile == "" {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(path)
+}
@@ -1,12 +1,16 @@
 package newsstats
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestLoad_MissingFile(t *testing.T) {
@@ -133,6 +137,37 @@ func TestSaveLoad_RoundTrip(t *testing.T) {
 	}
 }
 
+// TestSave_Atomic verifies that Save writes its files through temporary
+// files in the same directory, and that a Save which cannot put one of them
+// in place returns the error without leaving a temporary file behind.
+func TestSave_Atomic(t *testing.T) {
+	dir := t.TempDir()
+	sf := filepath.Join(dir, "stats.json")
+	n := &NewsStats{StateFile: sf, DownloadLangs: map[string]int{"en_US": 3}}
+	if err := n.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	// A non-empty directory in place of the visits file makes its rename fail.
+	if err := os.Remove(n.VisitStateFile()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(n.VisitStateFile(), "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Save(); err == nil {
+		t.Fatal("expected an error saving over a directory")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
 // TestIncrement_ConcurrentSafety verifies that Increment is safe to call from
 // multiple goroutines simultaneously. Before the mutex was added, concurrent
 // calls produced a "fatal error: concurrent map writes" runtime panic. Running
@@ -299,3 +334,557 @@ func TestSave_ConcurrentWithIncrement(t *testing.T) {
 	}
 	wg.Wait()
 }
+
+// TestProtocolStats_CountsRequestsAndConnections verifies that requests are
+// bucketed by protocol version and that only new connections are counted.
+func TestProtocolStats_CountsRequestsAndConnections(t *testing.T) {
+	var p ProtocolStats
+	rq1 := httptest.NewRequest(http.MethodGet, "/news.su3", nil)
+	rq2 := httptest.NewRequest(http.MethodGet, "/news.su3", nil)
+	rq2.Proto, rq2.ProtoMajor, rq2.ProtoMinor = "HTTP/2.0", 2, 0
+	p.Observe(rq1)
+	p.Observe(rq1)
+	p.Observe(rq2)
+	p.ConnState(nil, http.StateNew)
+	p.ConnState(nil, http.StateActive)
+	p.ConnState(nil, http.StateIdle)
+
+	data, err := p.JSON()
+	if err != nil {
+		t.Fatalf("JSON: %v", err)
+	}
+	var got struct {
+		Requests    map[string]int `json:"requests"`
+		Connections int            `json:"connections"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("invalid JSON %s: %v", data, err)
+	}
+	if got.Requests["HTTP/1.1"] != 2 || got.Requests["HTTP/2.0"] != 1 {
+		t.Errorf("requests = %v, want HTTP/1.1:2 HTTP/2.0:1", got.Requests)
+	}
+	if got.Connections != 1 {
+		t.Errorf("connections = %d, want 1", got.Connections)
+	}
+}
+
+// TestSaveLoad_ContentClasses verifies that content-class counts round-trip
+// through ClassStateFile while StateFile keeps its language-only format.
+func TestSaveLoad_ContentClasses(t *testing.T) {
+	dir := t.TempDir()
+	sf := filepath.Join(dir, "stats.json")
+	n := &NewsStats{StateFile: sf, DownloadLangs: map[string]int{"en_US": 1}}
+	n.IncrementClass(ClassAtom)
+	n.IncrementClass(ClassAtom)
+	n.IncrementClass(ClassSu3)
+	if err := n.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	if got, want := n.ClassStateFile(), filepath.Join(dir, "stats.classes.json"); got != want {
+		t.Errorf("ClassStateFile = %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(sf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"en_US":1}` {
+		t.Errorf("StateFile = %s, want language counts only", data)
+	}
+	n2 := &NewsStats{StateFile: sf}
+	n2.Load()
+	if n2.ContentClasses[ClassAtom] != 2 || n2.ContentClasses[ClassSu3] != 1 {
+		t.Errorf("ContentClasses = %v, want atom=2 su3=1", n2.ContentClasses)
+	}
+}
+
+// TestLoad_MissingClassFile verifies that a stats file written before content
+// classes were tracked still loads, with empty class counts.
+func TestLoad_MissingClassFile(t *testing.T) {
+	dir := t.TempDir()
+	sf := filepath.Join(dir, "stats.json")
+	if err := os.WriteFile(sf, []byte(`{"de":4}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n := &NewsStats{StateFile: sf}
+	n.Load()
+	if n.DownloadLangs["de"] != 4 {
+		t.Errorf("expected de=4, got %d", n.DownloadLangs["de"])
+	}
+	if n.ContentClasses == nil || len(n.ContentClasses) != 0 {
+		t.Errorf("ContentClasses = %v, want empty non-nil map", n.ContentClasses)
+	}
+}
+
+// TestClassesJSON_AllClassesPresent verifies that ClassesJSON reports every
+// class, including ones never requested.
+func TestClassesJSON_AllClassesPresent(t *testing.T) {
+	n := &NewsStats{}
+	n.IncrementClass(ClassListing)
+	data, err := n.ClassesJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]int
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("ClassesJSON returned invalid JSON: %v", err)
+	}
+	want := map[string]int{ClassAtom: 0, ClassSu3: 0, ClassListing: 1, ClassLanding: 0, ClassPage: 0, ClassSVG: 0, ClassOther: 0}
+	if len(got) != len(want) {
+		t.Fatalf("ClassesJSON = %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("ClassesJSON[%q] = %d, want %d", k, got[k], v)
+		}
+	}
+}
+
+// TestClassGraph_WritesSVG verifies that ClassGraph renders both the empty
+// placeholder and a populated chart.
+func TestClassGraph_WritesSVG(t *testing.T) {
+	n := &NewsStats{}
+	rw := httptest.NewRecorder()
+	if err := n.ClassGraph(rw); err != nil {
+		t.Fatalf("ClassGraph on empty stats: %v", err)
+	}
+	if !containsSVG(rw.Body.Bytes()) {
+		t.Errorf("expected placeholder SVG, got %q", rw.Body.String())
+	}
+	n.IncrementClass(ClassAtom)
+	n.IncrementClass(ClassSu3)
+	rw = httptest.NewRecorder()
+	if err := n.ClassGraph(rw); err != nil {
+		t.Fatalf("ClassGraph: %v", err)
+	}
+	if !containsSVG(rw.Body.Bytes()) {
+		t.Errorf("ClassGraph body does not contain '<svg'")
+	}
+}
+
+func TestRequestChannel(t *testing.T) {
+	for in, want := range map[string]string{
+		"/news.su3":                  "",
+		"/news/news_de.su3":          "",
+		"/mac/stable/news.su3":       "mac/stable",
+		"/news/win/beta/news_de.su3": "win/beta",
+		"mac/stable/../beta/x.su3":   "mac/beta",
+	} {
+		if got := requestChannel(in); got != want {
+			t.Errorf("requestChannel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// TestIncrement_CountsChannels verifies that platform downloads are counted
+// per channel as well as overall, and that the channel counts round-trip
+// through ChannelStateFile.
+func TestIncrement_CountsChannels(t *testing.T) {
+	sf := filepath.Join(t.TempDir(), "stats.json")
+	n := &NewsStats{StateFile: sf}
+	for _, target := range []string{"/news.su3", "/mac/stable/news.su3?lang=de", "/mac/beta/news.su3", "/win/stable/news.su3"} {
+		n.Increment(httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	if n.DownloadLangs["en_US"] != 3 || n.DownloadLangs["de"] != 1 {
+		t.Errorf("DownloadLangs = %v, want en_US=3 de=1", n.DownloadLangs)
+	}
+	if err := n.Save(); err != nil {
+		t.Fatalf("Save error: %v", err)
+	}
+	n2 := &NewsStats{StateFile: sf}
+	n2.Load()
+	want := map[string]map[string]int{
+		"mac/stable": {"de": 1},
+		"mac/beta":   {"en_US": 1},
+		"win/stable": {"en_US": 1},
+	}
+	if len(n2.ChannelLangs) != len(want) {
+		t.Fatalf("ChannelLangs = %v, want %v", n2.ChannelLangs, want)
+	}
+	for channel, langs := range want {
+		for lang, v := range langs {
+			if n2.ChannelLangs[channel][lang] != v {
+				t.Errorf("ChannelLangs[%q][%q] = %d, want %d", channel, lang, n2.ChannelLangs[channel][lang], v)
+			}
+		}
+	}
+}
+
+// TestGraphSVG_Filter verifies that a platform/status filter only charts the
+// matching channels.
+func TestGraphSVG_Filter(t *testing.T) {
+	n := &NewsStats{ChannelLangs: map[string]map[string]int{
+		"mac/stable": {"de": 4},
+		"win/stable": {"fr": 2},
+	}}
+	for _, tt := range []struct {
+		platform, status string
+		want, notWant    []string
+	}{
+		{"mac", "stable", []string{"(mac/stable)", ">de<"}, []string{">fr<"}},
+		{"", "stable", []string{"(*/stable)", ">de<", ">fr<"}, nil},
+		{"win", "", []string{"(win/*)", ">fr<"}, []string{">de<"}},
+	} {
+		svg, err := n.GraphSVG(tt.platform, tt.status)
+		if err != nil {
+			t.Fatalf("GraphSVG(%q, %q): %v", tt.platform, tt.status, err)
+		}
+		for _, w := range tt.want {
+			if !strings.Contains(string(svg), w) {
+				t.Errorf("GraphSVG(%q, %q) missing %q", tt.platform, tt.status, w)
+			}
+		}
+		for _, w := range tt.notWant {
+			if strings.Contains(string(svg), w) {
+				t.Errorf("GraphSVG(%q, %q) contains %q", tt.platform, tt.status, w)
+			}
+		}
+	}
+	svg, err := n.GraphSVG("ios", "")
+	if err != nil || !strings.Contains(string(svg), "No download data yet for ios/*") {
+		t.Errorf("GraphSVG for a channel without downloads = %q, %v; want placeholder", svg, err)
+	}
+}
+
+// TestNormalizeLang verifies the canonical bucket form, the default and
+// unknown buckets, and alias mapping.
+func TestNormalizeLang(t *testing.T) {
+	aliases, err := ParseLangAliases([]string{"de-DE=de", "pt=pt_BR"})
+	if err != nil {
+		t.Fatalf("ParseLangAliases: %v", err)
+	}
+	for raw, want := range map[string]string{
+		"":           DefaultLang,
+		"de":         "de",
+		"DE":         "de",
+		"de_DE":      "de",
+		"de-de":      "de",
+		"fr-fr":      "fr_FR",
+		"zh-hant-tw": "zh_Hant_TW",
+		"es-419":     "es_419",
+		"pt":         "pt_BR",
+		"<script>":   UnknownLang,
+		"english!!":  UnknownLang,
+	} {
+		if got := NormalizeLang(raw, aliases); got != want {
+			t.Errorf("NormalizeLang(%q) = %q, want %q", raw, got, want)
+		}
+	}
+	if _, err := ParseLangAliases([]string{"de"}); err == nil {
+		t.Error("ParseLangAliases accepted a spec without '='")
+	}
+}
+
+// TestLoad_MergesLangBuckets verifies that buckets saved before
+// normalization are merged on Load and again under aliases installed later,
+// overall and per channel.
+func TestLoad_MergesLangBuckets(t *testing.T) {
+	dir := t.TempDir()
+	n := &NewsStats{StateFile: filepath.Join(dir, "stats.json")}
+	if err := os.WriteFile(n.StateFile, []byte(`{"de":1,"de-DE":2,"de_DE":3,"en_US":4}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(n.ChannelStateFile(), []byte(`{"mac/stable":{"fr-fr":1,"fr_FR":1}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n.Load()
+	if n.DownloadLangs["de_DE"] != 5 || n.DownloadLangs["de"] != 1 || len(n.DownloadLangs) != 3 {
+		t.Errorf("after Load: %v, want de_DE merged to 5", n.DownloadLangs)
+	}
+	if got := n.ChannelLangs["mac/stable"]["fr_FR"]; got != 2 {
+		t.Errorf("channel fr_FR = %d, want 2", got)
+	}
+
+	n.SetLangAliases(map[string]string{"de_DE": "de"})
+	n.Increment(httptest.NewRequest(http.MethodGet, "/news.su3?lang=de-de", nil))
+	if n.DownloadLangs["de"] != 7 || n.DownloadLangs["de_DE"] != 0 {
+		t.Errorf("after aliasing: %v, want every German download under de", n.DownloadLangs)
+	}
+	if err := n.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(n.StateFile)
+	if strings.Contains(string(data), "de_DE") || strings.Contains(string(data), "de-DE") {
+		t.Errorf("saved stats still hold the merged buckets: %s", data)
+	}
+}
+
+// TestGraphPNG verifies that the PNG charts, placeholder and populated, are
+// PNG images.
+func TestGraphPNG(t *testing.T) {
+	isPNG := func(b []byte) bool { return bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")) }
+	n := &NewsStats{}
+	for name, render := range map[string]func() ([]byte, error){
+		"GraphPNG":      func() ([]byte, error) { return n.GraphPNG("", "") },
+		"ClassGraphPNG": n.ClassGraphPNG,
+	} {
+		img, err := render()
+		if err != nil || !isPNG(img) {
+			t.Errorf("%s on empty stats = %d bytes, %v; want a PNG placeholder", name, len(img), err)
+		}
+	}
+	n.DownloadLangs = map[string]int{"de": 3}
+	n.IncrementClass(ClassAtom)
+	for name, render := range map[string]func() ([]byte, error){
+		"GraphPNG":      func() ([]byte, error) { return n.GraphPNG("", "") },
+		"ClassGraphPNG": n.ClassGraphPNG,
+	} {
+		img, err := render()
+		if err != nil || !isPNG(img) {
+			t.Errorf("%s = %d bytes, %v; want a PNG chart", name, len(img), err)
+		}
+	}
+}
+
+func TestArchive(t *testing.T) {
+	dir := t.TempDir()
+	n := &NewsStats{StateFile: filepath.Join(dir, "stats.json")}
+	n.Load()
+	n.Increment(httptest.NewRequest("GET", "/news.su3?lang=de", nil))
+	n.IncrementClass("su3")
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	archive, err := n.Archive(now)
+	if err != nil {
+		t.Fatalf("Archive: %v", err)
+	}
+	if want := filepath.Join(dir, "stats.20250601T120000Z.json"); archive != want {
+		t.Errorf("archive = %q, want %q", archive, want)
+	}
+	if ok, _ := filepath.Match(n.ArchivePattern(), archive); !ok {
+		t.Errorf("ArchivePattern %q does not match %q", n.ArchivePattern(), archive)
+	}
+	archived := &NewsStats{StateFile: archive}
+	archived.Load()
+	if archived.DownloadLangs["de"] != 1 || archived.ContentClasses["su3"] != 1 {
+		t.Errorf("archive = %v %v, want the counts before the reset", archived.DownloadLangs, archived.ContentClasses)
+	}
+	reloaded := &NewsStats{StateFile: n.StateFile}
+	reloaded.Load()
+	if len(n.DownloadLangs) != 0 || len(reloaded.DownloadLangs) != 0 || len(reloaded.ContentClasses) != 0 {
+		t.Errorf("counts after Archive = %v, saved %v %v, want none", n.DownloadLangs, reloaded.DownloadLangs, reloaded.ContentClasses)
+	}
+}
+
+// TestStateFile_Empty verifies that without a StateFile the counts are kept
+// in memory only: Load starts from zero, Save writes nothing, and Archive is
+// refused.
+func TestStateFile_Empty(t *testing.T) {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	n := &NewsStats{}
+	n.Load()
+	n.Increment(httptest.NewRequest("GET", "/news.su3?lang=de", nil))
+	if err := n.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("Save without a StateFile wrote %d files", len(entries))
+	}
+	if _, err := n.Archive(time.Now()); err == nil {
+		t.Error("Archive without a StateFile succeeded")
+	}
+	if n.DownloadLangs["de"] != 1 {
+		t.Errorf("DownloadLangs = %v, want the in-memory count kept", n.DownloadLangs)
+	}
+}
+
+// TestTransferKind verifies the classification of su3 requests by their
+// Range header.
+func TestTransferKind(t *testing.T) {
+	tests := map[string]string{
+		"":                TransferComplete,
+		"bytes=0-":        TransferPartial,
+		"bytes=0-1023":    TransferPartial,
+		"bytes=1024-":     TransferResumed,
+		"bytes=-512":      TransferResumed,
+		"bytes=2048-, 0-": TransferResumed,
+		"items=5-":        TransferComplete,
+		"bytes=garbage":   TransferComplete,
+	}
+	for header, want := range tests {
+		if got := transferKind(header); got != want {
+			t.Errorf("transferKind(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
+
+// TestIncrement_DownloadWindow verifies that resumed transfers and repeat
+// downloads by the same client within DownloadWindow are not counted as
+// downloads, while every request is counted in Transfers.
+func TestIncrement_DownloadWindow(t *testing.T) {
+	n := &NewsStats{DownloadWindow: time.Hour}
+	start := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	request := func(client, target, rangeHeader string) *http.Request {
+		rq := httptest.NewRequest("GET", target, nil)
+		rq.RemoteAddr = client
+		if rangeHeader != "" {
+			rq.Header.Set("Range", rangeHeader)
+		}
+		return rq
+	}
+	n.increment(request("10.0.0.1:1000", "/news.su3?lang=de", "bytes=0-4095"), start)
+	n.increment(request("10.0.0.1:1001", "/news.su3?lang=de", "bytes=4096-"), start.Add(time.Minute))
+	n.increment(request("10.0.0.1:1002", "/news.su3?lang=de", ""), start.Add(10*time.Minute))
+	n.increment(request("10.0.0.2:1000", "/news.su3?lang=de", ""), start.Add(10*time.Minute))
+	n.increment(request("10.0.0.1:1003", "/mac/stable/news.su3?lang=de", ""), start.Add(10*time.Minute))
+	if got := n.DownloadLangs["de"]; got != 3 {
+		t.Errorf("downloads within the window = %d, want 3 (one per client and file)", got)
+	}
+	n.increment(request("10.0.0.1:1004", "/news.su3?lang=de", ""), start.Add(2*time.Hour))
+	if got := n.DownloadLangs["de"]; got != 4 {
+		t.Errorf("downloads after the window = %d, want 4", got)
+	}
+	want := map[string]int{TransferComplete: 4, TransferPartial: 1, TransferResumed: 1}
+	for kind, count := range want {
+		if n.Transfers[kind] != count {
+			t.Errorf("Transfers[%s] = %d, want %d", kind, n.Transfers[kind], count)
+		}
+	}
+	if len(n.recent) != 1 {
+		t.Errorf("recent holds %d downloads after the window, want only the last one", len(n.recent))
+	}
+
+	dir := t.TempDir()
+	n.StateFile = filepath.Join(dir, "stats.json")
+	if err := n.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	reloaded := &NewsStats{StateFile: n.StateFile}
+	reloaded.Load()
+	if reloaded.Transfers[TransferResumed] != 1 || reloaded.Transfers[TransferComplete] != 4 {
+		t.Errorf("reloaded Transfers = %v, want %v", reloaded.Transfers, want)
+	}
+}
+
+// TestIncrement_DownloadWindowLoopback verifies that loopback peers, as seen
+// behind an I2PTunnel server tunnel, are told apart by their destination
+// header, and that loopback requests without one are all counted.
+func TestIncrement_DownloadWindowLoopback(t *testing.T) {
+	n := &NewsStats{DownloadWindow: time.Hour}
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	request := func(header, dest string) *http.Request {
+		rq := httptest.NewRequest("GET", "/news.su3?lang=de", nil)
+		rq.RemoteAddr = "127.0.0.1:40000"
+		if header != "" {
+			rq.Header.Set(header, dest)
+		}
+		return rq
+	}
+	n.increment(request("X-I2P-DestB32", "aaaa.b32.i2p"), now)
+	n.increment(request("X-I2P-DestB32", "AAAA.b32.i2p"), now)
+	n.increment(request("X-I2P-DestB32", "bbbb.b32.i2p"), now)
+	n.increment(request("X-I2P-DestHash", "cccc"), now)
+	n.increment(request("X-I2P-DestHash", "cccc"), now)
+	if got := n.DownloadLangs["de"]; got != 3 {
+		t.Errorf("downloads of three tunnelled destinations = %d, want 3", got)
+	}
+	n.increment(request("", ""), now)
+	n.increment(request("", ""), now)
+	if got := n.DownloadLangs["de"]; got != 5 {
+		t.Errorf("downloads after two unidentified loopback requests = %d, want 5", got)
+	}
+
+	// The header is not trusted from anywhere but loopback.
+	rq := request("X-I2P-DestB32", "dddd.b32.i2p")
+	rq.RemoteAddr = "10.0.0.1:1000"
+	if got := downloadClient(rq); got != "10.0.0.1" {
+		t.Errorf("downloadClient of a remote peer with a destination header = %q, want its address", got)
+	}
+}
+
+// TestVisits verifies that VisitsJSON splits the content classes into
+// machine and human traffic and that visits survive a Save and Load.
+func TestVisits(t *testing.T) {
+	sf := filepath.Join(t.TempDir(), "stats.json")
+	n := &NewsStats{StateFile: sf}
+	for _, class := range []string{ClassSu3, ClassSu3, ClassAtom, ClassListing, ClassLanding, ClassPage, ClassSVG, ClassOther} {
+		n.IncrementClass(class)
+	}
+	n.IncrementVisit("/", "")
+	n.IncrementVisit("/", "forum.example.org")
+	n.IncrementVisit("mac//stable/", "")
+	var got struct {
+		Machine, Human    int
+		Entries, Referers map[string]int
+	}
+	data, err := n.VisitsJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("VisitsJSON returned invalid JSON: %v", err)
+	}
+	if got.Machine != 3 || got.Human != 3 {
+		t.Errorf("machine, human = %d, %d; want 3, 3", got.Machine, got.Human)
+	}
+	if got.Entries["/"] != 2 || got.Entries["/mac/stable"] != 1 {
+		t.Errorf("Entries = %v", got.Entries)
+	}
+	if got.Referers[DirectReferer] != 2 || got.Referers["forum.example.org"] != 1 {
+		t.Errorf("Referers = %v", got.Referers)
+	}
+	if err := n.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := &NewsStats{StateFile: sf}
+	reloaded.Load()
+	if reloaded.Entries["/"] != 2 || reloaded.Referers["forum.example.org"] != 1 {
+		t.Errorf("reloaded Entries = %v, Referers = %v", reloaded.Entries, reloaded.Referers)
+	}
+	if Audience(ClassSVG) != "" {
+		t.Errorf("Audience(%q) = %q, want none", ClassSVG, Audience(ClassSVG))
+	}
+}
+
+// TestLastDownload verifies that Increment records the last download time of
+// each language, that the times merge under aliases keeping the latest, and
+// that they survive a Save and Load and appear in LangsJSON and the chart.
+func TestLastDownload(t *testing.T) {
+	sf := filepath.Join(t.TempDir(), "stats.json")
+	n := &NewsStats{StateFile: sf}
+	n.Load()
+	early := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	late := early.Add(2 * time.Hour)
+	n.increment(httptest.NewRequest(http.MethodGet, "/news.su3?lang=de_DE", nil), late)
+	n.increment(httptest.NewRequest(http.MethodGet, "/news.su3?lang=de", nil), early)
+	n.increment(httptest.NewRequest(http.MethodGet, "/news.su3?lang=fr", nil), early)
+	n.SetLangAliases(map[string]string{"de_DE": "de"})
+	if got := n.LastDownload["de"]; !got.Equal(late) {
+		t.Errorf("LastDownload[de] = %v, want the later %v", got, late)
+	}
+	if err := n.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded := &NewsStats{StateFile: sf}
+	reloaded.Load()
+	data, err := reloaded.LangsJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]struct {
+		Downloads    int        `json:"downloads"`
+		LastDownload *time.Time `json:"last_download"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("LangsJSON returned invalid JSON: %v", err)
+	}
+	if d := got["de"]; d.Downloads != 2 || d.LastDownload == nil || !d.LastDownload.Equal(late) {
+		t.Errorf("de = %+v, want 2 downloads, last at %v", d, late)
+	}
+	if f := got["fr"]; f.Downloads != 1 || f.LastDownload == nil || !f.LastDownload.Equal(early) {
+		t.Errorf("fr = %+v, want 1 download, last at %v", f, early)
+	}
+	svg, err := reloaded.GraphSVG("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(svg, []byte("<title>Last download by language:")) || !bytes.Contains(svg, []byte("fr: 2025-06-01 12:00 UTC")) {
+		t.Errorf("chart has no last download tooltip:\n%s", svg)
+	}
+}
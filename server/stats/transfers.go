@@ -0,0 +1,102 @@
+package newsstats
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transfer kinds recorded in Transfers, one per su3 request.
+const (
+	// TransferComplete is a request for the whole file.
+	TransferComplete = "complete"
+	// TransferPartial is a Range request starting at the first byte: the
+	// first chunk of a download fetched in pieces.
+	TransferPartial = "partial"
+	// TransferResumed is a Range request starting past the first byte: a
+	// client continuing a download it already started.
+	TransferResumed = "resumed"
+)
+
+// DefaultDownloadWindow is the DownloadWindow serve uses unless told
+// otherwise.
+const DefaultDownloadWindow = time.Hour
+
+// TransferStateFile returns the file the transfer counts are persisted to,
+// e.g. "build/stats.transfers.json"; see ClassStateFile.
+func (n *NewsStats) TransferStateFile() string {
+	return n.siblingStateFile("transfers")
+}
+
+// transferKind classifies a su3 request by its Range header.
+func transferKind(rangeHeader string) string {
+	spec, ok := strings.CutPrefix(strings.TrimSpace(rangeHeader), "bytes=")
+	if !ok {
+		// No Range, or one ServeContent ignores: the whole file is sent.
+		return TransferComplete
+	}
+	first, _, _ := strings.Cut(spec, ",")
+	start, _, ok := strings.Cut(strings.TrimSpace(first), "-")
+	if !ok {
+		return TransferComplete
+	}
+	if start == "" {
+		// A suffix range ("bytes=-500") is the tail of the file.
+		return TransferResumed
+	}
+	if offset, err := strconv.ParseInt(start, 10, 64); err == nil && offset > 0 {
+		return TransferResumed
+	}
+	return TransferPartial
+}
+
+// downloadClient returns the client of rq for DownloadWindow: the host of
+// RemoteAddr, which over SAM is the client's destination.  Behind an
+// I2PTunnel server tunnel or a reverse proxy every request comes from
+// loopback, so a loopback peer is identified by the X-I2P-DestB32 or
+// X-I2P-DestHash header the tunnel adds, trusted from loopback only as by
+// newsserver.Allowlist.  A loopback request without either returns "": the
+// client cannot be told apart from any other.
+func downloadClient(rq *http.Request) string {
+	host := rq.RemoteAddr
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if dest := rq.Header.Get("X-I2P-DestB32"); dest != "" {
+			return strings.ToLower(dest)
+		}
+		return rq.Header.Get("X-I2P-DestHash")
+	}
+	return host
+}
+
+// firstDownloadLocked reports whether a download of file by client at now is
+// the first within DownloadWindow, and records it.  Every download of an
+// unidentified client ("") counts, rather than all of them counting as one.
+// Expired records are pruned at most once per window.  n.mu must be held for
+// writing.
+func (n *NewsStats) firstDownloadLocked(client, file string, now time.Time) bool {
+	if n.DownloadWindow <= 0 || client == "" {
+		return true
+	}
+	if n.recent == nil {
+		n.recent = make(map[string]time.Time)
+	}
+	if now.Sub(n.swept) >= n.DownloadWindow {
+		for k, t := range n.recent {
+			if now.Sub(t) >= n.DownloadWindow {
+				delete(n.recent, k)
+			}
+		}
+		n.swept = now
+	}
+	key := client + "\x00" + file
+	if t, ok := n.recent[key]; ok && now.Sub(t) < n.DownloadWindow {
+		return false
+	}
+	n.recent[key] = now
+	return true
+}
@@ -0,0 +1,94 @@
+package newsstats
+
+import (
+	"encoding/json"
+	"path"
+)
+
+// Audiences of the content classes; see Audience.
+const (
+	// AudienceMachine is traffic from routers and feed readers: the su3
+	// files and Atom feeds.
+	AudienceMachine = "machine"
+	// AudienceHuman is page views: directory listings, the landing page, and
+	// HTML pages.
+	AudienceHuman = "human"
+)
+
+// DirectReferer is the Referers bucket of page views without a Referer,
+// such as typed or bookmarked URLs.
+const DirectReferer = "(direct)"
+
+// Audience returns the audience of the content class class, AudienceMachine
+// or AudienceHuman, or "" for classes that are neither, such as charts.
+func Audience(class string) string {
+	switch class {
+	case ClassAtom, ClassSu3:
+		return AudienceMachine
+	case ClassListing, ClassLanding, ClassPage:
+		return AudienceHuman
+	}
+	return ""
+}
+
+// VisitStateFile returns the file the entry page and referer counts are
+// persisted to, e.g. "build/stats.visits.json"; see ClassStateFile.
+func (n *NewsStats) VisitStateFile() string {
+	return n.siblingStateFile("visits")
+}
+
+// visitState is the JSON form of VisitStateFile.
+type visitState struct {
+	Entries  map[string]int `json:"entries"`
+	Referers map[string]int `json:"referers"`
+}
+
+// IncrementVisit records a page view that entered the mirror: the human
+// page at urlPath was requested from another site, whose host is referer,
+// or with no referer at all when referer is "".  Navigation between the
+// mirror's own pages is not a visit and should not be recorded.  Like
+// Increment it is safe to call on a zero-value NewsStats.
+func (n *NewsStats) IncrementVisit(urlPath, referer string) {
+	if referer == "" {
+		referer = DirectReferer
+	}
+	n.mu.Lock()
+	if n.Entries == nil {
+		n.Entries = make(map[string]int)
+	}
+	if n.Referers == nil {
+		n.Referers = make(map[string]int)
+	}
+	n.Entries[path.Clean("/"+urlPath)]++
+	n.Referers[referer]++
+	n.mu.Unlock()
+}
+
+// audienceTotalsLocked returns the request counts of ContentClasses summed
+// per audience.  n.mu must be held.
+func (n *NewsStats) audienceTotalsLocked() map[string]int {
+	totals := map[string]int{AudienceMachine: 0, AudienceHuman: 0}
+	for class, v := range n.ContentClasses {
+		if a := Audience(class); a != "" {
+			totals[a] += v
+		}
+	}
+	return totals
+}
+
+// VisitsJSON returns the machine and human request totals, and the entry
+// pages and referring hosts of the human visits, as a JSON object.
+func (n *NewsStats) VisitsJSON() ([]byte, error) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	totals := n.audienceTotalsLocked()
+	out := struct {
+		Machine  int            `json:"machine"`
+		Human    int            `json:"human"`
+		Entries  map[string]int `json:"entries"`
+		Referers map[string]int `json:"referers"`
+	}{totals[AudienceMachine], totals[AudienceHuman], map[string]int{}, map[string]int{}}
+	addCounts(out.Entries, n.Entries)
+	addCounts(out.Referers, n.Referers)
+	return json.MarshalIndent(out, "", "  ")
+}
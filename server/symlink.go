@@ -0,0 +1,122 @@
+package newsserver
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+)
+
+// Symlink modes of a SymlinkPolicy.
+const (
+	// SymlinksInside follows a symlink only when it resolves inside the
+	// served tree or one of SymlinkPolicy.Allow.  It is the default.
+	SymlinksInside = "inside"
+	// SymlinksDeny follows no symlink below the served tree at all.
+	SymlinksDeny = "deny"
+	// SymlinksFollow follows every symlink, wherever it leads.
+	SymlinksFollow = "follow"
+)
+
+// SymlinkPolicy decides which symlinks in a served tree are followed.
+// Cleaning the request path stops ".." from leaving the tree, but a symlink
+// inside it pointing at /etc would still escape; a file whose path leads
+// through a symlink the policy refuses is answered 404 Not Found.  The root
+// of the tree may itself be a symlink, as with ResolveCurrent, and is always
+// followed.  The zero value is SymlinksInside with no further directories.
+type SymlinkPolicy struct {
+	// Mode is SymlinksInside, SymlinksDeny, or SymlinksFollow; empty means
+	// SymlinksInside.
+	Mode string
+	// Allow lists further directories, absolute and resolved, that
+	// symlinks may lead into under SymlinksInside, e.g. a shared store of
+	// su3 files linked into several trees.
+	Allow []string
+}
+
+// ParseSymlinkPolicy parses the mode and allowed directories of a
+// SymlinkPolicy.  The allowed directories must exist and are only
+// meaningful with SymlinksInside.
+func ParseSymlinkPolicy(mode string, allow []string) (SymlinkPolicy, error) {
+	switch mode {
+	case "":
+		mode = SymlinksInside
+	case SymlinksInside, SymlinksDeny, SymlinksFollow:
+	default:
+		return SymlinkPolicy{}, fmt.Errorf("ParseSymlinkPolicy: unknown mode %q; want %s, %s, or %s", mode, SymlinksInside, SymlinksDeny, SymlinksFollow)
+	}
+	if len(allow) > 0 && mode != SymlinksInside {
+		return SymlinkPolicy{}, fmt.Errorf("ParseSymlinkPolicy: allowed directories need mode %s, not %s", SymlinksInside, mode)
+	}
+	p := SymlinkPolicy{Mode: mode}
+	for _, dir := range allow {
+		resolved, err := resolvePath(dir)
+		if err != nil {
+			return SymlinkPolicy{}, fmt.Errorf("ParseSymlinkPolicy: %w", err)
+		}
+		p.Allow = append(p.Allow, resolved)
+	}
+	return p, nil
+}
+
+// resolvePath returns the absolute path of p with every symlink resolved.
+func resolvePath(p string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(resolved)
+}
+
+// permits reports whether the policy lets file, below root, be served.  A
+// file that does not exist has nothing to escape through and is permitted;
+// the request for it fails later.  A root that cannot be resolved permits
+// nothing.
+func (p SymlinkPolicy) permits(root, file string) bool {
+	if p.Mode == SymlinksFollow {
+		return true
+	}
+	resolved, err := resolvePath(file)
+	if err != nil {
+		return true
+	}
+	realRoot, err := resolvePath(root)
+	if err != nil {
+		return false
+	}
+	if p.Mode == SymlinksDeny {
+		// Without symlinks below the root, file resolves to the same path
+		// below the resolved root.
+		rel, err := filepath.Rel(root, file)
+		return err == nil && resolved == filepath.Join(realRoot, rel)
+	}
+	if containsPath(realRoot, resolved) {
+		return true
+	}
+	for _, dir := range p.Allow {
+		if containsPath(dir, resolved) {
+			return true
+		}
+	}
+	return false
+}
+
+// symlinkRefused answers 404 Not Found and reports true when n.Symlinks
+// does not let file, below root, be served.
+func (n *NewsServer) symlinkRefused(rw http.ResponseWriter, rq *http.Request, root, file string) bool {
+	if n.Symlinks.permits(root, file) {
+		return false
+	}
+	log.Printf("ServeHTTP: symlink refused by --symlinks %s: %q", n.Symlinks.mode(), rq.URL.Path)
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.WriteHeader(http.StatusNotFound)
+	return true
+}
+
+// mode returns p.Mode, with the default spelt out.
+func (p SymlinkPolicy) mode() string {
+	if p.Mode == "" {
+		return SymlinksInside
+	}
+	return p.Mode
+}
@@ -0,0 +1,432 @@
+package newsserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	newslogger "github.com/go-i2p/newsgo/logger"
+)
+
+// WebSubPath is the URL path of the built-in WebSub hub.
+const WebSubPath = "/websub"
+
+// Limits of the built-in hub.  A subscription lasts defaultLease unless the
+// subscriber asks for less, and never longer than maxLease; subscribers renew
+// by subscribing again.  maxSubscriptions bounds the memory an open hub can be
+// made to use.
+const (
+	defaultLease     = 10 * 24 * time.Hour
+	maxLease         = 30 * 24 * time.Hour
+	maxSubscriptions = 1000
+	maxSecretLen     = 199
+)
+
+// hubDebounce is how long the hub waits after the last write to a feed before
+// distributing it, so that a build rewriting a feed in several steps is
+// delivered once.  It is a variable so that tests can shorten it.
+var hubDebounce = 2 * time.Second
+
+// subscription is one verified subscriber callback for a topic.
+type subscription struct {
+	topic    string
+	callback string
+	secret   string
+	expires  time.Time
+}
+
+// Hub is a minimal WebSub hub for the Atom feeds of a NewsServer, so that
+// clearnet consumers can subscribe to the mirror directly.  It accepts
+// subscription requests at WebSubPath, verifies them with the subscriber,
+// and POSTs a feed to its subscribers when Watch sees it change.  Topics are
+// feed URLs under the server's SiteURL.  Subscriptions are kept in memory
+// only; subscribers renew them before their lease ends anyway.
+type Hub struct {
+	// Client makes the verification and delivery requests.  NewHub sets a
+	// client with a 30 second timeout.
+	Client *http.Client
+
+	n       *NewsServer
+	mu      sync.Mutex
+	subs    map[string]map[string]subscription // feed file → callback → subscription
+	digests map[string]string                  // feed file → SHA-256 last delivered
+	timers  map[string]*time.Timer
+	wg      sync.WaitGroup
+}
+
+// NewHub returns a hub for the feeds served by n.  n.SiteURL must be set: it
+// is the base of the hub URL and of every topic.
+func NewHub(n *NewsServer) *Hub {
+	return &Hub{
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		n:       n,
+		subs:    make(map[string]map[string]subscription),
+		digests: make(map[string]string),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// URL returns the public URL of the hub.
+func (h *Hub) URL() string {
+	return strings.TrimSuffix(h.n.SiteURL, "/") + WebSubPath
+}
+
+// topicURL returns the topic URL of the feed served at urlPath.
+func (h *Hub) topicURL(urlPath string) string {
+	return strings.TrimSuffix(h.n.SiteURL, "/") + urlPath
+}
+
+// advertise adds the Link headers by which WebSub subscribers discover the
+// hub and topic of the feed file served for a request for urlPath.  The
+// topic names the file actually served, so a subscriber to a negotiated
+// translation receives that translation.
+func (h *Hub) advertise(rw http.ResponseWriter, urlPath, file string) {
+	topic := path.Join(path.Dir(urlPath), filepath.Base(file))
+	rw.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"hub\"", h.URL()))
+	rw.Header().Add("Link", fmt.Sprintf("<%s>; rel=\"self\"", h.topicURL(topic)))
+}
+
+// topicFile returns the feed file served for topic.  The topic must be an
+// existing Atom feed under the server's SiteURL.
+func (h *Hub) topicFile(topic string) (string, error) {
+	u, err := url.Parse(topic)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("hub.topic %q is not an http(s) URL", topic)
+	}
+	if hostOf(u.Host) != hostOf(h.n.SiteURL) {
+		return "", fmt.Errorf("hub.topic %q is not served by this hub", topic)
+	}
+	root, p := h.n.route(u.Path)
+	file := filepath.Join(root, p)
+	if !containsPath(filepath.Clean(root), file) || !strings.HasSuffix(file, ".atom.xml") {
+		return "", fmt.Errorf("hub.topic %q is not an Atom feed", topic)
+	}
+	if _, err := os.Stat(file); err != nil {
+		return "", fmt.Errorf("hub.topic %q does not exist", topic)
+	}
+	return file, nil
+}
+
+// ServeHTTP implements the hub endpoint.  Subscribe and unsubscribe requests
+// are answered 202 Accepted and verified asynchronously.  A publish ping, as
+// sent by `newsgo build --websub-hub`, delivers the named topics at once if
+// they changed, without waiting for Watch.
+func (h *Hub) ServeHTTP(rw http.ResponseWriter, rq *http.Request) {
+	if rq.Method != http.MethodPost {
+		rw.Header().Set("Allow", http.MethodPost)
+		http.Error(rw, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rq.Body = http.MaxBytesReader(rw, rq.Body, 64<<10)
+	if err := rq.ParseForm(); err != nil {
+		http.Error(rw, "Bad Request", http.StatusBadRequest)
+		return
+	}
+	var err error
+	switch mode := rq.PostForm.Get("hub.mode"); mode {
+	case "subscribe", "unsubscribe":
+		err = h.request(mode, rq.PostForm)
+	case "publish":
+		err = h.publish(append(rq.PostForm["hub.url"], rq.PostForm["hub.topic"]...))
+	default:
+		err = fmt.Errorf("unsupported hub.mode %q", mode)
+	}
+	if err != nil {
+		newslogger.Verbosef("websub: %s: %v", rq.RemoteAddr, err)
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rw.WriteHeader(http.StatusAccepted)
+}
+
+// request validates a subscribe or unsubscribe request and starts verifying
+// it with the subscriber.
+func (h *Hub) request(mode string, form url.Values) error {
+	topic, callback := form.Get("hub.topic"), form.Get("hub.callback")
+	file, err := h.topicFile(topic)
+	if err != nil {
+		return err
+	}
+	cb, err := url.Parse(callback)
+	if err != nil || (cb.Scheme != "http" && cb.Scheme != "https") || cb.Host == "" {
+		return fmt.Errorf("hub.callback %q is not an http(s) URL", callback)
+	}
+	lease := defaultLease
+	if s := form.Get("hub.lease_seconds"); s != "" {
+		secs, err := strconv.Atoi(s)
+		if err != nil || secs <= 0 {
+			return fmt.Errorf("hub.lease_seconds %q is not a positive integer", s)
+		}
+		lease = min(time.Duration(secs)*time.Second, maxLease)
+	}
+	secret := form.Get("hub.secret")
+	if len(secret) > maxSecretLen {
+		return fmt.Errorf("hub.secret is longer than %d bytes", maxSecretLen)
+	}
+	if mode == "subscribe" && h.full(file, callback) {
+		return fmt.Errorf("hub has reached %d subscriptions", maxSubscriptions)
+	}
+	sub := subscription{topic: topic, callback: callback, secret: secret}
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		h.verify(mode, file, sub, lease)
+	}()
+	return nil
+}
+
+// full reports whether a new subscription of callback to file would exceed
+// maxSubscriptions.  Renewing an existing subscription is always possible.
+func (h *Hub) full(file, callback string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[file][callback]; ok {
+		return false
+	}
+	total := 0
+	for _, subs := range h.subs {
+		total += len(subs)
+	}
+	return total >= maxSubscriptions
+}
+
+// verify confirms the intent of a subscribe or unsubscribe request by
+// sending the subscriber a challenge it must echo, and applies the request
+// when it does.
+func (h *Hub) verify(mode, file string, sub subscription, lease time.Duration) {
+	var b [16]byte
+	rand.Read(b[:]) //nolint:errcheck — crypto/rand.Read never returns an error
+	challenge := hex.EncodeToString(b[:])
+	cb, _ := url.Parse(sub.callback)
+	q := cb.Query()
+	q.Set("hub.mode", mode)
+	q.Set("hub.topic", sub.topic)
+	q.Set("hub.challenge", challenge)
+	if mode == "subscribe" {
+		q.Set("hub.lease_seconds", strconv.Itoa(int(lease/time.Second)))
+	}
+	cb.RawQuery = q.Encode()
+	resp, err := h.Client.Get(cb.String())
+	if err != nil {
+		newslogger.Verbosef("websub: verify %s of %s: %v", mode, sub.callback, err)
+		return
+	}
+	defer resp.Body.Close()
+	echo, _ := io.ReadAll(io.LimitReader(resp.Body, int64(len(challenge))+1))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || string(echo) != challenge {
+		newslogger.Verbosef("websub: %s of %s not confirmed: %s", mode, sub.callback, resp.Status)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if mode == "unsubscribe" {
+		delete(h.subs[file], sub.callback)
+		newslogger.Verbosef("websub: %s unsubscribed from %s", sub.callback, sub.topic)
+		return
+	}
+	sub.expires = time.Now().Add(lease)
+	if h.subs[file] == nil {
+		h.subs[file] = make(map[string]subscription)
+	}
+	h.subs[file][sub.callback] = sub
+	// Deliveries start from the content the subscriber could fetch now.
+	if _, ok := h.digests[file]; !ok {
+		if sum, err := fileChecksum(file); err == nil {
+			h.digests[file] = sum
+		}
+	}
+	newslogger.Verbosef("websub: %s subscribed to %s until %s", sub.callback, sub.topic, sub.expires.Format(time.RFC3339))
+}
+
+// publish delivers the feeds of topics to their subscribers if they changed.
+func (h *Hub) publish(topics []string) error {
+	if len(topics) == 0 {
+		return fmt.Errorf("publish without hub.url")
+	}
+	for _, topic := range topics {
+		file, err := h.topicFile(topic)
+		if err != nil {
+			return err
+		}
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			h.distribute(file)
+		}()
+	}
+	return nil
+}
+
+// Watch watches the directories served by the hub's server, including their
+// subdirectories, and delivers every Atom feed written there to its
+// subscribers once writes to it have settled for hubDebounce.  It returns
+// when ctx is done.
+func (h *Hub) Watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("Watch: %w", err)
+	}
+	defer w.Close()
+	roots := []string{h.n.NewsDir}
+	for _, r := range h.n.Routes {
+		roots = append(roots, r.Dir)
+	}
+	for _, root := range roots {
+		if err := watchTree(w, root); err != nil {
+			return fmt.Errorf("Watch: %w", err)
+		}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					if err := watchTree(w, ev.Name); err != nil {
+						log.Printf("Watch: %v", err)
+					}
+					continue
+				}
+			}
+			if strings.HasSuffix(ev.Name, ".atom.xml") && ev.Has(fsnotify.Create|fsnotify.Write) {
+				h.schedule(ev.Name)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			log.Printf("Watch: %v", err)
+		}
+	}
+}
+
+// watchTree adds root and every directory below it to w.
+func watchTree(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.Add(p)
+		}
+		return nil
+	})
+}
+
+// schedule delivers file hubDebounce after the last call for it.
+func (h *Hub) schedule(file string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if t, ok := h.timers[file]; ok {
+		t.Reset(hubDebounce)
+		return
+	}
+	h.timers[file] = time.AfterFunc(hubDebounce, func() {
+		h.mu.Lock()
+		delete(h.timers, file)
+		h.mu.Unlock()
+		h.distribute(file)
+	})
+}
+
+// distribute POSTs file to the current subscribers of its topic unless its
+// content is the same as at the last delivery.  Expired subscriptions are
+// dropped, as are subscribers answering 410 Gone.
+func (h *Hub) distribute(file string) {
+	sum, err := fileChecksum(file)
+	if err != nil {
+		newslogger.Verbosef("websub: %v", err)
+		return
+	}
+	now := time.Now()
+	h.mu.Lock()
+	var subs []subscription
+	for cb, sub := range h.subs[file] {
+		if now.After(sub.expires) {
+			delete(h.subs[file], cb)
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	if len(subs) == 0 || h.digests[file] == sum {
+		h.mu.Unlock()
+		return
+	}
+	h.digests[file] = sum
+	h.mu.Unlock()
+
+	body, err := os.ReadFile(file)
+	if err != nil {
+		log.Printf("websub: %v", err)
+		return
+	}
+	for _, sub := range subs {
+		if err := h.deliver(sub, body); err != nil {
+			newslogger.Printf("websub: deliver %s to %s: %v", sub.topic, sub.callback, err)
+		}
+	}
+	newslogger.Verbosef("websub: delivered %s to %d subscriber(s)", file, len(subs))
+}
+
+// deliver POSTs body, the content of sub's topic, to the subscriber.  With a
+// secret the body is signed in the X-Hub-Signature header.
+func (h *Hub) deliver(sub subscription, body []byte) error {
+	rq, err := http.NewRequest(http.MethodPost, sub.callback, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	rq.Header.Set("Content-Type", "application/atom+xml")
+	rq.Header.Add("Link", fmt.Sprintf("<%s>; rel=\"hub\"", h.URL()))
+	rq.Header.Add("Link", fmt.Sprintf("<%s>; rel=\"self\"", sub.topic))
+	if sub.secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.secret))
+		mac.Write(body)
+		rq.Header.Set("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := h.Client.Do(rq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10)) //nolint:errcheck
+	if resp.StatusCode == http.StatusGone {
+		h.mu.Lock()
+		for file := range h.subs {
+			if s, ok := h.subs[file][sub.callback]; ok && s.topic == sub.topic {
+				delete(h.subs[file], sub.callback)
+			}
+		}
+		h.mu.Unlock()
+		return fmt.Errorf("subscriber is gone; subscription removed")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// wait blocks until pending verifications and publish deliveries finish.
+func (h *Hub) wait() {
+	h.wg.Wait()
+}
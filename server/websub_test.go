@@ -0,0 +1,204 @@
+package newsserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testSubscriber is a WebSub subscriber that confirms every verification
+// and records the deliveries it receives.
+type testSubscriber struct {
+	mu         sync.Mutex
+	bodies     []string
+	signatures []string
+}
+
+func (ts *testSubscriber) ServeHTTP(rw http.ResponseWriter, rq *http.Request) {
+	if rq.Method == http.MethodGet {
+		io.WriteString(rw, rq.URL.Query().Get("hub.challenge"))
+		return
+	}
+	body, _ := io.ReadAll(rq.Body)
+	ts.mu.Lock()
+	ts.bodies = append(ts.bodies, string(body))
+	ts.signatures = append(ts.signatures, rq.Header.Get("X-Hub-Signature"))
+	ts.mu.Unlock()
+}
+
+func (ts *testSubscriber) deliveries() []string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	return append([]string(nil), ts.bodies...)
+}
+
+// writeFeed writes body to file with a distinct mtime, so that the checksum
+// cache sees every rewrite.
+func writeFeed(t *testing.T, file, body string, mtime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(file, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(file, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// hubServer returns a NewsServer with a hub serving a news.atom.xml with
+// content "v1" from a temporary directory.
+func hubServer(t *testing.T) (*NewsServer, string) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "news.atom.xml")
+	writeFeed(t, file, "v1", time.Now().Add(-time.Hour))
+	s := &NewsServer{NewsDir: dir, Stats: statsForTest(dir), SiteURL: "https://news.example.org"}
+	s.Hub = NewHub(s)
+	return s, file
+}
+
+// hubRequest POSTs form to the hub of s and returns the status code.
+func hubRequest(s *NewsServer, form url.Values) int {
+	rq := httptest.NewRequest(http.MethodPost, WebSubPath, strings.NewReader(form.Encode()))
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, rq)
+	return rw.Code
+}
+
+// TestHub_SubscribeAndDistribute verifies a subscription round trip: the
+// verified subscriber receives changed content, signed with its secret,
+// exactly once, and no longer after unsubscribing.
+func TestHub_SubscribeAndDistribute(t *testing.T) {
+	s, file := hubServer(t)
+	sub := &testSubscriber{}
+	srv := httptest.NewServer(sub)
+	defer srv.Close()
+	s.Hub.Client = srv.Client()
+
+	const topic = "https://news.example.org/news.atom.xml"
+	form := url.Values{
+		"hub.mode":     {"subscribe"},
+		"hub.topic":    {topic},
+		"hub.callback": {srv.URL + "/cb?id=1"},
+		"hub.secret":   {"s3cret"},
+	}
+	if code := hubRequest(s, form); code != http.StatusAccepted {
+		t.Fatalf("subscribe: code %d, want 202", code)
+	}
+	s.Hub.wait()
+
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/news.atom.xml", nil))
+	links := strings.Join(rw.Header().Values("Link"), ", ")
+	for _, want := range []string{`<https://news.example.org/websub>; rel="hub"`, `<` + topic + `>; rel="self"`} {
+		if !strings.Contains(links, want) {
+			t.Errorf("Link headers %q lack %s", links, want)
+		}
+	}
+
+	// Unchanged content is not delivered.
+	s.Hub.distribute(file)
+	if got := sub.deliveries(); len(got) != 0 {
+		t.Fatalf("unchanged feed delivered: %q", got)
+	}
+
+	writeFeed(t, file, "v2", time.Now())
+	if code := hubRequest(s, url.Values{"hub.mode": {"publish"}, "hub.url": {topic}}); code != http.StatusAccepted {
+		t.Fatalf("publish: code %d, want 202", code)
+	}
+	s.Hub.wait()
+	s.Hub.distribute(file)
+	if got := sub.deliveries(); len(got) != 1 || got[0] != "v2" {
+		t.Fatalf("deliveries %q, want [v2]", got)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte("v2"))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); sub.signatures[0] != want {
+		t.Errorf("X-Hub-Signature %q, want %q", sub.signatures[0], want)
+	}
+
+	form.Set("hub.mode", "unsubscribe")
+	if code := hubRequest(s, form); code != http.StatusAccepted {
+		t.Fatalf("unsubscribe: code %d, want 202", code)
+	}
+	s.Hub.wait()
+	writeFeed(t, file, "v3", time.Now().Add(time.Minute))
+	s.Hub.distribute(file)
+	if got := sub.deliveries(); len(got) != 1 {
+		t.Errorf("delivered after unsubscribe: %q", got)
+	}
+}
+
+// TestHub_Watch verifies that a feed rewritten on disk is delivered without
+// a publish ping.
+func TestHub_Watch(t *testing.T) {
+	prev := hubDebounce
+	hubDebounce = 10 * time.Millisecond
+	defer func() { hubDebounce = prev }()
+
+	s, file := hubServer(t)
+	sub := &testSubscriber{}
+	srv := httptest.NewServer(sub)
+	defer srv.Close()
+	s.Hub.Client = srv.Client()
+	hubRequest(s, url.Values{
+		"hub.mode":     {"subscribe"},
+		"hub.topic":    {"https://news.example.org/news.atom.xml"},
+		"hub.callback": {srv.URL},
+	})
+	s.Hub.wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Hub.Watch(ctx) }()
+	defer func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Watch: %v", err)
+		}
+	}()
+	// Give the watcher time to register the directory.
+	time.Sleep(100 * time.Millisecond)
+	writeFeed(t, file, "rebuilt", time.Now())
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if got := sub.deliveries(); len(got) == 1 && got[0] == "rebuilt" {
+			return
+		}
+	}
+	t.Errorf("deliveries %q, want [rebuilt]", sub.deliveries())
+}
+
+// TestHub_RejectsBadRequests verifies that requests the hub cannot serve are
+// refused up front.
+func TestHub_RejectsBadRequests(t *testing.T) {
+	s, _ := hubServer(t)
+	for name, form := range map[string]url.Values{
+		"other host":     {"hub.mode": {"subscribe"}, "hub.topic": {"https://evil.example/news.atom.xml"}, "hub.callback": {"https://sub.example/"}},
+		"not a feed":     {"hub.mode": {"subscribe"}, "hub.topic": {"https://news.example.org/stats.json"}, "hub.callback": {"https://sub.example/"}},
+		"missing feed":   {"hub.mode": {"subscribe"}, "hub.topic": {"https://news.example.org/news_de.atom.xml"}, "hub.callback": {"https://sub.example/"}},
+		"bad callback":   {"hub.mode": {"subscribe"}, "hub.topic": {"https://news.example.org/news.atom.xml"}, "hub.callback": {"file:///etc/passwd"}},
+		"bad lease":      {"hub.mode": {"subscribe"}, "hub.topic": {"https://news.example.org/news.atom.xml"}, "hub.callback": {"https://sub.example/"}, "hub.lease_seconds": {"-1"}},
+		"unknown mode":   {"hub.mode": {"list"}},
+		"empty publish":  {"hub.mode": {"publish"}},
+		"escape the dir": {"hub.mode": {"publish"}, "hub.url": {"https://news.example.org/../news.atom.xml"}},
+	} {
+		if code := hubRequest(s, form); code != http.StatusBadRequest {
+			t.Errorf("%s: code %d, want 400", name, code)
+		}
+	}
+	rw := httptest.NewRecorder()
+	s.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, WebSubPath, nil))
+	if rw.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET %s: code %d, want 405", WebSubPath, rw.Code)
+	}
+}
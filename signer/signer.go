@@ -3,12 +3,16 @@
 package newssigner
 
 import (
+	"bytes"
+	"compress/gzip"
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
+	"crypto/x509"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"i2pgit.org/go-i2p/reseed-tools/su3"
@@ -18,9 +22,49 @@ import (
 // SigningKey must be a crypto.Signer — typically *rsa.PrivateKey, *ecdsa.PrivateKey,
 // or ed25519.PrivateKey. The su3 SignatureType is auto-detected from the key type
 // so callers do not need to set it manually.
+//
+// A NewsSigner holds no mutable state: CreateSu3 and Su3Current only read its
+// fields, so one NewsSigner, and the key it holds, may sign many feeds from
+// concurrent goroutines as long as its fields are not changed meanwhile and
+// each call writes a different su3 file.  All supported keys are safe to
+// share this way.  Batch signers should set Certificate: without it every
+// call self-certifies SigningKey, which costs one more signature per feed.
 type NewsSigner struct {
 	SignerID   string
 	SigningKey crypto.Signer
+	// Certificate is the published certificate for SigningKey.  When set,
+	// CreateSu3 verifies its output against it; when nil a self-signed
+	// certificate for SigningKey is used instead.
+	Certificate *x509.Certificate
+	// Compress gzips the Atom XML before packaging it and marks the su3 as
+	// gzipped XML, which routers unpack transparently.  News feeds shrink
+	// to about a third of their size.
+	Compress bool
+}
+
+// su3FileTypeXMLGZ is the su3 file type of gzipped XML content.
+const su3FileTypeXMLGZ = 3
+
+// payload returns the su3 file type and content CreateSu3 packages for the
+// Atom XML data: data itself, or gzipped when ns.Compress is set.  The gzip
+// stream carries no name or time, so the same data always compresses to the
+// same bytes and Su3Current can compare them.
+func (ns *NewsSigner) payload(data []byte) (uint8, []byte, error) {
+	if !ns.Compress {
+		return su3.FileTypeXML, data, nil
+	}
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return 0, nil, fmt.Errorf("newssigner: compress: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return 0, nil, fmt.Errorf("newssigner: compress: %w", err)
+	}
+	return su3FileTypeXMLGZ, buf.Bytes(), nil
 }
 
 // sigTypeForKey returns the su3 SignatureType constant that matches the
@@ -49,9 +93,47 @@ func sigTypeForKey(key crypto.Signer) (uint16, error) {
 	}
 }
 
+// su3Path returns the su3 file CreateSu3 writes for the Atom XML file at
+// xmldata.
+func su3Path(xmldata string) string {
+	return strings.TrimSuffix(xmldata, ".atom.xml") + ".su3"
+}
+
+// Su3Current reports whether the su3 file for xmldata already embeds exactly
+// the current content of xmldata, compressed as ns.Compress asks, signed by
+// ns.SignerID with a signature that verifies under ns's certificate.
+// Re-signing such a file would change only its bytes, not what routers
+// extract from it, so callers can skip it and spare routers a needless
+// download.  Any error reading or checking either file reports false.
+func (ns *NewsSigner) Su3Current(xmldata string) bool {
+	if !strings.HasSuffix(xmldata, ".atom.xml") {
+		return false
+	}
+	data, err := os.ReadFile(xmldata)
+	if err != nil {
+		return false
+	}
+	_, content, err := ns.payload(data)
+	if err != nil {
+		return false
+	}
+	cert, err := ns.verifyCertificate()
+	if err != nil {
+		return false
+	}
+	return verifySu3File(su3Path(xmldata), cert, ns.SignerID, content) == nil
+}
+
 // CreateSu3 reads the Atom XML file at xmldata, wraps it in an su3 container
 // signed with ns.SigningKey, and writes the result to a file with the same
-// base name but the ".atom.xml" suffix replaced by ".su3".
+// base name but the ".atom.xml" suffix replaced by ".su3".  With ns.Compress
+// the XML is gzipped first; see payload.
+//
+// The su3 is written to a temporary file next to it, which CreateSu3 re-opens
+// to verify its signature and embedded content (see verifySu3File) before
+// renaming it over the su3.  If writing or that check fails the temporary
+// file is removed and an existing su3 is left untouched, so a failed re-sign
+// never takes down the su3 already published, nor leaves a corrupt one.
 //
 // CreateSu3 returns an error if xmldata does not end with ".atom.xml".  This
 // guard prevents a dangerous silent overwrite: strings.Replace would return the
@@ -62,7 +144,6 @@ func (ns *NewsSigner) CreateSu3(xmldata string) error {
 		return fmt.Errorf("newssigner: CreateSu3: input path %q does not have .atom.xml suffix; refusing to derive output path to avoid overwriting source", xmldata)
 	}
 	su3File := su3.New()
-	su3File.FileType = su3.FileTypeXML
 	su3File.ContentType = su3.ContentTypeNews
 
 	sigType, err := sigTypeForKey(ns.SigningKey)
@@ -75,7 +156,10 @@ func (ns *NewsSigner) CreateSu3(xmldata string) error {
 	if err != nil {
 		return err
 	}
-	su3File.Content = data
+	su3File.FileType, su3File.Content, err = ns.payload(data)
+	if err != nil {
+		return err
+	}
 
 	su3File.SignerID = []byte(ns.SignerID)
 	if err := su3File.Sign(ns.SigningKey); err != nil {
@@ -86,6 +170,28 @@ func (ns *NewsSigner) CreateSu3(xmldata string) error {
 	if err != nil {
 		return err
 	}
-	outfile := strings.TrimSuffix(xmldata, ".atom.xml") + ".su3"
-	return os.WriteFile(outfile, b, 0o644)
+	cert, err := ns.verifyCertificate()
+	if err != nil {
+		return err
+	}
+	outfile := su3Path(xmldata)
+	tmp, err := os.CreateTemp(filepath.Dir(outfile), "."+filepath.Base(outfile)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o644)
+	}
+	if err != nil {
+		return fmt.Errorf("newssigner: write %s: %w", outfile, err)
+	}
+	if err := verifySu3File(tmp.Name(), cert, ns.SignerID, su3File.Content); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), outfile)
 }
@@ -1,18 +1,29 @@
 package newssigner
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+
+	"i2pgit.org/go-i2p/reseed-tools/su3"
 )
 
 // generateTestKey produces a 2048-bit RSA key for use in signer tests.
 // 2048 bits is chosen to keep test runtime reasonable while remaining
 // large enough to exercise the full signing pipeline.
-func generateTestKey(t *testing.T) *rsa.PrivateKey {
+func generateTestKey(t testing.TB) *rsa.PrivateKey {
 	t.Helper()
 	key, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
@@ -158,3 +169,285 @@ func TestCreateSu3_OutputPathDerivation(t *testing.T) {
 		t.Errorf("expected su3 output at %s: %v", su3Path, err)
 	}
 }
+
+// TestCreateSu3_VerifiesAgainstCertificate verifies that signing succeeds
+// when Certificate matches SigningKey.
+func TestCreateSu3_VerifiesAgainstCertificate(t *testing.T) {
+	dir := t.TempDir()
+	key := generateTestKey(t)
+	xmlPath := filepath.Join(dir, "news.atom.xml")
+	if err := os.WriteFile(xmlPath, []byte("<feed/>"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	cert, err := selfCertificate("test@example.i2p", key)
+	if err != nil {
+		t.Fatalf("selfCertificate: %v", err)
+	}
+	ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: key, Certificate: cert}
+	if err := ns.CreateSu3(xmlPath); err != nil {
+		t.Fatalf("CreateSu3: %v", err)
+	}
+}
+
+// TestCreateSu3_WrongCertificate_RemovesOutput verifies that a Certificate for
+// a different key fails the round-trip check and leaves no su3 behind.
+func TestCreateSu3_WrongCertificate_RemovesOutput(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "news.atom.xml")
+	if err := os.WriteFile(xmlPath, []byte("<feed/>"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	other, err := selfCertificate("other@example.i2p", generateTestKey(t))
+	if err != nil {
+		t.Fatalf("selfCertificate: %v", err)
+	}
+	ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: generateTestKey(t), Certificate: other}
+	if err := ns.CreateSu3(xmlPath); err == nil {
+		t.Fatal("expected verification error for mismatched certificate, got nil")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "news.su3")); !os.IsNotExist(err) {
+		t.Errorf("su3 left behind after failed verification (stat err: %v)", err)
+	}
+}
+
+// TestCreateSu3_FailedResign_KeepsOutput verifies that a re-sign failing the
+// round-trip check leaves the su3 already published untouched and no
+// temporary file behind.
+func TestCreateSu3_FailedResign_KeepsOutput(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "news.atom.xml")
+	if err := os.WriteFile(xmlPath, []byte("<feed/>"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: generateTestKey(t)}
+	if err := ns.CreateSu3(xmlPath); err != nil {
+		t.Fatalf("CreateSu3: %v", err)
+	}
+	published, err := os.ReadFile(filepath.Join(dir, "news.su3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ns.Certificate, err = selfCertificate("other@example.i2p", generateTestKey(t)); err != nil {
+		t.Fatalf("selfCertificate: %v", err)
+	}
+	if err := ns.CreateSu3(xmlPath); err == nil {
+		t.Fatal("expected verification error for mismatched certificate, got nil")
+	}
+	if got, err := os.ReadFile(filepath.Join(dir, "news.su3")); err != nil || !bytes.Equal(got, published) {
+		t.Errorf("published su3 changed by a failed re-sign (read err: %v)", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("directory holds %d files after a failed re-sign, want the feed and its su3", len(entries))
+	}
+}
+
+// TestVerifySu3File_DetectsCorruption verifies that a flipped content byte or
+// a content mismatch is reported.
+func TestVerifySu3File_DetectsCorruption(t *testing.T) {
+	dir := t.TempDir()
+	key := generateTestKey(t)
+	xmlPath := filepath.Join(dir, "news.atom.xml")
+	su3Path := filepath.Join(dir, "news.su3")
+	content := []byte("<feed>round trip</feed>")
+	if err := os.WriteFile(xmlPath, content, 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: key}
+	if err := ns.CreateSu3(xmlPath); err != nil {
+		t.Fatalf("CreateSu3: %v", err)
+	}
+	cert, err := selfCertificate(ns.SignerID, key)
+	if err != nil {
+		t.Fatalf("selfCertificate: %v", err)
+	}
+	if err := verifySu3File(su3Path, cert, ns.SignerID, content); err != nil {
+		t.Fatalf("verifySu3File on fresh output: %v", err)
+	}
+	if err := verifySu3File(su3Path, cert, ns.SignerID, []byte("<feed>other</feed>")); err == nil {
+		t.Error("expected content mismatch error, got nil")
+	}
+
+	data, err := os.ReadFile(su3Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(string(data), "round trip")
+	if i < 0 {
+		t.Fatal("content not found in su3")
+	}
+	data[i] ^= 0xff
+	if err := os.WriteFile(su3Path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifySu3File(su3Path, cert, ns.SignerID, content); err == nil {
+		t.Error("expected signature error for corrupted su3, got nil")
+	}
+}
+
+// TestSu3Current verifies that a freshly signed feed is reported current and
+// that a content change, a different signing key, or a missing su3 is not.
+func TestSu3Current(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "news.atom.xml")
+	if err := os.WriteFile(xmlPath, []byte("<feed>one</feed>"), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: generateTestKey(t)}
+	if ns.Su3Current(xmlPath) {
+		t.Error("Su3Current = true before the feed was signed")
+	}
+	if err := ns.CreateSu3(xmlPath); err != nil {
+		t.Fatalf("CreateSu3: %v", err)
+	}
+	if !ns.Su3Current(xmlPath) {
+		t.Error("Su3Current = false for a freshly signed feed")
+	}
+	rotated := &NewsSigner{SignerID: ns.SignerID, SigningKey: generateTestKey(t)}
+	if rotated.Su3Current(xmlPath) {
+		t.Error("Su3Current = true for a su3 signed with a different key")
+	}
+	if err := os.WriteFile(xmlPath, []byte("<feed>two</feed>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if ns.Su3Current(xmlPath) {
+		t.Error("Su3Current = true after the feed content changed")
+	}
+}
+
+// TestCreateSu3_Compress verifies that with Compress the su3 holds the feed
+// gzipped, marked as gzipped XML, and that Su3Current tells a compressed su3
+// from an uncompressed one.
+func TestCreateSu3_Compress(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "news.atom.xml")
+	feed := []byte("<feed>" + strings.Repeat("<entry>news</entry>", 200) + "</feed>")
+	if err := os.WriteFile(xmlPath, feed, 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: generateTestKey(t), Compress: true}
+	if err := ns.CreateSu3(xmlPath); err != nil {
+		t.Fatalf("CreateSu3: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "news.su3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := su3.New()
+	if err := f.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if f.FileType != su3FileTypeXMLGZ || len(f.Content) >= len(feed) {
+		t.Fatalf("file type %d with %d content bytes; want gzipped XML smaller than %d bytes", f.FileType, len(f.Content), len(feed))
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(f.Content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(zr)
+	if err != nil || !bytes.Equal(got, feed) {
+		t.Errorf("decompressed content = %d bytes, %v; want the feed", len(got), err)
+	}
+	if !ns.Su3Current(xmlPath) {
+		t.Error("Su3Current = false for a freshly signed compressed feed")
+	}
+	plain := &NewsSigner{SignerID: ns.SignerID, SigningKey: ns.SigningKey}
+	if plain.Su3Current(xmlPath) {
+		t.Error("Su3Current = true without Compress for a compressed su3")
+	}
+}
+
+// writeFeeds writes n distinct Atom feeds to dir and returns their paths.
+func writeFeeds(t testing.TB, dir string, n int) []string {
+	t.Helper()
+	feeds := make([]string, n)
+	for i := range feeds {
+		feeds[i] = filepath.Join(dir, fmt.Sprintf("news_%03d.atom.xml", i))
+		if err := os.WriteFile(feeds[i], []byte(fmt.Sprintf("<feed><id>%d</id></feed>", i)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return feeds
+}
+
+// signConcurrently signs every feed with ns from its own goroutine and
+// returns the errors.
+func signConcurrently(ns *NewsSigner, feeds []string) []error {
+	errs := make([]error, len(feeds))
+	var wg sync.WaitGroup
+	for i, feed := range feeds {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = ns.CreateSu3(feed)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
+
+// TestCreateSu3_Concurrent verifies that one NewsSigner, and its key, can
+// sign many feeds from concurrent goroutines: every su3 is written, verifies,
+// and embeds its own feed.  Run with -race to check for shared state.
+func TestCreateSu3_Concurrent(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, key := range map[string]crypto.Signer{"rsa": generateTestKey(t), "ecdsa": ecKey, "ed25519": edKey} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			feeds := writeFeeds(t, dir, 20)
+			cert, err := selfCertificate("test@example.i2p", key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ns := &NewsSigner{SignerID: "test@example.i2p", SigningKey: key, Certificate: cert}
+			for i, err := range signConcurrently(ns, feeds) {
+				if err != nil {
+					t.Errorf("CreateSu3(%s): %v", feeds[i], err)
+				}
+			}
+			for _, feed := range feeds {
+				data, err := os.ReadFile(feed)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := verifySu3File(su3Path(feed), cert, ns.SignerID, data); err != nil {
+					t.Errorf("%v", err)
+				}
+				if !ns.Su3Current(feed) {
+					t.Errorf("Su3Current(%s) = false after signing", feed)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkCreateSu3_Concurrent100 signs 100 feeds concurrently with one
+// NewsSigner and a 2048-bit RSA key.
+func BenchmarkCreateSu3_Concurrent100(b *testing.B) {
+	key := generateTestKey(b)
+	cert, err := selfCertificate("bench@example.i2p", key)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ns := &NewsSigner{SignerID: "bench@example.i2p", SigningKey: key, Certificate: cert}
+	feeds := writeFeeds(b, b.TempDir(), 100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, err := range signConcurrently(ns, feeds) {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package newssigner
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"i2pgit.org/go-i2p/reseed-tools/su3"
+)
+
+// verifyCertificate returns the certificate CreateSu3 checks its own output
+// against: ns.Certificate when set, otherwise a throwaway self-signed
+// certificate for ns.SigningKey.  The throwaway certificate proves that the
+// file verifies under the signing key; only a real ns.Certificate also proves
+// that the key matches the certificate routers have been given.
+func (ns *NewsSigner) verifyCertificate() (*x509.Certificate, error) {
+	if ns.Certificate != nil {
+		return ns.Certificate, nil
+	}
+	return selfCertificate(ns.SignerID, ns.SigningKey)
+}
+
+// selfCertificate creates an in-memory self-signed certificate for key.
+func selfCertificate(signerID string, key crypto.Signer) (*x509.Certificate, error) {
+	now := time.Now()
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: signerID},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, fmt.Errorf("newssigner: self-certify signing key: %w", err)
+	}
+	return x509.ParseCertificate(der)
+}
+
+// verifySu3File re-reads the su3 file CreateSu3 just wrote and checks that it
+// parses, that its signature verifies under cert, and that its signer ID and
+// embedded content are exactly what was signed.  This catches corruption
+// introduced anywhere between signing and the bytes on disk — a marshalling
+// bug, a short write, or a full filesystem — before the file is published.
+func verifySu3File(path string, cert *x509.Certificate, signerID string, content []byte) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("newssigner: verify %s: %w", path, err)
+	}
+	f := su3.New()
+	if err := f.UnmarshalBinary(data); err != nil {
+		return fmt.Errorf("newssigner: verify %s: unmarshal: %w", path, err)
+	}
+	if err := f.VerifySignature(cert); err != nil {
+		return fmt.Errorf("newssigner: verify %s: signature: %w", path, err)
+	}
+	if string(f.SignerID) != signerID {
+		return fmt.Errorf("newssigner: verify %s: signer ID is %q, want %q", path, f.SignerID, signerID)
+	}
+	if !bytes.Equal(f.Content, content) {
+		return fmt.Errorf("newssigner: verify %s: embedded content (%d bytes) differs from source (%d bytes)", path, len(f.Content), len(content))
+	}
+	return nil
+}